@@ -0,0 +1,491 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package admission
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"right-sizer/logger"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Certificate modes of the webhook server
+const (
+	CertModeSelfSigned  = "selfSigned"  // the operator issues and rotates its own CA and certificate
+	CertModeCertManager = "certManager" // cert-manager issues the certificate into the TLS directory
+)
+
+// Keys of the Secret keeping the self-signed certificates
+const (
+	secretCACert   = "ca.crt"
+	secretCAKey    = "ca.key"
+	secretCABundle = "ca-bundle.crt"
+)
+
+// CertificateSource supplies the serving certificate of the webhook server.
+// Rotated certificates are served without restarting the server.
+type CertificateSource interface {
+	// Start loads or issues the certificate and keeps it current until ctx is done
+	Start(ctx context.Context) error
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+// CertRotator issues the webhook's serving certificate from a self-signed CA
+// kept in a Secret, renews both before they expire, and keeps the caBundle of
+// the webhook configurations in sync
+type CertRotator struct {
+	Clientset             kubernetes.Interface
+	Namespace             string   // Namespace of the Service and the Secret
+	ServiceName           string   // Service fronting the webhook server
+	SecretName            string   // Secret keeping the CA and the certificate
+	WebhookConfigurations []string // Validating and mutating webhook configurations to patch
+	CAValidity            time.Duration
+	CertValidity          time.Duration
+	RenewBefore           time.Duration // Renew once less than this is left
+	CheckInterval         time.Duration
+	// Conversion, when set, keeps the CRD conversion webhook trusted too
+	Conversion *ConversionWebhook
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// defaults fills the unset durations
+func (r *CertRotator) defaults() {
+	if r.CAValidity <= 0 {
+		r.CAValidity = 5 * 365 * 24 * time.Hour
+	}
+	if r.CertValidity <= 0 {
+		r.CertValidity = 365 * 24 * time.Hour
+	}
+	if r.RenewBefore <= 0 {
+		r.RenewBefore = 30 * 24 * time.Hour
+	}
+	if r.CheckInterval <= 0 {
+		r.CheckInterval = time.Hour
+	}
+}
+
+// Start issues or loads the certificate, then checks it every CheckInterval
+func (r *CertRotator) Start(ctx context.Context) error {
+	r.defaults()
+	if err := r.Ensure(ctx); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(r.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.Ensure(ctx); err != nil {
+					logger.Error("Failed to rotate webhook certificate: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// GetCertificate returns the current serving certificate
+func (r *CertRotator) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil {
+		return nil, errors.New("webhook certificate not issued yet")
+	}
+	return r.cert, nil
+}
+
+// Ensure loads the certificates from the Secret, reissues the ones that are
+// missing or due for renewal, and patches the CA bundle of the webhook
+// configurations
+func (r *CertRotator) Ensure(ctx context.Context) error {
+	r.defaults()
+	secrets := r.Clientset.CoreV1().Secrets(r.Namespace)
+	secret, err := secrets.Get(ctx, r.SecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: r.SecretName, Namespace: r.Namespace},
+			Type:       corev1.SecretTypeTLS,
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get secret %s/%s: %w", r.Namespace, r.SecretName, err)
+	}
+
+	data, changed, err := r.rotate(secret.Data, time.Now())
+	if err != nil {
+		return err
+	}
+	if changed {
+		secret.Data = data
+		if secret.ResourceVersion == "" {
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		} else {
+			_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		}
+		if apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err) {
+			// Another replica rotated first: serve its certificates instead
+			return r.Ensure(ctx)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store webhook certificate: %w", err)
+		}
+		logger.Info("🔐 Issued webhook certificate for %s.%s.svc, stored in secret %s", r.ServiceName, r.Namespace, r.SecretName)
+	}
+
+	cert, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return fmt.Errorf("failed to load webhook certificate: %w", err)
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+
+	err = r.patchCABundle(ctx, data[secretCABundle])
+	if r.Conversion != nil {
+		err = errors.Join(err, r.Conversion.Patch(ctx, data[secretCABundle]))
+	}
+	return err
+}
+
+// rotate returns the Secret data with the CA and certificate reissued when
+// missing, invalid or due for renewal, and whether anything was reissued.
+// A replaced CA stays in the bundle until it expires, so clients trusting it
+// keep working while the new bundle propagates.
+func (r *CertRotator) rotate(data map[string][]byte, now time.Time) (map[string][]byte, bool, error) {
+	out := make(map[string][]byte, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+
+	ca, caKey, err := parseKeyPair(out[secretCACert], out[secretCAKey])
+	caRenewed := false
+	if err != nil || now.Add(r.RenewBefore).After(ca.NotAfter) {
+		previous := ca
+		ca, caKey, err = newCA(r.ServiceName+"-webhook-ca", now, r.CAValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		out[secretCACert] = encodeCertificate(ca)
+		if out[secretCAKey], err = encodeKey(caKey); err != nil {
+			return nil, false, err
+		}
+		out[secretCABundle] = out[secretCACert]
+		if previous != nil && now.Before(previous.NotAfter) {
+			out[secretCABundle] = append(slices.Clone(out[secretCACert]), encodeCertificate(previous)...)
+		}
+		caRenewed = true
+	}
+
+	cert, _, err := parseKeyPair(out[corev1.TLSCertKey], out[corev1.TLSPrivateKeyKey])
+	dnsNames := r.dnsNames()
+	if caRenewed || err != nil || now.Add(r.RenewBefore).After(cert.NotAfter) ||
+		cert.CheckSignatureFrom(ca) != nil || !slices.Equal(cert.DNSNames, dnsNames) {
+		certPEM, keyPEM, err := newServingCert(ca, caKey, dnsNames, now, r.CertValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		out[corev1.TLSCertKey] = certPEM
+		out[corev1.TLSPrivateKeyKey] = keyPEM
+		return out, true, nil
+	}
+	return out, caRenewed, nil
+}
+
+// dnsNames are the names the API server may use to reach the Service
+func (r *CertRotator) dnsNames() []string {
+	return []string{
+		r.ServiceName,
+		r.ServiceName + "." + r.Namespace,
+		r.ServiceName + "." + r.Namespace + ".svc",
+		r.ServiceName + "." + r.Namespace + ".svc.cluster.local",
+	}
+}
+
+// patchCABundle sets the CA bundle of every webhook of the configurations
+// that calls the Service. Configurations that do not exist are skipped.
+func (r *CertRotator) patchCABundle(ctx context.Context, bundle []byte) error {
+	webhooks := r.Clientset.AdmissionregistrationV1()
+	var errs []error
+	for _, name := range r.WebhookConfigurations {
+		validating, err := webhooks.ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			changed := false
+			for i := range validating.Webhooks {
+				changed = r.setCABundle(&validating.Webhooks[i].ClientConfig.CABundle, validating.Webhooks[i].ClientConfig.Service, bundle) || changed
+			}
+			if changed {
+				if _, err := webhooks.ValidatingWebhookConfigurations().Update(ctx, validating, metav1.UpdateOptions{}); err != nil {
+					errs = append(errs, fmt.Errorf("failed to patch CA bundle of validating webhook configuration %s: %w", name, err))
+				}
+			}
+		} else if !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+
+		mutating, err := webhooks.MutatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			changed := false
+			for i := range mutating.Webhooks {
+				changed = r.setCABundle(&mutating.Webhooks[i].ClientConfig.CABundle, mutating.Webhooks[i].ClientConfig.Service, bundle) || changed
+			}
+			if changed {
+				if _, err := webhooks.MutatingWebhookConfigurations().Update(ctx, mutating, metav1.UpdateOptions{}); err != nil {
+					errs = append(errs, fmt.Errorf("failed to patch CA bundle of mutating webhook configuration %s: %w", name, err))
+				}
+			}
+		} else if !apierrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setCABundle sets the bundle of a webhook calling the operator's Service and
+// reports whether it changed
+func (r *CertRotator) setCABundle(caBundle *[]byte, service *admissionregistrationv1.ServiceReference, bundle []byte) bool {
+	if service == nil || service.Name != r.ServiceName || service.Namespace != r.Namespace || bytes.Equal(*caBundle, bundle) {
+		return false
+	}
+	*caBundle = bundle
+	return true
+}
+
+// CertFileLoader serves a certificate from files, such as a Secret issued by
+// cert-manager, and reloads it when the files change
+type CertFileLoader struct {
+	CertPath     string
+	KeyPath      string
+	CAPath       string // CA of the certificate, trusted by the conversion webhook
+	PollInterval time.Duration
+	// Name is what the certificate serves, for logs and errors; "webhook" when empty
+	Name string
+	// Conversion, when set, keeps the CRD conversion webhook trusting CAPath
+	Conversion *ConversionWebhook
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// Start loads the certificate, then reloads it every PollInterval when the
+// files were modified
+func (l *CertFileLoader) Start(ctx context.Context) error {
+	if l.PollInterval <= 0 {
+		l.PollInterval = time.Minute
+	}
+	if _, err := l.reload(); err != nil {
+		return err
+	}
+	l.patchConversion(ctx)
+	go func() {
+		ticker := time.NewTicker(l.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reloaded, err := l.reload()
+				if err != nil {
+					logger.Error("Failed to reload %s certificate: %v", l.name(), err)
+				} else if reloaded {
+					l.patchConversion(ctx)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// GetCertificate returns the certificate last loaded
+func (l *CertFileLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.cert == nil {
+		return nil, fmt.Errorf("%s certificate not loaded yet", l.name())
+	}
+	return l.cert, nil
+}
+
+// name returns what the certificate serves
+func (l *CertFileLoader) name() string {
+	if l.Name == "" {
+		return "webhook"
+	}
+	return l.Name
+}
+
+// patchConversion points the conversion webhook at the CA in CAPath
+func (l *CertFileLoader) patchConversion(ctx context.Context) {
+	if l.Conversion == nil || l.CAPath == "" {
+		return
+	}
+	caBundle, err := os.ReadFile(l.CAPath)
+	if err == nil {
+		err = l.Conversion.Patch(ctx, caBundle)
+	}
+	if err != nil {
+		logger.Error("Failed to set conversion webhook CA bundle: %v", err)
+	}
+}
+
+// reload loads the key pair when either file is newer than the loaded one
+// and reports whether it did
+func (l *CertFileLoader) reload() (bool, error) {
+	var modTime time.Time
+	for _, path := range []string{l.CertPath, l.KeyPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s certificate: %w", l.name(), err)
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+
+	l.mu.RLock()
+	current := l.cert != nil && !modTime.After(l.modTime)
+	l.mu.RUnlock()
+	if current {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.CertPath, l.KeyPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to load %s certificate: %w", l.name(), err)
+	}
+	l.mu.Lock()
+	l.cert = &cert
+	l.modTime = modTime
+	l.mu.Unlock()
+	logger.Info("🔐 Loaded %s certificate from %s", l.name(), l.CertPath)
+	return true, nil
+}
+
+// newCA creates a self-signed CA certificate
+func newCA(commonName string, now time.Time, validity time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          newSerialNumber(),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	return ca, key, err
+}
+
+// newServingCert issues a serving certificate for the DNS names, signed by the CA
+func newServingCert(ca *x509.Certificate, caKey *ecdsa.PrivateKey, dnsNames []string, now time.Time, validity time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate certificate key: %w", err)
+	}
+	notAfter := now.Add(validity)
+	if notAfter.After(ca.NotAfter) {
+		notAfter = ca.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: newSerialNumber(),
+		Subject:      pkix.Name{CommonName: dnsNames[len(dnsNames)-2]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create serving certificate: %w", err)
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+// parseKeyPair parses a PEM certificate and its ECDSA key
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, errors.New("missing certificate or key")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+func encodeCertificate(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func newSerialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
@@ -0,0 +1,159 @@
+package admission
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestCertRotator() *CertRotator {
+	webhook := admissionregistrationv1.ValidatingWebhook{
+		Name: "validate.rightsizer.io",
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service: &admissionregistrationv1.ServiceReference{Name: "right-sizer", Namespace: "right-sizer"},
+		},
+	}
+	other := admissionregistrationv1.ValidatingWebhook{
+		Name: "other.example.com",
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service: &admissionregistrationv1.ServiceReference{Name: "other", Namespace: "default"},
+		},
+	}
+	clientset := k8sfake.NewSimpleClientset(&admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "right-sizer"},
+		Webhooks:   []admissionregistrationv1.ValidatingWebhook{webhook, other},
+	})
+	return &CertRotator{
+		Clientset:             clientset,
+		Namespace:             "right-sizer",
+		ServiceName:           "right-sizer",
+		SecretName:            "right-sizer-webhook-tls",
+		WebhookConfigurations: []string{"right-sizer"},
+	}
+}
+
+// verifyServingCert checks the serving certificate against the CA bundle
+func verifyServingCert(t *testing.T, certPEM, bundle []byte, now time.Time) {
+	t.Helper()
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(bundle))
+	block, _ := pem.Decode(certPEM)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:     "right-sizer.right-sizer.svc",
+		Roots:       roots,
+		CurrentTime: now,
+	})
+	assert.NoError(t, err)
+}
+
+func TestCertRotator_Ensure(t *testing.T) {
+	ctx := context.Background()
+	r := newTestCertRotator()
+	require.NoError(t, r.Ensure(ctx))
+
+	secret, err := r.Clientset.CoreV1().Secrets("right-sizer").Get(ctx, "right-sizer-webhook-tls", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	verifyServingCert(t, secret.Data[corev1.TLSCertKey], secret.Data[secretCABundle], time.Now())
+
+	served, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	assert.Equal(t, block.Bytes, served.Certificate[0])
+
+	config, err := r.Clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, "right-sizer", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, secret.Data[secretCABundle], config.Webhooks[0].ClientConfig.CABundle)
+	assert.Empty(t, config.Webhooks[1].ClientConfig.CABundle, "webhooks of other services are left alone")
+
+	// A current certificate is kept
+	require.NoError(t, r.Ensure(ctx))
+	again, err := r.Clientset.CoreV1().Secrets("right-sizer").Get(ctx, "right-sizer-webhook-tls", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, secret.Data, again.Data)
+}
+
+func TestCertRotator_Rotate(t *testing.T) {
+	r := newTestCertRotator()
+	r.defaults()
+	now := time.Now()
+
+	data, changed, err := r.rotate(nil, now)
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// The serving certificate is renewed before it expires, under the same CA
+	renewAt := now.Add(r.CertValidity - r.RenewBefore + time.Hour)
+	renewed, changed, err := r.rotate(data, renewAt)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, data[secretCACert], renewed[secretCACert])
+	assert.NotEqual(t, data[corev1.TLSCertKey], renewed[corev1.TLSCertKey])
+	verifyServingCert(t, renewed[corev1.TLSCertKey], renewed[secretCABundle], renewAt)
+
+	// A renewed CA is bundled with the one it replaces until that expires
+	caRenewAt := now.Add(r.CAValidity - r.RenewBefore + time.Hour)
+	rotated, changed, err := r.rotate(renewed, caRenewAt)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.NotEqual(t, renewed[secretCACert], rotated[secretCACert])
+	assert.Equal(t, append(append([]byte{}, rotated[secretCACert]...), renewed[secretCACert]...), rotated[secretCABundle])
+	verifyServingCert(t, rotated[corev1.TLSCertKey], rotated[secretCABundle], caRenewAt)
+
+	// A certificate for another Service is reissued
+	r.ServiceName = "renamed"
+	_, changed, err = r.rotate(rotated, caRenewAt)
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+func TestCertFileLoader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	r := newTestCertRotator()
+	r.defaults()
+	write := func(data map[string][]byte, modTime time.Time) {
+		require.NoError(t, os.WriteFile(certPath, data[corev1.TLSCertKey], 0o600))
+		require.NoError(t, os.WriteFile(keyPath, data[corev1.TLSPrivateKeyKey], 0o600))
+		require.NoError(t, os.Chtimes(certPath, modTime, modTime))
+		require.NoError(t, os.Chtimes(keyPath, modTime, modTime))
+	}
+
+	first, _, err := r.rotate(nil, time.Now())
+	require.NoError(t, err)
+	write(first, time.Now().Add(-time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l := &CertFileLoader{CertPath: certPath, KeyPath: keyPath, PollInterval: time.Hour}
+	require.NoError(t, l.Start(ctx))
+	served, err := l.GetCertificate(nil)
+	require.NoError(t, err)
+
+	// Reissued files are picked up on the next poll
+	second, _, err := r.rotate(map[string][]byte{secretCACert: first[secretCACert], secretCAKey: first[secretCAKey]}, time.Now())
+	require.NoError(t, err)
+	write(second, time.Now())
+	reloaded, err := l.reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	current, err := l.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, served.Certificate[0], current.Certificate[0])
+
+	assert.Error(t, (&CertFileLoader{CertPath: filepath.Join(dir, "missing.crt"), KeyPath: keyPath}).Start(ctx))
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package admission
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/api/v1beta1"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConvertPath is where the webhook server converts custom resources between
+// API versions
+const ConvertPath = "/convert"
+
+// ConvertedCRDs are the CustomResourceDefinitions served in more than one version
+var ConvertedCRDs = []string{
+	"rightsizerconfigs." + v1alpha1.GroupVersion.Group,
+	"rightsizerpolicies." + v1alpha1.GroupVersion.Group,
+}
+
+// newConversionScheme registers every served version of the converted kinds
+func newConversionScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add v1alpha1 to scheme: %w", err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add v1beta1 to scheme: %w", err)
+	}
+	return scheme, nil
+}
+
+// ConversionWebhook points the conversion of CustomResourceDefinitions at the
+// webhook server, so the API server converts between versions through it
+type ConversionWebhook struct {
+	Client      apiextensionsclientset.Interface
+	Namespace   string // Namespace of the Service
+	ServiceName string // Service fronting the webhook server
+	Port        int32
+	CRDs        []string
+}
+
+// Patch sets the conversion of each CRD to the webhook trusted through
+// caBundle. CRDs that are not installed are skipped.
+func (c *ConversionWebhook) Patch(ctx context.Context, caBundle []byte) error {
+	crds := c.Client.ApiextensionsV1().CustomResourceDefinitions()
+	var errs []error
+	for _, name := range c.CRDs {
+		crd, err := crds.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if c.current(crd.Spec.Conversion, caBundle) {
+			continue
+		}
+		path, port := ConvertPath, c.Port
+		crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+			Strategy: apiextensionsv1.WebhookConverter,
+			Webhook: &apiextensionsv1.WebhookConversion{
+				ClientConfig: &apiextensionsv1.WebhookClientConfig{
+					Service: &apiextensionsv1.ServiceReference{
+						Namespace: c.Namespace,
+						Name:      c.ServiceName,
+						Path:      &path,
+						Port:      &port,
+					},
+					CABundle: caBundle,
+				},
+				ConversionReviewVersions: []string{"v1"},
+			},
+		}
+		if _, err := crds.Update(ctx, crd, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set conversion webhook of CRD %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// current reports whether the conversion already calls this webhook with caBundle
+func (c *ConversionWebhook) current(conversion *apiextensionsv1.CustomResourceConversion, caBundle []byte) bool {
+	if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter ||
+		conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil {
+		return false
+	}
+	config := conversion.Webhook.ClientConfig
+	service := config.Service
+	return service != nil &&
+		service.Namespace == c.Namespace &&
+		service.Name == c.ServiceName &&
+		service.Path != nil && *service.Path == ConvertPath &&
+		service.Port != nil && *service.Port == c.Port &&
+		bytes.Equal(config.CABundle, caBundle)
+}
@@ -0,0 +1,92 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsfake "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+func TestConvertHandler(t *testing.T) {
+	scheme, err := newConversionScheme()
+	require.NoError(t, err)
+	handler := conversion.NewWebhookHandler(scheme)
+
+	object := []byte(`{"apiVersion":"rightsizer.io/v1alpha1","kind":"RightSizerPolicy",` +
+		`"metadata":{"name":"web","namespace":"shop"},` +
+		`"spec":{"mode":"aggressive","targetRef":{"kind":"Deployment"},"resourceStrategy":{"percentile":99}}}`)
+	review := apiextensionsv1.ConversionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apiextensions.k8s.io/v1", Kind: "ConversionReview"},
+		Request: &apiextensionsv1.ConversionRequest{
+			UID:               types.UID("1"),
+			DesiredAPIVersion: "rightsizer.io/v1beta1",
+			Objects:           []runtime.RawExtension{{Raw: object}},
+		},
+	}
+	body, err := json.Marshal(review)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, ConvertPath, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response apiextensionsv1.ConversionReview
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	require.NotNil(t, response.Response)
+	assert.Equal(t, metav1.StatusSuccess, response.Response.Result.Status, response.Response.Result.Message)
+	require.Len(t, response.Response.ConvertedObjects, 1)
+
+	var converted map[string]any
+	require.NoError(t, json.Unmarshal(response.Response.ConvertedObjects[0].Raw, &converted))
+	assert.Equal(t, "rightsizer.io/v1beta1", converted["apiVersion"])
+	spec := converted["spec"].(map[string]any)
+	assert.Equal(t, map[string]any{"kind": "Deployment"}, spec["target"])
+	assert.Equal(t, float64(99), spec["resources"].(map[string]any)["percentile"])
+	assert.NotContains(t, spec, "targetRef")
+}
+
+func TestConversionWebhook_Patch(t *testing.T) {
+	ctx := context.Background()
+	client := apiextensionsfake.NewSimpleClientset(&apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: ConvertedCRDs[0]},
+	})
+	c := &ConversionWebhook{
+		Client:      client,
+		Namespace:   "right-sizer",
+		ServiceName: "right-sizer",
+		Port:        8443,
+		CRDs:        ConvertedCRDs,
+	}
+
+	// CRDs that are not installed are skipped
+	require.NoError(t, c.Patch(ctx, []byte("ca")))
+	crd, err := client.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, ConvertedCRDs[0], metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, crd.Spec.Conversion)
+	assert.Equal(t, apiextensionsv1.WebhookConverter, crd.Spec.Conversion.Strategy)
+	config := crd.Spec.Conversion.Webhook.ClientConfig
+	assert.Equal(t, []byte("ca"), config.CABundle)
+	assert.Equal(t, "right-sizer", config.Service.Name)
+	assert.Equal(t, ConvertPath, *config.Service.Path)
+	assert.Equal(t, int32(8443), *config.Service.Port)
+	assert.True(t, c.current(crd.Spec.Conversion, []byte("ca")))
+
+	// A new CA is written through
+	require.NoError(t, c.Patch(ctx, []byte("rotated")))
+	crd, err = client.ApiextensionsV1().CustomResourceDefinitions().Get(ctx, ConvertedCRDs[0], metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("rotated"), crd.Spec.Conversion.Webhook.ClientConfig.CABundle)
+}
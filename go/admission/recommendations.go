@@ -0,0 +1,148 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package admission
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/logger"
+	"right-sizer/workload"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recommendationAnnotation records which recommendation sized a new pod
+const recommendationAnnotation = "rightsizer.io/recommendation"
+
+// recommendedResources are the resources taken from a recommendation; others,
+// such as ephemeral storage, are left as the pod template sets them
+var recommendedResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// findRecommendation returns the stored recommendation for the workload that
+// owns a new pod. Pods without a controlling owner have no stable name to
+// look a recommendation up by, so they are not matched.
+func (ws *WebhookServer) findRecommendation(ctx context.Context, pod *corev1.Pod) (*v1alpha1.RightSizerRecommendation, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil, nil
+	}
+
+	target := workload.Resolve(ctx, ws.client, pod)
+	kind, name := target.Kind, target.Name
+
+	// Named as the RecommendationWriter names them: <kind>-<name>
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: strings.ToLower(kind) + "-" + name}
+	var rec v1alpha1.RightSizerRecommendation
+	if err := ws.client.Get(ctx, key, &rec); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	if rec.Spec.TargetRef.Kind != kind || rec.Spec.TargetRef.Name != name {
+		return nil, nil
+	}
+	return &rec, nil
+}
+
+// generateRecommendationPatches sets the resources of a new pod's containers
+// from the stored recommendation of its workload, clamped to the namespace's
+// LimitRanges and quotas so the pod is not rejected at admission. The pod is
+// updated to match, so later patches see the recommended resources.
+func (ws *WebhookServer) generateRecommendationPatches(ctx context.Context, pod *corev1.Pod) []JSONPatch {
+	rec, err := ws.findRecommendation(ctx, pod)
+	if err != nil {
+		logger.Warn("Failed to look up recommendation for new pod in %s: %v", pod.Namespace, err)
+		return nil
+	}
+	if rec == nil || len(rec.Status.ContainerRecommendations) == 0 {
+		return nil
+	}
+
+	recommended := make(map[string]corev1.ResourceRequirements, len(rec.Status.ContainerRecommendations))
+	for _, containerRec := range rec.Status.ContainerRecommendations {
+		recommended[containerRec.ContainerName] = containerRec.Recommended
+	}
+
+	// Recommendations may also cover native sidecars and init containers
+	var patches []JSONPatch
+	for _, list := range []struct {
+		field      string
+		containers []corev1.Container
+	}{
+		{"containers", pod.Spec.Containers},
+		{"initContainers", pod.Spec.InitContainers},
+	} {
+		for i := range list.containers {
+			container := &list.containers[i]
+			target, ok := recommended[container.Name]
+			if !ok {
+				continue
+			}
+
+			resources := *container.Resources.DeepCopy()
+			for _, name := range recommendedResources {
+				if value, ok := target.Requests[name]; ok {
+					if resources.Requests == nil {
+						resources.Requests = corev1.ResourceList{}
+					}
+					resources.Requests[name] = value.DeepCopy()
+				}
+				if value, ok := target.Limits[name]; ok {
+					if resources.Limits == nil {
+						resources.Limits = corev1.ResourceList{}
+					}
+					resources.Limits[name] = value.DeepCopy()
+				}
+			}
+
+			if ws.validator != nil {
+				var notes []string
+				resources, notes = ws.validator.ClampToNamespaceConstraints(ctx, pod, container.Name, resources)
+				for _, note := range notes {
+					logger.Debug("Recommendation for container %s clamped: %s", container.Name, note)
+				}
+			}
+			if ws.areResourcesEqual(container.Resources, resources) {
+				continue
+			}
+
+			patches = append(patches, JSONPatch{
+				Op:    "add",
+				Path:  fmt.Sprintf("/spec/%s/%d/resources", list.field, i),
+				Value: resources,
+			})
+			container.Resources = resources
+		}
+	}
+
+	if len(patches) > 0 {
+		if pod.Annotations == nil {
+			patches = append(patches, JSONPatch{Op: "add", Path: "/metadata/annotations", Value: map[string]string{}})
+			pod.Annotations = map[string]string{}
+		}
+		patches = append(patches, JSONPatch{
+			Op:    "add",
+			Path:  "/metadata/annotations/" + strings.ReplaceAll(recommendationAnnotation, "/", "~1"),
+			Value: rec.Name,
+		})
+		pod.Annotations[recommendationAnnotation] = rec.Name
+	}
+	return patches
+}
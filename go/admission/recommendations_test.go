@@ -0,0 +1,193 @@
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/validation"
+)
+
+// newRecommendationServer returns a webhook server that knows a recommendation for the web Deployment
+func newRecommendationServer(t *testing.T, objects ...runtime.Object) *WebhookServer {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, appsv1.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	controller := true
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller},
+			},
+		},
+	}
+	rec := &v1alpha1.RightSizerRecommendation{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment-web", Namespace: "default"},
+		Spec: v1alpha1.RightSizerRecommendationSpec{
+			TargetRef: v1alpha1.RecommendationTargetRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+		},
+		Status: v1alpha1.RightSizerRecommendationStatus{
+			ContainerRecommendations: []v1alpha1.ContainerRecommendation{{
+				ContainerName: "app",
+				Recommended: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("250m"),
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				},
+			}},
+		},
+	}
+
+	client := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(append(objects, rs, rec)...).Build()
+	clientset := k8sfake.NewSimpleClientset()
+	cfg := config.GetDefaults()
+	cfg.MutatingWebhook = true
+	validator := validation.NewResourceValidator(client, clientset, cfg, nil)
+
+	server, err := NewWebhookServer(client, clientset, validator, cfg, nil, WebhookConfig{EnableMutation: true})
+	require.NoError(t, err)
+	return server
+}
+
+func newReplicaPod() *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "web-abc-",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Controller: &controller},
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:              resource.MustParse("1"),
+						corev1.ResourceMemory:           resource.MustParse("1Gi"),
+						corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func createReview(t *testing.T, pod *corev1.Pod) *admissionv1.AdmissionReview {
+	t.Helper()
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	return &admissionv1.AdmissionReview{
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Kind:      metav1.GroupVersionKind{Kind: "Pod"},
+			Namespace: "default",
+			Object:    runtime.RawExtension{Raw: raw},
+			Operation: admissionv1.Create,
+		},
+	}
+}
+
+func TestWebhookServer_InjectsRecommendation(t *testing.T) {
+	server := newRecommendationServer(t)
+
+	result := server.mutatePodResources(context.Background(), createReview(t, newReplicaPod()))
+	require.True(t, result.Response.Allowed)
+
+	var patches []map[string]interface{}
+	require.NoError(t, json.Unmarshal(result.Response.Patch, &patches))
+	require.NotEmpty(t, patches)
+
+	resources := patches[0]["value"].(map[string]interface{})
+	requests := resources["requests"].(map[string]interface{})
+	assert.Equal(t, "/spec/containers/0/resources", patches[0]["path"])
+	assert.Equal(t, "250m", requests["cpu"])
+	assert.Equal(t, "256Mi", requests["memory"])
+	assert.Equal(t, "1Gi", requests["ephemeral-storage"], "resources without a recommendation are kept")
+
+	var annotated bool
+	for _, patch := range patches {
+		if patch["path"] == "/metadata/annotations/rightsizer.io~1recommendation" {
+			annotated = patch["value"] == "deployment-web"
+		}
+	}
+	assert.True(t, annotated, "expected the pod annotated with its recommendation")
+}
+
+func TestWebhookServer_RecommendationClampedToLimitRange(t *testing.T) {
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "bounds", Namespace: "default"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			Min:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		}}},
+	}
+	server := newRecommendationServer(t, limitRange)
+
+	pod := newReplicaPod()
+	pod.Namespace = "default"
+	patches := server.generateRecommendationPatches(context.Background(), pod)
+	require.NotEmpty(t, patches)
+	assert.Equal(t, "500m", pod.Spec.Containers[0].Resources.Requests.Cpu().String())
+}
+
+func TestWebhookServer_InjectsInitContainerRecommendation(t *testing.T) {
+	server := newRecommendationServer(t)
+
+	pod := newReplicaPod()
+	pod.Namespace = "default"
+	pod.Spec.InitContainers = pod.Spec.Containers
+	pod.Spec.Containers = []corev1.Container{{Name: "main"}}
+	patches := server.generateRecommendationPatches(context.Background(), pod)
+	require.NotEmpty(t, patches)
+	assert.Equal(t, "/spec/initContainers/0/resources", patches[0].Path)
+	assert.Equal(t, "250m", pod.Spec.InitContainers[0].Resources.Requests.Cpu().String())
+}
+
+func TestWebhookServer_NoRecommendationForUnownedPod(t *testing.T) {
+	server := newRecommendationServer(t)
+
+	pod := newReplicaPod()
+	pod.Namespace = "default"
+	pod.OwnerReferences = nil
+	assert.Empty(t, server.generateRecommendationPatches(context.Background(), pod))
+}
+
+func TestWebhookServer_MutationDisabledInConfig(t *testing.T) {
+	server := newRecommendationServer(t)
+	server.config.MutatingWebhook = false
+
+	body, err := json.Marshal(createReview(t, newReplicaPod()))
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.server.Handler.ServeHTTP(w, req)
+
+	var review admissionv1.AdmissionReview
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+	assert.True(t, review.Response.Allowed)
+	assert.Empty(t, review.Response.Patch)
+}
@@ -18,6 +18,7 @@ package admission
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -38,6 +39,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
 )
 
 const (
@@ -67,6 +69,11 @@ type WebhookConfig struct {
 	EnableMutation    bool
 	DryRun            bool
 	RequireAnnotation bool
+	// EnableConversion serves CRD version conversion at ConvertPath
+	EnableConversion bool
+	// Certificates serves rotated certificates without a restart; when set
+	// CertPath and KeyPath are ignored
+	Certificates CertificateSource
 }
 
 // NewWebhookServer creates a new admission webhook server
@@ -113,6 +120,15 @@ func NewWebhookServer(
 		logger.Info("Registered mutation webhook at /mutate")
 	}
 
+	if webhookConfig.EnableConversion {
+		conversionScheme, err := newConversionScheme()
+		if err != nil {
+			return nil, err
+		}
+		mux.Handle(ConvertPath, conversion.NewWebhookHandler(conversionScheme))
+		logger.Info("Registered conversion webhook at %s", ConvertPath)
+	}
+
 	// Health check endpoint
 	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -136,6 +152,17 @@ func (ws *WebhookServer) Start(certPath, keyPath string) error {
 	return ws.server.ListenAndServe()
 }
 
+// StartWithCertificates starts the webhook server, serving the current
+// certificate of the source on every handshake
+func (ws *WebhookServer) StartWithCertificates(certificates CertificateSource) error {
+	logger.Info("Starting admission webhook server on %s", ws.server.Addr)
+	ws.server.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certificates.GetCertificate,
+	}
+	return ws.server.ListenAndServeTLS("", "")
+}
+
 // Stop stops the webhook server
 func (ws *WebhookServer) Stop(ctx context.Context) error {
 	logger.Info("Stopping admission webhook server")
@@ -188,7 +215,13 @@ func (ws *WebhookServer) handleMutate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := ws.mutatePodResources(&review)
+	// The endpoint stays registered so mutation can be switched on from the RightSizerConfig
+	if !ws.config.MutatingWebhook {
+		ws.sendResponse(w, &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true})
+		return
+	}
+
+	response := ws.mutatePodResources(r.Context(), &review)
 	ws.sendResponse(w, response.Response)
 }
 
@@ -294,7 +327,7 @@ func (ws *WebhookServer) validatePodResourceChange(ctx context.Context, review *
 }
 
 // mutatePodResources applies automatic resource adjustments
-func (ws *WebhookServer) mutatePodResources(review *admissionv1.AdmissionReview) admissionv1.AdmissionReview {
+func (ws *WebhookServer) mutatePodResources(ctx context.Context, review *admissionv1.AdmissionReview) admissionv1.AdmissionReview {
 	req := review.Request
 	response := &admissionv1.AdmissionResponse{
 		UID:     req.UID,
@@ -331,8 +364,17 @@ func (ws *WebhookServer) mutatePodResources(review *admissionv1.AdmissionReview)
 		logger.Debug("Pod %s/%s has Guaranteed QoS, will maintain during mutation", pod.Namespace, pod.Name)
 	}
 
+	// New pods start from their workload's stored recommendation
+	var patches []JSONPatch
+	if req.Operation == admissionv1.Create {
+		if pod.Namespace == "" {
+			pod.Namespace = req.Namespace
+		}
+		patches = ws.generateRecommendationPatches(ctx, &pod)
+	}
+
 	// Apply mutations
-	patches := ws.generateResourcePatches(&pod)
+	patches = append(patches, ws.generateResourcePatches(&pod)...)
 	if len(patches) > 0 {
 		patchBytes, err := json.Marshal(patches)
 		if err != nil {
@@ -674,13 +716,27 @@ func NewWebhookManager(
 	}
 }
 
+// SetCertificates sets where the webhook server takes its certificate from
+func (wm *WebhookManager) SetCertificates(certificates CertificateSource) {
+	wm.config.Certificates = certificates
+}
+
 // Start starts the webhook manager
 func (wm *WebhookManager) Start(ctx context.Context) error {
 	errChan := make(chan error, 1)
 
-	go func() {
-		errChan <- wm.server.Start(wm.config.CertPath, wm.config.KeyPath)
-	}()
+	if certificates := wm.config.Certificates; certificates != nil {
+		if err := certificates.Start(ctx); err != nil {
+			return fmt.Errorf("failed to set up webhook certificate: %w", err)
+		}
+		go func() {
+			errChan <- wm.server.StartWithCertificates(certificates)
+		}()
+	} else {
+		go func() {
+			errChan <- wm.server.Start(wm.config.CertPath, wm.config.KeyPath)
+		}()
+	}
 
 	select {
 	case err := <-errChan:
@@ -750,7 +750,7 @@ func TestWebhookServer_MutatePodResources(t *testing.T) {
 		},
 	}
 
-	result := server.mutatePodResources(review)
+	result := server.mutatePodResources(context.Background(), review)
 
 	assert.NotNil(t, result.Response)
 	assert.True(t, result.Response.Allowed)
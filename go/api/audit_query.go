@@ -0,0 +1,105 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/logger"
+)
+
+// SetAuditStore sets the store /api/audit queries
+func (s *Server) SetAuditStore(store *audit.Store) {
+	s.auditStore = store
+}
+
+// handleAudit returns audit events, newest first:
+//
+//	?namespace=  only events in the namespace
+//	?workload=   only events of the workload, as Kind/name or name
+//	?type=       only events of the type, e.g. ResourceChange
+//	?operation=  only events of the operation, e.g. resize
+//	?status=     only events with the status, e.g. success
+//	?since=      only events after an RFC 3339 time, or a duration ago such as 24h or 7d
+//	?until=      only events before an RFC 3339 time, or a duration ago
+//	?limit=      at most limit events, 100 by default and 1000 at most
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.auditStore == nil {
+		http.Error(w, "Audit store not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseAuditQuery(r.URL.Query(), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	result, err := s.auditStore.Query(query)
+	if err != nil {
+		logger.Error("Failed to query audit events: %v", err)
+		http.Error(w, "Failed to query audit events", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSONResponse(w, result)
+}
+
+func parseAuditQuery(values url.Values, now time.Time) (audit.Query, error) {
+	query := audit.Query{
+		Namespace: values.Get("namespace"),
+		Workload:  values.Get("workload"),
+		EventType: values.Get("type"),
+		Operation: values.Get("operation"),
+		Status:    values.Get("status"),
+	}
+	var err error
+	if query.Since, err = parseAuditTime(values.Get("since"), now); err != nil {
+		return query, fmt.Errorf("invalid since: %w", err)
+	}
+	if query.Until, err = parseAuditTime(values.Get("until"), now); err != nil {
+		return query, fmt.Errorf("invalid until: %w", err)
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && query.Until.Before(query.Since) {
+		return query, fmt.Errorf("until is before since")
+	}
+	if query.Limit, err = positiveParam(values, "limit", 0); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
+// parseAuditTime reads an RFC 3339 time or a duration before now
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	ago, err := config.ParseHistoryWindow(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration", value)
+	}
+	return now.Add(-ago), nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/audit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleAudit(t *testing.T) {
+	store, err := audit.OpenStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Now().UTC()
+	for _, event := range []audit.AuditEvent{
+		{EventID: "1", Timestamp: now.Add(-72 * time.Hour), Namespace: "prod", Workload: "Deployment/web", Operation: "resize", Status: "success"},
+		{EventID: "2", Timestamp: now.Add(-time.Hour), Namespace: "prod", Workload: "Deployment/web", Operation: "resize", Status: "success"},
+		{EventID: "3", Timestamp: now, Namespace: "dev", Workload: "Deployment/web", Operation: "resize", Status: "failure"},
+	} {
+		require.NoError(t, store.Append(event))
+	}
+
+	s := &Server{}
+	s.SetAuditStore(store)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantIDs    []string
+	}{
+		{"all", "", http.StatusOK, []string{"3", "2", "1"}},
+		{"namespace and workload", "?namespace=prod&workload=web", http.StatusOK, []string{"2", "1"}},
+		{"relative since", "?since=2d", http.StatusOK, []string{"3", "2"}},
+		{"absolute until", "?until=" + now.Add(-30*time.Minute).Format(time.RFC3339), http.StatusOK, []string{"2", "1"}},
+		{"status", "?status=failure", http.StatusOK, []string{"3"}},
+		{"invalid since", "?since=yesterday", http.StatusBadRequest, nil},
+		{"invalid limit", "?limit=0", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleAudit(w, httptest.NewRequest(http.MethodGet, "/api/audit"+tt.query, nil))
+			require.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var result audit.QueryResult
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			var ids []string
+			for _, event := range result.Events {
+				ids = append(ids, event.EventID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, len(tt.wantIDs), result.Total)
+		})
+	}
+}
+
+func TestServer_HandleAuditWithoutStore(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleAudit(w, httptest.NewRequest(http.MethodGet, "/api/audit", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,238 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/logger"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// API server authentication modes
+const (
+	AuthModeNone       = "none"
+	AuthModeKubernetes = "kubernetes"
+	AuthModeAPIKey     = "apikey"
+)
+
+const (
+	// authCacheTTL is how long a TokenReview/SubjectAccessReview decision is reused
+	authCacheTTL = 30 * time.Second
+	// authCacheSize bounds the decision cache; it is emptied when full
+	authCacheSize = 1024
+	// authReviewTimeout bounds the reviews made for a single request
+	authReviewTimeout = 10 * time.Second
+)
+
+// unauthenticatedPaths are served without credentials so probes keep working
+var unauthenticatedPaths = map[string]bool{
+	"/health":     true,
+	"/api/health": true,
+}
+
+type authDecision struct {
+	status  int
+	user    string // username the token was authenticated as
+	expires time.Time
+}
+
+// identityKey is the request context key of the authenticated identity
+type identityKey struct{}
+
+// Authenticator protects the API server. In kubernetes mode, bearer tokens
+// are authenticated with a TokenReview and each request is authorized with a
+// SubjectAccessReview for the request path, using the lowercased HTTP method
+// as the verb, as kube-rbac-proxy does. Read access therefore needs "get" on
+// the path, and mutating endpoints need "post", "put", "patch" or "delete",
+// which the chart only grants to its admin role. In apikey mode, requests
+// carrying the static key get full access.
+type Authenticator struct {
+	mode      string
+	apiKey    string
+	clientset kubernetes.Interface
+
+	mu    sync.Mutex
+	cache map[string]authDecision
+}
+
+// NewAuthenticator creates an authenticator for the given mode
+func NewAuthenticator(clientset kubernetes.Interface, mode, apiKey string) *Authenticator {
+	if mode == "" {
+		mode = AuthModeNone
+	}
+	return &Authenticator{
+		mode:      mode,
+		apiKey:    apiKey,
+		clientset: clientset,
+		cache:     make(map[string]authDecision),
+	}
+}
+
+// Wrap returns a handler that only passes authenticated and authorized requests to next
+func (a *Authenticator) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.mode == AuthModeNone || unauthenticatedPaths[r.URL.Path] || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, status := a.authorize(r)
+		if status != http.StatusOK {
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="right-sizer"`)
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, identity)))
+	})
+}
+
+// requestIdentity returns the identity the request was authenticated as, or
+// "" when it was served without authentication
+func requestIdentity(r *http.Request) string {
+	identity, _ := r.Context().Value(identityKey{}).(string)
+	return identity
+}
+
+// authorize returns http.StatusOK and the identity of the client when the
+// request may proceed, or the status to reject it with
+func (a *Authenticator) authorize(r *http.Request) (string, int) {
+	token := bearerToken(r)
+
+	switch a.mode {
+	case AuthModeAPIKey:
+		if token == "" {
+			token = r.Header.Get("X-API-Key")
+		}
+		if token == "" || a.apiKey == "" {
+			return "", http.StatusUnauthorized
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.apiKey)) != 1 {
+			return "", http.StatusUnauthorized
+		}
+		return "apikey", http.StatusOK
+
+	case AuthModeKubernetes:
+		if token == "" {
+			return "", http.StatusUnauthorized
+		}
+		verb := strings.ToLower(r.Method)
+		if verb == "head" {
+			verb = "get"
+		}
+		sum := sha256.Sum256([]byte(token))
+		key := hex.EncodeToString(sum[:]) + "|" + verb + "|" + r.URL.Path
+		if decision, ok := a.cachedDecision(key); ok {
+			return "user:" + decision.user, decision.status
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), authReviewTimeout)
+		defer cancel()
+		user, status, err := a.review(ctx, token, verb, r.URL.Path)
+		if err != nil {
+			// Do not cache failures of the API server itself
+			logger.Warn("API request authorization failed: %v", err)
+			return "", http.StatusServiceUnavailable
+		}
+		a.storeDecision(key, user, status)
+		return "user:" + user, status
+	}
+
+	logger.Error("Unknown API auth mode %q, rejecting request", a.mode)
+	return "", http.StatusForbidden
+}
+
+// review authenticates a token with a TokenReview and authorizes the user for
+// the verb on the path with a SubjectAccessReview. It returns the username
+// along with the status.
+func (a *Authenticator) review(ctx context.Context, token, verb, path string) (string, int, error) {
+	tokenReview, err := a.clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	if !tokenReview.Status.Authenticated {
+		return "", http.StatusUnauthorized, nil
+	}
+
+	user := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar, err := a.clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	if !sar.Status.Allowed {
+		logger.Debug("API request %s %s denied for %s: %s", verb, path, user.Username, sar.Status.Reason)
+		return user.Username, http.StatusForbidden, nil
+	}
+	return user.Username, http.StatusOK, nil
+}
+
+func (a *Authenticator) cachedDecision(key string) (authDecision, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	decision, ok := a.cache[key]
+	if !ok || time.Now().After(decision.expires) {
+		return authDecision{}, false
+	}
+	return decision, true
+}
+
+func (a *Authenticator) storeDecision(key, user string, status int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.cache) >= authCacheSize {
+		a.cache = make(map[string]authDecision)
+	}
+	a.cache[key] = authDecision{status: status, user: user, expires: time.Now().Add(authCacheTTL)}
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// newReviewClientset authenticates "viewer-token" and "admin-token"; only the
+// admin may use verbs other than get
+func newReviewClientset(reviews *int) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "tokenreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		*reviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "viewer-token":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "viewer"}}
+		case "admin-token":
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "admin"}}
+		}
+		return true, review, nil
+	})
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		sar := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		sar.Status.Allowed = sar.Spec.User == "admin" || sar.Spec.NonResourceAttributes.Verb == "get"
+		return true, sar, nil
+	})
+	return clientset
+}
+
+func serveAuthenticated(auth *Authenticator, method, path, header, value string) int {
+	handler := auth.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(method, path, nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAuthenticator_Kubernetes(t *testing.T) {
+	reviews := 0
+	auth := NewAuthenticator(newReviewClientset(&reviews), AuthModeKubernetes, "")
+
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(auth, http.MethodGet, "/api/pods", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(auth, http.MethodGet, "/api/pods", "Authorization", "Bearer unknown"))
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodGet, "/api/pods", "Authorization", "Bearer viewer-token"))
+	assert.Equal(t, http.StatusForbidden, serveAuthenticated(auth, http.MethodPost, "/api/recommendations/approve", "Authorization", "Bearer viewer-token"))
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodPost, "/api/recommendations/approve", "Authorization", "Bearer admin-token"))
+
+	// Probes need no credentials
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodGet, "/health", "", ""))
+
+	// Decisions are cached
+	before := reviews
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodGet, "/api/pods", "Authorization", "Bearer viewer-token"))
+	assert.Equal(t, before, reviews)
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	auth := NewAuthenticator(fake.NewSimpleClientset(), AuthModeAPIKey, "secret-key")
+
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(auth, http.MethodGet, "/api/pods", "", ""))
+	assert.Equal(t, http.StatusUnauthorized, serveAuthenticated(auth, http.MethodGet, "/api/pods", "X-API-Key", "wrong"))
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodGet, "/api/pods", "X-API-Key", "secret-key"))
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodDelete, "/api/policies/default/web", "Authorization", "Bearer secret-key"))
+}
+
+func TestAuthenticator_None(t *testing.T) {
+	auth := NewAuthenticator(fake.NewSimpleClientset(), "", "")
+	assert.Equal(t, http.StatusOK, serveAuthenticated(auth, http.MethodPost, "/api/policies", "", ""))
+}
+
+func TestAuthenticator_Identity(t *testing.T) {
+	reviews := 0
+	auth := NewAuthenticator(newReviewClientset(&reviews), AuthModeKubernetes, "")
+	var identity string
+	handler := auth.Wrap(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		identity = requestIdentity(r)
+	}))
+
+	// The identity is the reviewed user, also when the decision is cached
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/pods", nil)
+		req.Header.Set("Authorization", "Bearer viewer-token")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, "user:viewer", identity)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"net/http"
+
+	"right-sizer/retry"
+)
+
+// SetRetryHandler sets the retry handler /api/health/circuit reports on
+func (s *Server) SetRetryHandler(handler *retry.RetryWithCircuitBreaker) {
+	s.retryHandler = handler
+}
+
+// circuitResponse lists the shared circuit breaker and those of each target
+type circuitResponse struct {
+	CircuitBreakers []retry.CircuitBreakerStatus `json:"circuitBreakers"`
+}
+
+// handleCircuitHealth reports the state of the circuit breakers guarding
+// resize operations.
+//
+//	GET /api/health/circuit
+func (s *Server) handleCircuitHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.retryHandler == nil {
+		http.Error(w, "Circuit breaker not available", http.StatusServiceUnavailable)
+		return
+	}
+	s.writeJSONResponse(w, circuitResponse{CircuitBreakers: s.retryHandler.GetCircuitBreakerStatuses()})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleCircuitHealth(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleCircuitHealth(w, httptest.NewRequest(http.MethodGet, "/api/health/circuit", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	handler := retry.NewRetryWithCircuitBreaker("resize", retry.Config{}, retry.CircuitBreakerConfig{FailureThreshold: 1, RecoveryTimeout: time.Minute}, nil)
+	_ = handler.Execute("resize", func() error { return errors.New("failure") })
+	s.SetRetryHandler(handler)
+
+	w = httptest.NewRecorder()
+	s.handleCircuitHealth(w, httptest.NewRequest(http.MethodGet, "/api/health/circuit", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var result circuitResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.CircuitBreakers, 1)
+	assert.Equal(t, "resize", result.CircuitBreakers[0].Name)
+	assert.Equal(t, "OPEN", result.CircuitBreakers[0].State)
+	assert.NotNil(t, result.CircuitBreakers[0].RetryAt)
+
+	w = httptest.NewRecorder()
+	s.handleCircuitHealth(w, httptest.NewRequest(http.MethodPost, "/api/health/circuit", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
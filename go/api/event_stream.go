@@ -0,0 +1,134 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"right-sizer/events"
+	"right-sizer/logger"
+)
+
+const (
+	// eventStreamHeartbeat keeps idle streams open through proxies
+	eventStreamHeartbeat = 15 * time.Second
+	// eventStreamBuffer is how many events a slow client may fall behind by
+	eventStreamBuffer = 64
+)
+
+// defaultStreamEventTypes are streamed when no type parameter is given
+var defaultStreamEventTypes = []events.EventType{
+	events.EventResizeApplied,
+	events.EventResizeFailed,
+	events.EventResizeRolledBack,
+}
+
+// SetEventBus sets the bus /api/events/stream reads from
+func (s *Server) SetEventBus(bus *events.EventBus) {
+	s.eventBus = bus
+}
+
+// handleEventStream streams events from the controller as Server-Sent Events:
+//
+//	?namespace=  only events of the comma-separated namespaces
+//	?type=       comma-separated event types, by default resize.applied,
+//	             resize.failed and resize.rolled_back
+//
+// Each event is sent with its type as the SSE event name and as JSON data.
+func (s *Server) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.eventBus == nil {
+		http.Error(w, "Event stream not available", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	filter := &events.EventFilter{
+		EventTypes: defaultStreamEventTypes,
+		Namespaces: splitParam(r.URL.Query().Get("namespace")),
+	}
+	if types := splitParam(r.URL.Query().Get("type")); len(types) > 0 {
+		filter.EventTypes = make([]events.EventType, 0, len(types))
+		for _, t := range types {
+			filter.EventTypes = append(filter.EventTypes, events.EventType(t))
+		}
+	}
+
+	// Streams outlive the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Debug("Failed to clear write deadline of event stream: %v", err)
+	}
+
+	eventChan := make(chan *events.Event, eventStreamBuffer)
+	subscriberID := s.eventBus.SubscribeChannel(filter, eventChan)
+	defer s.eventBus.Unsubscribe(subscriberID)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-eventChan:
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Warn("Failed to encode event %s for stream: %v", event.ID, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// splitParam splits a comma-separated query parameter, dropping empty entries
+func splitParam(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"right-sizer/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleEventStream(t *testing.T) {
+	bus := events.NewEventBus(10)
+	defer bus.Stop()
+	s := &Server{}
+	s.SetEventBus(bus)
+
+	ts := httptest.NewServer(http.HandlerFunc(s.handleEventStream))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"?namespace=default", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+
+	// Filtered out by namespace and by type
+	bus.Publish(events.NewEvent(events.EventResizeApplied, "test", "other", "web-1", events.SeverityInfo, "resized"))
+	bus.Publish(events.NewEvent(events.EventPodOOMKilled, "test", "default", "web-1", events.SeverityError, "oom killed"))
+	// Streamed
+	bus.Publish(events.NewEvent(events.EventResizeRolledBack, "test", "default", "web-1", events.SeverityWarning, "rolled back"))
+
+	var eventName, data string
+	for data == "" {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventName = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+	assert.Equal(t, string(events.EventResizeRolledBack), eventName)
+
+	var event events.Event
+	require.NoError(t, json.Unmarshal([]byte(data), &event))
+	assert.Equal(t, "default", event.Namespace)
+	assert.Equal(t, "rolled back", event.Message)
+}
+
+func TestServer_HandleEventStreamWithoutBus(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleEventStream(w, httptest.NewRequest(http.MethodGet, "/api/events/stream", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"right-sizer/explain"
+)
+
+// SetExplanationStore sets the store /api/workloads/{namespace}/{name}/explain reads
+func (s *Server) SetExplanationStore(store *explain.Store) {
+	s.explanations = store
+}
+
+// workloadExplanation is the response of the explain endpoint
+type workloadExplanation struct {
+	Namespace  string             `json:"namespace"`
+	Name       string             `json:"name"`
+	Containers []explain.Decision `json:"containers"`
+}
+
+// handleWorkloadExplain returns how the latest decision of each container of
+// a workload was reached: the usage window, predictions, thresholds and
+// policies considered, and every step that changed the resources.
+//
+//	GET /api/workloads/{namespace}/{name}/explain
+func (s *Server) handleWorkloadExplain(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/workloads/"), "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] != "explain" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.explanations == nil {
+		http.Error(w, "Decision explanations not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	namespace, name := parts[0], parts[1]
+	decisions := s.explanations.Latest(namespace, name)
+	if len(decisions) == 0 {
+		http.Error(w, "No decision recorded for workload "+namespace+"/"+name, http.StatusNotFound)
+		return
+	}
+	s.writeJSONResponse(w, workloadExplanation{Namespace: namespace, Name: name, Containers: decisions})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"right-sizer/explain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleWorkloadExplain(t *testing.T) {
+	store := explain.NewStore()
+	store.Record(explain.Decision{
+		Namespace: "prod",
+		Workload:  "Deployment/web",
+		Container: "app",
+		Window:    explain.Window{Algorithm: "percentile", Percentile: 95, Duration: "1h0m0s", Profile: "steady"},
+		Policies:  []string{"web-policy"},
+		Steps:     []explain.Step{{Stage: "usage"}, {Stage: "namespace-constraints"}},
+	})
+
+	s := &Server{}
+	s.SetExplanationStore(store)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"explain", http.MethodGet, "/api/workloads/prod/web/explain", http.StatusOK},
+		{"unknown workload", http.MethodGet, "/api/workloads/prod/api/explain", http.StatusNotFound},
+		{"unknown subresource", http.MethodGet, "/api/workloads/prod/web/history", http.StatusNotFound},
+		{"missing name", http.MethodGet, "/api/workloads/prod/explain", http.StatusNotFound},
+		{"wrong method", http.MethodPost, "/api/workloads/prod/web/explain", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleWorkloadExplain(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	s.handleWorkloadExplain(w, httptest.NewRequest(http.MethodGet, "/api/workloads/prod/web/explain", nil))
+	var result workloadExplanation
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Containers, 1)
+	decision := result.Containers[0]
+	assert.Equal(t, "app", decision.Container)
+	assert.Equal(t, 95, decision.Window.Percentile)
+	assert.Equal(t, []string{"web-policy"}, decision.Policies)
+	require.Len(t, decision.Steps, 2)
+	assert.Equal(t, "namespace-constraints", decision.Steps[1].Stage)
+}
+
+func TestServer_HandleWorkloadExplainWithoutStore(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleWorkloadExplain(w, httptest.NewRequest(http.MethodGet, "/api/workloads/prod/web/explain", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
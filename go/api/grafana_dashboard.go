@@ -0,0 +1,276 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// grafanaDashboard is the subset of Grafana's dashboard model the generated
+// dashboard uses
+type grafanaDashboard struct {
+	UID           string            `json:"uid"`
+	Title         string            `json:"title"`
+	Tags          []string          `json:"tags"`
+	Timezone      string            `json:"timezone"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Refresh       string            `json:"refresh"`
+	Time          grafanaTimeRange  `json:"time"`
+	Templating    grafanaTemplating `json:"templating"`
+	Panels        []grafanaPanel    `json:"panels"`
+}
+
+type grafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name       string             `json:"name"`
+	Label      string             `json:"label"`
+	Type       string             `json:"type"`
+	Query      string             `json:"query"`
+	Datasource *grafanaDatasource `json:"datasource,omitempty"`
+	Refresh    int                `json:"refresh,omitempty"`
+	IncludeAll bool               `json:"includeAll"`
+	AllValue   string             `json:"allValue,omitempty"`
+	Multi      bool               `json:"multi"`
+	Current    map[string]any     `json:"current"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	Datasource  grafanaDatasource  `json:"datasource"`
+	FieldConfig grafanaFieldConfig `json:"fieldConfig"`
+	Targets     []grafanaTarget    `json:"targets"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults  grafanaFieldDefaults `json:"defaults"`
+	Overrides []any                `json:"overrides"`
+}
+
+type grafanaFieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+}
+
+type grafanaTarget struct {
+	RefID        string `json:"refId"`
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat"`
+}
+
+// grafanaPanelSpec describes a generated panel; the queries use the
+// $selector placeholder for the dashboard's label matchers
+type grafanaPanelSpec struct {
+	title       string
+	description string
+	panelType   string
+	unit        string
+	queries     []grafanaTarget
+	clusterWide bool // The metrics have no namespace label
+}
+
+// promDatasource refers to the dashboard's datasource variable
+var promDatasource = grafanaDatasource{Type: "prometheus", UID: "${datasource}"}
+
+// grafanaPanels are the panels of the generated dashboard, in display order
+var grafanaPanels = []grafanaPanelSpec{
+	{
+		title: "Resize decisions", panelType: "timeseries", unit: "ops",
+		description: "Rate of resize decisions by outcome and direction",
+		queries: []grafanaTarget{{
+			Expr:         `sum by (decision, reason) (rate(rightsizer_resize_decisions_total{%s}[$__rate_interval]))`,
+			LegendFormat: "{{decision}} {{reason}}",
+		}},
+	},
+	{
+		title: "Resize latency", panelType: "timeseries", unit: "s",
+		description: "Time taken to apply in-place resizes",
+		queries: []grafanaTarget{
+			{
+				Expr:         `histogram_quantile(0.5, sum by (le) (rate(rightsizer_resize_duration_seconds_bucket{%s}[$__rate_interval])))`,
+				LegendFormat: "p50",
+			},
+			{
+				Expr:         `histogram_quantile(0.95, sum by (le) (rate(rightsizer_resize_duration_seconds_bucket{%s}[$__rate_interval])))`,
+				LegendFormat: "p95",
+			},
+		},
+	},
+	{
+		title: "Pods resized", panelType: "timeseries", unit: "short",
+		description: "Containers resized per workload and resource",
+		queries: []grafanaTarget{{
+			Expr:         `sum by (namespace, resize_type) (increase(rightsizer_pods_resized_total{%s, pod_name=~"$workload.*"}[$__range]))`,
+			LegendFormat: "{{namespace}} {{resize_type}}",
+		}},
+	},
+	{
+		title: "Resource adjustments", panelType: "timeseries", unit: "short",
+		description: "CPU and memory adjustments per workload by direction",
+		queries: []grafanaTarget{
+			{
+				Expr:         `sum by (direction) (increase(rightsizer_cpu_adjustments_total{%s, pod_name=~"$workload.*"}[$__rate_interval]))`,
+				LegendFormat: "cpu {{direction}}",
+			},
+			{
+				Expr:         `sum by (direction) (increase(rightsizer_memory_adjustments_total{%s, pod_name=~"$workload.*"}[$__rate_interval]))`,
+				LegendFormat: "memory {{direction}}",
+			},
+		},
+	},
+	{
+		title: "Suppressed resizes", panelType: "timeseries", unit: "short",
+		description: "Resizes held back, e.g. by a cooldown or an anomaly",
+		queries: []grafanaTarget{{
+			Expr:         `sum by (reason) (increase(rightsizer_resizes_suppressed_total{%s}[$__rate_interval]))`,
+			LegendFormat: "{{reason}}",
+		}},
+	},
+	{
+		title: "OOM kills", panelType: "timeseries", unit: "short",
+		description: "OOM-killed containers and the emergency action taken",
+		queries: []grafanaTarget{{
+			Expr:         `sum by (pod_name, action) (increase(rightsizer_oom_kills_total{%s, pod_name=~"$workload.*"}[$__rate_interval]))`,
+			LegendFormat: "{{pod_name}} {{action}}",
+		}},
+	},
+	{
+		title: "API errors", panelType: "timeseries", unit: "ops",
+		description: "Failed Kubernetes API calls by reason",
+		queries: []grafanaTarget{{
+			Expr:         `sum by (api_endpoint, reason) (rate(rightsizer_api_errors_total{%s}[$__rate_interval]))`,
+			LegendFormat: "{{api_endpoint}} {{reason}}",
+		}},
+	},
+	{
+		title: "Pending recommendations", panelType: "stat", unit: "short", clusterWide: true,
+		queries: []grafanaTarget{{
+			Expr:         `sum(rightsizer_recommendations_pending{%s})`,
+			LegendFormat: "pending",
+		}},
+	},
+	{
+		title: "Prediction confidence", panelType: "timeseries", unit: "percentunit",
+		description: "Confidence of the selected prediction model per container",
+		queries: []grafanaTarget{{
+			Expr:         `max by (pod_name, container_name, resource_type) (rightsizer_prediction_model_confidence{%s, pod_name=~"$workload.*"} * on (cluster, namespace, pod_name, container_name, resource_type, method) (rightsizer_prediction_model_selected == 1))`,
+			LegendFormat: "{{pod_name}}/{{container_name}} {{resource_type}}",
+		}},
+	},
+}
+
+// generateGrafanaDashboard builds a dashboard of the operator's metrics
+// filtered by the cluster, namespace and workload variables
+func generateGrafanaDashboard(title string) grafanaDashboard {
+	dashboard := grafanaDashboard{
+		UID:           "right-sizer",
+		Title:         title,
+		Tags:          []string{"right-sizer", "kubernetes"},
+		Timezone:      "browser",
+		SchemaVersion: 39,
+		Refresh:       "1m",
+		Time:          grafanaTimeRange{From: "now-6h", To: "now"},
+		Templating: grafanaTemplating{List: []grafanaVariable{
+			{
+				Name: "datasource", Label: "Data source", Type: "datasource", Query: "prometheus",
+				Current: map[string]any{},
+			},
+			grafanaQueryVariable("cluster", "Cluster", `label_values(rightsizer_pods_processed_total, cluster)`),
+			grafanaQueryVariable("namespace", "Namespace", `label_values(rightsizer_pods_resized_total{cluster=~"$cluster"}, namespace)`),
+			grafanaQueryVariable("workload", "Workload", `label_values(rightsizer_pods_resized_total{cluster=~"$cluster", namespace=~"$namespace"}, pod_name)`),
+		}},
+	}
+
+	const width, height = 12, 8
+	for i, spec := range grafanaPanels {
+		selector := `cluster=~"$cluster", namespace=~"$namespace"`
+		if spec.clusterWide {
+			selector = `cluster=~"$cluster"`
+		}
+		panel := grafanaPanel{
+			ID:          i + 1,
+			Type:        spec.panelType,
+			Title:       spec.title,
+			Description: spec.description,
+			GridPos:     grafanaGridPos{H: height, W: width, X: (i % 2) * width, Y: (i / 2) * height},
+			Datasource:  promDatasource,
+			FieldConfig: grafanaFieldConfig{Defaults: grafanaFieldDefaults{Unit: spec.unit}, Overrides: []any{}},
+		}
+		for j, query := range spec.queries {
+			panel.Targets = append(panel.Targets, grafanaTarget{
+				RefID:        string(rune('A' + j)),
+				Expr:         fmt.Sprintf(query.Expr, selector),
+				LegendFormat: query.LegendFormat,
+			})
+		}
+		dashboard.Panels = append(dashboard.Panels, panel)
+	}
+	return dashboard
+}
+
+// grafanaQueryVariable returns a multi-value variable of label values that
+// matches everything by default, including series without the label
+func grafanaQueryVariable(name, label, query string) grafanaVariable {
+	return grafanaVariable{
+		Name:       name,
+		Label:      label,
+		Type:       "query",
+		Query:      query,
+		Datasource: &promDatasource,
+		Refresh:    2,
+		IncludeAll: true,
+		AllValue:   ".*",
+		Multi:      true,
+		Current:    map[string]any{"text": "All", "value": "$__all"},
+	}
+}
+
+// handleGrafanaDashboard returns a Grafana dashboard of the operator's
+// metrics, ready to import; ?title= overrides its title
+func (s *Server) handleGrafanaDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		title = "Right-Sizer"
+	}
+	s.writeJSONResponse(w, generateGrafanaDashboard(title))
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"right-sizer/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricNameRegisterer records the names of the metrics registered with it
+type metricNameRegisterer struct {
+	names map[string]bool
+}
+
+func (m *metricNameRegisterer) Register(c prometheus.Collector) error {
+	descs := make(chan *prometheus.Desc, 16)
+	go func() {
+		c.Describe(descs)
+		close(descs)
+	}()
+	name := regexp.MustCompile(`fqName: "([^"]+)"`)
+	for desc := range descs {
+		if match := name.FindStringSubmatch(desc.String()); match != nil {
+			m.names[match[1]] = true
+		}
+	}
+	return nil
+}
+
+func (m *metricNameRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		_ = m.Register(c)
+	}
+}
+
+func (m *metricNameRegisterer) Unregister(prometheus.Collector) bool { return false }
+
+func TestServer_HandleGrafanaDashboard(t *testing.T) {
+	s := &Server{}
+
+	w := httptest.NewRecorder()
+	s.handleGrafanaDashboard(w, httptest.NewRequest(http.MethodPost, "/api/dashboards/grafana", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	s.handleGrafanaDashboard(w, httptest.NewRequest(http.MethodGet, "/api/dashboards/grafana?title=Prod", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var dashboard grafanaDashboard
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dashboard))
+	assert.Equal(t, "Prod", dashboard.Title)
+
+	var variables []string
+	for _, variable := range dashboard.Templating.List {
+		variables = append(variables, variable.Name)
+	}
+	assert.Equal(t, []string{"datasource", "cluster", "namespace", "workload"}, variables)
+	require.NotEmpty(t, dashboard.Panels)
+}
+
+func TestGrafanaDashboardUsesOperatorMetrics(t *testing.T) {
+	registered := &metricNameRegisterer{names: map[string]bool{}}
+	_, err := metrics.NewOperatorMetricsWithRegisterer(registered)
+	require.NoError(t, err)
+
+	metricName := regexp.MustCompile(`rightsizer_[a-z_]+`)
+	for _, panel := range generateGrafanaDashboard("Right-Sizer").Panels {
+		require.NotEmpty(t, panel.Targets, panel.Title)
+		for _, target := range panel.Targets {
+			assert.Contains(t, target.Expr, `cluster=~"$cluster"`, panel.Title)
+			for _, name := range metricName.FindAllString(target.Expr, -1) {
+				name = strings.TrimSuffix(name, "_bucket")
+				assert.True(t, registered.names[name], "panel %q queries unknown metric %s", panel.Title, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	pb "right-sizer/api/grpc/v1"
+	"right-sizer/events"
+	"right-sizer/logger"
+)
+
+// ContainerResize is the outcome of analyzing a container: the resources it
+// has and those it would be sized to
+type ContainerResize struct {
+	Container   string
+	Current     corev1.ResourceRequirements
+	Recommended corev1.ResourceRequirements
+	Reason      string
+}
+
+// Analyzer sizes a pod on demand without applying the result
+type Analyzer interface {
+	AnalyzePod(ctx context.Context, namespace, name string) ([]ContainerResize, error)
+}
+
+// AnalyzerFunc adapts a function to the Analyzer interface
+type AnalyzerFunc func(ctx context.Context, namespace, name string) ([]ContainerResize, error)
+
+// AnalyzePod calls f
+func (f AnalyzerFunc) AnalyzePod(ctx context.Context, namespace, name string) ([]ContainerResize, error) {
+	return f(ctx, namespace, name)
+}
+
+// RecommendationServer implements the RecommendationService, mirroring the
+// REST recommendation endpoints
+type RecommendationServer struct {
+	pb.UnimplementedRecommendationServiceServer
+
+	manager  *events.RecommendationManager
+	eventBus *events.EventBus
+	analyzer Analyzer
+}
+
+// NewRecommendationServer creates the recommendation service; without an
+// analyzer AnalyzePod is unavailable
+func NewRecommendationServer(manager *events.RecommendationManager, eventBus *events.EventBus, analyzer Analyzer) *RecommendationServer {
+	return &RecommendationServer{manager: manager, eventBus: eventBus, analyzer: analyzer}
+}
+
+// ListRecommendations returns the recommendations matching the request's filters
+func (s *RecommendationServer) ListRecommendations(ctx context.Context, req *pb.ListRecommendationsRequest) (*pb.ListRecommendationsResponse, error) {
+	if s.manager == nil {
+		return nil, status.Error(codes.Unavailable, "recommendation manager not available")
+	}
+
+	resp := &pb.ListRecommendationsResponse{}
+	for _, rec := range s.manager.GetRecommendations() {
+		if req.Status != pb.RecommendationStatus_RECOMMENDATION_STATUS_UNSPECIFIED && convertRecommendationStatusToProto(rec.Status) != req.Status {
+			continue
+		}
+		if req.Urgency != pb.Urgency_URGENCY_UNSPECIFIED && convertUrgencyToProto(rec.Urgency) != req.Urgency {
+			continue
+		}
+		if req.Namespace != "" && rec.Namespace != req.Namespace {
+			continue
+		}
+		resp.Recommendations = append(resp.Recommendations, convertRecommendationToProto(rec))
+	}
+	resp.Total = int32(len(resp.Recommendations))
+	return resp, nil
+}
+
+// GetRecommendation returns a recommendation by ID
+func (s *RecommendationServer) GetRecommendation(ctx context.Context, req *pb.GetRecommendationRequest) (*pb.Recommendation, error) {
+	if s.manager == nil {
+		return nil, status.Error(codes.Unavailable, "recommendation manager not available")
+	}
+	return s.recommendation(req.Id)
+}
+
+// ApproveRecommendation approves a pending recommendation and executes it
+// when requested
+func (s *RecommendationServer) ApproveRecommendation(ctx context.Context, req *pb.ApproveRecommendationRequest) (*pb.Recommendation, error) {
+	if s.manager == nil {
+		return nil, status.Error(codes.Unavailable, "recommendation manager not available")
+	}
+	approvedBy := req.ApprovedBy
+	if approvedBy == "" {
+		approvedBy = "grpc"
+	}
+	if err := s.manager.ApproveRecommendation(req.Id, approvedBy); err != nil {
+		return nil, recommendationError(err)
+	}
+	if req.Execute {
+		if err := s.manager.ExecuteRecommendation(req.Id); err != nil {
+			// The approval stands; the failure is recorded on the recommendation
+			logger.Error("Failed to execute recommendation %s: %v", req.Id, err)
+		}
+	}
+	return s.recommendation(req.Id)
+}
+
+// RejectRecommendation rejects a pending recommendation
+func (s *RecommendationServer) RejectRecommendation(ctx context.Context, req *pb.RejectRecommendationRequest) (*pb.Recommendation, error) {
+	if s.manager == nil {
+		return nil, status.Error(codes.Unavailable, "recommendation manager not available")
+	}
+	rejectedBy := req.RejectedBy
+	if rejectedBy == "" {
+		rejectedBy = "grpc"
+	}
+	if err := s.manager.RejectRecommendation(req.Id, rejectedBy, req.Reason); err != nil {
+		return nil, recommendationError(err)
+	}
+	return s.recommendation(req.Id)
+}
+
+// WatchRecommendations streams recommendations whenever they are created or
+// change status, until the client cancels
+func (s *RecommendationServer) WatchRecommendations(req *pb.WatchRecommendationsRequest, stream pb.RecommendationService_WatchRecommendationsServer) error {
+	if s.manager == nil || s.eventBus == nil {
+		return status.Error(codes.Unavailable, "recommendation manager not available")
+	}
+
+	eventChan := make(chan *events.Event, 100)
+	subscriptionID := s.eventBus.SubscribeChannel(&events.EventFilter{
+		Namespaces: req.Namespaces,
+		Tags:       []string{"recommendation"},
+	}, eventChan)
+	defer s.eventBus.Unsubscribe(subscriptionID)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-eventChan:
+			id, _ := event.Details["recommendationId"].(string)
+			rec, ok := s.manager.GetRecommendation(id)
+			if !ok {
+				continue
+			}
+			if err := stream.Send(convertRecommendationToProto(rec)); err != nil {
+				logger.Error("Failed to send recommendation to stream: %v", err)
+				return err
+			}
+		}
+	}
+}
+
+// AnalyzePod analyzes a pod now and returns the containers that would be resized
+func (s *RecommendationServer) AnalyzePod(ctx context.Context, req *pb.AnalyzePodRequest) (*pb.AnalyzePodResponse, error) {
+	if s.analyzer == nil {
+		return nil, status.Error(codes.Unavailable, "on-demand analysis not available")
+	}
+	if req.Namespace == "" || req.PodName == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace and pod_name are required")
+	}
+
+	resizes, err := s.analyzer.AnalyzePod(ctx, req.Namespace, req.PodName)
+	if apierrors.IsNotFound(err) {
+		return nil, status.Errorf(codes.NotFound, "pod %s/%s not found", req.Namespace, req.PodName)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to analyze pod %s/%s: %v", req.Namespace, req.PodName, err)
+	}
+
+	resp := &pb.AnalyzePodResponse{
+		Namespace:  req.Namespace,
+		PodName:    req.PodName,
+		AnalyzedAt: timestamppb.Now(),
+	}
+	for _, resize := range resizes {
+		resp.Containers = append(resp.Containers, &pb.ContainerAnalysis{
+			ContainerName:       resize.Container,
+			CurrentRequests:     convertResourceListToProto(resize.Current.Requests),
+			CurrentLimits:       convertResourceListToProto(resize.Current.Limits),
+			RecommendedRequests: convertResourceListToProto(resize.Recommended.Requests),
+			RecommendedLimits:   convertResourceListToProto(resize.Recommended.Limits),
+			Reason:              resize.Reason,
+		})
+	}
+	return resp, nil
+}
+
+// recommendation returns the current state of a recommendation
+func (s *RecommendationServer) recommendation(id string) (*pb.Recommendation, error) {
+	rec, ok := s.manager.GetRecommendation(id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "recommendation %s not found", id)
+	}
+	return convertRecommendationToProto(rec), nil
+}
+
+// recommendationError maps an error of the recommendation manager to a status
+func recommendationError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return status.Error(codes.NotFound, err.Error())
+	case strings.Contains(err.Error(), "status"):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// Conversion helpers
+func convertRecommendationToProto(rec *events.Recommendation) *pb.Recommendation {
+	return &pb.Recommendation{
+		Id:                  rec.ID,
+		EventId:             rec.EventID,
+		ResourceType:        rec.ResourceType,
+		ResourceName:        rec.ResourceName,
+		Namespace:           rec.Namespace,
+		Title:               rec.Title,
+		Description:         rec.Description,
+		Action:              rec.Action,
+		Parameters:          convertDetailsToProto(rec.Parameters),
+		Urgency:             convertUrgencyToProto(rec.Urgency),
+		Severity:            convertSeverityToProto(rec.Severity),
+		Confidence:          rec.Confidence,
+		TimeToActionSeconds: int64(rec.TimeToAction.Seconds()),
+		CreatedAt:           timestamppb.New(rec.CreatedAt),
+		ExpiresAt:           timestamppb.New(rec.ExpiresAt),
+		Status:              convertRecommendationStatusToProto(rec.Status),
+		ApprovedBy:          rec.ApprovedBy,
+		ApprovedAt:          optionalTimestamp(rec.ApprovedAt),
+		RejectedBy:          rec.RejectedBy,
+		RejectedAt:          optionalTimestamp(rec.RejectedAt),
+		RejectedReason:      rec.RejectedReason,
+		ExecutedAt:          optionalTimestamp(rec.ExecutedAt),
+		Result:              rec.Result,
+		Error:               rec.Error,
+		Tags:                rec.Tags,
+	}
+}
+
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func convertRecommendationStatusToProto(recStatus events.RecommendationStatus) pb.RecommendationStatus {
+	switch recStatus {
+	case events.RecommendationStatusPending:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_PENDING
+	case events.RecommendationStatusApproved:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_APPROVED
+	case events.RecommendationStatusRejected:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_REJECTED
+	case events.RecommendationStatusExpired:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_EXPIRED
+	case events.RecommendationStatusExecuting:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_EXECUTING
+	case events.RecommendationStatusCompleted:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_COMPLETED
+	case events.RecommendationStatusFailed:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_FAILED
+	default:
+		return pb.RecommendationStatus_RECOMMENDATION_STATUS_UNSPECIFIED
+	}
+}
+
+func convertUrgencyToProto(urgency events.Urgency) pb.Urgency {
+	switch urgency {
+	case events.UrgencyLow:
+		return pb.Urgency_URGENCY_LOW
+	case events.UrgencyMedium:
+		return pb.Urgency_URGENCY_MEDIUM
+	case events.UrgencyHigh:
+		return pb.Urgency_URGENCY_HIGH
+	case events.UrgencyCritical:
+		return pb.Urgency_URGENCY_CRITICAL
+	default:
+		return pb.Urgency_URGENCY_UNSPECIFIED
+	}
+}
+
+func convertResourceListToProto(list corev1.ResourceList) *pb.ResourceValues {
+	values := &pb.ResourceValues{}
+	if cpu, ok := list[corev1.ResourceCPU]; ok {
+		values.Cpu = cpu.String()
+	}
+	if memory, ok := list[corev1.ResourceMemory]; ok {
+		values.Memory = memory.String()
+	}
+	return values
+}
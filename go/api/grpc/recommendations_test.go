@@ -0,0 +1,127 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "right-sizer/api/grpc/v1"
+	"right-sizer/events"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestRecommendationServer(analyzer Analyzer) (*RecommendationServer, *events.RecommendationManager) {
+	eventBus := events.NewEventBus(10)
+	manager := events.NewRecommendationManager(fake.NewSimpleClientset(), eventBus, logr.Discard(), nil)
+	return NewRecommendationServer(manager, eventBus, analyzer), manager
+}
+
+func createTestRecommendation(manager *events.RecommendationManager, namespace string, urgency events.Urgency) *events.Recommendation {
+	return manager.CreateRecommendation("event-1", "Pod", "web", namespace, "Increase memory", "OOM risk",
+		"increase_memory_limit", map[string]interface{}{"container": "app"}, urgency, events.SeverityWarning, 0.9, time.Minute)
+}
+
+func TestRecommendationServer_ListAndGet(t *testing.T) {
+	s, manager := newTestRecommendationServer(nil)
+	prod := createTestRecommendation(manager, "prod", events.UrgencyHigh)
+	createTestRecommendation(manager, "staging", events.UrgencyLow)
+
+	resp, err := s.ListRecommendations(context.Background(), &pb.ListRecommendationsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), resp.Total)
+
+	resp, err = s.ListRecommendations(context.Background(), &pb.ListRecommendationsRequest{Namespace: "prod"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Recommendations, 1)
+
+	resp, err = s.ListRecommendations(context.Background(), &pb.ListRecommendationsRequest{Urgency: pb.Urgency_URGENCY_LOW})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Recommendations, 1)
+	assert.Equal(t, "staging", resp.Recommendations[0].Namespace)
+
+	rec, err := s.GetRecommendation(context.Background(), &pb.GetRecommendationRequest{Id: prod.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.RecommendationStatus_RECOMMENDATION_STATUS_PENDING, rec.Status)
+	assert.Equal(t, pb.Severity_SEVERITY_WARNING, rec.Severity)
+	assert.Equal(t, "app", rec.Parameters["container"])
+	assert.Nil(t, rec.ApprovedAt)
+
+	_, err = s.GetRecommendation(context.Background(), &pb.GetRecommendationRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRecommendationServer_ApproveAndReject(t *testing.T) {
+	s, manager := newTestRecommendationServer(nil)
+	approved := createTestRecommendation(manager, "prod", events.UrgencyHigh)
+	rejected := createTestRecommendation(manager, "prod", events.UrgencyHigh)
+
+	rec, err := s.ApproveRecommendation(context.Background(), &pb.ApproveRecommendationRequest{Id: approved.ID, ApprovedBy: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.RecommendationStatus_RECOMMENDATION_STATUS_APPROVED, rec.Status)
+	assert.Equal(t, "alice", rec.ApprovedBy)
+	assert.NotNil(t, rec.ApprovedAt)
+
+	rec, err = s.RejectRecommendation(context.Background(), &pb.RejectRecommendationRequest{Id: rejected.ID, Reason: "planned migration"})
+	assert.NoError(t, err)
+	assert.Equal(t, pb.RecommendationStatus_RECOMMENDATION_STATUS_REJECTED, rec.Status)
+	assert.Equal(t, "grpc", rec.RejectedBy)
+	assert.Equal(t, "planned migration", rec.RejectedReason)
+
+	_, err = s.RejectRecommendation(context.Background(), &pb.RejectRecommendationRequest{Id: approved.ID})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = s.ApproveRecommendation(context.Background(), &pb.ApproveRecommendationRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRecommendationServer_AnalyzePod(t *testing.T) {
+	analyzer := AnalyzerFunc(func(ctx context.Context, namespace, name string) ([]ContainerResize, error) {
+		if name != "web" {
+			return nil, apierrors.NewNotFound(corev1.Resource("pods"), name)
+		}
+		return []ContainerResize{{
+			Container: "app",
+			Current: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			}},
+			Recommended: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			}},
+			Reason: "over-provisioned",
+		}}, nil
+	})
+	s, _ := newTestRecommendationServer(analyzer)
+
+	resp, err := s.AnalyzePod(context.Background(), &pb.AnalyzePodRequest{Namespace: "prod", PodName: "web"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.Containers, 1) {
+		assert.Equal(t, "500m", resp.Containers[0].CurrentRequests.Cpu)
+		assert.Equal(t, "512Mi", resp.Containers[0].RecommendedRequests.Memory)
+		assert.Equal(t, "", resp.Containers[0].RecommendedLimits.Cpu)
+	}
+
+	_, err = s.AnalyzePod(context.Background(), &pb.AnalyzePodRequest{Namespace: "prod", PodName: "api"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = s.AnalyzePod(context.Background(), &pb.AnalyzePodRequest{Namespace: "prod"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	s, _ = newTestRecommendationServer(nil)
+	_, err = s.AnalyzePod(context.Background(), &pb.AnalyzePodRequest{Namespace: "prod", PodName: "web"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -10,6 +10,7 @@ package grpc
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -39,6 +40,7 @@ type Server struct {
 	eventBus          *events.EventBus
 	remediationEngine *remediation.Engine
 	metricsProvider   metrics.Provider
+	recommendations   *RecommendationServer
 
 	// gRPC server
 	server   *grpc.Server
@@ -64,8 +66,62 @@ func NewServer(
 	}
 }
 
-// Start starts the gRPC server
+// SetRecommendationServer serves the RecommendationService alongside the
+// RightSizerService
+func (s *Server) SetRecommendationServer(recommendations *RecommendationServer) {
+	s.recommendations = recommendations
+}
+
+// checkJWTSecret rejects secrets anyone could sign tokens with: an empty
+// HMAC key is accepted by jwt, and the default one is public
+func checkJWTSecret(secret string) error {
+	switch secret {
+	case "":
+		return errors.New("gRPC API requires a JWT secret, set JWT_SECRET")
+	case config.DefaultJWTSecret:
+		return errors.New("gRPC API refuses the default JWT secret, set JWT_SECRET")
+	}
+	return nil
+}
+
+// shutdownTimeout bounds how long Stop waits for in-flight calls and streams
+const shutdownTimeout = 10 * time.Second
+
+// Start starts the gRPC server. It refuses to serve without TLS or with a JWT
+// secret tokens could be forged with, since approved recommendations resize pods.
 func (s *Server) Start(address string, tlsConfig *tls.Config) error {
+	if err := s.listen(address, tlsConfig); err != nil {
+		return err
+	}
+	return s.serve()
+}
+
+// Run serves the gRPC API like Start until ctx is done, then stops it
+func (s *Server) Run(ctx context.Context, address string, tlsConfig *tls.Config) error {
+	if err := s.listen(address, tlsConfig); err != nil {
+		return err
+	}
+	served := make(chan struct{})
+	defer close(served)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Stop()
+		case <-served:
+		}
+	}()
+	return s.serve()
+}
+
+// listen opens the listener and sets up the server
+func (s *Server) listen(address string, tlsConfig *tls.Config) error {
+	if err := checkJWTSecret(s.config.JWTSecret); err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		return errors.New("gRPC API requires TLS, set GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+	}
+
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", address, err)
@@ -73,42 +129,48 @@ func (s *Server) Start(address string, tlsConfig *tls.Config) error {
 	s.listener = listener
 
 	// Configure gRPC server options
-	var opts []grpc.ServerOption
-
-	// Add TLS if configured
-	if tlsConfig != nil {
-		creds := credentials.NewTLS(tlsConfig)
-		opts = append(opts, grpc.Creds(creds))
-	}
-
-	// Add authentication interceptor
-	opts = append(opts,
+	opts := []grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		// Add authentication interceptor
 		grpc.UnaryInterceptor(s.authUnaryInterceptor),
 		grpc.StreamInterceptor(s.authStreamInterceptor),
-	)
+	}
 
 	s.server = grpc.NewServer(opts...)
 	pb.RegisterRightSizerServiceServer(s.server, s)
+	if s.recommendations != nil {
+		pb.RegisterRecommendationServiceServer(s.server, s.recommendations)
+	}
+	return nil
+}
 
-	logger.Info("Starting gRPC server on %s", address)
+// serve accepts connections until the server is stopped
+func (s *Server) serve() error {
+	logger.Info("Starting gRPC server on %s", s.listener.Addr())
 
-	if err := s.server.Serve(listener); err != nil {
+	if err := s.server.Serve(s.listener); err != nil {
 		return fmt.Errorf("failed to serve gRPC: %w", err)
 	}
 
 	return nil
 }
 
-// Stop stops the gRPC server gracefully
+// Stop stops the gRPC server gracefully, cancelling the calls and streams
+// still open after shutdownTimeout
 func (s *Server) Stop() {
-	if s.server != nil {
-		logger.Info("Stopping gRPC server")
-		s.server.GracefulStop()
+	if s.server == nil {
+		return
 	}
-	if s.listener != nil {
-		if err := s.listener.Close(); err != nil {
-			logger.Error("Failed to close gRPC listener: %v", err)
-		}
+	logger.Info("Stopping gRPC server")
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		s.server.Stop()
 	}
 }
 
@@ -202,6 +264,10 @@ func (s *Server) StreamEvents(req *pb.EventStreamRequest, stream pb.RightSizerSe
 
 // ExecuteAction executes a remediation action
 func (s *Server) ExecuteAction(ctx context.Context, req *pb.ActionRequest) (*pb.ActionResponse, error) {
+	if s.remediationEngine == nil {
+		return nil, status.Error(codes.Unavailable, "remediation engine not available")
+	}
+
 	// Convert proto action to internal action
 	action := s.convertProtoToAction(req.Action)
 
@@ -222,6 +288,10 @@ func (s *Server) ExecuteAction(ctx context.Context, req *pb.ActionRequest) (*pb.
 
 // GetActionStatus returns the status of an action
 func (s *Server) GetActionStatus(ctx context.Context, req *pb.ActionStatusRequest) (*pb.ActionStatusResponse, error) {
+	if s.remediationEngine == nil {
+		return nil, status.Error(codes.Unavailable, "remediation engine not available")
+	}
+
 	action, exists := s.remediationEngine.GetAction(req.ActionId)
 	if !exists {
 		return nil, status.Errorf(codes.NotFound, "action %s not found", req.ActionId)
@@ -288,6 +358,11 @@ func (s *Server) authenticate(ctx context.Context) error {
 
 // isValidToken checks if a token is valid with signature and expiration validation
 func (s *Server) isValidToken(token string) bool {
+	if err := checkJWTSecret(s.config.JWTSecret); err != nil {
+		logger.Warn("Token validation failed: %v", err)
+		return false
+	}
+
 	// Validate JWT tokens with proper signature and expiration checks
 	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
 		// Ensure the signing method is HMAC (prevent algorithm substitution attacks)
@@ -401,7 +476,7 @@ func (s *Server) convertEventToProto(event *events.Event) *pb.Event {
 		ClusterId:     event.ClusterID,
 		Namespace:     event.Namespace,
 		ResourceName:  event.Resource,
-		Severity:      s.convertSeverityToProto(event.Severity),
+		Severity:      convertSeverityToProto(event.Severity),
 		Message:       event.Message,
 		Timestamp:     timestamppb.New(event.Timestamp),
 		Details:       convertDetailsToProto(event.Details),
@@ -410,7 +485,7 @@ func (s *Server) convertEventToProto(event *events.Event) *pb.Event {
 	}
 }
 
-func (s *Server) convertSeverityToProto(severity events.Severity) pb.Severity {
+func convertSeverityToProto(severity events.Severity) pb.Severity {
 	switch severity {
 	case events.SeverityInfo:
 		return pb.Severity_SEVERITY_INFO
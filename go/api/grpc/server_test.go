@@ -1,6 +1,7 @@
 package grpc
 
 import (
+	"crypto/tls"
 	"testing"
 	"time"
 
@@ -92,3 +93,35 @@ func TestIsValidToken(t *testing.T) {
 		})
 	}
 }
+
+func TestStartRefusesInsecureSettings(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	tests := []struct {
+		name      string
+		secret    string
+		tlsConfig *tls.Config
+		want      string
+	}{
+		{name: "Empty Secret", secret: "", tlsConfig: tlsConfig, want: "requires a JWT secret"},
+		{name: "Default Secret", secret: config.DefaultJWTSecret, tlsConfig: tlsConfig, want: "default JWT secret"},
+		{name: "No TLS", secret: "test-secret", want: "requires TLS"}, // pragma: allowlist secret
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(&config.Config{JWTSecret: tt.secret}, nil, nil, nil)
+			err := s.Start("127.0.0.1:0", tt.tlsConfig)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestIsValidTokenRejectsDefaultSecret(t *testing.T) {
+	s := &Server{config: &config.Config{JWTSecret: config.DefaultJWTSecret}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user123",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(config.DefaultJWTSecret))
+	assert.NoError(t, err)
+	assert.False(t, s.isValidToken(token))
+}
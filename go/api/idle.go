@@ -0,0 +1,60 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"right-sizer/idle"
+)
+
+// SetIdleDetector sets the detector /api/idle-workloads reports
+func (s *Server) SetIdleDetector(detector *idle.Detector) {
+	s.idle = detector
+}
+
+// handleIdleWorkloads returns the workloads whose CPU usage has stayed near
+// zero for longer than the idle threshold. They are reported only, never
+// resized.
+//
+//	?namespace=  only idle workloads in the namespace
+func (s *Server) handleIdleWorkloads(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.idle == nil {
+		http.Error(w, "Idle workload detection not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	workloads := s.idle.Idle(time.Now())
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		filtered := []idle.Workload{}
+		for _, workload := range workloads {
+			if workload.Namespace == namespace {
+				filtered = append(filtered, workload)
+			}
+		}
+		workloads = filtered
+	}
+	s.writeJSONResponse(w, map[string]interface{}{
+		"workloads": workloads,
+		"total":     len(workloads),
+	})
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/idle"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleIdleWorkloads(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleIdleWorkloads(w, httptest.NewRequest(http.MethodGet, "/api/idle-workloads", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	detector := idle.NewDetector()
+	detector.Observe("shop", "Deployment/legacy", "legacy-1", 1)
+	detector.Observe("dev", "Deployment/sandbox", "sandbox-1", 0)
+	detector.Observe("dev", "Deployment/api", "api-1", 300)
+	detector.EndCycle(time.Now(), config.IdleConfig{Enabled: true, CPUMilli: 5})
+	s.SetIdleDetector(detector)
+
+	var response struct {
+		Workloads []idle.Workload `json:"workloads"`
+		Total     int             `json:"total"`
+	}
+	w = httptest.NewRecorder()
+	s.handleIdleWorkloads(w, httptest.NewRequest(http.MethodGet, "/api/idle-workloads", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Total)
+
+	w = httptest.NewRecorder()
+	s.handleIdleWorkloads(w, httptest.NewRequest(http.MethodGet, "/api/idle-workloads?namespace=shop", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, 1, response.Total)
+	assert.Equal(t, "Deployment/legacy", response.Workloads[0].Workload)
+}
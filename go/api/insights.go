@@ -0,0 +1,178 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/efficiency"
+)
+
+const (
+	// insightsDefaultK is how many workloads /api/insights/top lists by default
+	insightsDefaultK = 20
+	// insightsMaxK caps how many workloads /api/insights/top lists
+	insightsMaxK = 500
+	// overProvisionedPercent is the request utilization below which a workload is over-provisioned
+	overProvisionedPercent = 50
+	// underProvisionedPercent is the request utilization above which a workload is under-provisioned
+	underProvisionedPercent = 90
+)
+
+// Insight types of /api/insights/top
+const (
+	insightOverProvisioned  = "overprovisioned"
+	insightUnderProvisioned = "underprovisioned"
+)
+
+// workloadInsight is a workload's requests against its usage at the latest analysis
+type workloadInsight struct {
+	Namespace         string  `json:"namespace"`
+	Workload          string  `json:"workload"` // Kind/name
+	Containers        int     `json:"containers"`
+	CPURequestMillis  int64   `json:"cpuRequestMillis"`
+	CPUUsageMillis    int64   `json:"cpuUsageMillis"`
+	MemoryRequestMB   int64   `json:"memoryRequestMB"`
+	MemoryUsageMB     int64   `json:"memoryUsageMB"`
+	CPUUtilization    float64 `json:"cpuUtilization"`    // usage as a percentage of the request, 0 without a request
+	MemoryUtilization float64 `json:"memoryUtilization"` // usage as a percentage of the request, 0 without a request
+	MonthlyWaste      float64 `json:"monthlyWaste"`      // monthly cost of the requested but unused resources
+}
+
+// handleInsightsTop returns the k most over- or under-provisioned workloads
+// of the latest analysis. Over-provisioned workloads use less than half of
+// a resource they request and are ranked by the monthly cost of what they
+// leave unused; under-provisioned ones use almost all of a request, or a
+// resource they do not request, and are ranked by utilization.
+//
+//	?type=       overprovisioned (default) or underprovisioned
+//	?k=20        how many workloads to list
+//	?namespace=  only workloads in the namespace
+func (s *Server) handleInsightsTop(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	kind := query.Get("type")
+	if kind == "" {
+		kind = insightOverProvisioned
+	}
+	if kind != insightOverProvisioned && kind != insightUnderProvisioned {
+		http.Error(w, "type must be overprovisioned or underprovisioned", http.StatusBadRequest)
+		return
+	}
+	k, err := positiveParam(query, "k", insightsDefaultK)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	k = min(k, insightsMaxK)
+	if s.efficiency == nil {
+		http.Error(w, "Workload insights not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	pricing := cost.EstimatedPricing()
+	if s.costClient != nil {
+		pricing = s.costClient.Pricing(r.Context())
+	}
+	// Every container is observed once per cycle, so anything older than
+	// two intervals belongs to a pod that is gone
+	latest := s.efficiency.Latest(2 * config.Get().ResizeInterval)
+	namespace := query.Get("namespace")
+	insights := []workloadInsight{}
+	for _, usage := range latest {
+		if namespace != "" && usage.Namespace != namespace {
+			continue
+		}
+		insight := newWorkloadInsight(usage, pricing)
+		if (kind == insightOverProvisioned && isOverProvisioned(insight)) ||
+			(kind == insightUnderProvisioned && isUnderProvisioned(insight)) {
+			insights = append(insights, insight)
+		}
+	}
+	rankInsights(insights, kind)
+
+	total := len(insights)
+	s.writeJSONResponse(w, map[string]interface{}{
+		"type":       kind,
+		"workloads":  insights[:min(k, total)],
+		"total":      total,
+		"costSource": pricing.Source,
+	})
+}
+
+// newWorkloadInsight derives utilization and waste from a workload's usage
+func newWorkloadInsight(usage efficiency.WorkloadUsage, pricing *cost.Pricing) workloadInsight {
+	insight := workloadInsight{
+		Namespace:        usage.Namespace,
+		Workload:         usage.Workload,
+		Containers:       usage.Containers,
+		CPURequestMillis: int64(usage.CPURequest),
+		CPUUsageMillis:   int64(usage.CPUUsage),
+		MemoryRequestMB:  int64(usage.MemRequestMB),
+		MemoryUsageMB:    int64(usage.MemUsageMB),
+	}
+	if usage.CPURequest > 0 {
+		insight.CPUUtilization = usage.CPUUsage / usage.CPURequest * 100
+	}
+	if usage.MemRequestMB > 0 {
+		insight.MemoryUtilization = usage.MemUsageMB / usage.MemRequestMB * 100
+	}
+	unusedCPU := max(insight.CPURequestMillis-insight.CPUUsageMillis, 0)
+	unusedMemory := max(insight.MemoryRequestMB-insight.MemoryUsageMB, 0) * 1024 * 1024
+	insight.MonthlyWaste = pricing.MonthlyCost(unusedCPU, unusedMemory)
+	return insight
+}
+
+// isOverProvisioned reports a workload using less than half of a resource it requests
+func isOverProvisioned(i workloadInsight) bool {
+	return (i.CPURequestMillis > 0 && i.CPUUtilization < overProvisionedPercent) ||
+		(i.MemoryRequestMB > 0 && i.MemoryUtilization < overProvisionedPercent)
+}
+
+// isUnderProvisioned reports a workload using almost all of a resource it
+// requests, or using a resource without requesting it
+func isUnderProvisioned(i workloadInsight) bool {
+	return i.CPUUtilization > underProvisionedPercent || i.MemoryUtilization > underProvisionedPercent ||
+		(i.CPURequestMillis == 0 && i.CPUUsageMillis > 0) || (i.MemoryRequestMB == 0 && i.MemoryUsageMB > 0)
+}
+
+// rankInsights sorts over-provisioned workloads by wasted cost and
+// under-provisioned ones by their highest utilization, ties by name
+func rankInsights(insights []workloadInsight, kind string) {
+	key := func(i workloadInsight) float64 {
+		if kind == insightOverProvisioned {
+			return i.MonthlyWaste
+		}
+		return max(i.CPUUtilization, i.MemoryUtilization)
+	}
+	sort.Slice(insights, func(a, b int) bool {
+		ka, kb := key(insights[a]), key(insights[b])
+		if ka != kb {
+			return ka > kb
+		}
+		if insights[a].Namespace != insights[b].Namespace {
+			return insights[a].Namespace < insights[b].Namespace
+		}
+		return insights[a].Workload < insights[b].Workload
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/efficiency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleInsightsTop(t *testing.T) {
+	config.Load()
+	s := &Server{}
+
+	w := httptest.NewRecorder()
+	s.handleInsightsTop(w, httptest.NewRequest(http.MethodGet, "/api/insights/top", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	tracker := efficiency.NewTracker()
+	now := time.Now()
+	observe := func(namespace, workload, pod string, cpuRequest, cpuUsage, memRequest, memUsage float64) {
+		tracker.Observe(efficiency.Sample{
+			Time: now, Namespace: namespace, Workload: workload, Pod: pod, Container: "app",
+			CPURequest: cpuRequest, CPUUsage: cpuUsage, MemRequestMB: memRequest, MemUsageMB: memUsage,
+		})
+	}
+	observe("shop", "Deployment/web", "web-1", 2000, 100, 1024, 900)   // wastes the most CPU
+	observe("shop", "Deployment/cart", "cart-1", 500, 100, 512, 400)   // wastes some CPU
+	observe("shop", "Deployment/db", "db-1", 1000, 950, 1024, 1000)    // close to its requests
+	observe("dev", "Deployment/api", "api-1", 1000, 100, 0, 256)       // wastes CPU, no memory request
+	observe("dev", "Deployment/tools", "tools-1", 1000, 700, 512, 400) // well sized
+	s.SetEfficiencyTracker(tracker)
+
+	for _, query := range []string{"type=oversized", "k=0", "k=many"} {
+		w = httptest.NewRecorder()
+		s.handleInsightsTop(w, httptest.NewRequest(http.MethodGet, "/api/insights/top?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	var response struct {
+		Type      string            `json:"type"`
+		Workloads []workloadInsight `json:"workloads"`
+		Total     int               `json:"total"`
+	}
+	w = httptest.NewRecorder()
+	s.handleInsightsTop(w, httptest.NewRequest(http.MethodGet, "/api/insights/top?type=overprovisioned&k=2", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, insightOverProvisioned, response.Type)
+	assert.Equal(t, 3, response.Total)
+	require.Len(t, response.Workloads, 2)
+	assert.Equal(t, "Deployment/web", response.Workloads[0].Workload)
+	assert.InDelta(t, 5, response.Workloads[0].CPUUtilization, 0.01)
+	assert.Greater(t, response.Workloads[0].MonthlyWaste, response.Workloads[1].MonthlyWaste)
+
+	response.Workloads = nil
+	w = httptest.NewRecorder()
+	s.handleInsightsTop(w, httptest.NewRequest(http.MethodGet, "/api/insights/top?type=underprovisioned", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Workloads, 2)
+	assert.Equal(t, "Deployment/db", response.Workloads[0].Workload)
+	assert.Equal(t, "Deployment/api", response.Workloads[1].Workload)
+
+	response.Workloads = nil
+	w = httptest.NewRecorder()
+	s.handleInsightsTop(w, httptest.NewRequest(http.MethodGet, "/api/insights/top?namespace=dev", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Workloads, 1)
+	assert.Equal(t, "Deployment/api", response.Workloads[0].Workload)
+}
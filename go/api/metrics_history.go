@@ -0,0 +1,244 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// metricsHistory keeps the samples of /api/metrics/history
+var metricsHistory = newMetricsStore()
+
+// metricsStore keeps the aggregate metric samples at decreasing resolution as
+// they age: every sample for an hour, one-minute averages for a day,
+// five-minute averages for a week and hourly averages for 30 days. Each range
+// of the history endpoint is served from the finest tier covering it, so a
+// 30 day chart is as complete as a one hour one.
+type metricsStore struct {
+	mu    sync.Mutex
+	tiers []*historyTier // finest first
+}
+
+// historyTier keeps samples at one resolution for a retention period
+type historyTier struct {
+	resolution time.Duration // width of the buckets samples are averaged into; 0 keeps every sample
+	retention  time.Duration
+	buckets    []historyBucket // in time order
+}
+
+// historyBucket is the average of the samples of one bucket, timed at its start
+type historyBucket struct {
+	sample MetricSample
+	count  int
+}
+
+func newMetricsStore() *metricsStore {
+	return &metricsStore{tiers: []*historyTier{
+		{retention: hour1},
+		{resolution: time.Minute, retention: hour24},
+		{resolution: 5 * time.Minute, retention: day7},
+		{resolution: time.Hour, retention: day30},
+	}}
+}
+
+// add records samples in every tier and drops what has aged out of them
+func (s *metricsStore) add(samples ...MetricSample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tier := range s.tiers {
+		for _, sample := range samples {
+			tier.add(sample)
+		}
+		tier.trim()
+	}
+}
+
+// reset drops all samples
+func (s *metricsStore) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tier := range s.tiers {
+		tier.buckets = nil
+	}
+}
+
+// latest returns the most recent sample
+func (s *metricsStore) latest() (MetricSample, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw := s.tiers[0].buckets
+	if len(raw) == 0 {
+		return MetricSample{}, false
+	}
+	return raw[len(raw)-1].sample, true
+}
+
+// window returns the samples of the last window from the finest tier that
+// retains it, or from the coarsest tier for longer windows
+func (s *metricsStore) window(window time.Duration) []MetricSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tier := s.tiers[len(s.tiers)-1]
+	for _, t := range s.tiers {
+		if t.retention >= window {
+			tier = t
+			break
+		}
+	}
+
+	cutoff := time.Now().Add(-window)
+	out := make([]MetricSample, 0, len(tier.buckets))
+	for _, bucket := range tier.buckets {
+		if bucket.sample.Time.Add(tier.resolution).After(cutoff) {
+			out = append(out, bucket.sample)
+		}
+	}
+	return out
+}
+
+// all returns the whole history, each stretch of time at the finest
+// resolution still retained for it
+func (s *metricsStore) all() []MetricSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var parts [][]MetricSample
+	var boundary time.Time // start of what finer tiers cover
+	for _, tier := range s.tiers {
+		var part []MetricSample
+		for _, bucket := range tier.buckets {
+			if !boundary.IsZero() && bucket.sample.Time.Add(tier.resolution).After(boundary) {
+				break
+			}
+			part = append(part, bucket.sample)
+		}
+		parts = append(parts, part)
+		if len(tier.buckets) > 0 && (boundary.IsZero() || tier.buckets[0].sample.Time.Before(boundary)) {
+			boundary = tier.buckets[0].sample.Time
+		}
+	}
+
+	var out []MetricSample
+	for i := len(parts) - 1; i >= 0; i-- {
+		out = append(out, parts[i]...)
+	}
+	return out
+}
+
+// add merges a sample into its bucket, keeping the buckets in time order
+func (t *historyTier) add(sample MetricSample) {
+	start := sample.Time
+	if t.resolution > 0 {
+		start = sample.Time.Truncate(t.resolution)
+	}
+	i := sort.Search(len(t.buckets), func(i int) bool { return !t.buckets[i].sample.Time.Before(start) })
+	if t.resolution > 0 && i < len(t.buckets) && t.buckets[i].sample.Time.Equal(start) {
+		t.buckets[i].merge(sample)
+		return
+	}
+	bucket := historyBucket{sample: sample, count: 1}
+	bucket.sample.Time = start
+	t.buckets = append(t.buckets, historyBucket{})
+	copy(t.buckets[i+1:], t.buckets[i:])
+	t.buckets[i] = bucket
+}
+
+// trim drops the buckets older than the retention before the newest one.
+// Every sample tier is also capped at metricsHistoryLimit samples.
+func (t *historyTier) trim() {
+	if len(t.buckets) == 0 {
+		return
+	}
+	cutoff := t.buckets[len(t.buckets)-1].sample.Time.Add(-t.retention)
+	i := sort.Search(len(t.buckets), func(i int) bool { return !t.buckets[i].sample.Time.Before(cutoff) })
+	if t.resolution == 0 {
+		i = max(i, len(t.buckets)-metricsHistoryLimit)
+	}
+	if i > 0 {
+		t.buckets = append(t.buckets[:0:0], t.buckets[i:]...)
+	}
+}
+
+// merge averages a sample into the bucket. The optimization counter keeps
+// its latest value.
+func (b *historyBucket) merge(sample MetricSample) {
+	b.count++
+	n := float64(b.count)
+	avg := func(into *float64, value float64) { *into += (value - *into) / n }
+	avg(&b.sample.CPUUsagePercent, sample.CPUUsagePercent)
+	avg(&b.sample.MemoryUsagePercent, sample.MemoryUsagePercent)
+	avg(&b.sample.ActivePods, sample.ActivePods)
+	avg(&b.sample.NetworkUsageMbps, sample.NetworkUsageMbps)
+	avg(&b.sample.DiskIOMBps, sample.DiskIOMBps)
+	avg(&b.sample.AvgUtilization, sample.AvgUtilization)
+	b.sample.OptimizedResources = max(b.sample.OptimizedResources, sample.OptimizedResources)
+}
+
+// filterMetricsHistory returns the stored history for a simple time range
+// string: 1h,6h,12h,24h,7d,14d,30d. An unknown or empty range returns the
+// whole history.
+func filterMetricsHistory(rangeParam string) []MetricSample {
+	windows := map[string]time.Duration{
+		"1h":  hour1,
+		"6h":  hour6,
+		"12h": hour12,
+		"24h": hour24,
+		"7d":  day7,
+		"14d": day14,
+		"30d": day30,
+	}
+	if window, ok := windows[rangeParam]; ok {
+		return metricsHistory.window(window)
+	}
+	return metricsHistory.all()
+}
+
+// LoadMetricsHistory restores metrics history saved by SaveMetricsHistory.
+// A missing file is not an error.
+func LoadMetricsHistory(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var samples []MetricSample
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return fmt.Errorf("failed to decode metrics history %s: %w", path, err)
+	}
+	metricsHistory.add(samples...)
+	return nil
+}
+
+// SaveMetricsHistory writes the metrics history to path so it survives restarts
+func SaveMetricsHistory(path string) error {
+	data, err := json.Marshal(metricsHistory.all())
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsStore_Downsamples(t *testing.T) {
+	store := newMetricsStore()
+	now := time.Now().Truncate(time.Hour)
+
+	// One sample every 10 seconds for two days
+	for at := now.Add(-48 * time.Hour); !at.After(now); at = at.Add(10 * time.Second) {
+		store.add(MetricSample{Time: at, CPUUsagePercent: 50})
+	}
+
+	assert.Len(t, store.tiers[0].buckets, 361, "raw samples kept for an hour")
+	assert.Len(t, store.tiers[1].buckets, 1441, "one-minute buckets kept for a day")
+	assert.Len(t, store.tiers[2].buckets, 48*12+1, "five-minute buckets for all two days")
+	assert.Len(t, store.tiers[3].buckets, 49, "hourly buckets for all two days")
+
+	bucket := store.tiers[1].buckets[0]
+	assert.Equal(t, 6, bucket.count)
+	assert.InDelta(t, 50, bucket.sample.CPUUsagePercent, 0.001)
+}
+
+func TestMetricsStore_AveragesBuckets(t *testing.T) {
+	store := newMetricsStore()
+	start := time.Now().Truncate(time.Hour)
+	store.add(
+		MetricSample{Time: start, CPUUsagePercent: 10, OptimizedResources: 1},
+		MetricSample{Time: start.Add(20 * time.Second), CPUUsagePercent: 30, OptimizedResources: 3},
+	)
+
+	minute := store.tiers[1].buckets
+	require.Len(t, minute, 1)
+	assert.Equal(t, start, minute[0].sample.Time)
+	assert.InDelta(t, 20, minute[0].sample.CPUUsagePercent, 0.001)
+	assert.Equal(t, float64(3), minute[0].sample.OptimizedResources, "counters keep their latest value")
+	assert.Len(t, store.tiers[0].buckets, 2)
+}
+
+func TestMetricsStore_AllStitchesTiers(t *testing.T) {
+	store := newMetricsStore()
+	now := time.Now()
+	store.add(
+		MetricSample{Time: now.Add(-10 * 24 * time.Hour)},
+		MetricSample{Time: now.Add(-3 * 24 * time.Hour)},
+		MetricSample{Time: now.Add(-3 * time.Hour)},
+		MetricSample{Time: now.Add(-10 * time.Minute)},
+		MetricSample{Time: now},
+	)
+
+	all := store.all()
+	require.Len(t, all, 5)
+	for i := 1; i < len(all); i++ {
+		assert.True(t, all[i-1].Time.Before(all[i].Time), "samples in time order")
+	}
+	assert.Equal(t, now, all[4].Time, "recent samples at full resolution")
+}
+
+func TestMetricsHistory_SaveAndLoad(t *testing.T) {
+	metricsHistory.reset()
+	defer metricsHistory.reset()
+	now := time.Now().Truncate(time.Second)
+	metricsHistory.add(
+		MetricSample{Time: now.Add(-2 * time.Hour), CPUUsagePercent: 20},
+		MetricSample{Time: now, CPUUsagePercent: 10},
+	)
+
+	path := filepath.Join(t.TempDir(), "metrics-history.json")
+	require.NoError(t, SaveMetricsHistory(path))
+	metricsHistory.reset()
+	require.NoError(t, LoadMetricsHistory(path))
+
+	samples := filterMetricsHistory("24h")
+	require.Len(t, samples, 2)
+	assert.Equal(t, 20.0, samples[0].CPUUsagePercent)
+	latest, ok := metricsHistory.latest()
+	require.True(t, ok)
+	assert.True(t, now.Equal(latest.Time))
+}
@@ -0,0 +1,259 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/cost"
+	"right-sizer/logger"
+	"right-sizer/workload"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// namespaceSummary is the rollup of a namespace returned by /api/namespaces
+type namespaceSummary struct {
+	Namespace string `json:"namespace"`
+	Pods      int    `json:"pods"`
+	Running   int    `json:"runningPods"`
+
+	// CPU is in millicores and Memory in bytes
+	CPU    resourceRollup `json:"cpu"`
+	Memory resourceRollup `json:"memory"`
+
+	// Recommendations counts the workloads with a RightSizerRecommendation
+	Recommendations int            `json:"recommendations"`
+	Savings         savingsRollup  `json:"savings"`
+	PolicyCoverage  policyCoverage `json:"policyCoverage"`
+}
+
+// resourceRollup sums one resource over the pods of a namespace. Recommended
+// equals Requests for containers without a recommendation.
+type resourceRollup struct {
+	Requests    int64   `json:"requests"`
+	Recommended int64   `json:"recommended"`
+	Usage       int64   `json:"usage"`
+	Utilization float64 `json:"utilization"` // usage as a percentage of requests
+}
+
+// savingsRollup is what applying the namespace's recommendations would free
+type savingsRollup struct {
+	CPU         int64   `json:"cpu"`    // millicores
+	Memory      int64   `json:"memory"` // bytes
+	MonthlyCost float64 `json:"monthlyCost"`
+	Source      string  `json:"source"` // pricing source: opencost, kubecost or estimate
+}
+
+// policyCoverage reports how many pods of a namespace an enabled policy selects
+type policyCoverage struct {
+	CoveredPods int      `json:"coveredPods"`
+	Percent     float64  `json:"percent"`
+	Policies    []string `json:"policies,omitempty"`
+}
+
+// handleNamespaces returns per-namespace rollups of pod counts, current and
+// recommended requests, utilization, savings potential and policy coverage,
+// sorted by namespace.
+//
+//	GET /api/namespaces
+func (s *Server) handleNamespaces(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	pods, err := s.listPods(ctx, "", nil)
+	if err != nil {
+		logger.Error("Failed to list pods for namespace summary: %v", err)
+		http.Error(w, "Failed to list pods", http.StatusInternalServerError)
+		return
+	}
+
+	pricing := cost.EstimatedPricing()
+	if s.costClient != nil {
+		pricing = s.costClient.Pricing(ctx)
+	}
+	usage := s.podUsage(ctx)
+	recommendations := s.recommendationsByWorkload(ctx)
+	policies := s.enabledPolicies(ctx)
+
+	summaries := map[string]*namespaceSummary{}
+	withRecommendation := map[string]map[string]bool{}
+	for i := range pods {
+		pod := &pods[i]
+		summary, ok := summaries[pod.Namespace]
+		if !ok {
+			summary = &namespaceSummary{Namespace: pod.Namespace}
+			summaries[pod.Namespace] = summary
+			withRecommendation[pod.Namespace] = map[string]bool{}
+		}
+		summary.Pods++
+		if pod.Status.Phase == v1.PodRunning {
+			summary.Running++
+		}
+
+		var target v1alpha1.RecommendationTargetRef
+		if s.ctrlClient != nil && (len(recommendations) > 0 || len(policies) > 0) {
+			target = workload.Resolve(ctx, s.ctrlClient, pod)
+		}
+		key := pod.Namespace + "/" + target.Kind + "/" + target.Name
+		recommended := recommendations[key]
+		if recommended != nil {
+			withRecommendation[pod.Namespace][key] = true
+		}
+
+		for _, container := range pod.Spec.Containers {
+			cpu := container.Resources.Requests.Cpu().MilliValue()
+			memory := container.Resources.Requests.Memory().Value()
+			recCPU, recMemory := cpu, memory
+			if requests, ok := recommended[container.Name]; ok {
+				if q, ok := requests[v1.ResourceCPU]; ok {
+					recCPU = q.MilliValue()
+				}
+				if q, ok := requests[v1.ResourceMemory]; ok {
+					recMemory = q.Value()
+				}
+			}
+			summary.CPU.Requests += cpu
+			summary.CPU.Recommended += recCPU
+			summary.Memory.Requests += memory
+			summary.Memory.Recommended += recMemory
+			summary.Savings.CPU += max(cpu-recCPU, 0)
+			summary.Savings.Memory += max(memory-recMemory, 0)
+		}
+
+		if used, ok := usage[pod.Namespace+"/"+pod.Name]; ok {
+			summary.CPU.Usage += used.cpu
+			summary.Memory.Usage += used.memory
+		}
+
+		covered := false
+		for j := range policies {
+			if workload.MatchesPolicy(&policies[j], pod, target) {
+				covered = true
+				if name := policies[j].Namespace + "/" + policies[j].Name; !slices.Contains(summary.PolicyCoverage.Policies, name) {
+					summary.PolicyCoverage.Policies = append(summary.PolicyCoverage.Policies, name)
+				}
+			}
+		}
+		if covered {
+			summary.PolicyCoverage.CoveredPods++
+		}
+	}
+
+	result := make([]namespaceSummary, 0, len(summaries))
+	for namespace, summary := range summaries {
+		summary.Recommendations = len(withRecommendation[namespace])
+		summary.CPU.Utilization = utilizationPercent(summary.CPU.Usage, summary.CPU.Requests)
+		summary.Memory.Utilization = utilizationPercent(summary.Memory.Usage, summary.Memory.Requests)
+		summary.Savings.MonthlyCost = pricing.MonthlyCost(summary.Savings.CPU, summary.Savings.Memory)
+		summary.Savings.Source = pricing.Source
+		summary.PolicyCoverage.Percent = float64(summary.PolicyCoverage.CoveredPods) / float64(summary.Pods) * percentMultiplier
+		sort.Strings(summary.PolicyCoverage.Policies)
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Namespace < result[j].Namespace })
+
+	s.writeJSONResponse(w, map[string]interface{}{
+		"namespaces": result,
+		"total":      len(result),
+	})
+}
+
+// containerUsage is the CPU (millicores) and memory (bytes) a pod uses
+type containerUsage struct {
+	cpu, memory int64
+}
+
+// podUsage returns the metrics-server usage of every pod keyed by
+// namespace/name, or nothing when metrics-server is not available
+func (s *Server) podUsage(ctx context.Context) map[string]containerUsage {
+	usage := map[string]containerUsage{}
+	if s.metricsClient == nil {
+		return usage
+	}
+	list, err := s.metricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Debug("Pod metrics not available for namespace summary: %v", err)
+		return usage
+	}
+	for _, pm := range list.Items {
+		var used containerUsage
+		for _, container := range pm.Containers {
+			used.cpu += container.Usage.Cpu().MilliValue()
+			used.memory += container.Usage.Memory().Value()
+		}
+		usage[pm.Namespace+"/"+pm.Name] = used
+	}
+	return usage
+}
+
+// recommendationsByWorkload returns the recommended requests of each
+// container, keyed by namespace/kind/name of the workload and container name
+func (s *Server) recommendationsByWorkload(ctx context.Context) map[string]map[string]v1.ResourceList {
+	out := map[string]map[string]v1.ResourceList{}
+	if s.ctrlClient == nil {
+		return out
+	}
+	var list v1alpha1.RightSizerRecommendationList
+	if err := s.ctrlClient.List(ctx, &list); err != nil {
+		logger.Debug("Recommendations not available for namespace summary: %v", err)
+		return out
+	}
+	for _, rec := range list.Items {
+		containers := map[string]v1.ResourceList{}
+		for _, c := range rec.Status.ContainerRecommendations {
+			containers[c.ContainerName] = c.Recommended.Requests
+		}
+		out[rec.Namespace+"/"+rec.Spec.TargetRef.Kind+"/"+rec.Spec.TargetRef.Name] = containers
+	}
+	return out
+}
+
+// enabledPolicies returns the enabled RightSizerPolicies, or none when they cannot be listed
+func (s *Server) enabledPolicies(ctx context.Context) []v1alpha1.RightSizerPolicy {
+	if s.ctrlClient == nil {
+		return nil
+	}
+	var list v1alpha1.RightSizerPolicyList
+	if err := s.ctrlClient.List(ctx, &list); err != nil {
+		logger.Debug("Policies not available for namespace summary: %v", err)
+		return nil
+	}
+	var policies []v1alpha1.RightSizerPolicy
+	for _, policy := range list.Items {
+		if policy.Spec.Enabled {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+// utilizationPercent returns used as a percentage of requested, or 0 without requests
+func utilizationPercent(used, requested int64) float64 {
+	if requested <= 0 {
+		return 0
+	}
+	return float64(used) / float64(requested) * percentMultiplier
+}
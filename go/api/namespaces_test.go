@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func namespacePod(namespace, name string, owners []metav1.OwnerReference, podLabels map[string]string, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: podLabels, OwnerReferences: owners},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestServer_HandleNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	controller := true
+	rsOwner := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}}
+	ctrlClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-abc", Namespace: "shop",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+		}},
+		namespacePod("shop", "web-abc-1", rsOwner, map[string]string{"app": "web"}, "500m", "512Mi"),
+		namespacePod("shop", "web-abc-2", rsOwner, map[string]string{"app": "web"}, "500m", "512Mi"),
+		namespacePod("shop", "cache", nil, map[string]string{"app": "cache"}, "200m", "256Mi"),
+		namespacePod("batch", "job", nil, nil, "1", "1Gi"),
+		&v1alpha1.RightSizerRecommendation{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       v1alpha1.RightSizerRecommendationSpec{TargetRef: v1alpha1.RecommendationTargetRef{Kind: "Deployment", Name: "web"}},
+			Status: v1alpha1.RightSizerRecommendationStatus{ContainerRecommendations: []v1alpha1.ContainerRecommendation{{
+				ContainerName: "app",
+				Recommended: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("250m"),
+					corev1.ResourceMemory: resource.MustParse("256Mi"),
+				}},
+			}}},
+		},
+		&v1alpha1.RightSizerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-policy", Namespace: "shop"},
+			Spec: v1alpha1.RightSizerPolicySpec{
+				Enabled:   true,
+				TargetRef: v1alpha1.TargetReference{Kind: "Deployment", Names: []string{"web"}},
+			},
+		},
+		&v1alpha1.RightSizerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "disabled", Namespace: "batch"},
+			Spec:       v1alpha1.RightSizerPolicySpec{Enabled: false},
+		},
+	).Build()
+	podMetrics := &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-abc-1", Namespace: "shop"},
+		Containers: []metricsv1beta1.ContainerMetrics{{Name: "app", Usage: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("300m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		}}},
+	}}}
+	metricsClient := metricsclient.NewSimpleClientset()
+	metricsClient.PrependReactor("list", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, podMetrics, nil
+	})
+
+	server := NewServer(nil, metricsClient, ctrlClient, nil, nil)
+
+	w := httptest.NewRecorder()
+	server.handleNamespaces(w, httptest.NewRequest(http.MethodPost, "/api/namespaces", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	server.handleNamespaces(w, httptest.NewRequest(http.MethodGet, "/api/namespaces", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Namespaces []namespaceSummary `json:"namespaces"`
+		Total      int                `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, 2, response.Total)
+	assert.Equal(t, "batch", response.Namespaces[0].Namespace)
+	assert.Zero(t, response.Namespaces[0].PolicyCoverage.CoveredPods)
+
+	shop := response.Namespaces[1]
+	assert.Equal(t, "shop", shop.Namespace)
+	assert.Equal(t, 3, shop.Pods)
+	assert.Equal(t, 3, shop.Running)
+	assert.Equal(t, 1, shop.Recommendations)
+	assert.Equal(t, int64(1200), shop.CPU.Requests)
+	assert.Equal(t, int64(700), shop.CPU.Recommended)
+	assert.Equal(t, int64(300), shop.CPU.Usage)
+	assert.InDelta(t, 25.0, shop.CPU.Utilization, 0.01)
+	assert.Equal(t, int64(1280*1024*1024), shop.Memory.Requests)
+	assert.Equal(t, int64(768*1024*1024), shop.Memory.Recommended)
+	assert.Equal(t, int64(500), shop.Savings.CPU)
+	assert.Equal(t, int64(512*1024*1024), shop.Savings.Memory)
+	assert.Positive(t, shop.Savings.MonthlyCost)
+	assert.Equal(t, "estimate", shop.Savings.Source)
+	assert.Equal(t, 2, shop.PolicyCoverage.CoveredPods)
+	assert.InDelta(t, 66.67, shop.PolicyCoverage.Percent, 0.01)
+	assert.Equal(t, []string{"shop/web-policy"}, shop.PolicyCoverage.Policies)
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"right-sizer/logger"
+	"right-sizer/pause"
+)
+
+// SetPauseState sets the pause state /api/pause and /api/resume change
+func (s *Server) SetPauseState(state *pause.State) {
+	s.pauses = state
+}
+
+// pauseRequest is the body of /api/pause and /api/resume; an empty
+// namespace means the whole cluster
+type pauseRequest struct {
+	Namespace string `json:"namespace"`
+	Reason    string `json:"reason"`
+}
+
+// pauseResponse lists every pause in effect
+type pauseResponse struct {
+	Paused []pause.Entry `json:"paused"`
+}
+
+// handlePause pauses resizing of the cluster or a namespace, or lists the
+// pauses in effect.
+//
+//	GET  /api/pause
+//	POST /api/pause {"namespace": "payments", "reason": "INC-1234"}
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.pauses == nil {
+		http.Error(w, "Pausing not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		req, err := decodePauseRequest(r)
+		if err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		entry := s.pauses.Pause(req.Namespace, req.Reason)
+		if entry.Namespace == "" {
+			logger.Warn("⏸️  Resizing paused for the whole cluster: %s", entry.Reason)
+		} else {
+			logger.Warn("⏸️  Resizing paused for namespace %s: %s", entry.Namespace, entry.Reason)
+		}
+	}
+	s.writeJSONResponse(w, pauseResponse{Paused: s.pauses.List()})
+}
+
+// handleResume lifts a pause set through /api/pause. Namespaces and
+// workloads paused by annotation stay paused until it is removed.
+//
+//	POST /api/resume {"namespace": "payments"}
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.pauses == nil {
+		http.Error(w, "Pausing not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	req, err := decodePauseRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !s.pauses.Resume(req.Namespace) {
+		if req.Namespace == "" {
+			http.Error(w, "Cluster is not paused", http.StatusNotFound)
+		} else {
+			http.Error(w, "Namespace "+req.Namespace+" is not paused through the API", http.StatusNotFound)
+		}
+		return
+	}
+	if req.Namespace == "" {
+		logger.Info("▶️  Resizing resumed for the whole cluster")
+	} else {
+		logger.Info("▶️  Resizing resumed for namespace %s", req.Namespace)
+	}
+	s.writeJSONResponse(w, pauseResponse{Paused: s.pauses.List()})
+}
+
+// decodePauseRequest reads the optional body of a pause or resume request
+func decodePauseRequest(r *http.Request) (pauseRequest, error) {
+	var req pauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return req, err
+	}
+	return req, nil
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"right-sizer/pause"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandlePauseAndResume(t *testing.T) {
+	s := &Server{}
+	s.SetPauseState(pause.NewState(nil))
+
+	request := func(handler http.HandlerFunc, method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(method, "/", strings.NewReader(body)))
+		return w
+	}
+
+	w := request(s.handlePause, http.MethodPost, `{"namespace": "payments", "reason": "INC-1234"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var result pauseResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Paused, 1)
+	assert.Equal(t, "payments", result.Paused[0].Namespace)
+	assert.Equal(t, "INC-1234", result.Paused[0].Reason)
+
+	// An empty body pauses the whole cluster
+	assert.Equal(t, http.StatusOK, request(s.handlePause, http.MethodPost, "").Code)
+	w = request(s.handlePause, http.MethodGet, "")
+	var listed pauseResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Paused, 2)
+	assert.Equal(t, "", listed.Paused[0].Namespace)
+
+	assert.Equal(t, http.StatusOK, request(s.handleResume, http.MethodPost, "").Code)
+	assert.Equal(t, http.StatusOK, request(s.handleResume, http.MethodPost, `{"namespace": "payments"}`).Code)
+	assert.Equal(t, http.StatusNotFound, request(s.handleResume, http.MethodPost, `{"namespace": "payments"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, request(s.handlePause, http.MethodPost, "{").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(s.handleResume, http.MethodGet, "").Code)
+}
+
+func TestServer_HandlePauseWithoutState(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handlePause(w, httptest.NewRequest(http.MethodPost, "/api/pause", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,193 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// defaultPageSize applies when page is given without pageSize
+	defaultPageSize = 100
+	// maxPageSize caps limit and pageSize
+	maxPageSize = 1000
+
+	continueTokenPrefix = "offset:"
+)
+
+// podSortFields are the fields /api/pods can be sorted by
+var podSortFields = map[string]func(a, b *v1.Pod) int{
+	"name": func(a, b *v1.Pod) int { return strings.Compare(a.Name, b.Name) },
+	"namespace": func(a, b *v1.Pod) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	},
+	"status": func(a, b *v1.Pod) int { return strings.Compare(string(a.Status.Phase), string(b.Status.Phase)) },
+	"node":   func(a, b *v1.Pod) int { return strings.Compare(a.Spec.NodeName, b.Spec.NodeName) },
+	"age": func(a, b *v1.Pod) int {
+		// Oldest first, like kubectl --sort-by=.metadata.creationTimestamp
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	},
+	"restarts": func(a, b *v1.Pod) int { return podRestarts(a) - podRestarts(b) },
+}
+
+// podListQuery holds the filtering, sorting and pagination parameters of /api/pods
+type podListQuery struct {
+	namespace  string
+	selector   labels.Selector
+	sortBy     string
+	descending bool
+	offset     int
+	limit      int // 0 returns all pods from offset on
+	page       int // set when paginating with page/pageSize
+}
+
+// parsePodListQuery reads the query parameters of /api/pods:
+//
+//	?namespace=       only pods in the namespace
+//	?labelSelector=   only pods matching the label selector
+//	?sort=            name, namespace (default), status, node, age or restarts
+//	?order=           asc (default) or desc
+//	?limit=&continue= at most limit pods, continuing from a previous nextToken
+//	?page=&pageSize=  the page-th page (from 1) of pageSize pods
+func parsePodListQuery(values url.Values) (*podListQuery, error) {
+	q := &podListQuery{namespace: values.Get("namespace"), sortBy: "namespace"}
+
+	if raw := values.Get("labelSelector"); raw != "" {
+		selector, err := labels.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labelSelector: %w", err)
+		}
+		q.selector = selector
+	}
+
+	if sortBy := values.Get("sort"); sortBy != "" {
+		if _, ok := podSortFields[sortBy]; !ok {
+			return nil, fmt.Errorf("invalid sort %q", sortBy)
+		}
+		q.sortBy = sortBy
+	}
+	switch order := values.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		q.descending = true
+	default:
+		return nil, fmt.Errorf("invalid order %q", order)
+	}
+
+	if values.Has("page") || values.Has("pageSize") {
+		if values.Has("limit") || values.Has("continue") {
+			return nil, fmt.Errorf("page/pageSize cannot be combined with limit/continue")
+		}
+		page, err := positiveParam(values, "page", 1)
+		if err != nil {
+			return nil, err
+		}
+		pageSize, err := positiveParam(values, "pageSize", defaultPageSize)
+		if err != nil {
+			return nil, err
+		}
+		q.page, q.limit, q.offset = page, min(pageSize, maxPageSize), (page-1)*min(pageSize, maxPageSize)
+		return q, nil
+	}
+
+	if values.Has("limit") {
+		limit, err := positiveParam(values, "limit", 0)
+		if err != nil {
+			return nil, err
+		}
+		q.limit = min(limit, maxPageSize)
+	}
+	if token := values.Get("continue"); token != "" {
+		offset, err := decodeContinueToken(token)
+		if err != nil {
+			return nil, err
+		}
+		q.offset = offset
+	}
+	return q, nil
+}
+
+func positiveParam(values url.Values, name string, fallback int) (int, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", name, raw)
+	}
+	return value, nil
+}
+
+// apply sorts the pods and returns the requested window of them and the
+// token of the next window, empty on the last one
+func (q *podListQuery) apply(pods []v1.Pod) ([]v1.Pod, string) {
+	compare := podSortFields[q.sortBy]
+	sort.SliceStable(pods, func(i, j int) bool {
+		c := compare(&pods[i], &pods[j])
+		if q.descending {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	if q.offset >= len(pods) {
+		return []v1.Pod{}, ""
+	}
+	end := len(pods)
+	if q.limit > 0 && q.offset+q.limit < end {
+		end = q.offset + q.limit
+	}
+	next := ""
+	if end < len(pods) {
+		next = encodeContinueToken(end)
+	}
+	return pods[q.offset:end], next
+}
+
+// Continue tokens are opaque to clients; they encode the offset of the next pod
+func encodeContinueToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(continueTokenPrefix + strconv.Itoa(offset)))
+}
+
+func decodeContinueToken(token string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil && strings.HasPrefix(string(raw), continueTokenPrefix) {
+		if offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), continueTokenPrefix)); err == nil && offset >= 0 {
+			return offset, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid continue token")
+}
+
+func podRestarts(pod *v1.Pod) int {
+	restarts := 0
+	for _, cs := range pod.Status.ContainerStatuses {
+		restarts += int(cs.RestartCount)
+	}
+	return restarts
+}
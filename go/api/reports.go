@@ -0,0 +1,103 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"right-sizer/logger"
+	"right-sizer/reports"
+)
+
+// SetReportGenerator sets the generator /api/reports reads and generates reports with
+func (s *Server) SetReportGenerator(generator *reports.Generator) {
+	s.reports = generator
+}
+
+// handleReports returns the stored namespace reports, or the report of
+// ?namespace= alone
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.reports == nil {
+		http.Error(w, "Reports not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		report, err := s.reports.Get(r.Context(), namespace)
+		if err != nil {
+			logger.Error("Failed to read the report of namespace %s: %v", namespace, err)
+			http.Error(w, "Failed to read report", http.StatusInternalServerError)
+			return
+		}
+		if report == nil {
+			http.Error(w, "No report for namespace "+namespace, http.StatusNotFound)
+			return
+		}
+		s.writeJSONResponse(w, report)
+		return
+	}
+
+	list, err := s.reports.List(r.Context())
+	if err != nil {
+		logger.Error("Failed to list reports: %v", err)
+		http.Error(w, "Failed to list reports", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSONResponse(w, map[string]interface{}{
+		"reports": list,
+		"total":   len(list),
+	})
+}
+
+// handleGenerateReports generates the report of ?namespace= now, or those
+// of all namespaces without it, and returns them
+func (s *Server) handleGenerateReports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.reports == nil {
+		http.Error(w, "Reports not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if namespace := r.URL.Query().Get("namespace"); namespace != "" {
+		report, err := s.reports.GenerateAndStore(r.Context(), namespace, time.Now())
+		if err != nil {
+			logger.Error("Failed to generate the report of namespace %s: %v", namespace, err)
+			http.Error(w, "Failed to generate report", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSONResponse(w, report)
+		return
+	}
+
+	list, err := s.reports.GenerateAll(r.Context())
+	if err != nil {
+		logger.Error("Failed to generate reports: %v", err)
+		http.Error(w, "Failed to generate reports", http.StatusInternalServerError)
+		return
+	}
+	s.writeJSONResponse(w, map[string]interface{}{
+		"reports": list,
+		"total":   len(list),
+	})
+}
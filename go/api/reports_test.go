@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"right-sizer/config"
+	"right-sizer/reports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestServer_HandleReports(t *testing.T) {
+	config.Load()
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU: resource.MustParse("500m"),
+				}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+	)
+
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleReports(w, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	s.SetReportGenerator(reports.NewGenerator(clientset, nil, nil, nil, nil))
+
+	w = httptest.NewRecorder()
+	s.handleReports(w, httptest.NewRequest(http.MethodGet, "/api/reports?namespace=shop", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	s.handleGenerateReports(w, httptest.NewRequest(http.MethodGet, "/api/reports/generate", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	s.handleGenerateReports(w, httptest.NewRequest(http.MethodPost, "/api/reports/generate?namespace=shop", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	s.handleReports(w, httptest.NewRequest(http.MethodGet, "/api/reports?namespace=shop", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var report reports.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, "shop", report.Namespace)
+	assert.Equal(t, 1, report.Workloads)
+	assert.Equal(t, reports.NarrativeTemplate, report.NarrativeSource)
+
+	w = httptest.NewRecorder()
+	s.handleReports(w, httptest.NewRequest(http.MethodGet, "/api/reports", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Reports []reports.Report `json:"reports"`
+		Total   int              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Total)
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/efficiency"
+)
+
+// SetEfficiencyTracker sets the tracker /api/scores reports
+func (s *Server) SetEfficiencyTracker(tracker *efficiency.Tracker) {
+	s.efficiency = tracker
+}
+
+// handleScores returns the efficiency scores over the configured window:
+// the share of requested CPU and memory actually used, for the cluster,
+// every namespace and every workload.
+//
+//	?namespace=  only the namespace, its workloads and their containers
+func (s *Server) handleScores(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.efficiency == nil {
+		http.Error(w, "Efficiency scores not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	report := s.efficiency.Scores(time.Now(), config.Get().EfficiencyWindow)
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		// Per-container scores are only listed for a namespace to keep the response small
+		report.Containers = nil
+		s.writeJSONResponse(w, report)
+		return
+	}
+
+	filtered := efficiency.Report{Window: report.Window, Time: report.Time, Cluster: report.Cluster, Namespaces: []efficiency.NamespaceScore{}, Workloads: []efficiency.WorkloadScore{}, Containers: []efficiency.ContainerScore{}}
+	for _, ns := range report.Namespaces {
+		if ns.Namespace == namespace {
+			filtered.Namespaces = append(filtered.Namespaces, ns)
+		}
+	}
+	for _, wl := range report.Workloads {
+		if wl.Namespace == namespace {
+			filtered.Workloads = append(filtered.Workloads, wl)
+		}
+	}
+	for _, c := range report.Containers {
+		if c.Namespace == namespace {
+			filtered.Containers = append(filtered.Containers, c)
+		}
+	}
+	s.writeJSONResponse(w, filtered)
+}
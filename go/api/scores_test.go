@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/efficiency"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_HandleScores(t *testing.T) {
+	config.Load()
+	s := &Server{}
+
+	w := httptest.NewRecorder()
+	s.handleScores(w, httptest.NewRequest(http.MethodGet, "/api/scores", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	tracker := efficiency.NewTracker()
+	now := time.Now()
+	tracker.Observe(efficiency.Sample{Time: now, Namespace: "shop", Workload: "Deployment/web", Pod: "web-1", Container: "app", CPURequest: 1000, CPUUsage: 250})
+	tracker.Observe(efficiency.Sample{Time: now, Namespace: "dev", Workload: "Deployment/api", Pod: "api-1", Container: "app", CPURequest: 1000, CPUUsage: 750})
+	s.SetEfficiencyTracker(tracker)
+
+	w = httptest.NewRecorder()
+	s.handleScores(w, httptest.NewRequest(http.MethodPost, "/api/scores", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	s.handleScores(w, httptest.NewRequest(http.MethodGet, "/api/scores", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var report efficiency.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.InDelta(t, 50, report.Cluster.CPU, 0.01)
+	assert.Len(t, report.Namespaces, 2)
+	assert.Len(t, report.Workloads, 2)
+	assert.Empty(t, report.Containers)
+
+	w = httptest.NewRecorder()
+	s.handleScores(w, httptest.NewRequest(http.MethodGet, "/api/scores?namespace=shop", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	report = efficiency.Report{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	require.Len(t, report.Namespaces, 1)
+	assert.InDelta(t, 25, report.Namespaces[0].CPU, 0.01)
+	require.Len(t, report.Workloads, 1)
+	assert.Equal(t, "Deployment/web", report.Workloads[0].Workload)
+	require.Len(t, report.Containers, 1)
+	assert.Equal(t, "web-1", report.Containers[0].Pod)
+}
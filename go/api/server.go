@@ -21,6 +21,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -32,13 +33,23 @@ import (
 	"time"
 
 	"right-sizer/api/v1alpha1"
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/efficiency"
 	"right-sizer/events"
+	"right-sizer/explain"
+	"right-sizer/idle"
 	"right-sizer/logger"
 	"right-sizer/metrics"
+	"right-sizer/pause"
 	"right-sizer/predictor"
+	"right-sizer/reports"
+	"right-sizer/retry"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -58,6 +69,7 @@ const (
 	serverReadTimeout       = 120 * time.Second
 	serverWriteTimeout      = 120 * time.Second
 	serverIdleTimeout       = 180 * time.Second
+	serverShutdownTimeout   = 10 * time.Second
 
 	defaultEventLimit = 20
 	logTailLines      = 50
@@ -70,6 +82,12 @@ const (
 
 	cpuUsageSimulationFactor = 10
 	memUsageSimulationFactor = 5
+
+	// metricsSourceHeader tells clients of the metrics API proxy whether usage
+	// was measured by metrics-server or estimated without it
+	metricsSourceHeader   = "X-Metrics-Source"
+	metricsSourceServer   = "metrics-server"
+	metricsSourceEstimate = "estimate"
 )
 
 // Server represents the API server
@@ -80,7 +98,40 @@ type Server struct {
 	operatorMetrics       *metrics.OperatorMetrics
 	predictor             *predictor.Engine // Resource prediction engine
 	recommendationManager *events.RecommendationManager
-	optimizationOps       atomic.Uint64 // counts optimization actions applied
+	costClient            *cost.Client                   // prices savings from OpenCost/Kubecost when configured
+	eventBus              *events.EventBus               // source of /api/events/stream
+	auditStore            *audit.Store                   // source of /api/audit
+	explanations          *explain.Store                 // source of /api/workloads/{namespace}/{name}/explain
+	pauses                *pause.State                   // changed by /api/pause and /api/resume
+	reports               *reports.Generator             // source of /api/reports
+	retryHandler          *retry.RetryWithCircuitBreaker // source of /api/health/circuit
+	ioSource              metrics.IOSource               // network and disk throughput of /api/metrics
+	usageHistory          metrics.RangeProvider          // usage sparklines of /api/workloads/{namespace}/{kind}/{name}
+	efficiency            *efficiency.Tracker            // source of /api/scores and /api/insights/top
+	idle                  *idle.Detector                 // source of /api/idle-workloads
+	assessor              Assessor                       // sizes manifests posted to /api/validate
+	simulator             Simulator                      // replays settings posted to /api/simulate
+	optimizationOps       atomic.Uint64                  // counts optimization actions applied
+
+	mux        *http.ServeMux // endpoints, registered once by handler
+	muxOnce    sync.Once
+	cache      *responseCache // reuses list and metrics responses
+	limiter    *clientLimiter // limits the request rate of each client
+	httpMu     sync.Mutex
+	httpServer *http.Server // current listener, replaced when its settings change
+}
+
+// listenSettings are the settings that require a new listener to change
+type listenSettings struct {
+	port     int
+	authMode string
+	apiKey   string
+}
+
+// currentListenSettings reads the listener settings from the configuration
+func currentListenSettings() listenSettings {
+	port, authMode, apiKey := config.Get().APIServerSettings()
+	return listenSettings{port: port, authMode: authMode, apiKey: apiKey}
 }
 
 // MetricSample stores a historical aggregate sample for time range filtering
@@ -95,60 +146,13 @@ type MetricSample struct {
 	AvgUtilization     float64   `json:"utilization"`
 }
 
-var (
-	metricsHistory      []MetricSample
-	metricsHistoryLimit = 2000
-	metricsHistoryMu    sync.Mutex
-)
-
-// filterMetricsHistory returns a copy of the stored history optionally
-// filtered by a simple time range string: 1h,6h,12h,24h,7d,14d,30d.
-// Unknown / empty range returns the full (bounded) history.
-func filterMetricsHistory(rangeParam string) []MetricSample {
-	if rangeParam == "" {
-		metricsHistoryMu.Lock()
-		defer metricsHistoryMu.Unlock()
-		cp := make([]MetricSample, len(metricsHistory))
-		copy(cp, metricsHistory)
-		return cp
-	}
-
-	now := time.Now()
-	var window time.Duration
-	switch rangeParam {
-	case "1h":
-		window = time.Hour
-	case "6h":
-		window = hour6
-	case "12h":
-		window = hour12
-	case "24h":
-		window = hour24
-	case "7d":
-		window = day7
-	case "14d":
-		window = day14
-	case "30d":
-		window = day30
-	default:
-		metricsHistoryMu.Lock()
-		defer metricsHistoryMu.Unlock()
-		cp := make([]MetricSample, len(metricsHistory))
-		copy(cp, metricsHistory)
-		return cp
-	}
-
-	cutoff := now.Add(-window)
+// metricsHistoryLimit caps the samples kept at full resolution
+const metricsHistoryLimit = 2000
 
-	metricsHistoryMu.Lock()
-	defer metricsHistoryMu.Unlock()
-	out := make([]MetricSample, 0, len(metricsHistory))
-	for _, s := range metricsHistory {
-		if s.Time.After(cutoff) {
-			out = append(out, s)
-		}
-	}
-	return out
+// SetIOSource sets the source of the network and disk throughput reported
+// by /api/metrics
+func (s *Server) SetIOSource(source metrics.IOSource) {
+	s.ioSource = source
 }
 
 // NewServer creates a new API server instance
@@ -164,78 +168,228 @@ func NewServer(clientset kubernetes.Interface, metricsClient metricsclient.Inter
 		operatorMetrics:       m,
 		predictor:             predictor,
 		recommendationManager: recommendationManager,
+		costClient:            cost.NewClient(),
 	}
 }
 
-// Start starts the API server
+// Start starts the API server on port and blocks until it stops
 func (s *Server) Start(port int) error {
-	logger.Info("🌐 Starting API server on port %d", port)
+	settings := currentListenSettings()
+	settings.port = port
+	return <-s.listen(settings)
+}
+
+// Run serves the API on the configured port until ctx is done, then shuts
+// it down gracefully. Each signal on reload re-reads the port and
+// authentication settings and restarts the listener when they changed.
+func (s *Server) Run(ctx context.Context, reload <-chan struct{}) error {
+	settings := currentListenSettings()
+	errs := s.listen(settings)
+	for {
+		select {
+		case <-ctx.Done():
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+			defer cancel()
+			return s.Shutdown(shutdownCtx)
+		case err := <-errs:
+			return err
+		case <-reload:
+			next := currentListenSettings()
+			if next == settings {
+				continue
+			}
+			logger.Info("🔄 Restarting API server: port %d -> %d, authentication %s -> %s",
+				settings.port, next.port, settings.authMode, next.authMode)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				logger.Warn("API server did not shut down cleanly: %v", err)
+			}
+			cancel()
+			if err := <-errs; err != nil && !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			settings = next
+			errs = s.listen(settings)
+		}
+	}
+}
+
+// Shutdown gracefully stops the API server, closing the connections that
+// are still active when ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.httpMu.Lock()
+	server := s.httpServer
+	s.httpServer = nil
+	s.httpMu.Unlock()
+	if server == nil {
+		return nil
+	}
 
-	// Register all endpoints
-	s.registerEndpoints()
+	logger.Info("🛑 Shutting down API server")
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return err
+	}
+	return nil
+}
+
+// listen starts a listener with settings and returns the channel that
+// receives its result once it stops
+func (s *Server) listen(settings listenSettings) <-chan error {
+	logger.Info("🌐 Starting API server on port %d", settings.port)
+
+	if settings.authMode == AuthModeNone {
+		logger.Warn("⚠️  API server authentication is disabled; set API_AUTH_MODE to kubernetes or apikey")
+	} else {
+		logger.Info("🔐 API server authentication mode: %s", settings.authMode)
+	}
+	auth := NewAuthenticator(s.clientset, settings.authMode, settings.apiKey)
+	mux := s.handler()
 
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", port),
+		Addr:              fmt.Sprintf(":%d", settings.port),
+		Handler:           auth.Wrap(s.limiter.Wrap(s.cache.Wrap(mux))),
 		ReadHeaderTimeout: serverReadHeaderTimeout,
 		ReadTimeout:       serverReadTimeout,
 		WriteTimeout:      serverWriteTimeout,
 		IdleTimeout:       serverIdleTimeout,
 	}
+	s.httpMu.Lock()
+	s.httpServer = server
+	s.httpMu.Unlock()
 
-	logger.Info("✅ API server started on port %d", port)
-	return server.ListenAndServe()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	logger.Info("✅ API server started on port %d", settings.port)
+	return errs
+}
+
+// handler returns the endpoint mux, registering the endpoints and creating
+// the response cache and rate limiter on first use
+func (s *Server) handler() http.Handler {
+	s.muxOnce.Do(func() {
+		cfg := config.Get()
+		s.cache = newResponseCache(cfg.APICacheTTL)
+		s.limiter = newClientLimiter(cfg.APIRateLimit, cfg.APIRateBurst)
+		s.mux = http.NewServeMux()
+		s.registerEndpoints()
+	})
+	return s.mux
+}
+
+// listPods lists pods from the informer cache of the manager's client when
+// available, falling back to the Kubernetes API
+func (s *Server) listPods(ctx context.Context, namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	if s.ctrlClient != nil {
+		var opts []client.ListOption
+		if namespace != "" {
+			opts = append(opts, client.InNamespace(namespace))
+		}
+		if selector != nil {
+			opts = append(opts, client.MatchingLabelsSelector{Selector: selector})
+		}
+		podList := &v1.PodList{}
+		if err := s.ctrlClient.List(ctx, podList, opts...); err != nil {
+			return nil, err
+		}
+		return podList.Items, nil
+	}
+
+	listOptions := metav1.ListOptions{}
+	if selector != nil {
+		listOptions.LabelSelector = selector.String()
+	}
+	podList, err := s.clientset.CoreV1().Pods(namespace).List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+// listNodes lists nodes like listPods
+func (s *Server) listNodes(ctx context.Context) ([]v1.Node, error) {
+	if s.ctrlClient != nil {
+		nodeList := &v1.NodeList{}
+		if err := s.ctrlClient.List(ctx, nodeList); err != nil {
+			return nil, err
+		}
+		return nodeList.Items, nil
+	}
+
+	nodeList, err := s.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return nodeList.Items, nil
 }
 
 // registerEndpoints registers all HTTP endpoints
 func (s *Server) registerEndpoints() {
 	// Basic endpoints
-	http.HandleFunc("/api/pods/count", s.handlePodCount)
-	http.HandleFunc("/api/health", s.handleHealth)
+	s.mux.HandleFunc("/api/pods/count", s.handlePodCount)
+	s.mux.HandleFunc("/api/health", s.handleHealth)
+	s.mux.HandleFunc("/api/health/circuit", s.handleCircuitHealth)
 
 	// Metrics endpoints
-	http.HandleFunc("/api/metrics", s.handleMetrics)
-	http.HandleFunc("/api/metrics/history", s.handleMetricsHistory) // NEW: historical samples
-	http.HandleFunc("/api/metrics/live", s.handleMetricsLive)       // NEW: live JSON cluster summary
+	s.mux.HandleFunc("/api/metrics", s.handleMetrics)
+	s.mux.HandleFunc("/api/metrics/history", s.handleMetricsHistory) // NEW: historical samples
+	s.mux.HandleFunc("/api/metrics/live", s.handleMetricsLive)       // NEW: live JSON cluster summary
 
 	// Prediction endpoints
-	http.HandleFunc("/api/predictions", s.handlePredictions)               // NEW: get predictions for resources
-	http.HandleFunc("/api/predictions/historical", s.handleHistoricalData) // NEW: get historical data
-	http.HandleFunc("/api/predictions/stats", s.handlePredictionStats)     // NEW: prediction engine stats
+	s.mux.HandleFunc("/api/predictions", s.handlePredictions)               // NEW: get predictions for resources
+	s.mux.HandleFunc("/api/predictions/historical", s.handleHistoricalData) // NEW: get historical data
+	s.mux.HandleFunc("/api/predictions/stats", s.handlePredictionStats)     // NEW: prediction engine stats
 
 	// Optimization events
-	http.HandleFunc("/api/optimization-events", s.handleOptimizationEvents)
-	http.HandleFunc("/api/recommendations", s.handleGetRecommendations)
-	http.HandleFunc("/api/recommendations/stats/summary", s.handleGetRecommendationStats)
-	http.HandleFunc("/api/recommendations/approve", s.handleApproveRecommendation)
-	http.HandleFunc("/api/recommendations/reject", s.handleRejectRecommendation)
-	http.HandleFunc("/api/recommendations/execute", s.handleExecuteRecommendation)
-	http.HandleFunc("/api/recommendations/", s.handleRecommendationByID)
+	s.mux.HandleFunc("/api/optimization-events", s.handleOptimizationEvents)
+	s.mux.HandleFunc("/api/events/stream", s.handleEventStream)
+	s.mux.HandleFunc("/api/audit", s.handleAudit)
+	s.mux.HandleFunc("/api/workloads/", s.handleWorkloads)
+	s.mux.HandleFunc("/api/namespaces", s.handleNamespaces)
+	s.mux.HandleFunc("/api/scores", s.handleScores)
+	s.mux.HandleFunc("/api/idle-workloads", s.handleIdleWorkloads)
+	s.mux.HandleFunc("/api/insights/top", s.handleInsightsTop)
+	s.mux.HandleFunc("/api/reports", s.handleReports)
+	s.mux.HandleFunc("/api/reports/generate", s.handleGenerateReports)
+	s.mux.HandleFunc("/api/dashboards/grafana", s.handleGrafanaDashboard)
+	s.mux.HandleFunc("/api/validate", s.handleValidate)
+	s.mux.HandleFunc("/api/simulate", s.handleSimulate)
+	s.mux.HandleFunc("/api/pause", s.handlePause)
+	s.mux.HandleFunc("/api/resume", s.handleResume)
+	s.mux.HandleFunc("/api/recommendations", s.handleGetRecommendations)
+	s.mux.HandleFunc("/api/recommendations/stats/summary", s.handleGetRecommendationStats)
+	s.mux.HandleFunc("/api/recommendations/approve", s.handleApproveRecommendation)
+	s.mux.HandleFunc("/api/recommendations/reject", s.handleRejectRecommendation)
+	s.mux.HandleFunc("/api/recommendations/execute", s.handleExecuteRecommendation)
+	s.mux.HandleFunc("/api/recommendations/", s.handleRecommendationByID)
 
 	// Proxy endpoints for metrics API
-	http.HandleFunc("/apis/metrics.k8s.io/v1beta1/nodes", s.handleNodesProxy)
-	http.HandleFunc("/apis/metrics.k8s.io/v1beta1/pods", s.handlePodsProxy)
+	s.mux.HandleFunc("/apis/metrics.k8s.io/v1beta1/nodes", s.handleNodesProxy)
+	s.mux.HandleFunc("/apis/metrics.k8s.io/v1beta1/pods", s.handlePodsProxy)
 
 	// Pod data endpoints
-	http.HandleFunc("/api/pods", s.handlePods)
-	http.HandleFunc("/api/pods/system", s.handleSystemPods) // NEW: system namespaces only
-	http.HandleFunc("/api/v1/pods", s.handlePodsV1)
-	http.HandleFunc("/apis/v1/pods", s.handlePodsRedirect)
+	s.mux.HandleFunc("/api/pods", s.handlePods)
+	s.mux.HandleFunc("/api/pods/system", s.handleSystemPods) // NEW: system namespaces only
+	s.mux.HandleFunc("/api/v1/pods", s.handlePodsV1)
+	s.mux.HandleFunc("/apis/v1/pods", s.handlePodsRedirect)
 
 	// System / support (version & capability baseline)
-	http.HandleFunc("/api/system/support", s.handleSystemSupport)
+	s.mux.HandleFunc("/api/system/support", s.handleSystemSupport)
 
 	// AIOps incidents (basic placeholder listing)
-	http.HandleFunc("/api/aiops/incidents", s.handleIncidents)
+	s.mux.HandleFunc("/api/aiops/incidents", s.handleIncidents)
 
 	// Health check
-	http.HandleFunc("/health", s.handleHealthCheck)
+	s.mux.HandleFunc("/health", s.handleHealthCheck)
 
 	// Log streaming
-	http.HandleFunc("/api/logs", s.handleLogs)
+	s.mux.HandleFunc("/api/logs", s.handleLogs)
 
 	// Policy management
-	http.HandleFunc("/api/policies", s.handlePolicies)
-	http.HandleFunc("/api/policies/", s.handlePolicy)
+	s.mux.HandleFunc("/api/policies", s.handlePolicies)
+	s.mux.HandleFunc("/api/policies/", s.handlePolicy)
 }
 
 // handleSystemSupport returns a minimal support policy payload.
@@ -325,14 +479,14 @@ func (s *Server) handleIncidents(w http.ResponseWriter, r *http.Request) {
 
 // handlePodCount handles /api/pods/count endpoint
 func (s *Server) handlePodCount(w http.ResponseWriter, r *http.Request) {
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	pods, err := s.listPods(r.Context(), "", nil)
 	if err != nil {
 		logger.Error("Failed to get pod count: %v", err)
 		http.Error(w, "Failed to get pod count", http.StatusInternalServerError)
 		return
 	}
 
-	podCount := len(podList.Items)
+	podCount := len(pods)
 	response := map[string]int{"count": podCount}
 
 	s.writeJSONResponse(w, response)
@@ -352,34 +506,29 @@ func (s *Server) handleMetricsLive(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Collect fresh pod & node info
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	pods, err := s.listPods(r.Context(), "", nil)
 	if err != nil {
 		http.Error(w, "failed to collect pods", http.StatusInternalServerError)
 		return
 	}
-	nodeList, err := s.clientset.CoreV1().Nodes().List(r.Context(), metav1.ListOptions{})
+	nodes, err := s.listNodes(r.Context())
 	if err != nil {
 		http.Error(w, "failed to collect nodes", http.StatusInternalServerError)
 		return
 	}
 
-	cluster := s.calculateClusterMetrics(podList.Items, nodeList.Items)
+	cluster := s.calculateClusterMetrics(r.Context(), pods, nodes)
 
 	// Fetch latest aggregated sample (if any) from in‑memory history
 	var latest *MetricSample
-	metricsHistoryMu.Lock()
-	if len(metricsHistory) > 0 {
-		sample := metricsHistory[len(metricsHistory)-1]
-		// copy to avoid race on underlying slice references
-		tmp := sample
-		latest = &tmp
+	if sample, ok := metricsHistory.latest(); ok {
+		latest = &sample
 	}
-	metricsHistoryMu.Unlock()
 
 	resp := map[string]interface{}{
 		"cluster":       cluster,
 		"latestSample":  latest,
-		"historyLength": len(metricsHistory),
+		"historyLength": len(metricsHistory.all()),
 		"timestamp":     time.Now().UTC().Format(time.RFC3339),
 	}
 
@@ -400,21 +549,21 @@ func (s *Server) handleMetricsLive(w http.ResponseWriter, r *http.Request) {
 // We emit a minimal set of gauge metrics consumed by the React UI and also
 // maintain an in‑memory history slice that the server could expose later if needed.
 func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	pods, err := s.listPods(r.Context(), "", nil)
 	if err != nil {
 		logger.Error("Failed to get pods for metrics: %v", err)
 		http.Error(w, "failed to collect pods", http.StatusInternalServerError)
 		return
 	}
 
-	nodeList, err := s.clientset.CoreV1().Nodes().List(r.Context(), metav1.ListOptions{})
+	nodes, err := s.listNodes(r.Context())
 	if err != nil {
 		logger.Error("Failed to get nodes for metrics: %v", err)
 		http.Error(w, "failed to collect nodes", http.StatusInternalServerError)
 		return
 	}
 
-	cluster := s.calculateClusterMetrics(podList.Items, nodeList.Items)
+	cluster := s.calculateClusterMetrics(r.Context(), pods, nodes)
 
 	// Extract numeric percentages from strings like "23.4%"
 	parsePercent := func(v interface{}) float64 {
@@ -467,9 +616,16 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		optimized = maxInt // clamp to max int on overflow
 	}
 
-	// Simulated / placeholder values for network & disk for now
+	// Network and disk stay at zero when the metrics provider does not report I/O
 	network := 0.0
 	diskIO := 0.0
+	if s.ioSource != nil {
+		if io, err := s.ioSource.FetchClusterIO(r.Context()); err == nil {
+			network, diskIO = io.NetworkMbps, io.DiskIOMBps
+		} else {
+			logger.Debug("Cluster I/O unavailable: %v", err)
+		}
+	}
 
 	avgUtil := 0.0
 	if cpuUtil > 0 || memUtil > 0 {
@@ -500,13 +656,8 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
-	// Persist history (trim if exceeds limit)
-	metricsHistoryMu.Lock()
-	metricsHistory = append(metricsHistory, sample)
-	if len(metricsHistory) > metricsHistoryLimit {
-		metricsHistory = metricsHistory[len(metricsHistory)-metricsHistoryLimit:]
-	}
-	metricsHistoryMu.Unlock()
+	// Record the sample in the downsampled history
+	metricsHistory.add(sample)
 
 	// Prometheus exposition format
 	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
@@ -526,11 +677,11 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "# TYPE rightsizer_optimized_resources_total gauge\n")
 	fmt.Fprintf(w, "rightsizer_optimized_resources_total %.0f\n", sample.OptimizedResources)
 
-	fmt.Fprintf(w, "# HELP rightsizer_network_usage_mbps Estimated aggregate network usage (simulated)\n")
+	fmt.Fprintf(w, "# HELP rightsizer_network_usage_mbps Aggregate network throughput of all pods, received plus transmitted\n")
 	fmt.Fprintf(w, "# TYPE rightsizer_network_usage_mbps gauge\n")
 	fmt.Fprintf(w, "rightsizer_network_usage_mbps %.3f\n", sample.NetworkUsageMbps)
 
-	fmt.Fprintf(w, "# HELP rightsizer_disk_io_mbps Estimated aggregate disk IO MB/s (simulated)\n")
+	fmt.Fprintf(w, "# HELP rightsizer_disk_io_mbps Aggregate disk throughput of all pods in MB/s, read plus written\n")
 	fmt.Fprintf(w, "# TYPE rightsizer_disk_io_mbps gauge\n")
 	fmt.Fprintf(w, "rightsizer_disk_io_mbps %.3f\n", sample.DiskIOMBps)
 
@@ -540,7 +691,7 @@ func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
 }
 
 // calculateClusterMetrics calculates comprehensive cluster metrics
-func (s *Server) calculateClusterMetrics(pods []v1.Pod, nodes []v1.Node) map[string]interface{} {
+func (s *Server) calculateClusterMetrics(ctx context.Context, pods []v1.Pod, nodes []v1.Node) map[string]interface{} {
 	// Calculate comprehensive metrics
 	var totalCPURequests, totalMemoryRequests int64
 	var totalCPULimits, totalMemoryLimits int64
@@ -601,6 +752,14 @@ func (s *Server) calculateClusterMetrics(pods []v1.Pod, nodes []v1.Node) map[str
 		}
 	}
 
+	// Price the potential savings with the cost provider's node prices when one is configured
+	pricing := cost.EstimatedPricing()
+	if s.costClient != nil {
+		pricing = s.costClient.Pricing(ctx)
+	}
+	savingsCPU := int64(float64(totalCPURequests) * cpuSavingsFactor)
+	savingsMemory := int64(float64(totalMemoryRequests) * memSavingsFactor)
+
 	metrics := map[string]interface{}{
 		"totalPods":          len(pods),
 		"totalNodes":         len(nodes),
@@ -625,10 +784,12 @@ func (s *Server) calculateClusterMetrics(pods []v1.Pod, nodes []v1.Node) map[str
 			"podsWithoutRequests": podsWithoutRequests,
 			"podsWithoutLimits":   podsWithoutLimits,
 			"potentialSavings": map[string]interface{}{
-				"cpu":    fmt.Sprintf("%.0fm", float64(totalCPURequests)*cpuSavingsFactor), // Assume 30% savings potential
-				"memory": fmt.Sprintf("%.0fMi", float64(totalMemoryRequests)*memSavingsFactor/(mbFactor)),
+				"cpu":         fmt.Sprintf("%dm", savingsCPU), // Assume 30% savings potential
+				"memory":      fmt.Sprintf("%.0fMi", float64(savingsMemory)/(mbFactor)),
+				"monthlyCost": pricing.MonthlyCost(savingsCPU, savingsMemory),
 			},
 		},
+		"cost":      pricing,
 		"timestamp": time.Now().Unix(),
 	}
 
@@ -672,10 +833,30 @@ func (s *Server) getOptimizationEvents(ctx context.Context) []map[string]interfa
 	return events
 }
 
-// getEventsFromAuditLog reads events from audit log file
+// getEventsFromAuditLog reads the latest resource changes from the audit store,
+// or from the audit log file when the store is disabled
 func (s *Server) getEventsFromAuditLog() []map[string]interface{} {
 	events := []map[string]interface{}{}
 
+	if s.auditStore != nil {
+		result, err := s.auditStore.Query(audit.Query{EventType: "ResourceChange", Limit: logTailLines})
+		if err != nil {
+			logger.Warn("Failed to query audit store: %v", err)
+			return events
+		}
+		for _, auditEvent := range result.Events {
+			data, err := json.Marshal(auditEvent)
+			if err != nil {
+				continue
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal(data, &fields); err == nil {
+				events = append(events, s.convertAuditEvent(fields))
+			}
+		}
+		return events
+	}
+
 	auditLogPath := "/tmp/right-sizer-audit.log"
 	file, err := os.Open(auditLogPath)
 	if err != nil {
@@ -909,28 +1090,71 @@ func (s *Server) sortAndLimitEvents(events *[]map[string]interface{}, limit int)
 	}
 }
 
-// handleNodesProxy handles /apis/metrics.k8s.io/v1beta1/nodes endpoint
+// handleNodesProxy handles /apis/metrics.k8s.io/v1beta1/nodes endpoint. Usage
+// comes from metrics-server; without it, node capacity is returned as an
+// estimate.
 func (s *Server) handleNodesProxy(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	nodeList, err := s.clientset.CoreV1().Nodes().List(r.Context(), metav1.ListOptions{})
+	if s.metricsClient != nil {
+		nodeMetrics, err := s.metricsClient.MetricsV1beta1().NodeMetricses().List(r.Context(), metav1.ListOptions{})
+		if err == nil {
+			w.Header().Set(metricsSourceHeader, metricsSourceServer)
+			s.writeJSONResponse(w, convertNodeMetricsToMetricsAPI(nodeMetrics.Items))
+			return
+		}
+		logger.Debug("Node metrics unavailable, estimating node usage: %v", err)
+	}
+
+	nodes, err := s.listNodes(r.Context())
 	if err != nil {
 		logger.Error("Failed to get nodes for proxy: %v", err)
 		http.Error(w, "Failed to get nodes", http.StatusInternalServerError)
 		return
 	}
 
-	response := s.convertNodesToMetricsAPI(nodeList.Items)
+	response := s.convertNodesToMetricsAPI(nodes)
+	w.Header().Set(metricsSourceHeader, metricsSourceEstimate)
 	s.writeJSONResponse(w, response)
 }
 
-// convertNodesToMetricsAPI converts nodes to metrics API format
+// convertNodeMetricsToMetricsAPI converts node metrics from metrics-server to
+// metrics API format
+func convertNodeMetricsToMetricsAPI(nodeMetrics []metricsv1beta1.NodeMetrics) map[string]interface{} {
+	items := []map[string]interface{}{}
+	for _, nm := range nodeMetrics {
+		items = append(items, map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": nm.Name,
+			},
+			"timestamp": nm.Timestamp.UTC().Format(time.RFC3339),
+			"window":    nm.Window.Duration.String(),
+			"usage": map[string]interface{}{
+				"cpu":    nm.Usage.Cpu().String(),
+				"memory": nm.Usage.Memory().String(),
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"kind":       "NodeMetricsList",
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"metadata":   map[string]interface{}{},
+		"estimated":  false,
+		"items":      items,
+	}
+}
+
+// convertNodesToMetricsAPI converts nodes to metrics API format when
+// metrics-server is absent. Node capacity stands in for usage, and the list
+// is marked as estimated.
 func (s *Server) convertNodesToMetricsAPI(nodes []v1.Node) map[string]interface{} {
 	response := map[string]interface{}{
 		"kind":       "NodeMetricsList",
 		"apiVersion": "metrics.k8s.io/v1beta1",
 		"metadata":   map[string]interface{}{},
+		"estimated":  true,
 		"items":      []map[string]interface{}{},
 	}
 
@@ -952,28 +1176,78 @@ func (s *Server) convertNodesToMetricsAPI(nodes []v1.Node) map[string]interface{
 	return response
 }
 
-// handlePodsProxy handles /apis/metrics.k8s.io/v1beta1/pods endpoint
+// handlePodsProxy handles /apis/metrics.k8s.io/v1beta1/pods endpoint. Usage
+// comes from metrics-server; without it, usage is estimated from requests.
 func (s *Server) handlePodsProxy(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	if s.metricsClient != nil {
+		podMetrics, err := s.metricsClient.MetricsV1beta1().PodMetricses("").List(r.Context(), metav1.ListOptions{})
+		if err == nil {
+			w.Header().Set(metricsSourceHeader, metricsSourceServer)
+			s.writeJSONResponse(w, convertPodMetricsToMetricsAPI(podMetrics.Items))
+			return
+		}
+		logger.Debug("Pod metrics unavailable, estimating pod usage: %v", err)
+	}
+
+	pods, err := s.listPods(r.Context(), "", nil)
 	if err != nil {
 		logger.Error("Failed to get pods for proxy: %v", err)
 		http.Error(w, "Failed to get pods", http.StatusInternalServerError)
 		return
 	}
 
-	response := s.convertPodsToMetricsAPI(podList.Items)
+	response := s.convertPodsToMetricsAPI(pods)
+	w.Header().Set(metricsSourceHeader, metricsSourceEstimate)
 	s.writeJSONResponse(w, response)
 }
 
-// convertPodsToMetricsAPI converts pods to metrics API format
+// convertPodMetricsToMetricsAPI converts pod metrics from metrics-server to
+// metrics API format
+func convertPodMetricsToMetricsAPI(podMetrics []metricsv1beta1.PodMetrics) map[string]interface{} {
+	items := []map[string]interface{}{}
+	for _, pm := range podMetrics {
+		containers := []map[string]interface{}{}
+		for _, container := range pm.Containers {
+			containers = append(containers, map[string]interface{}{
+				"name": container.Name,
+				"usage": map[string]interface{}{
+					"cpu":    container.Usage.Cpu().String(),
+					"memory": container.Usage.Memory().String(),
+				},
+			})
+		}
+		items = append(items, map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":      pm.Name,
+				"namespace": pm.Namespace,
+			},
+			"timestamp":  pm.Timestamp.UTC().Format(time.RFC3339),
+			"window":     pm.Window.Duration.String(),
+			"containers": containers,
+		})
+	}
+
+	return map[string]interface{}{
+		"kind":       "PodMetricsList",
+		"apiVersion": "metrics.k8s.io/v1beta1",
+		"metadata":   map[string]interface{}{},
+		"estimated":  false,
+		"items":      items,
+	}
+}
+
+// convertPodsToMetricsAPI converts pods to metrics API format when
+// metrics-server is absent. Usage is estimated as a fixed share of each
+// container's requests, and the list is marked as estimated.
 func (s *Server) convertPodsToMetricsAPI(pods []v1.Pod) map[string]interface{} {
 	response := map[string]interface{}{
 		"kind":       "PodMetricsList",
 		"apiVersion": "metrics.k8s.io/v1beta1",
 		"metadata":   map[string]interface{}{},
+		"estimated":  true,
 		"items":      []map[string]interface{}{},
 	}
 
@@ -1022,15 +1296,43 @@ func (s *Server) handlePods(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	// Without parameters the full list is returned as a bare array, as before
+	// pagination was added
+	legacy := r.URL.RawQuery == ""
+	query, err := parsePodListQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pods, err := s.listPods(r.Context(), query.namespace, query.selector)
 	if err != nil {
 		logger.Error("Failed to get pods: %v", err)
 		http.Error(w, "Failed to get pods", http.StatusInternalServerError)
 		return
 	}
 
-	pods := s.buildEnhancedPodData(r.Context(), podList.Items)
-	s.writeJSONResponse(w, pods)
+	if legacy {
+		s.writeJSONResponse(w, s.buildEnhancedPodData(r.Context(), pods))
+		return
+	}
+
+	// Only the pods of the requested page are enriched with metrics
+	window, nextToken := query.apply(pods)
+	response := map[string]interface{}{
+		"items": s.buildEnhancedPodData(r.Context(), window),
+		"total": len(pods),
+	}
+	if query.limit > 0 {
+		response["limit"] = query.limit
+	}
+	if query.page > 0 {
+		response["page"] = query.page
+	}
+	if nextToken != "" {
+		response["nextToken"] = nextToken
+	}
+	s.writeJSONResponse(w, response)
 }
 
 // buildEnhancedPodData builds enhanced pod data
@@ -1163,14 +1465,14 @@ func (s *Server) handlePodsV1(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	pods, err := s.listPods(r.Context(), "", nil)
 	if err != nil {
 		logger.Error("Failed to get pods for proxy: %v", err)
 		http.Error(w, "Failed to get pods", http.StatusInternalServerError)
 		return
 	}
 
-	response := s.convertPodsToV1API(podList.Items)
+	response := s.convertPodsToV1API(pods)
 	s.writeJSONResponse(w, response)
 }
 
@@ -1253,7 +1555,8 @@ func (s *Server) handleHealthCheck(w http.ResponseWriter, _ *http.Request) {
 
 // handleMetricsHistory returns historical aggregate metric samples collected
 // by handleMetrics. Optional query param "range" may be one of:
-// 1h,6h,12h,24h,7d,14d,30d
+// 1h,6h,12h,24h,7d,14d,30d. Samples older than an hour are averaged into
+// 1m, 5m or hourly buckets depending on the range.
 // Response JSON format:
 //
 //	{
@@ -1312,7 +1615,7 @@ func (s *Server) handleSystemPods(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	podList, err := s.clientset.CoreV1().Pods("").List(r.Context(), metav1.ListOptions{})
+	pods, err := s.listPods(r.Context(), "", nil)
 	if err != nil {
 		logger.Error("Failed to get system pods: %v", err)
 		http.Error(w, "Failed to get system pods", http.StatusInternalServerError)
@@ -1327,7 +1630,7 @@ func (s *Server) handleSystemPods(w http.ResponseWriter, r *http.Request) {
 	}
 
 	results := []map[string]interface{}{}
-	for _, pod := range podList.Items {
+	for _, pod := range pods {
 		if !systemNamespaces[pod.Namespace] {
 			continue
 		}
@@ -3,17 +3,26 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"right-sizer/config"
+	"right-sizer/cost"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned/fake"
 )
 
@@ -196,7 +205,7 @@ func TestServer_CalculateClusterMetrics(t *testing.T) {
 	pods := []v1.Pod{*pod1, *pod2}
 	nodes := []v1.Node{*node}
 
-	metrics := server.calculateClusterMetrics(pods, nodes)
+	metrics := server.calculateClusterMetrics(context.Background(), pods, nodes)
 
 	assert.NotNil(t, metrics)
 	assert.Equal(t, 2, metrics["totalPods"])
@@ -216,6 +225,13 @@ func TestServer_CalculateClusterMetrics(t *testing.T) {
 	assert.Contains(t, memory["totalLimits"], "Mi")
 	assert.Contains(t, memory["nodeCapacity"], "Mi")
 	assert.Contains(t, memory["utilization"], "%")
+
+	// Without a cost provider savings are priced with the default prices
+	pricing := metrics["cost"].(*cost.Pricing)
+	assert.Equal(t, cost.SourceEstimate, pricing.Source)
+	savings := metrics["optimization"].(map[string]interface{})["potentialSavings"].(map[string]interface{})
+	assert.Equal(t, "45m", savings["cpu"])
+	assert.InDelta(t, pricing.MonthlyCost(45, 192*1024*1024*3/10), savings["monthlyCost"], 1e-9)
 }
 
 func TestServer_ConvertPodsToMetricsAPI(t *testing.T) {
@@ -297,9 +313,7 @@ func TestServer_ConvertNodesToMetricsAPI(t *testing.T) {
 
 func TestServer_FilterMetricsHistory(t *testing.T) {
 	// Clear existing history
-	metricsHistoryMu.Lock()
-	metricsHistory = nil
-	metricsHistoryMu.Unlock()
+	metricsHistory.reset()
 
 	// Add test samples
 	now := time.Now()
@@ -308,10 +322,7 @@ func TestServer_FilterMetricsHistory(t *testing.T) {
 		{Time: now.Add(-2 * time.Hour), CPUUsagePercent: 20.0},    // Within 24 hours but not 1 hour
 		{Time: now.Add(-25 * time.Hour), CPUUsagePercent: 30.0},   // Outside 24 hours
 	}
-
-	metricsHistoryMu.Lock()
-	metricsHistory = append(metricsHistory, samples...)
-	metricsHistoryMu.Unlock()
+	metricsHistory.add(samples...)
 
 	tests := []struct {
 		name        string
@@ -334,7 +345,7 @@ func TestServer_FilterMetricsHistory(t *testing.T) {
 			name:        "24h range",
 			rangeParam:  "24h",
 			expectedLen: 2,
-			expectedCPU: 10.0,
+			expectedCPU: 20.0,
 		},
 		{
 			name:        "invalid range",
@@ -474,6 +485,62 @@ func TestServer_HandlePods(t *testing.T) {
 	assert.Equal(t, "default", pods[0]["namespace"])
 }
 
+func TestServer_HandlePodsPagination(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	server := NewServer(clientset, nil, nil, nil, nil)
+
+	for i, name := range []string{"web-c", "web-a", "web-b", "db-a"} {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{"app": name[:strings.Index(name, "-")]},
+			},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{RestartCount: int32(i)}}},
+		}
+		_, err := clientset.CoreV1().Pods("default").Create(context.Background(), pod, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	_, err := clientset.CoreV1().Pods("other").Create(context.Background(), &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-z", Namespace: "other", Labels: map[string]string{"app": "web"}}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	get := func(query string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		server.handlePods(w, httptest.NewRequest("GET", "/api/pods?"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	names := func(response map[string]interface{}) []string {
+		var out []string
+		for _, item := range response["items"].([]interface{}) {
+			out = append(out, item.(map[string]interface{})["name"].(string))
+		}
+		return out
+	}
+
+	first := get("namespace=default&labelSelector=app%3Dweb&limit=2&sort=name")
+	assert.Equal(t, []string{"web-a", "web-b"}, names(first))
+	assert.Equal(t, float64(3), first["total"])
+	require.NotEmpty(t, first["nextToken"])
+
+	second := get("namespace=default&labelSelector=app%3Dweb&limit=2&sort=name&continue=" + first["nextToken"].(string))
+	assert.Equal(t, []string{"web-c"}, names(second))
+	assert.Nil(t, second["nextToken"])
+
+	byRestarts := get("namespace=default&sort=restarts&order=desc&page=1&pageSize=2")
+	assert.Equal(t, []string{"db-a", "web-b"}, names(byRestarts))
+	assert.Equal(t, float64(1), byRestarts["page"])
+	assert.Equal(t, float64(4), byRestarts["total"])
+
+	for _, query := range []string{"sort=size", "limit=0", "continue=bogus", "page=1&limit=5", "labelSelector=app%3D%3D%3D"} {
+		w := httptest.NewRecorder()
+		server.handlePods(w, httptest.NewRequest("GET", "/api/pods?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
 func TestServer_HandlePodsV1(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	server := NewServer(clientset, nil, nil, nil, nil)
@@ -529,9 +596,7 @@ func TestServer_HandleMetricsHistory(t *testing.T) {
 	server := NewServer(clientset, nil, nil, nil, nil)
 
 	// Clear any existing metrics history to avoid test interference
-	metricsHistoryMu.Lock()
-	metricsHistory = []MetricSample{}
-	metricsHistoryMu.Unlock()
+	metricsHistory.reset()
 
 	// Add test sample to history - ensure it's well within the 1h window
 	sample := MetricSample{
@@ -545,9 +610,7 @@ func TestServer_HandleMetricsHistory(t *testing.T) {
 		AvgUtilization:     35.35,
 	}
 
-	metricsHistoryMu.Lock()
-	metricsHistory = append(metricsHistory, sample)
-	metricsHistoryMu.Unlock()
+	metricsHistory.add(sample)
 
 	req := httptest.NewRequest("GET", "/api/metrics/history?range=1h", nil)
 	w := httptest.NewRecorder()
@@ -672,6 +735,8 @@ func TestServer_HandleNodesProxy(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "NodeMetricsList", response["kind"])
 	assert.Equal(t, "metrics.k8s.io/v1beta1", response["apiVersion"])
+	assert.Equal(t, true, response["estimated"])
+	assert.Equal(t, "estimate", w.Header().Get("X-Metrics-Source"))
 
 	items := response["items"].([]interface{})
 	assert.Len(t, items, 1)
@@ -721,11 +786,87 @@ func TestServer_HandlePodsProxy(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "PodMetricsList", response["kind"])
 	assert.Equal(t, "metrics.k8s.io/v1beta1", response["apiVersion"])
+	assert.Equal(t, true, response["estimated"])
+	assert.Equal(t, "estimate", w.Header().Get("X-Metrics-Source"))
 
 	items := response["items"].([]interface{})
 	assert.Len(t, items, 1)
 }
 
+func TestServer_MetricsProxyUsesMetricsServer(t *testing.T) {
+	timestamp := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	window := metav1.Duration{Duration: 30 * time.Second}
+	podMetrics := &metricsv1beta1.PodMetricsList{Items: []metricsv1beta1.PodMetrics{{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Timestamp:  timestamp,
+		Window:     window,
+		Containers: []metricsv1beta1.ContainerMetrics{{
+			Name: "app",
+			Usage: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("250m"),
+				v1.ResourceMemory: resource.MustParse("300Mi"),
+			},
+		}},
+	}}}
+	nodeMetrics := &metricsv1beta1.NodeMetricsList{Items: []metricsv1beta1.NodeMetrics{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Timestamp:  timestamp,
+		Window:     window,
+		Usage: v1.ResourceList{
+			v1.ResourceCPU:    resource.MustParse("1500m"),
+			v1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+	}}}
+	// The fake tracker does not map metrics kinds to their resources, so
+	// lists are answered directly
+	metricsClient := metricsclient.NewSimpleClientset()
+	metricsClient.PrependReactor("list", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, podMetrics, nil
+	})
+	metricsClient.PrependReactor("list", "nodes", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nodeMetrics, nil
+	})
+	server := NewServer(fake.NewSimpleClientset(), metricsClient, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	server.handlePodsProxy(w, httptest.NewRequest("GET", "/apis/metrics.k8s.io/v1beta1/pods", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "metrics-server", w.Header().Get("X-Metrics-Source"))
+	var pods struct {
+		Estimated bool `json:"estimated"`
+		Items     []struct {
+			Timestamp  string `json:"timestamp"`
+			Window     string `json:"window"`
+			Containers []struct {
+				Name  string            `json:"name"`
+				Usage map[string]string `json:"usage"`
+			} `json:"containers"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pods))
+	assert.False(t, pods.Estimated)
+	require.Len(t, pods.Items, 1)
+	assert.Equal(t, "2025-01-02T03:04:05Z", pods.Items[0].Timestamp)
+	assert.Equal(t, "30s", pods.Items[0].Window)
+	require.Len(t, pods.Items[0].Containers, 1)
+	assert.Equal(t, map[string]string{"cpu": "250m", "memory": "300Mi"}, pods.Items[0].Containers[0].Usage)
+
+	w = httptest.NewRecorder()
+	server.handleNodesProxy(w, httptest.NewRequest("GET", "/apis/metrics.k8s.io/v1beta1/nodes", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "metrics-server", w.Header().Get("X-Metrics-Source"))
+	var nodes struct {
+		Estimated bool `json:"estimated"`
+		Items     []struct {
+			Usage map[string]string `json:"usage"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &nodes))
+	assert.False(t, nodes.Estimated)
+	require.Len(t, nodes.Items, 1)
+	assert.Equal(t, map[string]string{"cpu": "1500m", "memory": "2Gi"}, nodes.Items[0].Usage)
+}
+
 func TestServer_BuildEnhancedPodData(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	server := NewServer(clientset, nil, nil, nil, nil)
@@ -846,3 +987,68 @@ func TestServer_ConvertPodsToV1API(t *testing.T) {
 	assert.Equal(t, "200m", limits["cpu"])
 	assert.Equal(t, "256Mi", limits["memory"])
 }
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_RunRestartsOnSettingsChange(t *testing.T) {
+	cfg := config.Get()
+	previousPort, previousMode, _ := cfg.APIServerSettings()
+	defer cfg.SetAPIServer(previousPort, previousMode)
+
+	first, second := freePort(t), freePort(t)
+	cfg.SetAPIServer(first, AuthModeNone)
+
+	server := NewServer(fake.NewSimpleClientset(), nil, nil, nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	reload := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx, reload) }()
+
+	// Without keep-alives no spare connection holds up the listener's shutdown
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(port int) (*http.Response, error) {
+		return client.Get(fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	}
+	waitFor := func(port int) {
+		require.Eventually(t, func() bool {
+			resp, err := get(port)
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusOK
+		}, 5*time.Second, 20*time.Millisecond)
+	}
+	waitFor(first)
+
+	// An unchanged configuration keeps the listener
+	reload <- struct{}{}
+	waitFor(first)
+
+	cfg.SetAPIServer(second, AuthModeNone)
+	reload <- struct{}{}
+	waitFor(second)
+	_, err := get(first)
+	assert.Error(t, err, "the previous listener should be closed")
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+	_, err = get(second)
+	assert.Error(t, err, "the listener should be closed on shutdown")
+}
+
+func TestServer_ShutdownWithoutListener(t *testing.T) {
+	server := NewServer(fake.NewSimpleClientset(), nil, nil, nil, nil)
+	assert.NoError(t, server.Shutdown(context.Background()))
+}
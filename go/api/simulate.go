@@ -0,0 +1,245 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// maxSimulationWindow bounds the usage history a simulation replays
+	maxSimulationWindow = 30 * 24 * time.Hour
+	// maxSimulationBytes caps the size of the settings posted to /api/simulate
+	maxSimulationBytes = 64 << 10
+)
+
+// SimulationSettings are the hypothetical settings posted to /api/simulate.
+// Settings left out keep their live value.
+type SimulationSettings struct {
+	Namespace                string  `json:"namespace,omitempty"` // all managed namespaces when empty
+	Window                   string  `json:"window,omitempty"`    // history replayed, the percentile window by default
+	Algorithm                string  `json:"algorithm,omitempty"` // percentile, average or max
+	CPURequestMultiplier     float64 `json:"cpuRequestMultiplier,omitempty"`
+	MemoryRequestMultiplier  float64 `json:"memoryRequestMultiplier,omitempty"`
+	CPULimitMultiplier       float64 `json:"cpuLimitMultiplier,omitempty"`
+	MemoryLimitMultiplier    float64 `json:"memoryLimitMultiplier,omitempty"`
+	CPUScaleUpThreshold      float64 `json:"cpuScaleUpThreshold,omitempty"` // fraction of the limit, e.g. 0.8
+	CPUScaleDownThreshold    float64 `json:"cpuScaleDownThreshold,omitempty"`
+	MemoryScaleUpThreshold   float64 `json:"memoryScaleUpThreshold,omitempty"`
+	MemoryScaleDownThreshold float64 `json:"memoryScaleDownThreshold,omitempty"`
+}
+
+// SimulationResult compares the decisions of the live and the hypothetical settings
+type SimulationResult struct {
+	CostSource string               `json:"costSource"`
+	Live       SimulationTotals     `json:"live"`
+	Simulated  SimulationTotals     `json:"simulated"`
+	Containers []SimulatedContainer `json:"containers"`
+}
+
+// SimulationTotals sums the replays of every container under one configuration
+type SimulationTotals struct {
+	Resizes             int     `json:"resizes"`
+	ContainersResized   int     `json:"containersResized"`
+	CPUMillisReleased   int64   `json:"cpuMillisReleased"`   // negative when requests grew
+	MemoryBytesReleased int64   `json:"memoryBytesReleased"` // negative when requests grew
+	MonthlySavings      float64 `json:"monthlySavings"`
+}
+
+// SimulatedContainer is the replay of a container either configuration resizes
+type SimulatedContainer struct {
+	Namespace string                      `json:"namespace"`
+	Pod       string                      `json:"pod"`
+	Container string                      `json:"container"`
+	Samples   int                         `json:"samples"`
+	Current   corev1.ResourceRequirements `json:"current"`
+	Live      ReplayOutcome               `json:"live"`
+	Simulated ReplayOutcome               `json:"simulated"`
+}
+
+// ReplayOutcome is what a configuration did to a container over the history
+type ReplayOutcome struct {
+	Resizes    int                         `json:"resizes"`
+	Final      corev1.ResourceRequirements `json:"final"`
+	LastReason string                      `json:"lastReason,omitempty"`
+}
+
+// Simulator replays the usage history under a hypothetical configuration
+type Simulator interface {
+	Simulate(ctx context.Context, cfg *config.Config, namespace string, window time.Duration) (SimulationResult, error)
+}
+
+// SimulatorFunc adapts a function to the Simulator interface
+type SimulatorFunc func(ctx context.Context, cfg *config.Config, namespace string, window time.Duration) (SimulationResult, error)
+
+// Simulate calls f
+func (f SimulatorFunc) Simulate(ctx context.Context, cfg *config.Config, namespace string, window time.Duration) (SimulationResult, error) {
+	return f(ctx, cfg, namespace, window)
+}
+
+// SetSimulator sets the simulator /api/simulate replays settings with
+func (s *Server) SetSimulator(simulator Simulator) {
+	s.simulator = simulator
+}
+
+// simulateResponse is the outcome of a simulation
+type simulateResponse struct {
+	Window    string            `json:"window"`
+	Namespace string            `json:"namespace,omitempty"`
+	Settings  effectiveSettings `json:"settings"`
+	SimulationResult
+}
+
+// effectiveSettings shows the hypothetical settings after defaulting
+type effectiveSettings struct {
+	Algorithm                string  `json:"algorithm"`
+	CPURequestMultiplier     float64 `json:"cpuRequestMultiplier"`
+	MemoryRequestMultiplier  float64 `json:"memoryRequestMultiplier"`
+	CPULimitMultiplier       float64 `json:"cpuLimitMultiplier"`
+	MemoryLimitMultiplier    float64 `json:"memoryLimitMultiplier"`
+	CPUScaleUpThreshold      float64 `json:"cpuScaleUpThreshold"`
+	CPUScaleDownThreshold    float64 `json:"cpuScaleDownThreshold"`
+	MemoryScaleUpThreshold   float64 `json:"memoryScaleUpThreshold"`
+	MemoryScaleDownThreshold float64 `json:"memoryScaleDownThreshold"`
+}
+
+// handleSimulate replays the stored usage history under hypothetical
+// multipliers, thresholds and algorithm next to the live configuration, and
+// reports the resizes and savings each would have made. The live
+// configuration is not changed, so thresholds can be tuned safely.
+//
+//	POST /api/simulate {"cpuScaleDownThreshold": 0.2, "window": "72h"}
+func (s *Server) handleSimulate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.simulator == nil {
+		http.Error(w, "Simulation not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var settings SimulationSettings
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxSimulationBytes)).Decode(&settings); err != nil && err != io.EOF {
+		http.Error(w, "Invalid simulation settings: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	cfg, window, err := simulationConfig(config.Get(), settings)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.simulator.Simulate(r.Context(), cfg, settings.Namespace, window)
+	if err != nil {
+		http.Error(w, "Failed to simulate: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if result.Containers == nil {
+		result.Containers = []SimulatedContainer{}
+	}
+	s.writeJSONResponse(w, simulateResponse{
+		Window:    window.String(),
+		Namespace: settings.Namespace,
+		Settings: effectiveSettings{
+			Algorithm:                cfg.Algorithm,
+			CPURequestMultiplier:     cfg.CPURequestMultiplier,
+			MemoryRequestMultiplier:  cfg.MemoryRequestMultiplier,
+			CPULimitMultiplier:       cfg.CPULimitMultiplier,
+			MemoryLimitMultiplier:    cfg.MemoryLimitMultiplier,
+			CPUScaleUpThreshold:      cfg.CPUScaleUpThreshold,
+			CPUScaleDownThreshold:    cfg.CPUScaleDownThreshold,
+			MemoryScaleUpThreshold:   cfg.MemoryScaleUpThreshold,
+			MemoryScaleDownThreshold: cfg.MemoryScaleDownThreshold,
+		},
+		SimulationResult: result,
+	})
+}
+
+// simulationConfig returns a copy of the live configuration with the
+// hypothetical settings applied, and the window of history to replay
+func simulationConfig(live *config.Config, settings SimulationSettings) (*config.Config, time.Duration, error) {
+	cfg := live.Clone()
+	window := cfg.PercentileWindow
+	if settings.Window != "" {
+		parsed, err := config.ParseHistoryWindow(settings.Window)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid window %q: %v", settings.Window, err)
+		}
+		window = parsed
+	}
+	if window <= 0 || window > maxSimulationWindow {
+		return nil, 0, fmt.Errorf("window must be positive and at most %v", maxSimulationWindow)
+	}
+
+	switch settings.Algorithm {
+	case "":
+	case "percentile", "average", "max":
+		cfg.Algorithm = settings.Algorithm
+		// The algorithm decides the aggregation only where none is set
+		cfg.CPUAggregation, cfg.MemoryAggregation = "", ""
+	default:
+		return nil, 0, fmt.Errorf("unknown algorithm %q: expected percentile, average or max", settings.Algorithm)
+	}
+
+	for _, m := range []struct {
+		name   string
+		value  float64
+		target *float64
+	}{
+		{"cpuRequestMultiplier", settings.CPURequestMultiplier, &cfg.CPURequestMultiplier},
+		{"memoryRequestMultiplier", settings.MemoryRequestMultiplier, &cfg.MemoryRequestMultiplier},
+		{"cpuLimitMultiplier", settings.CPULimitMultiplier, &cfg.CPULimitMultiplier},
+		{"memoryLimitMultiplier", settings.MemoryLimitMultiplier, &cfg.MemoryLimitMultiplier},
+	} {
+		if m.value < 0 || (m.value > 0 && m.value < 1) {
+			return nil, 0, fmt.Errorf("%s must be at least 1", m.name)
+		}
+		if m.value > 0 {
+			*m.target = m.value
+		}
+	}
+	for _, t := range []struct {
+		name   string
+		value  float64
+		target *float64
+	}{
+		{"cpuScaleUpThreshold", settings.CPUScaleUpThreshold, &cfg.CPUScaleUpThreshold},
+		{"cpuScaleDownThreshold", settings.CPUScaleDownThreshold, &cfg.CPUScaleDownThreshold},
+		{"memoryScaleUpThreshold", settings.MemoryScaleUpThreshold, &cfg.MemoryScaleUpThreshold},
+		{"memoryScaleDownThreshold", settings.MemoryScaleDownThreshold, &cfg.MemoryScaleDownThreshold},
+	} {
+		if t.value < 0 || t.value > 1 {
+			return nil, 0, fmt.Errorf("%s must be between 0 and 1", t.name)
+		}
+		if t.value > 0 {
+			*t.target = t.value
+		}
+	}
+	if cfg.CPUScaleDownThreshold >= cfg.CPUScaleUpThreshold || cfg.MemoryScaleDownThreshold >= cfg.MemoryScaleUpThreshold {
+		return nil, 0, fmt.Errorf("scale-down thresholds must be below scale-up thresholds")
+	}
+	return cfg, window, nil
+}
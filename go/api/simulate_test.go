@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"right-sizer/config"
+)
+
+func TestServer_HandleSimulate(t *testing.T) {
+	var gotCfg *config.Config
+	var gotNamespace string
+	var gotWindow time.Duration
+	s := &Server{}
+	s.SetSimulator(SimulatorFunc(func(_ context.Context, cfg *config.Config, namespace string, window time.Duration) (SimulationResult, error) {
+		gotCfg, gotNamespace, gotWindow = cfg, namespace, window
+		return SimulationResult{
+			CostSource: "estimate",
+			Live:       SimulationTotals{Resizes: 4, ContainersResized: 2},
+			Simulated:  SimulationTotals{Resizes: 1, ContainersResized: 1, CPUMillisReleased: 500},
+		}, nil
+	}))
+
+	live := config.Get()
+	liveThreshold := live.CPUScaleDownThreshold
+	body := `{"namespace":"shop","window":"72h","algorithm":"max","cpuScaleDownThreshold":0.1,"memoryRequestMultiplier":1.5}`
+	w := httptest.NewRecorder()
+	s.handleSimulate(w, httptest.NewRequest(http.MethodPost, "/api/simulate", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, "shop", gotNamespace)
+	assert.Equal(t, 72*time.Hour, gotWindow)
+	require.NotNil(t, gotCfg)
+	assert.NotSame(t, live, gotCfg)
+	assert.Equal(t, "max", gotCfg.Algorithm)
+	assert.Equal(t, 0.1, gotCfg.CPUScaleDownThreshold)
+	assert.Equal(t, 1.5, gotCfg.MemoryRequestMultiplier)
+	assert.Equal(t, live.CPURequestMultiplier, gotCfg.CPURequestMultiplier)
+	assert.Equal(t, liveThreshold, live.CPUScaleDownThreshold, "the live configuration must not change")
+
+	var resp struct {
+		Window   string `json:"window"`
+		Settings struct {
+			CPUScaleDownThreshold float64 `json:"cpuScaleDownThreshold"`
+		} `json:"settings"`
+		Live       SimulationTotals     `json:"live"`
+		Simulated  SimulationTotals     `json:"simulated"`
+		Containers []SimulatedContainer `json:"containers"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "72h0m0s", resp.Window)
+	assert.Equal(t, 0.1, resp.Settings.CPUScaleDownThreshold)
+	assert.Equal(t, 4, resp.Live.Resizes)
+	assert.Equal(t, int64(500), resp.Simulated.CPUMillisReleased)
+	assert.NotNil(t, resp.Containers)
+}
+
+func TestServer_HandleSimulateRejects(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleSimulate(w, httptest.NewRequest(http.MethodPost, "/api/simulate", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	s.SetSimulator(SimulatorFunc(func(context.Context, *config.Config, string, time.Duration) (SimulationResult, error) {
+		t.Error("invalid settings must not be simulated")
+		return SimulationResult{}, nil
+	}))
+	for name, body := range map[string]string{
+		"unknown algorithm":      `{"algorithm":"magic"}`,
+		"multiplier below one":   `{"cpuRequestMultiplier":0.5}`,
+		"threshold above one":    `{"memoryScaleUpThreshold":1.5}`,
+		"crossed thresholds":     `{"cpuScaleDownThreshold":0.9,"cpuScaleUpThreshold":0.5}`,
+		"window beyond 30 days":  `{"window":"60d"}`,
+		"malformed settings":     `{"window":`,
+		"unparsable window text": `{"window":"soon"}`,
+	} {
+		w := httptest.NewRecorder()
+		s.handleSimulate(w, httptest.NewRequest(http.MethodPost, "/api/simulate", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, w.Code, name)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleSimulate(w, httptest.NewRequest(http.MethodGet, "/api/simulate", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
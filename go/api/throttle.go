@@ -0,0 +1,296 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// responseCacheSize bounds the response cache; expired entries are dropped
+	// when it is full, and everything if that is not enough
+	responseCacheSize = 256
+	// clientLimiterSize bounds the number of clients tracked by the rate limiter
+	clientLimiterSize = 4096
+	// clientIdleTTL is how long an idle client keeps its rate limiter
+	clientIdleTTL = 10 * time.Minute
+)
+
+// cachedPaths are the GET endpoints whose responses are reused for a short
+// time. Each request to them lists every pod or node, so a dashboard polling
+// them would otherwise reach the Kubernetes API on every refresh.
+var cachedPaths = map[string]bool{
+	"/api/pods":                          true,
+	"/api/pods/count":                    true,
+	"/api/pods/system":                   true,
+	"/api/v1/pods":                       true,
+	"/api/metrics":                       true,
+	"/api/metrics/live":                  true,
+	"/api/namespaces":                    true,
+	"/apis/metrics.k8s.io/v1beta1/nodes": true,
+	"/apis/metrics.k8s.io/v1beta1/pods":  true,
+}
+
+// responseCache reuses successful responses of the cached paths for ttl.
+// Concurrent requests for the same URL wait for the first one instead of
+// repeating its work.
+type responseCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+type cachedResponse struct {
+	done    chan struct{} // closed once the response is recorded
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// newResponseCache creates a response cache; a zero ttl disables it
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]*cachedResponse),
+	}
+}
+
+// Wrap returns a handler serving the cached paths from the cache
+func (c *responseCache) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.ttl <= 0 || r.Method != http.MethodGet || !cachedPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.URL.RequestURI()
+		entry, fill := c.lookup(key)
+		if fill {
+			c.record(key, entry, next, r)
+			entry.writeTo(w, "MISS")
+			return
+		}
+
+		select {
+		case <-entry.done:
+		case <-r.Context().Done():
+			return
+		}
+		// Failed responses are not shared; the request is served on its own
+		if entry.status != http.StatusOK {
+			next.ServeHTTP(w, r)
+			return
+		}
+		entry.writeTo(w, "HIT")
+	})
+}
+
+// lookup returns the entry for key, and whether the caller must fill it
+func (c *responseCache) lookup(key string) (*cachedResponse, bool) {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok && !(entry.recorded() && now.After(entry.expires)) {
+		return entry, false
+	}
+	if len(c.entries) >= responseCacheSize {
+		for k, entry := range c.entries {
+			if entry.recorded() && now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= responseCacheSize {
+			c.entries = make(map[string]*cachedResponse)
+		}
+	}
+	entry := &cachedResponse{done: make(chan struct{})}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// record serves r with next into entry. Responses other than 200 OK are
+// removed from the cache again.
+func (c *responseCache) record(key string, entry *cachedResponse, next http.Handler, r *http.Request) {
+	defer close(entry.done)
+
+	rec := &responseRecorder{header: make(http.Header)}
+	next.ServeHTTP(rec, r)
+	entry.status = rec.status
+	if entry.status == 0 {
+		entry.status = http.StatusOK
+	}
+	entry.header = rec.header
+	entry.body = rec.body.Bytes()
+	entry.expires = c.now().Add(c.ttl)
+
+	if entry.status != http.StatusOK {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// recorded reports whether the response has been recorded
+func (e *cachedResponse) recorded() bool {
+	select {
+	case <-e.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// writeTo writes the recorded response, marking it as a cache hit or miss
+func (e *cachedResponse) writeTo(w http.ResponseWriter, cache string) {
+	for name, values := range e.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", cache)
+	w.WriteHeader(e.status)
+	_, _ = w.Write(e.body)
+}
+
+// responseRecorder captures a response so it can be cached
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+// clientLimiter limits the request rate of each client. It runs after the
+// Authenticator: clients are told apart by the identity they authenticated
+// as, or by their address when the API is served without authentication.
+type clientLimiter struct {
+	limit rate.Limit
+	burst int
+	now   func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*clientRate
+}
+
+type clientRate struct {
+	limiter *rate.Limiter
+	seen    time.Time
+}
+
+// newClientLimiter creates a limiter allowing limit requests per second with
+// the given burst to each client; a zero limit disables it
+func newClientLimiter(limit float64, burst int) *clientLimiter {
+	if burst < 1 {
+		burst = int(math.Ceil(limit))
+	}
+	return &clientLimiter{
+		limit:   rate.Limit(limit),
+		burst:   burst,
+		now:     time.Now,
+		clients: make(map[string]*clientRate),
+	}
+}
+
+// Wrap returns a handler rejecting requests over the client's rate with 429
+// Too Many Requests. Probes are never limited.
+func (l *clientLimiter) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.limit <= 0 || unauthenticatedPaths[r.URL.Path] || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !l.allow(clientKey(r)) {
+			retryAfter := int(math.Ceil(1 / float64(l.limit)))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow reports whether the client identified by key may make a request now
+func (l *clientLimiter) allow(key string) bool {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[key]
+	if !ok {
+		if len(l.clients) >= clientLimiterSize {
+			for k, c := range l.clients {
+				if now.Sub(c.seen) > clientIdleTTL {
+					delete(l.clients, k)
+				}
+			}
+			// Evict the least recently seen client rather than resetting
+			// everyone's budget
+			if len(l.clients) >= clientLimiterSize {
+				var oldest string
+				for k, c := range l.clients {
+					if oldest == "" || c.seen.Before(l.clients[oldest].seen) {
+						oldest = k
+					}
+				}
+				delete(l.clients, oldest)
+			}
+		}
+		client = &clientRate{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.seen = now
+	return client.limiter.AllowN(now, 1)
+}
+
+// clientKey identifies the client of r by its authenticated identity, or by
+// its address. Credentials the Authenticator has not verified are ignored, so
+// made-up tokens cannot buy a fresh budget.
+func clientKey(r *http.Request) string {
+	if identity := requestIdentity(r); identity != "" {
+		return "identity:" + identity
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "address:" + host
+}
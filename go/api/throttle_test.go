@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResponseCache(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	status := http.StatusOK
+	cache := newResponseCache(5 * time.Second)
+	cache.now = func() time.Time { return now }
+	handler := cache.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"count":1}`))
+	}))
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	first := get("/api/pods/count")
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+	second := get("/api/pods/count")
+	assert.Equal(t, "HIT", second.Header().Get("X-Cache"))
+	assert.Equal(t, `{"count":1}`, second.Body.String())
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	// Other query strings and uncached paths are served by the handler
+	get("/api/pods/count?namespace=default")
+	get("/api/policies")
+	get("/api/policies")
+	assert.Equal(t, 4, calls)
+
+	now = now.Add(6 * time.Second)
+	assert.Equal(t, "MISS", get("/api/pods/count").Header().Get("X-Cache"))
+	assert.Equal(t, 5, calls)
+
+	// Failures are not cached
+	now = now.Add(6 * time.Second)
+	status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, get("/api/pods/count").Code)
+	assert.Equal(t, http.StatusInternalServerError, get("/api/pods/count").Code)
+	assert.Equal(t, 7, calls)
+}
+
+func TestResponseCacheDisabled(t *testing.T) {
+	calls := 0
+	handler := newResponseCache(0).Wrap(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls++ }))
+	for i := 0; i < 2; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/pods", nil))
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestClientLimiter(t *testing.T) {
+	limiter := newClientLimiter(1, 2)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	handler := limiter.Wrap(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	serve := func(path, remoteAddr, identity string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		if identity != "" {
+			req = req.WithContext(context.WithValue(req.Context(), identityKey{}, identity))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/api/pods", "10.0.0.1:1000", "").Code)
+	assert.Equal(t, http.StatusOK, serve("/api/pods", "10.0.0.1:1001", "").Code)
+	limited := serve("/api/pods", "10.0.0.1:1002", "")
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+
+	// Probes, other addresses and other authenticated identities have their own budget
+	assert.Equal(t, http.StatusOK, serve("/health", "10.0.0.1:1003", "").Code)
+	assert.Equal(t, http.StatusOK, serve("/api/pods", "10.0.0.2:1000", "").Code)
+	assert.Equal(t, http.StatusOK, serve("/api/pods", "10.0.0.1:1004", "user:dashboard").Code)
+
+	// Unverified credentials do not buy a fresh budget
+	req := httptest.NewRequest(http.MethodGet, "/api/pods", nil)
+	req.RemoteAddr = "10.0.0.1:1006"
+	req.Header.Set("Authorization", "Bearer made-up")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, serve("/api/pods", "10.0.0.1:1005", "").Code)
+}
+
+// TestClientLimiterEvictsOldestClient verifies a full limiter forgets only
+// the least recently seen client
+func TestClientLimiterEvictsOldestClient(t *testing.T) {
+	limiter := newClientLimiter(1, 1)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	assert.True(t, limiter.allow("busy"))
+	for i := 1; i < clientLimiterSize; i++ {
+		now = now.Add(time.Microsecond)
+		limiter.allow(fmt.Sprintf("client-%d", i))
+	}
+	now = now.Add(time.Microsecond)
+	assert.False(t, limiter.allow("client-1"))
+
+	// A new client evicts "busy", the least recently seen one; the others keep their budget
+	now = now.Add(time.Microsecond)
+	assert.True(t, limiter.allow("newcomer"))
+	assert.Len(t, limiter.clients, clientLimiterSize)
+	assert.NotContains(t, limiter.clients, "busy")
+	assert.False(t, limiter.allow("client-2"))
+}
+
+func TestServer_ListPodsFromInformerCache(t *testing.T) {
+	ctrlClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Labels: map[string]string{"app": "web"}}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop", Labels: map[string]string{"app": "db"}}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "staging", Labels: map[string]string{"app": "web"}}},
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+	).Build()
+	// Without a clientset, results can only come from ctrlClient
+	server := NewServer(nil, nil, ctrlClient, nil, nil)
+
+	pods, err := server.listPods(context.Background(), "", nil)
+	require.NoError(t, err)
+	assert.Len(t, pods, 3)
+
+	pods, err = server.listPods(context.Background(), "shop", labels.SelectorFromSet(labels.Set{"app": "web"}))
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "shop", pods[0].Namespace)
+
+	nodes, err := server.listNodes(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+}
@@ -0,0 +1,24 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1alpha1
+
+// v1alpha1 is the storage version; the other versions convert to and from it
+
+// Hub marks RightSizerConfig as the conversion hub
+func (*RightSizerConfig) Hub() {}
+
+// Hub marks RightSizerPolicy as the conversion hub
+func (*RightSizerPolicy) Hub() {}
@@ -58,6 +58,15 @@ type RightSizerConfigSpec struct {
 	// +kubebuilder:default=false
 	DryRun bool `json:"dryRun,omitempty"`
 
+	// RecommendationOnly writes RightSizerRecommendation objects per workload
+	// instead of resizing pods, so changes can be reviewed before they are applied
+	// +kubebuilder:default=false
+	RecommendationOnly bool `json:"recommendationOnly,omitempty"`
+
+	// ExportConfig renders resize decisions as patches for a GitOps pipeline
+	// instead of resizing pods, so live changes are not reverted by the sync
+	ExportConfig ExportConfigSpec `json:"exportConfig,omitempty"`
+
 	// DefaultResourceStrategy defines default resource calculation strategy
 	DefaultResourceStrategy DefaultResourceStrategySpec `json:"defaultResourceStrategy,omitempty"`
 
@@ -67,6 +76,12 @@ type RightSizerConfigSpec struct {
 	// MetricsConfig configures metrics collection
 	MetricsConfig MetricsConfigSpec `json:"metricsConfig,omitempty"`
 
+	// CostConfig defines the cost provider savings are priced with
+	CostConfig CostConfigSpec `json:"costConfig,omitempty"`
+
+	// AutoscalerConfig coordinates resizes with Karpenter and the Cluster Autoscaler
+	AutoscalerConfig AutoscalerConfigSpec `json:"autoscalerConfig,omitempty"`
+
 	// ObservabilityConfig configures observability features
 	ObservabilityConfig ObservabilityConfigSpec `json:"observabilityConfig,omitempty"`
 
@@ -79,13 +94,88 @@ type RightSizerConfigSpec struct {
 	// NamespaceConfig defines global namespace inclusion/exclusion
 	NamespaceConfig NamespaceConfigSpec `json:"namespaceConfig,omitempty"`
 
+	// Exclusions keep workloads from being right-sized by their labels or owner
+	// kind, in addition to the rightsizer.io/skip pod annotation
+	Exclusions []WorkloadExclusion `json:"exclusions,omitempty"`
+
+	// ExcludedContainers are name patterns of containers never right-sized in
+	// any namespace, such as service mesh proxies and log shippers, matched
+	// with shell globs like "*-exporter". Unset, the proxies of Istio and
+	// Linkerd are excluded; an empty list resizes every container.
+	ExcludedContainers []string `json:"excludedContainers,omitempty"`
+
+	// NamespaceOverrides let teams tune thresholds and multipliers for their
+	// namespaces; settings left empty fall back to the global ones
+	NamespaceOverrides []NamespaceOverrideSpec `json:"namespaceOverrides,omitempty"`
+
 	// NotificationConfig configures notifications
 	NotificationConfig NotificationConfigSpec `json:"notificationConfig,omitempty"`
 
+	// Reporting schedules weekly and monthly savings and efficiency reports
+	Reporting ReportingSpec `json:"reporting,omitempty"`
+
 	// FeatureGates enables/disables specific features
 	FeatureGates map[string]bool `json:"featureGates,omitempty"`
 }
 
+// ExportConfigSpec configures the GitOps export of resize decisions
+type ExportConfigSpec struct {
+	// Enabled switches the operator to export mode: decisions are rendered as
+	// patches instead of being applied to pods
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Format of the rendered patches
+	// +kubebuilder:validation:Enum=strategic-merge;json-patch;kustomize
+	// +kubebuilder:default=strategic-merge
+	Format string `json:"format,omitempty"`
+
+	// Target the patches are written to
+	// +kubebuilder:validation:Enum=configmap;git
+	// +kubebuilder:default=configmap
+	Target string `json:"target,omitempty"`
+
+	// ConfigMapName is the ConfigMap the patches are written to
+	// +kubebuilder:default=right-sizer-export
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// ConfigMapNamespace is the namespace of the ConfigMap, the operator's namespace by default
+	ConfigMapNamespace string `json:"configMapNamespace,omitempty"`
+
+	// Git configures the repository the patches are pushed to
+	Git *GitExportSpec `json:"git,omitempty"`
+
+	// VerticalPodAutoscalers also writes the recommendations of every workload
+	// into a VerticalPodAutoscaler with updateMode Off, for tooling built on
+	// VPA objects. It does not depend on enabled.
+	VerticalPodAutoscalers bool `json:"verticalPodAutoscalers,omitempty"`
+}
+
+// GitExportSpec configures the Git repository patches are pushed to
+type GitExportSpec struct {
+	// Repository is the HTTPS URL of the repository
+	Repository string `json:"repository"`
+
+	// Branch the patches are committed to
+	// +kubebuilder:default=main
+	Branch string `json:"branch,omitempty"`
+
+	// Path is the directory in the repository the patches are written to
+	// +kubebuilder:default=right-sizer
+	Path string `json:"path,omitempty"`
+
+	// AuthSecretRef selects the access token used to push, read from the operator's namespace
+	AuthSecretRef *corev1.SecretKeySelector `json:"authSecretRef,omitempty"`
+
+	// AuthorName of the export commits
+	// +kubebuilder:default=right-sizer
+	AuthorName string `json:"authorName,omitempty"`
+
+	// AuthorEmail of the export commits
+	// +kubebuilder:default="right-sizer@noreply.local"
+	AuthorEmail string `json:"authorEmail,omitempty"`
+}
+
 // DefaultResourceStrategySpec defines default resource calculation parameters
 type DefaultResourceStrategySpec struct {
 	// CPU default strategy
@@ -103,6 +193,20 @@ type DefaultResourceStrategySpec struct {
 	// +kubebuilder:validation:Enum=50;90;95;99
 	Percentile int32 `json:"percentile,omitempty"`
 
+	// LimitPercentile of the usage over a resource's limit window that its
+	// limit is sized from, e.g. 99.9, so limits follow spikes while requests
+	// follow the steady state
+	// +kubebuilder:default=99.9
+	// +kubebuilder:validation:Minimum=50
+	// +kubebuilder:validation:Maximum=100
+	LimitPercentile float64 `json:"limitPercentile,omitempty"`
+
+	// LimitHeadroomPercent is added to the peak usage limits are sized from
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=900
+	LimitHeadroomPercent int32 `json:"limitHeadroomPercent,omitempty"`
+
 	// UpdateMode default for how updates should be applied
 	// +kubebuilder:validation:Enum=immediate;rolling;scheduled
 	// +kubebuilder:default=rolling
@@ -112,6 +216,46 @@ type DefaultResourceStrategySpec struct {
 	// +kubebuilder:validation:Enum=percentile;average;max
 	// +kubebuilder:default=percentile
 	Algorithm string `json:"algorithm,omitempty"`
+
+	// WorkloadAggregation controls how the recommendations of a workload's
+	// replicas are combined into the one applied to every replica
+	// +kubebuilder:validation:Enum=max;percentile;none
+	// +kubebuilder:default=max
+	WorkloadAggregation string `json:"workloadAggregation,omitempty"`
+
+	// DaemonSetNodeClassLabel is a node label, such as a node pool or instance
+	// type label, whose values split DaemonSet replicas into node classes that
+	// are sized separately. Empty sizes every replica of a DaemonSet alike.
+	DaemonSetNodeClassLabel string `json:"daemonSetNodeClassLabel,omitempty"`
+
+	// JobMode controls how Job and CronJob pods are sized: recommend writes a
+	// recommendation from the usage of past runs, patch also updates the
+	// CronJob's job template, resize treats them like long-running pods
+	// +kubebuilder:validation:Enum=recommend;patch;resize
+	// +kubebuilder:default=recommend
+	JobMode string `json:"jobMode,omitempty"`
+
+	// HorizontalAdvice suggests replica count changes next to the vertical
+	// recommendations when set. Suggestions are published in the workload's
+	// RightSizerRecommendation and never applied.
+	// +optional
+	HorizontalAdvice *HorizontalAdviceSpec `json:"horizontalAdvice,omitempty"`
+}
+
+// HorizontalAdviceSpec configures replica count suggestions
+type HorizontalAdviceSpec struct {
+	// MinSavingsPercent is how much cheaper another replica count must be than
+	// the vertical recommendation alone to be suggested
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	MinSavingsPercent int32 `json:"minSavingsPercent,omitempty"`
+
+	// MinReplicas is the fewest replicas suggested for a workload without a
+	// HorizontalPodAutoscaler, which otherwise bounds the suggestions
+	// +kubebuilder:default=2
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas int32 `json:"minReplicas,omitempty"`
 }
 
 // DefaultCPUStrategy defines default CPU resource calculation
@@ -138,6 +282,11 @@ type DefaultCPUStrategy struct {
 	// +kubebuilder:validation:Minimum=0
 	LimitAddition int64 `json:"limitAddition,omitempty"`
 
+	// LimitWindow is the history window, e.g. "1h", whose peak usage the CPU
+	// limit is sized from; the limit is derived from the request when unset
+	// +optional
+	LimitWindow string `json:"limitWindow,omitempty"`
+
 	// MinRequest default in millicores
 	// +kubebuilder:default="10m"
 	MinRequest string `json:"minRequest,omitempty"`
@@ -157,6 +306,28 @@ type DefaultCPUStrategy struct {
 	// +kubebuilder:validation:Minimum=0.1
 	// +kubebuilder:validation:Maximum=1.0
 	ScaleDownThreshold float64 `json:"scaleDownThreshold,omitempty"`
+
+	// ThrottleThreshold is the CPU throttling percentage (0-100) that triggers
+	// scale up regardless of average usage
+	// +kubebuilder:default=25
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ThrottleThreshold float64 `json:"throttleThreshold,omitempty"`
+
+	// PressureThreshold is the percentage (0-100) of time a container's
+	// tasks stall waiting for CPU, per the cgroup v2 pressure stall
+	// information averaged over 5 minutes, that triggers scale up even when
+	// utilization looks acceptable; 0 disables it
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	PressureThreshold float64 `json:"pressureThreshold,omitempty"`
+
+	// Aggregation reduces the CPU usage history to the usage sized from;
+	// follows the algorithm when unset
+	// +kubebuilder:validation:Enum=latest;average;max;percentile
+	// +optional
+	Aggregation string `json:"aggregation,omitempty"`
 }
 
 // DefaultMemoryStrategy defines default Memory resource calculation
@@ -183,6 +354,12 @@ type DefaultMemoryStrategy struct {
 	// +kubebuilder:validation:Minimum=0
 	LimitAddition int64 `json:"limitAddition,omitempty"`
 
+	// LimitWindow is the history window, e.g. "24h", whose peak usage the
+	// memory limit is sized from; the limit is derived from the request when
+	// unset
+	// +optional
+	LimitWindow string `json:"limitWindow,omitempty"`
+
 	// MinRequest default in MB
 	// +kubebuilder:default="64Mi"
 	MinRequest string `json:"minRequest,omitempty"`
@@ -202,11 +379,28 @@ type DefaultMemoryStrategy struct {
 	// +kubebuilder:validation:Minimum=0.1
 	// +kubebuilder:validation:Maximum=1.0
 	ScaleDownThreshold float64 `json:"scaleDownThreshold,omitempty"`
+
+	// PressureThreshold is the percentage (0-100) of time a container's
+	// tasks stall waiting for memory, per the cgroup v2 pressure stall
+	// information averaged over 5 minutes, that triggers scale up even when
+	// utilization looks acceptable; 0 disables it
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	PressureThreshold float64 `json:"pressureThreshold,omitempty"`
+
+	// Aggregation reduces the memory usage history to the usage sized from;
+	// the peak over the history window by default
+	// +kubebuilder:validation:Enum=latest;average;max;percentile
+	// +kubebuilder:default=max
+	Aggregation string `json:"aggregation,omitempty"`
 }
 
 // GlobalConstraintsSpec defines global constraints for the operator
 type GlobalConstraintsSpec struct {
-	// MaxChangePercentage global limit for resource changes
+	// MaxChangePercentage limits how far a request or limit moves in one
+	// resize, in percent of its current value, so sizing converges over
+	// several runs; 0 for no limit
 	// +kubebuilder:default=50
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
@@ -222,11 +416,50 @@ type GlobalConstraintsSpec struct {
 	// +kubebuilder:default="5m"
 	CooldownPeriod string `json:"cooldownPeriod,omitempty"`
 
+	// MinPodAge is how long a pod must have been running, and ready, before
+	// it is analyzed, so the low usage of a starting pod does not size it down
+	// +kubebuilder:default="5m"
+	MinPodAge string `json:"minPodAge,omitempty"`
+
+	// ScaleDownDelay is how long usage must stay below the scale-down
+	// threshold before a resource is sized down, so a momentary idle sample
+	// does not shrink it
+	// +kubebuilder:default="30m"
+	ScaleDownDelay string `json:"scaleDownDelay,omitempty"`
+
 	// MaxConcurrentResizes limits concurrent resize operations
 	// +kubebuilder:default=10
 	// +kubebuilder:validation:Minimum=1
 	MaxConcurrentResizes int32 `json:"maxConcurrentResizes,omitempty"`
 
+	// NodeCapacityStrategy handles upsizes that do not fit in the node's
+	// remaining allocatable: cap them to what is left, or defer them
+	// +kubebuilder:validation:Enum=cap;defer
+	// +kubebuilder:default=cap
+	NodeCapacityStrategy string `json:"nodeCapacityStrategy,omitempty"`
+
+	// MaxResizesPerNode limits how many pods of a node are resized at once,
+	// counting resizes the kubelet has not finished yet
+	// +kubebuilder:default=2
+	// +kubebuilder:validation:Minimum=1
+	MaxResizesPerNode int32 `json:"maxResizesPerNode,omitempty"`
+
+	// ChangeBudget limits the share of managed pods resized within a window,
+	// so a configuration mistake cannot resize the whole cluster in one pass
+	ChangeBudget *ChangeBudgetSpec `json:"changeBudget,omitempty"`
+
+	// Canary resizes one replica of a large Deployment first and rolls the
+	// change out to the others once it has run cleanly for the bake time
+	Canary *CanarySpec `json:"canary,omitempty"`
+
+	// SelfSizing lets the operator size its own Deployment with extra
+	// conservative limits; the operator's pods are skipped when unset
+	SelfSizing *SelfSizingSpec `json:"selfSizing,omitempty"`
+
+	// DecisionFilters names decision filters, registered with the operator
+	// build, that run in order on every resize and may veto or adjust it
+	DecisionFilters []string `json:"decisionFilters,omitempty"`
+
 	// RespectPDB globally ensures PodDisruptionBudgets are respected
 	// +kubebuilder:default=true
 	RespectPDB bool `json:"respectPDB,omitempty"`
@@ -239,6 +472,13 @@ type GlobalConstraintsSpec struct {
 	// +kubebuilder:default=true
 	RespectVPA bool `json:"respectVPA,omitempty"`
 
+	// VPAMode handles workloads a VerticalPodAutoscaler manages: skip them,
+	// compare (publish recommendations next to the VPA's target without
+	// resizing) or ignore the VPA. respectVPA=false is the same as ignore.
+	// +kubebuilder:default=skip
+	// +kubebuilder:validation:Enum=skip;compare;ignore
+	VPAMode string `json:"vpaMode,omitempty"`
+
 	// MaxCPUCores global maximum CPU cores limit
 	// +kubebuilder:default=16
 	// +kubebuilder:validation:Minimum=1
@@ -250,6 +490,104 @@ type GlobalConstraintsSpec struct {
 	MaxMemoryGB int32 `json:"maxMemoryGB,omitempty"`
 }
 
+// ChangeBudgetSpec limits the share of managed pods resized within a sliding
+// window; a percentage of zero disables that budget
+type ChangeBudgetSpec struct {
+	// ClusterPercent is the share of all managed pods resized per window
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ClusterPercent int32 `json:"clusterPercent"`
+
+	// NamespacePercent is the share of a namespace's managed pods resized per window
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	NamespacePercent int32 `json:"namespacePercent,omitempty"`
+
+	// MinPods is the number of pods the cluster budget always allows,
+	// however small the cluster
+	// +kubebuilder:default=5
+	// +kubebuilder:validation:Minimum=0
+	MinPods int32 `json:"minPods"`
+
+	// Window the budgets are counted over
+	// +kubebuilder:default="1h"
+	Window string `json:"window,omitempty"`
+}
+
+// CanarySpec configures the canary rollout of resizes to large Deployments
+type CanarySpec struct {
+	// MinReplicas is the number of replicas a Deployment needs to be resized
+	// through a canary; smaller ones are resized at once
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=2
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+
+	// BakeTime is how long the canary must run cleanly before the other
+	// replicas are resized
+	// +kubebuilder:default="10m"
+	BakeTime string `json:"bakeTime,omitempty"`
+
+	// MaxRestarts is the number of container restarts of the canary
+	// tolerated while it bakes
+	// +kubebuilder:default=0
+	// +kubebuilder:validation:Minimum=0
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+}
+
+// SelfSizingSpec configures how the operator sizes its own pods
+type SelfSizingSpec struct {
+	// MaxChangePercent is the largest share by which a request or limit of
+	// the operator is lowered per resize
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxChangePercent int32 `json:"maxChangePercent,omitempty"`
+
+	// MinCPURequest is the smallest CPU request the operator is sized to
+	// +kubebuilder:default="100m"
+	MinCPURequest string `json:"minCPURequest,omitempty"`
+
+	// MinMemoryRequest is the smallest memory request the operator is sized to
+	// +kubebuilder:default="128Mi"
+	MinMemoryRequest string `json:"minMemoryRequest,omitempty"`
+}
+
+// CostConfigSpec configures the OpenCost or Kubecost endpoint CPU and memory
+// prices are taken from
+type CostConfigSpec struct {
+	// Provider of the allocation data; with none, built-in default prices are used
+	// +kubebuilder:validation:Enum=none;opencost;kubecost
+	// +kubebuilder:default=none
+	Provider string `json:"provider,omitempty"`
+
+	// Endpoint is the base URL of the OpenCost or Kubecost API,
+	// e.g. http://opencost.opencost:9003
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Window of allocation data prices are derived from
+	// +kubebuilder:default="7d"
+	Window string `json:"window,omitempty"`
+
+	// RefreshInterval is how long fetched prices are reused
+	// +kubebuilder:default="1h"
+	RefreshInterval string `json:"refreshInterval,omitempty"`
+}
+
+// AutoscalerConfigSpec coordinates resizes with node autoscalers that
+// consolidate nodes by their requested capacity
+type AutoscalerConfigSpec struct {
+	// AnnotateNodes writes the share of each node's allocatable CPU and memory
+	// requested once the planned resizes are applied to the node's annotations
+	// +kubebuilder:default=false
+	AnnotateNodes bool `json:"annotateNodes,omitempty"`
+
+	// SkipConsolidatingNodes holds back downsizes of pods on nodes Karpenter or
+	// the Cluster Autoscaler has tainted for removal
+	// +kubebuilder:default=false
+	SkipConsolidatingNodes bool `json:"skipConsolidatingNodes,omitempty"`
+}
+
 // MetricsConfigSpec configures metrics collection
 type MetricsConfigSpec struct {
 	// Provider defines the metrics provider to use
@@ -257,6 +595,11 @@ type MetricsConfigSpec struct {
 	// +kubebuilder:default=metrics-server
 	Provider string `json:"provider,omitempty"`
 
+	// FallbackProvider is used while the provider's health probes fail
+	// +kubebuilder:validation:Enum=metrics-server;prometheus
+	// +optional
+	FallbackProvider string `json:"fallbackProvider,omitempty"`
+
 	// PrometheusEndpoint for Prometheus metrics
 	PrometheusEndpoint string `json:"prometheusEndpoint,omitempty"`
 
@@ -271,9 +614,27 @@ type MetricsConfigSpec struct {
 	// +kubebuilder:default="30d"
 	RetentionPeriod string `json:"retentionPeriod,omitempty"`
 
-	// CustomQueries for custom metrics
+	// CustomQueries overrides the Prometheus queries by name (cpu, memory, cpuThrottled,
+	// containerCPU, containerMemory, containerWorkingSet, containerRSS,
+	// containerCPUThrottled, containerCPUPressure, containerMemoryPressure,
+	// cpuHistory, memoryHistory, workingSetHistory, rssHistory, network, diskIO,
+	// clusterNetwork, clusterDiskIO) with PromQL templates over {{.Namespace}},
+	// {{.Pod}} and {{.Container}}
 	CustomQueries map[string]string `json:"customQueries,omitempty"`
 
+	// MemoryMetric is the memory reading containers are sized from: usage,
+	// the provider's memory usage (page cache included for Prometheus),
+	// workingSet or rss, which leaves out all page cache for cache-heavy
+	// workloads. The provider's memory usage is used when unset or when the
+	// provider does not report the selected reading.
+	// +kubebuilder:validation:Enum=usage;workingSet;rss
+	// +optional
+	MemoryMetric string `json:"memoryMetric,omitempty"`
+
+	// QueryStep is the resolution of Prometheus range queries over the history window
+	// +kubebuilder:default="1m"
+	QueryStep string `json:"queryStep,omitempty"`
+
 	// EnableProfiling enables CPU and memory profiling
 	// +kubebuilder:default=false
 	EnableProfiling bool `json:"enableProfiling,omitempty"`
@@ -287,9 +648,19 @@ type MetricsConfigSpec struct {
 	// +kubebuilder:default="30d"
 	HistoryRetention string `json:"historyRetention,omitempty"`
 
-	// IncludeCustomMetrics enables custom metrics
+	// EfficiencyWindow is the window efficiency scores compare requested
+	// and used resources over, such as 24h or 7d
+	EfficiencyWindow string `json:"efficiencyWindow,omitempty"`
+
+	// IncludeCustomMetrics lets policies size from custom metrics API
+	// (custom.metrics.k8s.io) metrics
 	// +kubebuilder:default=false
 	IncludeCustomMetrics bool `json:"includeCustomMetrics,omitempty"`
+
+	// CustomMetrics restricts the custom metrics policies may size from;
+	// any metric is allowed when empty
+	// +optional
+	CustomMetrics []string `json:"customMetrics,omitempty"`
 }
 
 // ObservabilityConfigSpec configures observability features
@@ -312,6 +683,10 @@ type ObservabilityConfigSpec struct {
 	// +kubebuilder:default="/var/log/right-sizer/audit.log"
 	AuditLogPath string `json:"auditLogPath,omitempty"`
 
+	// AuditSinks ship audit events to remote storage so resize history
+	// survives pod restarts
+	AuditSinks AuditSinksSpec `json:"auditSinks,omitempty"`
+
 	// EnableMetricsExport enables Prometheus metrics export
 	// +kubebuilder:default=true
 	EnableMetricsExport bool `json:"enableMetricsExport,omitempty"`
@@ -340,6 +715,78 @@ type ObservabilityConfigSpec struct {
 	ProfilingPort int32 `json:"profilingPort,omitempty"`
 }
 
+// AuditSinksSpec configures the remote destinations of audit events; each
+// sink is enabled by setting its bucket, URL or topic
+type AuditSinksSpec struct {
+	// S3 writes audit events to S3 or S3-compatible object storage, such as
+	// GCS through its XML API or MinIO
+	S3 *S3AuditSinkSpec `json:"s3,omitempty"`
+
+	// Elasticsearch indexes audit events with the bulk API
+	Elasticsearch *ElasticsearchAuditSinkSpec `json:"elasticsearch,omitempty"`
+
+	// Kafka produces audit events to a topic through a Kafka REST Proxy
+	Kafka *KafkaAuditSinkSpec `json:"kafka,omitempty"`
+}
+
+// S3AuditSinkSpec configures the object storage audit sink
+type S3AuditSinkSpec struct {
+	// Bucket the audit objects are written to
+	Bucket string `json:"bucket"`
+
+	// Endpoint of the object storage API, https://s3.<region>.amazonaws.com by
+	// default; use https://storage.googleapis.com for GCS
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region used to sign requests
+	// +kubebuilder:default=us-east-1
+	Region string `json:"region,omitempty"`
+
+	// Prefix of the object keys
+	// +kubebuilder:default="right-sizer/audit"
+	Prefix string `json:"prefix,omitempty"`
+
+	// PathStyle addresses the bucket in the path instead of the host name, as MinIO requires
+	// +kubebuilder:default=false
+	PathStyle bool `json:"pathStyle,omitempty"`
+
+	// RotationInterval is how often a new object is started
+	// +kubebuilder:default="5m"
+	RotationInterval string `json:"rotationInterval,omitempty"`
+
+	// CredentialsSecret names a secret in the operator's namespace with
+	// accessKeyId and secretAccessKey keys
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// ElasticsearchAuditSinkSpec configures the Elasticsearch audit sink
+type ElasticsearchAuditSinkSpec struct {
+	// URL of the Elasticsearch cluster, e.g. https://elasticsearch:9200
+	URL string `json:"url"`
+
+	// Index audit events are written to
+	// +kubebuilder:default=right-sizer-audit
+	Index string `json:"index,omitempty"`
+
+	// CredentialsSecret names a secret in the operator's namespace with either
+	// an apiKey key or username and password keys
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// KafkaAuditSinkSpec configures the Kafka audit sink
+type KafkaAuditSinkSpec struct {
+	// RESTProxyURL is the URL of a Kafka REST Proxy (v2 API), e.g. http://kafka-rest:8082
+	RESTProxyURL string `json:"restProxyURL"`
+
+	// Topic audit events are produced to
+	// +kubebuilder:default=right-sizer-audit
+	Topic string `json:"topic,omitempty"`
+
+	// CredentialsSecret names a secret in the operator's namespace with
+	// username and password keys for basic authentication
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
 // SecurityConfigSpec configures security features
 type SecurityConfigSpec struct {
 	// EnableAdmissionController enables admission webhook
@@ -376,6 +823,17 @@ type SecurityConfigSpec struct {
 	// WebhookTimeoutSeconds for webhook timeout
 	// +kubebuilder:default=10
 	WebhookTimeoutSeconds int32 `json:"webhookTimeoutSeconds,omitempty"`
+
+	// APIPort for the HTTP API server. Changing it restarts the listener.
+	// Defaults to the API_PORT environment variable or 8082.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	APIPort int32 `json:"apiPort,omitempty"`
+
+	// APIAuthMode for the HTTP API server. Changing it restarts the listener.
+	// Defaults to the API_AUTH_MODE environment variable or none.
+	// +kubebuilder:validation:Enum=none;kubernetes;apikey
+	APIAuthMode string `json:"apiAuthMode,omitempty"`
 }
 
 // WebhookTLSConfig defines TLS configuration for webhooks
@@ -431,6 +889,42 @@ type OperatorConfigSpec struct {
 	// +kubebuilder:validation:Minimum=1
 	CircuitBreakerThreshold int32 `json:"circuitBreakerThreshold,omitempty"`
 
+	// CircuitBreakerScope is what one circuit breaker guards, so failing
+	// resizes in one namespace or on one node do not stop the others
+	// +kubebuilder:default="namespace"
+	// +kubebuilder:validation:Enum=cluster;namespace;node
+	CircuitBreakerScope string `json:"circuitBreakerScope,omitempty"`
+
+	// CircuitBreakerRecoveryTimeout is how long an open circuit breaker
+	// rejects resizes before letting a trial resize through
+	// +kubebuilder:default="30s"
+	CircuitBreakerRecoveryTimeout string `json:"circuitBreakerRecoveryTimeout,omitempty"`
+
+	// CircuitBreakerSuccessThreshold is the number of successful trial
+	// resizes that close a circuit breaker again
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=1
+	CircuitBreakerSuccessThreshold int32 `json:"circuitBreakerSuccessThreshold,omitempty"`
+
+	// AdaptiveApplyPacing adapts the delay between resizes to the API
+	// server, slowing down on throttling, server errors and slow calls and
+	// speeding up while it is idle
+	// +kubebuilder:default=true
+	AdaptiveApplyPacing bool `json:"adaptiveApplyPacing,omitempty"`
+
+	// ApplyLatencyTarget is the resize call latency above which the API
+	// server counts as congested
+	// +kubebuilder:default="1s"
+	ApplyLatencyTarget string `json:"applyLatencyTarget,omitempty"`
+
+	// MinApplyDelay is the shortest delay between resizes while the API server is idle
+	// +kubebuilder:default="100ms"
+	MinApplyDelay string `json:"minApplyDelay,omitempty"`
+
+	// MaxApplyDelay is the longest delay between resizes while the API server is congested
+	// +kubebuilder:default="30s"
+	MaxApplyDelay string `json:"maxApplyDelay,omitempty"`
+
 	// ReconcileInterval for reconciliation loop
 	// +kubebuilder:default="10m"
 	ReconcileInterval string `json:"reconcileInterval,omitempty"`
@@ -459,6 +953,23 @@ type OperatorConfigSpec struct {
 	// +kubebuilder:validation:Maximum=20
 	MaxConcurrentReconciles int32 `json:"maxConcurrentReconciles,omitempty"`
 
+	// MaxAnalysisWorkers is the number of pods analyzed concurrently each cycle
+	// +kubebuilder:default=4
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	MaxAnalysisWorkers int32 `json:"maxAnalysisWorkers,omitempty"`
+
+	// MaxPodsPerCycle caps the pods analyzed per cycle, the next cycle
+	// resuming where the last one stopped. 0 analyzes every pod.
+	// +kubebuilder:validation:Minimum=0
+	MaxPodsPerCycle int32 `json:"maxPodsPerCycle,omitempty"`
+
+	// MaxUpdatesPerCycle caps the resizes applied per cycle, the most urgent
+	// first; the others wait for the next cycle
+	// +kubebuilder:default=50
+	// +kubebuilder:validation:Minimum=1
+	MaxUpdatesPerCycle int32 `json:"maxUpdatesPerCycle,omitempty"`
+
 	// HealthProbePort for health probe
 	// +kubebuilder:default=8081
 	HealthProbePort int32 `json:"healthProbePort,omitempty"`
@@ -507,6 +1018,118 @@ type NamespaceConfigSpec struct {
 	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
 }
 
+// WorkloadExclusion excludes the pods matching all of its criteria
+type WorkloadExclusion struct {
+	// LabelSelector the labels of excluded pods match
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// OwnerKinds of excluded pods, matched against the pod's controller and
+	// the workload owning it (e.g. DaemonSet, StatefulSet, ReplicaSet, Deployment, Pod)
+	OwnerKinds []string `json:"ownerKinds,omitempty"`
+}
+
+// NamespaceOverrideSpec overrides the default resource strategy for some namespaces.
+// When several overrides list the same namespace, the first one applies.
+type NamespaceOverrideSpec struct {
+	// Namespaces the override applies to
+	// +kubebuilder:validation:MinItems=1
+	Namespaces []string `json:"namespaces"`
+
+	// CPU settings overriding the default CPU strategy
+	CPU ResourceStrategyOverride `json:"cpu,omitempty"`
+
+	// Memory settings overriding the default memory strategy
+	Memory ResourceStrategyOverride `json:"memory,omitempty"`
+}
+
+// ResourceStrategyOverride overrides settings of a resource's default strategy.
+// Unset fields keep the global value. Additions are in millicores for CPU and
+// MB for memory.
+type ResourceStrategyOverride struct {
+	// RequestMultiplier applied to usage for requests
+	// +kubebuilder:validation:Minimum=0.1
+	// +kubebuilder:validation:Maximum=10
+	RequestMultiplier float64 `json:"requestMultiplier,omitempty"`
+
+	// RequestAddition added to requests
+	// +kubebuilder:validation:Minimum=0
+	RequestAddition int64 `json:"requestAddition,omitempty"`
+
+	// LimitMultiplier applied to requests for limits
+	// +kubebuilder:validation:Minimum=0.1
+	// +kubebuilder:validation:Maximum=10
+	LimitMultiplier float64 `json:"limitMultiplier,omitempty"`
+
+	// LimitAddition added to limits
+	// +kubebuilder:validation:Minimum=0
+	LimitAddition int64 `json:"limitAddition,omitempty"`
+
+	// MinRequest is the smallest request set
+	MinRequest string `json:"minRequest,omitempty"`
+
+	// MaxLimit is the largest limit set
+	MaxLimit string `json:"maxLimit,omitempty"`
+
+	// ScaleUpThreshold is the usage percentage (0-1) that triggers scale up
+	// +kubebuilder:validation:Minimum=0.1
+	// +kubebuilder:validation:Maximum=1.0
+	ScaleUpThreshold float64 `json:"scaleUpThreshold,omitempty"`
+
+	// ScaleDownThreshold is the usage percentage (0-1) that triggers scale down
+	// +kubebuilder:validation:Minimum=0.1
+	// +kubebuilder:validation:Maximum=1.0
+	ScaleDownThreshold float64 `json:"scaleDownThreshold,omitempty"`
+}
+
+// ReportingSpec schedules savings and efficiency reports of the cluster and
+// configures where they are delivered
+type ReportingSpec struct {
+	// Periods lists the reports generated: weekly reports cover the week up
+	// to Monday 00:00 UTC and monthly reports the previous calendar month.
+	// No reports are generated when empty.
+	// +kubebuilder:validation:items:Enum=weekly;monthly
+	Periods []string `json:"periods,omitempty"`
+
+	// Formats lists the formats each report is rendered in
+	// +kubebuilder:validation:items:Enum=csv;json;html
+	// +kubebuilder:default={"html"}
+	Formats []string `json:"formats,omitempty"`
+
+	// Notify sends the reports through the notification channels, attached
+	// to emails and summarized for the other channels
+	// +kubebuilder:default=false
+	Notify bool `json:"notify,omitempty"`
+
+	// S3 writes the reports to object storage
+	S3 *ReportS3Spec `json:"s3,omitempty"`
+}
+
+// ReportS3Spec configures the object storage reports are written to
+type ReportS3Spec struct {
+	// Bucket the reports are written to
+	Bucket string `json:"bucket"`
+
+	// Endpoint of the object storage API, https://s3.<region>.amazonaws.com by
+	// default; use https://storage.googleapis.com for GCS
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region used to sign requests
+	// +kubebuilder:default=us-east-1
+	Region string `json:"region,omitempty"`
+
+	// Prefix of the object keys
+	// +kubebuilder:default="right-sizer/reports"
+	Prefix string `json:"prefix,omitempty"`
+
+	// PathStyle addresses the bucket in the path instead of the host name, as MinIO requires
+	// +kubebuilder:default=false
+	PathStyle bool `json:"pathStyle,omitempty"`
+
+	// CredentialsSecret names a secret in the operator's namespace with
+	// accessKeyId and secretAccessKey keys
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
 // NotificationConfigSpec configures notifications
 type NotificationConfigSpec struct {
 	// EnableNotifications globally enables notifications
@@ -516,6 +1139,12 @@ type NotificationConfigSpec struct {
 	// SlackConfig for Slack notifications
 	SlackConfig *SlackNotificationConfig `json:"slackConfig,omitempty"`
 
+	// TeamsConfig for Microsoft Teams notifications
+	TeamsConfig *TeamsNotificationConfig `json:"teamsConfig,omitempty"`
+
+	// PagerDutyConfig for PagerDuty notifications
+	PagerDutyConfig *PagerDutyNotificationConfig `json:"pagerDutyConfig,omitempty"`
+
 	// EmailConfig for email notifications
 	EmailConfig *EmailNotificationConfig `json:"emailConfig,omitempty"`
 
@@ -545,6 +1174,18 @@ type SlackNotificationConfig struct {
 	IconEmoji string `json:"iconEmoji,omitempty"`
 }
 
+// TeamsNotificationConfig defines Microsoft Teams notification settings
+type TeamsNotificationConfig struct {
+	// WebhookURL of the Teams incoming webhook
+	WebhookURL string `json:"webhookURL"`
+}
+
+// PagerDutyNotificationConfig defines PagerDuty notification settings
+type PagerDutyNotificationConfig struct {
+	// RoutingKeySecretRef selects the Events API v2 integration key
+	RoutingKeySecretRef corev1.SecretKeySelector `json:"routingKeySecretRef"`
+}
+
 // EmailNotificationConfig defines email notification settings
 type EmailNotificationConfig struct {
 	// SMTPServer address
@@ -604,6 +1245,18 @@ type RightSizerConfigStatus struct {
 	// LastAppliedTime when the configuration was last applied
 	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
 
+	// LastReloadTime when the operator last loaded the configuration, whether
+	// or not it could be applied
+	LastReloadTime *metav1.Time `json:"lastReloadTime,omitempty"`
+
+	// ValidationErrors lists the settings that were invalid and ignored, in
+	// favor of their current or default values
+	ValidationErrors []string `json:"validationErrors,omitempty"`
+
+	// EffectiveConfig is the configuration the operator resolved from the
+	// spec and its defaults
+	EffectiveConfig *EffectiveConfigStatus `json:"effectiveConfig,omitempty"`
+
 	// ActivePolicies count of active policies
 	ActivePolicies int32 `json:"activePolicies,omitempty"`
 
@@ -626,6 +1279,72 @@ type RightSizerConfigStatus struct {
 	SystemHealth *SystemHealthStatus `json:"systemHealth,omitempty"`
 }
 
+// EffectiveConfigStatus is the resolved configuration the operator runs with
+type EffectiveConfigStatus struct {
+	// ResizeInterval between sizing cycles
+	ResizeInterval string `json:"resizeInterval,omitempty"`
+
+	// DryRun reports whether resizes are only logged
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// RecommendationOnly reports whether recommendations are written instead of resizing
+	RecommendationOnly bool `json:"recommendationOnly,omitempty"`
+
+	// Algorithm sizing resources from usage
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// Percentile of usage the percentile algorithm sizes from
+	Percentile int32 `json:"percentile,omitempty"`
+
+	// PercentileWindow the percentile is computed over
+	PercentileWindow string `json:"percentileWindow,omitempty"`
+
+	// CPURequestMultiplier applied to CPU usage
+	CPURequestMultiplier float64 `json:"cpuRequestMultiplier,omitempty"`
+
+	// MemoryRequestMultiplier applied to memory usage
+	MemoryRequestMultiplier float64 `json:"memoryRequestMultiplier,omitempty"`
+
+	// CPULimitMultiplier applied to CPU requests
+	CPULimitMultiplier float64 `json:"cpuLimitMultiplier,omitempty"`
+
+	// MemoryLimitMultiplier applied to memory requests
+	MemoryLimitMultiplier float64 `json:"memoryLimitMultiplier,omitempty"`
+
+	// MinCPURequest and MaxCPULimit bound CPU, e.g. 10m and 4000m
+	MinCPURequest string `json:"minCPURequest,omitempty"`
+	MaxCPULimit   string `json:"maxCPULimit,omitempty"`
+
+	// MinMemoryRequest and MaxMemoryLimit bound memory, e.g. 64Mi and 8192Mi
+	MinMemoryRequest string `json:"minMemoryRequest,omitempty"`
+	MaxMemoryLimit   string `json:"maxMemoryLimit,omitempty"`
+
+	// CooldownPeriod between resizes of the same container
+	CooldownPeriod string `json:"cooldownPeriod,omitempty"`
+
+	// MinPodAge before a pod is analyzed
+	MinPodAge string `json:"minPodAge,omitempty"`
+
+	// ScaleDownDelay of sustained low usage before a resource is sized down
+	ScaleDownDelay string `json:"scaleDownDelay,omitempty"`
+
+	// MaxResizesPerNode in flight at once
+	MaxResizesPerNode int32 `json:"maxResizesPerNode,omitempty"`
+
+	// NodeCapacityStrategy for upsizes that do not fit their node
+	NodeCapacityStrategy string `json:"nodeCapacityStrategy,omitempty"`
+
+	// WorkloadAggregation across replicas
+	WorkloadAggregation string `json:"workloadAggregation,omitempty"`
+
+	// MetricsProvider usage is read from
+	MetricsProvider string `json:"metricsProvider,omitempty"`
+
+	// IncludeNamespaces and ExcludeNamespaces filter the namespaces sized
+	IncludeNamespaces []string `json:"includeNamespaces,omitempty"`
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+}
+
 // SystemHealthStatus provides system health information
 type SystemHealthStatus struct {
 	// MetricsProviderHealthy indicates metrics provider health
@@ -53,11 +53,29 @@ type RightSizerPolicySpec struct {
 	// +kubebuilder:validation:Maximum=1000
 	Priority int32 `json:"priority,omitempty"`
 
+	// MergeStrategy controls how this policy combines with lower-priority
+	// policies selecting the same workload: merge fills the settings it leaves
+	// unset from them, override ignores them
+	// +kubebuilder:validation:Enum=merge;override
+	// +kubebuilder:default=merge
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
+
 	// Mode defines the sizing mode for this policy
 	// +kubebuilder:validation:Enum=aggressive;balanced;conservative;custom
 	// +kubebuilder:default=balanced
 	Mode string `json:"mode,omitempty"`
 
+	// Profile selects the sizing profile of the targeted workloads: steady,
+	// bursty, batch or a profile registered by the operator
+	Profile string `json:"profile,omitempty"`
+
+	// QoSMode controls how resizes treat the QoS class of the targeted pods:
+	// preserve keeps Guaranteed pods Guaranteed, allow-burstable sizes limits
+	// independently, force-guaranteed sets limits to requests. Unset uses the
+	// global preserveGuaranteedQoS setting.
+	// +kubebuilder:validation:Enum=preserve;allow-burstable;force-guaranteed
+	QoSMode string `json:"qosMode,omitempty"`
+
 	// DryRun enables dry-run mode for this policy
 	// +kubebuilder:default=false
 	DryRun bool `json:"dryRun,omitempty"`
@@ -65,6 +83,10 @@ type RightSizerPolicySpec struct {
 	// TargetRef defines which resources this policy applies to
 	TargetRef TargetReference `json:"targetRef"`
 
+	// Exclusions keep workloads in the namespaces of this policy from being
+	// right-sized, whether or not the target reference selects them
+	Exclusions []WorkloadExclusion `json:"exclusions,omitempty"`
+
 	// ResourceStrategy defines how resources should be calculated
 	ResourceStrategy ResourceStrategy `json:"resourceStrategy,omitempty"`
 
@@ -139,6 +161,36 @@ type ResourceStrategy struct {
 	// +kubebuilder:validation:Enum=immediate;rolling;scheduled
 	// +kubebuilder:default=rolling
 	UpdateMode string `json:"updateMode,omitempty"`
+
+	// CustomMetrics size CPU or memory from application metrics served by the
+	// custom metrics API (custom.metrics.k8s.io), blended with observed usage
+	CustomMetrics []CustomMetricRule `json:"customMetrics,omitempty"`
+}
+
+// CustomMetricRule sizes a resource from a per-pod custom metric such as
+// queue depth, requests per second or latency
+type CustomMetricRule struct {
+	// Metric is the name of the pod metric, e.g. http_requests_per_second
+	Metric string `json:"metric"`
+
+	// Resource sized from the metric
+	// +kubebuilder:validation:Enum=cpu;memory
+	Resource string `json:"resource"`
+
+	// PerUnit is the amount of the resource needed per unit of the metric,
+	// e.g. 5m of CPU per request per second or 1Mi of memory per queued item
+	PerUnit string `json:"perUnit"`
+
+	// Container the rule applies to; the pod's first container when empty
+	// +optional
+	Container string `json:"container,omitempty"`
+
+	// Weight is the percentage of the usage taken from the metric, the rest
+	// coming from observed usage. When unset the larger of the two is used.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
 }
 
 // CPUStrategy defines CPU resource calculation strategy
@@ -173,6 +225,12 @@ type CPUStrategy struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
 	TargetUtilization *int32 `json:"targetUtilization,omitempty"`
+
+	// Aggregation reduces the CPU usage history to the usage sized from,
+	// overriding the global setting
+	// +kubebuilder:validation:Enum=latest;average;max;percentile
+	// +optional
+	Aggregation string `json:"aggregation,omitempty"`
 }
 
 // MemoryStrategy defines Memory resource calculation strategy
@@ -207,6 +265,26 @@ type MemoryStrategy struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
 	TargetUtilization *int32 `json:"targetUtilization,omitempty"`
+
+	// Aggregation reduces the memory usage history to the usage sized from,
+	// overriding the global setting
+	// +kubebuilder:validation:Enum=latest;average;max;percentile
+	// +optional
+	Aggregation string `json:"aggregation,omitempty"`
+
+	// Metric is the memory reading sized from, overriding the global
+	// memoryMetric: usage, workingSet or rss
+	// +kubebuilder:validation:Enum=usage;workingSet;rss
+	// +optional
+	Metric string `json:"metric,omitempty"`
+
+	// SpecialMemory handles containers whose memory the usage heuristics
+	// misjudge: those mounting memory-backed emptyDir volumes or requesting
+	// hugepages. floor keeps their memory at or above the size of their
+	// tmpfs volumes, skip leaves their memory unchanged.
+	// +kubebuilder:validation:Enum=floor;skip
+	// +optional
+	SpecialMemory string `json:"specialMemory,omitempty"`
 }
 
 // PrometheusConfig defines Prometheus configuration
@@ -263,8 +341,33 @@ type ScheduleSpec struct {
 	// CronSchedule for cron-based evaluation
 	CronSchedule string `json:"cronSchedule,omitempty"`
 
+	// ResizeInterval is how often the pods the policy selects are analyzed
+	// and resized (e.g., "15m", "1h"), instead of the global resizeInterval
+	ResizeInterval string `json:"resizeInterval,omitempty"`
+
 	// TimeWindows when the policy is active
 	TimeWindows []TimeWindow `json:"timeWindows,omitempty"`
+
+	// AllowedWindows restricts resizes to these maintenance windows (empty means always allowed).
+	// Decisions made outside a window are queued and applied when one opens.
+	AllowedWindows []MaintenanceWindow `json:"allowedWindows,omitempty"`
+
+	// BlockedWindows during which no resizes are applied, even inside an allowed window
+	BlockedWindows []MaintenanceWindow `json:"blockedWindows,omitempty"`
+
+	// Timezone the window schedules are evaluated in
+	// +kubebuilder:default="UTC"
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window that opens on a cron schedule
+type MaintenanceWindow struct {
+	// Schedule is a cron expression (minute hour day-of-month month day-of-week)
+	// for when the window opens, e.g. "0 22 * * 1-5" for weekday nights
+	Schedule string `json:"schedule"`
+
+	// Duration the window stays open (e.g., "30m", "8h")
+	Duration string `json:"duration"`
 }
 
 // TimeWindow defines a time window when the policy is active
@@ -291,6 +394,12 @@ type ResourceConstraints struct {
 	// +kubebuilder:validation:Maximum=100
 	MaxChangePercentage *int32 `json:"maxChangePercentage,omitempty"`
 
+	// MaxScaleUpPercentage overrides the step limit for increases, e.g. 300
+	// to let workloads that must not be starved catch up in one resize; 0
+	// for no limit
+	// +kubebuilder:validation:Minimum=0
+	MaxScaleUpPercentage *int32 `json:"maxScaleUpPercentage,omitempty"`
+
 	// MinChangeThreshold below which changes are not applied (percentage)
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=100
@@ -300,6 +409,26 @@ type ResourceConstraints struct {
 	// +kubebuilder:default="5m"
 	CooldownPeriod string `json:"cooldownPeriod,omitempty"`
 
+	// MinPodAge overrides how long a pod must have been running, and ready,
+	// before it is analyzed
+	MinPodAge string `json:"minPodAge,omitempty"`
+
+	// ScaleDownDelay overrides how long usage must stay below the scale-down
+	// threshold before a resource is sized down
+	ScaleDownDelay string `json:"scaleDownDelay,omitempty"`
+
+	// MaxNetworkMbps skips resizing a pod while its network throughput,
+	// received plus transmitted, is above this many megabits per second.
+	// Needs a metrics provider with I/O metrics, such as Prometheus.
+	// +kubebuilder:validation:Minimum=1
+	MaxNetworkMbps *int32 `json:"maxNetworkMbps,omitempty"`
+
+	// MaxDiskIOMBps skips resizing a pod while its disk throughput, read plus
+	// written, is above this many megabytes per second. Needs a metrics
+	// provider with I/O metrics, such as Prometheus.
+	// +kubebuilder:validation:Minimum=1
+	MaxDiskIOMBps *int32 `json:"maxDiskIOMBps,omitempty"`
+
 	// RespectPDB ensures PodDisruptionBudgets are respected
 	// +kubebuilder:default=true
 	RespectPDB bool `json:"respectPDB,omitempty"`
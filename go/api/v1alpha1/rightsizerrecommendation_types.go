@@ -0,0 +1,183 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=rsr
+// +kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.targetRef.kind`
+// +kubebuilder:printcolumn:name="Target",type=string,JSONPath=`.spec.targetRef.name`
+// +kubebuilder:printcolumn:name="Window",type=string,JSONPath=`.status.dataWindow`
+// +kubebuilder:printcolumn:name="Updated",type=date,JSONPath=`.status.lastUpdateTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RightSizerRecommendation is the Schema for the rightsizerrecommendations API
+// The operator writes one per workload with the resources it would apply
+type RightSizerRecommendation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RightSizerRecommendationSpec   `json:"spec,omitempty"`
+	Status RightSizerRecommendationStatus `json:"status,omitempty"`
+}
+
+// RightSizerRecommendationSpec defines the workload a recommendation is for
+type RightSizerRecommendationSpec struct {
+	// TargetRef identifies the workload the recommendation applies to
+	TargetRef RecommendationTargetRef `json:"targetRef"`
+}
+
+// RecommendationTargetRef identifies a workload by kind and name
+type RecommendationTargetRef struct {
+	// APIVersion of the target workload
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the target workload (Deployment, StatefulSet, DaemonSet, Pod, ...)
+	Kind string `json:"kind"`
+
+	// Name of the target workload
+	Name string `json:"name"`
+}
+
+// RightSizerRecommendationStatus holds the latest recommendation for the workload
+type RightSizerRecommendationStatus struct {
+	// ContainerRecommendations lists the suggested resources per container
+	ContainerRecommendations []ContainerRecommendation `json:"containerRecommendations,omitempty"`
+
+	// Algorithm used to compute the recommendation (percentile, peak, average)
+	Algorithm string `json:"algorithm,omitempty"`
+
+	// DataWindow is the span of usage history the recommendation is based on
+	DataWindow string `json:"dataWindow,omitempty"`
+
+	// LastUpdateTime when the recommendation was last written
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+
+	// SafetyMargin is the extra headroom learned from the workload's resize history
+	SafetyMargin *SafetyMarginStatus `json:"safetyMargin,omitempty"`
+
+	// Horizontal suggests running the workload on a different number of
+	// replicas when that is cheaper than the vertical recommendation alone.
+	// It is advisory and never applied.
+	Horizontal *HorizontalRecommendation `json:"horizontal,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ContainerRecommendation holds the suggested resources for a single container
+type ContainerRecommendation struct {
+	// ContainerName is the name of the container
+	ContainerName string `json:"containerName"`
+
+	// Current resources observed on the container
+	Current corev1.ResourceRequirements `json:"current,omitempty"`
+
+	// Recommended resources for the container
+	Recommended corev1.ResourceRequirements `json:"recommended"`
+
+	// Confidence in the recommendation as a percentage (0-100)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Confidence int32 `json:"confidence,omitempty"`
+
+	// Samples is the number of usage samples the recommendation is based on
+	Samples int32 `json:"samples,omitempty"`
+
+	// Reason describes why the change is recommended
+	Reason string `json:"reason,omitempty"`
+
+	// VPATarget is the target of the workload's VerticalPodAutoscaler, for
+	// comparison, when the VPA mode is compare
+	VPATarget corev1.ResourceList `json:"vpaTarget,omitempty"`
+}
+
+// HorizontalRecommendation suggests a replica count for a workload and the
+// resources of its containers at that count
+type HorizontalRecommendation struct {
+	// CurrentReplicas is the number of replicas the workload runs
+	CurrentReplicas int32 `json:"currentReplicas"`
+
+	// RecommendedReplicas is the suggested number of replicas
+	RecommendedReplicas int32 `json:"recommendedReplicas"`
+
+	// HorizontalPodAutoscaler scaling the workload, if any. Its replica
+	// bounds and CPU utilization target are taken into account.
+	HorizontalPodAutoscaler string `json:"horizontalPodAutoscaler,omitempty"`
+
+	// Containers lists the suggested resources of each container at the
+	// recommended replica count
+	Containers []HorizontalContainerRecommendation `json:"containers,omitempty"`
+
+	// SavingsPercent is how much cheaper the suggestion is than running the
+	// current replica count with the vertical recommendation
+	SavingsPercent int32 `json:"savingsPercent,omitempty"`
+
+	// Reason describes the suggestion
+	Reason string `json:"reason,omitempty"`
+}
+
+// HorizontalContainerRecommendation holds the suggested resources of a
+// container at the recommended replica count
+type HorizontalContainerRecommendation struct {
+	// ContainerName is the name of the container
+	ContainerName string `json:"containerName"`
+
+	// Recommended resources for the container
+	Recommended corev1.ResourceRequirements `json:"recommended"`
+}
+
+// SafetyMarginStatus tracks how often resizes of a workload went wrong and
+// the extra headroom its recommendations get as a result
+type SafetyMarginStatus struct {
+	// HeadroomPercent is added on top of the recommended requests and limits
+	// +kubebuilder:validation:Minimum=0
+	HeadroomPercent int32 `json:"headroomPercent"`
+
+	// Resizes is the number of container resizes applied to the workload
+	Resizes int32 `json:"resizes,omitempty"`
+
+	// Rollbacks is the number of resizes rolled back because the kubelet could not apply them
+	Rollbacks int32 `json:"rollbacks,omitempty"`
+
+	// Restarts is the number of container restarts and OOM kills that followed a resize
+	Restarts int32 `json:"restarts,omitempty"`
+
+	// CleanResizes counts the resizes since the last incident; the headroom
+	// shrinks after a streak of them
+	CleanResizes int32 `json:"cleanResizes,omitempty"`
+
+	// LastIncidentTime is when a resize of the workload last went wrong
+	LastIncidentTime *metav1.Time `json:"lastIncidentTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RightSizerRecommendationList contains a list of RightSizerRecommendation
+type RightSizerRecommendationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RightSizerRecommendation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RightSizerRecommendation{}, &RightSizerRecommendationList{})
+}
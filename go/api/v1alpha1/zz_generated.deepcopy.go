@@ -10,6 +10,51 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuditSinksSpec) DeepCopyInto(out *AuditSinksSpec) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3AuditSinkSpec)
+		**out = **in
+	}
+	if in.Elasticsearch != nil {
+		in, out := &in.Elasticsearch, &out.Elasticsearch
+		*out = new(ElasticsearchAuditSinkSpec)
+		**out = **in
+	}
+	if in.Kafka != nil {
+		in, out := &in.Kafka, &out.Kafka
+		*out = new(KafkaAuditSinkSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuditSinksSpec.
+func (in *AuditSinksSpec) DeepCopy() *AuditSinksSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuditSinksSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalerConfigSpec) DeepCopyInto(out *AutoscalerConfigSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalerConfigSpec.
+func (in *AutoscalerConfigSpec) DeepCopy() *AutoscalerConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalerConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BasicAuth) DeepCopyInto(out *BasicAuth) {
 	*out = *in
@@ -76,6 +121,50 @@ func (in *CPUStrategy) DeepCopy() *CPUStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRecommendation) DeepCopyInto(out *ContainerRecommendation) {
+	*out = *in
+	in.Current.DeepCopyInto(&out.Current)
+	in.Recommended.DeepCopyInto(&out.Recommended)
+	if in.VPATarget != nil {
+		in, out := &in.VPATarget, &out.VPATarget
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRecommendation.
+func (in *ContainerRecommendation) DeepCopy() *ContainerRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomMetricRule) DeepCopyInto(out *CustomMetricRule) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricRule.
+func (in *CustomMetricRule) DeepCopy() *CustomMetricRule {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomMetricRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DefaultCPUStrategy) DeepCopyInto(out *DefaultCPUStrategy) {
 	*out = *in
@@ -111,6 +200,11 @@ func (in *DefaultResourceStrategySpec) DeepCopyInto(out *DefaultResourceStrategy
 	*out = *in
 	out.CPU = in.CPU
 	out.Memory = in.Memory
+	if in.HorizontalAdvice != nil {
+		in, out := &in.HorizontalAdvice, &out.HorizontalAdvice
+		*out = new(HorizontalAdviceSpec)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultResourceStrategySpec.
@@ -123,6 +217,46 @@ func (in *DefaultResourceStrategySpec) DeepCopy() *DefaultResourceStrategySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ElasticsearchAuditSinkSpec) DeepCopyInto(out *ElasticsearchAuditSinkSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ElasticsearchAuditSinkSpec.
+func (in *ElasticsearchAuditSinkSpec) DeepCopy() *ElasticsearchAuditSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ElasticsearchAuditSinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveConfigStatus) DeepCopyInto(out *EffectiveConfigStatus) {
+	*out = *in
+	if in.IncludeNamespaces != nil {
+		in, out := &in.IncludeNamespaces, &out.IncludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeNamespaces != nil {
+		in, out := &in.ExcludeNamespaces, &out.ExcludeNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveConfigStatus.
+func (in *EffectiveConfigStatus) DeepCopy() *EffectiveConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EmailNotificationConfig) DeepCopyInto(out *EmailNotificationConfig) {
 	*out = *in
@@ -148,9 +282,129 @@ func (in *EmailNotificationConfig) DeepCopy() *EmailNotificationConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CostConfigSpec) DeepCopyInto(out *CostConfigSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CostConfigSpec.
+func (in *CostConfigSpec) DeepCopy() *CostConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CostConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportConfigSpec) DeepCopyInto(out *ExportConfigSpec) {
+	*out = *in
+	if in.Git != nil {
+		in, out := &in.Git, &out.Git
+		*out = new(GitExportSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportConfigSpec.
+func (in *ExportConfigSpec) DeepCopy() *ExportConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitExportSpec) DeepCopyInto(out *GitExportSpec) {
+	*out = *in
+	if in.AuthSecretRef != nil {
+		in, out := &in.AuthSecretRef, &out.AuthSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitExportSpec.
+func (in *GitExportSpec) DeepCopy() *GitExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GitExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanarySpec) DeepCopyInto(out *CanarySpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanarySpec.
+func (in *CanarySpec) DeepCopy() *CanarySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CanarySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChangeBudgetSpec) DeepCopyInto(out *ChangeBudgetSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChangeBudgetSpec.
+func (in *ChangeBudgetSpec) DeepCopy() *ChangeBudgetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ChangeBudgetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelfSizingSpec) DeepCopyInto(out *SelfSizingSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfSizingSpec.
+func (in *SelfSizingSpec) DeepCopy() *SelfSizingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SelfSizingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalConstraintsSpec) DeepCopyInto(out *GlobalConstraintsSpec) {
 	*out = *in
+	if in.ChangeBudget != nil {
+		in, out := &in.ChangeBudget, &out.ChangeBudget
+		*out = new(ChangeBudgetSpec)
+		**out = **in
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanarySpec)
+		**out = **in
+	}
+	if in.SelfSizing != nil {
+		in, out := &in.SelfSizing, &out.SelfSizing
+		*out = new(SelfSizingSpec)
+		**out = **in
+	}
+	if in.DecisionFilters != nil {
+		in, out := &in.DecisionFilters, &out.DecisionFilters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalConstraintsSpec.
@@ -163,6 +417,36 @@ func (in *GlobalConstraintsSpec) DeepCopy() *GlobalConstraintsSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KafkaAuditSinkSpec) DeepCopyInto(out *KafkaAuditSinkSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KafkaAuditSinkSpec.
+func (in *KafkaAuditSinkSpec) DeepCopy() *KafkaAuditSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KafkaAuditSinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MemoryStrategy) DeepCopyInto(out *MemoryStrategy) {
 	*out = *in
@@ -223,6 +507,11 @@ func (in *MetricsConfigSpec) DeepCopyInto(out *MetricsConfigSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.CustomMetrics != nil {
+		in, out := &in.CustomMetrics, &out.CustomMetrics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsConfigSpec.
@@ -291,6 +580,28 @@ func (in *NamespaceConfigSpec) DeepCopy() *NamespaceConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceOverrideSpec) DeepCopyInto(out *NamespaceOverrideSpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.CPU = in.CPU
+	out.Memory = in.Memory
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceOverrideSpec.
+func (in *NamespaceOverrideSpec) DeepCopy() *NamespaceOverrideSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceOverrideSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NotificationConfigSpec) DeepCopyInto(out *NotificationConfigSpec) {
 	*out = *in
@@ -299,6 +610,16 @@ func (in *NotificationConfigSpec) DeepCopyInto(out *NotificationConfigSpec) {
 		*out = new(SlackNotificationConfig)
 		**out = **in
 	}
+	if in.TeamsConfig != nil {
+		in, out := &in.TeamsConfig, &out.TeamsConfig
+		*out = new(TeamsNotificationConfig)
+		**out = **in
+	}
+	if in.PagerDutyConfig != nil {
+		in, out := &in.PagerDutyConfig, &out.PagerDutyConfig
+		*out = new(PagerDutyNotificationConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.EmailConfig != nil {
 		in, out := &in.EmailConfig, &out.EmailConfig
 		*out = new(EmailNotificationConfig)
@@ -326,6 +647,7 @@ func (in *NotificationConfigSpec) DeepCopy() *NotificationConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObservabilityConfigSpec) DeepCopyInto(out *ObservabilityConfigSpec) {
 	*out = *in
+	in.AuditSinks.DeepCopyInto(&out.AuditSinks)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilityConfigSpec.
@@ -353,6 +675,22 @@ func (in *OperatorConfigSpec) DeepCopy() *OperatorConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PagerDutyNotificationConfig) DeepCopyInto(out *PagerDutyNotificationConfig) {
+	*out = *in
+	in.RoutingKeySecretRef.DeepCopyInto(&out.RoutingKeySecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PagerDutyNotificationConfig.
+func (in *PagerDutyNotificationConfig) DeepCopy() *PagerDutyNotificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PagerDutyNotificationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PrometheusAuth) DeepCopyInto(out *PrometheusAuth) {
 	*out = *in
@@ -398,6 +736,66 @@ func (in *PrometheusConfig) DeepCopy() *PrometheusConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommendationTargetRef) DeepCopyInto(out *RecommendationTargetRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommendationTargetRef.
+func (in *RecommendationTargetRef) DeepCopy() *RecommendationTargetRef {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommendationTargetRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportS3Spec) DeepCopyInto(out *ReportS3Spec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportS3Spec.
+func (in *ReportS3Spec) DeepCopy() *ReportS3Spec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportS3Spec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReportingSpec) DeepCopyInto(out *ReportingSpec) {
+	*out = *in
+	if in.Periods != nil {
+		in, out := &in.Periods, &out.Periods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Formats != nil {
+		in, out := &in.Formats, &out.Formats
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(ReportS3Spec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportingSpec.
+func (in *ReportingSpec) DeepCopy() *ReportingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReportingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceConstraints) DeepCopyInto(out *ResourceConstraints) {
 	*out = *in
@@ -406,11 +804,26 @@ func (in *ResourceConstraints) DeepCopyInto(out *ResourceConstraints) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.MaxScaleUpPercentage != nil {
+		in, out := &in.MaxScaleUpPercentage, &out.MaxScaleUpPercentage
+		*out = new(int32)
+		**out = **in
+	}
 	if in.MinChangeThreshold != nil {
 		in, out := &in.MinChangeThreshold, &out.MinChangeThreshold
 		*out = new(int32)
 		**out = **in
 	}
+	if in.MaxNetworkMbps != nil {
+		in, out := &in.MaxNetworkMbps, &out.MaxNetworkMbps
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxDiskIOMBps != nil {
+		in, out := &in.MaxDiskIOMBps, &out.MaxDiskIOMBps
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceConstraints.
@@ -448,6 +861,13 @@ func (in *ResourceStrategy) DeepCopyInto(out *ResourceStrategy) {
 		*out = new(PrometheusConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.CustomMetrics != nil {
+		in, out := &in.CustomMetrics, &out.CustomMetrics
+		*out = make([]CustomMetricRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceStrategy.
@@ -460,6 +880,21 @@ func (in *ResourceStrategy) DeepCopy() *ResourceStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceStrategyOverride) DeepCopyInto(out *ResourceStrategyOverride) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceStrategyOverride.
+func (in *ResourceStrategyOverride) DeepCopy() *ResourceStrategyOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceStrategyOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RightSizerConfig) DeepCopyInto(out *RightSizerConfig) {
 	*out = *in
@@ -522,14 +957,37 @@ func (in *RightSizerConfigList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RightSizerConfigSpec) DeepCopyInto(out *RightSizerConfigSpec) {
 	*out = *in
-	out.DefaultResourceStrategy = in.DefaultResourceStrategy
-	out.GlobalConstraints = in.GlobalConstraints
+	in.ExportConfig.DeepCopyInto(&out.ExportConfig)
+	in.DefaultResourceStrategy.DeepCopyInto(&out.DefaultResourceStrategy)
+	in.GlobalConstraints.DeepCopyInto(&out.GlobalConstraints)
 	in.MetricsConfig.DeepCopyInto(&out.MetricsConfig)
-	out.ObservabilityConfig = in.ObservabilityConfig
+	out.CostConfig = in.CostConfig
+	out.AutoscalerConfig = in.AutoscalerConfig
+	in.ObservabilityConfig.DeepCopyInto(&out.ObservabilityConfig)
 	in.SecurityConfig.DeepCopyInto(&out.SecurityConfig)
 	out.OperatorConfig = in.OperatorConfig
 	in.NamespaceConfig.DeepCopyInto(&out.NamespaceConfig)
+	if in.Exclusions != nil {
+		in, out := &in.Exclusions, &out.Exclusions
+		*out = make([]WorkloadExclusion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ExcludedContainers != nil {
+		in, out := &in.ExcludedContainers, &out.ExcludedContainers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceOverrides != nil {
+		in, out := &in.NamespaceOverrides, &out.NamespaceOverrides
+		*out = make([]NamespaceOverrideSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.NotificationConfig.DeepCopyInto(&out.NotificationConfig)
+	in.Reporting.DeepCopyInto(&out.Reporting)
 	if in.FeatureGates != nil {
 		in, out := &in.FeatureGates, &out.FeatureGates
 		*out = make(map[string]bool, len(*in))
@@ -563,6 +1021,20 @@ func (in *RightSizerConfigStatus) DeepCopyInto(out *RightSizerConfigStatus) {
 		in, out := &in.LastAppliedTime, &out.LastAppliedTime
 		*out = (*in).DeepCopy()
 	}
+	if in.LastReloadTime != nil {
+		in, out := &in.LastReloadTime, &out.LastReloadTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ValidationErrors != nil {
+		in, out := &in.ValidationErrors, &out.ValidationErrors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EffectiveConfig != nil {
+		in, out := &in.EffectiveConfig, &out.EffectiveConfig
+		*out = new(EffectiveConfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SystemHealth != nil {
 		in, out := &in.SystemHealth, &out.SystemHealth
 		*out = new(SystemHealthStatus)
@@ -643,6 +1115,13 @@ func (in *RightSizerPolicyList) DeepCopyObject() runtime.Object {
 func (in *RightSizerPolicySpec) DeepCopyInto(out *RightSizerPolicySpec) {
 	*out = *in
 	in.TargetRef.DeepCopyInto(&out.TargetRef)
+	if in.Exclusions != nil {
+		in, out := &in.Exclusions, &out.Exclusions
+		*out = make([]WorkloadExclusion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.ResourceStrategy.DeepCopyInto(&out.ResourceStrategy)
 	in.Schedule.DeepCopyInto(&out.Schedule)
 	in.Constraints.DeepCopyInto(&out.Constraints)
@@ -708,6 +1187,139 @@ func (in *RightSizerPolicyStatus) DeepCopy() *RightSizerPolicyStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RightSizerRecommendation) DeepCopyInto(out *RightSizerRecommendation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RightSizerRecommendation.
+func (in *RightSizerRecommendation) DeepCopy() *RightSizerRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(RightSizerRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RightSizerRecommendation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RightSizerRecommendationList) DeepCopyInto(out *RightSizerRecommendationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RightSizerRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RightSizerRecommendationList.
+func (in *RightSizerRecommendationList) DeepCopy() *RightSizerRecommendationList {
+	if in == nil {
+		return nil
+	}
+	out := new(RightSizerRecommendationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RightSizerRecommendationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RightSizerRecommendationSpec) DeepCopyInto(out *RightSizerRecommendationSpec) {
+	*out = *in
+	out.TargetRef = in.TargetRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RightSizerRecommendationSpec.
+func (in *RightSizerRecommendationSpec) DeepCopy() *RightSizerRecommendationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RightSizerRecommendationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RightSizerRecommendationStatus) DeepCopyInto(out *RightSizerRecommendationStatus) {
+	*out = *in
+	if in.ContainerRecommendations != nil {
+		in, out := &in.ContainerRecommendations, &out.ContainerRecommendations
+		*out = make([]ContainerRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SafetyMargin != nil {
+		in, out := &in.SafetyMargin, &out.SafetyMargin
+		*out = new(SafetyMarginStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Horizontal != nil {
+		in, out := &in.Horizontal, &out.Horizontal
+		*out = new(HorizontalRecommendation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RightSizerRecommendationStatus.
+func (in *RightSizerRecommendationStatus) DeepCopy() *RightSizerRecommendationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RightSizerRecommendationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3AuditSinkSpec) DeepCopyInto(out *S3AuditSinkSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3AuditSinkSpec.
+func (in *S3AuditSinkSpec) DeepCopy() *S3AuditSinkSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3AuditSinkSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScheduleSpec) DeepCopyInto(out *ScheduleSpec) {
 	*out = *in
@@ -718,6 +1330,16 @@ func (in *ScheduleSpec) DeepCopyInto(out *ScheduleSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.AllowedWindows != nil {
+		in, out := &in.AllowedWindows, &out.AllowedWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlockedWindows != nil {
+		in, out := &in.BlockedWindows, &out.BlockedWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleSpec.
@@ -851,6 +1473,21 @@ func (in *TargetReference) DeepCopy() *TargetReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TeamsNotificationConfig) DeepCopyInto(out *TeamsNotificationConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TeamsNotificationConfig.
+func (in *TeamsNotificationConfig) DeepCopy() *TeamsNotificationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TeamsNotificationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TimeWindow) DeepCopyInto(out *TimeWindow) {
 	*out = *in
@@ -954,3 +1591,100 @@ func (in *WebhookTLSConfig) DeepCopy() *WebhookTLSConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadExclusion) DeepCopyInto(out *WorkloadExclusion) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OwnerKinds != nil {
+		in, out := &in.OwnerKinds, &out.OwnerKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadExclusion.
+func (in *WorkloadExclusion) DeepCopy() *WorkloadExclusion {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadExclusion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HorizontalAdviceSpec) DeepCopyInto(out *HorizontalAdviceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalAdviceSpec.
+func (in *HorizontalAdviceSpec) DeepCopy() *HorizontalAdviceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalAdviceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HorizontalContainerRecommendation) DeepCopyInto(out *HorizontalContainerRecommendation) {
+	*out = *in
+	in.Recommended.DeepCopyInto(&out.Recommended)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalContainerRecommendation.
+func (in *HorizontalContainerRecommendation) DeepCopy() *HorizontalContainerRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalContainerRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HorizontalRecommendation) DeepCopyInto(out *HorizontalRecommendation) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]HorizontalContainerRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalRecommendation.
+func (in *HorizontalRecommendation) DeepCopy() *HorizontalRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(HorizontalRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SafetyMarginStatus) DeepCopyInto(out *SafetyMarginStatus) {
+	*out = *in
+	if in.LastIncidentTime != nil {
+		in, out := &in.LastIncidentTime, &out.LastIncidentTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SafetyMarginStatus.
+func (in *SafetyMarginStatus) DeepCopy() *SafetyMarginStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SafetyMarginStatus)
+	in.DeepCopyInto(out)
+	return out
+}
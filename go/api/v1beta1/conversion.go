@@ -0,0 +1,134 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1beta1
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"right-sizer/api/v1alpha1"
+)
+
+// ConvertTo converts this RightSizerConfig to the v1alpha1 hub version
+func (r *RightSizerConfig) ConvertTo(hub conversion.Hub) error {
+	dst := hub.(*v1alpha1.RightSizerConfig)
+	src := r.DeepCopy()
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+	dst.Spec = v1alpha1.RightSizerConfigSpec{
+		Enabled:                 src.Spec.Enabled,
+		DefaultMode:             src.Spec.Mode,
+		ResizeInterval:          src.Spec.ResizeInterval,
+		DryRun:                  src.Spec.DryRun,
+		RecommendationOnly:      src.Spec.RecommendationOnly,
+		ExportConfig:            src.Spec.Export,
+		DefaultResourceStrategy: src.Spec.Defaults,
+		GlobalConstraints:       src.Spec.Constraints,
+		MetricsConfig:           src.Spec.Metrics,
+		CostConfig:              src.Spec.Cost,
+		AutoscalerConfig:        src.Spec.Autoscaler,
+		ObservabilityConfig:     src.Spec.Observability,
+		SecurityConfig:          src.Spec.Security,
+		OperatorConfig:          src.Spec.Operator,
+		NamespaceConfig:         src.Spec.Namespaces,
+		Exclusions:              src.Spec.Exclusions,
+		ExcludedContainers:      src.Spec.ExcludedContainers,
+		NamespaceOverrides:      src.Spec.NamespaceOverrides,
+		NotificationConfig:      src.Spec.Notifications,
+		Reporting:               src.Spec.Reporting,
+		FeatureGates:            src.Spec.FeatureGates,
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to this RightSizerConfig
+func (r *RightSizerConfig) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*v1alpha1.RightSizerConfig).DeepCopy()
+	r.ObjectMeta = src.ObjectMeta
+	r.Status = src.Status
+	r.Spec = RightSizerConfigSpec{
+		Enabled:            src.Spec.Enabled,
+		Mode:               src.Spec.DefaultMode,
+		ResizeInterval:     src.Spec.ResizeInterval,
+		DryRun:             src.Spec.DryRun,
+		RecommendationOnly: src.Spec.RecommendationOnly,
+		Export:             src.Spec.ExportConfig,
+		Defaults:           src.Spec.DefaultResourceStrategy,
+		Constraints:        src.Spec.GlobalConstraints,
+		Metrics:            src.Spec.MetricsConfig,
+		Cost:               src.Spec.CostConfig,
+		Autoscaler:         src.Spec.AutoscalerConfig,
+		Observability:      src.Spec.ObservabilityConfig,
+		Security:           src.Spec.SecurityConfig,
+		Operator:           src.Spec.OperatorConfig,
+		Namespaces:         src.Spec.NamespaceConfig,
+		Exclusions:         src.Spec.Exclusions,
+		ExcludedContainers: src.Spec.ExcludedContainers,
+		NamespaceOverrides: src.Spec.NamespaceOverrides,
+		Notifications:      src.Spec.NotificationConfig,
+		Reporting:          src.Spec.Reporting,
+		FeatureGates:       src.Spec.FeatureGates,
+	}
+	return nil
+}
+
+// ConvertTo converts this RightSizerPolicy to the v1alpha1 hub version
+func (r *RightSizerPolicy) ConvertTo(hub conversion.Hub) error {
+	dst := hub.(*v1alpha1.RightSizerPolicy)
+	src := r.DeepCopy()
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Status = src.Status
+	dst.Spec = v1alpha1.RightSizerPolicySpec{
+		Enabled:             src.Spec.Enabled,
+		Priority:            src.Spec.Priority,
+		MergeStrategy:       src.Spec.MergeStrategy,
+		Mode:                src.Spec.Mode,
+		Profile:             src.Spec.Profile,
+		QoSMode:             src.Spec.QoSMode,
+		DryRun:              src.Spec.DryRun,
+		TargetRef:           src.Spec.Target,
+		Exclusions:          src.Spec.Exclusions,
+		ResourceStrategy:    src.Spec.Resources,
+		Schedule:            src.Spec.Schedule,
+		Constraints:         src.Spec.Constraints,
+		Webhooks:            src.Spec.Webhooks,
+		ResourceAnnotations: src.Spec.PodAnnotations,
+	}
+	return nil
+}
+
+// ConvertFrom converts the v1alpha1 hub version to this RightSizerPolicy
+func (r *RightSizerPolicy) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*v1alpha1.RightSizerPolicy).DeepCopy()
+	r.ObjectMeta = src.ObjectMeta
+	r.Status = src.Status
+	r.Spec = RightSizerPolicySpec{
+		Enabled:        src.Spec.Enabled,
+		Priority:       src.Spec.Priority,
+		MergeStrategy:  src.Spec.MergeStrategy,
+		Mode:           src.Spec.Mode,
+		Profile:        src.Spec.Profile,
+		QoSMode:        src.Spec.QoSMode,
+		DryRun:         src.Spec.DryRun,
+		Target:         src.Spec.TargetRef,
+		Exclusions:     src.Spec.Exclusions,
+		Resources:      src.Spec.ResourceStrategy,
+		Schedule:       src.Spec.Schedule,
+		Constraints:    src.Spec.Constraints,
+		Webhooks:       src.Spec.Webhooks,
+		PodAnnotations: src.Spec.ResourceAnnotations,
+	}
+	return nil
+}
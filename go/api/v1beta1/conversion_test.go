@@ -0,0 +1,76 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"right-sizer/api/v1alpha1"
+)
+
+func TestRightSizerConfigConversion(t *testing.T) {
+	hub := &v1alpha1.RightSizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", ResourceVersion: "7"},
+		Spec: v1alpha1.RightSizerConfigSpec{
+			Enabled:                 true,
+			DefaultMode:             "conservative",
+			ResizeInterval:          "5m",
+			DefaultResourceStrategy: v1alpha1.DefaultResourceStrategySpec{Percentile: 95},
+			GlobalConstraints:       v1alpha1.GlobalConstraintsSpec{MaxChangePercentage: 30},
+			MetricsConfig:           v1alpha1.MetricsConfigSpec{Provider: "prometheus"},
+			SecurityConfig:          v1alpha1.SecurityConfigSpec{EnableAdmissionController: true},
+			NamespaceConfig:         v1alpha1.NamespaceConfigSpec{ExcludeNamespaces: []string{"kube-system"}},
+			FeatureGates:            map[string]bool{"inPlaceResize": true},
+		},
+		Status: v1alpha1.RightSizerConfigStatus{Phase: "Active"},
+	}
+
+	var config RightSizerConfig
+	require.NoError(t, config.ConvertFrom(hub))
+	assert.Equal(t, "default", config.Name)
+	assert.Equal(t, "conservative", config.Spec.Mode)
+	assert.Equal(t, int32(95), config.Spec.Defaults.Percentile)
+	assert.Equal(t, int32(30), config.Spec.Constraints.MaxChangePercentage)
+	assert.Equal(t, "prometheus", config.Spec.Metrics.Provider)
+	assert.True(t, config.Spec.Security.EnableAdmissionController)
+	assert.Equal(t, []string{"kube-system"}, config.Spec.Namespaces.ExcludeNamespaces)
+	assert.Equal(t, "Active", config.Status.Phase)
+
+	// The converted object does not share state with the hub
+	config.Spec.FeatureGates["inPlaceResize"] = false
+	assert.True(t, hub.Spec.FeatureGates["inPlaceResize"])
+	config.Spec.FeatureGates["inPlaceResize"] = true
+
+	var back v1alpha1.RightSizerConfig
+	require.NoError(t, config.ConvertTo(&back))
+	assert.Equal(t, hub, &back)
+}
+
+func TestRightSizerPolicyConversion(t *testing.T) {
+	hub := &v1alpha1.RightSizerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: v1alpha1.RightSizerPolicySpec{
+			Enabled:  true,
+			Priority: 200,
+			Mode:     "aggressive",
+			TargetRef: v1alpha1.TargetReference{
+				Kind:       "Deployment",
+				Namespaces: []string{"shop"},
+			},
+			ResourceStrategy:    v1alpha1.ResourceStrategy{Percentile: 99},
+			ResourceAnnotations: map[string]string{"team": "checkout"},
+		},
+	}
+
+	var policy RightSizerPolicy
+	require.NoError(t, policy.ConvertFrom(hub))
+	assert.Equal(t, "Deployment", policy.Spec.Target.Kind)
+	assert.Equal(t, int32(99), policy.Spec.Resources.Percentile)
+	assert.Equal(t, map[string]string{"team": "checkout"}, policy.Spec.PodAnnotations)
+
+	var back v1alpha1.RightSizerPolicy
+	require.NoError(t, policy.ConvertTo(&back))
+	assert.Equal(t, hub, &back)
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"right-sizer/api/v1alpha1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=rsc
+// +kubebuilder:printcolumn:name="Enabled",type=boolean,JSONPath=`.spec.enabled`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Interval",type=string,JSONPath=`.spec.resizeInterval`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RightSizerConfig is the Schema for the rightsizerconfigs API
+// This is a cluster-scoped resource that configures the global behavior of the right-sizer operator
+type RightSizerConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RightSizerConfigSpec            `json:"spec,omitempty"`
+	Status v1alpha1.RightSizerConfigStatus `json:"status,omitempty"`
+}
+
+// RightSizerConfigSpec defines the desired state of RightSizerConfig
+type RightSizerConfigSpec struct {
+	// Enabled indicates if the right-sizer operator is enabled globally
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Mode sets the default sizing mode when not specified in policies
+	// +kubebuilder:validation:Enum=aggressive;balanced;conservative;custom
+	// +kubebuilder:default=balanced
+	Mode string `json:"mode,omitempty"`
+
+	// ResizeInterval defines how often to check and resize resources globally
+	// +kubebuilder:default="1m"
+	ResizeInterval string `json:"resizeInterval,omitempty"`
+
+	// DryRun enables global dry-run mode
+	// +kubebuilder:default=false
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// RecommendationOnly writes RightSizerRecommendation objects per workload
+	// instead of resizing pods, so changes can be reviewed before they are applied
+	// +kubebuilder:default=false
+	RecommendationOnly bool `json:"recommendationOnly,omitempty"`
+
+	// Export renders resize decisions as patches for a GitOps pipeline
+	// instead of resizing pods, so live changes are not reverted by the sync
+	Export v1alpha1.ExportConfigSpec `json:"export,omitempty"`
+
+	// Defaults defines the default resource calculation strategy
+	Defaults v1alpha1.DefaultResourceStrategySpec `json:"defaults,omitempty"`
+
+	// Constraints defines global resource constraints
+	Constraints v1alpha1.GlobalConstraintsSpec `json:"constraints,omitempty"`
+
+	// Metrics configures metrics collection
+	Metrics v1alpha1.MetricsConfigSpec `json:"metrics,omitempty"`
+
+	// Cost defines the cost provider savings are priced with
+	Cost v1alpha1.CostConfigSpec `json:"cost,omitempty"`
+
+	// Autoscaler coordinates resizes with Karpenter and the Cluster Autoscaler
+	Autoscaler v1alpha1.AutoscalerConfigSpec `json:"autoscaler,omitempty"`
+
+	// Observability configures observability features
+	Observability v1alpha1.ObservabilityConfigSpec `json:"observability,omitempty"`
+
+	// Security configures security features
+	Security v1alpha1.SecurityConfigSpec `json:"security,omitempty"`
+
+	// Operator configures operator behavior
+	Operator v1alpha1.OperatorConfigSpec `json:"operator,omitempty"`
+
+	// Namespaces defines global namespace inclusion/exclusion
+	Namespaces v1alpha1.NamespaceConfigSpec `json:"namespaces,omitempty"`
+
+	// Exclusions keep workloads from being right-sized by their labels or owner
+	// kind, in addition to the rightsizer.io/skip pod annotation
+	Exclusions []v1alpha1.WorkloadExclusion `json:"exclusions,omitempty"`
+
+	// ExcludedContainers are name patterns of containers never right-sized in
+	// any namespace, such as service mesh proxies and log shippers, matched
+	// with shell globs like "*-exporter". Unset, the proxies of Istio and
+	// Linkerd are excluded; an empty list resizes every container.
+	ExcludedContainers []string `json:"excludedContainers,omitempty"`
+
+	// NamespaceOverrides let teams tune thresholds and multipliers for their
+	// namespaces; settings left empty fall back to the global ones
+	NamespaceOverrides []v1alpha1.NamespaceOverrideSpec `json:"namespaceOverrides,omitempty"`
+
+	// Notifications configures notifications
+	Notifications v1alpha1.NotificationConfigSpec `json:"notifications,omitempty"`
+
+	// Reporting schedules weekly and monthly savings and efficiency reports
+	Reporting v1alpha1.ReportingSpec `json:"reporting,omitempty"`
+
+	// FeatureGates enables/disables specific features
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RightSizerConfigList contains a list of RightSizerConfig
+type RightSizerConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RightSizerConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RightSizerConfig{}, &RightSizerConfigList{})
+}
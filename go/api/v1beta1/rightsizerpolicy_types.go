@@ -0,0 +1,115 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"right-sizer/api/v1alpha1"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=rsp
+// +kubebuilder:printcolumn:name="Enabled",type=boolean,JSONPath=`.spec.enabled`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Last Applied",type=date,JSONPath=`.status.lastAppliedTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// RightSizerPolicy is the Schema for the rightsizerpolicies API
+type RightSizerPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RightSizerPolicySpec            `json:"spec,omitempty"`
+	Status v1alpha1.RightSizerPolicyStatus `json:"status,omitempty"`
+}
+
+// RightSizerPolicySpec defines the desired state of RightSizerPolicy
+type RightSizerPolicySpec struct {
+	// Enabled indicates if this policy is active
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Priority determines the order of policy application (higher priority wins)
+	// +kubebuilder:default=100
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	Priority int32 `json:"priority,omitempty"`
+
+	// MergeStrategy controls how this policy combines with lower-priority
+	// policies selecting the same workload: merge fills the settings it leaves
+	// unset from them, override ignores them
+	// +kubebuilder:validation:Enum=merge;override
+	// +kubebuilder:default=merge
+	MergeStrategy string `json:"mergeStrategy,omitempty"`
+
+	// Mode defines the sizing mode for this policy
+	// +kubebuilder:validation:Enum=aggressive;balanced;conservative;custom
+	// +kubebuilder:default=balanced
+	Mode string `json:"mode,omitempty"`
+
+	// Profile selects the sizing profile of the targeted workloads: steady,
+	// bursty, batch or a profile registered by the operator
+	Profile string `json:"profile,omitempty"`
+
+	// QoSMode controls how resizes treat the QoS class of the targeted pods:
+	// preserve keeps Guaranteed pods Guaranteed, allow-burstable sizes limits
+	// independently, force-guaranteed sets limits to requests. Unset uses the
+	// global preserveGuaranteedQoS setting.
+	// +kubebuilder:validation:Enum=preserve;allow-burstable;force-guaranteed
+	QoSMode string `json:"qosMode,omitempty"`
+
+	// DryRun enables dry-run mode for this policy
+	// +kubebuilder:default=false
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// Target defines which resources this policy applies to
+	Target v1alpha1.TargetReference `json:"target"`
+
+	// Exclusions keep workloads in the namespaces of this policy from being
+	// right-sized, whether or not the target selects them
+	Exclusions []v1alpha1.WorkloadExclusion `json:"exclusions,omitempty"`
+
+	// Resources defines how resources should be calculated
+	Resources v1alpha1.ResourceStrategy `json:"resources,omitempty"`
+
+	// Schedule defines when this policy should be evaluated
+	Schedule v1alpha1.ScheduleSpec `json:"schedule,omitempty"`
+
+	// Constraints defines resource constraints and limits
+	Constraints v1alpha1.ResourceConstraints `json:"constraints,omitempty"`
+
+	// Webhooks defines webhook notifications for policy events
+	Webhooks []v1alpha1.WebhookSpec `json:"webhooks,omitempty"`
+
+	// PodAnnotations are added to resized pods
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RightSizerPolicyList contains a list of RightSizerPolicy
+type RightSizerPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RightSizerPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RightSizerPolicy{}, &RightSizerPolicyList{})
+}
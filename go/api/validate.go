@@ -0,0 +1,200 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// maxManifestBytes caps the size of a manifest posted to /api/validate
+const maxManifestBytes = 1 << 20
+
+// ManifestAssessment is how the operator would size the pods of a manifest
+type ManifestAssessment struct {
+	Skipped    string                // why the pods would not be sized, or ""
+	Policies   []string              // enabled policies selecting the pods
+	UsageFrom  string                // running pod whose usage the manifest was sized against
+	Containers []ContainerAssessment // containers that would be resized
+}
+
+// ContainerAssessment is the resize of one container of a manifest
+type ContainerAssessment struct {
+	Container   string                      `json:"container"`
+	Current     corev1.ResourceRequirements `json:"current"`
+	Recommended corev1.ResourceRequirements `json:"recommended"`
+	Reason      string                      `json:"reason"`
+}
+
+// Assessor sizes the pod of a manifest against the usage of the running
+// pods the selector matches, without applying anything
+type Assessor interface {
+	AssessPod(ctx context.Context, pod *corev1.Pod, selector labels.Selector) (ManifestAssessment, error)
+}
+
+// AssessorFunc adapts a function to the Assessor interface
+type AssessorFunc func(ctx context.Context, pod *corev1.Pod, selector labels.Selector) (ManifestAssessment, error)
+
+// AssessPod calls f
+func (f AssessorFunc) AssessPod(ctx context.Context, pod *corev1.Pod, selector labels.Selector) (ManifestAssessment, error) {
+	return f(ctx, pod, selector)
+}
+
+// SetAssessor sets the assessor /api/validate checks manifests with
+func (s *Server) SetAssessor(assessor Assessor) {
+	s.assessor = assessor
+}
+
+// validateResponse is the assessment of a manifest
+type validateResponse struct {
+	Kind        string                `json:"kind"`
+	Namespace   string                `json:"namespace"`
+	Name        string                `json:"name"`
+	WouldResize bool                  `json:"wouldResize"`
+	Skipped     string                `json:"skipped,omitempty"`
+	Policies    []string              `json:"policies"`
+	UsageFrom   string                `json:"usageFrom,omitempty"`
+	Containers  []ContainerAssessment `json:"containers"`
+}
+
+// handleValidate assesses a Pod, Deployment, StatefulSet or DaemonSet
+// manifest, in YAML or JSON, the way the operator would size it once
+// deployed: whether it would be resized, to what, and which policies apply.
+// The manifest is sized against the usage of the running pods it selects in
+// its namespace, which defaults to the namespace query parameter. Nothing is
+// applied, so CI pipelines can check resource specs before merge.
+//
+//	POST /api/validate?namespace=prod
+func (s *Server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.assessor == nil {
+		http.Error(w, "Manifest validation not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestBytes))
+	if err != nil {
+		http.Error(w, "Manifest too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	kind, pod, selector, err := manifestPod(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = r.URL.Query().Get("namespace")
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = metav1.NamespaceDefault
+	}
+
+	assessment, err := s.assessor.AssessPod(r.Context(), pod, selector)
+	if err != nil {
+		http.Error(w, "Failed to assess manifest: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	resp := validateResponse{
+		Kind:        kind,
+		Namespace:   pod.Namespace,
+		Name:        pod.Name,
+		WouldResize: len(assessment.Containers) > 0,
+		Skipped:     assessment.Skipped,
+		Policies:    assessment.Policies,
+		UsageFrom:   assessment.UsageFrom,
+		Containers:  assessment.Containers,
+	}
+	if resp.Policies == nil {
+		resp.Policies = []string{}
+	}
+	if resp.Containers == nil {
+		resp.Containers = []ContainerAssessment{}
+	}
+	s.writeJSONResponse(w, resp)
+}
+
+// manifestPod returns the kind of a manifest, the pod it describes and the
+// selector of its running pods. Workloads give their pod template and
+// selector; a bare pod selects the running pods by its labels, or by name.
+func manifestPod(body []byte) (string, *corev1.Pod, labels.Selector, error) {
+	var meta metav1.TypeMeta
+	if err := yaml.Unmarshal(body, &meta); err != nil {
+		return "", nil, nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+
+	var (
+		object   metav1.ObjectMeta
+		template corev1.PodTemplateSpec
+		selector *metav1.LabelSelector
+	)
+	switch meta.Kind {
+	case "Pod":
+		var pod corev1.Pod
+		if err := yaml.Unmarshal(body, &pod); err != nil {
+			return "", nil, nil, fmt.Errorf("invalid Pod: %v", err)
+		}
+		podSelector := labels.Nothing()
+		if len(pod.Labels) > 0 {
+			podSelector = labels.SelectorFromSet(pod.Labels)
+		}
+		return meta.Kind, &pod, podSelector, nil
+	case "Deployment":
+		var deployment appsv1.Deployment
+		if err := yaml.Unmarshal(body, &deployment); err != nil {
+			return "", nil, nil, fmt.Errorf("invalid Deployment: %v", err)
+		}
+		object, template, selector = deployment.ObjectMeta, deployment.Spec.Template, deployment.Spec.Selector
+	case "StatefulSet":
+		var statefulSet appsv1.StatefulSet
+		if err := yaml.Unmarshal(body, &statefulSet); err != nil {
+			return "", nil, nil, fmt.Errorf("invalid StatefulSet: %v", err)
+		}
+		object, template, selector = statefulSet.ObjectMeta, statefulSet.Spec.Template, statefulSet.Spec.Selector
+	case "DaemonSet":
+		var daemonSet appsv1.DaemonSet
+		if err := yaml.Unmarshal(body, &daemonSet); err != nil {
+			return "", nil, nil, fmt.Errorf("invalid DaemonSet: %v", err)
+		}
+		object, template, selector = daemonSet.ObjectMeta, daemonSet.Spec.Template, daemonSet.Spec.Selector
+	case "":
+		return "", nil, nil, fmt.Errorf("manifest has no kind")
+	default:
+		return "", nil, nil, fmt.Errorf("unsupported kind %s: expected Pod, Deployment, StatefulSet or DaemonSet", meta.Kind)
+	}
+
+	if selector == nil {
+		return "", nil, nil, fmt.Errorf("%s %s has no selector", meta.Kind, object.Name)
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("invalid selector of %s %s: %v", meta.Kind, object.Name, err)
+	}
+	pod := &corev1.Pod{ObjectMeta: template.ObjectMeta, Spec: template.Spec}
+	pod.Name = object.Name
+	pod.Namespace = object.Namespace
+	return meta.Kind, pod, podSelector, nil
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const deploymentManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: app
+          image: nginx
+          resources:
+            requests:
+              cpu: 500m
+              memory: 512Mi
+`
+
+func TestServer_HandleValidate(t *testing.T) {
+	var gotPod *corev1.Pod
+	var gotSelector labels.Selector
+	s := &Server{}
+	s.SetAssessor(AssessorFunc(func(_ context.Context, pod *corev1.Pod, selector labels.Selector) (ManifestAssessment, error) {
+		gotPod, gotSelector = pod, selector
+		return ManifestAssessment{
+			Policies:  []string{"web-policy"},
+			UsageFrom: "web-abc-1",
+			Containers: []ContainerAssessment{{
+				Container:   "app",
+				Current:     pod.Spec.Containers[0].Resources,
+				Recommended: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")}},
+				Reason:      "CPU scale down",
+			}},
+		}, nil
+	}))
+
+	w := httptest.NewRecorder()
+	s.handleValidate(w, httptest.NewRequest(http.MethodPost, "/api/validate?namespace=prod", strings.NewReader(deploymentManifest)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.NotNil(t, gotPod)
+	assert.Equal(t, "prod", gotPod.Namespace)
+	assert.Equal(t, "app=web", gotSelector.String())
+	assert.Equal(t, "500m", gotPod.Spec.Containers[0].Resources.Requests.Cpu().String())
+
+	var resp validateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "Deployment", resp.Kind)
+	assert.Equal(t, "web", resp.Name)
+	assert.True(t, resp.WouldResize)
+	assert.Equal(t, []string{"web-policy"}, resp.Policies)
+	require.Len(t, resp.Containers, 1)
+	assert.Equal(t, "200m", resp.Containers[0].Recommended.Requests.Cpu().String())
+}
+
+func TestServer_HandleValidateRejectsManifests(t *testing.T) {
+	s := &Server{}
+	s.SetAssessor(AssessorFunc(func(context.Context, *corev1.Pod, labels.Selector) (ManifestAssessment, error) {
+		return ManifestAssessment{Skipped: "annotated rightsizer.io/skip"}, nil
+	}))
+	request := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handleValidate(w, httptest.NewRequest(method, "/api/validate", strings.NewReader(body)))
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "kind: Service\nmetadata:\n  name: web\n").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "metadata:\n  name: web\n").Code)
+	assert.Equal(t, http.StatusBadRequest, request(http.MethodPost, "kind: Deployment\nmetadata:\n  name: web\n").Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, request(http.MethodGet, "").Code)
+
+	// A bare pod without labels selects no running pods
+	w := request(http.MethodPost, `{"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "debug"}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp validateResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "default", resp.Namespace)
+	assert.False(t, resp.WouldResize)
+	assert.Equal(t, "annotated rightsizer.io/skip", resp.Skipped)
+	assert.Empty(t, resp.Containers)
+}
+
+func TestServer_HandleValidateWithoutAssessor(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	s.handleValidate(w, httptest.NewRequest(http.MethodPost, "/api/validate", strings.NewReader(deploymentManifest)))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
@@ -0,0 +1,311 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+	"right-sizer/workload"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultDetailRange is the span of history returned without ?range=
+	defaultDetailRange = 24 * time.Hour
+	// sparklinePoints is the number of buckets usage history is averaged into
+	sparklinePoints = 60
+	// maxSparklinePods bounds the pods whose usage history is read per request
+	maxSparklinePods = 5
+)
+
+// SetUsageHistory sets the source of the usage history of
+// /api/workloads/{namespace}/{kind}/{name}. Without one, the prediction
+// engine's samples are used.
+func (s *Server) SetUsageHistory(source metrics.RangeProvider) {
+	s.usageHistory = source
+}
+
+// workloadDetail is the response of the workload detail endpoint
+type workloadDetail struct {
+	Namespace      string                                   `json:"namespace"`
+	Kind           string                                   `json:"kind"`
+	Name           string                                   `json:"name"`
+	Pods           []string                                 `json:"pods"`
+	History        []audit.AuditEvent                       `json:"history"`
+	Usage          []containerSparkline                     `json:"usage"`
+	Recommendation *v1alpha1.RightSizerRecommendationStatus `json:"recommendation,omitempty"`
+	Policies       []string                                 `json:"policies"`
+}
+
+// containerSparkline is the usage of a container averaged over the
+// workload's pods: CPU in millicores and memory in MB
+type containerSparkline struct {
+	Container string       `json:"container"`
+	CPU       []usagePoint `json:"cpu"`
+	Memory    []usagePoint `json:"memory"`
+}
+
+// usagePoint is one bucket of a sparkline
+type usagePoint struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// handleWorkloads routes /api/workloads/ to the explain and detail endpoints
+func (s *Server) handleWorkloads(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/explain") {
+		s.handleWorkloadExplain(w, r)
+		return
+	}
+	s.handleWorkloadDetail(w, r)
+}
+
+// handleWorkloadDetail returns what the dashboard shows on a workload's page:
+// its resize history from the audit store, usage sparklines per container,
+// the active recommendation and the enabled policies that select it.
+//
+//	GET /api/workloads/{namespace}/{kind}/{name}
+//	?range=  span of history and usage, such as 6h or 7d, 24h by default
+//	?limit=  at most limit resize events, 100 by default
+func (s *Server) handleWorkloadDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/workloads/"), "/"), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	window := defaultDetailRange
+	if raw := r.URL.Query().Get("range"); raw != "" {
+		var err error
+		if window, err = config.ParseHistoryWindow(raw); err != nil {
+			http.Error(w, fmt.Sprintf("invalid range %q: %v", raw, err), http.StatusBadRequest)
+			return
+		}
+	}
+	limit, err := positiveParam(r.URL.Query(), "limit", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	detail := workloadDetail{Namespace: parts[0], Kind: parts[1], Name: parts[2], Pods: []string{}, History: []audit.AuditEvent{}, Usage: []containerSparkline{}, Policies: []string{}}
+	pods, kind, err := s.workloadPods(ctx, detail.Namespace, detail.Kind, detail.Name)
+	if err != nil {
+		logger.Error("Failed to list pods of workload %s/%s/%s: %v", detail.Namespace, detail.Kind, detail.Name, err)
+		http.Error(w, "Failed to list pods", http.StatusInternalServerError)
+		return
+	}
+	recommendation := s.workloadRecommendation(ctx, detail.Namespace, detail.Kind, detail.Name)
+	if len(pods) == 0 && recommendation == nil {
+		http.Error(w, fmt.Sprintf("Workload %s/%s/%s not found", detail.Namespace, detail.Kind, detail.Name), http.StatusNotFound)
+		return
+	}
+	// Use the kind as Kubernetes spells it, as audit events record it
+	if kind != "" {
+		detail.Kind = kind
+	}
+	if recommendation != nil {
+		detail.Kind = recommendation.Spec.TargetRef.Kind
+		detail.Recommendation = &recommendation.Status
+	}
+	for _, pod := range pods {
+		detail.Pods = append(detail.Pods, pod.Name)
+	}
+
+	now := time.Now()
+	if s.auditStore != nil {
+		result, err := s.auditStore.Query(audit.Query{
+			Namespace: detail.Namespace,
+			Workload:  detail.Kind + "/" + detail.Name,
+			EventType: "ResourceChange",
+			Since:     now.Add(-window),
+			Limit:     limit,
+		})
+		if err != nil {
+			logger.Warn("Failed to query resize history of %s/%s/%s: %v", detail.Namespace, detail.Kind, detail.Name, err)
+		} else {
+			detail.History = result.Events
+		}
+	}
+
+	detail.Usage = s.workloadUsage(ctx, pods, now.Add(-window), now)
+
+	target := v1alpha1.RecommendationTargetRef{Kind: detail.Kind, Name: detail.Name}
+	subject := &v1.Pod{}
+	subject.Namespace = detail.Namespace
+	if len(pods) > 0 {
+		subject = &pods[0]
+	}
+	for _, policy := range s.enabledPolicies(ctx) {
+		if workload.MatchesPolicy(&policy, subject, target) {
+			detail.Policies = append(detail.Policies, policy.Namespace+"/"+policy.Name)
+		}
+	}
+	sort.Strings(detail.Policies)
+
+	s.writeJSONResponse(w, detail)
+}
+
+// workloadPods returns the pods of the namespace whose workload is kind/name,
+// compared case-insensitively, and the kind as the pods' owners spell it
+func (s *Server) workloadPods(ctx context.Context, namespace, kind, name string) ([]v1.Pod, string, error) {
+	pods, err := s.listPods(ctx, namespace, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	var out []v1.Pod
+	var resolved string
+	for i := range pods {
+		var podKind, podName string
+		if s.ctrlClient != nil {
+			target := workload.Resolve(ctx, s.ctrlClient, &pods[i])
+			podKind, podName = target.Kind, target.Name
+		} else {
+			podKind, podName, _ = strings.Cut(audit.WorkloadOf(&pods[i]), "/")
+		}
+		if strings.EqualFold(podKind, kind) && podName == name {
+			out = append(out, pods[i])
+			resolved = podKind
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, resolved, nil
+}
+
+// workloadRecommendation returns the RightSizerRecommendation of the workload, if any
+func (s *Server) workloadRecommendation(ctx context.Context, namespace, kind, name string) *v1alpha1.RightSizerRecommendation {
+	if s.ctrlClient == nil {
+		return nil
+	}
+	var list v1alpha1.RightSizerRecommendationList
+	if err := s.ctrlClient.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		logger.Debug("Recommendations not available for workload detail: %v", err)
+		return nil
+	}
+	for i := range list.Items {
+		ref := list.Items[i].Spec.TargetRef
+		if strings.EqualFold(ref.Kind, kind) && ref.Name == name {
+			return &list.Items[i]
+		}
+	}
+	return nil
+}
+
+// workloadUsage returns the usage of each container between start and end,
+// averaged over the first pods of the workload into sparkline buckets
+func (s *Server) workloadUsage(ctx context.Context, pods []v1.Pod, start, end time.Time) []containerSparkline {
+	if len(pods) > maxSparklinePods {
+		pods = pods[:maxSparklinePods]
+	}
+	samples := map[string]*metrics.ContainerHistory{}
+	var containers []string
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			history, ok := s.containerHistory(ctx, pod.Namespace, pod.Name, container.Name, start, end)
+			if !ok {
+				continue
+			}
+			merged, seen := samples[container.Name]
+			if !seen {
+				merged = &metrics.ContainerHistory{}
+				samples[container.Name] = merged
+				containers = append(containers, container.Name)
+			}
+			merged.CPUMilli = append(merged.CPUMilli, history.CPUMilli...)
+			merged.MemMB = append(merged.MemMB, history.MemMB...)
+		}
+	}
+
+	out := make([]containerSparkline, 0, len(containers))
+	for _, name := range containers {
+		out = append(out, containerSparkline{
+			Container: name,
+			CPU:       sparkline(samples[name].CPUMilli, start, end),
+			Memory:    sparkline(samples[name].MemMB, start, end),
+		})
+	}
+	return out
+}
+
+// containerHistory reads a container's usage from the usage history source,
+// falling back to the samples the prediction engine keeps
+func (s *Server) containerHistory(ctx context.Context, namespace, pod, container string, start, end time.Time) (metrics.ContainerHistory, bool) {
+	if s.usageHistory != nil {
+		history, err := s.usageHistory.FetchContainerHistory(ctx, namespace, pod, container, start, end)
+		if err == nil {
+			return history, true
+		}
+		logger.Debug("Usage history of %s/%s/%s not available: %v", namespace, pod, container, err)
+	}
+	if s.predictor == nil {
+		return metrics.ContainerHistory{}, false
+	}
+	var history metrics.ContainerHistory
+	for resourceType, series := range map[string]*[]metrics.Sample{"cpu": &history.CPUMilli, "memory": &history.MemMB} {
+		data, err := s.predictor.GetHistoricalData(namespace, pod, container, resourceType, start)
+		if err != nil {
+			continue
+		}
+		for _, point := range data.DataPoints {
+			*series = append(*series, metrics.Sample{Timestamp: point.Timestamp, Value: point.Value})
+		}
+	}
+	return history, len(history.CPUMilli) > 0 || len(history.MemMB) > 0
+}
+
+// sparkline averages samples into sparklinePoints equal buckets between
+// start and end, leaving out buckets without samples
+func sparkline(samples []metrics.Sample, start, end time.Time) []usagePoint {
+	out := []usagePoint{}
+	width := end.Sub(start) / sparklinePoints
+	if width <= 0 {
+		return out
+	}
+	var sums [sparklinePoints]float64
+	var counts [sparklinePoints]int
+	for _, sample := range samples {
+		if sample.Timestamp.Before(start) || sample.Timestamp.After(end) {
+			continue
+		}
+		i := min(int(sample.Timestamp.Sub(start)/width), sparklinePoints-1)
+		sums[i] += sample.Value
+		counts[i]++
+	}
+	for i := range sums {
+		if counts[i] > 0 {
+			out = append(out, usagePoint{Time: start.Add(time.Duration(i) * width), Value: sums[i] / float64(counts[i])})
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/audit"
+	"right-sizer/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeUsageHistory returns the same samples, ending now, for every container
+type fakeUsageHistory struct {
+	cpu []float64
+}
+
+func (f fakeUsageHistory) FetchContainerHistory(_ context.Context, _, _, _ string, _, end time.Time) (metrics.ContainerHistory, error) {
+	var history metrics.ContainerHistory
+	for i, value := range f.cpu {
+		at := end.Add(-time.Duration(len(f.cpu)-i) * time.Hour)
+		history.CPUMilli = append(history.CPUMilli, metrics.Sample{Timestamp: at, Value: value})
+		history.MemMB = append(history.MemMB, metrics.Sample{Timestamp: at, Value: 256})
+	}
+	return history, nil
+}
+
+func TestServer_HandleWorkloadDetail(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	controller := true
+	rsOwner := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}}
+	ctrlClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-abc", Namespace: "shop",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+		}},
+		namespacePod("shop", "web-abc-1", rsOwner, map[string]string{"app": "web"}, "500m", "512Mi"),
+		namespacePod("shop", "web-abc-2", rsOwner, map[string]string{"app": "web"}, "500m", "512Mi"),
+		namespacePod("shop", "cache", nil, nil, "200m", "256Mi"),
+		&v1alpha1.RightSizerRecommendation{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       v1alpha1.RightSizerRecommendationSpec{TargetRef: v1alpha1.RecommendationTargetRef{Kind: "Deployment", Name: "web"}},
+			Status:     v1alpha1.RightSizerRecommendationStatus{Algorithm: "percentile"},
+		},
+		&v1alpha1.RightSizerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-policy", Namespace: "shop"},
+			Spec: v1alpha1.RightSizerPolicySpec{
+				Enabled:   true,
+				TargetRef: v1alpha1.TargetReference{LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+			},
+		},
+		&v1alpha1.RightSizerPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "statefulsets", Namespace: "shop"},
+			Spec:       v1alpha1.RightSizerPolicySpec{Enabled: true, TargetRef: v1alpha1.TargetReference{Kind: "StatefulSet"}},
+		},
+	).Build()
+
+	store, err := audit.OpenStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+	now := time.Now().UTC()
+	for _, event := range []audit.AuditEvent{
+		{EventID: "1", Timestamp: now.Add(-72 * time.Hour), Namespace: "shop", Workload: "Deployment/web", EventType: "ResourceChange", Operation: "resize"},
+		{EventID: "2", Timestamp: now.Add(-time.Hour), Namespace: "shop", Workload: "Deployment/web", EventType: "ResourceChange", Operation: "resize"},
+		{EventID: "3", Timestamp: now.Add(-time.Hour), Namespace: "shop", Workload: "Pod/cache", EventType: "ResourceChange", Operation: "resize"},
+	} {
+		require.NoError(t, store.Append(event))
+	}
+
+	server := NewServer(nil, nil, ctrlClient, nil, nil)
+	server.SetAuditStore(store)
+	server.SetUsageHistory(fakeUsageHistory{cpu: []float64{100, 200, 300}})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"unknown workload", http.MethodGet, "/api/workloads/shop/Deployment/api", http.StatusNotFound},
+		{"missing name", http.MethodGet, "/api/workloads/shop/Deployment", http.StatusNotFound},
+		{"wrong method", http.MethodPost, "/api/workloads/shop/Deployment/web", http.StatusMethodNotAllowed},
+		{"invalid range", http.MethodGet, "/api/workloads/shop/Deployment/web?range=soon", http.StatusBadRequest},
+		{"explain is routed", http.MethodGet, "/api/workloads/shop/web/explain", http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.handleWorkloads(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	server.handleWorkloads(w, httptest.NewRequest(http.MethodGet, "/api/workloads/shop/deployment/web", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var detail workloadDetail
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	assert.Equal(t, "Deployment", detail.Kind)
+	assert.Equal(t, []string{"web-abc-1", "web-abc-2"}, detail.Pods)
+	require.Len(t, detail.History, 1)
+	assert.Equal(t, "2", detail.History[0].EventID)
+	require.NotNil(t, detail.Recommendation)
+	assert.Equal(t, "percentile", detail.Recommendation.Algorithm)
+	assert.Equal(t, []string{"shop/web-policy"}, detail.Policies)
+
+	require.Len(t, detail.Usage, 1)
+	assert.Equal(t, "app", detail.Usage[0].Container)
+	require.Len(t, detail.Usage[0].CPU, 3)
+	assert.InDelta(t, 100, detail.Usage[0].CPU[0].Value, 0.01)
+	assert.InDelta(t, 300, detail.Usage[0].CPU[2].Value, 0.01)
+
+	w = httptest.NewRecorder()
+	server.handleWorkloads(w, httptest.NewRequest(http.MethodGet, "/api/workloads/shop/Deployment/web?range=7d&limit=1", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &detail))
+	require.Len(t, detail.History, 1)
+	assert.Equal(t, "2", detail.History[0].EventID)
+}
+
+func TestSparkline(t *testing.T) {
+	end := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	start := end.Add(-time.Hour)
+	points := sparkline([]metrics.Sample{
+		{Timestamp: start.Add(-time.Minute), Value: 1000}, // before the range
+		{Timestamp: start, Value: 10},
+		{Timestamp: start.Add(30 * time.Second), Value: 20},
+		{Timestamp: end, Value: 40},
+	}, start, end)
+
+	require.Len(t, points, 2)
+	assert.Equal(t, start, points[0].Time)
+	assert.InDelta(t, 15, points[0].Value, 0.01)
+	assert.Equal(t, start.Add(59*time.Minute), points[1].Time)
+	assert.InDelta(t, 40, points[1].Value, 0.01)
+}
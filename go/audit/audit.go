@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -42,6 +43,7 @@ type AuditEvent struct {
 	Operation     string                       `json:"operation"`
 	Namespace     string                       `json:"namespace"`
 	PodName       string                       `json:"podName"`
+	Workload      string                       `json:"workload,omitempty"` // Kind/name of the pod's workload
 	ContainerName string                       `json:"containerName"`
 	User          string                       `json:"user"`
 	Source        string                       `json:"source"`
@@ -62,11 +64,19 @@ type AuditLogger struct {
 	metrics        *metrics.OperatorMetrics
 	client         client.Client
 	logFile        *os.File
+	store          *Store
 	logChannel     chan AuditEvent
 	stopChannel    chan struct{}
 	wg             sync.WaitGroup
 	mutex          sync.RWMutex
 	eventIDCounter uint64
+
+	// Remote sinks, only used by the event processing goroutine
+	sinks        []Sink
+	sinkConfig   config.AuditSinkConfig
+	sinksReady   bool
+	sinksFailed  bool
+	sinksRetryAt time.Time
 }
 
 // AuditConfig holds audit logger configuration
@@ -80,6 +90,8 @@ type AuditConfig struct {
 	EnableEventLog bool
 	EnableMetrics  bool
 	RetentionDays  int
+	StorePath      string        // Directory of the queryable event store; empty disables it
+	CompactEvery   time.Duration // How often events past HistoryRetention are removed from the store
 }
 
 // DefaultAuditConfig returns default audit configuration
@@ -94,6 +106,8 @@ func DefaultAuditConfig() AuditConfig {
 		EnableEventLog: true,
 		EnableMetrics:  true,
 		RetentionDays:  30,
+		StorePath:      "/tmp/right-sizer-audit",
+		CompactEvery:   time.Hour,
 	}
 }
 
@@ -131,6 +145,16 @@ func NewAuditLogger(client client.Client, cfg *config.Config, metrics *metrics.O
 		al.logFile = logFile
 	}
 
+	if auditConfig.StorePath != "" {
+		store, err := OpenStore(auditConfig.StorePath)
+		if err != nil {
+			logger.Warn("Cannot open audit store, audit events will not be queryable: %v", err)
+		} else {
+			al.store = store
+			al.compactStore()
+		}
+	}
+
 	// Start background processor
 	al.wg.Add(1)
 	go al.processAuditEvents(auditConfig)
@@ -146,6 +170,11 @@ func (al *AuditLogger) Close() error {
 	close(al.stopChannel)
 	al.wg.Wait()
 
+	if al.store != nil {
+		if err := al.store.Close(); err != nil {
+			logger.Warn("Failed to close audit store: %v", err)
+		}
+	}
 	if al.logFile != nil {
 		return al.logFile.Close()
 	}
@@ -153,6 +182,27 @@ func (al *AuditLogger) Close() error {
 	return nil
 }
 
+// Store returns the queryable event store, nil when it is disabled
+func (al *AuditLogger) Store() *Store {
+	return al.store
+}
+
+// compactStore removes events older than the configured history retention from the store
+func (al *AuditLogger) compactStore() {
+	retention := "30d"
+	if al.config != nil && al.config.HistoryRetention != "" {
+		retention = al.config.HistoryRetention
+	}
+	window, err := config.ParseHistoryWindow(retention)
+	if err != nil {
+		logger.Warn("Invalid history retention %q, audit store not compacted: %v", retention, err)
+		return
+	}
+	if err := al.store.Compact(window); err != nil {
+		logger.Warn("Failed to compact audit store: %v", err)
+	}
+}
+
 // LogResourceChange logs a resource change event
 func (al *AuditLogger) LogResourceChange(ctx context.Context, pod *corev1.Pod, containerName string, oldResources, newResources corev1.ResourceRequirements, operation, reason, status string, duration time.Duration, err error) {
 	event := AuditEvent{
@@ -162,6 +212,7 @@ func (al *AuditLogger) LogResourceChange(ctx context.Context, pod *corev1.Pod, c
 		Operation:     operation,
 		Namespace:     pod.Namespace,
 		PodName:       pod.Name,
+		Workload:      WorkloadOf(pod),
 		ContainerName: containerName,
 		User:          "right-sizer-operator",
 		Source:        "right-sizer",
@@ -196,6 +247,7 @@ func (al *AuditLogger) LogPolicyApplication(ctx context.Context, pod *corev1.Pod
 		Operation:     "policy_evaluation",
 		Namespace:     pod.Namespace,
 		PodName:       pod.Name,
+		Workload:      WorkloadOf(pod),
 		ContainerName: containerName,
 		User:          "right-sizer-operator",
 		Source:        "policy-engine",
@@ -224,6 +276,7 @@ func (al *AuditLogger) LogValidationResult(ctx context.Context, pod *corev1.Pod,
 		Operation:     validationType,
 		Namespace:     pod.Namespace,
 		PodName:       pod.Name,
+		Workload:      WorkloadOf(pod),
 		ContainerName: containerName,
 		User:          "right-sizer-operator",
 		Source:        "resource-validator",
@@ -302,6 +355,13 @@ func (al *AuditLogger) processAuditEvents(config AuditConfig) {
 	ticker := time.NewTicker(config.FlushInterval)
 	defer ticker.Stop()
 
+	compactEvery := config.CompactEvery
+	if compactEvery <= 0 {
+		compactEvery = time.Hour
+	}
+	compactTicker := time.NewTicker(compactEvery)
+	defer compactTicker.Stop()
+
 	var eventBuffer []AuditEvent
 
 	for {
@@ -317,10 +377,13 @@ func (al *AuditLogger) processAuditEvents(config AuditConfig) {
 			}
 
 		case <-ticker.C:
-			// Periodic flush
-			if len(eventBuffer) > 0 {
-				al.flushEvents(eventBuffer, config)
-				eventBuffer = eventBuffer[:0]
+			// Periodic flush; remote sinks also rotate their objects when no events arrived
+			al.flushEvents(eventBuffer, config)
+			eventBuffer = eventBuffer[:0]
+
+		case <-compactTicker.C:
+			if al.store != nil {
+				al.compactStore()
 			}
 
 		case <-al.stopChannel:
@@ -328,6 +391,7 @@ func (al *AuditLogger) processAuditEvents(config AuditConfig) {
 			if len(eventBuffer) > 0 {
 				al.flushEvents(eventBuffer, config)
 			}
+			al.closeSinks()
 			return
 		}
 	}
@@ -340,6 +404,13 @@ func (al *AuditLogger) processEvent(event AuditEvent, config AuditConfig) {
 		al.writeToFile(event)
 	}
 
+	// Index the event so it can be queried
+	if al.store != nil {
+		if err := al.store.Append(event); err != nil {
+			logger.Error("Failed to store audit event: %v", err)
+		}
+	}
+
 	// Create Kubernetes event
 	if config.EnableEventLog {
 		al.createKubernetesEvent(event)
@@ -481,6 +552,9 @@ func (al *AuditLogger) flushEvents(events []AuditEvent, config AuditConfig) {
 	if config.EnableFileLog {
 		al.checkLogRotation(config)
 	}
+
+	// Ship the batch to the remote sinks
+	al.shipToSinks(events)
 }
 
 // checkLogRotation checks if log rotation is needed
@@ -586,6 +660,22 @@ func (al *AuditLogger) getUserAgent(ctx context.Context) string {
 	return "right-sizer-operator"
 }
 
+// WorkloadOf returns the Kind/name of the workload controlling a pod, without
+// querying the API server: a ReplicaSet named after its pod-template-hash is
+// attributed to its Deployment
+func WorkloadOf(pod *corev1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod/" + pod.Name
+	}
+	if owner.Kind == "ReplicaSet" {
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return "Deployment/" + strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	}
+	return owner.Kind + "/" + owner.Name
+}
+
 // getQoSClass determines the QoS class of a pod
 func getQoSClass(pod *corev1.Pod) corev1.PodQOSClass {
 	requests := make(corev1.ResourceList)
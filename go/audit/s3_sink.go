@@ -0,0 +1,282 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+)
+
+const (
+	// s3MaxObjectSize starts a new object before the rotation interval passes
+	s3MaxObjectSize = 16 * 1024 * 1024
+	// s3MaxPendingSize bounds the events held while the bucket cannot be reached
+	s3MaxPendingSize = 4 * s3MaxObjectSize
+
+	amzDateFormat = "20060102T150405Z"
+)
+
+// S3Sink writes audit events as JSON lines objects to S3 or an S3-compatible
+// object store. Events are collected into one object per rotation interval,
+// stored under <prefix>/<yyyy>/<mm>/<dd>/ and named after the time the object
+// was started and the operator pod, so replicas never overwrite each other.
+// Requests are signed with AWS Signature Version 4, which GCS accepts with
+// HMAC keys on storage.googleapis.com.
+type S3Sink struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	prefix    string
+	pathStyle bool
+	rotation  time.Duration
+	accessKey string
+	secretKey string
+	source    string // operator pod name, part of the object names
+
+	httpClient *http.Client
+	pending    bytes.Buffer
+	started    time.Time
+}
+
+// NewS3Sink creates an object storage sink from the audit sink configuration
+func NewS3Sink(cfg config.AuditSinkConfig, accessKey, secretKey string) (*S3Sink, error) {
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("accessKeyId and secretAccessKey are required")
+	}
+	rawEndpoint := cfg.S3Endpoint
+	if rawEndpoint == "" {
+		rawEndpoint = "https://s3." + cfg.S3Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(rawEndpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", rawEndpoint)
+	}
+
+	source, _ := os.Hostname()
+	if name := os.Getenv("HOSTNAME"); name != "" {
+		source = name
+	}
+	return &S3Sink{
+		endpoint:   endpoint,
+		region:     cfg.S3Region,
+		bucket:     cfg.S3Bucket,
+		prefix:     strings.Trim(cfg.S3Prefix, "/"),
+		pathStyle:  cfg.S3PathStyle,
+		rotation:   cfg.S3RotationInterval,
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		source:     source,
+		httpClient: &http.Client{Timeout: sinkTimeout},
+	}, nil
+}
+
+// Name implements Sink
+func (s *S3Sink) Name() string { return "s3" }
+
+// Write implements Sink; the object is uploaded once the rotation interval
+// passed or it grew too large, and kept for the next write if that fails
+func (s *S3Sink) Write(ctx context.Context, events []AuditEvent) error {
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit event %s: %w", event.EventID, err)
+		}
+		if s.pending.Len() == 0 {
+			s.started = time.Now().UTC()
+		}
+		s.pending.Write(line)
+		s.pending.WriteByte('\n')
+	}
+
+	if s.pending.Len() == 0 || (time.Since(s.started) < s.rotation && s.pending.Len() < s3MaxObjectSize) {
+		return nil
+	}
+	err := s.upload(ctx)
+	if err != nil && s.pending.Len() >= s3MaxPendingSize {
+		logger.Warn("Dropping %d bytes of audit events that could not be uploaded to bucket %s", s.pending.Len(), s.bucket)
+		s.pending.Reset()
+	}
+	return err
+}
+
+// Close implements Sink
+func (s *S3Sink) Close(ctx context.Context) error {
+	if s.pending.Len() == 0 {
+		return nil
+	}
+	return s.upload(ctx)
+}
+
+// objectKey names the object started at the given time
+func (s *S3Sink) objectKey(started time.Time) string {
+	name := fmt.Sprintf("%s/%s-%s.jsonl", started.Format("2006/01/02"), started.Format(amzDateFormat), s.source)
+	if s.prefix == "" {
+		return name
+	}
+	return s.prefix + "/" + name
+}
+
+// objectURL returns the URL of an object, addressing the bucket in the host
+// name unless path-style addressing is configured
+func (s *S3Sink) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := "/" + key
+	if s.pathStyle {
+		path = "/" + s.bucket + path
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + s3EscapePath(path)
+	return &u
+}
+
+func (s *S3Sink) upload(ctx context.Context) error {
+	if err := s.PutObject(ctx, s.objectKey(s.started), "application/x-ndjson", s.pending.Bytes()); err != nil {
+		return err
+	}
+	s.pending.Reset()
+	return nil
+}
+
+// PutObject writes one object under the given key, which is used as is
+// without the configured prefix
+func (s *S3Sink) PutObject(ctx context.Context, key, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	sum := sha256.Sum256(body)
+	signV4(req, hex.EncodeToString(sum[:]), s.accessKey, s.secretKey, s.region, "s3", time.Now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("upload to bucket "+s.bucket, resp)
+	}
+	return nil
+}
+
+// signV4 signs a request with AWS Signature Version 4. All headers set on the
+// request are signed, along with the host.
+func signV4(req *http.Request, payloadHash, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery sorts and encodes query parameters as Signature Version 4 requires
+func canonicalQuery(values url.Values) string {
+	pairs := make([]string, 0, len(values))
+	for key, vals := range values {
+		for _, v := range vals {
+			pairs = append(pairs, s3Escape(key, true)+"="+s3Escape(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3EscapePath escapes each segment of an object path
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent-encodes everything but unreserved characters, and slashes
+// unless encodeSlash is set
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
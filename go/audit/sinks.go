@@ -0,0 +1,367 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"right-sizer/config"
+	"right-sizer/logger"
+)
+
+const (
+	// sinkTimeout bounds a single write to a remote sink
+	sinkTimeout = 30 * time.Second
+	// sinkRetryInterval is how long to wait before retrying sinks that could not be set up
+	sinkRetryInterval = time.Minute
+)
+
+// Sink ships batches of audit events to remote storage
+type Sink interface {
+	// Name identifies the sink in logs and metrics
+	Name() string
+	// Write ships a batch of events; it is called on every flush, with no
+	// events when the flush interval passed without any
+	Write(ctx context.Context, events []AuditEvent) error
+	// Close ships events the sink still holds
+	Close(ctx context.Context) error
+}
+
+// shipToSinks writes a batch of events to the configured remote sinks,
+// setting them up again when their configuration changed
+func (al *AuditLogger) shipToSinks(events []AuditEvent) {
+	cfg := al.sinkSettings()
+	if !al.sinksReady || cfg != al.sinkConfig || (al.sinksFailed && time.Now().After(al.sinksRetryAt)) {
+		al.closeSinks()
+		al.sinks, al.sinksFailed = al.buildSinks(cfg)
+		al.sinkConfig = cfg
+		al.sinksReady = true
+		al.sinksRetryAt = time.Now().Add(sinkRetryInterval)
+	}
+
+	for _, sink := range al.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+		if err := sink.Write(ctx, events); err != nil {
+			logger.Warn("Failed to write audit events to %s: %v", sink.Name(), err)
+			if al.metrics != nil {
+				al.metrics.RecordProcessingError("", "", "audit_sink_"+sink.Name())
+			}
+		}
+		cancel()
+	}
+}
+
+// closeSinks ships what the sinks still hold and drops them
+func (al *AuditLogger) closeSinks() {
+	for _, sink := range al.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+		if err := sink.Close(ctx); err != nil {
+			logger.Warn("Failed to close audit sink %s: %v", sink.Name(), err)
+		}
+		cancel()
+	}
+	al.sinks = nil
+}
+
+// sinkSettings returns the current audit sink configuration
+func (al *AuditLogger) sinkSettings() config.AuditSinkConfig {
+	if al.config != nil {
+		return al.config.AuditSinks
+	}
+	return config.Get().AuditSinks
+}
+
+// buildSinks creates the sinks enabled in the configuration; it reports
+// whether any of them could not be created
+func (al *AuditLogger) buildSinks(cfg config.AuditSinkConfig) ([]Sink, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+
+	var sinks []Sink
+	failed := false
+	add := func(name string, build func() (Sink, error)) {
+		sink, err := build()
+		if err != nil {
+			logger.Warn("Failed to set up %s audit sink: %v", name, err)
+			failed = true
+			return
+		}
+		logger.Info("Audit events are shipped to %s", sink.Name())
+		sinks = append(sinks, sink)
+	}
+
+	if cfg.S3Bucket != "" {
+		add("s3", func() (Sink, error) {
+			data, err := al.secretData(ctx, cfg.S3CredentialsSecret)
+			if err != nil {
+				return nil, err
+			}
+			return NewS3Sink(cfg, string(data["accessKeyId"]), string(data["secretAccessKey"]))
+		})
+	}
+	if cfg.ElasticsearchURL != "" {
+		add("elasticsearch", func() (Sink, error) {
+			data, err := al.secretData(ctx, cfg.ElasticsearchCredentialsSecret)
+			if err != nil {
+				return nil, err
+			}
+			return NewElasticsearchSink(cfg.ElasticsearchURL, cfg.ElasticsearchIndex, sinkCredentials(data)), nil
+		})
+	}
+	if cfg.KafkaRESTProxyURL != "" {
+		add("kafka", func() (Sink, error) {
+			data, err := al.secretData(ctx, cfg.KafkaCredentialsSecret)
+			if err != nil {
+				return nil, err
+			}
+			return NewKafkaSink(cfg.KafkaRESTProxyURL, cfg.KafkaTopic, sinkCredentials(data)), nil
+		})
+	}
+	return sinks, failed
+}
+
+// secretData reads a secret from the operator's namespace; no name means no credentials
+func (al *AuditLogger) secretData(ctx context.Context, name string) (map[string][]byte, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if al.client == nil {
+		return nil, fmt.Errorf("cannot read credentials secret %s without a Kubernetes client", name)
+	}
+	namespace := os.Getenv("OPERATOR_NAMESPACE")
+	if namespace == "" {
+		namespace = "right-sizer"
+	}
+	var secret corev1.Secret
+	if err := al.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		return nil, fmt.Errorf("failed to get credentials secret %s/%s: %w", namespace, name, err)
+	}
+	return secret.Data, nil
+}
+
+// SinkCredentials authenticate requests to Elasticsearch or a Kafka REST Proxy
+type SinkCredentials struct {
+	APIKey   string
+	Username string
+	Password string
+}
+
+func sinkCredentials(data map[string][]byte) SinkCredentials {
+	return SinkCredentials{
+		APIKey:   strings.TrimSpace(string(data["apiKey"])),
+		Username: strings.TrimSpace(string(data["username"])),
+		Password: strings.TrimSpace(string(data["password"])),
+	}
+}
+
+func (c SinkCredentials) apply(req *http.Request) {
+	switch {
+	case c.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+c.APIKey)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// ElasticsearchSink indexes audit events with the Elasticsearch bulk API
+type ElasticsearchSink struct {
+	url         string
+	index       string
+	credentials SinkCredentials
+	httpClient  *http.Client
+}
+
+// NewElasticsearchSink creates a sink indexing into index of the cluster at url
+func NewElasticsearchSink(url, index string, credentials SinkCredentials) *ElasticsearchSink {
+	return &ElasticsearchSink{
+		url:         strings.TrimSuffix(url, "/"),
+		index:       index,
+		credentials: credentials,
+		httpClient:  &http.Client{Timeout: sinkTimeout},
+	}
+}
+
+// Name implements Sink
+func (s *ElasticsearchSink) Name() string { return "elasticsearch" }
+
+// Write implements Sink
+func (s *ElasticsearchSink) Write(ctx context.Context, events []AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	// "create" also works with data streams, which only accept appends
+	action, err := json.Marshal(map[string]interface{}{"create": map[string]string{"_index": s.index}})
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	for _, event := range events {
+		doc, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit event %s: %w", event.EventID, err)
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(doc)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/_bulk", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	s.credentials.apply(req)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("bulk request", resp)
+	}
+
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+	failed, reason := 0, ""
+	for _, item := range result.Items {
+		for _, op := range item {
+			if op.Status >= 300 {
+				failed++
+				reason = op.Error.Type + ": " + op.Error.Reason
+			}
+		}
+	}
+	return fmt.Errorf("%d of %d audit events were not indexed, last error %s", failed, len(events), reason)
+}
+
+// Close implements Sink
+func (s *ElasticsearchSink) Close(ctx context.Context) error { return nil }
+
+// KafkaSink produces audit events to a topic through a Kafka REST Proxy
+// using the v2 JSON embedded format. Events are keyed by namespace so the
+// history of a namespace stays ordered within a partition.
+type KafkaSink struct {
+	url         string
+	topic       string
+	credentials SinkCredentials
+	httpClient  *http.Client
+}
+
+// NewKafkaSink creates a sink producing to topic through the REST Proxy at url
+func NewKafkaSink(url, topic string, credentials SinkCredentials) *KafkaSink {
+	return &KafkaSink{
+		url:         strings.TrimSuffix(url, "/"),
+		topic:       topic,
+		credentials: credentials,
+		httpClient:  &http.Client{Timeout: sinkTimeout},
+	}
+}
+
+// Name implements Sink
+func (s *KafkaSink) Name() string { return "kafka" }
+
+type kafkaRecord struct {
+	Key   string     `json:"key,omitempty"`
+	Value AuditEvent `json:"value"`
+}
+
+// Write implements Sink
+func (s *KafkaSink) Write(ctx context.Context, events []AuditEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	records := make([]kafkaRecord, 0, len(events))
+	for _, event := range events {
+		records = append(records, kafkaRecord{Key: event.Namespace, Value: event})
+	}
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url+"/topics/"+s.topic, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	s.credentials.apply(req)
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("produce request", resp)
+	}
+
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode produce response: %w", err)
+	}
+	var errs []error
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			errs = append(errs, fmt.Errorf("error %d: %s", *offset.ErrorCode, offset.Error))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d audit events were not produced: %w", len(errs), len(events), errs[len(errs)-1])
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *KafkaSink) Close(ctx context.Context) error { return nil }
+
+// responseError describes a failed request to a sink
+func responseError(what string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return errors.New(what + " failed with status " + resp.Status + ": " + strings.TrimSpace(string(body)))
+}
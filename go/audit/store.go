@@ -0,0 +1,431 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/logger"
+)
+
+const (
+	segmentPrefix     = "audit-"
+	segmentSuffix     = ".jsonl"
+	segmentDayFormat  = "20060102"
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// Query selects audit events from the store; empty fields match everything
+type Query struct {
+	Namespace string
+	Workload  string // Kind/name, or just the name
+	EventType string
+	Operation string
+	Status    string
+	Since     time.Time
+	Until     time.Time
+	Limit     int // defaults to 100, at most 1000
+}
+
+// QueryResult holds the newest matching events and the number of all matches
+type QueryResult struct {
+	Events []AuditEvent `json:"items"`
+	Total  int          `json:"total"`
+}
+
+// indexEntry locates an event in its segment and holds the fields queries filter on
+type indexEntry struct {
+	offset    int64
+	length    int64
+	timestamp time.Time
+	namespace string
+	workload  string
+	eventType string
+	operation string
+	status    string
+}
+
+// segment is the file of the events of one UTC day
+type segment struct {
+	day     time.Time
+	path    string
+	size    int64
+	entries []indexEntry
+}
+
+// Store keeps audit events on disk in one JSON lines file per UTC day, with
+// an in-memory index of the fields queries filter on so only matching events
+// are read back. The index is rebuilt from the files when the store is
+// opened. Days past the retention are deleted by Compact, and the day the
+// retention ends in is rewritten without its expired events.
+type Store struct {
+	dir string
+
+	mu       sync.RWMutex
+	segments []*segment // oldest first
+	active   *segment   // segment the append handle writes to
+	file     *os.File
+}
+
+// storeRecord holds the indexed fields of a stored event
+type storeRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Namespace string    `json:"namespace"`
+	Workload  string    `json:"workload"`
+	EventType string    `json:"eventType"`
+	Operation string    `json:"operation"`
+	Status    string    `json:"status"`
+}
+
+// OpenStore opens the store in dir, indexing the events already there
+func OpenStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create audit store directory: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"+segmentSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir}
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), segmentPrefix), segmentSuffix)
+		day, err := time.Parse(segmentDayFormat, name)
+		if err != nil {
+			continue
+		}
+		seg := &segment{day: day, path: path}
+		if err := seg.index(); err != nil {
+			return nil, err
+		}
+		s.segments = append(s.segments, seg)
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].day.Before(s.segments[j].day) })
+	return s, nil
+}
+
+// index reads the segment's events into its index. A last line without a
+// newline, left by a crash during a write, is cut off so appends stay valid.
+func (seg *segment) index() error {
+	file, err := os.OpenFile(seg.path, os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit segment: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				logger.Warn("Truncating incomplete audit event at the end of %s", seg.path)
+				if err := file.Truncate(offset); err != nil {
+					return fmt.Errorf("failed to truncate audit segment: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read audit segment: %w", err)
+		}
+
+		var record storeRecord
+		if err := json.Unmarshal(line, &record); err == nil {
+			seg.entries = append(seg.entries, record.entry(offset, int64(len(line))))
+		}
+		offset += int64(len(line))
+	}
+	seg.size = offset
+	return nil
+}
+
+func (r storeRecord) entry(offset, length int64) indexEntry {
+	return indexEntry{
+		offset:    offset,
+		length:    length,
+		timestamp: r.Timestamp,
+		namespace: r.Namespace,
+		workload:  r.Workload,
+		eventType: r.EventType,
+		operation: r.Operation,
+		status:    r.Status,
+	}
+}
+
+// Append stores an event in the segment of its day
+func (s *Store) Append(event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seg, err := s.segmentFor(event.Timestamp)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	record := storeRecord{
+		Timestamp: event.Timestamp,
+		Namespace: event.Namespace,
+		Workload:  event.Workload,
+		EventType: event.EventType,
+		Operation: event.Operation,
+		Status:    event.Status,
+	}
+	seg.entries = append(seg.entries, record.entry(seg.size, int64(len(line))))
+	seg.size += int64(len(line))
+	return nil
+}
+
+// segmentFor returns the segment of a time's day, opened for appending; the
+// caller must hold the write lock
+func (s *Store) segmentFor(t time.Time) (*segment, error) {
+	day := t.UTC().Truncate(24 * time.Hour)
+	if s.active != nil && s.active.day.Equal(day) {
+		return s.active, nil
+	}
+
+	var seg *segment
+	for _, existing := range s.segments {
+		if existing.day.Equal(day) {
+			seg = existing
+			break
+		}
+	}
+	if seg == nil {
+		seg = &segment{day: day, path: filepath.Join(s.dir, segmentPrefix+day.Format(segmentDayFormat)+segmentSuffix)}
+		s.segments = append(s.segments, seg)
+		sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].day.Before(s.segments[j].day) })
+	}
+	if err := s.openActive(seg); err != nil {
+		return nil, err
+	}
+	return seg, nil
+}
+
+// openActive points the append handle at a segment; the caller must hold the write lock
+func (s *Store) openActive(seg *segment) error {
+	if s.file != nil {
+		s.file.Close()
+		s.file, s.active = nil, nil
+	}
+	file, err := os.OpenFile(seg.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit segment: %w", err)
+	}
+	s.file, s.active = file, seg
+	return nil
+}
+
+// Query returns the newest events matching the query, newest first
+func (s *Store) Query(q Query) (*QueryResult, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	limit = min(limit, maxQueryLimit)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := &QueryResult{Events: []AuditEvent{}}
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		seg := s.segments[i]
+		if (!q.Since.IsZero() && !seg.day.Add(24*time.Hour).After(q.Since)) || (!q.Until.IsZero() && seg.day.After(q.Until)) {
+			continue
+		}
+
+		var file *os.File
+		for j := len(seg.entries) - 1; j >= 0; j-- {
+			entry := &seg.entries[j]
+			if !q.matches(entry) {
+				continue
+			}
+			result.Total++
+			if len(result.Events) >= limit {
+				continue
+			}
+
+			if file == nil {
+				var err error
+				if file, err = os.Open(seg.path); err != nil {
+					return nil, fmt.Errorf("failed to open audit segment: %w", err)
+				}
+				defer file.Close()
+			}
+			buf := make([]byte, entry.length)
+			if _, err := file.ReadAt(buf, entry.offset); err != nil {
+				return nil, fmt.Errorf("failed to read audit event: %w", err)
+			}
+			var event AuditEvent
+			if err := json.Unmarshal(buf, &event); err != nil {
+				return nil, fmt.Errorf("failed to decode audit event: %w", err)
+			}
+			result.Events = append(result.Events, event)
+		}
+	}
+	return result, nil
+}
+
+func (q *Query) matches(entry *indexEntry) bool {
+	if q.Namespace != "" && entry.namespace != q.Namespace {
+		return false
+	}
+	if q.Workload != "" && entry.workload != q.Workload && !strings.HasSuffix(entry.workload, "/"+q.Workload) {
+		return false
+	}
+	if q.EventType != "" && entry.eventType != q.EventType {
+		return false
+	}
+	if q.Operation != "" && entry.operation != q.Operation {
+		return false
+	}
+	if q.Status != "" && entry.status != q.Status {
+		return false
+	}
+	if !q.Since.IsZero() && entry.timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.timestamp.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Compact removes the events older than the retention: segments of days
+// entirely past it are deleted, and the segment the retention ends in is
+// rewritten without its expired events
+func (s *Store) Compact(retention time.Duration) error {
+	cutoff := time.Now().Add(-retention)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.segments[:0]
+	var errs []error
+	for _, seg := range s.segments {
+		switch {
+		case !seg.day.Add(24 * time.Hour).After(cutoff):
+			if seg == s.active {
+				s.file.Close()
+				s.file, s.active = nil, nil
+			}
+			if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+				kept = append(kept, seg)
+				continue
+			}
+			logger.Info("Removed audit events of %s, older than the %v retention", seg.day.Format("2006-01-02"), retention)
+		case seg.day.Before(cutoff):
+			if err := s.rewrite(seg, cutoff); err != nil {
+				errs = append(errs, err)
+			}
+			kept = append(kept, seg)
+		default:
+			kept = append(kept, seg)
+		}
+	}
+	s.segments = kept
+	return errors.Join(errs...)
+}
+
+// rewrite drops the events of a segment older than cutoff; the caller must hold the write lock
+func (s *Store) rewrite(seg *segment, cutoff time.Time) error {
+	expired := 0
+	for _, entry := range seg.entries {
+		if entry.timestamp.Before(cutoff) {
+			expired++
+		}
+	}
+	if expired == 0 {
+		return nil
+	}
+
+	src, err := os.Open(seg.path)
+	if err != nil {
+		return fmt.Errorf("failed to open audit segment: %w", err)
+	}
+	defer src.Close()
+	tmpPath := seg.path + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted audit segment: %w", err)
+	}
+
+	entries := make([]indexEntry, 0, len(seg.entries)-expired)
+	var size int64
+	for _, entry := range seg.entries {
+		if entry.timestamp.Before(cutoff) {
+			continue
+		}
+		if _, err := io.Copy(dst, io.NewSectionReader(src, entry.offset, entry.length)); err != nil {
+			dst.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to copy audit event: %w", err)
+		}
+		entry.offset = size
+		size += entry.length
+		entries = append(entries, entry)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, seg.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace audit segment: %w", err)
+	}
+
+	seg.entries, seg.size = entries, size
+	if seg == s.active {
+		// The append handle still points at the replaced file
+		if err := s.openActive(seg); err != nil {
+			return err
+		}
+	}
+	logger.Info("Compacted audit events of %s, dropped %d expired events", seg.day.Format("2006-01-02"), expired)
+	return nil
+}
+
+// Close closes the append handle
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file, s.active = nil, nil
+	return err
+}
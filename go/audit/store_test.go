@@ -0,0 +1,198 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func appendEvents(t *testing.T, store *Store, events ...AuditEvent) {
+	t.Helper()
+	for _, event := range events {
+		if err := store.Append(event); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+}
+
+// TestStoreQuery verifies filters, ordering and limits
+func TestStoreQuery(t *testing.T) {
+	store, err := OpenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().UTC()
+	appendEvents(t, store,
+		AuditEvent{EventID: "1", Timestamp: now.Add(-48 * time.Hour), Namespace: "prod", Workload: "Deployment/web", Operation: "resize", Status: "success"},
+		AuditEvent{EventID: "2", Timestamp: now.Add(-2 * time.Hour), Namespace: "prod", Workload: "Deployment/web", Operation: "resize", Status: "failure"},
+		AuditEvent{EventID: "3", Timestamp: now.Add(-time.Hour), Namespace: "prod", Workload: "StatefulSet/db", Operation: "resize", Status: "success"},
+		AuditEvent{EventID: "4", Timestamp: now, Namespace: "dev", Workload: "Deployment/web", Operation: "policy_evaluation", Status: "success"},
+	)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+		total int
+	}{
+		{"all newest first", Query{}, []string{"4", "3", "2", "1"}, 4},
+		{"namespace", Query{Namespace: "prod"}, []string{"3", "2", "1"}, 3},
+		{"workload by name", Query{Namespace: "prod", Workload: "web"}, []string{"2", "1"}, 2},
+		{"workload by kind and name", Query{Workload: "StatefulSet/db"}, []string{"3"}, 1},
+		{"operation and status", Query{Operation: "resize", Status: "success"}, []string{"3", "1"}, 2},
+		{"time range", Query{Since: now.Add(-3 * time.Hour), Until: now.Add(-30 * time.Minute)}, []string{"3", "2"}, 2},
+		{"limit keeps the total", Query{Limit: 1}, []string{"4"}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := store.Query(tt.query)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			var ids []string
+			for _, event := range result.Events {
+				ids = append(ids, event.EventID)
+			}
+			if len(ids) != len(tt.want) || result.Total != tt.total {
+				t.Fatalf("got %v (total %d), want %v (total %d)", ids, result.Total, tt.want, tt.total)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestStoreReopen verifies the index is rebuilt and a torn last line is dropped
+func TestStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	now := time.Now().UTC()
+	appendEvents(t, store, AuditEvent{EventID: "1", Timestamp: now, Namespace: "prod"})
+	store.Close()
+
+	// Simulate a crash in the middle of a write
+	path := filepath.Join(dir, segmentPrefix+now.Format(segmentDayFormat)+segmentSuffix)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	_, _ = file.WriteString(`{"eventId":"torn","times`)
+	file.Close()
+
+	store, err = OpenStore(dir)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+	appendEvents(t, store, AuditEvent{EventID: "2", Timestamp: now, Namespace: "prod"})
+
+	result, err := store.Query(Query{Namespace: "prod"})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if result.Total != 2 || result.Events[0].EventID != "2" || result.Events[1].EventID != "1" {
+		t.Fatalf("unexpected events after reopening: %+v", result.Events)
+	}
+}
+
+// TestStoreCompact verifies expired days are deleted and the boundary day is rewritten
+func TestStoreCompact(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+
+	now := time.Now().UTC()
+	boundary := now.Add(-10 * 24 * time.Hour).Truncate(24 * time.Hour)
+	appendEvents(t, store,
+		AuditEvent{EventID: "old", Timestamp: now.Add(-30 * 24 * time.Hour)},
+		AuditEvent{EventID: "expired", Timestamp: boundary.Add(time.Hour)},
+		AuditEvent{EventID: "kept", Timestamp: boundary.Add(23 * time.Hour)},
+		AuditEvent{EventID: "new", Timestamp: now},
+	)
+
+	// The retention ends between the expired and the kept event of the boundary day
+	retention := now.Sub(boundary.Add(12 * time.Hour))
+	if err := store.Compact(retention); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+
+	result, err := store.Query(Query{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if result.Total != 2 || result.Events[0].EventID != "new" || result.Events[1].EventID != "kept" {
+		t.Fatalf("unexpected events after compaction: %+v", result.Events)
+	}
+	segments, _ := filepath.Glob(filepath.Join(dir, segmentPrefix+"*"))
+	if len(segments) != 2 {
+		t.Fatalf("expected the expired day's segment to be deleted, got %v", segments)
+	}
+
+	// The compacted segment can still be appended to and reopened
+	appendEvents(t, store, AuditEvent{EventID: "late", Timestamp: boundary.Add(22 * time.Hour)})
+	store.Close()
+	store, err = OpenStore(dir)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	if result, _ := store.Query(Query{Until: boundary.Add(24 * time.Hour)}); result.Total != 2 {
+		t.Fatalf("expected 2 events on the boundary day, got %+v", result.Events)
+	}
+}
+
+// TestWorkloadOf verifies pods are attributed to their top-level workload
+func TestWorkloadOf(t *testing.T) {
+	controller := true
+	pod := func(kind, name string, labels map[string]string) *corev1.Pod {
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-7d4b9c-x2k4p", Labels: labels}}
+		if kind != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+		}
+		return p
+	}
+
+	tests := []struct {
+		pod  *corev1.Pod
+		want string
+	}{
+		{pod("ReplicaSet", "web-7d4b9c", map[string]string{"pod-template-hash": "7d4b9c"}), "Deployment/web"},
+		{pod("ReplicaSet", "legacy", nil), "ReplicaSet/legacy"},
+		{pod("StatefulSet", "db", nil), "StatefulSet/db"},
+		{pod("", "", nil), "Pod/web-7d4b9c-x2k4p"},
+	}
+	for _, tt := range tests {
+		if got := WorkloadOf(tt.pod); got != tt.want {
+			t.Errorf("WorkloadOf() = %s, want %s", got, tt.want)
+		}
+	}
+}
@@ -21,32 +21,289 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
 	// DefaultPredictionConfidenceThreshold is the default minimum confidence for using predictions
 	DefaultPredictionConfidenceThreshold = 0.6
+
+	// DefaultJWTSecret is the placeholder JWT secret, which the gRPC API refuses
+	DefaultJWTSecret = "default-secret-change-me-in-production" // pragma: allowlist secret
 )
 
 // Config holds all configuration for resource sizing
 // This configuration is now loaded from CRDs instead of environment variables
 // NotificationConfig holds notification settings
 type NotificationConfig struct {
-	EnableNotifications bool     // Enable sending notifications
-	SlackWebhookURL     string   // Slack webhook URL for notifications
-	EmailRecipients     []string // Email addresses to notify
-	SMTPHost            string   // SMTP server host
-	SMTPPort            int      // SMTP server port
-	SMTPUsername        string   // SMTP username
-	SMTPPassword        string   // SMTP password
+	EnableNotifications          bool                  // Enable sending notifications
+	Level                        string                // Minimum severity notified: debug, info, warning or error
+	SlackWebhookURL              string                // Slack webhook URL for notifications
+	SlackChannel                 string                // Channel overriding the webhook's default
+	SlackUsername                string                // Name messages are posted as
+	SlackIconEmoji               string                // Emoji used as the avatar
+	TeamsWebhookURL              string                // Microsoft Teams incoming webhook URL
+	PagerDutyRoutingKeySecret    string                // Secret with the PagerDuty Events API v2 routing key
+	PagerDutyRoutingKeySecretKey string                // Key of the routing key in the secret
+	EmailFrom                    string                // Sender address, SMTPUsername when empty
+	EmailRecipients              []string              // Email addresses to notify
+	SMTPHost                     string                // SMTP server host
+	SMTPPort                     int                   // SMTP server port
+	SMTPUseTLS                   bool                  // Require TLS, implicit on port 465 and STARTTLS otherwise
+	SMTPUsername                 string                // SMTP username
+	SMTPPassword                 string                // SMTP password
+	SMTPPasswordSecret           string                // Secret with the SMTP password, overriding SMTPPassword
+	SMTPPasswordSecretKey        string                // Key of the password in the secret
+	Webhooks                     []NotificationWebhook // Generic webhooks notifications are posted to
+}
+
+// NotificationWebhook is a generic webhook notifications are sent to as JSON
+type NotificationWebhook struct {
+	Name       string            // Name used in logs
+	URL        string            // Endpoint of the webhook
+	Method     string            // GET, POST or PUT
+	Headers    map[string]string // Headers added to every request
+	Timeout    time.Duration     // Timeout of a single request
+	RetryCount int               // Retries after a failed request
+}
+
+// ExportConfig holds the GitOps export settings
+type ExportConfig struct {
+	Enabled            bool   // Render decisions as patches instead of resizing pods
+	Format             string // strategic-merge, json-patch or kustomize
+	Target             string // configmap or git
+	ConfigMapName      string // ConfigMap the patches are written to
+	ConfigMapNamespace string // Namespace of the ConfigMap
+	GitRepository      string // HTTPS URL of the repository the patches are pushed to
+	GitBranch          string // Branch the patches are committed to
+	GitPath            string // Directory in the repository for the patches
+	GitAuthSecretName  string // Secret holding the access token used to push
+	GitAuthSecretKey   string // Key of the access token in the secret
+	GitAuthorName      string // Author name of the export commits
+	GitAuthorEmail     string // Author email of the export commits
+
+	VerticalPodAutoscalers bool // Also write recommendations into VerticalPodAutoscalers with updateMode Off
+}
+
+// CostConfig holds the settings of the cost provider used to price savings
+type CostConfig struct {
+	Provider        string        // none, opencost or kubecost
+	Endpoint        string        // Base URL of the OpenCost or Kubecost API
+	Window          string        // Allocation window prices are derived from, e.g. 7d
+	RefreshInterval time.Duration // How long fetched prices are reused
+}
+
+// AutoscalerConfig coordinates resizes with Karpenter and the Cluster Autoscaler
+type AutoscalerConfig struct {
+	AnnotateNodes          bool // Write the projected utilization after resizes to node annotations
+	SkipConsolidatingNodes bool // Hold back downsizes of pods on nodes marked for consolidation
+}
+
+// Circuit breaker scopes: what a single breaker guards
+const (
+	CircuitBreakerScopeCluster   = "cluster"
+	CircuitBreakerScopeNamespace = "namespace"
+	CircuitBreakerScopeNode      = "node"
+)
+
+// VPA modes: what happens to workloads a VerticalPodAutoscaler manages
+const (
+	VPAModeSkip    = "skip"    // Leave them to the VPA
+	VPAModeCompare = "compare" // Publish recommendations next to the VPA's target without resizing
+	VPAModeIgnore  = "ignore"  // Resize them as if there were no VPA
+)
+
+// Usage aggregations: how a window of usage history is reduced to the usage
+// a resource is sized from
+const (
+	AggregationLatest     = "latest"     // The latest sample
+	AggregationAverage    = "average"    // The mean over the window
+	AggregationMax        = "max"        // The peak over the window
+	AggregationPercentile = "percentile" // The configured percentile over the window
+)
+
+// CircuitBreakerConfig controls the circuit breakers guarding resize calls
+type CircuitBreakerConfig struct {
+	Enabled          bool          // Stop resizing a target while its resize calls keep failing
+	Scope            string        // Target a breaker guards: cluster, namespace or node
+	FailureThreshold int           // Failed calls in a row that open a breaker
+	RecoveryTimeout  time.Duration // How long an open breaker rejects calls before a trial call
+	SuccessThreshold int           // Successful trial calls that close a breaker again
+}
+
+// AuditSinkConfig holds the remote destinations audit events are shipped to;
+// a sink is enabled when its bucket, URL or topic is set
+type AuditSinkConfig struct {
+	S3Bucket                       string        // Bucket audit objects are written to
+	S3Endpoint                     string        // Object storage API, AWS S3 in S3Region when empty
+	S3Region                       string        // Region used to sign requests
+	S3Prefix                       string        // Prefix of the object keys
+	S3PathStyle                    bool          // Address the bucket in the path instead of the host name
+	S3RotationInterval             time.Duration // How often a new object is started
+	S3CredentialsSecret            string        // Secret with accessKeyId and secretAccessKey keys
+	ElasticsearchURL               string        // Elasticsearch cluster events are indexed in
+	ElasticsearchIndex             string        // Index audit events are written to
+	ElasticsearchCredentialsSecret string        // Secret with an apiKey key or username and password keys
+	KafkaRESTProxyURL              string        // Kafka REST Proxy events are produced through
+	KafkaTopic                     string        // Topic audit events are produced to
+	KafkaCredentialsSecret         string        // Secret with username and password keys
+}
+
+// AnomalyConfig holds the thresholds usage anomalies are detected with
+type AnomalyConfig struct {
+	Enabled              bool          // Detect memory growth, CPU saturation and restart storms
+	SuppressResizes      bool          // Hold back resizes of containers with an active anomaly
+	Window               time.Duration // Recent usage compared against the history before it
+	MemoryGrowthPercent  float64       // Memory growth over the window that is anomalous
+	CPUSaturationPercent float64       // Share of the CPU limit at which a container is saturated
+	RestartThreshold     int           // Restarts within the window that make a restart storm
+	ClearAfter           time.Duration // How long an anomaly must be gone before resizes resume
+}
+
+// ApplyPacingConfig adapts the delays between resizes to the API server:
+// throttling, server errors and slow resize calls multiply the delay, each
+// healthy call takes a step off it
+type ApplyPacingConfig struct {
+	Adaptive      bool          // Pace resizes by the API server instead of the fixed delays
+	LatencyTarget time.Duration // Resize calls slower than this count as congestion
+	MinDelay      time.Duration // Shortest delay between resizes while the API server is idle
+	MaxDelay      time.Duration // Longest delay between resizes while the API server is congested
+}
+
+// ChangeBudgetConfig limits the share of managed pods resized within a
+// sliding window; a percentage of zero disables that budget
+type ChangeBudgetConfig struct {
+	ClusterPercent   int           // Share of all managed pods resized per window
+	NamespacePercent int           // Share of a namespace's managed pods resized per window
+	MinPods          int           // Pods the cluster budget always allows, however small the cluster
+	Window           time.Duration // Sliding window the budgets are counted over
+}
+
+// CanaryConfig resizes one replica of a large Deployment first and rolls the
+// change out to the others once the canary has run cleanly for the bake time
+type CanaryConfig struct {
+	Enabled     bool
+	MinReplicas int           // Replicas a Deployment needs to be resized through a canary
+	BakeTime    time.Duration // How long the canary must run cleanly before the rollout continues
+	MaxRestarts int           // Container restarts of the canary tolerated while it bakes
+}
+
+// SelfSizingConfig lets the operator size its own Deployment, with smaller
+// steps and higher floors than other workloads
+type SelfSizingConfig struct {
+	Enabled          bool
+	MaxStepPercent   int   // Largest share by which a resource of the operator is lowered per resize
+	MinCPURequest    int64 // in millicores
+	MinMemoryRequest int64 // in MB
+}
+
+// HorizontalAdviceConfig suggests replica count changes next to the vertical
+// recommendations; the suggestions are never applied
+type HorizontalAdviceConfig struct {
+	Enabled           bool
+	MinSavingsPercent int // How much cheaper another replica count must be to be suggested
+	MinReplicas       int // Fewest replicas suggested for workloads without an HPA
+}
+
+// SafetyTuningConfig controls the extra headroom learned for workloads whose
+// resizes were rolled back or followed by restarts
+type SafetyTuningConfig struct {
+	Enabled       bool          // Widen the margin of workloads with a bad resize track record
+	StepPercent   int           // Headroom added per incident and removed per clean streak
+	MaxPercent    int           // Upper bound of the learned headroom
+	DecayAfter    int           // Clean resizes in a row after which the headroom shrinks by one step
+	RestartWindow time.Duration // How long after a resize container restarts are blamed on it
+}
+
+// ReportConfig controls the periodic per-namespace reports
+type ReportConfig struct {
+	Enabled      bool          // Generate reports on a schedule
+	Interval     time.Duration // How often reports are generated and the period they cover
+	TopWorkloads int           // Over- and under-provisioned workloads listed in each report
+}
+
+// ReportingConfig schedules savings and efficiency reports of the cluster
+// and where they are delivered
+type ReportingConfig struct {
+	Periods             []string // weekly and monthly; no reports when empty
+	Formats             []string // csv, json and html
+	Notify              bool     // Send reports through the notification channels
+	S3Bucket            string   // Bucket reports are written to, none when empty
+	S3Endpoint          string   // Object storage API, AWS S3 in S3Region when empty
+	S3Region            string   // Region used to sign requests
+	S3Prefix            string   // Prefix of the object keys
+	S3PathStyle         bool     // Address the bucket in the path instead of the host name
+	S3CredentialsSecret string   // Secret with accessKeyId and secretAccessKey keys
+}
+
+// Enabled reports whether any report is generated and delivered somewhere
+func (r ReportingConfig) Enabled() bool {
+	return len(r.Periods) > 0 && (r.Notify || r.S3Bucket != "")
+}
+
+// IdleConfig controls how workloads whose usage stays near zero are flagged
+// as idle. Idle workloads are only reported, never resized.
+type IdleConfig struct {
+	Enabled  bool          // Flag workloads whose usage stays near zero
+	CPUMilli float64       // CPU usage, summed over a workload's pods, at or below which it is idle
+	After    time.Duration // How long usage must stay near zero before a workload is flagged
+	Notify   bool          // Send a notification when a workload is flagged
+}
+
+// WASMPolicyConfig loads WebAssembly policy modules that may veto or adjust
+// resizes, for custom rules without rebuilding the operator
+type WASMPolicyConfig struct {
+	Dir     string        // Directory of the .wasm modules, evaluated in name order; none are loaded when empty
+	Timeout time.Duration // Longest a module may run on one decision
+}
+
+// OPAConfig asks an Open Policy Agent server to decide on every resize
+type OPAConfig struct {
+	URL     string        // Data API URL of the rule, e.g. http://localhost:8181/v1/data/rightsizer/resize; disabled when empty
+	Timeout time.Duration // Longest to wait for a decision
+}
+
+// WorkloadExclusion excludes the pods matching all of its criteria from right-sizing
+type WorkloadExclusion struct {
+	LabelSelector string   // Label selector in its string form, e.g. "app.kubernetes.io/component=database"
+	OwnerKinds    []string // Kinds of the pod's controller or owning workload, e.g. DaemonSet
+}
+
+// NamespaceOverride replaces sizing settings for the pods of some namespaces
+type NamespaceOverride struct {
+	Namespaces []string         // Namespaces the override applies to
+	CPU        ResourceOverride // CPU settings, in millicores
+	Memory     ResourceOverride // Memory settings, in MB
+}
+
+// ResourceOverride holds the settings of one resource a namespace overrides;
+// zero values keep the global setting
+type ResourceOverride struct {
+	RequestMultiplier  float64 // Multiplier applied to usage for requests
+	RequestAddition    int64   // Amount added to requests
+	LimitMultiplier    float64 // Multiplier applied to requests for limits
+	LimitAddition      int64   // Amount added to limits
+	MinRequest         int64   // Smallest request set
+	MaxLimit           int64   // Largest limit set
+	ScaleUpThreshold   float64 // Usage share (0-1) that triggers scale up
+	ScaleDownThreshold float64 // Usage share (0-1) that triggers scale down
 }
 
 type Config struct {
 	mu sync.RWMutex
 
+	// Subscribers called after each announced change, by subscription id
+	subscribersMu  sync.Mutex
+	subscribers    map[int]func(*Config)
+	nextSubscriber int
+
 	// Request multipliers - how much to multiply usage to get requests
 	CPURequestMultiplier    float64
 	MemoryRequestMultiplier float64
@@ -72,10 +329,82 @@ type Config struct {
 	MinMemoryRequest int64 // in MB
 
 	// Algorithm for resource calculation
-	Algorithm string // percentile, peak, average
+	Algorithm        string        // percentile, peak, average
+	Percentile       int           // Percentile of historical usage used by the percentile algorithm (50, 90, 95, 99)
+	PercentileWindow time.Duration // History window the percentile is computed over
+
+	// Per-resource usage aggregation over the history window (latest, average,
+	// max or percentile); empty follows the algorithm
+	CPUAggregation    string
+	MemoryAggregation string
+
+	// Peak-based limits: a resource with a limit window sizes its limit from
+	// the LimitPercentile of its usage over that window plus
+	// LimitHeadroomPercent, so limits follow spikes while requests follow the
+	// steady state. Without a window the limit is derived from the request.
+	LimitPercentile      float64       // e.g. 99.9
+	LimitHeadroomPercent int           // Percent added to the peak
+	CPULimitWindow       time.Duration // Short window of the CPU peak, e.g. 1h; 0 derives the limit from the request
+	MemoryLimitWindow    time.Duration // Window of the memory peak, e.g. 24h; 0 derives the limit from the request
+
+	// WorkloadAggregation combines the recommendations of a workload's replicas: max, percentile or none
+	WorkloadAggregation string
+
+	// DaemonSetNodeClassLabel is the node label whose values split DaemonSet
+	// replicas into node classes sized separately; empty sizes them together
+	DaemonSetNodeClassLabel string
+
+	// JobMode sizes Job and CronJob pods from their past runs: recommend,
+	// patch (the CronJob's job template) or resize (like long-running pods)
+	JobMode string
+
+	// NodeCapacityStrategy handles upsizes that do not fit on the node right now: cap or defer
+	NodeCapacityStrategy string
+
+	// VPAMode handles workloads a VerticalPodAutoscaler manages: skip, compare or ignore
+	VPAMode string
+
+	// Export renders decisions as patches for a GitOps pipeline instead of resizing pods
+	Export ExportConfig
+
+	// Cost prices savings from an OpenCost or Kubecost allocation endpoint
+	Cost CostConfig
+
+	// Autoscaler coordinates resizes with node autoscalers consolidating nodes
+	Autoscaler AutoscalerConfig
+
+	// CircuitBreaker isolates targets whose resize calls keep failing
+	CircuitBreaker CircuitBreakerConfig
+
+	// AuditSinks ship audit events to object storage, Elasticsearch or Kafka
+	AuditSinks AuditSinkConfig
+
+	// Anomalies detects usage anomalies and pauses resizes while they last
+	Anomalies AnomalyConfig
+
+	// Reports summarize each namespace's sizing, savings and incidents on a schedule
+	Reports ReportConfig
+
+	// Reporting delivers weekly and monthly savings and efficiency reports
+	Reporting ReportingConfig
+
+	// Idle flags workloads whose usage stays near zero as candidates for removal
+	Idle IdleConfig
+
+	// WASMPolicies evaluates user-supplied WebAssembly modules on every resize
+	WASMPolicies WASMPolicyConfig
+
+	// OPA asks an Open Policy Agent server to decide on every resize
+	OPA OPAConfig
+
+	// SafetyTuning widens the headroom of workloads whose resizes went wrong
+	SafetyTuning SafetyTuningConfig
 
 	// Operational configuration
 	ResizeInterval time.Duration // How often to check and resize resources
+	ResizeCooldown time.Duration // Minimum time between resizes of the same container
+	MinPodAge      time.Duration // Minimum time a pod has been running before it is analyzed
+	ScaleDownDelay time.Duration // Time usage must stay low before a resource is sized down
 	LogLevel       string        // Log level: debug, info, warn, error
 	MaxRetries     int           // Maximum retry attempts for operations
 	RetryInterval  time.Duration // Interval between retries
@@ -88,48 +417,107 @@ type Config struct {
 	MaxConcurrentReconciles int     // Max concurrent reconciles per controller
 	AuditEnabled            bool    // Enable audit logging for resource changes
 	DryRun                  bool    // Only log recommendations without applying changes
+	RecommendationOnly      bool    // Write RightSizerRecommendation objects instead of resizing pods
 	SafetyThreshold         float64 // Safety threshold for resource changes (0-1)
+	MaxStepPercent          int     // Largest change of a request or limit in one resize, in percent; 0 for no limit
 
 	// Batch processing configuration for API server protection
 	BatchSize           int           // Number of pods to process per batch
 	DelayBetweenBatches time.Duration // Delay between processing batches
 	DelayBetweenPods    time.Duration // Delay between individual pod updates
+	MaxResizesPerNode   int           // Pods resizing on a node at once, counting resizes in flight
+
+	// ApplyPacing starts from the delays above and adapts them to the API server
+	ApplyPacing ApplyPacingConfig
+
+	// ChangeBudget keeps a configuration mistake from resizing the whole cluster at once
+	ChangeBudget ChangeBudgetConfig
+
+	// Canary resizes one replica of a large Deployment before the others
+	Canary CanaryConfig
+
+	// SelfSizing sizes the operator's own pods, which are skipped otherwise
+	SelfSizing SelfSizingConfig
+
+	// HorizontalAdvice publishes replica count suggestions with the recommendations
+	HorizontalAdvice HorizontalAdviceConfig
+
+	// Analysis concurrency
+	MaxAnalysisWorkers int // Number of pods analyzed concurrently each cycle
+	MaxPodsPerCycle    int // Pods analyzed per cycle, resuming where the last cycle stopped (0 for all)
+	MaxUpdatesPerCycle int // Resizes applied per cycle, the most urgent first; the rest wait for the next cycle
 
 	// Global constraints
-	MaxCPUCores                int  // Global limit for CPU cores
-	MaxMemoryGB                int  // Global limit for memory in GB
-	PreventOOMKill             bool // Prevent OOM kills globally
-	RespectPodDisruptionBudget bool // Respect Pod Disruption Budgets globally
+	MaxCPUCores                int     // Global limit for CPU cores
+	MaxMemoryGB                int     // Global limit for memory in GB
+	PreventOOMKill             bool    // Prevent OOM kills globally
+	OOMMemoryBumpFactor        float64 // Factor applied to the memory limit of an OOM-killed container
+	RespectPodDisruptionBudget bool    // Respect Pod Disruption Budgets globally
 
 	// Namespace filters
 	NamespaceInclude []string // Namespaces to include
 	NamespaceExclude []string // Namespaces to exclude
 	SystemNamespaces []string // System namespaces to exclude
 
+	// ExcludedContainers are name patterns (shell globs) of containers never
+	// right-sized, such as service mesh proxies, whatever their namespace
+	ExcludedContainers []string
+
+	// NamespaceOverrides tune sizing per namespace, taking precedence over the global settings
+	NamespaceOverrides []NamespaceOverride
+
+	// Exclusions keep pods from being right-sized by their labels or owner kind
+	Exclusions []WorkloadExclusion
+
+	// DecisionFilters names the registered decision filters run, in order,
+	// on every resize before it is applied
+	DecisionFilters []string
+
 	// Advanced features
 	HistoryDays         int      // Days of history to keep for trend analysis
-	CustomMetrics       []string // Custom metrics to consider
+	CustomMetrics       []string // Custom metrics policies may size from; any when empty
 	AdmissionController bool     // Enable admission controller for validation
+	MutatingWebhook     bool     // Size new pods from their workload's stored recommendation
 
 	// Metrics provider configuration
 	MetricsProvider       string // "metrics-server" or "prometheus"
 	PrometheusURL         string // URL for Prometheus if used
 	MetricsServerEndpoint string // Endpoint for metrics server
 
+	// Prometheus-compatible backend settings (Prometheus, Thanos Query, VictoriaMetrics)
+	PrometheusUsername           string            // Basic auth username
+	PrometheusPassword           string            // Basic auth password
+	PrometheusBearerToken        string            // Bearer token, takes precedence over basic auth
+	PrometheusCAFile             string            // CA bundle used to verify the server certificate
+	PrometheusInsecureSkipVerify bool              // Skip TLS certificate verification
+	PrometheusQueryStep          time.Duration     // Resolution of range queries
+	PrometheusQueries            map[string]string // PromQL template overrides by query name
+
+	// MemoryMetric is the memory reading containers are sized from: usage,
+	// workingSet or rss; empty uses the provider's memory usage
+	MemoryMetric string
+
 	// Metrics configuration
-	AggregationMethod    string // avg, max, min, sum
-	HistoryRetention     string // Duration for metrics history
-	IncludeCustomMetrics bool   // Enable custom metrics
+	AggregationMethod    string        // avg, max, min, sum
+	HistoryRetention     string        // Duration for metrics history
+	IncludeCustomMetrics bool          // Let policies size from custom metrics
+	EfficiencyWindow     time.Duration // Window efficiency scores compare requests and usage over (env EFFICIENCY_WINDOW)
 
 	// Feature flags
 	UpdateResizePolicy bool // Update resize policy for in-place pod resizing (Kubernetes 1.33+)
 	PatchResizePolicy  bool // Automatically patch parent resources with resize policy
+	GroupedResize      bool // Resize CPU and memory in a single patch instead of two
 
 	// Prediction configuration
-	PredictionEnabled             bool     // Enable resource prediction using historical data
-	PredictionConfidenceThreshold float64  // Minimum confidence threshold for using predictions (0-1)
-	PredictionHistoryDays         int      // Days of historical data to retain for predictions
-	PredictionMethods             []string // Enabled prediction methods (linear_regression, exponential_smoothing, simple_moving_average, seasonal)
+	PredictionEnabled             bool          // Enable resource prediction using historical data
+	PredictionConfidenceThreshold float64       // Minimum confidence threshold for using predictions (0-1)
+	PredictionHistoryDays         int           // Days of historical data to retain for predictions
+	PredictionMethods             []string      // Enabled prediction methods (linear_regression, exponential_smoothing, simple_moving_average, seasonal, holt_winters_daily, holt_winters_weekly)
+	PredictionStorage             string        // Where usage history is kept: memory, file or prometheus
+	PredictionStoragePath         string        // Directory for the file storage, typically a PVC mount
+	ExternalPredictorURL          string        // Model endpoint forecasts are requested from, disabled when empty
+	ExternalPredictorTimeout      time.Duration // Timeout of a forecast request
+	ExternalPredictorToken        string        // Bearer token sent to the model endpoint
 
 	// QoS preservation settings
 	PreserveGuaranteedQoS      bool // Preserve Guaranteed QoS class during resizing
@@ -154,12 +542,20 @@ type Config struct {
 	// Security configuration
 	TLSCertDir            string // Directory for TLS certificates
 	WebhookTimeoutSeconds int    // Timeout for webhook requests
+	WebhookCertMode       string // selfSigned, certManager, or empty for CertPath/KeyPath (env WEBHOOK_CERT_MODE)
+	WebhookServiceName    string // Service fronting the webhook, named in its certificate (env WEBHOOK_SERVICE_NAME)
+	WebhookCertSecret     string // Secret keeping the self-signed CA and certificate (env WEBHOOK_CERT_SECRET)
+	WebhookConfigName     string // Webhook configurations whose caBundle is kept in sync (env WEBHOOK_CONFIGURATION_NAME)
+	ConversionWebhook     bool   // Serve CRD version conversion from the webhook server (env CONVERSION_WEBHOOK_ENABLED)
 
 	// Scaling thresholds
 	MemoryScaleUpThreshold   float64 // Memory usage percentage to trigger scale up (0-1)
 	MemoryScaleDownThreshold float64 // Memory usage percentage to trigger scale down (0-1)
 	CPUScaleUpThreshold      float64 // CPU usage percentage to trigger scale up (0-1)
 	CPUScaleDownThreshold    float64 // CPU usage percentage to trigger scale down (0-1)
+	CPUThrottleThreshold     float64 // CPU throttling percentage to trigger scale up (0-100, 0 disables)
+	CPUPressureThreshold     float64 // CPU PSI stall percentage to trigger scale up (0-100, 0 disables)
+	MemoryPressureThreshold  float64 // Memory PSI stall percentage to trigger scale up (0-100, 0 disables)
 
 	// Notification configuration
 	NotificationConfig *NotificationConfig // Notification settings
@@ -187,7 +583,18 @@ type Config struct {
 	DashboardRetryAttempts     int           // Number of retry attempts for failed requests
 
 	// Security configuration
-	JWTSecret string // JWT secret for token validation (env JWT_SECRET)
+	JWTSecret   string // JWT secret for token validation (env JWT_SECRET)
+	APIAuthMode string // API server authentication: none, kubernetes or apikey (env API_AUTH_MODE)
+	APIKey      string // Static API key for the apikey mode (env API_KEY)
+	APIPort     int    // Port of the HTTP API (env API_PORT)
+	GRPCPort    int    // Port of the gRPC API, authenticated with JWTSecret; 0 disables it (env GRPC_PORT)
+	GRPCTLSCert string // Serving certificate of the gRPC API (env GRPC_TLS_CERT_FILE)
+	GRPCTLSKey  string // Private key of the gRPC API certificate (env GRPC_TLS_KEY_FILE)
+
+	// HTTP API load protection
+	APICacheTTL  time.Duration // How long list and metrics responses are reused; 0 disables the cache (env API_CACHE_TTL)
+	APIRateLimit float64       // Requests per second allowed per client; 0 disables the limit (env API_RATE_LIMIT)
+	APIRateBurst int           // Requests a client may make at once above APIRateLimit (env API_RATE_BURST)
 }
 
 // Global config instance with thread-safe access
@@ -214,7 +621,85 @@ func GetDefaults() *Config {
 		MinMemoryRequest:        1,
 
 		// Default algorithm
-		Algorithm: "percentile",
+		Algorithm:        "percentile",
+		Percentile:       95,
+		PercentileWindow: 7 * 24 * time.Hour,
+
+		// Memory is sized to its peak so a spike does not end in an OOM kill
+		MemoryAggregation: AggregationMax,
+
+		LimitPercentile:      99.9,
+		LimitHeadroomPercent: 10,
+
+		WorkloadAggregation:  "max",
+		JobMode:              "recommend",
+		NodeCapacityStrategy: "cap",
+		VPAMode:              VPAModeSkip,
+		Export: ExportConfig{
+			Format:         "strategic-merge",
+			Target:         "configmap",
+			ConfigMapName:  "right-sizer-export",
+			GitBranch:      "main",
+			GitPath:        "right-sizer",
+			GitAuthorName:  "right-sizer",
+			GitAuthorEmail: "right-sizer@noreply.local",
+		},
+		Cost: CostConfig{
+			Provider:        "none",
+			Window:          "7d",
+			RefreshInterval: time.Hour,
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			Enabled:          true,
+			Scope:            CircuitBreakerScopeNamespace,
+			FailureThreshold: 5,
+			RecoveryTimeout:  30 * time.Second,
+			SuccessThreshold: 3,
+		},
+		AuditSinks: AuditSinkConfig{
+			S3Region:           "us-east-1",
+			S3Prefix:           "right-sizer/audit",
+			S3RotationInterval: 5 * time.Minute,
+			ElasticsearchIndex: "right-sizer-audit",
+			KafkaTopic:         "right-sizer-audit",
+		},
+		Anomalies: AnomalyConfig{
+			Enabled:              true,
+			SuppressResizes:      true,
+			Window:               15 * time.Minute,
+			MemoryGrowthPercent:  50,
+			CPUSaturationPercent: 95,
+			RestartThreshold:     3,
+			ClearAfter:           15 * time.Minute,
+		},
+		SafetyTuning: SafetyTuningConfig{
+			Enabled:       true,
+			StepPercent:   10,
+			MaxPercent:    50,
+			DecayAfter:    5,
+			RestartWindow: time.Hour,
+		},
+		Reports: ReportConfig{
+			Enabled:      true,
+			Interval:     7 * 24 * time.Hour,
+			TopWorkloads: 5,
+		},
+		Reporting: ReportingConfig{
+			Formats:  []string{"html"},
+			S3Region: "us-east-1",
+			S3Prefix: "right-sizer/reports",
+		},
+		Idle: IdleConfig{
+			Enabled:  true,
+			CPUMilli: 5,
+			After:    72 * time.Hour,
+		},
+		WASMPolicies: WASMPolicyConfig{
+			Timeout: 100 * time.Millisecond,
+		},
+		OPA: OPAConfig{
+			Timeout: time.Second,
+		},
 
 		// Default QoS preservation settings
 		PreserveGuaranteedQoS:      true,
@@ -223,6 +708,9 @@ func GetDefaults() *Config {
 
 		// Default operational settings
 		ResizeInterval: 30 * time.Second,
+		ResizeCooldown: 5 * time.Minute,
+		MinPodAge:      5 * time.Minute,
+		ScaleDownDelay: 30 * time.Minute,
 		LogLevel:       "info",
 		MaxRetries:     3,
 		RetryInterval:  5 * time.Second,
@@ -235,17 +723,49 @@ func GetDefaults() *Config {
 		MaxConcurrentReconciles: 3,
 		AuditEnabled:            true,
 		DryRun:                  false,
+		RecommendationOnly:      false,
 		SafetyThreshold:         0.5, // 50% change threshold
 
 		// Default batch processing values
 		BatchSize:           3,
 		DelayBetweenBatches: 5 * time.Second,
 		DelayBetweenPods:    500 * time.Millisecond,
+		MaxResizesPerNode:   2,
+		ApplyPacing: ApplyPacingConfig{
+			Adaptive:      true,
+			LatencyTarget: time.Second,
+			MinDelay:      100 * time.Millisecond,
+			MaxDelay:      30 * time.Second,
+		},
+		ChangeBudget: ChangeBudgetConfig{
+			ClusterPercent: 10,
+			MinPods:        5,
+			Window:         time.Hour,
+		},
+		Canary: CanaryConfig{
+			MinReplicas: 3,
+			BakeTime:    10 * time.Minute,
+		},
+		SelfSizing: SelfSizingConfig{
+			MaxStepPercent:   10,
+			MinCPURequest:    100,
+			MinMemoryRequest: 128,
+		},
+		HorizontalAdvice: HorizontalAdviceConfig{
+			MinSavingsPercent: 10,
+			MinReplicas:       2,
+		},
+
+		// Default analysis concurrency
+		MaxAnalysisWorkers: 4,
+		MaxPodsPerCycle:    0,
+		MaxUpdatesPerCycle: 50,
 
 		// Default global constraints
 		MaxCPUCores:                16,
 		MaxMemoryGB:                32,
 		PreventOOMKill:             true,
+		OOMMemoryBumpFactor:        1.5,
 		RespectPodDisruptionBudget: true,
 
 		// Default namespace filters
@@ -259,28 +779,36 @@ func GetDefaults() *Config {
 			"ingress-nginx",
 			"istio-system",
 		},
+		ExcludedContainers: []string{"istio-proxy", "linkerd-proxy"},
 
 		// Default advanced features
 		HistoryDays:         7,
 		AdmissionController: false,
+		MutatingWebhook:     false,
 
 		// Default metrics configuration
 		MetricsProvider:       "metrics-server",
 		MetricsServerEndpoint: "",
 		PrometheusURL:         "http://prometheus:9090",
+		PrometheusQueryStep:   time.Minute,
 		AggregationMethod:     "avg",
 		HistoryRetention:      "30d",
 		IncludeCustomMetrics:  false,
+		EfficiencyWindow:      24 * time.Hour,
 
 		// Default feature flags
 		UpdateResizePolicy: false,
 		PatchResizePolicy:  false,
+		GroupedResize:      true,
 
 		// Default prediction configuration
 		PredictionEnabled:             true,
 		PredictionConfidenceThreshold: 0.6,
 		PredictionHistoryDays:         7,
-		PredictionMethods:             []string{"linear_regression", "exponential_smoothing", "simple_moving_average", "seasonal"},
+		PredictionMethods:             []string{"linear_regression", "exponential_smoothing", "simple_moving_average", "seasonal", "holt_winters_daily", "holt_winters_weekly"},
+		PredictionStorage:             "memory",
+		PredictionStoragePath:         "/var/lib/right-sizer",
+		ExternalPredictorTimeout:      10 * time.Second,
 
 		// Default observability configuration
 		EnableAuditLogging: true,
@@ -306,14 +834,19 @@ func GetDefaults() *Config {
 		MemoryScaleDownThreshold: 0.3, // Scale down when memory usage is below 30%
 		CPUScaleUpThreshold:      0.8, // Scale up when CPU usage exceeds 80%
 		CPUScaleDownThreshold:    0.3, // Scale down when CPU usage is below 30%
+		CPUThrottleThreshold:     25,  // Scale up CPU when more than 25% of periods are throttled
 
 		// Default notification configuration
 		NotificationConfig: &NotificationConfig{
 			EnableNotifications: false,
+			Level:               "warning",
 			SlackWebhookURL:     "",
+			SlackUsername:       "RightSizer",
+			SlackIconEmoji:      ":robot_face:",
 			EmailRecipients:     []string{},
 			SMTPHost:            "",
 			SMTPPort:            587,
+			SMTPUseTLS:          true,
 			SMTPUsername:        "",
 			SMTPPassword:        "",
 		},
@@ -334,7 +867,14 @@ func GetDefaults() *Config {
 		DashboardRetryAttempts:     3,
 
 		// Default security settings
-		JWTSecret: "default-secret-change-me-in-production", // pragma: allowlist secret
+		JWTSecret:   DefaultJWTSecret,
+		APIAuthMode: "none",
+		APIPort:     8082,
+
+		// Default HTTP API load protection
+		APICacheTTL:  5 * time.Second,
+		APIRateLimit: 10,
+		APIRateBurst: 20,
 	}
 
 	// Load JWT secret from environment
@@ -342,6 +882,43 @@ func GetDefaults() *Config {
 		c.JWTSecret = jwtSecret // pragma: allowlist secret
 	}
 
+	// Load API server authentication from environment
+	switch mode := os.Getenv("API_AUTH_MODE"); mode {
+	case "none", "kubernetes", "apikey":
+		c.APIAuthMode = mode
+	}
+	c.APIKey = os.Getenv("API_KEY")
+	if port, err := strconv.Atoi(os.Getenv("API_PORT")); err == nil && port > 0 {
+		c.APIPort = port
+	}
+	if window, err := ParseHistoryWindow(os.Getenv("EFFICIENCY_WINDOW")); err == nil && window > 0 {
+		c.EfficiencyWindow = window
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("API_CACHE_TTL")); err == nil && ttl >= 0 {
+		c.APICacheTTL = ttl
+	}
+	if limit, err := strconv.ParseFloat(os.Getenv("API_RATE_LIMIT"), 64); err == nil && limit >= 0 {
+		c.APIRateLimit = limit
+	}
+	if burst, err := strconv.Atoi(os.Getenv("API_RATE_BURST")); err == nil && burst > 0 {
+		c.APIRateBurst = burst
+	}
+
+	// Load admission webhook certificate management from environment
+	switch mode := os.Getenv("WEBHOOK_CERT_MODE"); mode {
+	case "selfSigned", "certManager":
+		c.WebhookCertMode = mode
+	}
+	c.WebhookServiceName = os.Getenv("WEBHOOK_SERVICE_NAME")
+	c.WebhookCertSecret = os.Getenv("WEBHOOK_CERT_SECRET")
+	c.WebhookConfigName = os.Getenv("WEBHOOK_CONFIGURATION_NAME")
+	c.ConversionWebhook = os.Getenv("CONVERSION_WEBHOOK_ENABLED") == "true"
+	if port, err := strconv.Atoi(os.Getenv("GRPC_PORT")); err == nil && port > 0 {
+		c.GRPCPort = port
+	}
+	c.GRPCTLSCert = os.Getenv("GRPC_TLS_CERT_FILE")
+	c.GRPCTLSKey = os.Getenv("GRPC_TLS_KEY_FILE")
+
 	// Derive cluster ID from environment; fall back if unset
 	clusterId := os.Getenv("CLUSTER_ID")
 	if strings.TrimSpace(clusterId) == "" {
@@ -377,6 +954,111 @@ func GetDefaults() *Config {
 		c.DashboardAPIToken = dashboardToken
 	}
 
+	// Load history persistence settings from environment
+	if storage := os.Getenv("PREDICTION_STORAGE"); storage != "" {
+		c.PredictionStorage = storage
+	}
+	if storagePath := os.Getenv("PREDICTION_STORAGE_PATH"); storagePath != "" {
+		c.PredictionStoragePath = storagePath
+	}
+
+	// Load the external forecasting model endpoint from environment
+	c.ExternalPredictorURL = os.Getenv("EXTERNAL_PREDICTOR_URL")
+	c.ExternalPredictorToken = os.Getenv("EXTERNAL_PREDICTOR_TOKEN")
+	if timeout := os.Getenv("EXTERNAL_PREDICTOR_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil && d > 0 {
+			c.ExternalPredictorTimeout = d
+		}
+	}
+
+	// Load anomaly detection settings from environment
+	if enabled := os.Getenv("AIOPS_ANOMALY_DETECTION"); enabled != "" {
+		c.Anomalies.Enabled = enabled == "true"
+	}
+	if suppress := os.Getenv("AIOPS_ANOMALY_SUPPRESS_RESIZES"); suppress != "" {
+		c.Anomalies.SuppressResizes = suppress == "true"
+	}
+	if window, err := time.ParseDuration(os.Getenv("AIOPS_ANOMALY_WINDOW")); err == nil && window > 0 {
+		c.Anomalies.Window = window
+	}
+	if growth, err := strconv.ParseFloat(os.Getenv("AIOPS_ANOMALY_MEMORY_GROWTH_PERCENT"), 64); err == nil && growth > 0 {
+		c.Anomalies.MemoryGrowthPercent = growth
+	}
+	if saturation, err := strconv.ParseFloat(os.Getenv("AIOPS_ANOMALY_CPU_SATURATION_PERCENT"), 64); err == nil && saturation > 0 && saturation <= 100 {
+		c.Anomalies.CPUSaturationPercent = saturation
+	}
+	if restarts, err := strconv.Atoi(os.Getenv("AIOPS_ANOMALY_RESTART_THRESHOLD")); err == nil && restarts > 0 {
+		c.Anomalies.RestartThreshold = restarts
+	}
+	if clearAfter, err := time.ParseDuration(os.Getenv("AIOPS_ANOMALY_CLEAR_AFTER")); err == nil && clearAfter > 0 {
+		c.Anomalies.ClearAfter = clearAfter
+	}
+
+	// Load namespace report settings from environment
+	if enabled := os.Getenv("REPORTS_ENABLED"); enabled != "" {
+		c.Reports.Enabled = enabled == "true"
+	}
+	if interval, err := time.ParseDuration(os.Getenv("REPORTS_INTERVAL")); err == nil && interval > 0 {
+		c.Reports.Interval = interval
+	}
+	if top, err := strconv.Atoi(os.Getenv("REPORTS_TOP_WORKLOADS")); err == nil && top > 0 {
+		c.Reports.TopWorkloads = top
+	}
+
+	// Load idle workload detection settings from environment
+	if enabled := os.Getenv("IDLE_DETECTION_ENABLED"); enabled != "" {
+		c.Idle.Enabled = enabled == "true"
+	}
+	if cpu, err := strconv.ParseFloat(os.Getenv("IDLE_CPU_MILLICORES"), 64); err == nil && cpu >= 0 {
+		c.Idle.CPUMilli = cpu
+	}
+	if after, err := ParseHistoryWindow(os.Getenv("IDLE_AFTER")); err == nil && after > 0 {
+		c.Idle.After = after
+	}
+	if notify := os.Getenv("IDLE_NOTIFY"); notify != "" {
+		c.Idle.Notify = notify == "true"
+	}
+
+	// Load WebAssembly policy settings from environment
+	if dir := os.Getenv("WASM_POLICY_DIR"); dir != "" {
+		c.WASMPolicies.Dir = dir
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("WASM_POLICY_TIMEOUT")); err == nil && timeout > 0 {
+		c.WASMPolicies.Timeout = timeout
+	}
+
+	// Load OPA settings from environment
+	if url := os.Getenv("OPA_URL"); url != "" {
+		c.OPA.URL = url
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("OPA_TIMEOUT")); err == nil && timeout > 0 {
+		c.OPA.Timeout = timeout
+	}
+
+	// Load safety margin tuning settings from environment
+	if enabled := os.Getenv("SAFETY_TUNING_ENABLED"); enabled != "" {
+		c.SafetyTuning.Enabled = enabled == "true"
+	}
+	if step, err := strconv.Atoi(os.Getenv("SAFETY_TUNING_STEP_PERCENT")); err == nil && step > 0 {
+		c.SafetyTuning.StepPercent = step
+	}
+	if limit, err := strconv.Atoi(os.Getenv("SAFETY_TUNING_MAX_PERCENT")); err == nil && limit >= 0 {
+		c.SafetyTuning.MaxPercent = limit
+	}
+	if decay, err := strconv.Atoi(os.Getenv("SAFETY_TUNING_DECAY_AFTER")); err == nil && decay > 0 {
+		c.SafetyTuning.DecayAfter = decay
+	}
+	if window, err := time.ParseDuration(os.Getenv("SAFETY_TUNING_RESTART_WINDOW")); err == nil && window > 0 {
+		c.SafetyTuning.RestartWindow = window
+	}
+
+	// Load Prometheus credentials and TLS settings from environment
+	c.PrometheusUsername = os.Getenv("PROMETHEUS_USERNAME")
+	c.PrometheusPassword = os.Getenv("PROMETHEUS_PASSWORD")
+	c.PrometheusBearerToken = os.Getenv("PROMETHEUS_BEARER_TOKEN")
+	c.PrometheusCAFile = os.Getenv("PROMETHEUS_CA_FILE")
+	c.PrometheusInsecureSkipVerify = os.Getenv("PROMETHEUS_INSECURE_SKIP_VERIFY") == "true"
+
 	return c
 }
 
@@ -489,22 +1171,22 @@ func (c *Config) UpdateFromCRD(
 	c.MemoryLimitAddition = memoryLimitAddition
 
 	if minCPURequest != "" {
-		if parsed, err := parseResourceQuantity(minCPURequest, "cpu"); err == nil {
+		if parsed, err := ParseCPUQuantity(minCPURequest); err == nil {
 			c.MinCPURequest = parsed
 		}
 	}
 	if minMemoryRequest != "" {
-		if parsed, err := parseResourceQuantity(minMemoryRequest, "memory"); err == nil {
+		if parsed, err := ParseMemoryQuantity(minMemoryRequest); err == nil {
 			c.MinMemoryRequest = parsed
 		}
 	}
 	if maxCPULimit != "" {
-		if parsed, err := parseResourceQuantity(maxCPULimit, "cpu"); err == nil {
+		if parsed, err := ParseCPUQuantity(maxCPULimit); err == nil {
 			c.MaxCPULimit = parsed
 		}
 	}
 	if maxMemoryLimit != "" {
-		if parsed, err := parseResourceQuantity(maxMemoryLimit, "memory"); err == nil {
+		if parsed, err := ParseMemoryQuantity(maxMemoryLimit); err == nil {
 			c.MaxMemoryLimit = parsed
 		}
 	}
@@ -662,6 +1344,608 @@ func (c *Config) UpdateFromCRD(
 	c.ConfigSource = "crd"
 }
 
+// UpdatePercentileSettings updates the percentile algorithm settings.
+// Zero values leave the current setting unchanged.
+func (c *Config) UpdatePercentileSettings(percentile int, window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if percentile > 0 && percentile <= 100 {
+		c.Percentile = percentile
+	}
+	if window > 0 {
+		c.PercentileWindow = window
+	}
+}
+
+// SetUsageAggregation sets how the history window is reduced to the CPU and
+// memory usage containers are sized from; empty follows the algorithm and
+// unknown methods leave the current setting unchanged
+func (c *Config) SetUsageAggregation(cpu, memory string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cpu == "" || ValidAggregation(cpu) {
+		c.CPUAggregation = cpu
+	}
+	if memory == "" || ValidAggregation(memory) {
+		c.MemoryAggregation = memory
+	}
+}
+
+// SetPeakLimits configures the peak-based limits. A zero percentile keeps
+// the current one, percentiles outside (0, 100] and negative headroom are
+// ignored; a zero window derives that resource's limit from its request.
+func (c *Config) SetPeakLimits(percentile float64, headroomPercent int, cpuWindow, memoryWindow time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if percentile > 0 && percentile <= 100 {
+		c.LimitPercentile = percentile
+	}
+	if headroomPercent >= 0 {
+		c.LimitHeadroomPercent = headroomPercent
+	}
+	c.CPULimitWindow = max(cpuWindow, 0)
+	c.MemoryLimitWindow = max(memoryWindow, 0)
+}
+
+// LimitWindow returns the history window the limit of a resource is sized
+// from, zero when it is derived from the request
+func (c *Config) LimitWindow(resource string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if resource == "memory" {
+		return c.MemoryLimitWindow
+	}
+	return c.CPULimitWindow
+}
+
+// ValidAggregation reports whether method is a known usage aggregation
+func ValidAggregation(method string) bool {
+	switch method {
+	case AggregationLatest, AggregationAverage, AggregationMax, AggregationPercentile:
+		return true
+	}
+	return false
+}
+
+// UsageAggregation returns the aggregation of a resource: its own setting,
+// else the one the algorithm implies
+func (c *Config) UsageAggregation(resource string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	method := c.CPUAggregation
+	if resource == "memory" {
+		method = c.MemoryAggregation
+	}
+	if method != "" {
+		return method
+	}
+	return AlgorithmAggregation(c.Algorithm)
+}
+
+// AlgorithmAggregation returns the usage aggregation an algorithm implies
+func AlgorithmAggregation(algorithm string) string {
+	switch algorithm {
+	case "percentile":
+		return AggregationPercentile
+	case "average":
+		return AggregationAverage
+	case "max", "peak":
+		return AggregationMax
+	}
+	return AggregationLatest
+}
+
+// SetWorkloadAggregation sets how replica recommendations are combined per workload.
+// Unknown modes leave the current setting unchanged.
+func (c *Config) SetWorkloadAggregation(mode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch mode {
+	case "max", "percentile", "none":
+		c.WorkloadAggregation = mode
+	}
+}
+
+// SetDaemonSetNodeClassLabel sets the node label that splits DaemonSet
+// replicas into separately sized node classes. Empty disables the split.
+func (c *Config) SetDaemonSetNodeClassLabel(label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.DaemonSetNodeClassLabel = label
+}
+
+// SetJobMode sets how Job and CronJob pods are sized.
+// Unknown modes leave the current setting unchanged.
+func (c *Config) SetJobMode(mode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch mode {
+	case "recommend", "patch", "resize":
+		c.JobMode = mode
+	}
+}
+
+// SetAnalysisConcurrency sets how many pods are analyzed concurrently and
+// per cycle. Workers below one and negative pod counts are ignored.
+func (c *Config) SetAnalysisConcurrency(workers, podsPerCycle int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if workers >= 1 {
+		c.MaxAnalysisWorkers = workers
+	}
+	if podsPerCycle >= 0 {
+		c.MaxPodsPerCycle = podsPerCycle
+	}
+}
+
+// SetMaxUpdatesPerCycle sets how many resizes are applied per cycle;
+// values below one are ignored
+func (c *Config) SetMaxUpdatesPerCycle(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limit >= 1 {
+		c.MaxUpdatesPerCycle = limit
+	}
+}
+
+// SetMaxResizesPerNode sets how many pods of a node are resized at once;
+// values below one are ignored
+func (c *Config) SetMaxResizesPerNode(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limit >= 1 {
+		c.MaxResizesPerNode = limit
+	}
+}
+
+// SetMaxStepPercent sets how far a request or limit may move in one resize,
+// in percent of its current value; 0 removes the limit and negative values
+// are ignored
+func (c *Config) SetMaxStepPercent(percent int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if percent >= 0 {
+		c.MaxStepPercent = percent
+	}
+}
+
+// SetChangeBudget sets the change budgets; percentages outside 0-100 and
+// an unset window keep the current values
+func (c *Config) SetChangeBudget(budget ChangeBudgetConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if budget.ClusterPercent < 0 || budget.ClusterPercent > 100 {
+		budget.ClusterPercent = c.ChangeBudget.ClusterPercent
+	}
+	if budget.NamespacePercent < 0 || budget.NamespacePercent > 100 {
+		budget.NamespacePercent = c.ChangeBudget.NamespacePercent
+	}
+	if budget.MinPods < 0 {
+		budget.MinPods = c.ChangeBudget.MinPods
+	}
+	if budget.Window <= 0 {
+		budget.Window = c.ChangeBudget.Window
+	}
+	c.ChangeBudget = budget
+}
+
+// SetHorizontalAdvice sets the replica count suggestions; savings outside
+// 0-100 and fewer than one replica keep the current values
+func (c *Config) SetHorizontalAdvice(advice HorizontalAdviceConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if advice.MinSavingsPercent < 0 || advice.MinSavingsPercent > 100 {
+		advice.MinSavingsPercent = c.HorizontalAdvice.MinSavingsPercent
+	}
+	if advice.MinReplicas < 1 {
+		advice.MinReplicas = c.HorizontalAdvice.MinReplicas
+	}
+	c.HorizontalAdvice = advice
+}
+
+// SetCanary sets the canary rollout of large Deployments; fewer than two
+// replicas, an unset bake time and negative restarts keep the current values
+func (c *Config) SetCanary(canary CanaryConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if canary.MinReplicas < 2 {
+		canary.MinReplicas = c.Canary.MinReplicas
+	}
+	if canary.BakeTime <= 0 {
+		canary.BakeTime = c.Canary.BakeTime
+	}
+	if canary.MaxRestarts < 0 {
+		canary.MaxRestarts = c.Canary.MaxRestarts
+	}
+	c.Canary = canary
+}
+
+// SetSelfSizing sets how the operator sizes its own pods; a step outside
+// 1-100 and floors below one keep the current values
+func (c *Config) SetSelfSizing(self SelfSizingConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if self.MaxStepPercent < 1 || self.MaxStepPercent > 100 {
+		self.MaxStepPercent = c.SelfSizing.MaxStepPercent
+	}
+	if self.MinCPURequest < 1 {
+		self.MinCPURequest = c.SelfSizing.MinCPURequest
+	}
+	if self.MinMemoryRequest < 1 {
+		self.MinMemoryRequest = c.SelfSizing.MinMemoryRequest
+	}
+	c.SelfSizing = self
+}
+
+// SetNodeCapacityStrategy sets how upsizes that do not fit on their node are handled
+func (c *Config) SetNodeCapacityStrategy(strategy string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch strategy {
+	case "cap", "defer":
+		c.NodeCapacityStrategy = strategy
+	}
+}
+
+// SetVPAMode sets how workloads managed by a VerticalPodAutoscaler are handled
+func (c *Config) SetVPAMode(mode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch mode {
+	case VPAModeSkip, VPAModeCompare, VPAModeIgnore:
+		c.VPAMode = mode
+	}
+}
+
+// SetCustomMetrics sets the custom metrics policies may size from; any
+// metric is allowed when names is empty
+func (c *Config) SetCustomMetrics(names []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.CustomMetrics = append([]string(nil), names...)
+}
+
+// SetDecisionFilters sets the decision filters run on every resize, in order
+func (c *Config) SetDecisionFilters(names []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.DecisionFilters = append([]string(nil), names...)
+}
+
+// SetExportConfig sets the GitOps export settings; empty values keep the defaults
+func (c *Config) SetExportConfig(export ExportConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().Export
+	switch export.Format {
+	case "strategic-merge", "json-patch", "kustomize":
+	default:
+		export.Format = defaults.Format
+	}
+	switch export.Target {
+	case "configmap", "git":
+	default:
+		export.Target = defaults.Target
+	}
+	if export.ConfigMapName == "" {
+		export.ConfigMapName = defaults.ConfigMapName
+	}
+	if export.GitBranch == "" {
+		export.GitBranch = defaults.GitBranch
+	}
+	if export.GitPath == "" {
+		export.GitPath = defaults.GitPath
+	}
+	if export.GitAuthorName == "" {
+		export.GitAuthorName = defaults.GitAuthorName
+	}
+	if export.GitAuthorEmail == "" {
+		export.GitAuthorEmail = defaults.GitAuthorEmail
+	}
+	c.Export = export
+}
+
+// SetCostConfig sets the cost provider settings; empty values keep the defaults
+func (c *Config) SetCostConfig(cost CostConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().Cost
+	switch cost.Provider {
+	case "opencost", "kubecost":
+	default:
+		cost.Provider = defaults.Provider
+	}
+	if cost.Window == "" {
+		cost.Window = defaults.Window
+	}
+	if cost.RefreshInterval <= 0 {
+		cost.RefreshInterval = defaults.RefreshInterval
+	}
+	c.Cost = cost
+}
+
+// SetAutoscalerConfig sets how resizes are coordinated with node autoscalers
+func (c *Config) SetAutoscalerConfig(autoscaler AutoscalerConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Autoscaler = autoscaler
+}
+
+// SetApplyPacing sets how resizes are paced by the API server; empty values
+// keep the defaults and the delays are kept in order
+func (c *Config) SetApplyPacing(pacing ApplyPacingConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().ApplyPacing
+	if pacing.LatencyTarget <= 0 {
+		pacing.LatencyTarget = defaults.LatencyTarget
+	}
+	if pacing.MinDelay <= 0 {
+		pacing.MinDelay = defaults.MinDelay
+	}
+	if pacing.MaxDelay <= 0 {
+		pacing.MaxDelay = defaults.MaxDelay
+	}
+	if pacing.MaxDelay < pacing.MinDelay {
+		pacing.MaxDelay = pacing.MinDelay
+	}
+	c.ApplyPacing = pacing
+}
+
+// SetCircuitBreakerConfig sets the circuit breakers of resize calls; empty
+// values keep the defaults
+func (c *Config) SetCircuitBreakerConfig(breaker CircuitBreakerConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().CircuitBreaker
+	switch breaker.Scope {
+	case CircuitBreakerScopeCluster, CircuitBreakerScopeNamespace, CircuitBreakerScopeNode:
+	default:
+		breaker.Scope = defaults.Scope
+	}
+	if breaker.FailureThreshold <= 0 {
+		breaker.FailureThreshold = defaults.FailureThreshold
+	}
+	if breaker.RecoveryTimeout <= 0 {
+		breaker.RecoveryTimeout = defaults.RecoveryTimeout
+	}
+	if breaker.SuccessThreshold <= 0 {
+		breaker.SuccessThreshold = defaults.SuccessThreshold
+	}
+	c.CircuitBreaker = breaker
+}
+
+// SetAuditSinks sets the remote audit sinks; empty values keep the defaults
+func (c *Config) SetAuditSinks(sinks AuditSinkConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().AuditSinks
+	if sinks.S3Region == "" {
+		sinks.S3Region = defaults.S3Region
+	}
+	if sinks.S3Prefix == "" {
+		sinks.S3Prefix = defaults.S3Prefix
+	}
+	if sinks.S3RotationInterval <= 0 {
+		sinks.S3RotationInterval = defaults.S3RotationInterval
+	}
+	if sinks.ElasticsearchIndex == "" {
+		sinks.ElasticsearchIndex = defaults.ElasticsearchIndex
+	}
+	if sinks.KafkaTopic == "" {
+		sinks.KafkaTopic = defaults.KafkaTopic
+	}
+	c.AuditSinks = sinks
+}
+
+// SetReporting sets the scheduled reports; empty formats, region and prefix
+// keep their defaults
+func (c *Config) SetReporting(reporting ReportingConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().Reporting
+	if len(reporting.Formats) == 0 {
+		reporting.Formats = defaults.Formats
+	}
+	if reporting.S3Region == "" {
+		reporting.S3Region = defaults.S3Region
+	}
+	if reporting.S3Prefix == "" {
+		reporting.S3Prefix = defaults.S3Prefix
+	}
+	c.Reporting = reporting
+}
+
+// SetNotificationConfig updates where and when notifications are sent
+func (c *Config) SetNotificationConfig(notifications NotificationConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defaults := GetDefaults().NotificationConfig
+	if notifications.Level == "" {
+		notifications.Level = defaults.Level
+	}
+	if notifications.SlackUsername == "" {
+		notifications.SlackUsername = defaults.SlackUsername
+	}
+	if notifications.SlackIconEmoji == "" {
+		notifications.SlackIconEmoji = defaults.SlackIconEmoji
+	}
+	if notifications.SMTPPort <= 0 {
+		notifications.SMTPPort = defaults.SMTPPort
+	}
+	for i := range notifications.Webhooks {
+		webhook := &notifications.Webhooks[i]
+		if webhook.Method == "" {
+			webhook.Method = "POST"
+		}
+		if webhook.Timeout <= 0 {
+			webhook.Timeout = 30 * time.Second
+		}
+		if webhook.RetryCount < 0 {
+			webhook.RetryCount = 0
+		}
+	}
+	c.NotificationConfig = &notifications
+}
+
+// SetAdmissionWebhooks enables the admission webhook server and its mutating path
+func (c *Config) SetAdmissionWebhooks(admissionController, mutating bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.AdmissionController = admissionController
+	c.MutatingWebhook = mutating
+}
+
+// SetGroupedResize enables resizing CPU and memory in a single patch
+func (c *Config) SetGroupedResize(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.GroupedResize = enabled
+}
+
+// SetPrometheusQuerySettings sets the range query step and the PromQL template
+// overrides; a zero step keeps the current value
+func (c *Config) SetPrometheusQuerySettings(step time.Duration, queries map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if step > 0 {
+		c.PrometheusQueryStep = step
+	}
+	c.PrometheusQueries = queries
+}
+
+// SetMemoryMetric sets the memory reading containers are sized from; empty
+// uses the provider's memory usage
+func (c *Config) SetMemoryMetric(metric string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.MemoryMetric = metric
+}
+
+// SetResizeCooldown sets the minimum time between resizes of the same container
+func (c *Config) SetResizeCooldown(cooldown time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cooldown >= 0 {
+		c.ResizeCooldown = cooldown
+	}
+}
+
+// SetMinPodAge sets how long a pod must have been running before it is analyzed
+func (c *Config) SetMinPodAge(age time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if age >= 0 {
+		c.MinPodAge = age
+	}
+}
+
+// SetScaleDownDelay sets how long usage must stay low before a resource is sized down
+func (c *Config) SetScaleDownDelay(delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if delay >= 0 {
+		c.ScaleDownDelay = delay
+	}
+}
+
+// SetEfficiencyWindow sets the window efficiency scores are computed over
+func (c *Config) SetEfficiencyWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if window > 0 {
+		c.EfficiencyWindow = window
+	}
+}
+
+// SetAPIServer updates the HTTP API port and authentication mode. A zero
+// port or an empty mode leaves the current setting unchanged.
+func (c *Config) SetAPIServer(port int, authMode string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if port > 0 {
+		c.APIPort = port
+	}
+	if authMode != "" {
+		c.APIAuthMode = authMode
+	}
+}
+
+// APIServerSettings returns the HTTP API port, authentication mode and API key
+func (c *Config) APIServerSettings() (port int, authMode, apiKey string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.APIPort, c.APIAuthMode, c.APIKey
+}
+
+// SetCPUThrottleThreshold updates the CPU throttling percentage that triggers scale up
+func (c *Config) SetCPUThrottleThreshold(threshold float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if threshold >= 0 && threshold <= 100 {
+		c.CPUThrottleThreshold = threshold
+	}
+}
+
+// SetPressureThresholds updates the CPU and memory pressure stall
+// percentages that trigger scale up; 0 disables the trigger and values
+// outside 0-100 are ignored
+func (c *Config) SetPressureThresholds(cpu, memory float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cpu >= 0 && cpu <= 100 {
+		c.CPUPressureThreshold = cpu
+	}
+	if memory >= 0 && memory <= 100 {
+		c.MemoryPressureThreshold = memory
+	}
+}
+
+// SetRecommendationOnly enables or disables recommendation-only mode
+func (c *Config) SetRecommendationOnly(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.RecommendationOnly = enabled
+}
+
 // ResetToDefaults resets the configuration to default values
 func (c *Config) ResetToDefaults() {
 	c.mu.Lock()
@@ -682,7 +1966,35 @@ func (c *Config) ResetToDefaults() {
 	c.MaxCPULimit = defaults.MaxCPULimit
 	c.MaxMemoryLimit = defaults.MaxMemoryLimit
 	c.Algorithm = defaults.Algorithm
+	c.Percentile = defaults.Percentile
+	c.PercentileWindow = defaults.PercentileWindow
+	c.CPUAggregation = defaults.CPUAggregation
+	c.MemoryAggregation = defaults.MemoryAggregation
+	c.LimitPercentile = defaults.LimitPercentile
+	c.LimitHeadroomPercent = defaults.LimitHeadroomPercent
+	c.CPULimitWindow = defaults.CPULimitWindow
+	c.MemoryLimitWindow = defaults.MemoryLimitWindow
+	c.WorkloadAggregation = defaults.WorkloadAggregation
+	c.JobMode = defaults.JobMode
+	c.DaemonSetNodeClassLabel = defaults.DaemonSetNodeClassLabel
 	c.ResizeInterval = defaults.ResizeInterval
+	c.ResizeCooldown = defaults.ResizeCooldown
+	c.MinPodAge = defaults.MinPodAge
+	c.ScaleDownDelay = defaults.ScaleDownDelay
+	c.NodeCapacityStrategy = defaults.NodeCapacityStrategy
+	c.VPAMode = defaults.VPAMode
+	c.MaxResizesPerNode = defaults.MaxResizesPerNode
+	c.ChangeBudget = defaults.ChangeBudget
+	c.Canary = defaults.Canary
+	c.SelfSizing = defaults.SelfSizing
+	c.HorizontalAdvice = defaults.HorizontalAdvice
+	c.MaxStepPercent = defaults.MaxStepPercent
+	c.Export = defaults.Export
+	c.Cost = defaults.Cost
+	c.Autoscaler = defaults.Autoscaler
+	c.CircuitBreaker = defaults.CircuitBreaker
+	c.ApplyPacing = defaults.ApplyPacing
+	c.AuditSinks = defaults.AuditSinks
 	c.LogLevel = defaults.LogLevel
 	c.MaxRetries = defaults.MaxRetries
 	c.RetryInterval = defaults.RetryInterval
@@ -691,27 +2003,47 @@ func (c *Config) ResetToDefaults() {
 	c.QPS = defaults.QPS
 	c.Burst = defaults.Burst
 	c.MaxConcurrentReconciles = defaults.MaxConcurrentReconciles
+	c.MaxAnalysisWorkers = defaults.MaxAnalysisWorkers
+	c.MaxPodsPerCycle = defaults.MaxPodsPerCycle
+	c.MaxUpdatesPerCycle = defaults.MaxUpdatesPerCycle
 	c.AuditEnabled = defaults.AuditEnabled
 	c.DryRun = defaults.DryRun
+	c.RecommendationOnly = defaults.RecommendationOnly
 	c.SafetyThreshold = defaults.SafetyThreshold
 	c.MaxCPUCores = defaults.MaxCPUCores
 	c.MaxMemoryGB = defaults.MaxMemoryGB
 	c.PreventOOMKill = defaults.PreventOOMKill
+	c.OOMMemoryBumpFactor = defaults.OOMMemoryBumpFactor
 	c.RespectPodDisruptionBudget = defaults.RespectPodDisruptionBudget
 	c.NamespaceInclude = defaults.NamespaceInclude
 	c.NamespaceExclude = defaults.NamespaceExclude
 	c.SystemNamespaces = defaults.SystemNamespaces
+	c.ExcludedContainers = defaults.ExcludedContainers
+	c.NamespaceOverrides = defaults.NamespaceOverrides
+	c.Exclusions = defaults.Exclusions
+	c.DecisionFilters = defaults.DecisionFilters
 	c.HistoryDays = defaults.HistoryDays
 	c.CustomMetrics = defaults.CustomMetrics
 	c.AdmissionController = defaults.AdmissionController
+	c.MutatingWebhook = defaults.MutatingWebhook
 	c.MetricsProvider = defaults.MetricsProvider
 	c.PrometheusURL = defaults.PrometheusURL
+	c.PrometheusUsername = defaults.PrometheusUsername
+	c.PrometheusPassword = defaults.PrometheusPassword
+	c.PrometheusBearerToken = defaults.PrometheusBearerToken
+	c.PrometheusCAFile = defaults.PrometheusCAFile
+	c.PrometheusInsecureSkipVerify = defaults.PrometheusInsecureSkipVerify
+	c.PrometheusQueryStep = defaults.PrometheusQueryStep
+	c.PrometheusQueries = defaults.PrometheusQueries
+	c.MemoryMetric = defaults.MemoryMetric
 	c.MetricsServerEndpoint = defaults.MetricsServerEndpoint
 	c.AggregationMethod = defaults.AggregationMethod
 	c.HistoryRetention = defaults.HistoryRetention
 	c.IncludeCustomMetrics = defaults.IncludeCustomMetrics
+	c.EfficiencyWindow = defaults.EfficiencyWindow
 	c.UpdateResizePolicy = defaults.UpdateResizePolicy
 	c.PatchResizePolicy = defaults.PatchResizePolicy
+	c.GroupedResize = defaults.GroupedResize
 	c.PreserveGuaranteedQoS = defaults.PreserveGuaranteedQoS
 	c.ForceGuaranteedForCritical = defaults.ForceGuaranteedForCritical
 	c.QoSTransitionWarning = defaults.QoSTransitionWarning
@@ -732,7 +2064,11 @@ func (c *Config) ResetToDefaults() {
 	c.MemoryScaleDownThreshold = defaults.MemoryScaleDownThreshold
 	c.CPUScaleUpThreshold = defaults.CPUScaleUpThreshold
 	c.CPUScaleDownThreshold = defaults.CPUScaleDownThreshold
+	c.CPUThrottleThreshold = defaults.CPUThrottleThreshold
+	c.CPUPressureThreshold = defaults.CPUPressureThreshold
+	c.MemoryPressureThreshold = defaults.MemoryPressureThreshold
 	c.NotificationConfig = defaults.NotificationConfig
+	c.Reporting = defaults.Reporting
 	c.ConfigSource = defaults.ConfigSource
 }
 
@@ -828,6 +2164,12 @@ func (c *Config) Validate() error {
 	if c.CPUScaleDownThreshold >= c.CPUScaleUpThreshold {
 		errors = append(errors, "CPU scale down threshold must be less than scale up threshold")
 	}
+	if c.CPUThrottleThreshold < 0 || c.CPUThrottleThreshold > 100 {
+		errors = append(errors, "CPU throttle threshold must be between 0 and 100")
+	}
+	if c.CPUPressureThreshold < 0 || c.CPUPressureThreshold > 100 || c.MemoryPressureThreshold < 0 || c.MemoryPressureThreshold > 100 {
+		errors = append(errors, "pressure thresholds must be between 0 and 100")
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("configuration validation errors: %s", strings.Join(errors, "; "))
@@ -876,6 +2218,135 @@ func (c *Config) IsNamespaceIncluded(namespace string) bool {
 	return true
 }
 
+// Subscribe registers fn to be called with the configuration after every
+// change announced with NotifyChanged, so components keeping settings of
+// their own pick up new values. It returns a function removing fn again.
+// fn runs on the goroutine announcing the change and must not block.
+func (c *Config) Subscribe(fn func(*Config)) (unsubscribe func()) {
+	c.subscribersMu.Lock()
+	defer c.subscribersMu.Unlock()
+
+	if c.subscribers == nil {
+		c.subscribers = make(map[int]func(*Config))
+	}
+	id := c.nextSubscriber
+	c.nextSubscriber++
+	c.subscribers[id] = fn
+	return func() {
+		c.subscribersMu.Lock()
+		defer c.subscribersMu.Unlock()
+		delete(c.subscribers, id)
+	}
+}
+
+// NotifyChanged calls the subscribers once a batch of settings changed, e.g.
+// after a RightSizerConfig was applied
+func (c *Config) NotifyChanged() {
+	c.subscribersMu.Lock()
+	subscribers := make([]func(*Config), 0, len(c.subscribers))
+	for _, fn := range c.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	c.subscribersMu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(c)
+	}
+}
+
+// SetExcludedContainers replaces the name patterns of the containers never
+// right-sized. Patterns that are not valid globs are dropped.
+func (c *Config) SetExcludedContainers(patterns []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ExcludedContainers = make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err == nil {
+			c.ExcludedContainers = append(c.ExcludedContainers, pattern)
+		}
+	}
+}
+
+// IsContainerExcluded reports whether a container name matches one of the
+// patterns of the containers never right-sized
+func (c *Config) IsContainerExcluded(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, pattern := range c.ExcludedContainers {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// SetNamespaceOverrides replaces the per-namespace sizing overrides
+func (c *Config) SetNamespaceOverrides(overrides []NamespaceOverride) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.NamespaceOverrides = overrides
+}
+
+// SetExclusions replaces the rules excluding workloads from right-sizing
+func (c *Config) SetExclusions(exclusions []WorkloadExclusion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Exclusions = exclusions
+}
+
+// ForNamespace returns the configuration pods of a namespace are sized with:
+// the first override listing the namespace applied over the global settings,
+// or the configuration itself when no override lists it
+func (c *Config) ForNamespace(namespace string) *Config {
+	c.mu.RLock()
+	var override *NamespaceOverride
+	for i := range c.NamespaceOverrides {
+		if slices.Contains(c.NamespaceOverrides[i].Namespaces, namespace) {
+			override = &c.NamespaceOverrides[i]
+			break
+		}
+	}
+	c.mu.RUnlock()
+	if override == nil {
+		return c
+	}
+
+	clone := c.Clone()
+	cpu, memory := override.CPU, override.Memory
+	overrideFloat(&clone.CPURequestMultiplier, cpu.RequestMultiplier)
+	overrideInt(&clone.CPURequestAddition, cpu.RequestAddition)
+	overrideFloat(&clone.CPULimitMultiplier, cpu.LimitMultiplier)
+	overrideInt(&clone.CPULimitAddition, cpu.LimitAddition)
+	overrideInt(&clone.MinCPURequest, cpu.MinRequest)
+	overrideInt(&clone.MaxCPULimit, cpu.MaxLimit)
+	overrideFloat(&clone.CPUScaleUpThreshold, cpu.ScaleUpThreshold)
+	overrideFloat(&clone.CPUScaleDownThreshold, cpu.ScaleDownThreshold)
+	overrideFloat(&clone.MemoryRequestMultiplier, memory.RequestMultiplier)
+	overrideInt(&clone.MemoryRequestAddition, memory.RequestAddition)
+	overrideFloat(&clone.MemoryLimitMultiplier, memory.LimitMultiplier)
+	overrideInt(&clone.MemoryLimitAddition, memory.LimitAddition)
+	overrideInt(&clone.MinMemoryRequest, memory.MinRequest)
+	overrideInt(&clone.MaxMemoryLimit, memory.MaxLimit)
+	overrideFloat(&clone.MemoryScaleUpThreshold, memory.ScaleUpThreshold)
+	overrideFloat(&clone.MemoryScaleDownThreshold, memory.ScaleDownThreshold)
+	return clone
+}
+
+// overrideFloat replaces a setting with an override that is set
+func overrideFloat(setting *float64, override float64) {
+	if override != 0 {
+		*setting = override
+	}
+}
+
+// overrideInt replaces a setting with an override that is set
+func overrideInt(setting *int64, override int64) {
+	if override != 0 {
+		*setting = override
+	}
+}
+
 // GetRetryConfig returns retry configuration for operations
 func (c *Config) GetRetryConfig() (maxRetries int, interval time.Duration) {
 	c.mu.RLock()
@@ -906,66 +2377,160 @@ func (c *Config) Clone() *Config {
 	defer c.mu.RUnlock()
 
 	clone := &Config{
-		CPURequestMultiplier:        c.CPURequestMultiplier,
-		MemoryRequestMultiplier:     c.MemoryRequestMultiplier,
-		CPURequestAddition:          c.CPURequestAddition,
-		MemoryRequestAddition:       c.MemoryRequestAddition,
-		CPULimitMultiplier:          c.CPULimitMultiplier,
-		MemoryLimitMultiplier:       c.MemoryLimitMultiplier,
-		CPULimitAddition:            c.CPULimitAddition,
-		MemoryLimitAddition:         c.MemoryLimitAddition,
-		MaxCPULimit:                 c.MaxCPULimit,
-		MaxMemoryLimit:              c.MaxMemoryLimit,
-		MinCPURequest:               c.MinCPURequest,
-		MinMemoryRequest:            c.MinMemoryRequest,
-		Algorithm:                   c.Algorithm,
-		ResizeInterval:              c.ResizeInterval,
-		LogLevel:                    c.LogLevel,
-		MaxRetries:                  c.MaxRetries,
-		RetryInterval:               c.RetryInterval,
-		MetricsEnabled:              c.MetricsEnabled,
-		MetricsPort:                 c.MetricsPort,
-		AuditEnabled:                c.AuditEnabled,
-		QPS:                         c.QPS,
-		Burst:                       c.Burst,
-		MaxConcurrentReconciles:     c.MaxConcurrentReconciles,
-		DryRun:                      c.DryRun,
-		SafetyThreshold:             c.SafetyThreshold,
-		MaxCPUCores:                 c.MaxCPUCores,
-		MaxMemoryGB:                 c.MaxMemoryGB,
-		PreventOOMKill:              c.PreventOOMKill,
-		RespectPodDisruptionBudget:  c.RespectPodDisruptionBudget,
-		HistoryDays:                 c.HistoryDays,
-		AdmissionController:         c.AdmissionController,
-		MetricsProvider:             c.MetricsProvider,
-		PrometheusURL:               c.PrometheusURL,
-		MetricsServerEndpoint:       c.MetricsServerEndpoint,
-		AggregationMethod:           c.AggregationMethod,
-		HistoryRetention:            c.HistoryRetention,
-		IncludeCustomMetrics:        c.IncludeCustomMetrics,
-		UpdateResizePolicy:          c.UpdateResizePolicy,
-		PreserveGuaranteedQoS:       c.PreserveGuaranteedQoS,
-		ForceGuaranteedForCritical:  c.ForceGuaranteedForCritical,
-		QoSTransitionWarning:        c.QoSTransitionWarning,
-		EnableAuditLogging:          c.EnableAuditLogging,
-		EnableProfiling:             c.EnableProfiling,
-		ProfilingPort:               c.ProfilingPort,
-		HealthProbePort:             c.HealthProbePort,
-		LeaderElectionLeaseDuration: c.LeaderElectionLeaseDuration,
-		LeaderElectionRenewDeadline: c.LeaderElectionRenewDeadline,
-		LeaderElectionRetryPeriod:   c.LeaderElectionRetryPeriod,
-		LivenessEndpoint:            c.LivenessEndpoint,
-		ReadinessEndpoint:           c.ReadinessEndpoint,
-		RetryAttempts:               c.RetryAttempts,
-		SyncPeriod:                  c.SyncPeriod,
-		TLSCertDir:                  c.TLSCertDir,
-		WebhookTimeoutSeconds:       c.WebhookTimeoutSeconds,
-		MemoryScaleUpThreshold:      c.MemoryScaleUpThreshold,
-		MemoryScaleDownThreshold:    c.MemoryScaleDownThreshold,
-		CPUScaleUpThreshold:         c.CPUScaleUpThreshold,
-		CPUScaleDownThreshold:       c.CPUScaleDownThreshold,
-		ConfigSource:                c.ConfigSource,
-		JWTSecret:                   c.JWTSecret,
+		CPURequestMultiplier:          c.CPURequestMultiplier,
+		MemoryRequestMultiplier:       c.MemoryRequestMultiplier,
+		CPURequestAddition:            c.CPURequestAddition,
+		MemoryRequestAddition:         c.MemoryRequestAddition,
+		CPULimitMultiplier:            c.CPULimitMultiplier,
+		MemoryLimitMultiplier:         c.MemoryLimitMultiplier,
+		CPULimitAddition:              c.CPULimitAddition,
+		MemoryLimitAddition:           c.MemoryLimitAddition,
+		MaxCPULimit:                   c.MaxCPULimit,
+		MaxMemoryLimit:                c.MaxMemoryLimit,
+		MinCPURequest:                 c.MinCPURequest,
+		MinMemoryRequest:              c.MinMemoryRequest,
+		Algorithm:                     c.Algorithm,
+		Percentile:                    c.Percentile,
+		PercentileWindow:              c.PercentileWindow,
+		CPUAggregation:                c.CPUAggregation,
+		MemoryAggregation:             c.MemoryAggregation,
+		LimitPercentile:               c.LimitPercentile,
+		LimitHeadroomPercent:          c.LimitHeadroomPercent,
+		CPULimitWindow:                c.CPULimitWindow,
+		MemoryLimitWindow:             c.MemoryLimitWindow,
+		WorkloadAggregation:           c.WorkloadAggregation,
+		JobMode:                       c.JobMode,
+		DaemonSetNodeClassLabel:       c.DaemonSetNodeClassLabel,
+		ResizeInterval:                c.ResizeInterval,
+		ResizeCooldown:                c.ResizeCooldown,
+		MinPodAge:                     c.MinPodAge,
+		ScaleDownDelay:                c.ScaleDownDelay,
+		NodeCapacityStrategy:          c.NodeCapacityStrategy,
+		VPAMode:                       c.VPAMode,
+		MaxResizesPerNode:             c.MaxResizesPerNode,
+		ChangeBudget:                  c.ChangeBudget,
+		Canary:                        c.Canary,
+		SelfSizing:                    c.SelfSizing,
+		HorizontalAdvice:              c.HorizontalAdvice,
+		MaxStepPercent:                c.MaxStepPercent,
+		Export:                        c.Export,
+		Cost:                          c.Cost,
+		Autoscaler:                    c.Autoscaler,
+		CircuitBreaker:                c.CircuitBreaker,
+		ApplyPacing:                   c.ApplyPacing,
+		AuditSinks:                    c.AuditSinks,
+		Anomalies:                     c.Anomalies,
+		Reports:                       c.Reports,
+		Reporting:                     c.Reporting,
+		Idle:                          c.Idle,
+		WASMPolicies:                  c.WASMPolicies,
+		OPA:                           c.OPA,
+		SafetyTuning:                  c.SafetyTuning,
+		LogLevel:                      c.LogLevel,
+		MaxRetries:                    c.MaxRetries,
+		RetryInterval:                 c.RetryInterval,
+		MetricsEnabled:                c.MetricsEnabled,
+		MetricsPort:                   c.MetricsPort,
+		AuditEnabled:                  c.AuditEnabled,
+		QPS:                           c.QPS,
+		Burst:                         c.Burst,
+		MaxConcurrentReconciles:       c.MaxConcurrentReconciles,
+		MaxAnalysisWorkers:            c.MaxAnalysisWorkers,
+		MaxPodsPerCycle:               c.MaxPodsPerCycle,
+		MaxUpdatesPerCycle:            c.MaxUpdatesPerCycle,
+		DryRun:                        c.DryRun,
+		RecommendationOnly:            c.RecommendationOnly,
+		SafetyThreshold:               c.SafetyThreshold,
+		MaxCPUCores:                   c.MaxCPUCores,
+		MaxMemoryGB:                   c.MaxMemoryGB,
+		PreventOOMKill:                c.PreventOOMKill,
+		OOMMemoryBumpFactor:           c.OOMMemoryBumpFactor,
+		RespectPodDisruptionBudget:    c.RespectPodDisruptionBudget,
+		HistoryDays:                   c.HistoryDays,
+		AdmissionController:           c.AdmissionController,
+		MutatingWebhook:               c.MutatingWebhook,
+		MetricsProvider:               c.MetricsProvider,
+		PrometheusURL:                 c.PrometheusURL,
+		PrometheusUsername:            c.PrometheusUsername,
+		PrometheusPassword:            c.PrometheusPassword,
+		PrometheusBearerToken:         c.PrometheusBearerToken,
+		PrometheusCAFile:              c.PrometheusCAFile,
+		PrometheusInsecureSkipVerify:  c.PrometheusInsecureSkipVerify,
+		PrometheusQueryStep:           c.PrometheusQueryStep,
+		MemoryMetric:                  c.MemoryMetric,
+		MetricsServerEndpoint:         c.MetricsServerEndpoint,
+		AggregationMethod:             c.AggregationMethod,
+		HistoryRetention:              c.HistoryRetention,
+		IncludeCustomMetrics:          c.IncludeCustomMetrics,
+		EfficiencyWindow:              c.EfficiencyWindow,
+		UpdateResizePolicy:            c.UpdateResizePolicy,
+		GroupedResize:                 c.GroupedResize,
+		PreserveGuaranteedQoS:         c.PreserveGuaranteedQoS,
+		ForceGuaranteedForCritical:    c.ForceGuaranteedForCritical,
+		QoSTransitionWarning:          c.QoSTransitionWarning,
+		EnableAuditLogging:            c.EnableAuditLogging,
+		EnableProfiling:               c.EnableProfiling,
+		ProfilingPort:                 c.ProfilingPort,
+		HealthProbePort:               c.HealthProbePort,
+		LeaderElectionLeaseDuration:   c.LeaderElectionLeaseDuration,
+		LeaderElectionRenewDeadline:   c.LeaderElectionRenewDeadline,
+		LeaderElectionRetryPeriod:     c.LeaderElectionRetryPeriod,
+		LivenessEndpoint:              c.LivenessEndpoint,
+		ReadinessEndpoint:             c.ReadinessEndpoint,
+		RetryAttempts:                 c.RetryAttempts,
+		SyncPeriod:                    c.SyncPeriod,
+		TLSCertDir:                    c.TLSCertDir,
+		WebhookTimeoutSeconds:         c.WebhookTimeoutSeconds,
+		WebhookCertMode:               c.WebhookCertMode,
+		WebhookServiceName:            c.WebhookServiceName,
+		WebhookCertSecret:             c.WebhookCertSecret,
+		WebhookConfigName:             c.WebhookConfigName,
+		ConversionWebhook:             c.ConversionWebhook,
+		MemoryScaleUpThreshold:        c.MemoryScaleUpThreshold,
+		MemoryScaleDownThreshold:      c.MemoryScaleDownThreshold,
+		CPUScaleUpThreshold:           c.CPUScaleUpThreshold,
+		CPUScaleDownThreshold:         c.CPUScaleDownThreshold,
+		CPUThrottleThreshold:          c.CPUThrottleThreshold,
+		CPUPressureThreshold:          c.CPUPressureThreshold,
+		MemoryPressureThreshold:       c.MemoryPressureThreshold,
+		BatchSize:                     c.BatchSize,
+		DelayBetweenBatches:           c.DelayBetweenBatches,
+		DelayBetweenPods:              c.DelayBetweenPods,
+		PatchResizePolicy:             c.PatchResizePolicy,
+		PredictionEnabled:             c.PredictionEnabled,
+		PredictionConfidenceThreshold: c.PredictionConfidenceThreshold,
+		PredictionHistoryDays:         c.PredictionHistoryDays,
+		PredictionStorage:             c.PredictionStorage,
+		PredictionStoragePath:         c.PredictionStoragePath,
+		ExternalPredictorURL:          c.ExternalPredictorURL,
+		ExternalPredictorTimeout:      c.ExternalPredictorTimeout,
+		ExternalPredictorToken:        c.ExternalPredictorToken,
+		ClusterID:                     c.ClusterID,
+		ClusterName:                   c.ClusterName,
+		Environment:                   c.Environment,
+		Version:                       c.Version,
+		DashboardEnabled:              c.DashboardEnabled,
+		DashboardURL:                  c.DashboardURL,
+		DashboardAPIToken:             c.DashboardAPIToken,
+		DashboardEnableBatching:       c.DashboardEnableBatching,
+		DashboardBatchSize:            c.DashboardBatchSize,
+		DashboardBatchInterval:        c.DashboardBatchInterval,
+		DashboardEnableHeartbeat:      c.DashboardEnableHeartbeat,
+		DashboardHeartbeatInterval:    c.DashboardHeartbeatInterval,
+		DashboardHeartbeatTimeout:     c.DashboardHeartbeatTimeout,
+		DashboardTimeout:              c.DashboardTimeout,
+		DashboardRetryAttempts:        c.DashboardRetryAttempts,
+		ConfigSource:                  c.ConfigSource,
+		JWTSecret:                     c.JWTSecret,
+		APIAuthMode:                   c.APIAuthMode,
+		APIKey:                        c.APIKey,
+		APIPort:                       c.APIPort,
+		APICacheTTL:                   c.APICacheTTL,
+		APIRateLimit:                  c.APIRateLimit,
+		APIRateBurst:                  c.APIRateBurst,
+		GRPCPort:                      c.GRPCPort,
+		GRPCTLSCert:                   c.GRPCTLSCert,
+		GRPCTLSKey:                    c.GRPCTLSKey,
 	}
 
 	// Deep copy slices
@@ -981,25 +2546,67 @@ func (c *Config) Clone() *Config {
 		clone.SystemNamespaces = make([]string, len(c.SystemNamespaces))
 		copy(clone.SystemNamespaces, c.SystemNamespaces)
 	}
+	if len(c.ExcludedContainers) > 0 {
+		clone.ExcludedContainers = make([]string, len(c.ExcludedContainers))
+		copy(clone.ExcludedContainers, c.ExcludedContainers)
+	}
+	if len(c.NamespaceOverrides) > 0 {
+		clone.NamespaceOverrides = make([]NamespaceOverride, len(c.NamespaceOverrides))
+		for i, override := range c.NamespaceOverrides {
+			override.Namespaces = append([]string(nil), override.Namespaces...)
+			clone.NamespaceOverrides[i] = override
+		}
+	}
+	if len(c.Exclusions) > 0 {
+		clone.Exclusions = make([]WorkloadExclusion, len(c.Exclusions))
+		for i, exclusion := range c.Exclusions {
+			exclusion.OwnerKinds = append([]string(nil), exclusion.OwnerKinds...)
+			clone.Exclusions[i] = exclusion
+		}
+	}
+	if len(c.DecisionFilters) > 0 {
+		clone.DecisionFilters = make([]string, len(c.DecisionFilters))
+		copy(clone.DecisionFilters, c.DecisionFilters)
+	}
+	if len(c.PredictionMethods) > 0 {
+		clone.PredictionMethods = make([]string, len(c.PredictionMethods))
+		copy(clone.PredictionMethods, c.PredictionMethods)
+	}
 	if len(c.CustomMetrics) > 0 {
 		clone.CustomMetrics = make([]string, len(c.CustomMetrics))
 		copy(clone.CustomMetrics, c.CustomMetrics)
 	}
+	if len(c.PrometheusQueries) > 0 {
+		clone.PrometheusQueries = make(map[string]string, len(c.PrometheusQueries))
+		for name, query := range c.PrometheusQueries {
+			clone.PrometheusQueries[name] = query
+		}
+	}
+
+	clone.Reporting.Periods = append([]string(nil), c.Reporting.Periods...)
+	clone.Reporting.Formats = append([]string(nil), c.Reporting.Formats...)
 
 	// Deep copy notification config
 	if c.NotificationConfig != nil {
-		clone.NotificationConfig = &NotificationConfig{
-			EnableNotifications: c.NotificationConfig.EnableNotifications,
-			SlackWebhookURL:     c.NotificationConfig.SlackWebhookURL,
-			SMTPHost:            c.NotificationConfig.SMTPHost,
-			SMTPPort:            c.NotificationConfig.SMTPPort,
-			SMTPUsername:        c.NotificationConfig.SMTPUsername,
-			SMTPPassword:        c.NotificationConfig.SMTPPassword,
-		}
+		notifications := *c.NotificationConfig
+		clone.NotificationConfig = &notifications
 		if len(c.NotificationConfig.EmailRecipients) > 0 {
 			clone.NotificationConfig.EmailRecipients = make([]string, len(c.NotificationConfig.EmailRecipients))
 			copy(clone.NotificationConfig.EmailRecipients, c.NotificationConfig.EmailRecipients)
 		}
+		if len(c.NotificationConfig.Webhooks) > 0 {
+			clone.NotificationConfig.Webhooks = make([]NotificationWebhook, len(c.NotificationConfig.Webhooks))
+			for i, webhook := range c.NotificationConfig.Webhooks {
+				if webhook.Headers != nil {
+					headers := make(map[string]string, len(webhook.Headers))
+					for name, value := range webhook.Headers {
+						headers[name] = value
+					}
+					webhook.Headers = headers
+				}
+				clone.NotificationConfig.Webhooks[i] = webhook
+			}
+		}
 	}
 
 	return clone
@@ -1012,46 +2619,70 @@ func (c *Config) GetSafeValue(getter func(*Config) interface{}) interface{} {
 	return getter(c)
 }
 
-// parseResourceQuantity parses Kubernetes resource quantity strings to int64 values
-func parseResourceQuantity(quantity string, resourceType string) (int64, error) {
-	if quantity == "" {
-		return 0, errors.New("empty quantity string")
+// ParseHistoryWindow parses a history window such as "7d", "2w" or "12h".
+// Day and week suffixes are accepted in addition to time.ParseDuration units.
+func ParseHistoryWindow(window string) (time.Duration, error) {
+	if window == "" {
+		return 0, errors.New("empty history window")
 	}
 
-	// Simple parsing for common cases
-	// For CPU: "10m" -> 10 (millicores), "1" -> 1000 (millicores)
-	// For Memory: "64Mi" -> 64 (MiB), "1Gi" -> 1024 (MiB)
-
-	if resourceType == "cpu" {
-		if len(quantity) > 0 && quantity[len(quantity)-1:] == "m" {
-			// Parse millicores (e.g., "10m" -> 10)
-			return parseIntFromString(quantity[:len(quantity)-1])
+	unit := window[len(window)-1:]
+	if unit == "d" || unit == "w" {
+		val, err := parseIntFromString(window[:len(window)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid history window %q: %w", window, err)
 		}
-		// Assume whole cores, convert to millicores (e.g., "2" -> 2000)
-		if val, err := parseIntFromString(quantity); err == nil {
-			return val * 1000, nil
+		days := time.Duration(val) * 24 * time.Hour
+		if unit == "w" {
+			days *= 7
 		}
+		return days, nil
 	}
 
-	if resourceType == "memory" {
-		if len(quantity) >= 2 {
-			suffix := quantity[len(quantity)-2:]
-			if suffix == "Mi" {
-				// Parse MiB (e.g., "64Mi" -> 64)
-				return parseIntFromString(quantity[:len(quantity)-2])
-			}
-			if suffix == "Gi" {
-				// Parse GiB, convert to MiB (e.g., "1Gi" -> 1024)
-				if val, err := parseIntFromString(quantity[:len(quantity)-2]); err == nil {
-					return val * 1024, nil
-				}
-			}
-		}
-		// Assume MiB if no suffix
-		return parseIntFromString(quantity)
+	return time.ParseDuration(window)
+}
+
+// ParseCPUQuantity parses a Kubernetes CPU quantity, such as "250m", "0.5"
+// or "2", to millicores, rounding fractions of a millicore up
+func ParseCPUQuantity(value string) (int64, error) {
+	quantity, err := parseQuantity(value)
+	if err != nil {
+		return 0, err
 	}
+	return quantity.MilliValue(), nil
+}
 
-	return 0, fmt.Errorf("unknown resource type or format: %s", quantity)
+// ParseMemoryQuantity parses a Kubernetes memory quantity, such as "512Mi",
+// "1500Ki", "1G" or a number of bytes, to MB (MiB), rounding fractions up.
+// Bounds used to be plain numbers of MB, so a unitless value below 1Mi, such
+// as "8192", is rejected rather than read as a few bytes.
+func ParseMemoryQuantity(value string) (int64, error) {
+	quantity, err := parseQuantity(value)
+	if err != nil {
+		return 0, err
+	}
+	const mb = 1024 * 1024
+	if trimmed := strings.TrimSpace(value); !quantity.IsZero() && quantity.Value() < mb && unitless(trimmed) {
+		return 0, fmt.Errorf("memory quantity %q has no unit and is below 1Mi; unitless values are bytes, use %sMi for MiB", value, trimmed)
+	}
+	return (quantity.Value() + mb - 1) / mb, nil
+}
+
+// unitless reports whether a quantity is written without a suffix
+func unitless(value string) bool {
+	return value != "" && value[len(value)-1] >= '0' && value[len(value)-1] <= '9'
+}
+
+// parseQuantity parses a non-negative Kubernetes resource quantity
+func parseQuantity(value string) (resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(strings.TrimSpace(value))
+	if err != nil {
+		return quantity, fmt.Errorf("invalid quantity %q: %w", value, err)
+	}
+	if quantity.Sign() < 0 {
+		return quantity, fmt.Errorf("negative quantity %q", value)
+	}
+	return quantity, nil
 }
 
 // parseIntFromString is a simple integer parser
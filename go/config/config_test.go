@@ -95,10 +95,10 @@ func TestUpdateFromCRD(t *testing.T) {
 		2.0,                                    // memoryLimitMultiplier
 		0,                                      // cpuLimitAddition
 		0,                                      // memoryLimitAddition
-		"10",                                   // minCPURequest
-		"64",                                   // minMemoryRequest
-		"4000",                                 // maxCPULimit
-		"8192",                                 // maxMemoryLimit
+		"0.01",                                 // minCPURequest
+		"65536Ki",                              // minMemoryRequest
+		"4",                                    // maxCPULimit
+		"8Gi",                                  // maxMemoryLimit
 		60*time.Second,                         // resizeInterval
 		true,                                   // dryRun
 		[]string{"default", "production"},      // namespaceInclude
@@ -157,6 +157,14 @@ func TestUpdateFromCRD(t *testing.T) {
 		t.Errorf("Expected CPURequestAddition to be 100, got %d", cfg.CPURequestAddition)
 	}
 
+	if cfg.MinCPURequest != 10 || cfg.MaxCPULimit != 4000 {
+		t.Errorf("Expected CPU bounds of 10m and 4000m, got %d and %d", cfg.MinCPURequest, cfg.MaxCPULimit)
+	}
+
+	if cfg.MinMemoryRequest != 64 || cfg.MaxMemoryLimit != 8192 {
+		t.Errorf("Expected memory bounds of 64MB and 8192MB, got %d and %d", cfg.MinMemoryRequest, cfg.MaxMemoryLimit)
+	}
+
 	if cfg.ResizeInterval != 60*time.Second {
 		t.Errorf("Expected ResizeInterval to be 60s, got %v", cfg.ResizeInterval)
 	}
@@ -542,6 +550,178 @@ func TestGetRetryConfig(t *testing.T) {
 	}
 }
 
+func TestParseHistoryWindow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"7d", 7 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"", 0, true},
+		{"xd", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseHistoryWindow(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseHistoryWindow(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseHistoryWindow(%q) = %v, expected %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestUpdatePercentileSettings(t *testing.T) {
+	cfg := GetDefaults()
+
+	cfg.UpdatePercentileSettings(99, 24*time.Hour)
+	if cfg.Percentile != 99 || cfg.PercentileWindow != 24*time.Hour {
+		t.Errorf("Expected P99 over 24h, got P%d over %v", cfg.Percentile, cfg.PercentileWindow)
+	}
+
+	// Zero values keep the current settings
+	cfg.UpdatePercentileSettings(0, 0)
+	if cfg.Percentile != 99 || cfg.PercentileWindow != 24*time.Hour {
+		t.Errorf("Expected settings to be unchanged, got P%d over %v", cfg.Percentile, cfg.PercentileWindow)
+	}
+}
+
+func TestUsageAggregation(t *testing.T) {
+	cfg := GetDefaults()
+	if got := cfg.UsageAggregation("cpu"); got != AggregationPercentile {
+		t.Errorf("Expected CPU to follow the percentile algorithm, got %s", got)
+	}
+	if got := cfg.UsageAggregation("memory"); got != AggregationMax {
+		t.Errorf("Expected memory to be sized to its peak, got %s", got)
+	}
+
+	cfg.SetUsageAggregation(AggregationAverage, "")
+	cfg.Algorithm = "average"
+	if got := cfg.UsageAggregation("cpu"); got != AggregationAverage {
+		t.Errorf("Expected average CPU, got %s", got)
+	}
+	if got := cfg.UsageAggregation("memory"); got != AggregationAverage {
+		t.Errorf("Expected unset memory aggregation to follow the algorithm, got %s", got)
+	}
+
+	// Unknown methods keep the current setting
+	cfg.SetUsageAggregation("median", AggregationLatest)
+	if cfg.CPUAggregation != AggregationAverage || cfg.MemoryAggregation != AggregationLatest {
+		t.Errorf("Expected average CPU and latest memory, got %s and %s", cfg.CPUAggregation, cfg.MemoryAggregation)
+	}
+}
+
+// TestSetPeakLimits verifies invalid values keep the current settings
+func TestSetPeakLimits(t *testing.T) {
+	cfg := GetDefaults()
+	cfg.SetPeakLimits(0, -1, 30*time.Minute, 24*time.Hour)
+	if cfg.LimitPercentile != 99.9 || cfg.LimitHeadroomPercent != 10 {
+		t.Errorf("expected the default P99.9 and 10%% headroom, got P%g and %d%%", cfg.LimitPercentile, cfg.LimitHeadroomPercent)
+	}
+	if cfg.LimitWindow("cpu") != 30*time.Minute || cfg.LimitWindow("memory") != 24*time.Hour {
+		t.Errorf("unexpected limit windows %v and %v", cfg.LimitWindow("cpu"), cfg.LimitWindow("memory"))
+	}
+}
+
+func TestSetCircuitBreakerConfig(t *testing.T) {
+	cfg := GetDefaults()
+
+	cfg.SetCircuitBreakerConfig(CircuitBreakerConfig{Enabled: true, Scope: "node", FailureThreshold: 2})
+	breaker := cfg.CircuitBreaker
+	if breaker.Scope != CircuitBreakerScopeNode || breaker.FailureThreshold != 2 {
+		t.Errorf("Expected node scope with threshold 2, got %s with %d", breaker.Scope, breaker.FailureThreshold)
+	}
+	// Unset values keep the defaults
+	if breaker.RecoveryTimeout != 30*time.Second || breaker.SuccessThreshold != 3 {
+		t.Errorf("Expected default recovery, got %v and %d", breaker.RecoveryTimeout, breaker.SuccessThreshold)
+	}
+
+	cfg.SetCircuitBreakerConfig(CircuitBreakerConfig{Enabled: true, Scope: "pod"})
+	if cfg.CircuitBreaker.Scope != CircuitBreakerScopeNamespace {
+		t.Errorf("Expected unknown scope to fall back to namespace, got %s", cfg.CircuitBreaker.Scope)
+	}
+}
+
+func TestExcludedContainers(t *testing.T) {
+	cfg := GetDefaults()
+	if !cfg.IsContainerExcluded("istio-proxy") || !cfg.IsContainerExcluded("linkerd-proxy") || cfg.IsContainerExcluded("app") {
+		t.Errorf("Expected the mesh proxies to be excluded by default, got %v", cfg.ExcludedContainers)
+	}
+
+	cfg.SetExcludedContainers([]string{"fluent-bit", "*-exporter", "[bad"})
+	if len(cfg.ExcludedContainers) != 2 {
+		t.Errorf("Expected the invalid pattern to be dropped, got %v", cfg.ExcludedContainers)
+	}
+	if !cfg.IsContainerExcluded("redis-exporter") || !cfg.IsContainerExcluded("fluent-bit") || cfg.IsContainerExcluded("istio-proxy") {
+		t.Errorf("Expected the configured patterns to replace the defaults, got %v", cfg.ExcludedContainers)
+	}
+	if !cfg.ForNamespace("shop").IsContainerExcluded("fluent-bit") {
+		t.Error("Expected namespace configurations to keep the exclusions")
+	}
+
+	cfg.SetExcludedContainers([]string{})
+	if cfg.IsContainerExcluded("istio-proxy") {
+		t.Error("Expected an empty list to resize every container")
+	}
+}
+
+func TestParseResourceQuantities(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		cpu   bool
+		want  int64
+	}{
+		{"250m", true, 250},
+		{"0.5", true, 500},
+		{"2", true, 2000},
+		{"1.5m", true, 2},
+		{"512Mi", false, 512},
+		{"1Gi", false, 1024},
+		{"1500Ki", false, 2},
+		{"1G", false, 954},
+		{"1048576", false, 1},
+		{"0", false, 0},
+	} {
+		parse := ParseMemoryQuantity
+		if tc.cpu {
+			parse = ParseCPUQuantity
+		}
+		got, err := parse(tc.value)
+		if err != nil || got != tc.want {
+			t.Errorf("Expected %q to parse to %d, got %d (%v)", tc.value, tc.want, got, err)
+		}
+	}
+	// Unitless values below 1Mi were MB before bounds became quantities
+	for _, value := range []string{"", "lots", "-1", "10Mb", "8192", "64"} {
+		if _, err := ParseMemoryQuantity(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	cfg := GetDefaults()
+	var first, second int
+	unsubscribe := cfg.Subscribe(func(changed *Config) {
+		if changed != cfg {
+			t.Error("Expected subscribers to be called with the changed configuration")
+		}
+		first++
+	})
+	cfg.Subscribe(func(*Config) { second++ })
+
+	cfg.NotifyChanged()
+	unsubscribe()
+	cfg.NotifyChanged()
+	if first != 1 || second != 2 {
+		t.Errorf("Expected 1 and 2 notifications, got %d and %d", first, second)
+	}
+}
+
 func TestGetSafeValue(t *testing.T) {
 	cfg := &Config{
 		CPURequestMultiplier: 1.5,
@@ -666,3 +846,35 @@ func TestThreadSafety(t *testing.T) {
 	// If we get here without deadlock or panic, thread safety is working
 	t.Log("Thread safety test completed successfully")
 }
+
+func TestForNamespaceAppliesOverrides(t *testing.T) {
+	cfg := GetDefaults()
+	cfg.PredictionConfidenceThreshold = 0.75
+	cfg.SetNamespaceOverrides([]NamespaceOverride{
+		{Namespaces: []string{"batch"}, CPU: ResourceOverride{RequestMultiplier: 1.05, ScaleDownThreshold: 0.1}, Memory: ResourceOverride{MaxLimit: 16384}},
+		{Namespaces: []string{"batch", "web"}, CPU: ResourceOverride{RequestMultiplier: 2}},
+	})
+
+	if got := cfg.ForNamespace("default"); got != cfg {
+		t.Error("expected a namespace without overrides to use the global configuration")
+	}
+
+	batch := cfg.ForNamespace("batch")
+	if batch.CPURequestMultiplier != 1.05 || batch.CPUScaleDownThreshold != 0.1 || batch.MaxMemoryLimit != 16384 {
+		t.Errorf("expected the first override to apply, got multiplier %v, scale down %v, max memory %d",
+			batch.CPURequestMultiplier, batch.CPUScaleDownThreshold, batch.MaxMemoryLimit)
+	}
+	if batch.CPUScaleUpThreshold != cfg.CPUScaleUpThreshold || batch.MemoryRequestMultiplier != cfg.MemoryRequestMultiplier {
+		t.Error("expected settings the override leaves unset to keep the global values")
+	}
+	if batch.PredictionConfidenceThreshold != 0.75 {
+		t.Errorf("expected unrelated settings to be kept, got confidence threshold %v", batch.PredictionConfidenceThreshold)
+	}
+	if cfg.CPURequestMultiplier == 1.05 {
+		t.Error("expected the global configuration to be left unchanged")
+	}
+
+	if web := cfg.ForNamespace("web"); web.CPURequestMultiplier != 2 {
+		t.Errorf("expected the override for web to apply, got multiplier %v", web.CPURequestMultiplier)
+	}
+}
@@ -17,27 +17,42 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"right-sizer/api/v1alpha1"
 	"right-sizer/audit"
 	"right-sizer/config"
+	"right-sizer/cost"
 	dashboardapi "right-sizer/dashboard-api"
+	"right-sizer/efficiency"
+	"right-sizer/events"
+	"right-sizer/explain"
+	"right-sizer/idle"
 	"right-sizer/logger"
 	"right-sizer/metrics"
+	"right-sizer/pause"
 	"right-sizer/predictor"
+	"right-sizer/retry"
+	"right-sizer/validation"
+	"right-sizer/workload"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -50,6 +65,25 @@ type ResizeDecisionCache struct {
 	OldMemory    string
 	NewMemory    string
 	LastSeen     time.Time
+	LastResized  time.Time // When the container was last resized, for the cooldown
+	CPULowSince  time.Time // Since when CPU usage has stayed below the scale-down threshold
+	MemLowSince  time.Time // Since when memory usage has stayed below the scale-down threshold
+	UsageSeen    time.Time // When the container's usage was last checked
+}
+
+// memoryRestartAnnotation opts a pod into memory decreases that restart the container
+const memoryRestartAnnotation = "rightsizer.io/allow-memory-restart"
+
+// cooldownAnnotation overrides the resize cooldown for a pod's containers (e.g. "30m")
+const cooldownAnnotation = "rightsizer.io/cooldown"
+
+// maxCooldownRetention is how long resize times are kept for cooldown checks
+const maxCooldownRetention = 24 * time.Hour
+
+// AnomalyGate holds back resizes of containers with an active usage anomaly
+type AnomalyGate interface {
+	// SuppressResize returns why a container must not be resized right now
+	SuppressResize(namespace, pod, container string) (string, bool)
 }
 
 // ScalingDecision represents the scaling action to take
@@ -76,18 +110,53 @@ type AdaptiveRightSizer struct {
 	MetricsProvider metrics.Provider
 	OperatorMetrics *metrics.OperatorMetrics // Prometheus metrics recorder
 	AuditLogger     *audit.AuditLogger
-	Config          *config.Config    // Configuration with feature flags
-	Predictor       *predictor.Engine // Resource prediction engine
+	EventRecorder   record.EventRecorder // Records resize events on pods
+	Config          *config.Config       // Configuration with feature flags
+	Predictor       *predictor.Engine    // Resource prediction engine
 	Interval        time.Duration
-	InPlaceEnabled  bool       // Will be auto-detected
 	DryRun          bool       // If true, only log recommendations without applying
 	updateMutex     sync.Mutex // Prevents concurrent update operations
 	isRunning       bool       // Tracks if a rightsizing operation is in progress
 	runningMutex    sync.Mutex // Protects the isRunning flag
 	resizeCache     map[string]*ResizeDecisionCache
 	cacheMutex      sync.RWMutex
-	cacheExpiry     time.Duration        // How long to keep cache entries
-	DashboardClient *dashboardapi.Client // Dashboard API client for events and metrics
+	cacheExpiry     time.Duration                  // How long to keep cache entries
+	DashboardClient *dashboardapi.Client           // Dashboard API client for events and metrics
+	EventBus        *events.EventBus               // Streams resize decisions to API clients
+	Recommendations *RecommendationWriter          // Publishes decisions in recommendation-only mode
+	Exporter        *GitOpsExporter                // Renders decisions as patches in export mode
+	Maintenance     *MaintenanceScheduler          // Queues resizes until policy maintenance windows open
+	Validator       *validation.ResourceValidator  // Clamps decisions to namespace LimitRanges and quotas
+	Anomalies       AnomalyGate                    // Pauses resizes while a usage anomaly lasts
+	Jobs            *JobSizer                      // Sizes Job and CronJob pods from their past runs
+	Explanations    *explain.Store                 // Latest decision explanation of every container
+	Pauses          *pause.State                   // Pauses of the cluster and namespaces
+	RetryHandler    *retry.RetryWithCircuitBreaker // Retries resize patches and stops them while the API server fails
+	Safety          *SafetyTuner                   // Widens the headroom of workloads whose resizes went wrong
+	CustomMetrics   metrics.CustomMetricsSource    // Application metrics that policies size from
+	Efficiency      *efficiency.Tracker            // Requested against used resources of every container
+	Idle            *idle.Detector                 // Flags workloads whose usage stays near zero
+	Canaries        *CanaryRollouts                // Resizes one replica of large Deployments before the others
+	// groupedResizeUnsupported is set once the API server reports combined CPU and memory patches as unsupported
+	groupedResizeUnsupported atomic.Bool
+	// inPlaceMissing is set while the cluster cannot resize pods in place
+	inPlaceMissing atomic.Bool
+	// memoryQoS is set while the cluster enables cgroup v2 memory QoS
+	memoryQoS atomic.Bool
+	// initPeaks holds the peak usage of init containers for recommendation-only mode
+	initPeaks initContainerPeaks
+
+	// analysisCursor is the last pod analyzed when a cycle was capped by
+	// MaxPodsPerCycle; the next cycle resumes after it
+	analysisCursor string
+	// disruptions charges the restarts of the current run to PodDisruptionBudgets
+	disruptions *disruptionBudget
+	// changes limits the share of managed pods resized within the change budget window
+	changes changeBudget
+	// pacing adapts the delays between resizes to the API server
+	pacing applyPacer
+	// schedule keeps when the global and per-policy resize intervals are due
+	schedule resizeSchedule
 	// Metrics for dashboard heartbeat
 	totalPods            int
 	managedPods          int
@@ -104,9 +173,15 @@ type ResourceUpdate struct {
 	ResourceType   string // Pod only now
 	ContainerName  string
 	ContainerIndex int
+	InitContainer  bool // ContainerIndex indexes spec.initContainers: a native sidecar or an init container
 	OldResources   corev1.ResourceRequirements
 	NewResources   corev1.ResourceRequirements
+	Usage          metrics.Metrics // Usage the container was sized from, when known
 	Reason         string
+	QoSMode        string              // How the resize treats the pod's QoS class; the global mode when empty
+	Explanation    *explain.Decision   // How the decision was reached, when explanations are kept
+	VPATarget      corev1.ResourceList // Target of the workload's VerticalPodAutoscaler, in VPA compare mode
+	DecisionID     string              // Identifies an applied resize on the pod and on its events
 }
 
 // shouldLogResizeDecision checks if we should log this resize decision based on cache
@@ -142,6 +217,12 @@ func (r *AdaptiveRightSizer) cacheResizeDecision(containerKey, oldCPU, newCPU, o
 	r.cacheMutex.Lock()
 	defer r.cacheMutex.Unlock()
 
+	if cached, ok := r.resizeCache[containerKey]; ok {
+		cached.OldCPU, cached.NewCPU = oldCPU, newCPU
+		cached.OldMemory, cached.NewMemory = oldMemory, newMemory
+		cached.LastSeen = time.Now()
+		return
+	}
 	r.resizeCache[containerKey] = &ResizeDecisionCache{
 		ContainerKey: containerKey,
 		OldCPU:       oldCPU,
@@ -152,44 +233,214 @@ func (r *AdaptiveRightSizer) cacheResizeDecision(containerKey, oldCPU, newCPU, o
 	}
 }
 
+// recordResize marks a container as just resized, starting its cooldown
+func (r *AdaptiveRightSizer) recordResize(namespace, podName, containerName string) {
+	containerKey := fmt.Sprintf("%s/%s/%s", namespace, podName, containerName)
+
+	r.cacheMutex.Lock()
+	defer r.cacheMutex.Unlock()
+
+	now := time.Now()
+	if cached, ok := r.resizeCache[containerKey]; ok {
+		cached.LastResized = now
+		// Usage is measured against the new resources from here on
+		cached.CPULowSince, cached.MemLowSince = time.Time{}, time.Time{}
+		return
+	}
+	r.resizeCache[containerKey] = &ResizeDecisionCache{ContainerKey: containerKey, LastSeen: now, LastResized: now}
+}
+
+// resizeCooldown returns the cooldown for a pod's containers: the
+// rightsizer.io/cooldown annotation if valid, otherwise the configured default
+func resizeCooldown(pod *corev1.Pod, defaultCooldown time.Duration) time.Duration {
+	if pod == nil {
+		return defaultCooldown
+	}
+	value, ok := pod.Annotations[cooldownAnnotation]
+	if !ok {
+		return defaultCooldown
+	}
+	cooldown, err := time.ParseDuration(value)
+	if err != nil || cooldown < 0 {
+		logger.Warn("Ignoring invalid %s annotation %q on pod %s/%s", cooldownAnnotation, value, pod.Namespace, pod.Name)
+		return defaultCooldown
+	}
+	return cooldown
+}
+
+// filterCoolingDown drops updates for containers resized less than their cooldown ago
+func (r *AdaptiveRightSizer) filterCoolingDown(updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+	defaultCooldown := config.Get().ResizeCooldown
+
+	r.cacheMutex.RLock()
+	defer r.cacheMutex.RUnlock()
+
+	now := time.Now()
+	result := updates[:0:0]
+	for _, update := range updates {
+		containerKey := fmt.Sprintf("%s/%s/%s", update.Namespace, update.Name, update.ContainerName)
+		cached, ok := r.resizeCache[containerKey]
+		if ok && !cached.LastResized.IsZero() {
+			cooldown := resizeCooldown(podsByName[update.Namespace+"/"+update.Name], defaultCooldown)
+			if remaining := cooldown - now.Sub(cached.LastResized); remaining > 0 {
+				logger.Debug("Suppressing resize of %s: in cooldown for another %v", containerKey, remaining.Round(time.Second))
+				if r.OperatorMetrics != nil {
+					r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "cooldown")
+				}
+				continue
+			}
+		}
+		result = append(result, update)
+	}
+	return result
+}
+
+// filterAnomalous drops updates for containers with an active usage anomaly
+func (r *AdaptiveRightSizer) filterAnomalous(updates []ResourceUpdate) []ResourceUpdate {
+	if r.Anomalies == nil || len(updates) == 0 {
+		return updates
+	}
+
+	result := updates[:0:0]
+	for _, update := range updates {
+		if reason, suppress := r.Anomalies.SuppressResize(update.Namespace, update.Name, update.ContainerName); suppress {
+			logger.Info("⏸️  Suppressing resize of %s/%s/%s during usage anomaly: %s", update.Namespace, update.Name, update.ContainerName, reason)
+			if r.OperatorMetrics != nil {
+				r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "anomaly")
+			}
+			continue
+		}
+		result = append(result, update)
+	}
+	return result
+}
+
+// clampToNamespaceConstraints adjusts updates to fit their namespace's
+// LimitRanges and ResourceQuotas, dropping any that no longer change anything
+func (r *AdaptiveRightSizer) clampToNamespaceConstraints(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	result := updates[:0:0]
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok {
+			result = append(result, update)
+			continue
+		}
+
+		clamped, notes := r.Validator.ClampToNamespaceConstraints(ctx, pod, update.ContainerName, update.NewResources)
+		if len(notes) == 0 {
+			result = append(result, update)
+			continue
+		}
+
+		logger.Info("Clamped resize of %s/%s/%s: %s", update.Namespace, update.Name, update.ContainerName, strings.Join(notes, "; "))
+		if resourcesEqual(clamped, update.OldResources) {
+			continue
+		}
+		update.NewResources = clamped
+		update.Reason += " (clamped to namespace constraints)"
+		update.Explanation.AddStep("namespace-constraints", strings.Join(notes, "; "), clamped)
+		result = append(result, update)
+	}
+	return result
+}
+
 // cleanExpiredCacheEntries removes expired cache entries
 func (r *AdaptiveRightSizer) cleanExpiredCacheEntries() {
 	r.cacheMutex.Lock()
 	defer r.cacheMutex.Unlock()
 
 	now := time.Now()
+	usageExpiry := max(r.cacheExpiry, 2*r.Interval)
 	for key, cached := range r.resizeCache {
-		if now.Sub(cached.LastSeen) > r.cacheExpiry {
+		// Keep recently resized containers so their cooldown still applies, and
+		// analyzed ones so their low usage keeps counting towards a scale down
+		if now.Sub(cached.LastSeen) > r.cacheExpiry && now.Sub(cached.UsageSeen) > usageExpiry && now.Sub(cached.LastResized) > maxCooldownRetention {
 			delete(r.resizeCache, key)
 		}
 	}
 }
 
+// reloadConfig applies a changed configuration to the settings the loop
+// keeps of its own: the resize interval, the dry-run mode and the settings
+// of the prediction engine
+func (r *AdaptiveRightSizer) reloadConfig(cfg *config.Config) {
+	if cfg.ResizeInterval > 0 && cfg.ResizeInterval != r.Interval {
+		logger.Info("Resize interval changed from %v to %v", r.Interval, cfg.ResizeInterval)
+		r.schedule.replace(r.Interval, cfg.ResizeInterval, time.Now())
+		r.Interval = cfg.ResizeInterval
+	}
+	if cfg.DryRun != r.DryRun {
+		logger.Info("Dry-run mode changed to %v", cfg.DryRun)
+		r.DryRun = cfg.DryRun
+	}
+	if r.Predictor != nil {
+		r.Predictor.Reconfigure(predictionRetention(cfg), cfg.ResizeInterval, cfg.PredictionConfidenceThreshold)
+	}
+}
+
+// predictionRetention is how long the prediction engine keeps history: its
+// default retention, or the percentile or a limit window when longer
+func predictionRetention(cfg *config.Config) time.Duration {
+	return max(predictor.DefaultConfig().HistoricalDataRetention, cfg.PercentileWindow, cfg.CPULimitWindow, cfg.MemoryLimitWindow)
+}
+
 // Start begins the adaptive rightsizing loop
 func (r *AdaptiveRightSizer) Start(ctx context.Context) error {
-	ticker := time.NewTicker(r.Interval)
-	defer ticker.Stop()
-
 	// Test for in-place resize capability
-	r.InPlaceEnabled = r.testInPlaceCapability(ctx)
+	inPlace := r.testInPlaceCapability(ctx)
+	r.inPlaceMissing.Store(!inPlace)
 
-	if r.InPlaceEnabled {
+	if inPlace {
 		logger.Info("✅ In-place pod resizing is available - pods can be resized without restarts")
 	} else {
-		logger.Warn("⚠️  In-place pod resizing not available - will use rolling updates")
+		logger.Warn("⚠️  In-place pod resizing not available - will publish recommendations instead")
 	}
 
 	logger.Info("Starting adaptive right-sizer with %v interval (DryRun: %v)", r.Interval, r.DryRun)
 
-	// Run immediately on start
+	// Follow configuration changes between runs
+	cfg := r.Config
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	reload := make(chan struct{}, 1)
+	unsubscribe := cfg.Subscribe(func(*config.Config) {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	// Run immediately on start, then whenever the global interval or the
+	// resize interval of a policy comes due
 	r.performRightSizing(ctx)
+	timer := time.NewTimer(r.schedule.wait(time.Now(), r.Interval))
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
 			r.performRightSizing(ctx)
 			// Clean expired cache entries periodically
 			r.cleanExpiredCacheEntries()
+			timer.Reset(r.schedule.wait(time.Now(), r.Interval))
+		case <-reload:
+			r.reloadConfig(cfg)
+			timer.Reset(r.schedule.wait(time.Now(), r.Interval))
 		case <-ctx.Done():
 			log.Println("Stopping adaptive right-sizer")
 			return nil
@@ -197,6 +448,50 @@ func (r *AdaptiveRightSizer) Start(ctx context.Context) error {
 	}
 }
 
+// InPlaceEnabled reports whether pods are resized in place, rather than
+// sized through recommendations while the cluster lacks pods/resize
+func (r *AdaptiveRightSizer) InPlaceEnabled() bool {
+	return !r.inPlaceMissing.Load()
+}
+
+// SetInPlaceEnabled switches between resizing pods in place and publishing
+// recommendations, e.g. when capability re-detection finds pods/resize
+// after a cluster upgrade
+func (r *AdaptiveRightSizer) SetInPlaceEnabled(enabled bool) {
+	if r.inPlaceMissing.Swap(!enabled) == !enabled {
+		return
+	}
+	if enabled {
+		logger.Info("✅ In-place pod resizing became available - resuming resizes")
+	} else {
+		logger.Warn("⚠️  In-place pod resizing became unavailable - publishing recommendations instead")
+	}
+}
+
+// ResetGroupedResize allows combined CPU and memory patches again after the
+// cluster rejected one, e.g. once capability re-detection has run
+func (r *AdaptiveRightSizer) ResetGroupedResize() {
+	r.groupedResizeUnsupported.Store(false)
+}
+
+// groupedResizeNotSupported reports whether a combined resize patch failed
+// because the cluster does not support it, rather than because of the pod.
+// A 404 naming the pod means the pod is gone, not that pods/resize is missing.
+func groupedResizeNotSupported(err error) bool {
+	if k8serrors.IsMethodNotSupported(err) {
+		return true
+	}
+	if !k8serrors.IsNotFound(err) {
+		return false
+	}
+	var status k8serrors.APIStatus
+	if !errors.As(err, &status) {
+		return false
+	}
+	details := status.Status().Details
+	return details == nil || details.Name == ""
+}
+
 // testInPlaceCapability checks if in-place resize is supported
 func (r *AdaptiveRightSizer) testInPlaceCapability(ctx context.Context) bool {
 	// Check if the resize subresource is available by checking server version
@@ -268,6 +563,11 @@ func (r *AdaptiveRightSizer) performRightSizing(ctx context.Context) {
 		r.isRunning = false
 		r.runningMutex.Unlock()
 
+		// Keep the safety margins learned this run
+		if r.Safety != nil {
+			r.Safety.Flush(ctx)
+		}
+
 		// Log summary of the rightsizing run
 		duration := time.Since(startTime)
 		log.Printf("✅ Rightsizing run completed in %v", duration)
@@ -286,119 +586,369 @@ func (r *AdaptiveRightSizer) performRightSizing(ctx context.Context) {
 
 	updates := []ResourceUpdate{}
 
-	// Get total pods count
+	// Pods are read from the manager's shared informer cache, once per cycle
 	var podList corev1.PodList
-	if err := r.Client.List(ctx, &podList); err == nil {
-		r.metricsMutex.Lock()
-		r.totalPods = len(podList.Items)
-		r.metricsMutex.Unlock()
+	if err := r.Client.List(ctx, &podList); err != nil {
+		log.Printf("Error listing pods: %v", err)
+		return
+	}
+	r.metricsMutex.Lock()
+	r.totalPods = len(podList.Items)
+	r.metricsMutex.Unlock()
+
+	// Pick up namespaces paused or resumed by annotation since the last run
+	r.syncAnnotatedPauses(ctx)
+
+	// Blame restarts that followed recent resizes on them
+	if r.Safety != nil {
+		r.Safety.ObserveRestarts(ctx, podList.Items, config.Get())
 	}
 
 	// Analyze ALL pods directly (including those from deployments, statefulsets, etc)
 	// We will update pods directly using in-place resize, not their controllers
-	pods, err := r.analyzeAllPods(ctx)
-	if err != nil {
-		log.Printf("Error analyzing pods: %v", err)
-	} else {
-		updates = append(updates, pods...)
+	cycleStart := time.Now()
+	analyzedUpdates, analyzed := r.analyzeAllPods(ctx, r.duePods(ctx, podList.Items, r.profilePolicies(ctx), cycleStart))
+	updates = append(updates, analyzedUpdates...)
+
+	// Publish how much of their requests containers used over the window
+	if r.Efficiency != nil && r.OperatorMetrics != nil {
+		r.OperatorMetrics.SetEfficiencyScores(r.Efficiency.Scores(time.Now(), config.Get().EfficiencyWindow))
 	}
 
-	// Apply updates using in-place resize
-	r.applyUpdates(ctx, updates)
-}
+	// Flag workloads that have used next to nothing for a long time
+	if r.Idle != nil {
+		r.reportIdleWorkloads(time.Now(), config.Get().Idle)
+	}
 
-// analyzeAllPods analyzes all pods in the cluster for resource optimization
-func (r *AdaptiveRightSizer) analyzeAllPods(ctx context.Context) ([]ResourceUpdate, error) {
-	var podList corev1.PodList
-	if err := r.Client.List(ctx, &podList); err != nil {
-		return nil, err
+	// Size Jobs and CronJobs from the runs that finished. Job templates are
+	// only patched when the operator is allowed to change workloads.
+	if cfg := config.Get(); r.Jobs != nil && cfg.JobMode != JobModeResize {
+		patch := cfg.JobMode == JobModePatch && !cfg.RecommendationOnly && !cfg.Export.Enabled && !r.DryRun && !r.clusterPaused()
+		r.Jobs.Sync(ctx, cycleStart, patch, cfg, r.calculateOptimalResources)
 	}
 
-	updates := []ResourceUpdate{}
+	// Size replicas of the same workload together so they do not drift apart
+	if cfg := config.Get(); cfg.WorkloadAggregation != "none" {
+		aggregator := &WorkloadAggregator{Client: r.Client, Mode: cfg.WorkloadAggregation, Percentile: cfg.Percentile, NodeClassLabel: cfg.DaemonSetNodeClassLabel, Canaries: r.Canaries, Canary: cfg.Canary, Analyzed: analyzed}
+		updates = aggregator.Aggregate(ctx, updates, podList.Items)
+	}
 
-	// Limit the number of pods to process in a single cycle to prevent overload
-	const maxPodsPerCycle = 50
-	podsProcessed := 0
+	// Give workloads whose resizes went wrong more headroom
+	if r.Safety != nil {
+		updates = r.Safety.Widen(ctx, updates, podList.Items, config.Get())
+	}
 
-	for _, pod := range podList.Items {
-		// Limit pods processed per cycle
-		if podsProcessed >= maxPodsPerCycle {
-			log.Printf("📊 Reached maximum pods per cycle (%d), will process remaining pods in next cycle", maxPodsPerCycle)
-			break
-		}
-		// Skip pods that are not running
-		if pod.Status.Phase != corev1.PodRunning {
-			continue
-		}
+	// Keep resizes of the operator's own pods small and above its floors
+	updates = r.guardSelfSizing(updates, podList.Items, config.Get().SelfSizing)
 
-		// Skip pods that are being deleted (terminating)
-		if !pod.DeletionTimestamp.IsZero() {
-			log.Printf("⏭️  Skipping terminating pod %s/%s", pod.Namespace, pod.Name)
-			continue
-		}
+	// Fit decisions within namespace LimitRanges and ResourceQuotas
+	if r.Validator != nil {
+		updates = r.clampToNamespaceConstraints(ctx, updates, podList.Items)
+	}
 
-		// Check namespace filters first
-		if !r.shouldProcessNamespace(pod.Namespace) {
-			continue
+	// Leave paused namespaces and workloads alone
+	updates = r.filterPaused(ctx, updates, podList.Items)
+
+	// Leave workloads a VerticalPodAutoscaler manages to it, or only compare with it
+	updates = r.filterVPAManaged(ctx, updates, podList.Items, config.Get())
+
+	// Let the configured decision filters veto or adjust the decisions
+	updates = r.filterDecisions(ctx, updates, podList.Items, config.Get())
+
+	// Publish the decisions as VerticalPodAutoscalers for VPA-based tooling
+	if cfg := config.Get(); cfg.Export.VerticalPodAutoscalers && len(updates) > 0 {
+		exporter := &VPAExporter{Client: r.Client}
+		if err := exporter.Export(ctx, updates, podList.Items); err != nil {
+			logger.Warn("Error exporting VerticalPodAutoscalers: %v", err)
 		}
+	}
 
-		// Self-protection: Skip if this is the right-sizer pod itself
-		if r.isSelfPod(&pod) {
-			log.Printf("🛡️  Skipping self-pod %s/%s to prevent self-modification", pod.Namespace, pod.Name)
-			continue
+	// In recommendation-only mode publish the decisions for review instead of
+	// resizing. They are also kept when the mutating webhook sizes new pods from
+	// them, or carry replica count suggestions.
+	if cfg := config.Get(); (cfg.RecommendationOnly || cfg.MutatingWebhook || cfg.HorizontalAdvice.Enabled) && r.Recommendations != nil {
+		if len(updates) > 0 {
+			if err := r.Recommendations.Write(ctx, updates, cfg); err != nil {
+				log.Printf("Error writing recommendations: %v", err)
+			}
 		}
-		if r.isSystemWorkload(pod.Namespace, pod.Name) {
-			continue
+		if cfg.RecommendationOnly {
+			r.recordExplanations(updates)
+			return
 		}
+	}
 
-		// Skip pods with skip annotation
-		if pod.Annotations != nil {
-			if skip, ok := pod.Annotations["rightsizer.io/skip"]; ok && skip == "true" {
-				continue
+	// In export mode hand the decisions to the GitOps pipeline instead of resizing
+	if cfg := config.Get(); cfg.Export.Enabled && r.Exporter != nil {
+		if len(updates) > 0 {
+			if err := r.Exporter.Export(ctx, updates, cfg.Export); err != nil {
+				log.Printf("Error exporting patches: %v", err)
 			}
 		}
+		r.recordExplanations(updates)
+		return
+	}
 
-		// Skip pods that have no resource specifications at all
-		hasAnyResources := false
-		for _, container := range pod.Spec.Containers {
-			if len(container.Resources.Requests) > 0 {
-				hasAnyResources = true
-				break
+	// Without in-place resize the decisions can only be recommended
+	if !r.InPlaceEnabled() {
+		if cfg := config.Get(); !cfg.MutatingWebhook && !cfg.HorizontalAdvice.Enabled && r.Recommendations != nil && len(updates) > 0 {
+			if err := r.Recommendations.Write(ctx, updates, cfg); err != nil {
+				log.Printf("Error writing recommendations: %v", err)
 			}
-			if len(container.Resources.Limits) > 0 {
-				hasAnyResources = true
-				break
+		}
+		r.recordExplanations(updates)
+		return
+	}
+
+	// Windows containers cannot be resized in place: recommend their resizes,
+	// or replace the pods that opt in to restarts
+	updates = r.routeWindowsPods(ctx, updates, podList.Items)
+
+	// Hold back resizes outside the maintenance windows of matching policies
+	if r.Maintenance != nil {
+		updates = r.Maintenance.Filter(ctx, updates, podList.Items)
+	}
+
+	// Do not resize containers again within their cooldown
+	updates = r.filterCoolingDown(updates, podList.Items)
+
+	// Do not chase a leak or restart storm with resizes
+	updates = r.filterAnomalous(updates)
+
+	// Cap or defer upsizes that do not fit on their nodes
+	planner := &NodeCapacityPlanner{Client: r.Client, EventRecorder: r.EventRecorder, Metrics: r.OperatorMetrics, Strategy: config.Get().NodeCapacityStrategy}
+	updates = planner.Plan(ctx, updates, podList.Items)
+
+	// Keep downsizes off nodes autoscalers are removing and publish the node
+	// utilization the resizes leave behind
+	coordinator := &AutoscalerCoordinator{Client: r.Client, EventRecorder: r.EventRecorder, Metrics: r.OperatorMetrics, Config: config.Get().Autoscaler, DryRun: r.DryRun}
+	updates = coordinator.Coordinate(ctx, updates, podList.Items)
+
+	// Resize StatefulSet pods one ordinal at a time, waiting for every replica
+	// to be ready between ordinals
+	sequencer := &StatefulSetSequencer{Client: r.Client, Metrics: r.OperatorMetrics}
+	updates = sequencer.Sequence(ctx, updates, podList.Items)
+
+	// Resize the operator's standby replicas before the leader's own pod
+	if config.Get().SelfSizing.Enabled {
+		updates = r.holdLeaderResize(updates, podList.Items)
+	}
+
+	// Apply updates using in-place resize
+	r.recordExplanations(updates)
+	r.applyUpdates(ctx, updates, podList.Items)
+}
+
+// analyzeAllPods analyzes all pods in the cluster for resource optimization
+func (r *AdaptiveRightSizer) analyzeAllPods(ctx context.Context, pods []corev1.Pod) ([]ResourceUpdate, map[string]bool) {
+	updates := []ResourceUpdate{}
+	analyzed := make(map[string]bool)
+
+	// Usage is fetched for all pods at once when the provider allows it
+	provider := r.cycleMetrics(ctx)
+
+	// Policies are listed once per cycle to select sizing profiles
+	profilePolicies := r.profilePolicies(ctx)
+
+	cfg := config.Get()
+	pods = r.nextAnalysisBatch(pods, cfg.MaxPodsPerCycle)
+	exclusions := exclusionRules(cfg.Exclusions)
+
+	// Analyze pods concurrently; updates keep the order of the pods
+	results := make([][]ResourceUpdate, len(pods))
+	sized := make([]bool, len(pods))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(cfg.MaxAnalysisWorkers, 1), len(pods)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i], sized[i] = r.analyzePod(ctx, pods[i], provider, profilePolicies, exclusions, true)
 			}
+		}()
+	}
+	for i := range pods {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, result := range results {
+		updates = append(updates, result...)
+		if sized[i] {
+			analyzed[pods[i].Namespace+"/"+pods[i].Name] = true
 		}
-		if !hasAnyResources {
-			continue // Silently skip pods with no resource specs - nothing to resize
+	}
+	return updates, analyzed
+}
+
+// AnalyzePod analyzes a pod on demand and returns the updates the next
+// cycle would make to it, without applying them
+func (r *AdaptiveRightSizer) AnalyzePod(ctx context.Context, namespace, name string) ([]ResourceUpdate, error) {
+	var pod corev1.Pod
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &pod); err != nil {
+		return nil, err
+	}
+	updates, _ := r.analyzePod(ctx, pod, r.MetricsProvider, r.profilePolicies(ctx), exclusionRules(config.Get().Exclusions), false)
+	return updates, nil
+}
+
+// analyzePod analyzes the containers of one pod for resource optimization.
+// It runs on the analysis workers, concurrently with other pods. observe
+// records the usage in the trackers, the prediction history and the
+// scale-down delay; on-demand analyses pass false so they leave the sizing
+// loop's state as it was. The returned bool reports whether the pod passed
+// the filters and had its containers sized, whether or not they need a resize.
+func (r *AdaptiveRightSizer) analyzePod(ctx context.Context, pod corev1.Pod, provider metrics.Provider, profilePolicies []v1alpha1.RightSizerPolicy, exclusions []exclusionRule, observe bool) ([]ResourceUpdate, bool) {
+	// Skip pods that are not running. Pending pods are kept to observe
+	// the init containers they are running.
+	if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+		return nil, false
+	}
+
+	// Skip pods that are being deleted (terminating)
+	if !pod.DeletionTimestamp.IsZero() {
+		log.Printf("⏭️  Skipping terminating pod %s/%s", pod.Namespace, pod.Name)
+		return nil, false
+	}
+
+	// Self-protection: Skip the right-sizer pod itself unless self-sizing is
+	// enabled, which sizes it whatever the namespace filters
+	self := r.isSelfPod(&pod)
+	if self && !config.Get().SelfSizing.Enabled {
+		log.Printf("🛡️  Skipping self-pod %s/%s to prevent self-modification", pod.Namespace, pod.Name)
+		return nil, false
+	}
+
+	// Check namespace filters and system workloads
+	if !self && !r.shouldProcessNamespace(pod.Namespace) {
+		return nil, false
+	}
+	if !self && r.isSystemWorkload(pod.Namespace, pod.Name) {
+		return nil, false
+	}
+
+	// Skip pods with skip annotation
+	if pod.Annotations != nil {
+		if skip, ok := pod.Annotations["rightsizer.io/skip"]; ok && skip == "true" {
+			return nil, false
 		}
+	}
 
-		// Get metrics for this specific pod
-		podMetrics, err := r.MetricsProvider.FetchPodMetrics(ctx, pod.Namespace, pod.Name)
-		if err != nil {
-			log.Printf("Failed to get metrics for pod %s/%s: %v", pod.Namespace, pod.Name, err)
-			continue
+	// Skip pods excluded by their labels or owner kind
+	rules := append(policyExclusionRules(pod.Namespace, profilePolicies), exclusions...)
+	if rule, excluded := r.excludedPod(ctx, &pod, rules); excluded {
+		logger.Debug("Skipping pod %s/%s matching the %s", pod.Namespace, pod.Name, rule.describe())
+		return nil, false
+	}
+
+	// Jobs run to completion: their usage sizes the next run instead
+	if r.Jobs != nil && config.Get().JobMode != JobModeResize && isJobPod(&pod) {
+		if observe && pod.Status.Phase == corev1.PodRunning {
+			r.observeJobPod(ctx, &pod, provider)
 		}
+		return nil, false
+	}
+
+	// Init containers can only be sized from recommendations, which are
+	// only published in recommendation-only mode
+	if pod.Status.Phase == corev1.PodPending {
+		if observe && config.Get().RecommendationOnly {
+			r.observeInitContainers(ctx, &pod, provider)
+		}
+		return nil, false
+	}
+
+	// Starting pods show little usage: wait until they are up and settled
+	policies := r.matchingPolicies(ctx, &pod, profilePolicies)
+	if reason, starting := startupGrace(&pod, minPodAge(policies), time.Now()); starting {
+		logger.Debug("Skipping pod %s/%s in its startup grace period: %s", pod.Namespace, pod.Name, reason)
+		return nil, false
+	}
+
+	// Skip pods that have no resource specifications at all
+	targets := resizableContainers(&pod)
+	hasAnyResources := false
+	for _, target := range targets {
+		container := target.container
+		if len(container.Resources.Requests) > 0 {
+			hasAnyResources = true
+			break
+		}
+		if len(container.Resources.Limits) > 0 {
+			hasAnyResources = true
+			break
+		}
+	}
+	if !hasAnyResources {
+		return nil, false // Silently skip pods with no resource specs - nothing to resize
+	}
+
+	// Get metrics for this specific pod
+	podMetrics, err := provider.FetchPodMetrics(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		log.Printf("Failed to get metrics for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return nil, false
+	}
 
-		// Update metrics counters
+	// Update metrics counters
+	if observe {
 		r.metricsMutex.Lock()
 		r.managedPods++
 		r.totalCPUUsage += podMetrics.CPUMilli
 		r.totalMemoryUsage += podMetrics.MemMB
 		r.metricsMutex.Unlock()
+	}
+
+	// Get per-container metrics so sidecars are sized from their own usage
+	containerMetrics, err := provider.FetchContainerMetrics(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		logger.Debug("Per-container metrics unavailable for pod %s/%s, falling back to pod metrics: %v", pod.Namespace, pod.Name, err)
+		containerMetrics = nil
+	}
+
+	// Namespace overrides take precedence over the global sizing settings
+	cfg := config.Get().ForNamespace(pod.Namespace)
+	profile := podSizingProfile(&pod, policies)
+	qosMode := podQoSMode(&pod, policies)
+	steps := podStepLimits(policies, cfg)
+	customRules := podCustomMetricRules(policies, cfg)
+	aggregation := podUsageAggregation(policies, cfg)
+	scaleDownDelay := podScaleDownDelay(policies, cfg)
+	specialMemoryMode := podSpecialMemory(policies)
+	memoryMetric := podMemoryMetric(policies, cfg)
+	memoryQoS := r.MemoryQoSEnabled()
+	currentQoS := getQoSClass(&pod)
+
+	var updates []ResourceUpdate
+	// Check each container in the pod, native sidecars included
+	for _, target := range targets {
+		container := target.container
+		usage := selectMemoryMetric(containerUsage(podMetrics, containerMetrics, len(targets), container.Name), memoryMetric)
+		if observe {
+			r.Idle.Observe(pod.Namespace, audit.WorkloadOf(&pod), pod.Name, usage.CPUMilli)
+			r.Efficiency.Observe(efficiency.Sample{
+				Time:         time.Now(),
+				Namespace:    pod.Namespace,
+				Workload:     audit.WorkloadOf(&pod),
+				Pod:          pod.Name,
+				Container:    container.Name,
+				CPURequest:   float64(container.Resources.Requests.Cpu().MilliValue()),
+				CPUUsage:     usage.CPUMilli,
+				MemRequestMB: float64(container.Resources.Requests.Memory().Value()) / (1024 * 1024),
+				MemUsageMB:   usage.MemMB,
+			})
 
-		// Check each container in the pod
-		for i, container := range pod.Spec.Containers {
-			// Send metrics to dashboard for time-series data collection (once per pod)
-			if r.DashboardClient != nil && i == 0 { // Send once per pod, not per container
+			// Send metrics to dashboard for time-series data collection
+			if r.DashboardClient != nil {
 				metrics := dashboardapi.Metrics{
 					Namespace:     pod.Namespace,
 					PodName:       pod.Name,
-					ContainerName: container.Name, // Note: metrics-server provides pod-level metrics
+					ContainerName: container.Name,
 					Metrics: map[string]interface{}{
-						"cpu_milli":      podMetrics.CPUMilli,
-						"memory_mb":      podMetrics.MemMB,
+						"cpu_milli":      usage.CPUMilli,
+						"memory_mb":      usage.MemMB,
 						"cpu_percent":    0.0, // Would need current limits to calculate
 						"memory_percent": 0.0, // Would need current limits to calculate
 					},
@@ -407,100 +957,267 @@ func (r *AdaptiveRightSizer) analyzeAllPods(ctx context.Context) ([]ResourceUpda
 					logger.Warn("Failed to send metrics to dashboard: %v", err)
 				}
 			}
-			// Check scaling thresholds first
-			scalingDecision := r.checkScalingThresholds(podMetrics, container.Resources)
+		}
+		// Sidecars such as mesh proxies are observed but never sized
+		if cfg.IsContainerExcluded(container.Name) {
+			logger.Debug("Skipping excluded container %s of pod %s/%s", container.Name, pod.Namespace, pod.Name)
+			continue
+		}
 
-			// Skip if CPU should not be updated but memory should be reduced
-			if scalingDecision.CPU == ScaleNone && scalingDecision.Memory == ScaleDown {
-				logger.Info("⏭️  Skipping resize for pod %s/%s container %s: CPU doesn't need update and memory would be reduced",
-					pod.Namespace, pod.Name, container.Name)
-				continue
+		// Let the sizing profile pick the usage to size from
+		sample := usage
+		usage = profile.Usage(sample, func(percentile int) metrics.Metrics {
+			return r.percentileUsage(ctx, pod.Namespace, pod.Name, container.Name, sample, percentile, config.Get().PercentileWindow)
+		})
+		usage, customUsage := r.blendCustomMetrics(ctx, &pod, container.Name, usage, customRules)
+		// The container's annotations override the sizing parameters
+		overrides := parseContainerOverrides(&pod, container.Name, cfg)
+		containerCfg := overrides.config(cfg)
+
+		// Check scaling thresholds first
+		scalingDecision := r.checkScalingThresholds(usage, container.Resources, cfg)
+		if observe {
+			scalingDecision = r.sustainScaleDown(pod.Namespace, pod.Name, container.Name, scalingDecision, scaleDownDelay, time.Now())
+		} else {
+			scalingDecision = r.sustainedScaleDown(pod.Namespace, pod.Name, container.Name, scalingDecision, scaleDownDelay, time.Now())
+		}
+
+		// Skip if CPU should not be updated but memory should be reduced
+		if scalingDecision.CPU == ScaleNone && scalingDecision.Memory == ScaleDown {
+			logger.Info("⏭️  Skipping resize for pod %s/%s container %s: CPU doesn't need update and memory would be reduced",
+				pod.Namespace, pod.Name, container.Name)
+			continue
+		}
+
+		// Skip if both resources don't need changes
+		if scalingDecision.CPU == ScaleNone && scalingDecision.Memory == ScaleNone {
+			continue
+		}
+
+		// Calculate optimal resources based on the container's own usage and scaling decision
+		// Use prediction-enhanced calculation if predictor is available
+		explanation := r.newExplanation(&pod, container, profile, policyNames(policies), usage, scalingDecision)
+		var newResources corev1.ResourceRequirements
+		if r.Predictor != nil {
+			newResources = r.calculateOptimalResourcesWithPrediction(ctx, pod.Namespace, pod.Name, container.Name, usage, aggregation, scalingDecision, containerCfg, explanation, observe)
+		} else {
+			usage = r.windowUsage(ctx, pod.Namespace, pod.Name, container.Name, usage, aggregation, explanation)
+			newResources = r.calculateOptimalResourcesWithDecision(usage, scalingDecision, containerCfg)
+		}
+		usageDetail := "CPU " + scalingDecisionString(scalingDecision.CPU) + ", memory " + scalingDecisionString(scalingDecision.Memory)
+		if customUsage != "" {
+			usageDetail += "; custom metrics " + customUsage
+		}
+		explanation.AddStep("usage", usageDetail, newResources)
+		if profiled := profile.Resources(newResources, usage, containerCfg); !resourcesEqual(profiled, newResources) {
+			newResources = profiled
+			explanation.AddStep("profile", profile.Name()+" profile", newResources)
+		}
+		if cfg.CPUThrottleThreshold > 0 && usage.CPUThrottled > cfg.CPUThrottleThreshold {
+			newResources = raiseThrottledCPU(container.Resources, newResources, usage.CPUThrottled, containerCfg.MaxCPULimit)
+			explanation.AddStep("throttling", fmt.Sprintf("%.0f%% of CPU periods throttled", usage.CPUThrottled), newResources)
+		}
+		if cpuPressured, memoryPressured := pressured(usage, cfg); cpuPressured || memoryPressured {
+			if cpuPressured {
+				newResources = raisePressuredResource(container.Resources, newResources, corev1.ResourceCPU, usage.CPUPressure, containerCfg.MaxCPULimit)
+			}
+			if memoryPressured {
+				newResources = raisePressuredResource(container.Resources, newResources, corev1.ResourceMemory, usage.MemoryPressure, containerCfg.MaxMemoryLimit)
+			}
+			explanation.AddStep("pressure", pressureDetail(usage, cpuPressured, memoryPressured), newResources)
+		}
+		if memoryQoS {
+			if protected := protectMemoryMin(newResources, usage.MemMB); !resourcesEqual(protected, newResources) {
+				newResources = protected
+				explanation.AddStep("memory_qos", fmt.Sprintf("memory.min covers the %.0fMB memory usage", usage.MemMB), newResources)
+			}
+		}
+		if !overrides.empty() {
+			if clamped := overrides.clamp(newResources); !resourcesEqual(clamped, newResources) {
+				newResources = clamped
+				explanation.AddStep("annotations", strings.Join(overrides.annotations, ", "), newResources)
+			}
+		}
+		if adjusted := applyQoSMode(qosMode, currentQoS, newResources); !resourcesEqual(adjusted, newResources) {
+			newResources = adjusted
+			explanation.AddStep("qos", qosMode, newResources)
+		}
+		if limited := limitStep(container.Resources, newResources, steps); !resourcesEqual(limited, newResources) {
+			newResources = limited
+			explanation.AddStep("step", steps.String(), newResources)
+		}
+		if special, ok := containerSpecialMemory(&pod, &container); ok {
+			adjusted := applySpecialMemory(specialMemoryMode, special, container.Resources, newResources)
+			if !resourcesEqual(adjusted, newResources) {
+				explanation.AddStep("special_memory", specialMemoryMode+" memory with "+special.String(), adjusted)
 			}
+			newResources = adjusted
+		}
 
-			// Skip if both resources don't need changes
-			if scalingDecision.CPU == ScaleNone && scalingDecision.Memory == ScaleNone {
+		if r.needsAdjustmentWithDecision(container.Resources, newResources, scalingDecision) {
+			if reason, ok := checkQoS(&pod, target, newResources, qosMode); !ok {
+				logger.Info("Skipping resize of %s/%s container %s: %s", pod.Namespace, pod.Name, container.Name, reason)
 				continue
 			}
+			if memoryQoS {
+				if explanation != nil {
+					explanation.MemoryQoS = memoryQoSOf(newResources)
+				}
+				if warning, ok := memoryHighWarning(container.Resources, newResources, usage.MemMB); ok {
+					logger.Warn("⚠️  Resize of %s/%s container %s: %s", pod.Namespace, pod.Name, container.Name, warning)
+					explanation.AddWarning(warning)
+				}
+			}
 
-			// Calculate optimal resources based on actual usage and scaling decision
-			// Note: metrics-server provides pod-level metrics, not per-container
-			// So we'll use the pod metrics for all containers
-			// Use prediction-enhanced calculation if predictor is available
-			var newResources corev1.ResourceRequirements
-			if r.Predictor != nil {
-				newResources = r.calculateOptimalResourcesWithPrediction(ctx, pod.Namespace, pod.Name, container.Name, podMetrics, scalingDecision)
-			} else {
-				newResources = r.calculateOptimalResourcesWithDecision(podMetrics, scalingDecision)
+			// Log the actual resource changes that will be made
+			oldCPUReq := container.Resources.Requests[corev1.ResourceCPU]
+			oldMemReq := container.Resources.Requests[corev1.ResourceMemory]
+			newCPUReq := newResources.Requests[corev1.ResourceCPU]
+			newMemReq := newResources.Requests[corev1.ResourceMemory]
+
+			// Get current usage for detailed logging
+			cpuLimit := container.Resources.Limits.Cpu().AsApproximateFloat64() * 1000
+			memLimit := float64(container.Resources.Limits.Memory().Value()) / (1024 * 1024)
+			cpuUsagePercent := 0.0
+			memUsagePercent := 0.0
+			if cpuLimit > 0 {
+				cpuUsagePercent = (usage.CPUMilli / cpuLimit) * 100
+			}
+			if memLimit > 0 {
+				memUsagePercent = (usage.MemMB / memLimit) * 100
 			}
 
-			if r.needsAdjustmentWithDecision(container.Resources, newResources, scalingDecision) {
-				// Log the actual resource changes that will be made
-				oldCPUReq := container.Resources.Requests[corev1.ResourceCPU]
-				oldMemReq := container.Resources.Requests[corev1.ResourceMemory]
-				newCPUReq := newResources.Requests[corev1.ResourceCPU]
-				newMemReq := newResources.Requests[corev1.ResourceMemory]
-
-				// Get current usage for detailed logging
-				cpuLimit := container.Resources.Limits.Cpu().AsApproximateFloat64() * 1000
-				memLimit := float64(container.Resources.Limits.Memory().Value()) / (1024 * 1024)
-				cpuUsagePercent := 0.0
-				memUsagePercent := 0.0
-				if cpuLimit > 0 {
-					cpuUsagePercent = (podMetrics.CPUMilli / cpuLimit) * 100
-				}
-				if memLimit > 0 {
-					memUsagePercent = (podMetrics.MemMB / memLimit) * 100
-				}
+			// Check cache before logging to prevent repetitive messages
+			if observe && r.shouldLogResizeDecision(pod.Namespace, pod.Name, container.Name,
+				oldCPUReq.String(), newCPUReq.String(), oldMemReq.String(), newMemReq.String()) {
+				logger.Info("🔍 Scaling analysis - CPU: %s (usage: %.0fm/%.0fm, %.1f%%), Memory: %s (usage: %.0fMi/%.0fMi, %.1f%%)",
+					scalingDecisionString(scalingDecision.CPU), usage.CPUMilli, cpuLimit, cpuUsagePercent,
+					scalingDecisionString(scalingDecision.Memory), usage.MemMB, memLimit, memUsagePercent)
+				logger.Info("📈 Container %s/%s/%s will be resized - CPU: %s→%s, Memory: %s→%s",
+					pod.Namespace, pod.Name, container.Name,
+					oldCPUReq.String(), newCPUReq.String(),
+					oldMemReq.String(), newMemReq.String())
+			}
+			update := ResourceUpdate{
+				Namespace:      pod.Namespace,
+				Name:           pod.Name,
+				ResourceType:   "Pod",
+				ContainerName:  container.Name,
+				ContainerIndex: target.index,
+				InitContainer:  target.init,
+				OldResources:   container.Resources,
+				NewResources:   newResources,
+				Usage:          usage,
+				Reason:         r.getAdjustmentReasonWithDecision(container.Resources, newResources, scalingDecision),
+				QoSMode:        qosMode,
+				Explanation:    explanation,
+			}
+			if profile.Name() != ProfileSteady {
+				update.Reason += " (" + profile.Name() + " profile)"
+			}
+			updates = append(updates, update)
 
-				// Check cache before logging to prevent repetitive messages
-				if r.shouldLogResizeDecision(pod.Namespace, pod.Name, container.Name,
-					oldCPUReq.String(), newCPUReq.String(), oldMemReq.String(), newMemReq.String()) {
-					logger.Info("🔍 Scaling analysis - CPU: %s (usage: %.0fm/%.0fm, %.1f%%), Memory: %s (usage: %.0fMi/%.0fMi, %.1f%%)",
-						scalingDecisionString(scalingDecision.CPU), podMetrics.CPUMilli, cpuLimit, cpuUsagePercent,
-						scalingDecisionString(scalingDecision.Memory), podMetrics.MemMB, memLimit, memUsagePercent)
-					logger.Info("📈 Container %s/%s/%s will be resized - CPU: %s→%s, Memory: %s→%s",
+			// Send recommendation event to dashboard (only for new recommendations)
+			if observe && r.shouldLogResizeDecision(pod.Namespace, pod.Name, container.Name,
+				oldCPUReq.String(), newCPUReq.String(), oldMemReq.String(), newMemReq.String()) {
+				if r.DashboardClient != nil {
+					event := dashboardapi.NewRecommendationEvent(
 						pod.Namespace, pod.Name, container.Name,
-						oldCPUReq.String(), newCPUReq.String(),
-						oldMemReq.String(), newMemReq.String())
-				}
-				update := ResourceUpdate{
-					Namespace:      pod.Namespace,
-					Name:           pod.Name,
-					ResourceType:   "Pod",
-					ContainerName:  container.Name,
-					ContainerIndex: i,
-					OldResources:   container.Resources,
-					NewResources:   newResources,
-					Reason:         r.getAdjustmentReasonWithDecision(container.Resources, newResources, scalingDecision),
-				}
-				updates = append(updates, update)
-
-				// Send recommendation event to dashboard (only for new recommendations)
-				if r.shouldLogResizeDecision(pod.Namespace, pod.Name, container.Name,
-					oldCPUReq.String(), newCPUReq.String(), oldMemReq.String(), newMemReq.String()) {
-					if r.DashboardClient != nil {
-						event := dashboardapi.NewRecommendationEvent(
-							pod.Namespace, pod.Name, container.Name,
-							map[string]interface{}{
-								"oldResources": update.OldResources,
-								"newResources": update.NewResources,
-								"reason":       update.Reason,
-								"cpuUsage":     cpuUsagePercent,
-								"memoryUsage":  memUsagePercent,
-							},
-						)
-						if sendErr := r.DashboardClient.SendEvent(event); sendErr != nil {
-							logger.Warn("Failed to send recommendation event to dashboard: %v", sendErr)
-						}
+						map[string]interface{}{
+							"oldResources": update.OldResources,
+							"newResources": update.NewResources,
+							"reason":       update.Reason,
+							"cpuUsage":     cpuUsagePercent,
+							"memoryUsage":  memUsagePercent,
+						},
+					)
+					if sendErr := r.DashboardClient.SendEvent(event); sendErr != nil {
+						logger.Warn("Failed to send recommendation event to dashboard: %v", sendErr)
 					}
 				}
 			}
 		}
+	}
 
-		podsProcessed++
+	// Resizing during an I/O burst disrupts the pod when it is busiest: hold
+	// its updates back until the burst is over. Recommendations are not
+	// disruptive and are published regardless.
+	if len(updates) > 0 && !config.Get().RecommendationOnly {
+		if reason, busy := r.heavyIO(ctx, &pod, podIOLimits(policies)); busy {
+			logger.Info("⏸️  Suppressing resize of %s/%s during heavy I/O: %s", pod.Namespace, pod.Name, reason)
+			if r.OperatorMetrics != nil {
+				r.OperatorMetrics.RecordSuppressedResize(pod.Namespace, "heavy_io")
+			}
+			return nil, false
+		}
 	}
 
-	return updates, nil
+	if config.Get().RecommendationOnly {
+		updates = append(updates, r.initContainerUpdates(&pod)...)
+	}
+
+	return updates, true
+}
+
+// nextAnalysisBatch returns the pods to analyze this cycle: all of them, or
+// up to limit pods in name order starting after the last pod analyzed by the
+// previous cycle, so every pod is reached in turn
+func (r *AdaptiveRightSizer) nextAnalysisBatch(pods []corev1.Pod, limit int) []corev1.Pod {
+	if limit <= 0 || len(pods) <= limit {
+		r.analysisCursor = ""
+		return pods
+	}
+
+	key := func(pod *corev1.Pod) string { return pod.Namespace + "/" + pod.Name }
+	order := make([]*corev1.Pod, len(pods))
+	for i := range pods {
+		order[i] = &pods[i]
+	}
+	sort.Slice(order, func(i, j int) bool { return key(order[i]) < key(order[j]) })
+	start := sort.Search(len(order), func(i int) bool { return key(order[i]) > r.analysisCursor })
+
+	batch := make([]corev1.Pod, 0, limit)
+	for i := range limit {
+		batch = append(batch, *order[(start+i)%len(order)])
+	}
+	r.analysisCursor = key(&batch[len(batch)-1])
+	log.Printf("📊 Analyzing %d of %d pods this cycle, resuming after %s next cycle", limit, len(pods), r.analysisCursor)
+	return batch
+}
+
+// cycleMetrics returns the metrics provider for one cycle. Providers that can
+// list the usage of every pod at once are read through a snapshot, cutting
+// the per-cycle metrics calls from one per pod to one.
+func (r *AdaptiveRightSizer) cycleMetrics(ctx context.Context) metrics.Provider {
+	batch, ok := r.MetricsProvider.(metrics.BatchProvider)
+	if !ok {
+		return r.MetricsProvider
+	}
+	snapshot, err := metrics.NewSnapshot(ctx, batch)
+	if err != nil {
+		logger.Debug("Batched metrics unavailable, fetching per pod: %v", err)
+		return r.MetricsProvider
+	}
+	return snapshot
+}
+
+// containerUsage returns the usage for a single container. Per-container metrics are
+// preferred; when they are unavailable the pod-level usage is split evenly across
+// containers rather than attributing the whole pod's usage to every container.
+func containerUsage(podMetrics metrics.Metrics, containerMetrics metrics.ContainerMetrics, containerCount int, containerName string) metrics.Metrics {
+	if usage, ok := containerMetrics[containerName]; ok {
+		return usage
+	}
+	if containerCount <= 1 {
+		return podMetrics
+	}
+	return metrics.Metrics{
+		CPUMilli:       podMetrics.CPUMilli / float64(containerCount),
+		MemMB:          podMetrics.MemMB / float64(containerCount),
+		CPUThrottled:   podMetrics.CPUThrottled,
+		CPUPressure:    podMetrics.CPUPressure,
+		MemoryPressure: podMetrics.MemoryPressure,
+	}
 }
 
 // analyzeStandalonePods analyzes standalone pods (deprecated - all pods are now analyzed)
@@ -510,7 +1227,7 @@ func (r *AdaptiveRightSizer) analyzeStandalonePods(ctx context.Context) ([]Resou
 }
 
 // applyUpdates applies the calculated resource updates with batching and rate limiting
-func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []ResourceUpdate) {
+func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) {
 	if len(updates) == 0 {
 		return
 	}
@@ -520,17 +1237,30 @@ func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []Resourc
 		log.Printf("📊 Found %d resources needing adjustment", len(updates))
 	}
 
+	// Configuration for batching to prevent API server overload
+	cfg := config.Get()
+
+	// Apply the most impactful resizes first, so they are the ones that make
+	// the per-run budget
+	prioritizeUpdates(updates, cfg.CPUThrottleThreshold)
+
+	// Restarts of this run are charged against PodDisruptionBudgets afresh
+	r.disruptions = newDisruptionBudget(r.Client)
+
+	// Interleave the resizes across nodes and hold back those of busy nodes
+	spreader := &NodeResizeSpreader{MaxPerNode: cfg.MaxResizesPerNode}
+	updates = spreader.Spread(updates, pods)
+
+	// Keep within the cluster and namespace change budgets
+	updates = r.changes.filter(updates, pods, cfg, r.OperatorMetrics)
+
 	// Protect API server from too many updates at once
-	const maxUpdatesPerRun = 50 // Maximum updates to process in a single run
-	if len(updates) > maxUpdatesPerRun {
-		log.Printf("⚠️  Too many updates pending (%d > %d). Processing first %d to protect API server",
-			len(updates), maxUpdatesPerRun, maxUpdatesPerRun)
+	if maxUpdates := cfg.MaxUpdatesPerCycle; maxUpdates > 0 && len(updates) > maxUpdates {
+		log.Printf("⚠️  Too many updates pending (%d > %d). Processing the %d most urgent to protect API server",
+			len(updates), maxUpdates, maxUpdates)
 		log.Printf("   Remaining updates will be processed in the next run")
-		updates = updates[:maxUpdatesPerRun]
+		updates = mostUrgentUpdates(updates, maxUpdates, cfg.CPUThrottleThreshold)
 	}
-
-	// Configuration for batching to prevent API server overload
-	cfg := config.Get()
 	batchSize := cfg.BatchSize
 	delayBetweenBatches := cfg.DelayBetweenBatches
 	delayBetweenPods := cfg.DelayBetweenPods
@@ -545,6 +1275,7 @@ func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []Resourc
 	if delayBetweenPods <= 0 {
 		delayBetweenPods = 500 * time.Millisecond
 	}
+	r.pacing.configure(delayBetweenPods, cfg.ApplyPacing)
 
 	// Log all updates first if in dry-run mode
 	if r.DryRun {
@@ -559,6 +1290,11 @@ func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []Resourc
 		r.logUpdate(update, false)
 	}
 
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
 	// Apply pod updates in batches with rate limiting
 	podUpdates := []ResourceUpdate{}
 	for _, update := range updates {
@@ -604,9 +1340,27 @@ func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []Resourc
 			default:
 			}
 
+			// Honor a pause set while the run is in progress
+			if reason, paused := r.pausedReason(update.Namespace); paused {
+				log.Printf("⏸️  Skipping resize of %s/%s: %s", update.Namespace, update.Name, reason)
+				if r.OperatorMetrics != nil {
+					r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "paused")
+				}
+				r.recordResizeEvent(ctx, podsByName[update.Namespace+"/"+update.Name], update, EventReasonResizeSkipped, reason)
+				continue
+			}
+
+			update.DecisionID = newDecisionID()
+			resizeStart := time.Now()
 			actualChanges, err := r.updatePodInPlace(ctx, update)
+			r.recordResizeOutcome(update, actualChanges, err, time.Since(resizeStart))
+			if r.OperatorMetrics != nil {
+				r.OperatorMetrics.SetApplyDelay(r.pacing.podDelay())
+			}
+			pod := podsByName[update.Namespace+"/"+update.Name]
 			if err != nil {
 				log.Printf("❌ Error updating pod %s/%s: %v", update.Namespace, update.Name, err)
+				r.recordResizeEvent(ctx, pod, update, EventReasonResizeFailed, err.Error())
 				// Send error event to dashboard
 				if r.DashboardClient != nil {
 					event := dashboardapi.NewErrorEvent(
@@ -623,24 +1377,53 @@ func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []Resourc
 						logger.Warn("Failed to send error event to dashboard: %v", sendErr)
 					}
 				}
+				publishResizeEvent(r.EventBus, events.EventResizeFailed, events.SeverityError, update.Namespace, update.Name,
+					fmt.Sprintf("Failed to resize container %s: %v", update.ContainerName, err),
+					map[string]interface{}{
+						"containerName": update.ContainerName,
+						"error":         err.Error(),
+						"reason":        update.Reason,
+					})
 			} else if actualChanges != "" && !strings.Contains(actualChanges, "Skipped") && !strings.Contains(actualChanges, "already at target") {
 				log.Printf("✅ %s", actualChanges)
+				r.recordResizeEvent(ctx, pod, update, EventReasonResizeApplied, "")
+				if pod != nil {
+					if err := r.annotateDecision(ctx, pod, update, time.Now()); err != nil {
+						logger.Warn("Failed to annotate pod %s/%s with the resize decision: %v", update.Namespace, update.Name, err)
+					}
+				}
+				r.recordResize(update.Namespace, update.Name, update.ContainerName)
+				r.changes.record(update.Namespace, update.Name)
+				if pod != nil && r.Safety != nil {
+					r.Safety.RecordResize(ctx, pod, cfg)
+				}
 				// Increment optimizations applied counter
 				r.metricsMutex.Lock()
 				r.optimizationsApplied++
 				r.metricsMutex.Unlock()
+			} else {
+				detail := actualChanges
+				if detail == "" {
+					detail = "resources already at target or not resizable in place"
+				}
+				r.recordResizeEvent(ctx, pod, update, EventReasonResizeSkipped, detail)
 			}
 
-			// Add small delay between pods within a batch to avoid rapid-fire API calls
-			if j < len(batch)-1 {
-				time.Sleep(delayBetweenPods)
+			// Add a delay between pods within a batch, paced by the API server
+			if j < len(batch)-1 && !sleepContext(ctx, r.pacing.podDelay()) {
+				log.Printf("⚠️  Context canceled, stopping pod updates")
+				return
 			}
 		}
 
 		// Add delay between batches (except after the last batch)
 		if i+batchSize < len(podUpdates) {
-			log.Printf("⏳ Waiting %v before next batch to avoid API server overload", delayBetweenBatches)
-			time.Sleep(delayBetweenBatches)
+			delay := r.pacing.batchDelay(delayBetweenBatches)
+			log.Printf("⏳ Waiting %v before next batch to avoid API server overload", delay)
+			if !sleepContext(ctx, delay) {
+				log.Printf("⚠️  Context canceled, stopping pod updates")
+				return
+			}
 		}
 	}
 
@@ -655,6 +1438,50 @@ func (r *AdaptiveRightSizer) applyUpdates(ctx context.Context, updates []Resourc
 	}
 }
 
+// recordResizeOutcome records the latency and outcome of applying an update
+func (r *AdaptiveRightSizer) recordResizeOutcome(update ResourceUpdate, actualChanges string, err error, duration time.Duration) {
+	if r.OperatorMetrics == nil {
+		return
+	}
+	decision := "applied"
+	switch {
+	case err != nil:
+		decision = "failed"
+	case actualChanges == "" || strings.Contains(actualChanges, "Skipped") || strings.Contains(actualChanges, "already at target"):
+		decision = "skipped"
+	}
+	r.OperatorMetrics.RecordResizeDuration(update.Namespace, update.Name, decision, duration)
+	r.OperatorMetrics.RecordResizeDecision(update.Namespace, update.Name, decision, resizeDirection(update))
+}
+
+// resizeDirection classifies an update by how it changes the requests:
+// scale_up, scale_down, mixed or limits_only
+func resizeDirection(update ResourceUpdate) string {
+	up, down := false, false
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		newVal, ok := update.NewResources.Requests[name]
+		if !ok {
+			continue
+		}
+		oldVal := update.OldResources.Requests[name]
+		switch newVal.Cmp(oldVal) {
+		case 1:
+			up = true
+		case -1:
+			down = true
+		}
+	}
+	switch {
+	case up && down:
+		return "mixed"
+	case up:
+		return "scale_up"
+	case down:
+		return "scale_down"
+	}
+	return "limits_only"
+}
+
 // updatePodInPlace attempts to update pod resources in-place with mutex protection
 // Returns a description of what was actually changed
 // updatePodInPlace performs in-place resource update in two steps: CPU first, then memory
@@ -673,28 +1500,39 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 		return "", fmt.Errorf("failed to get pod: %w", err)
 	}
 
-	// Find the container index and check current resources
+	// Find the container index and check current resources. Native sidecars
+	// are found among the init containers.
 	var currentResources *corev1.ResourceRequirements
-	containerIndex := -1
-	for i, container := range pod.Spec.Containers {
-		if container.Name == update.ContainerName {
-			currentResources = &container.Resources
-			containerIndex = i
-			break
-		}
+	container, containerIndex, initContainer := findContainer(&pod, update.ContainerName)
+	if container != nil {
+		currentResources = &container.Resources
 	}
 
 	if currentResources == nil || containerIndex == -1 {
 		return "", fmt.Errorf("container %s not found in pod", update.ContainerName)
 	}
+	if initContainer && !isSidecar(container) {
+		return "", fmt.Errorf("init container %s cannot be resized in place", update.ContainerName)
+	}
+	if r.windowsPod(ctx, &pod) {
+		if !windowsRestartAllowed(&pod, config.Get()) {
+			return "Skipped resize (Windows containers cannot be resized in place)", nil
+		}
+		return r.evictForResize(ctx, &pod, update)
+	}
 
 	// Check the current QoS class
 	cfg := config.Get()
 	currentQoS := getQoSClass(&pod)
 	isGuaranteed := currentQoS == corev1.PodQOSGuaranteed
 
-	// If pod is Guaranteed and config says to preserve it, ensure we maintain the QoS class
-	if isGuaranteed && cfg.PreserveGuaranteedQoS {
+	preserveQoS := update.QoSMode != QoSAllowBurstable
+	if update.QoSMode == "" {
+		preserveQoS = cfg.PreserveGuaranteedQoS
+	}
+
+	// If pod is Guaranteed and its QoS mode preserves it, ensure we maintain the QoS class
+	if isGuaranteed && preserveQoS {
 		// For Guaranteed pods, requests must equal limits
 		update.NewResources.Limits = make(corev1.ResourceList)
 		for k, v := range update.NewResources.Requests {
@@ -704,7 +1542,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 		if len(update.NewResources.Requests) > 0 {
 			log.Printf("🔒 Maintaining Guaranteed QoS for pod %s/%s (requests = limits)", update.Namespace, update.Name)
 		}
-	} else if isGuaranteed && !cfg.PreserveGuaranteedQoS && cfg.QoSTransitionWarning {
+	} else if isGuaranteed && !preserveQoS && cfg.QoSTransitionWarning {
 		// Warn if QoS class will change
 		log.Printf("⚠️  QoS class for pod %s/%s may change from Guaranteed", update.Namespace, update.Name)
 	}
@@ -728,6 +1566,21 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 		}
 	}
 
+	// Pods that opt in reclaim memory by restarting the container, within
+	// their disruption budgets
+	if (memoryLimitDecreased || memoryRequestDecreased) && pod.Annotations[memoryRestartAnnotation] == "true" && r.allowDisruption(ctx, &pod) {
+		if err := r.ensureMemoryRestartPolicy(ctx, &pod, containerIndex, initContainer); err != nil {
+			log.Printf("⚠️  Cannot set RestartContainer memory policy for pod %s/%s: %v", update.Namespace, update.Name, err)
+		} else {
+			log.Printf("🔁 Decreasing memory for pod %s/%s container %s; the container will restart", update.Namespace, update.Name, update.ContainerName)
+			if r.EventRecorder != nil {
+				r.EventRecorder.Event(&pod, corev1.EventTypeNormal, "MemoryReclaimRestart",
+					fmt.Sprintf("Decreasing memory of container %s, which restarts it", update.ContainerName))
+			}
+			memoryLimitDecreased, memoryRequestDecreased = false, false
+		}
+	}
+
 	if memoryLimitDecreased || memoryRequestDecreased {
 		// Check if CPU is actually changing by comparing current pod resources with desired
 		currentCPURequest := currentResources.Requests.Cpu()
@@ -773,7 +1626,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 		}
 
 		// If Guaranteed and preserving QoS, ensure requests still equal limits for memory
-		if isGuaranteed && cfg.PreserveGuaranteedQoS && currentMemLimit != nil {
+		if isGuaranteed && preserveQoS && currentMemLimit != nil {
 			update.NewResources.Requests[corev1.ResourceMemory] = currentMemLimit.DeepCopy()
 		}
 
@@ -851,17 +1704,31 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 	}
 
 	// Re-find container after refresh
-	containerIndex = -1
-	for i, container := range pod.Spec.Containers {
-		if container.Name == update.ContainerName {
-			containerIndex = i
-			currentResources = &container.Resources
-			break
-		}
+	if container, containerIndex, initContainer = findContainer(&pod, update.ContainerName); container == nil {
+		return "", fmt.Errorf("container %s not found in pod after refresh", update.ContainerName)
 	}
+	currentResources = &container.Resources
 
 	// Ensure safe resource patch
 	safeResources := ensureSafeResourcePatchAdaptive(*currentResources, update.NewResources)
+	if changes := validation.DRAChanges(&pod, update.ContainerName, safeResources); len(changes) > 0 {
+		return "", fmt.Errorf("refusing to change DRA-backed resources: %s", strings.Join(changes, "; "))
+	}
+
+	// Resize CPU and memory together in one patch when the cluster accepts it
+	if cfg.GroupedResize && !r.groupedResizeUnsupported.Load() {
+		cpuChanged, memChanged, err := r.applyGroupedResize(ctx, &pod, update, containerPath(initContainer, containerIndex), *currentResources, safeResources)
+		if err == nil {
+			return r.completeResize(update, cpuChanged, memChanged), nil
+		}
+		if groupedResizeNotSupported(err) {
+			log.Printf("⚠️  Combined resize not supported by the cluster, using separate CPU and memory patches: %v", err)
+			r.groupedResizeUnsupported.Store(true)
+		} else {
+			// e.g. a forbidden memory decrease; the two-step flow can still apply the CPU change
+			log.Printf("⚠️  Combined resize failed for pod %s/%s, retrying CPU and memory separately: %v", update.Namespace, update.Name, err)
+		}
+	}
 
 	// Resize CPU first
 	cpuChanged := false
@@ -880,7 +1747,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			}
 			cpuPatchOps = append(cpuPatchOps, JSONPatchOp{
 				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%d/resources/requests", containerIndex),
+				Path:  containerPath(initContainer, containerIndex) + "/resources/requests",
 				Value: cpuRequests,
 			})
 			log.Printf("⚡ Container %s: CPU request %s -> %s", update.ContainerName, formatResource(currentCPU), formatResource(cpuReq))
@@ -900,7 +1767,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			}
 			cpuPatchOps = append(cpuPatchOps, JSONPatchOp{
 				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%d/resources/limits", containerIndex),
+				Path:  containerPath(initContainer, containerIndex) + "/resources/limits",
 				Value: cpuLimits,
 			})
 			log.Printf("⚡ Container %s: CPU limit %s -> %s", update.ContainerName, formatResource(currentCPU), formatResource(cpuLim))
@@ -915,15 +1782,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			return "", fmt.Errorf("failed to marshal CPU patch: %w", err)
 		}
 
-		_, err = r.ClientSet.CoreV1().Pods(update.Namespace).Patch(
-			ctx,
-			update.Name,
-			types.JSONPatchType,
-			cpuPatchData,
-			metav1.PatchOptions{},
-			"resize",
-		)
-		if err != nil {
+		if err := r.patchResize(ctx, &pod, cpuPatchData); err != nil {
 			log.Printf("❌ CPU resize failed: %v", err)
 			// Continue to try memory resize
 		} else {
@@ -942,12 +1801,9 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 		}
 
 		// Re-find container after refresh
-		for i, container := range pod.Spec.Containers {
-			if container.Name == update.ContainerName {
-				containerIndex = i
-				currentResources = &container.Resources
-				break
-			}
+		if refreshed, index, init := findContainer(&pod, update.ContainerName); refreshed != nil {
+			containerIndex, initContainer = index, init
+			currentResources = &refreshed.Resources
 		}
 	}
 
@@ -968,7 +1824,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			}
 			memPatchOps = append(memPatchOps, JSONPatchOp{
 				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%d/resources/requests", containerIndex),
+				Path:  containerPath(initContainer, containerIndex) + "/resources/requests",
 				Value: memRequests,
 			})
 			log.Printf("💾 Container %s: Memory request %s -> %s", update.ContainerName, formatMemory(currentMem), formatMemory(memReq))
@@ -988,7 +1844,7 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			}
 			memPatchOps = append(memPatchOps, JSONPatchOp{
 				Op:    "replace",
-				Path:  fmt.Sprintf("/spec/containers/%d/resources/limits", containerIndex),
+				Path:  containerPath(initContainer, containerIndex) + "/resources/limits",
 				Value: memLimits,
 			})
 			log.Printf("💾 Container %s: Memory limit %s -> %s", update.ContainerName, formatMemory(currentMem), formatMemory(memLim))
@@ -1003,20 +1859,17 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			return "", fmt.Errorf("failed to marshal memory patch: %w", err)
 		}
 
-		_, err = r.ClientSet.CoreV1().Pods(update.Namespace).Patch(
-			ctx,
-			update.Name,
-			types.JSONPatchType,
-			memPatchData,
-			metav1.PatchOptions{},
-			"resize",
-		)
-		if err != nil {
+		if err := r.patchResize(ctx, &pod, memPatchData); err != nil {
 			// Check for specific memory decrease error
 			if strings.Contains(err.Error(), "memory limits cannot be decreased") ||
 				strings.Contains(err.Error(), "Forbidden: pod updates may not change fields") ||
 				strings.Contains(err.Error(), "resize is not supported") {
 				log.Printf("⚠️  Cannot resize memory for pod %s/%s: %v", update.Namespace, update.Name, err)
+				// Pods that opt in to restarts are replaced instead when the
+				// webhook sizes the replacement
+				if strings.Contains(err.Error(), "resize is not supported") && cfg.MutatingWebhook && pod.Annotations[memoryRestartAnnotation] == "true" {
+					return r.evictForResize(ctx, &pod, update)
+				}
 				log.Printf("   💡 Pod may need RestartContainer policy for memory decreases")
 				// Return partial success if CPU was changed
 				if cpuChanged {
@@ -1029,9 +1882,122 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 		log.Printf("✅ Memory resize successful")
 	}
 
+	return r.completeResize(update, cpuChanged, memChanged), nil
+}
+
+// applyGroupedResize patches CPU and memory in a single resize-subresource call,
+// so the pod never sits with one resource resized and the other not
+func (r *AdaptiveRightSizer) applyGroupedResize(ctx context.Context, pod *corev1.Pod, update ResourceUpdate, containerPath string, current, safe corev1.ResourceRequirements) (cpuChanged, memChanged bool, err error) {
+	type JSONPatchOp struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}
+
+	var patchOps []JSONPatchOp
+	for _, part := range []struct {
+		name    string
+		current corev1.ResourceList
+		desired corev1.ResourceList
+	}{
+		{"requests", current.Requests, safe.Requests},
+		{"limits", current.Limits, safe.Limits},
+	} {
+		cpu := resourceChanged(part.current, part.desired, corev1.ResourceCPU)
+		mem := resourceChanged(part.current, part.desired, corev1.ResourceMemory)
+		if !cpu && !mem {
+			continue
+		}
+		cpuChanged = cpuChanged || cpu
+		memChanged = memChanged || mem
+		patchOps = append(patchOps, JSONPatchOp{
+			Op:    "replace",
+			Path:  containerPath + "/resources/" + part.name,
+			Value: part.desired,
+		})
+	}
+	if len(patchOps) == 0 {
+		return false, false, nil
+	}
+
+	patchData, err := json.Marshal(patchOps)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to marshal resize patch: %w", err)
+	}
+
+	log.Printf("⚡ Resizing CPU and memory for pod %s/%s container %s", update.Namespace, update.Name, update.ContainerName)
+	if err := r.patchResize(ctx, pod, patchData); err != nil {
+		return false, false, err
+	}
+	log.Printf("✅ Resize successful")
+	return cpuChanged, memChanged, nil
+}
+
+// patchResize sends a JSON patch to the resize subresource of a pod. With a
+// retry handler, transient API errors are retried and patches fail fast
+// while the circuit breaker of the pod's namespace or node is open.
+func (r *AdaptiveRightSizer) patchResize(ctx context.Context, pod *corev1.Pod, patchData []byte) error {
+	patch := func(ctx context.Context) error {
+		callStart := time.Now()
+		_, err := r.ClientSet.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.JSONPatchType, patchData, metav1.PatchOptions{}, "resize")
+		latency := time.Since(callStart)
+		r.pacing.observe(latency, err)
+		if r.OperatorMetrics != nil {
+			r.OperatorMetrics.RecordAPICall("pods/resize", "PATCH", latency)
+			if err != nil {
+				r.OperatorMetrics.RecordAPIError("pods/resize", "PATCH", err)
+			}
+		}
+		return err
+	}
+	if r.RetryHandler == nil {
+		return patch(ctx)
+	}
+	wrapped := func(ctx context.Context) error {
+		return retry.WrapKubernetesError(patch(ctx))
+	}
+
+	breaker := config.Get().CircuitBreaker
+	if !breaker.Enabled {
+		return r.RetryHandler.Retry(ctx, "pod_resize", wrapped)
+	}
+	r.RetryHandler.SetCircuitBreakerConfig(retry.CircuitBreakerConfig{
+		FailureThreshold: breaker.FailureThreshold,
+		RecoveryTimeout:  breaker.RecoveryTimeout,
+		SuccessThreshold: breaker.SuccessThreshold,
+	})
+	return r.RetryHandler.ExecuteForTarget(ctx, "pod_resize", circuitBreakerTarget(breaker.Scope, pod), wrapped)
+}
+
+// circuitBreakerTarget names the circuit breaker guarding resizes of pod;
+// empty for the shared breaker
+func circuitBreakerTarget(scope string, pod *corev1.Pod) string {
+	switch scope {
+	case config.CircuitBreakerScopeNamespace:
+		return "namespace/" + pod.Namespace
+	case config.CircuitBreakerScopeNode:
+		if pod.Spec.NodeName != "" {
+			return "node/" + pod.Spec.NodeName
+		}
+	}
+	return ""
+}
+
+// resourceChanged reports whether desired sets a different value for name than current
+func resourceChanged(current, desired corev1.ResourceList, name corev1.ResourceName) bool {
+	desiredVal, ok := desired[name]
+	if !ok {
+		return false
+	}
+	currentVal, ok := current[name]
+	return !ok || !currentVal.Equal(desiredVal)
+}
+
+// completeResize builds the result message for an applied resize and reports it to the dashboard
+func (r *AdaptiveRightSizer) completeResize(update ResourceUpdate, cpuChanged, memChanged bool) string {
 	// Build success message based on what was actually changed
 	if !cpuChanged && !memChanged {
-		return "", nil // Nothing changed
+		return "" // Nothing changed
 	}
 
 	var successMsg string
@@ -1063,8 +2029,62 @@ func (r *AdaptiveRightSizer) updatePodInPlace(ctx context.Context, update Resour
 			logger.Warn("Failed to send resize event to dashboard: %v", err)
 		}
 	}
+	publishResizeEvent(r.EventBus, events.EventResizeApplied, events.SeverityInfo, update.Namespace, update.Name, successMsg,
+		map[string]interface{}{
+			"containerName": update.ContainerName,
+			"oldResources":  update.OldResources,
+			"newResources":  update.NewResources,
+			"reason":        update.Reason,
+			"decisionId":    update.DecisionID,
+		})
 
-	return successMsg, nil
+	return successMsg
+}
+
+// publishResizeEvent publishes a resize event of a pod on the event bus, if there is one
+func publishResizeEvent(bus *events.EventBus, eventType events.EventType, severity events.Severity, namespace, podName, message string, details map[string]interface{}) {
+	if bus == nil {
+		return
+	}
+	event := events.NewEvent(eventType, config.Get().ClusterID, namespace, podName, severity, message).
+		WithDetails(details).
+		WithTags("resize")
+	bus.PublishAsync(event)
+}
+
+// ensureMemoryRestartPolicy sets the container's memory resize policy to
+// RestartContainer, so the kubelet applies a memory decrease by restarting it
+func (r *AdaptiveRightSizer) ensureMemoryRestartPolicy(ctx context.Context, pod *corev1.Pod, containerIndex int, initContainer bool) error {
+	container := containerAt(pod, containerIndex, initContainer)
+	policies := make([]corev1.ContainerResizePolicy, 0, len(container.ResizePolicy)+1)
+	found := false
+	for _, policy := range container.ResizePolicy {
+		if policy.ResourceName == corev1.ResourceMemory {
+			if policy.RestartPolicy == corev1.RestartContainer {
+				return nil
+			}
+			policy.RestartPolicy = corev1.RestartContainer
+			found = true
+		}
+		policies = append(policies, policy)
+	}
+	if !found {
+		policies = append(policies, corev1.ContainerResizePolicy{ResourceName: corev1.ResourceMemory, RestartPolicy: corev1.RestartContainer})
+	}
+
+	patchData, err := json.Marshal([]map[string]interface{}{{
+		"op":    "add",
+		"path":  containerPath(initContainer, containerIndex) + "/resizePolicy",
+		"value": policies,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal resize policy patch: %w", err)
+	}
+	if _, err := r.ClientSet.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.JSONPatchType, patchData, metav1.PatchOptions{}, "resize"); err != nil {
+		return err
+	}
+	container.ResizePolicy = policies
+	return nil
 }
 
 // ensureParentHasResizePolicy updates the parent resource (Deployment/StatefulSet/DaemonSet) with resize policy
@@ -1083,6 +2103,11 @@ func (r *AdaptiveRightSizer) ensureParentHasResizePolicy(ctx context.Context, po
 	for _, owner := range pod.OwnerReferences {
 		switch owner.Kind {
 		case "ReplicaSet":
+			// Argo Rollouts and Knative Services own their ReplicaSets
+			// through their own CRDs
+			if target := resolveWorkloadRef(ctx, r.Client, pod); workload.IsCustom(target) {
+				return r.updateCustomResizePolicy(ctx, pod.Namespace, target)
+			}
 			// For ReplicaSet, we need to find the Deployment
 			return r.updateDeploymentResizePolicy(ctx, pod, owner)
 		case "StatefulSet":
@@ -1398,39 +2423,15 @@ func (r *AdaptiveRightSizer) calculateOptimalResources(usage metrics.Metrics) co
 }
 
 // checkScalingThresholds determines if scaling is needed based on resource usage thresholds
-func (r *AdaptiveRightSizer) checkScalingThresholds(usage metrics.Metrics, current corev1.ResourceRequirements) ResourceScalingDecision {
-	cfg := config.Get()
-
-	// Get current limits (or requests if limits not set)
-	var cpuLimit, memLimit float64
-
-	if limit, exists := current.Limits[corev1.ResourceCPU]; exists && !limit.IsZero() {
-		cpuLimit = float64(limit.MilliValue())
-	} else if req, exists := current.Requests[corev1.ResourceCPU]; exists && !req.IsZero() {
-		cpuLimit = float64(req.MilliValue())
-	}
-
-	if limit, exists := current.Limits[corev1.ResourceMemory]; exists && !limit.IsZero() {
-		memLimit = float64(limit.Value()) / (1024 * 1024) // Convert to MB
-	} else if req, exists := current.Requests[corev1.ResourceMemory]; exists && !req.IsZero() {
-		memLimit = float64(req.Value()) / (1024 * 1024)
-	}
+func (r *AdaptiveRightSizer) checkScalingThresholds(usage metrics.Metrics, current corev1.ResourceRequirements, cfg *config.Config) ResourceScalingDecision {
 
 	// If no resources set, default to scale up
-	if cpuLimit == 0 && memLimit == 0 {
+	if !hasSizedResource(current, corev1.ResourceCPU) && !hasSizedResource(current, corev1.ResourceMemory) {
 		return ResourceScalingDecision{CPU: ScaleUp, Memory: ScaleUp}
 	}
 
 	// Calculate usage percentages
-	cpuUsagePercent := float64(0)
-	memUsagePercent := float64(0)
-
-	if cpuLimit > 0 {
-		cpuUsagePercent = usage.CPUMilli / cpuLimit
-	}
-	if memLimit > 0 {
-		memUsagePercent = usage.MemMB / memLimit
-	}
+	cpuUsagePercent, memUsagePercent := resourceUtilization(usage, current)
 
 	// Determine scaling decision for each resource independently
 	cpuDecision := ScaleNone
@@ -1443,6 +2444,11 @@ func (r *AdaptiveRightSizer) checkScalingThresholds(usage metrics.Metrics, curre
 		cpuDecision = ScaleDown
 	}
 
+	// A heavily throttled container needs more CPU even when its average usage looks modest
+	if cfg.CPUThrottleThreshold > 0 && usage.CPUThrottled > cfg.CPUThrottleThreshold {
+		cpuDecision = ScaleUp
+	}
+
 	// Check Memory scaling
 	if memUsagePercent > cfg.MemoryScaleUpThreshold {
 		memoryDecision = ScaleUp
@@ -1450,11 +2456,88 @@ func (r *AdaptiveRightSizer) checkScalingThresholds(usage metrics.Metrics, curre
 		memoryDecision = ScaleDown
 	}
 
+	// Sustained pressure stalls are distress even when utilization looks acceptable
+	cpuPressured, memoryPressured := pressured(usage, cfg)
+	if cpuPressured {
+		cpuDecision = ScaleUp
+	}
+	if memoryPressured {
+		memoryDecision = ScaleUp
+	}
+
 	// Don't log here to avoid duplication - logging happens in analyzeAllPods when resize is actually needed
 
 	return ResourceScalingDecision{CPU: cpuDecision, Memory: memoryDecision}
 }
 
+// sizedLimit returns a resource's limit, or its request when no limit is
+// set, in millicores for CPU and MB for memory; zero when neither is set
+func sizedLimit(current corev1.ResourceRequirements, name corev1.ResourceName) float64 {
+	quantity, exists := current.Limits[name]
+	if !exists || quantity.IsZero() {
+		quantity = current.Requests[name]
+	}
+	if name == corev1.ResourceCPU {
+		return float64(quantity.MilliValue())
+	}
+	return float64(quantity.Value()) / (1024 * 1024) // Convert to MB
+}
+
+// hasSizedResource reports whether a resource has a limit or request set
+func hasSizedResource(current corev1.ResourceRequirements, name corev1.ResourceName) bool {
+	return sizedLimit(current, name) > 0
+}
+
+// resourceUtilization returns CPU and memory usage as a share of the current
+// limits, or of the requests when no limits are set
+func resourceUtilization(usage metrics.Metrics, current corev1.ResourceRequirements) (cpu, memory float64) {
+	if limit := sizedLimit(current, corev1.ResourceCPU); limit > 0 {
+		cpu = usage.CPUMilli / limit
+	}
+	if limit := sizedLimit(current, corev1.ResourceMemory); limit > 0 {
+		memory = usage.MemMB / limit
+	}
+	return cpu, memory
+}
+
+// raiseThrottledCPU makes sure a throttled container gets more CPU than it has now.
+// Usage-based sizing can propose less CPU for a throttled container because the
+// throttling itself caps its usage, so the CPU limit is raised by the throttled
+// percentage (bounded by maxCPULimit) and the request is never reduced.
+func raiseThrottledCPU(current, proposed corev1.ResourceRequirements, throttledPercent float64, maxCPULimit int64) corev1.ResourceRequirements {
+	currentLimit := current.Limits.Cpu().MilliValue()
+	if currentLimit == 0 {
+		return proposed
+	}
+
+	target := int64(float64(currentLimit) * (1 + throttledPercent/100))
+	if maxCPULimit > 0 && target > maxCPULimit {
+		target = maxCPULimit
+	}
+
+	result := *proposed.DeepCopy()
+	if result.Requests == nil {
+		result.Requests = corev1.ResourceList{}
+	}
+	if result.Limits == nil {
+		result.Limits = corev1.ResourceList{}
+	}
+
+	if result.Limits.Cpu().MilliValue() < target {
+		result.Limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(target, resource.DecimalSI)
+	}
+
+	currentRequest := current.Requests.Cpu().MilliValue()
+	if currentRequest == currentLimit {
+		// Keep requests equal to limits for Guaranteed pods
+		result.Requests[corev1.ResourceCPU] = result.Limits[corev1.ResourceCPU]
+	} else if result.Requests.Cpu().MilliValue() < currentRequest {
+		result.Requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(currentRequest, resource.DecimalSI)
+	}
+
+	return result
+}
+
 // Helper function to convert ScalingDecision to string
 func scalingDecisionString(d ScalingDecision) string {
 	switch d {
@@ -1468,8 +2551,7 @@ func scalingDecisionString(d ScalingDecision) string {
 }
 
 // calculateOptimalResourcesWithDecision calculates resources based on scaling decision
-func (r *AdaptiveRightSizer) calculateOptimalResourcesWithDecision(usage metrics.Metrics, decision ResourceScalingDecision) corev1.ResourceRequirements {
-	cfg := config.Get()
+func (r *AdaptiveRightSizer) calculateOptimalResourcesWithDecision(usage metrics.Metrics, decision ResourceScalingDecision, cfg *config.Config) corev1.ResourceRequirements {
 
 	var cpuRequest, memRequest int64
 
@@ -1571,12 +2653,32 @@ func (r *AdaptiveRightSizer) calculateOptimalResourcesWithDecision(usage metrics
 	}
 }
 
-// calculateOptimalResourcesWithPrediction calculates resources using both current usage and future predictions
-func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context.Context, namespace, podName, containerName string, usage metrics.Metrics, decision ResourceScalingDecision) corev1.ResourceRequirements {
-	cfg := config.Get()
+// selectPrediction returns the most confident prediction for a container's
+// resource, or nil, and exports which model was selected
+func (r *AdaptiveRightSizer) selectPrediction(ctx context.Context, namespace, podName, containerName, resourceType string, horizon time.Duration) *predictor.ResourcePrediction {
+	selection, err := r.Predictor.SelectModel(ctx, namespace, podName, containerName, resourceType, horizon)
+	if err != nil {
+		logger.Debug("No %s prediction for %s/%s/%s: %v", resourceType, namespace, podName, containerName, err)
+		return nil
+	}
+	if r.OperatorMetrics != nil && len(selection.Confidences) > 0 {
+		selected := ""
+		if selection.Best != nil {
+			selected = string(selection.Best.Method)
+		}
+		confidences := make(map[string]float64, len(selection.Confidences))
+		for method, confidence := range selection.Confidences {
+			confidences[string(method)] = confidence
+		}
+		r.OperatorMetrics.RecordPredictionModelSelection(namespace, podName, containerName, resourceType, selected, confidences)
+	}
+	return selection.Best
+}
 
+// calculateOptimalResourcesWithPrediction calculates resources using both current usage and future predictions
+func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context.Context, namespace, podName, containerName string, usage metrics.Metrics, aggregation usageAggregation, decision ResourceScalingDecision, cfg *config.Config, explanation *explain.Decision, observe bool) corev1.ResourceRequirements {
 	// First, collect current usage data for predictions
-	if r.Predictor != nil {
+	if observe && r.Predictor != nil {
 		// Store current metrics as historical data
 		timestamp := time.Now()
 		if err := r.Predictor.StoreDataPoint(namespace, podName, containerName, "cpu", usage.CPUMilli, timestamp); err != nil {
@@ -1587,20 +2689,23 @@ func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context
 		}
 	}
 
+	// Size from the aggregated history, e.g. the memory peak, rather than the latest sample
+	usage = r.windowUsage(ctx, namespace, podName, containerName, usage, aggregation, explanation)
+
 	// Get predictions for future resource needs
 	var cpuPrediction, memoryPrediction *predictor.ResourcePrediction
 	if r.Predictor != nil {
 		// Get predictions for the next scheduling interval
 		predictionHorizon := r.Interval * 2 // Look ahead 2 intervals
 
-		if pred, err := r.Predictor.GetBestPrediction(ctx, namespace, podName, containerName, "cpu", predictionHorizon); err == nil {
+		if pred := r.selectPrediction(ctx, namespace, podName, containerName, "cpu", predictionHorizon); pred != nil {
 			cpuPrediction = pred
-			logger.Debug("CPU prediction for %s/%s/%s: %.2f millicores (confidence: %.2f)", namespace, podName, containerName, pred.Value, pred.Confidence)
+			logger.Debug("CPU prediction for %s/%s/%s: %.2f millicores (%s, confidence: %.2f)", namespace, podName, containerName, pred.Value, pred.Method, pred.Confidence)
 		}
 
-		if pred, err := r.Predictor.GetBestPrediction(ctx, namespace, podName, containerName, "memory", predictionHorizon); err == nil {
+		if pred := r.selectPrediction(ctx, namespace, podName, containerName, "memory", predictionHorizon); pred != nil {
 			memoryPrediction = pred
-			logger.Debug("Memory prediction for %s/%s/%s: %.2f MB (confidence: %.2f)", namespace, podName, containerName, pred.Value, pred.Confidence)
+			logger.Debug("Memory prediction for %s/%s/%s: %.2f MB (%s, confidence: %.2f)", namespace, podName, containerName, pred.Value, pred.Method, pred.Confidence)
 		}
 	}
 
@@ -1625,6 +2730,7 @@ func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context
 			r.OperatorMetrics.UpdateResourceTrendPrediction(namespace, podName, containerName, "cpu", r.Interval.String(), cpuPrediction.Value)
 		}
 	}
+	explainPrediction(explanation, "cpu", cpuPrediction, cpuRequest > baseCpuRequest)
 
 	// Memory calculation with prediction enhancement
 	baseMemRequest := r.calculateBaseMemoryRequest(usage, decision, cfg)
@@ -1645,6 +2751,7 @@ func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context
 			r.OperatorMetrics.UpdateResourceTrendPrediction(namespace, podName, containerName, "memory", r.Interval.String(), memoryPrediction.Value)
 		}
 	}
+	explainPrediction(explanation, "memory", memoryPrediction, memRequest > baseMemRequest)
 
 	// Apply minimum resource constraints
 	cpuRequest = r.applyMinimumCpuConstraints(usage, cpuRequest, cfg)
@@ -1654,6 +2761,14 @@ func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context
 	cpuLimit := int64(float64(cpuRequest)*cfg.CPULimitMultiplier) + cfg.CPULimitAddition
 	memLimit := int64(float64(memRequest)*cfg.MemoryLimitMultiplier) + cfg.MemoryLimitAddition
 
+	// Size the limits of resources with a limit window from their spikes
+	if peak, ok := r.peakLimit(namespace, podName, containerName, "cpu", cfg); ok {
+		cpuLimit = peak + cfg.CPULimitAddition
+	}
+	if peak, ok := r.peakLimit(namespace, podName, containerName, "memory", cfg); ok {
+		memLimit = peak + cfg.MemoryLimitAddition
+	}
+
 	// Apply maximum caps and ensure limits are not less than requests
 	cpuLimit = r.applyMaximumCpuLimits(cpuRequest, cpuLimit, cfg)
 	memLimit = r.applyMaximumMemoryLimits(memRequest, memLimit, cfg)
@@ -1676,6 +2791,20 @@ func (r *AdaptiveRightSizer) calculateOptimalResourcesWithPrediction(ctx context
 	}
 }
 
+// percentileUsage replaces the latest usage sample with the given percentile of the
+// container's recorded history
+func (r *AdaptiveRightSizer) percentileUsage(ctx context.Context, namespace, podName, containerName string, usage metrics.Metrics, percentile int, window time.Duration) metrics.Metrics {
+	if percentile <= 0 {
+		return usage
+	}
+	return r.aggregateUsage(ctx, namespace, podName, containerName, usage, usageAggregation{
+		CPU:        config.AggregationPercentile,
+		Memory:     config.AggregationPercentile,
+		Percentile: percentile,
+		Window:     window,
+	})
+}
+
 // Helper methods for resource calculation
 func (r *AdaptiveRightSizer) calculateBaseCpuRequest(usage metrics.Metrics, decision ResourceScalingDecision, cfg *config.Config) int64 {
 	var cpuRequest int64
@@ -2014,7 +3143,7 @@ func getQoSClass(pod *corev1.Pod) corev1.PodQOSClass {
 }
 
 // SetupAdaptiveRightSizer creates and starts the adaptive rightsizer
-func SetupAdaptiveRightSizer(mgr manager.Manager, provider metrics.Provider, auditLogger *audit.AuditLogger, dryRun bool, dashboardClient *dashboardapi.Client) (*predictor.Engine, error) {
+func SetupAdaptiveRightSizer(mgr manager.Manager, provider metrics.Provider, auditLogger *audit.AuditLogger, dryRun bool, dashboardClient *dashboardapi.Client, eventBus *events.EventBus, anomalies AnomalyGate, explanations *explain.Store, pauses *pause.State) (*AdaptiveRightSizer, error) {
 	cfg := config.Get()
 
 	// Get the rest config from the manager
@@ -2031,13 +3160,33 @@ func SetupAdaptiveRightSizer(mgr manager.Manager, provider metrics.Provider, aud
 	if cfg.PredictionEnabled {
 		predConfig := predictor.DefaultConfig()
 		predConfig.CollectionInterval = cfg.ResizeInterval // Align with resize interval
-		predConfig.ConfidenceThreshold = 0.6               // Default confidence threshold
+		predConfig.ConfidenceThreshold = cfg.PredictionConfidenceThreshold
+		// Keep enough history for the percentile algorithm's window
+		predConfig.HistoricalDataRetention = predictionRetention(cfg)
+		if cfg.PredictionStorage != "" {
+			predConfig.StorageDriver = cfg.PredictionStorage
+		}
+		predConfig.StoragePath = cfg.PredictionStoragePath
+		predConfig.PrometheusURL = cfg.PrometheusURL
+		if cfg.ExternalPredictorURL != "" {
+			// Consult the user's forecasting model alongside the built-in methods
+			predConfig.ExternalPredictorURL = cfg.ExternalPredictorURL
+			predConfig.ExternalPredictorTimeout = cfg.ExternalPredictorTimeout
+			predConfig.ExternalPredictorToken = cfg.ExternalPredictorToken
+			predConfig.EnabledMethods = append(predConfig.EnabledMethods, predictor.PredictionMethodExternal)
+		}
 
 		predictorEngine, err = predictor.NewEngine(predConfig)
+		if err != nil && predConfig.StorageDriver != "memory" {
+			// Losing history on restart is better than running without predictions
+			logger.Warn("Failed to open %s history storage, falling back to memory: %v", predConfig.StorageDriver, err)
+			predConfig.StorageDriver = "memory"
+			predictorEngine, err = predictor.NewEngine(predConfig)
+		}
 		if err != nil {
 			logger.Warn("Failed to create prediction engine: %v", err)
 		} else {
-			logger.Info("🔮 Prediction engine initialized with %d methods", len(predConfig.EnabledMethods))
+			logger.Info("🔮 Prediction engine initialized with %d methods (%s storage)", len(predConfig.EnabledMethods), predConfig.StorageDriver)
 		}
 	}
 
@@ -2048,6 +3197,7 @@ func SetupAdaptiveRightSizer(mgr manager.Manager, provider metrics.Provider, aud
 		MetricsProvider: provider,
 		OperatorMetrics: metrics.NewOperatorMetrics(),
 		AuditLogger:     auditLogger,
+		EventRecorder:   mgr.GetEventRecorderFor("right-sizer"),
 		Config:          cfg,
 		Predictor:       predictorEngine,
 		Interval:        cfg.ResizeInterval,
@@ -2055,7 +3205,21 @@ func SetupAdaptiveRightSizer(mgr manager.Manager, provider metrics.Provider, aud
 		resizeCache:     make(map[string]*ResizeDecisionCache),
 		cacheExpiry:     5 * time.Minute, // Cache entries for 5 minutes
 		DashboardClient: dashboardClient,
-	}
+		EventBus:        eventBus,
+		Recommendations: &RecommendationWriter{Client: mgr.GetClient(), Predictor: predictorEngine, Pricing: cost.NewClient()},
+		Exporter:        &GitOpsExporter{Client: mgr.GetClient()},
+		Maintenance:     NewMaintenanceScheduler(mgr.GetClient()),
+		Anomalies:       anomalies,
+		Explanations:    explanations,
+		Pauses:          pauses,
+		Safety:          NewSafetyTuner(mgr.GetClient()),
+		CustomMetrics:   metrics.NewCustomMetricsProvider(restConfig, mgr.GetRESTMapper(), clientSet.Discovery()),
+	}
+	rightsizer.Validator = validation.NewResourceValidator(mgr.GetClient(), clientSet, cfg, rightsizer.OperatorMetrics)
+	rightsizer.Jobs = NewJobSizer(mgr.GetClient(), rightsizer.Recommendations, rightsizer.EventRecorder)
+	rightsizer.Canaries = NewCanaryRollouts()
+	rightsizer.Canaries.EventRecorder = rightsizer.EventRecorder
+	rightsizer.Canaries.Metrics = rightsizer.OperatorMetrics
 
 	// Set metrics provider on dashboard client for heartbeat
 	if dashboardClient != nil {
@@ -2080,7 +3244,7 @@ func SetupAdaptiveRightSizer(mgr manager.Manager, provider metrics.Provider, aud
 		}
 	}()
 
-	return predictorEngine, nil
+	return rightsizer, nil
 }
 
 // ensureSafeResourcePatchAdaptive ensures the patch never tries to remove or add resource fields
@@ -2091,6 +3255,14 @@ func ensureSafeResourcePatchAdaptive(current, desired corev1.ResourceRequirement
 
 	result := corev1.ResourceRequirements{}
 
+	// Resource claims (DRA) are never changed, whatever desired specifies
+	if len(current.Claims) > 0 {
+		result.Claims = append([]corev1.ResourceClaim(nil), current.Claims...)
+		for _, claim := range current.Claims {
+			logger.Info("   🔒 Preserving resource claim %s", claim.Name)
+		}
+	}
+
 	// Handle requests - preserve ALL existing resource types
 	if len(current.Requests) > 0 {
 		result.Requests = make(corev1.ResourceList)
@@ -1,12 +1,20 @@
 package controllers
 
 import (
+	"context"
+	"fmt"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"right-sizer/config"
 	"right-sizer/metrics"
+	"right-sizer/predictor"
 	"strings"
 	"testing"
+	"time"
+
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 // minimal struct reuse: instantiate with Config only for helper methods
@@ -115,3 +123,328 @@ func TestGetAdjustmentReasonWithDecision(t *testing.T) {
 		t.Fatalf("expected default optimization reason got %s", none)
 	}
 }
+
+// TestContainerUsage verifies per-container metrics are preferred over pod totals
+func TestContainerUsage(t *testing.T) {
+	pod := metrics.Metrics{CPUMilli: 300, MemMB: 600}
+	perContainer := metrics.ContainerMetrics{
+		"app":     {CPUMilli: 280, MemMB: 550},
+		"sidecar": {CPUMilli: 20, MemMB: 50},
+	}
+
+	if got := containerUsage(pod, perContainer, 2, "sidecar"); got.CPUMilli != 20 || got.MemMB != 50 {
+		t.Fatalf("expected sidecar's own usage, got %+v", got)
+	}
+	// Missing container falls back to an even split of the pod usage
+	if got := containerUsage(pod, nil, 2, "app"); got.CPUMilli != 150 || got.MemMB != 300 {
+		t.Fatalf("expected evenly split pod usage, got %+v", got)
+	}
+	// Single-container pods use the pod usage directly
+	if got := containerUsage(pod, nil, 1, "app"); got != pod {
+		t.Fatalf("expected pod usage for single container, got %+v", got)
+	}
+}
+
+// TestPercentileUsage verifies recorded history replaces the latest sample once enough exists
+func TestPercentileUsage(t *testing.T) {
+	predCfg := predictor.DefaultConfig()
+	predCfg.MinDataPoints = 5
+	engine, err := predictor.NewEngine(predCfg)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Predictor = engine
+	latest := metrics.Metrics{CPUMilli: 50, MemMB: 100}
+
+	// Without history the latest sample is used
+	if got := r.percentileUsage(context.Background(), "ns", "pod", "app", latest, 95, time.Hour); got != latest {
+		t.Fatalf("expected latest sample without history, got %+v", got)
+	}
+
+	now := time.Now()
+	for i := 1; i <= 10; i++ {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		_ = engine.StoreDataPoint("ns", "pod", "app", "cpu", float64(i*100), ts)
+		_ = engine.StoreDataPoint("ns", "pod", "app", "memory", float64(i*10), ts)
+	}
+
+	got := r.percentileUsage(context.Background(), "ns", "pod", "app", latest, 90, time.Hour)
+	if got.CPUMilli != 910 || got.MemMB != 91 {
+		t.Fatalf("expected P90 of history (910m, 91MB), got %+v", got)
+	}
+}
+
+// TestCheckScalingThresholdsThrottling verifies throttling forces a CPU scale up at modest usage
+func TestCheckScalingThresholdsThrottling(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.CPUThrottleThreshold = 25
+	config.Global = cfg
+
+	r := newAdaptiveTestRig(cfg)
+	current := corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000m"), corev1.ResourceMemory: resource.MustParse("1000Mi")}}
+
+	// 50% CPU usage alone does not scale
+	if d := r.checkScalingThresholds(metrics.Metrics{CPUMilli: 500, MemMB: 500, CPUThrottled: 10}, current, cfg); d.CPU != ScaleNone {
+		t.Fatalf("expected no CPU scaling below throttle threshold, got %v", d.CPU)
+	}
+	if d := r.checkScalingThresholds(metrics.Metrics{CPUMilli: 500, MemMB: 500, CPUThrottled: 40}, current, cfg); d.CPU != ScaleUp {
+		t.Fatalf("expected CPU scale up when throttled, got %v", d.CPU)
+	}
+
+	// A zero threshold disables the throttling signal
+	cfg.CPUThrottleThreshold = 0
+	if d := r.checkScalingThresholds(metrics.Metrics{CPUMilli: 500, MemMB: 500, CPUThrottled: 90}, current, cfg); d.CPU != ScaleNone {
+		t.Fatalf("expected throttling ignored when disabled, got %v", d.CPU)
+	}
+}
+
+// TestRaiseThrottledCPU verifies the CPU limit grows by the throttled share and requests never shrink
+func TestRaiseThrottledCPU(t *testing.T) {
+	current := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("400m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000m")},
+	}
+	proposed := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("600m")},
+	}
+
+	got := raiseThrottledCPU(current, proposed, 40, 4000)
+	if got.Limits.Cpu().MilliValue() != 1400 || got.Requests.Cpu().MilliValue() != 400 {
+		t.Fatalf("expected 400m/1400m, got %s/%s", got.Requests.Cpu(), got.Limits.Cpu())
+	}
+
+	// Bounded by the maximum CPU limit
+	got = raiseThrottledCPU(current, proposed, 40, 1200)
+	if got.Limits.Cpu().MilliValue() != 1200 {
+		t.Fatalf("expected limit capped at 1200m, got %s", got.Limits.Cpu())
+	}
+
+	// Guaranteed containers keep requests equal to limits
+	guaranteed := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+	}
+	got = raiseThrottledCPU(guaranteed, proposed, 50, 4000)
+	if got.Requests.Cpu().MilliValue() != 750 || got.Limits.Cpu().MilliValue() != 750 {
+		t.Fatalf("expected 750m/750m, got %s/%s", got.Requests.Cpu(), got.Limits.Cpu())
+	}
+}
+
+func TestResizeCooldown(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	if got := resizeCooldown(pod, 5*time.Minute); got != 5*time.Minute {
+		t.Fatalf("expected default cooldown, got %v", got)
+	}
+	pod.Annotations = map[string]string{cooldownAnnotation: "30m"}
+	if got := resizeCooldown(pod, 5*time.Minute); got != 30*time.Minute {
+		t.Fatalf("expected annotation cooldown, got %v", got)
+	}
+	pod.Annotations[cooldownAnnotation] = "soon"
+	if got := resizeCooldown(pod, 5*time.Minute); got != 5*time.Minute {
+		t.Fatalf("expected default cooldown for invalid annotation, got %v", got)
+	}
+}
+
+// TestFilterCoolingDown verifies recently resized containers are not resized again
+func TestFilterCoolingDown(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "recent", Namespace: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "short", Namespace: "default", Annotations: map[string]string{cooldownAnnotation: "0s"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "fresh", Namespace: "default"}},
+	}
+	r.recordResize("default", "recent", "app")
+	r.recordResize("default", "short", "app")
+	// Logging a decision must not reset the cooldown
+	r.cacheResizeDecision("default/recent/app", "100m", "200m", "128Mi", "256Mi")
+
+	updates := []ResourceUpdate{
+		{Namespace: "default", Name: "recent", ContainerName: "app"},
+		{Namespace: "default", Name: "short", ContainerName: "app"},
+		{Namespace: "default", Name: "fresh", ContainerName: "app"},
+	}
+	got := r.filterCoolingDown(updates, pods)
+	if len(got) != 2 || got[0].Name != "short" || got[1].Name != "fresh" {
+		t.Fatalf("expected only the container in cooldown to be suppressed, got %+v", got)
+	}
+}
+
+// fakeRangeProvider serves a fixed usage history
+type fakeRangeProvider struct {
+	history metrics.ContainerHistory
+}
+
+func (f *fakeRangeProvider) FetchPodMetrics(ctx context.Context, namespace, podName string) (metrics.Metrics, error) {
+	return metrics.Metrics{}, nil
+}
+
+func (f *fakeRangeProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (metrics.ContainerMetrics, error) {
+	return metrics.ContainerMetrics{}, nil
+}
+
+func (f *fakeRangeProvider) FetchContainerHistory(ctx context.Context, namespace, podName, container string, start, end time.Time) (metrics.ContainerHistory, error) {
+	return f.history, nil
+}
+
+// TestPercentileUsageFromProviderHistory verifies range-capable providers fill in missing history
+func TestPercentileUsageFromProviderHistory(t *testing.T) {
+	var history metrics.ContainerHistory
+	now := time.Now()
+	for i := 1; i <= 10; i++ {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		history.CPUMilli = append(history.CPUMilli, metrics.Sample{Timestamp: ts, Value: float64(i * 100)})
+		history.MemMB = append(history.MemMB, metrics.Sample{Timestamp: ts, Value: float64(i * 10)})
+	}
+
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.MetricsProvider = &fakeRangeProvider{history: history}
+	got := r.percentileUsage(context.Background(), "ns", "pod", "app", metrics.Metrics{CPUMilli: 50, MemMB: 100}, 90, time.Hour)
+	if got.CPUMilli != 910 || got.MemMB != 91 {
+		t.Fatalf("expected P90 of provider history (910m, 91MB), got %+v", got)
+	}
+}
+
+// batchUsageProvider serves fixed usage for every pod, counting how it is asked
+type batchUsageProvider struct {
+	containerUsageProvider
+	batchCalls, podCalls int
+}
+
+func (p *batchUsageProvider) FetchPodMetrics(ctx context.Context, namespace, podName string) (metrics.Metrics, error) {
+	p.podCalls++
+	return p.containerUsageProvider.FetchPodMetrics(ctx, namespace, podName)
+}
+
+func (p *batchUsageProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (metrics.ContainerMetrics, error) {
+	p.podCalls++
+	return p.containerUsageProvider.FetchContainerMetrics(ctx, namespace, podName)
+}
+
+func (p *batchUsageProvider) FetchAllContainerMetrics(ctx context.Context) (map[string]metrics.ContainerMetrics, error) {
+	p.batchCalls++
+	return map[string]metrics.ContainerMetrics{
+		"default/pod-a": metrics.ContainerMetrics(p.containerUsageProvider),
+		"default/pod-b": metrics.ContainerMetrics(p.containerUsageProvider),
+	}, nil
+}
+
+// TestAnalyzeAllPodsBatchesMetrics verifies a cycle fetches the usage of all
+// pods with one call when the provider supports it
+func TestAnalyzeAllPodsBatchesMetrics(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).Build()
+	provider := &batchUsageProvider{containerUsageProvider: containerUsageProvider{"test-container": {CPUMilli: 900, MemMB: 900}}}
+	r.MetricsProvider = provider
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+
+	pods := []corev1.Pod{
+		*createTestPod("pod-a", "default", "100m", "128Mi", "200m", "256Mi"),
+		*createTestPod("pod-b", "default", "100m", "128Mi", "200m", "256Mi"),
+	}
+	updates, _ := r.analyzeAllPods(context.Background(), pods)
+	if len(updates) != 2 {
+		t.Fatalf("expected both pods sized, got %d updates", len(updates))
+	}
+	if provider.batchCalls != 1 || provider.podCalls != 0 {
+		t.Errorf("expected one batched call and no per-pod calls, got %d and %d", provider.batchCalls, provider.podCalls)
+	}
+}
+
+// TestNextAnalysisBatchRotates verifies capped cycles resume after the last
+// pod analyzed, so every pod is reached in turn
+func TestNextAnalysisBatchRotates(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	var pods []corev1.Pod
+	for _, name := range []string{"e", "b", "d", "a", "c"} {
+		pods = append(pods, *createTestPod(name, "default", "100m", "128Mi", "200m", "256Mi"))
+	}
+
+	var seen []string
+	for range 3 {
+		for _, pod := range r.nextAnalysisBatch(pods, 2) {
+			seen = append(seen, pod.Name)
+		}
+	}
+	if got := strings.Join(seen, ","); got != "a,b,c,d,e,a" {
+		t.Errorf("expected the pods in turn, got %s", got)
+	}
+
+	if got := r.nextAnalysisBatch(pods, 0); len(got) != len(pods) || r.analysisCursor != "" {
+		t.Errorf("expected every pod without a cap, got %d", len(got))
+	}
+}
+
+// TestAnalyzeAllPodsConcurrently verifies pods analyzed by several workers
+// yield their updates in pod order
+func TestAnalyzeAllPodsConcurrently(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	cfg := config.Get()
+	cfg.SetAnalysisConcurrency(4, 0)
+	defer cfg.SetAnalysisConcurrency(config.GetDefaults().MaxAnalysisWorkers, 0)
+
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).Build()
+	r.MetricsProvider = containerUsageProvider{"test-container": {CPUMilli: 900, MemMB: 900}}
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+
+	var pods []corev1.Pod
+	for i := range 20 {
+		pods = append(pods, *createTestPod(fmt.Sprintf("pod-%02d", i), "default", "100m", "128Mi", "200m", "256Mi"))
+	}
+	updates, _ := r.analyzeAllPods(context.Background(), pods)
+	if len(updates) != len(pods) {
+		t.Fatalf("expected every pod sized, got %d updates", len(updates))
+	}
+	for i, update := range updates {
+		if update.Name != pods[i].Name {
+			t.Fatalf("expected updates in pod order, got %s at %d", update.Name, i)
+		}
+	}
+}
+
+// TestSetInPlaceEnabled verifies in-place resizing can be switched at runtime
+func TestSetInPlaceEnabled(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	if !r.InPlaceEnabled() {
+		t.Fatalf("expected in-place resizing until detection says otherwise")
+	}
+	r.SetInPlaceEnabled(false)
+	if r.InPlaceEnabled() {
+		t.Fatalf("expected in-place resizing to be disabled")
+	}
+	r.SetInPlaceEnabled(true)
+	if !r.InPlaceEnabled() {
+		t.Fatalf("expected in-place resizing to be enabled again")
+	}
+}
+
+// TestReloadConfig verifies a changed configuration reaches the settings the
+// resize loop keeps of its own
+func TestReloadConfig(t *testing.T) {
+	cfg := config.GetDefaults()
+	engine, err := predictor.NewEngine(predictor.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	r := newAdaptiveTestRig(cfg)
+	r.Interval = cfg.ResizeInterval
+	r.Predictor = engine
+	r.schedule.sync([]time.Duration{r.Interval}, time.Now())
+
+	cfg.ResizeInterval = 10 * time.Millisecond
+	cfg.DryRun = true
+	r.reloadConfig(cfg)
+	if r.Interval != 10*time.Millisecond || !r.DryRun {
+		t.Fatalf("expected a 10ms interval in dry-run mode, got %v and %v", r.Interval, r.DryRun)
+	}
+	if wait := r.schedule.wait(time.Now(), time.Hour); wait > 10*time.Millisecond {
+		t.Fatalf("expected the loop to follow the new interval, waits %v", wait)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"right-sizer/config"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// pacingIncrease is the share of the configured rate a healthy resize
+	// call adds to the resize rate
+	pacingIncrease = 0.1
+	// pacingDecrease is what congestion multiplies the resize rate by
+	pacingDecrease = 0.5
+)
+
+// applyPacer paces the resizes of the apply phase by the API server with
+// additive increase, multiplicative decrease (AIMD) of the resize rate: each
+// healthy resize call adds a step to the rate, throttling, server errors and
+// calls slower than the latency target halve it. The rate carries over
+// between runs, so a congested control plane is not hit at full speed again.
+type applyPacer struct {
+	mu     sync.Mutex
+	base   time.Duration // configured delay between pods
+	pacing config.ApplyPacingConfig
+	rate   float64 // resizes per second; zero until configured
+}
+
+// configure bounds the pacer by the configuration of the current run
+func (p *applyPacer) configure(base time.Duration, pacing config.ApplyPacingConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.base = base
+	p.pacing = pacing
+	if p.rate == 0 || !pacing.Adaptive {
+		p.rate = 1 / base.Seconds()
+	}
+	p.rate = p.clamp(p.rate)
+}
+
+// observe adapts the resize rate to a resize call that took latency and
+// failed with err, if it failed. Errors that do not point at an overloaded
+// API server, e.g. a rejected patch, leave the rate as it is.
+func (p *applyPacer) observe(latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rate == 0 || !p.pacing.Adaptive {
+		return
+	}
+	switch {
+	case apiServerCongested(err) || latency > p.pacing.LatencyTarget:
+		p.rate *= pacingDecrease
+		if seconds, ok := k8serrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			p.rate = math.Min(p.rate, 1/float64(seconds))
+		}
+	case err == nil:
+		p.rate += pacingIncrease / p.base.Seconds()
+	}
+	p.rate = p.clamp(p.rate)
+}
+
+// clamp keeps a rate between the maximum and minimum delays, widened to the
+// configured delay should it lie outside them
+func (p *applyPacer) clamp(rate float64) float64 {
+	base := 1 / p.base.Seconds()
+	lowest, highest := base, base
+	if p.pacing.Adaptive {
+		lowest = math.Min(base, 1/p.pacing.MaxDelay.Seconds())
+		highest = math.Max(base, 1/p.pacing.MinDelay.Seconds())
+	}
+	return math.Max(lowest, math.Min(highest, rate))
+}
+
+// podDelay is the delay to keep before the next resize
+func (p *applyPacer) podDelay() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return time.Duration(float64(time.Second) / p.rate)
+}
+
+// batchDelay scales the configured delay between batches like the delay
+// between pods, never beyond the maximum delay unless configured longer
+func (p *applyPacer) batchDelay(base time.Duration) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.pacing.Adaptive {
+		return base
+	}
+	scaled := time.Duration(float64(base) / (p.rate * p.base.Seconds()))
+	if longest := max(base, p.pacing.MaxDelay); scaled > longest {
+		return longest
+	}
+	return scaled
+}
+
+// apiServerCongested reports whether err is the API server throttling
+// requests or failing with a server error
+func apiServerCongested(err error) bool {
+	if err == nil {
+		return false
+	}
+	if k8serrors.IsTooManyRequests(err) || k8serrors.IsServerTimeout(err) || k8serrors.IsTimeout(err) {
+		return true
+	}
+	var status k8serrors.APIStatus
+	return errors.As(err, &status) && status.Status().Code >= http.StatusInternalServerError
+}
+
+// sleepContext waits for d, returning false if ctx is canceled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
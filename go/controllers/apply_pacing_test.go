@@ -0,0 +1,133 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// TestApplyPacerAIMD verifies healthy calls shorten the delay step by step
+// down to the minimum and congestion doubles it up to the maximum
+func TestApplyPacerAIMD(t *testing.T) {
+	var p applyPacer
+	p.configure(500*time.Millisecond, config.GetDefaults().ApplyPacing)
+	if got := p.podDelay(); got != 500*time.Millisecond {
+		t.Fatalf("expected to start from the configured delay, got %v", got)
+	}
+
+	p.observe(10*time.Millisecond, nil)
+	if got := p.podDelay(); got >= 500*time.Millisecond {
+		t.Errorf("expected a healthy call to shorten the delay, got %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		p.observe(10*time.Millisecond, nil)
+	}
+	if got := p.podDelay(); got != 100*time.Millisecond {
+		t.Errorf("expected the minimum delay while idle, got %v", got)
+	}
+
+	for _, congestion := range []struct {
+		name    string
+		latency time.Duration
+		err     error
+	}{
+		{"slow call", 2 * time.Second, nil},
+		{"server error", 10 * time.Millisecond, k8serrors.NewInternalError(errors.New("etcd unavailable"))},
+		{"unavailable", 10 * time.Millisecond, k8serrors.NewServiceUnavailable("overloaded")},
+		{"timeout", 10 * time.Millisecond, k8serrors.NewTimeoutError("request timed out", 0)},
+	} {
+		before := p.podDelay()
+		p.observe(congestion.latency, congestion.err)
+		if got := p.podDelay(); got != 2*before {
+			t.Errorf("%s: expected the delay to double from %v, got %v", congestion.name, before, got)
+		}
+	}
+
+	before := p.podDelay()
+	p.observe(10*time.Millisecond, k8serrors.NewBadRequest("invalid patch"))
+	if got := p.podDelay(); got != before {
+		t.Errorf("expected a rejected patch to keep the delay at %v, got %v", before, got)
+	}
+
+	p.observe(10*time.Millisecond, k8serrors.NewTooManyRequests("throttled", 10))
+	if got := p.podDelay(); got != 10*time.Second {
+		t.Errorf("expected throttling to wait as long as the API server asks, got %v", got)
+	}
+	for i := 0; i < 10; i++ {
+		p.observe(10*time.Millisecond, k8serrors.NewTooManyRequests("throttled", 0))
+	}
+	if got := p.podDelay(); got != 30*time.Second {
+		t.Errorf("expected the maximum delay while throttled, got %v", got)
+	}
+	if got := p.batchDelay(5 * time.Second); got != 30*time.Second {
+		t.Errorf("expected the batch delay to stop at the maximum, got %v", got)
+	}
+
+	// The next run starts where the last one left off
+	p.configure(500*time.Millisecond, config.GetDefaults().ApplyPacing)
+	if got := p.podDelay(); got != 30*time.Second {
+		t.Errorf("expected the delay to carry over between runs, got %v", got)
+	}
+}
+
+// TestApplyPacerBatchDelay verifies the batch delay scales with the pod delay
+func TestApplyPacerBatchDelay(t *testing.T) {
+	var p applyPacer
+	p.configure(500*time.Millisecond, config.GetDefaults().ApplyPacing)
+	if got := p.batchDelay(5 * time.Second); got != 5*time.Second {
+		t.Errorf("expected the configured batch delay, got %v", got)
+	}
+	p.observe(2*time.Second, nil)
+	if got := p.batchDelay(5 * time.Second); got != 10*time.Second {
+		t.Errorf("expected the batch delay to double with the pod delay, got %v", got)
+	}
+}
+
+// TestApplyPacerFixed verifies the configured delays are kept when pacing
+// is not adaptive
+func TestApplyPacerFixed(t *testing.T) {
+	var p applyPacer
+	pacing := config.GetDefaults().ApplyPacing
+	pacing.Adaptive = false
+	p.configure(500*time.Millisecond, pacing)
+	p.observe(5*time.Second, k8serrors.NewTooManyRequests("throttled", 10))
+	p.observe(10*time.Millisecond, nil)
+	if got := p.podDelay(); got != 500*time.Millisecond {
+		t.Errorf("expected the fixed delay, got %v", got)
+	}
+	if got := p.batchDelay(5 * time.Second); got != 5*time.Second {
+		t.Errorf("expected the fixed batch delay, got %v", got)
+	}
+}
+
+// TestSleepContext verifies waits end early when the context is canceled
+func TestSleepContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if sleepContext(ctx, time.Hour) {
+		t.Error("expected a canceled context to end the wait")
+	}
+	if !sleepContext(context.Background(), time.Millisecond) {
+		t.Error("expected the wait to complete")
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
+
+// Annotations carrying the requested share of a node's allocatable once the
+// planned resizes are applied, for consolidation tooling to read
+const (
+	projectedCPUAnnotation    = "rightsizer.io/projected-cpu-utilization"
+	projectedMemoryAnnotation = "rightsizer.io/projected-memory-utilization"
+)
+
+// consolidationTaints mark nodes Karpenter or the Cluster Autoscaler is
+// about to drain and remove
+var consolidationTaints = []string{
+	"karpenter.sh/disrupted",               // Karpenter v1
+	"karpenter.sh/disruption",              // Karpenter v1beta1
+	"ToBeDeletedByClusterAutoscaler",       // Cluster Autoscaler scale-down
+	"DeletionCandidateOfClusterAutoscaler", // Cluster Autoscaler soft taint
+}
+
+// AutoscalerCoordinator keeps resizes from fighting node autoscalers. Both
+// Karpenter and the Cluster Autoscaler consolidate nodes by their requested
+// capacity, so downsizes change which nodes look removable. The coordinator
+// publishes the node utilization the planned resizes leave behind, and holds
+// back downsizes of pods on nodes already marked for removal, which would
+// only churn pods about to be evicted.
+type AutoscalerCoordinator struct {
+	Client        client.Client
+	EventRecorder record.EventRecorder
+	Metrics       *metrics.OperatorMetrics
+	Config        config.AutoscalerConfig
+	DryRun        bool // Projections are not written to nodes in dry-run mode
+}
+
+// Coordinate returns the updates to apply and publishes the projected
+// utilization of the nodes they touch
+func (a *AutoscalerCoordinator) Coordinate(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	nodes := make(map[string]*corev1.Node)
+	var nodeOrder []string
+	result := updates[:0:0]
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok || pod.Spec.NodeName == "" {
+			result = append(result, update)
+			continue
+		}
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok {
+			node = &corev1.Node{}
+			if err := a.Client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+				logger.Debug("Could not get node %s for autoscaler coordination: %v", pod.Spec.NodeName, err)
+				node = nil
+			} else {
+				nodeOrder = append(nodeOrder, node.Name)
+			}
+			nodes[pod.Spec.NodeName] = node
+		}
+
+		if node != nil && a.Config.SkipConsolidatingNodes && isDownsize(update) {
+			if taint, marked := consolidationTaint(node); marked {
+				message := fmt.Sprintf("Downsize of container %s held: node %s is being consolidated (%s)", update.ContainerName, node.Name, taint)
+				logger.Info("%s/%s: %s", update.Namespace, update.Name, message)
+				if a.EventRecorder != nil {
+					a.EventRecorder.Event(pod, corev1.EventTypeNormal, "ResizeHeldForConsolidation", message)
+				}
+				if a.Metrics != nil {
+					a.Metrics.RecordSuppressedResize(update.Namespace, "consolidation")
+				}
+				continue
+			}
+		}
+		result = append(result, update)
+	}
+
+	for _, name := range nodeOrder {
+		a.publish(ctx, nodes[name], projectedUtilization(nodes[name], result, podsByName, pods))
+	}
+	return result
+}
+
+// publish records a node's projected utilization and, when enabled, writes
+// it to the node's annotations
+func (a *AutoscalerCoordinator) publish(ctx context.Context, node *corev1.Node, utilization map[corev1.ResourceName]float64) {
+	if a.Metrics != nil {
+		for name, ratio := range utilization {
+			a.Metrics.RecordNodeProjectedUtilization(node.Name, string(name), ratio)
+		}
+	}
+	if !a.Config.AnnotateNodes || a.DryRun {
+		return
+	}
+
+	annotations := map[string]string{}
+	if ratio, ok := utilization[corev1.ResourceCPU]; ok {
+		annotations[projectedCPUAnnotation] = strconv.FormatFloat(ratio, 'f', 2, 64)
+	}
+	if ratio, ok := utilization[corev1.ResourceMemory]; ok {
+		annotations[projectedMemoryAnnotation] = strconv.FormatFloat(ratio, 'f', 2, 64)
+	}
+	changed := false
+	for key, value := range annotations {
+		if node.Annotations[key] != value {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	for key, value := range annotations {
+		node.Annotations[key] = value
+	}
+	if err := a.Client.Patch(ctx, node, patch); err != nil {
+		logger.Warn("Failed to annotate node %s with its projected utilization: %v", node.Name, err)
+	}
+}
+
+// projectedUtilization returns the share of the node's allocatable CPU and
+// memory requested once the updates of its pods are applied
+func projectedUtilization(node *corev1.Node, updates []ResourceUpdate, podsByName map[string]*corev1.Pod, pods []corev1.Pod) map[corev1.ResourceName]float64 {
+	requested := committedRequests(node.Name, pods)
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok || pod.Spec.NodeName != node.Name {
+			continue
+		}
+		for _, name := range plannedResources {
+			value, ok := update.NewResources.Requests[name]
+			if !ok {
+				continue
+			}
+			total := requested[name]
+			total.Add(value)
+			total.Sub(update.OldResources.Requests[name])
+			requested[name] = total
+		}
+	}
+
+	utilization := make(map[corev1.ResourceName]float64)
+	for _, name := range plannedResources {
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok || allocatable.IsZero() {
+			continue
+		}
+		total := requested[name]
+		utilization[name] = float64(total.MilliValue()) / float64(allocatable.MilliValue())
+	}
+	return utilization
+}
+
+// isDownsize reports whether an update lowers a CPU or memory request
+func isDownsize(update ResourceUpdate) bool {
+	for _, name := range plannedResources {
+		value, ok := update.NewResources.Requests[name]
+		if !ok {
+			continue
+		}
+		if old, ok := update.OldResources.Requests[name]; ok && value.Cmp(old) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// consolidationTaint returns the taint marking the node for removal by an autoscaler
+func consolidationTaint(node *corev1.Node) (string, bool) {
+	for _, taint := range node.Spec.Taints {
+		for _, key := range consolidationTaints {
+			if taint.Key == key {
+				return key, true
+			}
+		}
+	}
+	return "", false
+}
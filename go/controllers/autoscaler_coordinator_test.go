@@ -0,0 +1,108 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newCoordinator returns a coordinator for node-1 with 1 CPU and 1Gi allocatable
+func newCoordinator(cfg config.AutoscalerConfig, taints ...corev1.Taint) (*AutoscalerCoordinator, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	return &AutoscalerCoordinator{
+		Client:        ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(node).Build(),
+		EventRecorder: recorder,
+		Config:        cfg,
+	}, recorder
+}
+
+// TestAutoscalerCoordinatorAnnotatesProjection verifies the node is annotated
+// with the utilization left once its pods are resized
+func TestAutoscalerCoordinatorAnnotatesProjection(t *testing.T) {
+	coordinator, _ := newCoordinator(config.AutoscalerConfig{AnnotateNodes: true})
+	pods := scheduledPods()
+	updates := []ResourceUpdate{plannedUpdate(pods[0], "200m", "256Mi")}
+
+	if result := coordinator.Coordinate(context.Background(), updates, pods); len(result) != 1 {
+		t.Fatalf("expected the update kept, got %d", len(result))
+	}
+
+	var node corev1.Node
+	if err := coordinator.Client.Get(context.Background(), client.ObjectKey{Name: "node-1"}, &node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if got := node.Annotations[projectedCPUAnnotation]; got != "0.50" {
+		t.Errorf("expected projected CPU utilization 0.50, got %q", got)
+	}
+	if got := node.Annotations[projectedMemoryAnnotation]; got != "0.50" {
+		t.Errorf("expected projected memory utilization 0.50, got %q", got)
+	}
+}
+
+// TestAutoscalerCoordinatorHoldsDownsizesOnConsolidatingNodes verifies
+// downsizes are held on a node tainted for removal while upsizes go ahead
+func TestAutoscalerCoordinatorHoldsDownsizesOnConsolidatingNodes(t *testing.T) {
+	taint := corev1.Taint{Key: "karpenter.sh/disrupted", Effect: corev1.TaintEffectNoSchedule}
+	coordinator, recorder := newCoordinator(config.AutoscalerConfig{SkipConsolidatingNodes: true}, taint)
+	pods := scheduledPods()
+	updates := []ResourceUpdate{
+		plannedUpdate(pods[0], "200m", "256Mi"),
+		plannedUpdate(pods[1], "350m", "256Mi"),
+	}
+
+	result := coordinator.Coordinate(context.Background(), updates, pods)
+	if len(result) != 1 || result[0].Name != pods[1].Name {
+		t.Fatalf("expected only the upsize kept, got %+v", result)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "ResizeHeldForConsolidation") {
+			t.Errorf("expected a ResizeHeldForConsolidation event, got %q", event)
+		}
+	default:
+		t.Error("expected an event for the held downsize")
+	}
+
+	var node corev1.Node
+	if err := coordinator.Client.Get(context.Background(), client.ObjectKey{Name: "node-1"}, &node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if len(node.Annotations) != 0 {
+		t.Errorf("expected no annotations without annotateNodes, got %v", node.Annotations)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/client-go/tools/record"
+)
+
+// canaryKinds are the workload kinds resized through a canary replica
+var canaryKinds = map[string]bool{"Deployment": true}
+
+// canaryForgetAfter is how long a rollout is kept once its workload stops
+// receiving decisions, such as when its pods are analyzed in other cycles
+const canaryForgetAfter = time.Hour
+
+// canaryState is the stage of a canary rollout
+type canaryState string
+
+const (
+	canaryBaking   canaryState = "baking"   // the canary is resized, or waiting to be
+	canaryPromoted canaryState = "promoted" // the other replicas follow
+	canaryFailed   canaryState = "failed"   // the rollout is held
+)
+
+// canaryRollout is the rollout of one recommendation to a workload
+type canaryRollout struct {
+	pod       string                                 // the canary replica
+	targets   map[string]corev1.ResourceRequirements // recommended resources by container
+	restarts  int32                                  // container restarts of the canary when it was picked
+	appliedAt time.Time                              // when the canary was first seen with the targets
+	lastSeen  time.Time
+	state     canaryState
+}
+
+// CanaryRollouts stages the resizes of large Deployments. One replica is
+// resized first; once it has run for the bake time without restarts or
+// losing readiness, the decision is rolled out to the other replicas. A
+// canary that fails holds the rollout until the recommendation changes.
+type CanaryRollouts struct {
+	EventRecorder record.EventRecorder     // Records the outcome on the canary pod; optional
+	Metrics       *metrics.OperatorMetrics // Counts the held resizes; optional
+
+	mu       sync.Mutex
+	rollouts map[string]*canaryRollout // namespace/kind/name
+}
+
+// NewCanaryRollouts returns an empty canary tracker
+func NewCanaryRollouts() *CanaryRollouts {
+	return &CanaryRollouts{rollouts: make(map[string]*canaryRollout)}
+}
+
+// Stage returns the updates of a workload that may be applied now. The
+// updates carry the workload's aggregated decision for each of its replicas
+// that still differs from it.
+func (c *CanaryRollouts) Stage(now time.Time, workload string, updates []ResourceUpdate, pods map[string]*corev1.Pod, replicas int, cfg config.CanaryConfig) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+	targets := make(map[string]corev1.ResourceRequirements)
+	for _, update := range updates {
+		targets[update.ContainerName] = update.NewResources
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.forget(now)
+
+	rollout, ok := c.rollouts[workload]
+	if ok && !equality.Semantic.DeepEqual(rollout.targets, targets) {
+		// A new recommendation starts a new rollout
+		ok = false
+	}
+	if !ok {
+		if replicas < cfg.MinReplicas {
+			delete(c.rollouts, workload)
+			return updates
+		}
+		rollout = c.start(workload, updates, pods, targets)
+	}
+	rollout.lastSeen = now
+
+	switch rollout.state {
+	case canaryPromoted:
+		return updates
+	case canaryFailed:
+		c.hold(updates, nil)
+		return nil
+	}
+
+	pod := pods[updates[0].Namespace+"/"+rollout.pod]
+	if pod == nil || !pod.DeletionTimestamp.IsZero() {
+		logger.Info("🐤 Canary %s of %s is gone, picking another one", rollout.pod, workload)
+		delete(c.rollouts, workload)
+		return nil
+	}
+	if reason := canaryFailure(pod, rollout, cfg); reason != "" {
+		rollout.state = canaryFailed
+		logger.Warn("🐤 Canary %s of %s failed: %s; holding the resize of the other replicas", rollout.pod, workload, reason)
+		c.event(pod, corev1.EventTypeWarning, "CanaryFailed", fmt.Sprintf("Canary resize failed: %s; the other replicas of %s are not resized", reason, workload))
+		c.hold(updates, nil)
+		return nil
+	}
+
+	if rollout.appliedAt.IsZero() {
+		if !canaryApplied(pod, rollout.targets) {
+			return c.hold(updates, func(update ResourceUpdate) bool { return update.Name == rollout.pod })
+		}
+		rollout.appliedAt = now
+		logger.Info("🐤 Canary %s of %s resized, baking for %v", rollout.pod, workload, cfg.BakeTime)
+	}
+	if baked := now.Sub(rollout.appliedAt); baked < cfg.BakeTime {
+		logger.Debug("Canary %s of %s baking for another %v", rollout.pod, workload, (cfg.BakeTime - baked).Round(time.Second))
+		return c.hold(updates, nil)
+	}
+
+	rollout.state = canaryPromoted
+	logger.Info("🐤 Canary %s of %s ran cleanly for %v, resizing the other replicas", rollout.pod, workload, cfg.BakeTime)
+	c.event(pod, corev1.EventTypeNormal, "CanaryPromoted", fmt.Sprintf("Canary resize ran cleanly for %v; resizing the other replicas of %s", cfg.BakeTime, workload))
+	return updates
+}
+
+// start picks the canary of a new rollout: the first replica by name
+func (c *CanaryRollouts) start(workload string, updates []ResourceUpdate, pods map[string]*corev1.Pod, targets map[string]corev1.ResourceRequirements) *canaryRollout {
+	names := make([]string, 0, len(updates))
+	for _, update := range updates {
+		names = append(names, update.Name)
+	}
+	sort.Strings(names)
+
+	rollout := &canaryRollout{pod: names[0], targets: targets, state: canaryBaking}
+	if pod := pods[updates[0].Namespace+"/"+rollout.pod]; pod != nil {
+		rollout.restarts = podRestarts(pod)
+	}
+	c.rollouts[workload] = rollout
+	logger.Info("🐤 Resizing %s first as the canary of %s", rollout.pod, workload)
+	return rollout
+}
+
+// hold passes the updates keep selects and counts the others as suppressed
+func (c *CanaryRollouts) hold(updates []ResourceUpdate, keep func(ResourceUpdate) bool) []ResourceUpdate {
+	var result []ResourceUpdate
+	for _, update := range updates {
+		if keep != nil && keep(update) {
+			result = append(result, update)
+			continue
+		}
+		if c.Metrics != nil {
+			c.Metrics.RecordSuppressedResize(update.Namespace, "canary")
+		}
+	}
+	return result
+}
+
+// forget drops the rollouts of workloads no longer receiving decisions
+func (c *CanaryRollouts) forget(now time.Time) {
+	for workload, rollout := range c.rollouts {
+		if now.Sub(rollout.lastSeen) > canaryForgetAfter {
+			delete(c.rollouts, workload)
+		}
+	}
+}
+
+func (c *CanaryRollouts) event(pod *corev1.Pod, eventType, reason, message string) {
+	if c.EventRecorder != nil {
+		c.EventRecorder.Event(pod, eventType, reason, message)
+	}
+}
+
+// canaryFailure tells why a canary failed, or "" while it is healthy
+func canaryFailure(pod *corev1.Pod, rollout *canaryRollout, cfg config.CanaryConfig) string {
+	if restarts := podRestarts(pod) - rollout.restarts; int(restarts) > cfg.MaxRestarts {
+		return fmt.Sprintf("%d container restarts", restarts)
+	}
+	if !rollout.appliedAt.IsZero() && !podReady(pod) {
+		return "pod is not ready"
+	}
+	return ""
+}
+
+// canaryApplied reports whether the canary's containers have the targets
+func canaryApplied(pod *corev1.Pod, targets map[string]corev1.ResourceRequirements) bool {
+	for name, target := range targets {
+		container, _, _ := findContainer(pod, name)
+		if container == nil || !equality.Semantic.DeepEqual(container.Resources, target) {
+			return false
+		}
+	}
+	return true
+}
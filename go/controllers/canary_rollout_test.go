@@ -0,0 +1,141 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func canaryReplicas(names ...string) ([]corev1.Pod, map[string]*corev1.Pod) {
+	pods := make([]corev1.Pod, 0, len(names))
+	for _, name := range names {
+		pod := runningReplica(name, "web-abc", "100m", "128Mi")
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app"}}
+		pods = append(pods, pod)
+	}
+	byName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		byName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+	return pods, byName
+}
+
+func updateNames(updates []ResourceUpdate) []string {
+	names := make([]string, 0, len(updates))
+	for _, update := range updates {
+		names = append(names, update.Name)
+	}
+	return names
+}
+
+// TestCanaryRolloutPromotes verifies one replica is resized first and the
+// others follow once it has baked
+func TestCanaryRolloutPromotes(t *testing.T) {
+	cfg := config.CanaryConfig{Enabled: true, MinReplicas: 3, BakeTime: 10 * time.Minute}
+	recorder := record.NewFakeRecorder(10)
+	canaries := NewCanaryRollouts()
+	canaries.EventRecorder = recorder
+	_, pods := canaryReplicas("web-abc-1", "web-abc-2", "web-abc-3")
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-3", "200m", "256Mi"),
+		requestUpdate("web-abc-1", "200m", "256Mi"),
+		requestUpdate("web-abc-2", "200m", "256Mi"),
+	}
+	now := time.Now()
+
+	got := canaries.Stage(now, "default/Deployment/web", updates, pods, 3, cfg)
+	if names := updateNames(got); len(names) != 1 || names[0] != "web-abc-1" {
+		t.Fatalf("expected only the canary web-abc-1 to be resized, got %v", names)
+	}
+
+	// The canary is resized; the others wait while it bakes
+	pods["default/web-abc-1"].Spec.Containers[0].Resources = updates[1].NewResources
+	rest := []ResourceUpdate{updates[0], updates[2]}
+	if got := canaries.Stage(now.Add(time.Minute), "default/Deployment/web", rest, pods, 3, cfg); len(got) != 0 {
+		t.Fatalf("expected the other replicas to wait for the bake time, got %v", updateNames(got))
+	}
+	got = canaries.Stage(now.Add(12*time.Minute), "default/Deployment/web", rest, pods, 3, cfg)
+	if len(got) != 2 {
+		t.Fatalf("expected the other replicas to be resized after the bake time, got %v", updateNames(got))
+	}
+	if event := <-recorder.Events; event != "Normal CanaryPromoted Canary resize ran cleanly for 10m0s; resizing the other replicas of default/Deployment/web" {
+		t.Errorf("unexpected event %q", event)
+	}
+
+	// Replicas the promoted rollout did not reach yet are resized without a new canary
+	if got := canaries.Stage(now.Add(13*time.Minute), "default/Deployment/web", rest[:1], pods, 3, cfg); len(got) != 1 {
+		t.Errorf("expected the promoted rollout to continue, got %v", updateNames(got))
+	}
+}
+
+// TestCanaryRolloutFails verifies a canary that restarts holds the rollout
+// until the recommendation changes
+func TestCanaryRolloutFails(t *testing.T) {
+	cfg := config.CanaryConfig{Enabled: true, MinReplicas: 3, BakeTime: 10 * time.Minute}
+	canaries := NewCanaryRollouts()
+	_, pods := canaryReplicas("web-abc-1", "web-abc-2", "web-abc-3")
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-1", "50m", "64Mi"),
+		requestUpdate("web-abc-2", "50m", "64Mi"),
+		requestUpdate("web-abc-3", "50m", "64Mi"),
+	}
+	now := time.Now()
+
+	canaries.Stage(now, "default/Deployment/web", updates, pods, 3, cfg)
+	pods["default/web-abc-1"].Spec.Containers[0].Resources = updates[0].NewResources
+	canaries.Stage(now.Add(time.Minute), "default/Deployment/web", updates[1:], pods, 3, cfg)
+	pods["default/web-abc-1"].Status.ContainerStatuses[0].RestartCount = 1
+
+	if got := canaries.Stage(now.Add(20*time.Minute), "default/Deployment/web", updates[1:], pods, 3, cfg); len(got) != 0 {
+		t.Fatalf("expected a restarting canary to hold the rollout, got %v", updateNames(got))
+	}
+	if got := canaries.Stage(now.Add(30*time.Minute), "default/Deployment/web", updates[1:], pods, 3, cfg); len(got) != 0 {
+		t.Fatalf("expected the rollout to stay held, got %v", updateNames(got))
+	}
+
+	changed := []ResourceUpdate{requestUpdate("web-abc-2", "80m", "96Mi"), requestUpdate("web-abc-3", "80m", "96Mi")}
+	got := canaries.Stage(now.Add(40*time.Minute), "default/Deployment/web", changed, pods, 3, cfg)
+	if names := updateNames(got); len(names) != 1 || names[0] != "web-abc-2" {
+		t.Errorf("expected a new recommendation to start a new canary, got %v", names)
+	}
+}
+
+// TestWorkloadAggregatorCanary verifies small Deployments are resized at
+// once and large ones through a canary
+func TestWorkloadAggregatorCanary(t *testing.T) {
+	a := newAggregatorClient(t)
+	a.Canaries = NewCanaryRollouts()
+	a.Canary = config.CanaryConfig{Enabled: true, MinReplicas: 3, BakeTime: time.Minute}
+
+	pods, _ := canaryReplicas("web-abc-1", "web-abc-2")
+	updates := []ResourceUpdate{requestUpdate("web-abc-1", "200m", "256Mi")}
+	if got := a.Aggregate(context.Background(), updates, pods); len(got) != 2 {
+		t.Fatalf("expected both replicas of a small Deployment to be resized, got %v", updateNames(got))
+	}
+
+	pods, _ = canaryReplicas("web-abc-1", "web-abc-2", "web-abc-3")
+	if got := a.Aggregate(context.Background(), updates, pods); len(got) != 1 {
+		t.Fatalf("expected only the canary of a large Deployment to be resized, got %v", updateNames(got))
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// changeBudget limits the share of managed pods resized within a sliding
+// window, cluster-wide and per namespace, so a configuration mistake cannot
+// resize the whole cluster in one pass. A pod counts once per window however
+// often it is resized.
+type changeBudget struct {
+	mu      sync.Mutex
+	resized map[string]time.Time // namespace/pod -> last resize
+}
+
+// filter drops the updates of pods the budgets have no room for, keeping the
+// order of the rest. Pods already resized within the window are not charged again.
+func (b *changeBudget) filter(updates []ResourceUpdate, pods []corev1.Pod, cfg *config.Config, operatorMetrics *metrics.OperatorMetrics) []ResourceUpdate {
+	budget := cfg.ChangeBudget
+	if len(updates) == 0 || (budget.ClusterPercent == 0 && budget.NamespacePercent == 0) {
+		return updates
+	}
+
+	// Size the budgets from the managed pods
+	managed := 0
+	managedIn := make(map[string]int)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || !cfg.IsNamespaceIncluded(pod.Namespace) {
+			continue
+		}
+		managed++
+		managedIn[pod.Namespace]++
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Charge the pods resized within the window
+	now := time.Now()
+	used := 0
+	usedIn := make(map[string]int)
+	charged := make(map[string]bool)
+	for key, at := range b.resized {
+		if now.Sub(at) > budget.Window {
+			delete(b.resized, key)
+			continue
+		}
+		charged[key] = true
+		used++
+		namespace, _, _ := strings.Cut(key, "/")
+		usedIn[namespace]++
+	}
+
+	clusterLimit := budgetLimit(budget.ClusterPercent, managed, budget.MinPods)
+	result := updates[:0:0]
+	for _, update := range updates {
+		key := update.Namespace + "/" + update.Name
+		if !charged[key] {
+			namespaceLimit := budgetLimit(budget.NamespacePercent, managedIn[update.Namespace], 1)
+			if (clusterLimit >= 0 && used >= clusterLimit) || (namespaceLimit >= 0 && usedIn[update.Namespace] >= namespaceLimit) {
+				logger.Debug("Deferring resize of %s/%s/%s: change budget of the last %v used up", update.Namespace, update.Name, update.ContainerName, budget.Window)
+				if operatorMetrics != nil {
+					operatorMetrics.RecordSuppressedResize(update.Namespace, "change_budget")
+				}
+				continue
+			}
+			charged[key] = true
+			used++
+			usedIn[update.Namespace]++
+		}
+		result = append(result, update)
+	}
+	if dropped := len(updates) - len(result); dropped > 0 {
+		logger.Info("⏳ Change budget used up: deferring %d resizes to a later run", dropped)
+	}
+	return result
+}
+
+// record charges an applied resize of a pod to the budgets
+func (b *changeBudget) record(namespace, name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.resized == nil {
+		b.resized = make(map[string]time.Time)
+	}
+	b.resized[namespace+"/"+name] = time.Now()
+}
+
+// budgetLimit returns the pods a budget of percent allows out of total,
+// rounded up and at least minimum; -1 when the budget is disabled
+func budgetLimit(percent, total, minimum int) int {
+	if percent <= 0 {
+		return -1
+	}
+	limit := (total*percent + 99) / 100
+	return max(limit, minimum)
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestChangeBudgetCapsResizedPods verifies the cluster budget defers the
+// pods beyond its share and keeps a pod's containers together
+func TestChangeBudgetCapsResizedPods(t *testing.T) {
+	var pods []corev1.Pod
+	var updates []ResourceUpdate
+	for i := 0; i < 30; i++ {
+		pods = append(pods, podOnNode(fmt.Sprintf("p%d", i), "node-a", false))
+		if i < 8 {
+			updates = append(updates, ResourceUpdate{Namespace: "default", Name: fmt.Sprintf("p%d", i), ContainerName: "app"})
+		}
+	}
+	updates = append(updates, ResourceUpdate{Namespace: "default", Name: "p0", ContainerName: "sidecar"})
+
+	// 10% of 30 pods is 3, raised to the minimum of 5
+	cfg := config.GetDefaults()
+	var budget changeBudget
+	want := "p0/app,p1/app,p2/app,p3/app,p4/app,p0/sidecar"
+	if got := spreadNames(budget.filter(updates, pods, cfg, nil)); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+
+	// Resized pods use up the budget for the window but are not charged twice
+	for _, name := range []string{"p0", "p1", "p2", "p3", "p4"} {
+		budget.record("default", name)
+	}
+	want = "p4/app,p0/sidecar"
+	if got := spreadNames(budget.filter(updates[4:], pods, cfg, nil)); got != want {
+		t.Fatalf("expected only the resized pods, got %s", got)
+	}
+}
+
+// TestChangeBudgetPerNamespace verifies a namespace budget leaves other
+// namespaces alone
+func TestChangeBudgetPerNamespace(t *testing.T) {
+	var pods []corev1.Pod
+	for _, name := range []string{"b1", "b2", "b3", "b4"} {
+		pod := podOnNode(name, "node-a", false)
+		pod.Namespace = "batch"
+		pods = append(pods, pod)
+	}
+	pods = append(pods, podOnNode("web", "node-a", false))
+	updates := []ResourceUpdate{
+		{Namespace: "batch", Name: "b1", ContainerName: "app"},
+		{Namespace: "batch", Name: "b2", ContainerName: "app"},
+		{Namespace: "default", Name: "web", ContainerName: "app"},
+	}
+
+	cfg := config.GetDefaults()
+	cfg.SetChangeBudget(config.ChangeBudgetConfig{NamespacePercent: 25})
+	var budget changeBudget
+	want := "b1/app,web/app"
+	if got := spreadNames(budget.filter(updates, pods, cfg, nil)); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestBudgetLimit(t *testing.T) {
+	if got := budgetLimit(0, 100, 5); got != -1 {
+		t.Errorf("expected a disabled budget, got %d", got)
+	}
+	if got := budgetLimit(10, 101, 5); got != 11 {
+		t.Errorf("expected 10%% of 101 pods rounded up to 11, got %d", got)
+	}
+}
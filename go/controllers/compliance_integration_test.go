@@ -443,6 +443,10 @@ func (m *complianceMockMetricsProvider) FetchPodMetrics(ctx context.Context, nam
 	}, nil
 }
 
+func (m *complianceMockMetricsProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (metrics.ContainerMetrics, error) {
+	return metrics.ContainerMetrics{}, nil
+}
+
 // Helper function to check if a string contains a substring
 func containsString(s, substr string) bool {
 	return len(substr) <= len(s) && (substr == "" || s[len(s)-len(substr):] == substr ||
@@ -0,0 +1,107 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/logger"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ConfigReady signals that the configuration has been loaded from the
+// RightSizerConfig CRD, so components reading it at startup, such as the API,
+// webhook and metrics servers, start with the configured settings
+type ConfigReady struct {
+	once sync.Once
+	done chan struct{}
+}
+
+// NewConfigReady returns a signal that is not ready yet
+func NewConfigReady() *ConfigReady {
+	return &ConfigReady{done: make(chan struct{})}
+}
+
+// MarkReady marks the configuration as loaded; later calls have no effect
+func (c *ConfigReady) MarkReady() {
+	if c == nil {
+		return
+	}
+	c.once.Do(func() { close(c.done) })
+}
+
+// Done returns a channel closed once the configuration is loaded
+func (c *ConfigReady) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait blocks until the configuration is loaded, reporting false when ctx
+// ends first
+func (c *ConfigReady) Wait(ctx context.Context) bool {
+	select {
+	case <-c.done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// configReadyRunnable marks the configuration as loaded on replicas where the
+// reconciler will not do it: when no RightSizerConfig exists, and on replicas
+// that are not the leader, which apply the configuration themselves
+type configReadyRunnable struct {
+	reconciler *RightSizerConfigReconciler
+	mgr        ctrl.Manager
+}
+
+// Start waits for the cache and checks the RightSizerConfigs once
+func (c *configReadyRunnable) Start(ctx context.Context) error {
+	r := c.reconciler
+	if !c.mgr.GetCache().WaitForCacheSync(ctx) {
+		return nil
+	}
+
+	var configs v1alpha1.RightSizerConfigList
+	if err := r.List(ctx, &configs); err != nil {
+		logger.Warn("Failed to list RightSizerConfigs, starting with the default configuration: %v", err)
+		r.Ready.MarkReady()
+		return nil
+	}
+	if len(configs.Items) == 0 {
+		logger.Info("No RightSizerConfig found, starting with the default configuration")
+		r.Ready.MarkReady()
+		return nil
+	}
+
+	select {
+	case <-c.mgr.Elected():
+		// The leader's reconciler applies the configuration
+	default:
+		if _, err := r.applyConfiguration(ctx, &configs.Items[0]); err != nil {
+			logger.Warn("Failed to apply RightSizerConfig %s: %v", configs.Items[0].Name, err)
+		}
+		r.Ready.MarkReady()
+	}
+	return nil
+}
+
+// NeedLeaderElection returns false so every replica learns its configuration
+func (c *configReadyRunnable) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestConfigReady verifies the signal is closed once and waits honor the context
+func TestConfigReady(t *testing.T) {
+	ready := NewConfigReady()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if ready.Wait(ctx) {
+		t.Fatalf("expected Wait to give up when the context ends first")
+	}
+
+	ready.MarkReady()
+	ready.MarkReady()
+	if !ready.Wait(context.Background()) {
+		t.Fatalf("expected Wait to return once ready")
+	}
+
+	// A reconciler without a signal ignores it
+	var none *ConfigReady
+	none.MarkReady()
+}
+
+// TestReconcileMarksConfigReady verifies the first reconcile marks the configuration loaded
+func TestReconcileMarksConfigReady(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	for _, exists := range []bool{true, false} {
+		builder := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&v1alpha1.RightSizerConfig{})
+		if exists {
+			builder = builder.WithObjects(&v1alpha1.RightSizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+		}
+		ready := NewConfigReady()
+		r := &RightSizerConfigReconciler{Client: builder.Build(), Scheme: scheme, Config: config.GetDefaults(), Ready: ready}
+
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "default"}}); err != nil {
+			t.Fatalf("reconcile failed: %v", err)
+		}
+		select {
+		case <-ready.Done():
+		default:
+			t.Errorf("expected the configuration to be ready after reconciling (config exists: %v)", exists)
+		}
+	}
+}
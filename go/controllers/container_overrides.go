@@ -0,0 +1,215 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Annotations overriding the sizing parameters of a pod's containers. A key
+// suffixed with ".<container>", e.g. rightsizer.io/min-memory.app, applies
+// to that container only and wins over the unsuffixed key.
+const (
+	minCPUAnnotation         = "rightsizer.io/min-cpu"         // Floor of the CPU request, e.g. "250m"
+	maxCPUAnnotation         = "rightsizer.io/max-cpu"         // Cap of the CPU request and limit
+	minMemoryAnnotation      = "rightsizer.io/min-memory"      // Floor of the memory request, e.g. "512Mi"
+	maxMemoryAnnotation      = "rightsizer.io/max-memory"      // Cap of the memory request and limit
+	cpuHeadroomAnnotation    = "rightsizer.io/cpu-headroom"    // Percent added to CPU usage for the request, e.g. "30%"
+	memoryHeadroomAnnotation = "rightsizer.io/memory-headroom" // Percent added to memory usage for the request
+)
+
+// maxHeadroomPercent is the largest headroom an annotation may set, the
+// equivalent of the largest request multiplier a policy may set
+const maxHeadroomPercent = 900
+
+// containerOverrides are the sizing parameters a container's annotations
+// override; nil fields keep the configured value
+type containerOverrides struct {
+	minCPU, maxCPU              *int64   // millicores
+	minMemory, maxMemory        *int64   // MB
+	cpuHeadroom, memoryHeadroom *float64 // percent
+	annotations                 []string // annotations applied, for the explanation
+}
+
+// containerAnnotation returns the value of a sizing annotation for a
+// container, preferring the container-specific key, and the key it came from
+func containerAnnotation(pod *corev1.Pod, key, containerName string) (string, string, bool) {
+	if value, ok := pod.Annotations[key+"."+containerName]; ok {
+		return value, key + "." + containerName, true
+	}
+	value, ok := pod.Annotations[key]
+	return value, key, ok
+}
+
+// parseContainerOverrides reads the sizing annotations of a container.
+// Values that do not parse, exceed the configured maximum limits or
+// contradict each other are ignored with a warning.
+func parseContainerOverrides(pod *corev1.Pod, containerName string, cfg *config.Config) containerOverrides {
+	var o containerOverrides
+	invalid := func(key, value, reason string) {
+		logger.Warn("Ignoring %s annotation %q on pod %s/%s: %s", key, value, pod.Namespace, pod.Name, reason)
+	}
+
+	for _, q := range []struct {
+		key     string
+		cpu     bool
+		maximum int64
+		target  **int64
+	}{
+		{minCPUAnnotation, true, cfg.MaxCPULimit, &o.minCPU},
+		{maxCPUAnnotation, true, cfg.MaxCPULimit, &o.maxCPU},
+		{minMemoryAnnotation, false, cfg.MaxMemoryLimit, &o.minMemory},
+		{maxMemoryAnnotation, false, cfg.MaxMemoryLimit, &o.maxMemory},
+	} {
+		value, key, ok := containerAnnotation(pod, q.key, containerName)
+		if !ok {
+			continue
+		}
+		parse, unit := config.ParseMemoryQuantity, "MB"
+		if q.cpu {
+			parse, unit = config.ParseCPUQuantity, "m"
+		}
+		amount, err := parse(value)
+		if err != nil || amount <= 0 {
+			invalid(key, value, "not a positive quantity")
+			continue
+		}
+		if q.maximum > 0 && amount > q.maximum {
+			invalid(key, value, fmt.Sprintf("exceeds the maximum limit of %d%s", q.maximum, unit))
+			continue
+		}
+		*q.target = &amount
+		o.annotations = append(o.annotations, key+"="+value)
+	}
+	if o.minCPU != nil && o.maxCPU != nil && *o.minCPU > *o.maxCPU {
+		invalid(minCPUAnnotation, strconv.FormatInt(*o.minCPU, 10)+"m", "above the maximum CPU")
+		o.minCPU = nil
+	}
+	if o.minMemory != nil && o.maxMemory != nil && *o.minMemory > *o.maxMemory {
+		invalid(minMemoryAnnotation, strconv.FormatInt(*o.minMemory, 10)+"Mi", "above the maximum memory")
+		o.minMemory = nil
+	}
+
+	for _, h := range []struct {
+		key    string
+		target **float64
+	}{
+		{cpuHeadroomAnnotation, &o.cpuHeadroom},
+		{memoryHeadroomAnnotation, &o.memoryHeadroom},
+	} {
+		value, key, ok := containerAnnotation(pod, h.key, containerName)
+		if !ok {
+			continue
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+		if err != nil || percent < 0 || percent > maxHeadroomPercent {
+			invalid(key, value, fmt.Sprintf("not a percentage between 0 and %d", maxHeadroomPercent))
+			continue
+		}
+		*h.target = &percent
+		o.annotations = append(o.annotations, key+"="+value)
+	}
+	return o
+}
+
+// empty reports whether no annotation overrides anything
+func (o containerOverrides) empty() bool {
+	return len(o.annotations) == 0
+}
+
+// config returns a copy of cfg with the overridden parameters, or cfg
+// itself when nothing is overridden
+func (o containerOverrides) config(cfg *config.Config) *config.Config {
+	if o.empty() {
+		return cfg
+	}
+	overridden := cfg.Clone()
+	if o.cpuHeadroom != nil {
+		overridden.CPURequestMultiplier = 1 + *o.cpuHeadroom/100
+		overridden.CPURequestAddition = 0
+	}
+	if o.memoryHeadroom != nil {
+		overridden.MemoryRequestMultiplier = 1 + *o.memoryHeadroom/100
+		overridden.MemoryRequestAddition = 0
+	}
+	if o.minCPU != nil {
+		overridden.MinCPURequest = *o.minCPU
+	}
+	if o.maxCPU != nil {
+		overridden.MaxCPULimit = *o.maxCPU
+	}
+	if o.minMemory != nil {
+		overridden.MinMemoryRequest = *o.minMemory
+	}
+	if o.maxMemory != nil {
+		overridden.MaxMemoryLimit = *o.maxMemory
+	}
+	return overridden
+}
+
+// clamp keeps the requests at or above the minimums and the requests and
+// limits at or below the maximums, raising limits below a raised request
+func (o containerOverrides) clamp(resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	clamped := *resources.DeepCopy()
+	for _, b := range []struct {
+		name             corev1.ResourceName
+		minimum, maximum *resource.Quantity
+	}{
+		{corev1.ResourceCPU, milliQuantity(o.minCPU), milliQuantity(o.maxCPU)},
+		{corev1.ResourceMemory, megabyteQuantity(o.minMemory), megabyteQuantity(o.maxMemory)},
+	} {
+		if request, ok := clamped.Requests[b.name]; ok {
+			if b.minimum != nil && request.Cmp(*b.minimum) < 0 {
+				request = *b.minimum
+			}
+			if b.maximum != nil && request.Cmp(*b.maximum) > 0 {
+				request = *b.maximum
+			}
+			clamped.Requests[b.name] = request
+			if limit, ok := clamped.Limits[b.name]; ok && limit.Cmp(request) < 0 {
+				clamped.Limits[b.name] = request
+			}
+		}
+		if limit, ok := clamped.Limits[b.name]; ok && b.maximum != nil && limit.Cmp(*b.maximum) > 0 {
+			clamped.Limits[b.name] = *b.maximum
+		}
+	}
+	return clamped
+}
+
+// milliQuantity returns a quantity of millicores, nil for nil
+func milliQuantity(millis *int64) *resource.Quantity {
+	if millis == nil {
+		return nil
+	}
+	return resource.NewMilliQuantity(*millis, resource.DecimalSI)
+}
+
+// megabyteQuantity returns a quantity of MB, nil for nil
+func megabyteQuantity(mb *int64) *resource.Quantity {
+	if mb == nil {
+		return nil
+	}
+	return resource.NewQuantity(*mb*1024*1024, resource.BinarySI)
+}
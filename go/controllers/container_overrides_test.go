@@ -0,0 +1,102 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// TestParseContainerOverrides verifies container-specific annotations win
+// over pod-wide ones and values beyond the global caps are ignored
+func TestParseContainerOverrides(t *testing.T) {
+	cfg := config.GetDefaults()
+	pod := createTestPod("web-1", "shop", "500m", "512Mi", "1", "1Gi")
+	pod.Annotations = map[string]string{
+		minMemoryAnnotation:               "256Mi",
+		minMemoryAnnotation + ".sidecar":  "64Mi",
+		maxCPUAnnotation:                  "64", // beyond the 4 CPU maximum limit
+		cpuHeadroomAnnotation + ".app":    "30%",
+		memoryHeadroomAnnotation:          "lots",
+		maxMemoryAnnotation + ".sidecar":  "32Mi", // below its minimum
+		minCPUAnnotation + ".unreachable": "1",
+	}
+
+	app := parseContainerOverrides(pod, "app", cfg)
+	if app.minMemory == nil || *app.minMemory != 256 {
+		t.Errorf("expected the pod-wide minimum memory of 256MB, got %v", app.minMemory)
+	}
+	if app.maxCPU != nil {
+		t.Errorf("expected a maximum CPU beyond the global cap to be ignored, got %d", *app.maxCPU)
+	}
+	if app.cpuHeadroom == nil || *app.cpuHeadroom != 30 || app.memoryHeadroom != nil {
+		t.Errorf("expected a CPU headroom of 30%% and no memory headroom, got %v and %v", app.cpuHeadroom, app.memoryHeadroom)
+	}
+	if app.minCPU != nil {
+		t.Errorf("expected another container's annotation to be ignored")
+	}
+	if overridden := app.config(cfg); overridden.CPURequestMultiplier != 1.3 || overridden.MemoryRequestMultiplier != cfg.MemoryRequestMultiplier {
+		t.Errorf("expected the CPU request multiplier 1.3, got %v and memory %v", overridden.CPURequestMultiplier, overridden.MemoryRequestMultiplier)
+	}
+
+	sidecar := parseContainerOverrides(pod, "sidecar", cfg)
+	if sidecar.maxMemory == nil || *sidecar.maxMemory != 32 || sidecar.minMemory != nil {
+		t.Errorf("expected a minimum above the maximum to be dropped, got min %v max %v", sidecar.minMemory, sidecar.maxMemory)
+	}
+	if sidecar.cpuHeadroom != nil {
+		t.Errorf("expected the app container's headroom not to apply to the sidecar")
+	}
+
+	if none := parseContainerOverrides(createTestPod("plain", "shop", "500m", "512Mi", "1", "1Gi"), "app", cfg); !none.empty() || none.config(cfg) != cfg {
+		t.Errorf("expected no overrides without annotations")
+	}
+}
+
+// TestContainerOverridesClamp verifies requests are raised to the minimums
+// and requests and limits lowered to the maximums
+func TestContainerOverridesClamp(t *testing.T) {
+	minMemory, maxCPU := int64(512), int64(500)
+	o := containerOverrides{minMemory: &minMemory, maxCPU: &maxCPU, annotations: []string{"set"}}
+	clamped := o.clamp(corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("800m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+	})
+	for name, want := range map[string]resource.Quantity{
+		"cpu request":    resource.MustParse("500m"),
+		"cpu limit":      resource.MustParse("500m"),
+		"memory request": resource.MustParse("512Mi"),
+		"memory limit":   resource.MustParse("512Mi"),
+	} {
+		var got resource.Quantity
+		switch name {
+		case "cpu request":
+			got = clamped.Requests[corev1.ResourceCPU]
+		case "cpu limit":
+			got = clamped.Limits[corev1.ResourceCPU]
+		case "memory request":
+			got = clamped.Requests[corev1.ResourceMemory]
+		case "memory limit":
+			got = clamped.Limits[corev1.ResourceMemory]
+		}
+		if got.Cmp(want) != 0 {
+			t.Errorf("%s = %s, want %s", name, got.String(), want.String())
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// +kubebuilder:rbac:groups=custom.metrics.k8s.io,resources=*,verbs=get;list
+
+// customMetricRule is a policy custom metric rule with its per-unit amount
+// in millicores or MB
+type customMetricRule struct {
+	Metric    string
+	Resource  corev1.ResourceName
+	PerUnit   float64
+	Container string
+	// Weight is the percentage of the usage taken from the metric; negative
+	// to take the larger of the metric estimate and observed usage
+	Weight int
+}
+
+// podCustomMetricRules returns the custom metric rules of the effective
+// policy for a pod. None apply while custom metrics are disabled, and rules
+// for metrics outside the allowed list or with an invalid perUnit are dropped.
+func podCustomMetricRules(policies []*v1alpha1.RightSizerPolicy, cfg *config.Config) []customMetricRule {
+	if !cfg.IncludeCustomMetrics || len(policies) == 0 {
+		return nil
+	}
+
+	var rules []customMetricRule
+	for _, spec := range mergePolicies(policies).Spec.ResourceStrategy.CustomMetrics {
+		if len(cfg.CustomMetrics) > 0 && !slices.Contains(cfg.CustomMetrics, spec.Metric) {
+			logger.Debug("Ignoring custom metric %s: not in the allowed custom metrics", spec.Metric)
+			continue
+		}
+		rule, err := parseCustomMetricRule(spec)
+		if err != nil {
+			logger.Warn("Ignoring custom metric rule for %s: %v", spec.Metric, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// parseCustomMetricRule converts a policy rule, reading perUnit as
+// millicores for CPU and MB for memory
+func parseCustomMetricRule(spec v1alpha1.CustomMetricRule) (customMetricRule, error) {
+	rule := customMetricRule{Metric: spec.Metric, Container: spec.Container, Weight: -1}
+	if spec.Weight != nil {
+		rule.Weight = int(*spec.Weight)
+	}
+
+	perUnit, err := resource.ParseQuantity(spec.PerUnit)
+	if err != nil {
+		return rule, fmt.Errorf("invalid perUnit %q: %w", spec.PerUnit, err)
+	}
+	switch corev1.ResourceName(spec.Resource) {
+	case corev1.ResourceCPU:
+		rule.Resource = corev1.ResourceCPU
+		rule.PerUnit = perUnit.AsApproximateFloat64() * 1000
+	case corev1.ResourceMemory:
+		rule.Resource = corev1.ResourceMemory
+		rule.PerUnit = perUnit.AsApproximateFloat64() / (1024 * 1024)
+	default:
+		return rule, fmt.Errorf("unsupported resource %q", spec.Resource)
+	}
+	return rule, nil
+}
+
+// blendCustomMetrics estimates a container's CPU or memory usage from the
+// custom metrics of its rules and blends the estimate with observed usage,
+// rule by rule. It returns the blended usage and a description of the
+// metrics used, empty when none applied.
+func (r *AdaptiveRightSizer) blendCustomMetrics(ctx context.Context, pod *corev1.Pod, container string, usage metrics.Metrics, rules []customMetricRule) (metrics.Metrics, string) {
+	if r.CustomMetrics == nil || len(rules) == 0 {
+		return usage, ""
+	}
+
+	var used []string
+	for _, rule := range rules {
+		target := rule.Container
+		if target == "" && len(pod.Spec.Containers) > 0 {
+			target = pod.Spec.Containers[0].Name
+		}
+		if target != container {
+			continue
+		}
+
+		value, err := r.CustomMetrics.FetchPodCustomMetric(ctx, pod.Namespace, pod.Name, rule.Metric)
+		if err != nil {
+			logger.Debug("Custom metric %s unavailable for pod %s/%s: %v", rule.Metric, pod.Namespace, pod.Name, err)
+			continue
+		}
+
+		estimate := value * rule.PerUnit
+		switch rule.Resource {
+		case corev1.ResourceCPU:
+			usage.CPUMilli = blendUsage(usage.CPUMilli, estimate, rule.Weight)
+			used = append(used, fmt.Sprintf("%s=%g (%.0fm CPU)", rule.Metric, value, estimate))
+		case corev1.ResourceMemory:
+			usage.MemMB = blendUsage(usage.MemMB, estimate, rule.Weight)
+			used = append(used, fmt.Sprintf("%s=%g (%.0fMi memory)", rule.Metric, value, estimate))
+		}
+	}
+	return usage, strings.Join(used, ", ")
+}
+
+// blendUsage weighs a metric estimate against observed usage; a negative
+// weight takes the larger of the two
+func blendUsage(observed, estimate float64, weight int) float64 {
+	if weight < 0 {
+		return math.Max(observed, estimate)
+	}
+	return (observed*float64(100-weight) + estimate*float64(weight)) / 100
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeCustomMetrics serves custom metric values by name
+type fakeCustomMetrics map[string]float64
+
+func (f fakeCustomMetrics) FetchPodCustomMetric(_ context.Context, _, _, metric string) (float64, error) {
+	value, ok := f[metric]
+	if !ok {
+		return 0, errors.New("metric not found")
+	}
+	return value, nil
+}
+
+func TestPodCustomMetricRules(t *testing.T) {
+	weight := int32(50)
+	policy := &v1alpha1.RightSizerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "workers"}}
+	policy.Spec.ResourceStrategy.CustomMetrics = []v1alpha1.CustomMetricRule{
+		{Metric: "queue_depth", Resource: "memory", PerUnit: "2Mi"},
+		{Metric: "requests_per_second", Resource: "cpu", PerUnit: "5m", Weight: &weight},
+		{Metric: "latency", Resource: "cpu", PerUnit: "lots"},
+	}
+	policies := []*v1alpha1.RightSizerPolicy{policy}
+
+	cfg := config.GetDefaults()
+	if rules := podCustomMetricRules(policies, cfg); len(rules) != 0 {
+		t.Errorf("expected no rules while custom metrics are disabled, got %+v", rules)
+	}
+
+	cfg.IncludeCustomMetrics = true
+	rules := podCustomMetricRules(policies, cfg)
+	if len(rules) != 2 {
+		t.Fatalf("expected the invalid rule to be dropped, got %+v", rules)
+	}
+	if rules[0].Resource != corev1.ResourceMemory || rules[0].PerUnit != 2 || rules[0].Weight != -1 {
+		t.Errorf("unexpected memory rule: %+v", rules[0])
+	}
+	if rules[1].Resource != corev1.ResourceCPU || rules[1].PerUnit != 5 || rules[1].Weight != 50 {
+		t.Errorf("unexpected CPU rule: %+v", rules[1])
+	}
+
+	cfg.SetCustomMetrics([]string{"requests_per_second"})
+	if rules := podCustomMetricRules(policies, cfg); len(rules) != 1 || rules[0].Metric != "requests_per_second" {
+		t.Errorf("expected only the allowed metric, got %+v", rules)
+	}
+}
+
+func TestBlendCustomMetrics(t *testing.T) {
+	r := &AdaptiveRightSizer{CustomMetrics: fakeCustomMetrics{"queue_depth": 300, "requests_per_second": 40}}
+	pod := createTestPod("worker", "default", "100m", "256Mi", "200m", "512Mi")
+	pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "proxy"})
+	app := pod.Spec.Containers[0].Name
+
+	rules := []customMetricRule{
+		{Metric: "queue_depth", Resource: corev1.ResourceMemory, PerUnit: 2, Weight: -1},
+		{Metric: "requests_per_second", Resource: corev1.ResourceCPU, PerUnit: 5, Weight: 50},
+		{Metric: "missing", Resource: corev1.ResourceCPU, PerUnit: 1, Weight: 100},
+		{Metric: "requests_per_second", Resource: corev1.ResourceCPU, PerUnit: 1, Container: "proxy", Weight: 100},
+	}
+	usage, used := r.blendCustomMetrics(context.Background(), pod, app, metrics.Metrics{CPUMilli: 100, MemMB: 256}, rules)
+	if usage.MemMB != 600 {
+		t.Errorf("expected the larger memory estimate of 600MB, got %v", usage.MemMB)
+	}
+	if usage.CPUMilli != 150 {
+		t.Errorf("expected CPU halfway between 100m and 200m, got %v", usage.CPUMilli)
+	}
+	if !strings.Contains(used, "queue_depth=300") || !strings.Contains(used, "requests_per_second=40") {
+		t.Errorf("expected the metrics used to be described, got %q", used)
+	}
+
+	usage, _ = r.blendCustomMetrics(context.Background(), pod, "proxy", metrics.Metrics{CPUMilli: 10}, rules)
+	if usage.CPUMilli != 40 {
+		t.Errorf("expected the proxy to be sized from its own rule, got %v", usage.CPUMilli)
+	}
+
+	// Without a custom metrics source usage is left alone
+	r.CustomMetrics = nil
+	if usage, used := r.blendCustomMetrics(context.Background(), pod, app, metrics.Metrics{CPUMilli: 100}, rules); usage.CPUMilli != 100 || used != "" {
+		t.Errorf("expected observed usage, got %+v %q", usage, used)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// +kubebuilder:rbac:groups=argoproj.io,resources=rollouts,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=serving.knative.dev,resources=services;configurations;revisions,verbs=get;list;watch;update;patch
+
+// updateCustomResizePolicy adds the in-place resize policy to the pod template
+// of a workload defined by a third-party CRD. Argo Rollouts and Knative
+// Services and Configurations all keep their pod template at spec.template;
+// a Rollout that references a Deployment through spec.workloadRef has that
+// Deployment's template updated instead.
+func (r *AdaptiveRightSizer) updateCustomResizePolicy(ctx context.Context, namespace string, target v1alpha1.RecommendationTargetRef) error {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(target.APIVersion)
+	obj.SetKind(target.Kind)
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: target.Name}, obj); err != nil {
+		return fmt.Errorf("failed to get %s: %w", target.Kind, err)
+	}
+
+	containers, found, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	if err != nil {
+		return fmt.Errorf("failed to read the pod template of %s %s/%s: %w", target.Kind, namespace, target.Name, err)
+	}
+	if !found {
+		ref, ok, _ := unstructured.NestedStringMap(obj.Object, "spec", "workloadRef")
+		if ok && ref["kind"] == "Deployment" && ref["name"] != "" {
+			return r.updateCustomResizePolicy(ctx, namespace, v1alpha1.RecommendationTargetRef{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       ref["name"],
+			})
+		}
+		return nil
+	}
+
+	// Check if resize policy needs to be added
+	needsUpdate := false
+	for i := range containers {
+		container, ok := containers[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var typed corev1.Container
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(container, &typed); err != nil {
+			return fmt.Errorf("failed to read container of %s %s/%s: %w", target.Kind, namespace, target.Name, err)
+		}
+		if hasCorrectResizePolicy(&typed) {
+			continue
+		}
+		container["resizePolicy"] = []interface{}{
+			map[string]interface{}{"resourceName": string(corev1.ResourceCPU), "restartPolicy": string(corev1.NotRequired)},
+			map[string]interface{}{"resourceName": string(corev1.ResourceMemory), "restartPolicy": string(corev1.NotRequired)},
+		}
+		needsUpdate = true
+	}
+	if !needsUpdate {
+		return nil
+	}
+
+	if err := unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers"); err != nil {
+		return err
+	}
+	// Record when the policy was added, as for the built-in workloads
+	if err := unstructured.SetNestedField(obj.Object, time.Now().Format(time.RFC3339),
+		"spec", "template", "metadata", "annotations", "right-sizer/resize-policy-added"); err != nil {
+		return err
+	}
+
+	if err := r.Client.Update(ctx, obj); err != nil {
+		return fmt.Errorf("failed to update %s with resize policy: %w", target.Kind, err)
+	}
+	log.Printf("✅ Updated %s %s/%s with resize policy", target.Kind, namespace, target.Name)
+	return nil
+}
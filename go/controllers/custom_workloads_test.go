@@ -0,0 +1,110 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func rollout(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetAPIVersion("argoproj.io/v1alpha1")
+	obj.SetKind("Rollout")
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	return obj
+}
+
+// TestEnsureParentHasResizePolicyRollout verifies a Rollout's pod template
+// gets the resize policy, as well as the Deployment a Rollout references
+func TestEnsureParentHasResizePolicyRollout(t *testing.T) {
+	controller := true
+	owned := func(apiVersion, kind, name string) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &controller}}
+	}
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "canary-abc", Namespace: "default", OwnerReferences: owned("argoproj.io/v1alpha1", "Rollout", "canary")}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "ref-abc", Namespace: "default", OwnerReferences: owned("argoproj.io/v1alpha1", "Rollout", "ref")}},
+		rollout("canary", map[string]interface{}{
+			"strategy": map[string]interface{}{"canary": map[string]interface{}{}},
+			"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "app", "image": "app:1"}},
+			}},
+		}),
+		rollout("ref", map[string]interface{}{
+			"workloadRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "ref"},
+		}),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "ref", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "app:1"}},
+			}}},
+		},
+	).Build()
+
+	cfg := config.GetDefaults()
+	cfg.UpdateResizePolicy = true
+	r := newAdaptiveTestRig(cfg)
+	r.Client = fakeClient
+	ctx := context.Background()
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "canary-abc-1", Namespace: "default", OwnerReferences: owned("apps/v1", "ReplicaSet", "canary-abc")}}
+	if err := r.ensureParentHasResizePolicy(ctx, pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	updated := rollout("canary", nil)
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "canary"}, updated); err != nil {
+		t.Fatalf("failed to get rollout: %v", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(updated.Object, "spec", "template", "spec", "containers")
+	var container corev1.Container
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(containers[0].(map[string]interface{}), &container); err != nil {
+		t.Fatalf("failed to convert container: %v", err)
+	}
+	if !hasCorrectResizePolicy(&container) || container.Image != "app:1" {
+		t.Errorf("expected the resize policy on the rollout's container, got %+v", container)
+	}
+	if _, ok, _ := unstructured.NestedMap(updated.Object, "spec", "strategy"); !ok {
+		t.Error("expected the rest of the rollout spec kept")
+	}
+
+	pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "ref-abc-1", Namespace: "default", OwnerReferences: owned("apps/v1", "ReplicaSet", "ref-abc")}}
+	if err := r.ensureParentHasResizePolicy(ctx, pod); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var deployment appsv1.Deployment
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "ref"}, &deployment); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	if !hasCorrectResizePolicy(&deployment.Spec.Template.Spec.Containers[0]) {
+		t.Errorf("expected the resize policy on the referenced deployment, got %+v", deployment.Spec.Template.Spec.Containers[0])
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Annotations right-sizer leaves on the pods it resized, and on the pod
+// templates of the workloads it exports patches for
+const (
+	lastResizeAnnotation        = "rightsizer.io/last-resize"
+	previousResourcesAnnotation = "rightsizer.io/previous-resources"
+	decisionIDAnnotation        = "rightsizer.io/decision-id"
+)
+
+// newDecisionID returns a unique id for a resize decision
+func newDecisionID() string {
+	return string(uuid.NewUUID())
+}
+
+// decisionAnnotations renders the annotations of a decision: when it was
+// made, its id and the resources of each container before it, as JSON keyed
+// by container name. Values left empty are left out.
+func decisionAnnotations(decided time.Time, decisionID string, previous map[string]corev1.ResourceRequirements) map[string]string {
+	annotations := make(map[string]string, 3)
+	if !decided.IsZero() {
+		annotations[lastResizeAnnotation] = decided.UTC().Format(time.RFC3339)
+	}
+	if decisionID != "" {
+		annotations[decisionIDAnnotation] = decisionID
+	}
+	if len(previous) > 0 {
+		if data, err := json.Marshal(previous); err == nil {
+			annotations[previousResourcesAnnotation] = string(data)
+		}
+	}
+	return annotations
+}
+
+// annotateDecision records an applied resize on the pod, so other tooling
+// and `kubectl describe` show who changed its resources and from what. The
+// previous resources of the pod's other containers are kept. pod is updated
+// with the patched pod.
+func (r *AdaptiveRightSizer) annotateDecision(ctx context.Context, pod *corev1.Pod, update ResourceUpdate, decided time.Time) error {
+	previous := make(map[string]corev1.ResourceRequirements)
+	if existing := pod.Annotations[previousResourcesAnnotation]; existing != "" {
+		// A value that is not ours is replaced
+		_ = json.Unmarshal([]byte(existing), &previous)
+	}
+	previous[update.ContainerName] = update.OldResources
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": decisionAnnotations(decided, update.DecisionID, previous),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return r.Client.Patch(ctx, pod, client.RawPatch(types.MergePatchType, patch))
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestAnnotateDecision verifies an applied resize is annotated on the pod,
+// keeping the previous resources of its other containers
+func TestAnnotateDecision(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	pod := createTestPod("web-1", "shop", "500m", "512Mi", "1", "1Gi")
+	pod.Annotations = map[string]string{
+		previousResourcesAnnotation: `{"sidecar":{"requests":{"cpu":"50m"}}}`,
+		"team":                      "checkout",
+	}
+	r := &AdaptiveRightSizer{Client: ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()}
+
+	update := ResourceUpdate{
+		Namespace:     "shop",
+		Name:          "web-1",
+		ContainerName: "test-container",
+		OldResources:  pod.Spec.Containers[0].Resources,
+		NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+		},
+		DecisionID: newDecisionID(),
+	}
+	decided := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	if err := r.annotateDecision(context.Background(), pod, update, decided); err != nil {
+		t.Fatalf("annotateDecision() error: %v", err)
+	}
+
+	var stored corev1.Pod
+	if err := r.Client.Get(context.Background(), types.NamespacedName{Namespace: "shop", Name: "web-1"}, &stored); err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	annotations := stored.Annotations
+	if annotations[lastResizeAnnotation] != "2026-10-16T09:30:00Z" || annotations[decisionIDAnnotation] != update.DecisionID {
+		t.Errorf("unexpected decision annotations %v", annotations)
+	}
+	if annotations["team"] != "checkout" {
+		t.Errorf("expected other annotations to be kept, got %v", annotations)
+	}
+	var previous map[string]corev1.ResourceRequirements
+	if err := json.Unmarshal([]byte(annotations[previousResourcesAnnotation]), &previous); err != nil {
+		t.Fatalf("invalid previous resources %q: %v", annotations[previousResourcesAnnotation], err)
+	}
+	if cpu := previous["test-container"].Requests[corev1.ResourceCPU]; cpu.String() != "500m" {
+		t.Errorf("expected the previous CPU request 500m, got %s", cpu.String())
+	}
+	if cpu := previous["sidecar"].Requests[corev1.ResourceCPU]; cpu.String() != "50m" {
+		t.Errorf("expected the sidecar's previous resources to be kept, got %v", previous)
+	}
+	if pod.Annotations[decisionIDAnnotation] != update.DecisionID {
+		t.Errorf("expected the pod to be refreshed with the patched annotations")
+	}
+}
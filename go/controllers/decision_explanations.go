@@ -0,0 +1,105 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/explain"
+	"right-sizer/metrics"
+	"right-sizer/predictor"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newExplanation starts the explanation of a container's decision, nil when
+// explanations are not kept
+func (r *AdaptiveRightSizer) newExplanation(pod *corev1.Pod, container corev1.Container, profile SizingProfile, policies []string, usage metrics.Metrics, decision ResourceScalingDecision) *explain.Decision {
+	if r.Explanations == nil {
+		return nil
+	}
+	return &explain.Decision{
+		Namespace:  pod.Namespace,
+		Workload:   audit.WorkloadOf(pod),
+		Pod:        pod.Name,
+		Container:  container.Name,
+		Window:     explain.Window{Algorithm: "latest", Profile: profile.Name()},
+		Usage:      explanationUsage(usage),
+		Thresholds: scalingThresholds(usage, container.Resources, decision, config.Get().ForNamespace(pod.Namespace)),
+		Policies:   policies,
+		Current:    *container.Resources.DeepCopy(),
+	}
+}
+
+// recordExplanations keeps the explanations of the decisions that made it
+// through every stage, with the resources they ended with
+func (r *AdaptiveRightSizer) recordExplanations(updates []ResourceUpdate) {
+	if r.Explanations == nil {
+		return
+	}
+	now := time.Now()
+	for _, update := range updates {
+		if update.Explanation == nil {
+			continue
+		}
+		decision := *update.Explanation
+		decision.Time = now
+		decision.Pod = update.Name
+		decision.Current = *update.OldResources.DeepCopy()
+		decision.Final = *update.NewResources.DeepCopy()
+		decision.Reason = update.Reason
+		r.Explanations.Record(decision)
+	}
+}
+
+func explanationUsage(usage metrics.Metrics) explain.Usage {
+	return explain.Usage{CPUMilli: usage.CPUMilli, MemMB: usage.MemMB, CPUThrottled: usage.CPUThrottled, CPUPressure: usage.CPUPressure, MemoryPressure: usage.MemoryPressure}
+}
+
+// scalingThresholds lists the thresholds checkScalingThresholds compared the
+// container's utilization against, and what it decided for each resource
+func scalingThresholds(usage metrics.Metrics, current corev1.ResourceRequirements, decision ResourceScalingDecision, cfg *config.Config) []explain.Threshold {
+	cpu, memory := resourceUtilization(usage, current)
+	thresholds := []explain.Threshold{
+		{Resource: "cpu", Utilization: cpu, ScaleUp: cfg.CPUScaleUpThreshold, ScaleDown: cfg.CPUScaleDownThreshold, Decision: scalingDecisionString(decision.CPU)},
+		{Resource: "memory", Utilization: memory, ScaleUp: cfg.MemoryScaleUpThreshold, ScaleDown: cfg.MemoryScaleDownThreshold, Decision: scalingDecisionString(decision.Memory)},
+	}
+	if cfg.CPUThrottleThreshold > 0 && usage.CPUThrottled > cfg.CPUThrottleThreshold {
+		thresholds = append(thresholds, explain.Threshold{
+			Resource:    "cpu-throttling",
+			Utilization: usage.CPUThrottled,
+			ScaleUp:     cfg.CPUThrottleThreshold,
+			Decision:    scalingDecisionString(ScaleUp),
+		})
+	}
+	return append(thresholds, pressureThresholds(usage, cfg)...)
+}
+
+// explainPrediction records a forecast considered for a resource
+func explainPrediction(explanation *explain.Decision, resource string, prediction *predictor.ResourcePrediction, used bool) {
+	if explanation == nil || prediction == nil {
+		return
+	}
+	explanation.Predictions = append(explanation.Predictions, explain.Prediction{
+		Resource:   resource,
+		Method:     string(prediction.Method),
+		Value:      prediction.Value,
+		Confidence: prediction.Confidence,
+		Used:       used,
+	})
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/config"
+	"right-sizer/explain"
+	"right-sizer/metrics"
+)
+
+func TestRecordExplanationsKeepsFinalResources(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Explanations = explain.NewStore()
+
+	pod := createTestPod("web-abc", "prod", "100m", "128Mi", "200m", "256Mi")
+	container := pod.Spec.Containers[0]
+	usage := metrics.Metrics{CPUMilli: 190, MemMB: 100}
+	decision := r.checkScalingThresholds(usage, container.Resources, config.Get())
+	explanation := r.newExplanation(pod, container, profileByName(ProfileSteady), []string{"web-policy"}, usage, decision)
+
+	update := plannedUpdate(*pod, "300m", "256Mi")
+	update.Explanation = explanation
+	explanation.AddStep("namespace-constraints", "cpu request clamped", update.NewResources)
+	r.recordExplanations([]ResourceUpdate{update})
+
+	decisions := r.Explanations.Latest("prod", "web-abc")
+	if len(decisions) != 1 {
+		t.Fatalf("expected 1 decision, got %d", len(decisions))
+	}
+	got := decisions[0]
+	if got.Container != "test-container" || got.Window.Profile != ProfileSteady {
+		t.Errorf("unexpected decision %s with profile %s", got.Container, got.Window.Profile)
+	}
+	if len(got.Thresholds) < 2 || got.Thresholds[0].Decision != "scale up" {
+		t.Errorf("expected CPU to cross its scale-up threshold, got %+v", got.Thresholds)
+	}
+	if cpu := got.Final.Requests["cpu"]; cpu.String() != "300m" {
+		t.Errorf("expected final CPU request 300m, got %s", cpu.String())
+	}
+	if len(got.Steps) != 1 || got.Steps[0].Stage != "namespace-constraints" {
+		t.Errorf("expected the namespace-constraints step, got %+v", got.Steps)
+	}
+}
+
+func TestNewExplanationWithoutStore(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	pod := createTestPod("web-abc", "prod", "100m", "128Mi", "200m", "256Mi")
+	if r.newExplanation(pod, pod.Spec.Containers[0], profileByName(ProfileSteady), nil, metrics.Metrics{}, ResourceScalingDecision{}) != nil {
+		t.Error("expected no explanation when explanations are not kept")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DecisionFilter lets builds of the operator add their own constraints to
+// resizes, such as company policies the built-in settings cannot express.
+// Filters are registered by name and enabled through the decisionFilters
+// list of the RightSizerConfig's globalConstraints.
+type DecisionFilter interface {
+	// Name is the name the filter is enabled by
+	Name() string
+	// Filter returns the update to apply, adjusted as needed. Returning an
+	// error vetoes the update; the error is logged as the reason.
+	Filter(ctx context.Context, pod *corev1.Pod, update ResourceUpdate) (ResourceUpdate, error)
+}
+
+var (
+	decisionFiltersMu sync.RWMutex
+	decisionFilters   = make(map[string]DecisionFilter)
+)
+
+// RegisterDecisionFilter makes a filter available by its name, replacing
+// any filter registered under the same name
+func RegisterDecisionFilter(filter DecisionFilter) {
+	decisionFiltersMu.Lock()
+	defer decisionFiltersMu.Unlock()
+	decisionFilters[filter.Name()] = filter
+}
+
+// LookupDecisionFilter returns the filter registered under name
+func LookupDecisionFilter(name string) (DecisionFilter, bool) {
+	decisionFiltersMu.RLock()
+	defer decisionFiltersMu.RUnlock()
+	filter, ok := decisionFilters[name]
+	return filter, ok
+}
+
+// DecisionFilters returns the names of the registered filters
+func DecisionFilters() []string {
+	decisionFiltersMu.RLock()
+	defer decisionFiltersMu.RUnlock()
+	names := make([]string, 0, len(decisionFilters))
+	for name := range decisionFilters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// filterDecisions runs the configured decision filters, in order, on every
+// update. Updates a filter vetoes are dropped. A configured filter that is
+// not registered holds back all updates, so a missing constraint is never
+// silently skipped.
+func (r *AdaptiveRightSizer) filterDecisions(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod, cfg *config.Config) []ResourceUpdate {
+	if len(cfg.DecisionFilters) == 0 || len(updates) == 0 {
+		return updates
+	}
+	filters := make([]DecisionFilter, 0, len(cfg.DecisionFilters))
+	for _, name := range cfg.DecisionFilters {
+		filter, ok := LookupDecisionFilter(name)
+		if !ok {
+			logger.Error("Decision filter %q is not registered, holding back %d resizes", name, len(updates))
+			return nil
+		}
+		filters = append(filters, filter)
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	result := updates[:0:0]
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok {
+			continue
+		}
+		vetoed := false
+		for _, filter := range filters {
+			adjusted, err := filter.Filter(ctx, pod, update)
+			if err != nil {
+				logger.Info("🚫 Decision filter %s vetoed resize of %s/%s/%s: %v", filter.Name(), update.Namespace, update.Name, update.ContainerName, err)
+				if r.OperatorMetrics != nil {
+					r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "decision_filter")
+				}
+				vetoed = true
+				break
+			}
+			update = adjusted
+		}
+		if !vetoed {
+			result = append(result, update)
+		}
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// teamFilter vetoes resizes of pods without a team label and caps CPU
+// requests at one core
+type teamFilter struct{}
+
+func (teamFilter) Name() string { return "team" }
+
+func (teamFilter) Filter(_ context.Context, pod *corev1.Pod, update ResourceUpdate) (ResourceUpdate, error) {
+	if pod.Labels["team"] == "" {
+		return update, errors.New("pod has no team label")
+	}
+	if cpu, ok := update.NewResources.Requests[corev1.ResourceCPU]; ok && cpu.MilliValue() > 1000 {
+		update.NewResources = *update.NewResources.DeepCopy()
+		update.NewResources.Requests[corev1.ResourceCPU] = resource.MustParse("1")
+	}
+	return update, nil
+}
+
+func TestFilterDecisions(t *testing.T) {
+	RegisterDecisionFilter(teamFilter{})
+	defer func() {
+		decisionFiltersMu.Lock()
+		delete(decisionFilters, "team")
+		decisionFiltersMu.Unlock()
+	}()
+
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "web-1", Labels: map[string]string{"team": "web"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "orphan-1"}},
+	}
+	update := func(pod, cpu string) ResourceUpdate {
+		return ResourceUpdate{Namespace: "shop", Name: pod, ContainerName: "app", NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		}}
+	}
+	updates := []ResourceUpdate{update("web-1", "2"), update("orphan-1", "500m")}
+	r := &AdaptiveRightSizer{}
+
+	cfg := config.GetDefaults()
+	if got := r.filterDecisions(context.Background(), updates, pods, cfg); len(got) != 2 {
+		t.Fatalf("expected updates to pass without filters, got %d", len(got))
+	}
+
+	cfg.DecisionFilters = []string{"team"}
+	got := r.filterDecisions(context.Background(), updates, pods, cfg)
+	if len(got) != 1 || got[0].Name != "web-1" {
+		t.Fatalf("expected only the labelled pod's update, got %+v", got)
+	}
+	if cpu := got[0].NewResources.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 1000 {
+		t.Errorf("expected the CPU request capped at 1 core, got %s", cpu.String())
+	}
+	if cpu := updates[0].NewResources.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 2000 {
+		t.Errorf("expected the original update untouched, got %s", cpu.String())
+	}
+
+	cfg.DecisionFilters = []string{"team", "missing"}
+	if got := r.filterDecisions(context.Background(), updates, pods, cfg); len(got) != 0 {
+		t.Errorf("expected an unregistered filter to hold back all updates, got %+v", got)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// disruptionBudget tracks the restarts a run spends against the
+// PodDisruptionBudgets of the pods it resizes. The allowed disruptions in a
+// budget's status do not drop until the restarted pods are observed, so the
+// run counts its own restarts rather than relying on the status alone.
+type disruptionBudget struct {
+	client client.Reader
+	pdbs   map[string][]policyv1.PodDisruptionBudget // by namespace
+	spent  map[string]int32                          // by namespace/name of the budget
+}
+
+func newDisruptionBudget(c client.Reader) *disruptionBudget {
+	return &disruptionBudget{
+		client: c,
+		pdbs:   make(map[string][]policyv1.PodDisruptionBudget),
+		spent:  make(map[string]int32),
+	}
+}
+
+// take reserves a disruption of the pod against every budget selecting it.
+// It returns the name of the budget that has none left when the pod cannot
+// be disrupted now.
+func (b *disruptionBudget) take(ctx context.Context, pod *corev1.Pod) (bool, string, error) {
+	pdbs, ok := b.pdbs[pod.Namespace]
+	if !ok {
+		var list policyv1.PodDisruptionBudgetList
+		if err := b.client.List(ctx, &list, client.InNamespace(pod.Namespace)); err != nil {
+			return false, "", fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+		}
+		pdbs = list.Items
+		b.pdbs[pod.Namespace] = pdbs
+	}
+
+	var matching []string
+	for i := range pdbs {
+		pdb := &pdbs[i]
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		key := pdb.Namespace + "/" + pdb.Name
+		if pdb.Status.DisruptionsAllowed-b.spent[key] <= 0 {
+			return false, pdb.Name, nil
+		}
+		matching = append(matching, key)
+	}
+	for _, key := range matching {
+		b.spent[key]++
+	}
+	return true, "", nil
+}
+
+// allowDisruption reports whether a resize may restart the pod. With
+// RespectPodDisruptionBudget set the restart is charged to the pod's
+// budgets, and refused when one has no disruptions left this run.
+func (r *AdaptiveRightSizer) allowDisruption(ctx context.Context, pod *corev1.Pod) bool {
+	if !config.Get().RespectPodDisruptionBudget {
+		return true
+	}
+	if r.disruptions == nil {
+		r.disruptions = newDisruptionBudget(r.Client)
+	}
+	allowed, pdb, err := r.disruptions.take(ctx, pod)
+	if err != nil {
+		logger.Warn("Deferring restart of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return false
+	}
+	if !allowed {
+		logger.Info("Deferring restart of pod %s/%s: PodDisruptionBudget %s allows no more disruptions", pod.Namespace, pod.Name, pdb)
+		if r.EventRecorder != nil {
+			r.EventRecorder.Event(pod, corev1.EventTypeNormal, "RestartDeferred",
+				fmt.Sprintf("Resize needs a restart that PodDisruptionBudget %s does not allow now; retrying on a later run", pdb))
+		}
+	}
+	return allowed
+}
+
+// evictForResize applies a resize the cluster cannot make in place by
+// evicting the pod, so its replacement is created with the recommendation
+// by the mutating webhook. The eviction API enforces the pod's
+// PodDisruptionBudgets again, and an eviction it refuses is retried on a
+// later run.
+func (r *AdaptiveRightSizer) evictForResize(ctx context.Context, pod *corev1.Pod, update ResourceUpdate) (string, error) {
+	if !r.allowDisruption(ctx, pod) {
+		return "Skipped resize (restart deferred by PodDisruptionBudget)", nil
+	}
+	eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}}
+	if err := r.ClientSet.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction); err != nil {
+		if k8serrors.IsTooManyRequests(err) {
+			logger.Info("Deferring eviction of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+			return "Skipped resize (eviction refused by PodDisruptionBudget)", nil
+		}
+		return "", fmt.Errorf("failed to evict pod for resize: %w", err)
+	}
+	if r.EventRecorder != nil {
+		r.EventRecorder.Event(pod, corev1.EventTypeNormal, "ResizeEviction",
+			fmt.Sprintf("Evicted to resize container %s, which cannot be resized in place", update.ContainerName))
+	}
+	return fmt.Sprintf("Evicted pod %s/%s to resize container %s", pod.Namespace, pod.Name, update.ContainerName), nil
+}
@@ -0,0 +1,452 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// Export formats and targets
+const (
+	ExportFormatStrategicMerge = "strategic-merge"
+	ExportFormatJSONPatch      = "json-patch"
+	ExportFormatKustomize      = "kustomize"
+
+	ExportTargetConfigMap = "configmap"
+	ExportTargetGit       = "git"
+
+	// kustomizationFile is the kustomization listing the exported patches
+	kustomizationFile = "kustomization.yaml"
+)
+
+// GitOpsExporter renders resize decisions as patches against the owning
+// workloads, for clusters where a GitOps controller would revert live
+// resizes. The patches are written to a ConfigMap for an external pipeline or
+// committed to a Git repository. Exported patches are only ever added or
+// updated, never removed: once merged they are what keeps the workload sized.
+type GitOpsExporter struct {
+	Client client.Client
+}
+
+// exportedWorkload accumulates the container resources of one workload
+type exportedWorkload struct {
+	namespace  string
+	target     v1alpha1.RecommendationTargetRef
+	containers map[string]corev1.ResourceRequirements
+	indexes    map[string]int
+	init       map[string]bool // containers found in spec.initContainers
+	order      []string
+	previous   map[string]corev1.ResourceRequirements // resources of the first replica seen
+	decisionID string
+	decided    time.Time
+}
+
+// Export groups the updates by owning workload, renders a patch for each and
+// writes the patches to the configured target
+func (e *GitOpsExporter) Export(ctx context.Context, updates []ResourceUpdate, export config.ExportConfig) error {
+	workloads := e.group(ctx, updates)
+	if len(workloads) == 0 {
+		return nil
+	}
+
+	files := make(map[string][]byte, len(workloads))
+	for _, wl := range workloads {
+		name, data, err := renderPatch(wl, export.Format)
+		if err != nil {
+			return err
+		}
+		files[name] = data
+	}
+
+	var err error
+	switch export.Target {
+	case ExportTargetGit:
+		err = e.pushToGit(ctx, files, export)
+	default:
+		err = e.writeConfigMap(ctx, files, export)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("📦 Exported %d workload patches to %s", len(files), export.Target)
+	return nil
+}
+
+// group collects the updates per workload. Replicas of the same workload may
+// disagree; the larger resources are kept, as in recommendation-only mode.
+func (e *GitOpsExporter) group(ctx context.Context, updates []ResourceUpdate) []*exportedWorkload {
+	byKey := make(map[string]*exportedWorkload)
+	var workloads []*exportedWorkload
+
+	for _, update := range updates {
+		var pod corev1.Pod
+		if err := e.Client.Get(ctx, types.NamespacedName{Namespace: update.Namespace, Name: update.Name}, &pod); err != nil {
+			logger.Warn("Failed to get pod %s/%s for export: %v", update.Namespace, update.Name, err)
+			continue
+		}
+
+		target := resolveWorkloadRef(ctx, e.Client, &pod)
+		key := update.Namespace + "/" + recommendationName(target)
+		wl, ok := byKey[key]
+		if !ok {
+			wl = &exportedWorkload{
+				namespace:  update.Namespace,
+				target:     target,
+				containers: make(map[string]corev1.ResourceRequirements),
+				indexes:    make(map[string]int),
+				init:       make(map[string]bool),
+				previous:   make(map[string]corev1.ResourceRequirements),
+				decisionID: newDecisionID(),
+				decided:    time.Now(),
+			}
+			byKey[key] = wl
+			workloads = append(workloads, wl)
+		}
+
+		resources := *update.NewResources.DeepCopy()
+		if existing, ok := wl.containers[update.ContainerName]; ok {
+			resources.Requests = maxResourceList(existing.Requests, resources.Requests)
+			resources.Limits = maxResourceList(existing.Limits, resources.Limits)
+			resources.Claims = existing.Claims
+		} else {
+			wl.order = append(wl.order, update.ContainerName)
+			if container, index, init := findContainer(&pod, update.ContainerName); container != nil {
+				wl.indexes[update.ContainerName] = index
+				wl.init[update.ContainerName] = init
+				wl.previous[update.ContainerName] = *container.Resources.DeepCopy()
+				// JSON patches replace the whole resources, so the resource
+				// claims (DRA) are carried over
+				resources.Claims = container.Resources.DeepCopy().Claims
+			}
+		}
+		wl.containers[update.ContainerName] = resources
+	}
+	return workloads
+}
+
+// exportFileName returns the file name of a workload's patch. Names are flat
+// so they are valid ConfigMap keys and mount as files next to the kustomization.
+func exportFileName(wl *exportedWorkload, extension string) string {
+	return wl.namespace + "." + recommendationName(wl.target) + extension
+}
+
+// podSpecPath returns the path of the pod template's spec within a workload
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return []string{"spec", "template", "spec"}
+	}
+}
+
+// renderPatch renders the patch of one workload in the given format
+func renderPatch(wl *exportedWorkload, format string) (string, []byte, error) {
+	if format == ExportFormatJSONPatch {
+		base := "/" + strings.Join(podSpecPath(wl.target.Kind), "/")
+		ops := make([]map[string]interface{}, 0, len(wl.order))
+		for _, name := range wl.order {
+			list := "containers"
+			if wl.init[name] {
+				list = "initContainers"
+			}
+			ops = append(ops, map[string]interface{}{
+				"op":    "add",
+				"path":  fmt.Sprintf("%s/%s/%d/resources", base, list, wl.indexes[name]),
+				"value": wl.containers[name],
+			})
+		}
+		data, err := json.MarshalIndent(ops, "", "  ")
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to render patch for %s/%s: %w", wl.namespace, wl.target.Name, err)
+		}
+		return exportFileName(wl, ".json"), append(data, '\n'), nil
+	}
+
+	// Strategic merge patches merge containers by name, so indexes are not needed
+	var containers, initContainers []map[string]interface{}
+	for _, name := range wl.order {
+		entry := map[string]interface{}{
+			"name":      name,
+			"resources": wl.containers[name],
+		}
+		if wl.init[name] {
+			initContainers = append(initContainers, entry)
+		} else {
+			containers = append(containers, entry)
+		}
+	}
+	podSpec := make(map[string]interface{})
+	if len(containers) > 0 {
+		podSpec["containers"] = containers
+	}
+	if len(initContainers) > 0 {
+		podSpec["initContainers"] = initContainers
+	}
+	var spec interface{} = podSpec
+	path := podSpecPath(wl.target.Kind)
+	metadata := map[string]interface{}{"name": wl.target.Name, "namespace": wl.namespace}
+	// The decision is annotated on the pod template, or on a bare pod itself
+	if annotations := decisionAnnotations(wl.decided, wl.decisionID, wl.previous); len(annotations) > 0 {
+		if wl.target.Kind == "Pod" {
+			metadata["annotations"] = annotations
+		} else {
+			spec = map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": annotations},
+				"spec":     podSpec,
+			}
+			path = path[:len(path)-1]
+		}
+	}
+	for i := len(path) - 1; i > 0; i-- {
+		spec = map[string]interface{}{path[i]: spec}
+	}
+
+	data, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": wl.target.APIVersion,
+		"kind":       wl.target.Kind,
+		"metadata":   metadata,
+		path[0]:      spec,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render patch for %s/%s: %w", wl.namespace, wl.target.Name, err)
+	}
+	return exportFileName(wl, ".yaml"), data, nil
+}
+
+// renderKustomization lists every exported patch file. Kustomize reads the
+// target of a strategic merge patch from the patch itself.
+func renderKustomization(fileNames []string) ([]byte, error) {
+	var patches []map[string]string
+	for _, name := range fileNames {
+		if name != kustomizationFile && strings.HasSuffix(name, ".yaml") {
+			patches = append(patches, map[string]string{"path": name})
+		}
+	}
+	sort.Slice(patches, func(i, j int) bool { return patches[i]["path"] < patches[j]["path"] })
+
+	return yaml.Marshal(map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"patches":    patches,
+	})
+}
+
+// writeConfigMap merges the patches into the export ConfigMap
+func (e *GitOpsExporter) writeConfigMap(ctx context.Context, files map[string][]byte, export config.ExportConfig) error {
+	key := types.NamespacedName{Namespace: export.ConfigMapNamespace, Name: export.ConfigMapName}
+	if key.Namespace == "" {
+		key.Namespace = operatorNamespace()
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := e.Client.Get(ctx, key, cm)
+	create := k8serrors.IsNotFound(err)
+	if err != nil && !create {
+		return fmt.Errorf("failed to get export ConfigMap %s: %w", key, err)
+	}
+	if create {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/managed-by": "right-sizer",
+				},
+			},
+		}
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	for name, data := range files {
+		cm.Data[name] = string(data)
+	}
+	if export.Format == ExportFormatKustomize {
+		names := make([]string, 0, len(cm.Data))
+		for name := range cm.Data {
+			names = append(names, name)
+		}
+		kustomization, err := renderKustomization(names)
+		if err != nil {
+			return fmt.Errorf("failed to render kustomization: %w", err)
+		}
+		cm.Data[kustomizationFile] = string(kustomization)
+	}
+
+	if create {
+		err = e.Client.Create(ctx, cm)
+	} else {
+		err = e.Client.Update(ctx, cm)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export ConfigMap %s: %w", key, err)
+	}
+	return nil
+}
+
+// gitExportAvailable reports whether the git export target can run. The
+// shipped distroless images have no git binary, so the target only works in
+// custom images that add one.
+var gitExportAvailable = func() error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git export target requires the git binary in the operator image: %w", err)
+	}
+	return nil
+}
+
+// pushToGit commits the patches to the export repository with the git CLI.
+// The access token is passed to git through the environment, so it never
+// appears in the command line, the remote URL or error output.
+func (e *GitOpsExporter) pushToGit(ctx context.Context, files map[string][]byte, export config.ExportConfig) error {
+	if export.GitRepository == "" {
+		return fmt.Errorf("git export target requires a repository")
+	}
+	if err := gitExportAvailable(); err != nil {
+		return err
+	}
+
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if export.GitAuthSecretName != "" {
+		token, err := e.gitToken(ctx, export)
+		if err != nil {
+			return err
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials,
+		)
+	}
+
+	dir, err := os.MkdirTemp("", "right-sizer-export-")
+	if err != nil {
+		return fmt.Errorf("failed to create export workspace: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = env
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			return out, fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+		}
+		return out, nil
+	}
+
+	if _, err := git("clone", "--depth", "1", "--branch", export.GitBranch, export.GitRepository, "."); err != nil {
+		return err
+	}
+
+	patchDir := filepath.Join(dir, filepath.Clean("/"+export.GitPath))
+	if err := os.MkdirAll(patchDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(patchDir, name), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	if export.Format == ExportFormatKustomize {
+		entries, err := os.ReadDir(patchDir)
+		if err != nil {
+			return fmt.Errorf("failed to list export directory: %w", err)
+		}
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+		kustomization, err := renderKustomization(names)
+		if err != nil {
+			return fmt.Errorf("failed to render kustomization: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(patchDir, kustomizationFile), kustomization, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", kustomizationFile, err)
+		}
+	}
+
+	if _, err := git("add", "--all", "."); err != nil {
+		return err
+	}
+	if status, err := git("status", "--porcelain"); err != nil {
+		return err
+	} else if len(bytes.TrimSpace(status)) == 0 {
+		logger.Debug("Exported patches already up to date in %s", export.GitRepository)
+		return nil
+	}
+	if _, err := git("-c", "user.name="+export.GitAuthorName, "-c", "user.email="+export.GitAuthorEmail,
+		"commit", "-m", fmt.Sprintf("right-sizer: update resources of %d workloads", len(files))); err != nil {
+		return err
+	}
+	_, err = git("push", "origin", "HEAD:"+export.GitBranch)
+	return err
+}
+
+// gitToken reads the export access token from the operator's namespace
+func (e *GitOpsExporter) gitToken(ctx context.Context, export config.ExportConfig) (string, error) {
+	key := types.NamespacedName{Namespace: operatorNamespace(), Name: export.GitAuthSecretName}
+	var secret corev1.Secret
+	if err := e.Client.Get(ctx, key, &secret); err != nil {
+		return "", fmt.Errorf("failed to get git credentials secret %s: %w", key, err)
+	}
+
+	secretKey := export.GitAuthSecretKey
+	if secretKey == "" {
+		secretKey = "token"
+	}
+	token, ok := secret.Data[secretKey]
+	if !ok || len(token) == 0 {
+		return "", fmt.Errorf("git credentials secret %s has no %q key", key, secretKey)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+// operatorNamespace returns the namespace the operator runs in
+func operatorNamespace() string {
+	if namespace := os.Getenv("OPERATOR_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return "right-sizer"
+}
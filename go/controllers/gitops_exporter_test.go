@@ -0,0 +1,173 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"right-sizer/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+// newExporter returns an exporter seeing two replicas of the web Deployment
+func newExporter(t *testing.T) *GitOpsExporter {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	controller := true
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller},
+			},
+		},
+	}
+	pod1 := runningReplica("web-abc-1", "web-abc", "100m", "128Mi")
+	pod2 := runningReplica("web-abc-2", "web-abc", "100m", "128Mi")
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(rs, &pod1, &pod2).Build()
+	return &GitOpsExporter{Client: fakeClient}
+}
+
+func exportConfig(format string) config.ExportConfig {
+	export := config.GetDefaults().Export
+	export.Enabled = true
+	export.Format = format
+	export.ConfigMapNamespace = "right-sizer"
+	return export
+}
+
+func exportedConfigMap(t *testing.T, e *GitOpsExporter) *corev1.ConfigMap {
+	t.Helper()
+	var cm corev1.ConfigMap
+	if err := e.Client.Get(context.Background(), types.NamespacedName{Namespace: "right-sizer", Name: "right-sizer-export"}, &cm); err != nil {
+		t.Fatalf("expected export ConfigMap: %v", err)
+	}
+	return &cm
+}
+
+// TestGitOpsExporterStrategicMerge verifies replicas produce one patch against the Deployment
+func TestGitOpsExporterStrategicMerge(t *testing.T) {
+	e := newExporter(t)
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-1", "200m", "256Mi"),
+		requestUpdate("web-abc-2", "300m", "192Mi"),
+	}
+	if err := e.Export(context.Background(), updates, exportConfig(ExportFormatStrategicMerge)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm := exportedConfigMap(t, e)
+	if len(cm.Data) != 1 {
+		t.Fatalf("expected one patch, got %v", cm.Data)
+	}
+	var patch appsv1.Deployment
+	if err := yaml.Unmarshal([]byte(cm.Data["default.deployment-web.yaml"]), &patch); err != nil {
+		t.Fatalf("invalid patch: %v", err)
+	}
+	if patch.Kind != "Deployment" || patch.Name != "web" || patch.Namespace != "default" {
+		t.Errorf("expected patch against default/web Deployment, got %s %s/%s", patch.Kind, patch.Namespace, patch.Name)
+	}
+	containers := patch.Spec.Template.Spec.Containers
+	if len(containers) != 1 || containers[0].Name != "app" {
+		t.Fatalf("expected one app container, got %+v", containers)
+	}
+	if cpu := containers[0].Resources.Requests.Cpu(); cpu.MilliValue() != 300 {
+		t.Errorf("expected the larger CPU request 300m, got %s", cpu)
+	}
+	if mem := containers[0].Resources.Requests.Memory(); mem.String() != "256Mi" {
+		t.Errorf("expected the larger memory request 256Mi, got %s", mem)
+	}
+	annotations := patch.Spec.Template.Annotations
+	if annotations[decisionIDAnnotation] == "" || annotations[lastResizeAnnotation] == "" {
+		t.Errorf("expected the decision annotated on the pod template, got %v", annotations)
+	}
+	if previous := annotations[previousResourcesAnnotation]; !strings.Contains(previous, `"app":`) || !strings.Contains(previous, `"cpu":"100m"`) {
+		t.Errorf("expected the previous resources of app, got %q", previous)
+	}
+}
+
+func TestGitOpsExporterJSONPatch(t *testing.T) {
+	e := newExporter(t)
+	if err := e.Export(context.Background(), []ResourceUpdate{requestUpdate("web-abc-1", "200m", "256Mi")}, exportConfig(ExportFormatJSONPatch)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ops []map[string]interface{}
+	if err := json.Unmarshal([]byte(exportedConfigMap(t, e).Data["default.deployment-web.json"]), &ops); err != nil {
+		t.Fatalf("invalid patch: %v", err)
+	}
+	if len(ops) != 1 || ops[0]["op"] != "add" || ops[0]["path"] != "/spec/template/spec/containers/0/resources" {
+		t.Fatalf("expected one add op on the container resources, got %v", ops)
+	}
+}
+
+// TestGitOpsExporterKustomize verifies patches from earlier runs are kept and listed in the kustomization
+func TestGitOpsExporterKustomize(t *testing.T) {
+	e := newExporter(t)
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "right-sizer-export", Namespace: "right-sizer"},
+		Data:       map[string]string{"default.deployment-api.yaml": "kind: Deployment\n"},
+	}
+	if err := e.Client.Create(context.Background(), existing); err != nil {
+		t.Fatalf("failed to seed ConfigMap: %v", err)
+	}
+
+	if err := e.Export(context.Background(), []ResourceUpdate{requestUpdate("web-abc-1", "200m", "256Mi")}, exportConfig(ExportFormatKustomize)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm := exportedConfigMap(t, e)
+	if _, ok := cm.Data["default.deployment-api.yaml"]; !ok {
+		t.Error("expected the patch of an earlier run to be kept")
+	}
+	kustomization := cm.Data[kustomizationFile]
+	for _, path := range []string{"default.deployment-api.yaml", "default.deployment-web.yaml"} {
+		if !strings.Contains(kustomization, "path: "+path) {
+			t.Errorf("expected kustomization to list %s, got:\n%s", path, kustomization)
+		}
+	}
+}
+
+func TestPodSpecPath(t *testing.T) {
+	wl := &exportedWorkload{
+		namespace:  "default",
+		containers: map[string]corev1.ResourceRequirements{"app": requestUpdate("job", "100m", "64Mi").NewResources},
+		order:      []string{"app"},
+	}
+	wl.target.APIVersion, wl.target.Kind, wl.target.Name = "batch/v1", "CronJob", "nightly"
+
+	_, data, err := renderPatch(wl, ExportFormatStrategicMerge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(data), "jobTemplate:") {
+		t.Errorf("expected CronJob patch through the job template, got:\n%s", data)
+	}
+}
@@ -43,6 +43,10 @@ func (m *MockMetricsProvider) FetchPodMetrics(ctx context.Context, namespace, na
 	}, nil
 }
 
+func (m *MockMetricsProvider) FetchContainerMetrics(ctx context.Context, namespace, name string) (metrics.ContainerMetrics, error) {
+	return metrics.ContainerMetrics{}, nil
+}
+
 func (m *MockMetricsProvider) FetchNodeMetrics(nodeName string) (metrics.Metrics, error) {
 	return metrics.Metrics{}, nil
 }
@@ -0,0 +1,237 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/logger"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch
+
+// horizontalKinds are the workload kinds replica counts are suggested for
+var horizontalKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+}
+
+// replicaAdviceInput is what a replica count suggestion is based on
+type replicaAdviceInput struct {
+	replicas   int                                // replicas the workload runs
+	cpuUsage   float64                            // millicores used by all replicas together
+	containers []v1alpha1.ContainerRecommendation // vertical recommendation of each container
+	hpa        string                             // HorizontalPodAutoscaler scaling the workload, if any
+	minRep     int                                // fewest replicas to suggest
+	maxRep     int                                // most replicas to suggest
+	targetCPU  float64                            // CPU utilization the HPA keeps (0-1), 0 without one
+}
+
+// adviseReplicas compares running the workload on its current replicas with
+// the vertical recommendation against running it on every other replica
+// count in bounds, and returns the cheapest when it saves at least the
+// configured percentage. CPU is split across the replicas: together they
+// request what the vertical recommendation requests across the current
+// replicas, or, under an HPA with a CPU utilization target, the usage at that
+// target. Memory is assumed to be a per-replica footprint that does not
+// shrink with more replicas, so fewer, larger replicas save the memory of the
+// ones removed.
+func adviseReplicas(in replicaAdviceInput, pricing *cost.Pricing, cfg *config.Config) *v1alpha1.HorizontalRecommendation {
+	if in.replicas < 1 || in.minRep < 1 || in.maxRep < in.minRep {
+		return nil
+	}
+	var cpu, memory int64
+	for _, container := range in.containers {
+		cpu += container.Recommended.Requests.Cpu().MilliValue()
+		memory += container.Recommended.Requests.Memory().Value()
+	}
+	if cpu <= 0 {
+		return nil
+	}
+
+	demand := float64(cpu) * float64(in.replicas)
+	if in.targetCPU > 0 && in.cpuUsage > 0 {
+		demand = in.cpuUsage / in.targetCPU
+	}
+	cpuPerReplica := func(replicas int) int64 {
+		return max(int64(math.Ceil(demand/float64(replicas))), cfg.MinCPURequest)
+	}
+
+	vertical := float64(in.replicas) * pricing.MonthlyCost(cpu, memory)
+	best, bestCost := 0, vertical
+	for replicas := in.minRep; replicas <= in.maxRep; replicas++ {
+		perReplica := cpuPerReplica(replicas)
+		if cfg.MaxCPULimit > 0 && perReplica > cfg.MaxCPULimit {
+			continue
+		}
+		if total := float64(replicas) * pricing.MonthlyCost(perReplica, memory); total < bestCost {
+			best, bestCost = replicas, total
+		}
+	}
+	if best == 0 || vertical <= 0 {
+		return nil
+	}
+	savings := int32((vertical - bestCost) / vertical * 100)
+	if best == in.replicas || int(savings) < cfg.HorizontalAdvice.MinSavingsPercent {
+		return nil
+	}
+
+	perReplica := cpuPerReplica(best)
+	scale := float64(perReplica) / float64(cpu)
+	advice := &v1alpha1.HorizontalRecommendation{
+		CurrentReplicas:         int32(in.replicas),
+		RecommendedReplicas:     int32(best),
+		HorizontalPodAutoscaler: in.hpa,
+		SavingsPercent:          savings,
+		Reason: fmt.Sprintf("%d replicas at %dm CPU would cost %d%% less than %d replicas at %dm CPU",
+			best, perReplica, savings, in.replicas, cpu),
+	}
+	if in.hpa != "" {
+		advice.Reason += fmt.Sprintf("; HorizontalPodAutoscaler %s settles at %d replicas with these requests", in.hpa, best)
+	}
+	for _, container := range in.containers {
+		recommended := *container.Recommended.DeepCopy()
+		scaleCPU(recommended.Requests, scale)
+		scaleCPU(recommended.Limits, scale)
+		advice.Containers = append(advice.Containers, v1alpha1.HorizontalContainerRecommendation{
+			ContainerName: container.ContainerName,
+			Recommended:   recommended,
+		})
+	}
+	return advice
+}
+
+// scaleCPU multiplies the CPU in a resource list by factor
+func scaleCPU(list corev1.ResourceList, factor float64) {
+	if cpu, ok := list[corev1.ResourceCPU]; ok {
+		list[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(math.Ceil(float64(cpu.MilliValue())*factor)), resource.DecimalSI)
+	}
+}
+
+// horizontalAdvice returns the replica count suggestion for a workload, or
+// nil when there is none
+func (w *RecommendationWriter) horizontalAdvice(ctx context.Context, wl *workloadRecommendation, cfg *config.Config) *v1alpha1.HorizontalRecommendation {
+	if !cfg.HorizontalAdvice.Enabled || !horizontalKinds[wl.target.Kind] || len(wl.cpuUsage) == 0 {
+		return nil
+	}
+
+	replicas, err := w.workloadReplicas(ctx, wl.namespace, wl.target)
+	if err != nil {
+		logger.Debug("Not advising replicas of %s %s/%s: %v", wl.target.Kind, wl.namespace, wl.target.Name, err)
+		return nil
+	}
+	in := replicaAdviceInput{
+		replicas: replicas,
+		minRep:   min(replicas, cfg.HorizontalAdvice.MinReplicas),
+		maxRep:   max(2*replicas, cfg.HorizontalAdvice.MinReplicas),
+	}
+	var used float64
+	for _, cpu := range wl.cpuUsage {
+		used += cpu
+	}
+	// Replicas without a decision are assumed to use as much as the others
+	in.cpuUsage = used / float64(len(wl.cpuUsage)) * float64(replicas)
+	for _, name := range wl.order {
+		in.containers = append(in.containers, *wl.containers[name])
+	}
+
+	if hpa := w.workloadHPA(ctx, wl.namespace, wl.target); hpa != nil {
+		in.hpa = hpa.Name
+		in.minRep = 1
+		if hpa.Spec.MinReplicas != nil {
+			in.minRep = int(*hpa.Spec.MinReplicas)
+		}
+		in.maxRep = int(hpa.Spec.MaxReplicas)
+		if hpa.Status.CurrentReplicas > 0 {
+			in.replicas = int(hpa.Status.CurrentReplicas)
+		}
+		in.targetCPU = hpaCPUTarget(hpa)
+	}
+
+	pricing := cost.EstimatedPricing()
+	if w.Pricing != nil {
+		pricing = w.Pricing.Pricing(ctx)
+	}
+	return adviseReplicas(in, pricing, cfg)
+}
+
+// workloadReplicas returns the desired replicas of a Deployment or StatefulSet
+func (w *RecommendationWriter) workloadReplicas(ctx context.Context, namespace string, target v1alpha1.RecommendationTargetRef) (int, error) {
+	key := types.NamespacedName{Namespace: namespace, Name: target.Name}
+	var replicas *int32
+	switch target.Kind {
+	case "Deployment":
+		var deployment appsv1.Deployment
+		if err := w.Client.Get(ctx, key, &deployment); err != nil {
+			return 0, err
+		}
+		replicas = deployment.Spec.Replicas
+	case "StatefulSet":
+		var sts appsv1.StatefulSet
+		if err := w.Client.Get(ctx, key, &sts); err != nil {
+			return 0, err
+		}
+		replicas = sts.Spec.Replicas
+	default:
+		return 0, fmt.Errorf("unsupported kind %s", target.Kind)
+	}
+	if replicas == nil {
+		return 1, nil
+	}
+	return int(*replicas), nil
+}
+
+// workloadHPA returns the HorizontalPodAutoscaler scaling the workload, if any
+func (w *RecommendationWriter) workloadHPA(ctx context.Context, namespace string, target v1alpha1.RecommendationTargetRef) *autoscalingv2.HorizontalPodAutoscaler {
+	var list autoscalingv2.HorizontalPodAutoscalerList
+	if err := w.Client.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		logger.Debug("Failed to list HorizontalPodAutoscalers in %s: %v", namespace, err)
+		return nil
+	}
+	for i := range list.Items {
+		ref := list.Items[i].Spec.ScaleTargetRef
+		if ref.Kind == target.Kind && ref.Name == target.Name {
+			return &list.Items[i]
+		}
+	}
+	return nil
+}
+
+// hpaCPUTarget returns the CPU utilization an HPA keeps as a fraction, or 0
+// when it does not scale on CPU utilization
+func hpaCPUTarget(hpa *autoscalingv2.HorizontalPodAutoscaler) float64 {
+	for _, metric := range hpa.Spec.Metrics {
+		if metric.Type != autoscalingv2.ResourceMetricSourceType || metric.Resource == nil || metric.Resource.Name != corev1.ResourceCPU {
+			continue
+		}
+		if target := metric.Resource.Target; target.Type == autoscalingv2.UtilizationMetricType && target.AverageUtilization != nil {
+			return float64(*target.AverageUtilization) / 100
+		}
+	}
+	return 0
+}
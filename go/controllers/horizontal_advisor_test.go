@@ -0,0 +1,156 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/cost"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// unitPricing prices a CPU core and a GiB of memory alike
+var unitPricing = &cost.Pricing{CPUCoreHourCost: 1, RAMGiBHourCost: 1}
+
+func appRecommendation(cpu, mem string) []v1alpha1.ContainerRecommendation {
+	return []v1alpha1.ContainerRecommendation{{
+		ContainerName: "app",
+		Recommended: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu), corev1.ResourceMemory: resource.MustParse(mem)},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+		},
+	}}
+}
+
+// TestAdviseReplicasConsolidates verifies fewer, larger replicas are suggested
+// when they save the memory of the replicas removed
+func TestAdviseReplicasConsolidates(t *testing.T) {
+	cfg := config.GetDefaults()
+	in := replicaAdviceInput{replicas: 6, containers: appRecommendation("300m", "1Gi"), minRep: 2, maxRep: 12}
+
+	advice := adviseReplicas(in, unitPricing, cfg)
+	if advice == nil || advice.RecommendedReplicas != 2 || advice.CurrentReplicas != 6 {
+		t.Fatalf("expected 2 instead of 6 replicas, got %+v", advice)
+	}
+	if advice.SavingsPercent != 51 {
+		t.Errorf("expected 51%% savings, got %d", advice.SavingsPercent)
+	}
+	got := advice.Containers[0].Recommended
+	if got.Requests.Cpu().MilliValue() != 900 || got.Limits.Cpu().MilliValue() != 900 || got.Requests.Memory().Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Fatalf("expected 900m CPU and unchanged memory per replica, got %v", got)
+	}
+}
+
+// TestAdviseReplicasBounds verifies replicas are kept within the CPU limit
+// and small savings are not suggested
+func TestAdviseReplicasBounds(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.MaxCPULimit = 1000
+	in := replicaAdviceInput{replicas: 6, containers: appRecommendation("300m", "1Gi"), minRep: 1, maxRep: 12}
+	if advice := adviseReplicas(in, unitPricing, cfg); advice == nil || advice.RecommendedReplicas != 2 {
+		t.Fatalf("expected 2 replicas within the 1000m CPU limit, got %+v", advice)
+	}
+
+	cfg.HorizontalAdvice.MinSavingsPercent = 90
+	if advice := adviseReplicas(in, unitPricing, cfg); advice != nil {
+		t.Fatalf("expected no suggestion below the minimum savings, got %+v", advice)
+	}
+}
+
+// TestAdviseReplicasHPATarget verifies CPU is sized to the HPA's utilization target
+func TestAdviseReplicasHPATarget(t *testing.T) {
+	cfg := config.GetDefaults()
+	in := replicaAdviceInput{
+		replicas:   4,
+		cpuUsage:   600,
+		containers: appRecommendation("500m", "1Gi"),
+		hpa:        "web",
+		minRep:     2,
+		maxRep:     10,
+		targetCPU:  0.6,
+	}
+
+	advice := adviseReplicas(in, unitPricing, cfg)
+	if advice == nil || advice.RecommendedReplicas != 2 || advice.HorizontalPodAutoscaler != "web" {
+		t.Fatalf("expected 2 replicas under HPA web, got %+v", advice)
+	}
+	if cpu := advice.Containers[0].Recommended.Requests.Cpu().MilliValue(); cpu != 500 {
+		t.Fatalf("expected 500m per replica to keep 600m of usage at 60%%, got %dm", cpu)
+	}
+	if !strings.Contains(advice.Reason, "HorizontalPodAutoscaler web") {
+		t.Fatalf("expected the HPA in the reason, got %q", advice.Reason)
+	}
+}
+
+// TestRecommendationWriterHorizontalAdvice verifies the suggestion is
+// published with the workload's recommendation
+func TestRecommendationWriterHorizontalAdvice(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = autoscalingv2.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	controller := true
+	replicas := int32(4)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-abc",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+		},
+	}
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(deployment, rs, newOwnedPod("web-abc-1", "web-abc"), newOwnedPod("web-abc-2", "web-abc")).
+		WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).
+		Build()
+
+	cfg := config.GetDefaults()
+	cfg.HorizontalAdvice.Enabled = true
+	writer := &RecommendationWriter{Client: fakeClient}
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-1", "200m", "2Gi"),
+		requestUpdate("web-abc-2", "200m", "2Gi"),
+	}
+	if err := writer.Write(context.Background(), updates, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rec v1alpha1.RightSizerRecommendation
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "deployment-web"}, &rec); err != nil {
+		t.Fatalf("expected recommendation for deployment web: %v", err)
+	}
+	if rec.Status.Horizontal == nil || rec.Status.Horizontal.RecommendedReplicas != 2 || rec.Status.Horizontal.CurrentReplicas != 4 {
+		t.Fatalf("expected a suggestion of 2 instead of 4 replicas, got %+v", rec.Status.Horizontal)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/events"
+	"right-sizer/logger"
+)
+
+// reportIdleWorkloads ends the idle detection cycle: workloads that just
+// became idle are logged and, when enabled, notified, and the idle gauge is
+// refreshed. Idle workloads are not resized differently; they are only
+// candidates for someone to scale to zero or remove.
+func (r *AdaptiveRightSizer) reportIdleWorkloads(now time.Time, cfg config.IdleConfig) {
+	for _, w := range r.Idle.EndCycle(now, cfg) {
+		logger.Info("💤 Workload %s/%s has used at most %.0fm CPU for %s and looks idle", w.Namespace, w.Workload, cfg.CPUMilli, w.IdleFor)
+		if !cfg.Notify || r.EventBus == nil {
+			continue
+		}
+		message := fmt.Sprintf("%s has used at most %.0fm CPU for %s. It may be scaled to zero or removed.", w.Workload, cfg.CPUMilli, w.IdleFor)
+		event := events.NewEvent(events.EventWorkloadIdle, config.Get().ClusterID, w.Namespace, w.Workload, events.SeverityWarning, message).
+			WithDetails(map[string]interface{}{
+				"idleSince": w.IdleSince,
+				"cpuMilli":  w.CPUMilli,
+				"pods":      w.Pods,
+			}).
+			WithTags("idle")
+		r.EventBus.PublishAsync(event)
+	}
+	if r.OperatorMetrics != nil {
+		r.OperatorMetrics.SetIdleWorkloads(r.Idle.Idle(now), now)
+	}
+}
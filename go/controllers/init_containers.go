@@ -0,0 +1,218 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// initPeakRetention is how long the peak usage of an init container is kept
+// after it was last seen running
+const initPeakRetention = 7 * 24 * time.Hour
+
+// podContainer is a container of a pod with its index in spec.containers or,
+// for native sidecars and init containers, in spec.initContainers
+type podContainer struct {
+	container corev1.Container
+	index     int
+	init      bool
+}
+
+// isSidecar reports whether an init container is a native sidecar: a
+// restartable init container that keeps running next to the app containers
+func isSidecar(container *corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// resizableContainers returns the app containers and native sidecars of a
+// pod, the containers that run for the pod's lifetime and can be resized in place
+func resizableContainers(pod *corev1.Pod) []podContainer {
+	containers := make([]podContainer, 0, len(pod.Spec.Containers)+len(pod.Spec.InitContainers))
+	for i, container := range pod.Spec.Containers {
+		containers = append(containers, podContainer{container: container, index: i})
+	}
+	for i, container := range pod.Spec.InitContainers {
+		if isSidecar(&container) {
+			containers = append(containers, podContainer{container: container, index: i, init: true})
+		}
+	}
+	return containers
+}
+
+// findContainer returns the named container of a pod, looking in the app
+// containers first and the init containers second
+func findContainer(pod *corev1.Pod, name string) (*corev1.Container, int, bool) {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i], i, false
+		}
+	}
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == name {
+			return &pod.Spec.InitContainers[i], i, true
+		}
+	}
+	return nil, -1, false
+}
+
+// containerAt returns the container an update's index points at, nil when out of range
+func containerAt(pod *corev1.Pod, index int, init bool) *corev1.Container {
+	containers := pod.Spec.Containers
+	if init {
+		containers = pod.Spec.InitContainers
+	}
+	if index < 0 || index >= len(containers) {
+		return nil
+	}
+	return &containers[index]
+}
+
+// containerPath returns the JSON patch path of a container in a pod spec
+func containerPath(init bool, index int) string {
+	if init {
+		return fmt.Sprintf("/spec/initContainers/%d", index)
+	}
+	return fmt.Sprintf("/spec/containers/%d", index)
+}
+
+// initContainerPeaks keeps the peak usage init containers reached while they
+// ran, per workload, so they can be sized after they completed
+type initContainerPeaks struct {
+	mu    sync.Mutex
+	peaks map[string]initContainerPeak
+}
+
+type initContainerPeak struct {
+	usage    metrics.Metrics
+	lastSeen time.Time
+}
+
+// observe raises the recorded peak of an init container to the usage
+func (p *initContainerPeaks) observe(key string, usage metrics.Metrics, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.peaks == nil {
+		p.peaks = make(map[string]initContainerPeak)
+	}
+	peak := p.peaks[key]
+	peak.usage.CPUMilli = max(peak.usage.CPUMilli, usage.CPUMilli)
+	peak.usage.MemMB = max(peak.usage.MemMB, usage.MemMB)
+	peak.usage.CPUThrottled = max(peak.usage.CPUThrottled, usage.CPUThrottled)
+	peak.lastSeen = now
+	p.peaks[key] = peak
+}
+
+// get returns the recorded peak of an init container, forgetting peaks not
+// seen within initPeakRetention
+func (p *initContainerPeaks) get(key string, now time.Time) (metrics.Metrics, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peak, ok := p.peaks[key]
+	if !ok {
+		return metrics.Metrics{}, false
+	}
+	if now.Sub(peak.lastSeen) > initPeakRetention {
+		delete(p.peaks, key)
+		return metrics.Metrics{}, false
+	}
+	return peak.usage, true
+}
+
+// initPeakKey identifies an init container across the replicas of its workload
+func initPeakKey(pod *corev1.Pod, container string) string {
+	return pod.Namespace + "/" + audit.WorkloadOf(pod) + "/" + container
+}
+
+// observeInitContainers records the usage of the init containers a pending
+// pod is running. Init containers run before the pod does and cannot be
+// resized, so their peaks are only used for recommendations.
+func (r *AdaptiveRightSizer) observeInitContainers(ctx context.Context, pod *corev1.Pod, provider metrics.Provider) {
+	running := make(map[string]bool)
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.State.Running != nil {
+			running[status.Name] = true
+		}
+	}
+	if len(running) == 0 {
+		return
+	}
+
+	usage, err := provider.FetchContainerMetrics(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		logger.Debug("No init container metrics for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	now := time.Now()
+	for _, container := range pod.Spec.InitContainers {
+		if isSidecar(&container) || !running[container.Name] {
+			continue
+		}
+		if u, ok := usage[container.Name]; ok {
+			r.initPeaks.observe(initPeakKey(pod, container.Name), u, now)
+		}
+	}
+}
+
+// initContainerUpdates sizes the init containers of a running pod from the
+// peak usage they reached while running. The updates are recommendations
+// only: the resources of an init container cannot be changed once the pod
+// started.
+func (r *AdaptiveRightSizer) initContainerUpdates(pod *corev1.Pod) []ResourceUpdate {
+	var updates []ResourceUpdate
+	now := time.Now()
+	for i, container := range pod.Spec.InitContainers {
+		if isSidecar(&container) {
+			continue
+		}
+		peak, ok := r.initPeaks.get(initPeakKey(pod, container.Name), now)
+		if !ok {
+			continue
+		}
+
+		cfg := config.Get().ForNamespace(pod.Namespace)
+		decision := r.checkScalingThresholds(peak, container.Resources, cfg)
+		if decision.CPU == ScaleNone && decision.Memory == ScaleNone {
+			continue
+		}
+		newResources := r.calculateOptimalResourcesWithDecision(peak, decision, cfg)
+		if !r.needsAdjustmentWithDecision(container.Resources, newResources, decision) {
+			continue
+		}
+		updates = append(updates, ResourceUpdate{
+			Namespace:      pod.Namespace,
+			Name:           pod.Name,
+			ResourceType:   "Pod",
+			ContainerName:  container.Name,
+			ContainerIndex: i,
+			InitContainer:  true,
+			OldResources:   container.Resources,
+			NewResources:   newResources,
+			Usage:          peak,
+			Reason:         "init container peak usage: " + r.getAdjustmentReasonWithDecision(container.Resources, newResources, decision),
+		})
+	}
+	return updates
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// containerUsageProvider serves fixed per-container usage for every pod
+type containerUsageProvider metrics.ContainerMetrics
+
+func (p containerUsageProvider) FetchPodMetrics(ctx context.Context, namespace, podName string) (metrics.Metrics, error) {
+	var total metrics.Metrics
+	for _, usage := range p {
+		total.CPUMilli += usage.CPUMilli
+		total.MemMB += usage.MemMB
+	}
+	return total, nil
+}
+
+func (p containerUsageProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (metrics.ContainerMetrics, error) {
+	return metrics.ContainerMetrics(p), nil
+}
+
+func sizedContainer(name, cpu, memory string) corev1.Container {
+	return corev1.Container{
+		Name: name,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+// podWithSidecar returns a running pod with an app container, a native
+// sidecar and a plain init container
+func podWithSidecar() *corev1.Pod {
+	always := corev1.ContainerRestartPolicyAlways
+	sidecar := sizedContainer("proxy", "100m", "128Mi")
+	sidecar.RestartPolicy = &always
+
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	pod.Spec.InitContainers = []corev1.Container{
+		sizedContainer("migrate", "1", "1Gi"),
+		sidecar,
+	}
+	return pod
+}
+
+// TestResizableContainers verifies native sidecars are sized next to the app
+// containers while plain init containers are not
+func TestResizableContainers(t *testing.T) {
+	pod := podWithSidecar()
+
+	containers := resizableContainers(pod)
+	if len(containers) != 2 {
+		t.Fatalf("expected the app container and the sidecar, got %+v", containers)
+	}
+	if containers[1].container.Name != "proxy" || !containers[1].init || containers[1].index != 1 {
+		t.Errorf("expected the sidecar at spec.initContainers[1], got %+v", containers[1])
+	}
+
+	if container, index, init := findContainer(pod, "migrate"); container == nil || index != 0 || !init {
+		t.Errorf("expected migrate at spec.initContainers[0], got %v %d %v", container, index, init)
+	}
+	if container, _, _ := findContainer(pod, "missing"); container != nil {
+		t.Errorf("expected no container, got %+v", container)
+	}
+	if path := containerPath(true, 1); path != "/spec/initContainers/1" {
+		t.Errorf("unexpected path %s", path)
+	}
+}
+
+// TestSidecarResizedInPlace verifies a sidecar is patched through its
+// spec.initContainers path and that plain init containers are never resized
+func TestSidecarResizedInPlace(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	pod := podWithSidecar()
+	r, patches := resizeRecorder(pod, 0, nil)
+
+	update := groupedUpdate()
+	update.ContainerName = "proxy"
+	if _, err := r.updatePodInPlace(context.Background(), update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 1 {
+		t.Fatalf("expected a single resize patch, got %d", len(*patches))
+	}
+	for _, op := range (*patches)[0] {
+		if path := op["path"].(string); !strings.HasPrefix(path, "/spec/initContainers/1/resources/") {
+			t.Errorf("expected a sidecar path, got %s", path)
+		}
+	}
+
+	update.ContainerName = "migrate"
+	if _, err := r.updatePodInPlace(context.Background(), update); err == nil {
+		t.Error("expected init containers to be refused")
+	}
+}
+
+// TestInitContainerRecommendations verifies init containers are sized from
+// the peak usage observed while their pod was pending
+func TestInitContainerRecommendations(t *testing.T) {
+	config.Get().SetRecommendationOnly(true)
+	defer config.Get().SetRecommendationOnly(false)
+
+	pending := podWithSidecar()
+	pending.Status.Phase = corev1.PodPending
+	pending.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "migrate",
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	}}
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(pending).Build()
+	r.MetricsProvider = containerUsageProvider{"migrate": {CPUMilli: 50, MemMB: 64}}
+
+	updates, _ := r.analyzeAllPods(context.Background(), []corev1.Pod{*pending})
+	if len(updates) != 0 {
+		t.Fatalf("expected no updates for a pending pod, got %+v", updates)
+	}
+	if _, ok := r.initPeaks.get(initPeakKey(pending, "migrate"), time.Now()); !ok {
+		t.Fatal("expected the peak usage of migrate to be recorded")
+	}
+
+	running := pending.DeepCopy()
+	running.Status.Phase = corev1.PodRunning
+	if err := r.Client.Status().Update(context.Background(), running); err != nil {
+		t.Fatalf("failed to update pod: %v", err)
+	}
+	r.MetricsProvider = containerUsageProvider{"test-container": {CPUMilli: 150, MemMB: 200}}
+
+	updates, _ = r.analyzeAllPods(context.Background(), []corev1.Pod{*running})
+	var initUpdate *ResourceUpdate
+	for i := range updates {
+		if updates[i].ContainerName == "migrate" {
+			initUpdate = &updates[i]
+		}
+	}
+	if initUpdate == nil {
+		t.Fatalf("expected a recommendation for migrate, got %+v", updates)
+	}
+	if !initUpdate.InitContainer || initUpdate.ContainerIndex != 0 {
+		t.Errorf("expected migrate at spec.initContainers[0], got %+v", initUpdate)
+	}
+	if cpu := initUpdate.NewResources.Requests[corev1.ResourceCPU]; cpu.Cmp(resource.MustParse("1")) >= 0 {
+		t.Errorf("expected the idle init container to be sized down, got %s", cpu.String())
+	}
+}
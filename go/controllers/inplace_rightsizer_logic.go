@@ -126,6 +126,11 @@ func (r *InPlaceRightSizer) checkScalingThresholds(usage metrics.Metrics, pod *c
 		cpuDecision = ScaleDown
 	}
 
+	// A heavily throttled container needs more CPU even when its average usage looks modest
+	if cfg.CPUThrottleThreshold > 0 && usage.CPUThrottled > cfg.CPUThrottleThreshold {
+		cpuDecision = ScaleUp
+	}
+
 	// Check Memory scaling
 	if memUsagePercent > cfg.MemoryScaleUpThreshold {
 		memoryDecision = ScaleUp
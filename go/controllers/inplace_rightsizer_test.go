@@ -647,3 +647,7 @@ type mockMetricsProvider struct {
 func (m *mockMetricsProvider) FetchPodMetrics(ctx context.Context, namespace, name string) (metrics.Metrics, error) {
 	return m.metrics, m.err
 }
+
+func (m *mockMetricsProvider) FetchContainerMetrics(ctx context.Context, namespace, name string) (metrics.ContainerMetrics, error) {
+	return metrics.ContainerMetrics{}, m.err
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ioLimits are the throughputs above which a pod is not resized; zero
+// disables a limit
+type ioLimits struct {
+	NetworkMbps float64
+	DiskIOMBps  float64
+}
+
+// podIOLimits returns the I/O limits set by the effective policy of a pod
+func podIOLimits(policies []*v1alpha1.RightSizerPolicy) ioLimits {
+	var limits ioLimits
+	if len(policies) == 0 {
+		return limits
+	}
+	constraints := mergePolicies(policies).Spec.Constraints
+	if constraints.MaxNetworkMbps != nil {
+		limits.NetworkMbps = float64(*constraints.MaxNetworkMbps)
+	}
+	if constraints.MaxDiskIOMBps != nil {
+		limits.DiskIOMBps = float64(*constraints.MaxDiskIOMBps)
+	}
+	return limits
+}
+
+// heavyIO reports whether a pod's network or disk throughput is above its
+// limits, and why. Pods are never held back when the metrics provider does
+// not report I/O or their throughput cannot be read.
+func (r *AdaptiveRightSizer) heavyIO(ctx context.Context, pod *corev1.Pod, limits ioLimits) (string, bool) {
+	if limits.NetworkMbps <= 0 && limits.DiskIOMBps <= 0 {
+		return "", false
+	}
+	source, ok := r.MetricsProvider.(metrics.IOSource)
+	if !ok {
+		return "", false
+	}
+	usage, err := source.FetchPodIO(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		logger.Debug("Failed to fetch I/O of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return "", false
+	}
+	return ioAboveLimits(usage, limits)
+}
+
+// ioAboveLimits describes the throughputs of usage that exceed limits
+func ioAboveLimits(usage metrics.IOUsage, limits ioLimits) (string, bool) {
+	var reasons []string
+	if limits.NetworkMbps > 0 && usage.NetworkMbps > limits.NetworkMbps {
+		reasons = append(reasons, fmt.Sprintf("network %.1f Mbps above %.0f Mbps", usage.NetworkMbps, limits.NetworkMbps))
+	}
+	if limits.DiskIOMBps > 0 && usage.DiskIOMBps > limits.DiskIOMBps {
+		reasons = append(reasons, fmt.Sprintf("disk %.1f MB/s above %.0f MB/s", usage.DiskIOMBps, limits.DiskIOMBps))
+	}
+	return strings.Join(reasons, ", "), len(reasons) > 0
+}
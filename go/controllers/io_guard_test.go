@@ -0,0 +1,94 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeIOProvider reports a fixed pod throughput
+type fakeIOProvider struct {
+	fakeRangeProvider
+	usage metrics.IOUsage
+	err   error
+}
+
+func (f *fakeIOProvider) FetchPodIO(ctx context.Context, namespace, podName string) (metrics.IOUsage, error) {
+	return f.usage, f.err
+}
+
+func (f *fakeIOProvider) FetchClusterIO(ctx context.Context) (metrics.IOUsage, error) {
+	return f.usage, f.err
+}
+
+// TestPodIOLimits verifies the limits come from the effective policy
+func TestPodIOLimits(t *testing.T) {
+	if limits := podIOLimits(nil); limits != (ioLimits{}) {
+		t.Fatalf("expected no limits without policies, got %+v", limits)
+	}
+
+	network, disk := int32(500), int32(100)
+	high := &v1alpha1.RightSizerPolicy{Spec: v1alpha1.RightSizerPolicySpec{Priority: 10,
+		Constraints: v1alpha1.ResourceConstraints{MaxNetworkMbps: &network}}}
+	low := &v1alpha1.RightSizerPolicy{Spec: v1alpha1.RightSizerPolicySpec{Priority: 1,
+		Constraints: v1alpha1.ResourceConstraints{MaxDiskIOMBps: &disk}}}
+	limits := podIOLimits([]*v1alpha1.RightSizerPolicy{high, low})
+	if limits.NetworkMbps != 500 || limits.DiskIOMBps != 100 {
+		t.Fatalf("expected limits merged from both policies, got %+v", limits)
+	}
+}
+
+// TestHeavyIO verifies pods are held back only while their throughput is above a limit
+func TestHeavyIO(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "data"}}
+	limits := ioLimits{NetworkMbps: 500, DiskIOMBps: 100}
+	provider := &fakeIOProvider{usage: metrics.IOUsage{NetworkMbps: 200, DiskIOMBps: 150}}
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.MetricsProvider = provider
+
+	reason, busy := r.heavyIO(context.Background(), pod, limits)
+	if !busy || reason != "disk 150.0 MB/s above 100 MB/s" {
+		t.Fatalf("expected heavy disk I/O, got %v %q", busy, reason)
+	}
+
+	provider.usage = metrics.IOUsage{NetworkMbps: 200, DiskIOMBps: 50}
+	if _, busy := r.heavyIO(context.Background(), pod, limits); busy {
+		t.Fatal("expected throughput below the limits to be allowed")
+	}
+	if _, busy := r.heavyIO(context.Background(), pod, ioLimits{}); busy {
+		t.Fatal("expected no check without limits")
+	}
+
+	provider.err = errors.New("no data")
+	provider.usage = metrics.IOUsage{NetworkMbps: 900}
+	if _, busy := r.heavyIO(context.Background(), pod, limits); busy {
+		t.Fatal("expected unreadable throughput not to hold the pod back")
+	}
+
+	r.MetricsProvider = &fakeRangeProvider{}
+	if _, busy := r.heavyIO(context.Background(), pod, limits); busy {
+		t.Fatal("expected providers without I/O not to hold the pod back")
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Job modes
+const (
+	JobModeRecommend = "recommend"
+	JobModePatch     = "patch"
+	JobModeResize    = "resize"
+)
+
+// maxJobRuns is how many past runs of a workload are kept
+const maxJobRuns = 10
+
+// jobRunRetention is how long a run is kept after it was last seen
+const jobRunRetention = 30 * 24 * time.Hour
+
+// JobSizer sizes the pods of Jobs and CronJobs from the peak usage of their
+// past runs. Job pods run to completion, so resizing them in place only
+// helps the run that is about to end; the next run is sized instead, through
+// a recommendation or the CronJob's job template.
+type JobSizer struct {
+	Client          client.Client
+	Recommendations *RecommendationWriter
+	EventRecorder   record.EventRecorder
+
+	mu        sync.Mutex
+	workloads map[string]*jobWorkload
+}
+
+// jobWorkload holds the runs of one Job or CronJob
+type jobWorkload struct {
+	namespace  string
+	target     v1alpha1.RecommendationTargetRef
+	containers []string
+	current    map[string]corev1.ResourceRequirements
+	runs       map[string]*jobRun
+	// published is what was last written, and from how many runs
+	published     map[string]corev1.ResourceRequirements
+	publishedRuns int
+}
+
+// jobRun is one Job: the peak usage of its containers across its pods
+type jobRun struct {
+	peaks    map[string]metrics.Metrics
+	started  time.Time
+	lastSeen time.Time
+}
+
+// NewJobSizer creates a job sizer
+func NewJobSizer(c client.Client, recommendations *RecommendationWriter, recorder record.EventRecorder) *JobSizer {
+	return &JobSizer{
+		Client:          c,
+		Recommendations: recommendations,
+		EventRecorder:   recorder,
+		workloads:       make(map[string]*jobWorkload),
+	}
+}
+
+// isJobPod reports whether a pod is run by a Job
+func isJobPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "Job"
+}
+
+// Observe records the usage of a running Job pod in its run
+func (s *JobSizer) Observe(ctx context.Context, pod *corev1.Pod, podUsage metrics.Metrics, containerUsages metrics.ContainerMetrics, now time.Time) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return
+	}
+	target := resolveWorkloadRef(ctx, s.Client, pod)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := pod.Namespace + "/" + recommendationName(target)
+	wl, ok := s.workloads[key]
+	if !ok {
+		wl = &jobWorkload{
+			namespace: pod.Namespace,
+			target:    target,
+			current:   make(map[string]corev1.ResourceRequirements),
+			runs:      make(map[string]*jobRun),
+			published: make(map[string]corev1.ResourceRequirements),
+		}
+		s.workloads[key] = wl
+	}
+
+	run, ok := wl.runs[owner.Name]
+	if !ok {
+		run = &jobRun{peaks: make(map[string]metrics.Metrics), started: now}
+		wl.runs[owner.Name] = run
+	}
+	run.lastSeen = now
+
+	wl.containers = wl.containers[:0]
+	for _, container := range pod.Spec.Containers {
+		wl.containers = append(wl.containers, container.Name)
+		wl.current[container.Name] = container.Resources
+
+		usage := containerUsage(podUsage, containerUsages, len(pod.Spec.Containers), container.Name)
+		peak := run.peaks[container.Name]
+		peak.CPUMilli = max(peak.CPUMilli, usage.CPUMilli)
+		peak.MemMB = max(peak.MemMB, usage.MemMB)
+		run.peaks[container.Name] = peak
+	}
+}
+
+// Sync sizes every workload from the runs that finished before the cycle
+// started, the runs no pod was seen running for since. Workloads whose sizing
+// changed get a recommendation or, in patch mode, a new CronJob job template.
+func (s *JobSizer) Sync(ctx context.Context, cycleStart time.Time, patch bool, cfg *config.Config, size func(metrics.Metrics) corev1.ResourceRequirements) {
+	type pending struct {
+		wl        *jobWorkload
+		resources map[string]corev1.ResourceRequirements
+		runs      int
+	}
+	var changed []pending
+
+	s.mu.Lock()
+	for key, wl := range s.workloads {
+		wl.prune(cycleStart)
+		if len(wl.runs) == 0 {
+			delete(s.workloads, key)
+			continue
+		}
+
+		peaks, runs := wl.finishedPeaks(cycleStart)
+		if runs == 0 {
+			continue
+		}
+		resources := make(map[string]corev1.ResourceRequirements, len(peaks))
+		for name, peak := range peaks {
+			resources[name] = size(peak)
+		}
+		if runs == wl.publishedRuns && equality.Semantic.DeepEqual(resources, wl.published) {
+			continue
+		}
+		wl.published, wl.publishedRuns = resources, runs
+		changed = append(changed, pending{wl: wl, resources: resources, runs: runs})
+	}
+	s.mu.Unlock()
+
+	for _, p := range changed {
+		var err error
+		switch {
+		case patch && p.wl.target.Kind == "CronJob":
+			if err = s.patchCronJob(ctx, p.wl, p.resources); err != nil {
+				logger.Warn("Failed to patch the job template of CronJob %s/%s: %v", p.wl.namespace, p.wl.target.Name, err)
+			}
+		case s.Recommendations != nil:
+			if err = s.Recommendations.upsert(ctx, p.wl.recommendation(p.resources, p.runs), cfg.Algorithm); err != nil {
+				logger.Warn("Failed to write the recommendation of %s %s/%s: %v", p.wl.target.Kind, p.wl.namespace, p.wl.target.Name, err)
+			}
+		}
+		if err != nil {
+			// Try again next cycle
+			s.mu.Lock()
+			p.wl.published, p.wl.publishedRuns = nil, 0
+			s.mu.Unlock()
+		}
+	}
+}
+
+// prune drops runs not seen within jobRunRetention and keeps the newest maxJobRuns
+func (wl *jobWorkload) prune(now time.Time) {
+	names := make([]string, 0, len(wl.runs))
+	for name, run := range wl.runs {
+		if now.Sub(run.lastSeen) > jobRunRetention {
+			delete(wl.runs, name)
+			continue
+		}
+		names = append(names, name)
+	}
+	if len(names) <= maxJobRuns {
+		return
+	}
+	sort.Slice(names, func(i, j int) bool { return wl.runs[names[i]].started.After(wl.runs[names[j]].started) })
+	for _, name := range names[maxJobRuns:] {
+		delete(wl.runs, name)
+	}
+}
+
+// finishedPeaks returns the peak usage of each container across the runs
+// finished before the cycle started, and the number of those runs
+func (wl *jobWorkload) finishedPeaks(cycleStart time.Time) (map[string]metrics.Metrics, int) {
+	peaks := make(map[string]metrics.Metrics)
+	runs := 0
+	for _, run := range wl.runs {
+		if !run.lastSeen.Before(cycleStart) {
+			continue
+		}
+		runs++
+		for name, usage := range run.peaks {
+			peak := peaks[name]
+			peak.CPUMilli = max(peak.CPUMilli, usage.CPUMilli)
+			peak.MemMB = max(peak.MemMB, usage.MemMB)
+			peaks[name] = peak
+		}
+	}
+	return peaks, runs
+}
+
+// recommendation builds the workload's recommendation from the sized resources
+func (wl *jobWorkload) recommendation(resources map[string]corev1.ResourceRequirements, runs int) *workloadRecommendation {
+	rec := &workloadRecommendation{
+		namespace:  wl.namespace,
+		target:     wl.target,
+		containers: make(map[string]*v1alpha1.ContainerRecommendation, len(resources)),
+	}
+	for _, name := range wl.containers {
+		recommended, ok := resources[name]
+		if !ok {
+			continue
+		}
+		current := wl.current[name]
+		rec.containers[name] = &v1alpha1.ContainerRecommendation{
+			ContainerName: name,
+			Current:       *current.DeepCopy(),
+			Recommended:   *recommended.DeepCopy(),
+			Confidence:    int32(min(runs, maxJobRuns) * 100 / maxJobRuns),
+			Samples:       int32(runs),
+			Reason:        fmt.Sprintf("peak usage of the last %d runs", runs),
+		}
+		rec.order = append(rec.order, name)
+	}
+	return rec
+}
+
+// patchCronJob sets the recommended resources on the CronJob's job template,
+// so the next run starts right-sized
+func (s *JobSizer) patchCronJob(ctx context.Context, wl *jobWorkload, resources map[string]corev1.ResourceRequirements) error {
+	key := types.NamespacedName{Namespace: wl.namespace, Name: wl.target.Name}
+	var cronJob batchv1.CronJob
+	updated := false
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := s.Client.Get(ctx, key, &cronJob); err != nil {
+			return err
+		}
+		updated = false
+		containers := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers
+		for i := range containers {
+			recommended, ok := resources[containers[i].Name]
+			if !ok {
+				continue
+			}
+			merged := mergeCPUAndMemory(containers[i].Resources, recommended)
+			if equality.Semantic.DeepEqual(containers[i].Resources, merged) {
+				continue
+			}
+			containers[i].Resources = merged
+			updated = true
+		}
+		if !updated {
+			return nil
+		}
+		return s.Client.Update(ctx, &cronJob)
+	})
+	if err != nil || !updated {
+		return err
+	}
+
+	logger.Info("🗓️  Updated the job template of CronJob %s/%s from its past runs", wl.namespace, wl.target.Name)
+	if s.EventRecorder != nil {
+		s.EventRecorder.Event(&cronJob, corev1.EventTypeNormal, "JobTemplateResized", "Resized the job template from the peak usage of past runs")
+	}
+	return nil
+}
+
+// mergeCPUAndMemory sets the CPU and memory of current to the recommended
+// values, keeping its other resources
+func mergeCPUAndMemory(current, recommended corev1.ResourceRequirements) corev1.ResourceRequirements {
+	merged := *current.DeepCopy()
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if value, ok := recommended.Requests[name]; ok {
+			if merged.Requests == nil {
+				merged.Requests = corev1.ResourceList{}
+			}
+			merged.Requests[name] = value.DeepCopy()
+		}
+		if value, ok := recommended.Limits[name]; ok {
+			if merged.Limits == nil {
+				merged.Limits = corev1.ResourceList{}
+			}
+			merged.Limits[name] = value.DeepCopy()
+		}
+	}
+	return merged
+}
+
+// observeJobPod records the usage of a running Job pod with the job sizer
+func (r *AdaptiveRightSizer) observeJobPod(ctx context.Context, pod *corev1.Pod, provider metrics.Provider) {
+	podUsage, err := provider.FetchPodMetrics(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		logger.Debug("No metrics for job pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return
+	}
+	containerUsages, err := provider.FetchContainerMetrics(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		containerUsages = nil
+	}
+	r.Jobs.Observe(ctx, pod, podUsage, containerUsages, time.Now())
+}
@@ -0,0 +1,173 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newJobSizerRig returns a job sizer seeing the nightly CronJob and two of its runs
+func newJobSizerRig(t *testing.T) (*JobSizer, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = batchv1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	controller := true
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 2 * * *",
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+						corev1.ResourceCPU:              resource.MustParse("2"),
+						corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					}},
+				}}},
+			}}},
+		},
+	}
+	objects := []client.Object{cronJob}
+	for _, name := range []string{"nightly-1", "nightly-2"} {
+		objects = append(objects, &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "CronJob", Name: "nightly", Controller: &controller},
+			},
+		}})
+	}
+
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).
+		Build()
+	return NewJobSizer(fakeClient, &RecommendationWriter{Client: fakeClient}, nil), fakeClient
+}
+
+func jobPod(name, job string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "batch/v1", Kind: "Job", Name: job, Controller: &controller},
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+	}
+}
+
+// peakSize sizes requests at exactly the peak usage
+func peakSize(usage metrics.Metrics) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(usage.CPUMilli), resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(int64(usage.MemMB)*1024*1024, resource.BinarySI),
+	}}
+}
+
+func TestIsJobPod(t *testing.T) {
+	if !isJobPod(jobPod("nightly-1-abc", "nightly-1")) {
+		t.Error("expected a Job pod")
+	}
+	if isJobPod(newOwnedPod("web-abc-1", "web-abc")) {
+		t.Error("expected a ReplicaSet pod not to be a Job pod")
+	}
+}
+
+// TestJobSizerRecommendsFromPastRuns verifies finished runs are aggregated into the CronJob's recommendation
+func TestJobSizerRecommendsFromPastRuns(t *testing.T) {
+	sizer, c := newJobSizerRig(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	sizer.Observe(ctx, jobPod("nightly-1-abc", "nightly-1"), metrics.Metrics{}, metrics.ContainerMetrics{"app": {CPUMilli: 300, MemMB: 100}}, start)
+	sizer.Observe(ctx, jobPod("nightly-1-abc", "nightly-1"), metrics.Metrics{}, metrics.ContainerMetrics{"app": {CPUMilli: 100, MemMB: 400}}, start.Add(time.Minute))
+	sizer.Observe(ctx, jobPod("nightly-2-def", "nightly-2"), metrics.Metrics{}, metrics.ContainerMetrics{"app": {CPUMilli: 200, MemMB: 200}}, start.Add(2*time.Minute))
+
+	// The second run is still running in this cycle
+	sizer.Sync(ctx, start.Add(90*time.Second), false, config.GetDefaults(), peakSize)
+	var rec v1alpha1.RightSizerRecommendation
+	key := types.NamespacedName{Namespace: "default", Name: "cronjob-nightly"}
+	if err := c.Get(ctx, key, &rec); err != nil {
+		t.Fatalf("expected a recommendation for the CronJob: %v", err)
+	}
+	if got := rec.Status.ContainerRecommendations[0]; got.Samples != 1 || got.Recommended.Requests.Cpu().MilliValue() != 300 {
+		t.Fatalf("expected the first run alone, got %+v", got)
+	}
+
+	sizer.Sync(ctx, start.Add(time.Hour), false, config.GetDefaults(), peakSize)
+	if err := c.Get(ctx, key, &rec); err != nil {
+		t.Fatalf("failed to get recommendation: %v", err)
+	}
+	got := rec.Status.ContainerRecommendations[0]
+	if got.Samples != 2 {
+		t.Errorf("expected both runs, got %d", got.Samples)
+	}
+	if got.Recommended.Requests.Cpu().MilliValue() != 300 || got.Recommended.Requests.Memory().Value() != 400*1024*1024 {
+		t.Errorf("expected the peaks across runs (300m/400Mi), got %s/%s", got.Recommended.Requests.Cpu(), got.Recommended.Requests.Memory())
+	}
+
+	var cronJob batchv1.CronJob
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "nightly"}, &cronJob); err != nil {
+		t.Fatalf("failed to get CronJob: %v", err)
+	}
+	if cpu := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu(); cpu.MilliValue() != 2000 {
+		t.Errorf("expected the job template untouched in recommend mode, got %s", cpu)
+	}
+}
+
+// TestJobSizerPatchesCronJob verifies patch mode resizes the job template and keeps other resources
+func TestJobSizerPatchesCronJob(t *testing.T) {
+	sizer, c := newJobSizerRig(t)
+	ctx := context.Background()
+	start := time.Now()
+
+	sizer.Observe(ctx, jobPod("nightly-1-abc", "nightly-1"), metrics.Metrics{}, metrics.ContainerMetrics{"app": {CPUMilli: 250, MemMB: 128}}, start)
+	sizer.Sync(ctx, start.Add(time.Hour), true, config.GetDefaults(), peakSize)
+
+	var cronJob batchv1.CronJob
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "default", Name: "nightly"}, &cronJob); err != nil {
+		t.Fatalf("failed to get CronJob: %v", err)
+	}
+	requests := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Resources.Requests
+	if requests.Cpu().MilliValue() != 250 || requests.Memory().Value() != 128*1024*1024 {
+		t.Errorf("expected the job template resized to 250m/128Mi, got %s/%s", requests.Cpu(), requests.Memory())
+	}
+	if storage := requests[corev1.ResourceEphemeralStorage]; storage.String() != "1Gi" {
+		t.Errorf("expected ephemeral storage kept, got %s", storage.String())
+	}
+}
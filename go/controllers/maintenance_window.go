@@ -0,0 +1,351 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/logger"
+	"right-sizer/workload"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxWindowDuration bounds how far back a window opening is searched for
+const maxWindowDuration = 7 * 24 * time.Hour
+
+// cronSchedule is a parsed five-field cron expression
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// parseCronSchedule parses "minute hour day-of-month month day-of-week".
+// Fields accept *, single values, ranges (1-5), steps (*/15, 0-30/10) and lists.
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", expr)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %w", err)
+	}
+	// Both 0 and 7 mean Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return &s, nil
+}
+
+// parseCronField returns a bitmask of the values selected by a cron field
+func parseCronField(field string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:idx], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			lo, hi = v, v
+			if step > 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// matches reports whether the schedule fires at the minute containing t
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	// As in standard cron, a restricted day-of-month and day-of-week match either
+	if !s.domAny && !s.dowAny {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// maintenanceWindowOpen reports whether the window opened within its duration before now
+func maintenanceWindowOpen(window v1alpha1.MaintenanceWindow, now time.Time) (bool, error) {
+	schedule, err := parseCronSchedule(window.Schedule)
+	if err != nil {
+		return false, err
+	}
+	duration, err := time.ParseDuration(window.Duration)
+	if err != nil {
+		return false, fmt.Errorf("invalid window duration %q: %w", window.Duration, err)
+	}
+	if duration <= 0 || duration > maxWindowDuration {
+		return false, fmt.Errorf("window duration %s must be between 0 and %s", duration, maxWindowDuration)
+	}
+
+	start := now.Truncate(time.Minute)
+	for t := start; now.Sub(t) < duration; t = t.Add(-time.Minute) {
+		if schedule.matches(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasMaintenanceWindows reports whether the schedule restricts when resizes may happen
+func hasMaintenanceWindows(schedule v1alpha1.ScheduleSpec) bool {
+	return len(schedule.AllowedWindows) > 0 || len(schedule.BlockedWindows) > 0
+}
+
+// scheduleAllowsResize reports whether resizes are allowed at now: inside an
+// allowed window (when any are set) and outside every blocked window
+func scheduleAllowsResize(schedule v1alpha1.ScheduleSpec, now time.Time) (bool, error) {
+	if !hasMaintenanceWindows(schedule) {
+		return true, nil
+	}
+	if schedule.Timezone != "" {
+		loc, err := time.LoadLocation(schedule.Timezone)
+		if err != nil {
+			return false, fmt.Errorf("invalid timezone %q: %w", schedule.Timezone, err)
+		}
+		now = now.In(loc)
+	} else {
+		now = now.UTC()
+	}
+
+	for _, window := range schedule.BlockedWindows {
+		open, err := maintenanceWindowOpen(window, now)
+		if err != nil {
+			return false, err
+		}
+		if open {
+			return false, nil
+		}
+	}
+
+	if len(schedule.AllowedWindows) == 0 {
+		return true, nil
+	}
+	for _, window := range schedule.AllowedWindows {
+		open, err := maintenanceWindowOpen(window, now)
+		if err != nil {
+			return false, err
+		}
+		if open {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MaintenanceScheduler holds back resize decisions for workloads whose
+// RightSizerPolicy only allows resizes during maintenance windows. Decisions
+// made outside a window are queued and released on the first run after the
+// window opens.
+type MaintenanceScheduler struct {
+	Client client.Client
+
+	mu      sync.Mutex
+	pending map[string]ResourceUpdate
+	now     func() time.Time
+}
+
+// NewMaintenanceScheduler creates a scheduler reading policies through c
+func NewMaintenanceScheduler(c client.Client) *MaintenanceScheduler {
+	return &MaintenanceScheduler{
+		Client:  c,
+		pending: make(map[string]ResourceUpdate),
+		now:     time.Now,
+	}
+}
+
+// Filter returns the updates that may be applied now, queueing the rest, plus
+// any queued updates whose window has opened and whose pod is unchanged
+func (m *MaintenanceScheduler) Filter(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	policies := m.windowedPolicies(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(policies) == 0 && len(m.pending) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+	now := m.now()
+
+	var result []ResourceUpdate
+	seen := make(map[string]bool, len(updates))
+	for _, update := range updates {
+		key := pendingUpdateKey(update)
+		seen[key] = true
+
+		pod := podsByName[update.Namespace+"/"+update.Name]
+		if pod == nil || m.allowed(ctx, policies, pod, now) {
+			delete(m.pending, key)
+			result = append(result, update)
+			continue
+		}
+		if _, queued := m.pending[key]; !queued {
+			logger.Info("Queueing resize of %s/%s container %s until its maintenance window opens",
+				update.Namespace, update.Name, update.ContainerName)
+		}
+		m.pending[key] = update
+	}
+
+	// Release queued decisions that were not recomputed this run
+	for key, update := range m.pending {
+		if seen[key] {
+			continue
+		}
+		pod := podsByName[update.Namespace+"/"+update.Name]
+		if !podStillMatches(pod, update) {
+			delete(m.pending, key)
+			continue
+		}
+		if m.allowed(ctx, policies, pod, now) {
+			logger.Info("Maintenance window open, applying queued resize of %s/%s container %s",
+				update.Namespace, update.Name, update.ContainerName)
+			delete(m.pending, key)
+			result = append(result, update)
+		}
+	}
+
+	return result
+}
+
+// Pending returns the number of queued updates
+func (m *MaintenanceScheduler) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// allowed reports whether the highest-priority windowed policy matching the pod allows a resize now
+func (m *MaintenanceScheduler) allowed(ctx context.Context, policies []v1alpha1.RightSizerPolicy, pod *corev1.Pod, now time.Time) bool {
+	if len(policies) == 0 {
+		return true
+	}
+	target := resolveWorkloadRef(ctx, m.Client, pod)
+	for i := range policies {
+		if !policyMatchesPod(&policies[i], pod, target) {
+			continue
+		}
+		open, err := scheduleAllowsResize(policies[i].Spec.Schedule, now)
+		if err != nil {
+			logger.Warn("Invalid maintenance windows in policy %s/%s, holding resizes: %v",
+				policies[i].Namespace, policies[i].Name, err)
+			return false
+		}
+		return open
+	}
+	return true
+}
+
+// windowedPolicies lists enabled policies that define maintenance windows, highest priority first
+func (m *MaintenanceScheduler) windowedPolicies(ctx context.Context) []v1alpha1.RightSizerPolicy {
+	var list v1alpha1.RightSizerPolicyList
+	if err := m.Client.List(ctx, &list); err != nil {
+		logger.Debug("Unable to list RightSizerPolicies for maintenance windows: %v", err)
+		return nil
+	}
+
+	var policies []v1alpha1.RightSizerPolicy
+	for _, policy := range list.Items {
+		if policy.Spec.Enabled && hasMaintenanceWindows(policy.Spec.Schedule) {
+			policies = append(policies, policy)
+		}
+	}
+	sortPoliciesByPrecedence(policies)
+	return policies
+}
+
+// policyMatchesPod reports whether the policy's target reference selects the pod's workload
+func policyMatchesPod(policy *v1alpha1.RightSizerPolicy, pod *corev1.Pod, target v1alpha1.RecommendationTargetRef) bool {
+	return workload.MatchesPolicy(policy, pod, target)
+}
+
+// podStillMatches reports whether a queued update still applies to the pod as it is now
+func podStillMatches(pod *corev1.Pod, update ResourceUpdate) bool {
+	if pod == nil || pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+		return false
+	}
+	container := containerAt(pod, update.ContainerIndex, update.InitContainer)
+	if container == nil {
+		return false
+	}
+	return container.Name == update.ContainerName && equality.Semantic.DeepEqual(container.Resources, update.OldResources)
+}
+
+// pendingUpdateKey identifies a queued update by pod and container
+func pendingUpdateKey(update ResourceUpdate) string {
+	return update.Namespace + "/" + update.Name + "/" + update.ContainerName
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// weekdayNights opens at 22:00 Monday to Friday for six hours
+var weekdayNights = v1alpha1.MaintenanceWindow{Schedule: "0 22 * * 1-5", Duration: "6h"}
+
+func TestMaintenanceWindowOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		window v1alpha1.MaintenanceWindow
+		now    time.Time
+		open   bool
+	}{
+		{"wednesday noon", weekdayNights, time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC), false},
+		{"wednesday at opening", weekdayNights, time.Date(2026, 10, 14, 22, 0, 0, 0, time.UTC), true},
+		{"thursday early morning", weekdayNights, time.Date(2026, 10, 15, 3, 59, 0, 0, time.UTC), true},
+		{"thursday at closing", weekdayNights, time.Date(2026, 10, 15, 4, 0, 0, 0, time.UTC), false},
+		{"saturday night", weekdayNights, time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC), false},
+		{"saturday after friday night", weekdayNights, time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC), true},
+		{"every 15 minutes", v1alpha1.MaintenanceWindow{Schedule: "*/15 * * * *", Duration: "5m"}, time.Date(2026, 10, 14, 12, 32, 0, 0, time.UTC), true},
+		{"between steps", v1alpha1.MaintenanceWindow{Schedule: "*/15 * * * *", Duration: "5m"}, time.Date(2026, 10, 14, 12, 37, 0, 0, time.UTC), false},
+		{"sunday as 7", v1alpha1.MaintenanceWindow{Schedule: "0 3 * * 7", Duration: "1h"}, time.Date(2026, 10, 18, 3, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, err := maintenanceWindowOpen(tt.window, tt.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if open != tt.open {
+				t.Errorf("expected open=%v at %s, got %v", tt.open, tt.now, open)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindowInvalid(t *testing.T) {
+	for _, window := range []v1alpha1.MaintenanceWindow{
+		{Schedule: "0 22 * *", Duration: "1h"},
+		{Schedule: "60 * * * *", Duration: "1h"},
+		{Schedule: "0 22 * * 1-5", Duration: "soon"},
+		{Schedule: "0 22 * * 1-5", Duration: "0s"},
+	} {
+		if _, err := maintenanceWindowOpen(window, time.Now()); err == nil {
+			t.Errorf("expected error for %+v", window)
+		}
+	}
+}
+
+func TestScheduleAllowsResize(t *testing.T) {
+	wednesdayNight := time.Date(2026, 10, 14, 23, 0, 0, 0, time.UTC)
+
+	schedule := v1alpha1.ScheduleSpec{AllowedWindows: []v1alpha1.MaintenanceWindow{weekdayNights}}
+	if ok, _ := scheduleAllowsResize(schedule, wednesdayNight); !ok {
+		t.Error("expected resize allowed inside the window")
+	}
+
+	schedule.BlockedWindows = []v1alpha1.MaintenanceWindow{{Schedule: "30 22 14 10 *", Duration: "1h"}}
+	if ok, _ := scheduleAllowsResize(schedule, wednesdayNight); ok {
+		t.Error("expected blocked window to override allowed window")
+	}
+
+	// 23:00 UTC is 08:00 the next morning in Tokyo, outside the window
+	schedule = v1alpha1.ScheduleSpec{AllowedWindows: []v1alpha1.MaintenanceWindow{weekdayNights}, Timezone: "Asia/Tokyo"}
+	if ok, err := scheduleAllowsResize(schedule, wednesdayNight); err != nil || ok {
+		t.Errorf("expected window evaluated in the policy timezone, got ok=%v err=%v", ok, err)
+	}
+
+	if ok, _ := scheduleAllowsResize(v1alpha1.ScheduleSpec{}, wednesdayNight); !ok {
+		t.Error("expected resize allowed without windows")
+	}
+}
+
+// TestMaintenanceSchedulerQueuesUntilWindowOpens verifies decisions outside a window are applied once it opens
+func TestMaintenanceSchedulerQueuesUntilWindowOpens(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	policy := &v1alpha1.RightSizerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		Spec: v1alpha1.RightSizerPolicySpec{
+			Enabled:   true,
+			TargetRef: v1alpha1.TargetReference{Namespaces: []string{"default"}},
+			Schedule:  v1alpha1.ScheduleSpec{AllowedWindows: []v1alpha1.MaintenanceWindow{weekdayNights}},
+		},
+	}
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(policy).Build()
+
+	pods := []corev1.Pod{runningReplica("web-abc-1", "web-abc", "100m", "128Mi")}
+	update := requestUpdate("web-abc-1", "200m", "256Mi")
+	update.OldResources = pods[0].Spec.Containers[0].Resources
+
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	scheduler := NewMaintenanceScheduler(fakeClient)
+	scheduler.now = func() time.Time { return now }
+
+	if got := scheduler.Filter(context.Background(), []ResourceUpdate{update}, pods); len(got) != 0 {
+		t.Fatalf("expected update held outside the window, got %d", len(got))
+	}
+	if scheduler.Pending() != 1 {
+		t.Fatalf("expected one queued update, got %d", scheduler.Pending())
+	}
+
+	now = time.Date(2026, 10, 14, 22, 5, 0, 0, time.UTC)
+	got := scheduler.Filter(context.Background(), nil, pods)
+	if len(got) != 1 || got[0].NewResources.Requests.Cpu().Cmp(resource.MustParse("200m")) != 0 {
+		t.Fatalf("expected queued update released when the window opens, got %+v", got)
+	}
+	if scheduler.Pending() != 0 {
+		t.Fatalf("expected queue drained, got %d", scheduler.Pending())
+	}
+
+	// A queued update is dropped if the pod was resized by something else meanwhile
+	now = time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	scheduler.Filter(context.Background(), []ResourceUpdate{update}, pods)
+	resized := []corev1.Pod{runningReplica("web-abc-1", "web-abc", "300m", "128Mi")}
+	now = time.Date(2026, 10, 15, 22, 5, 0, 0, time.UTC)
+	if got := scheduler.Filter(context.Background(), nil, resized); len(got) != 0 {
+		t.Fatalf("expected stale queued update dropped, got %+v", got)
+	}
+	if scheduler.Pending() != 0 {
+		t.Fatalf("expected stale update removed from the queue, got %d", scheduler.Pending())
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"slices"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PodAssessment is how the operator would size the pods of a manifest
+type PodAssessment struct {
+	Skipped   string           // why the pods would not be sized, or ""
+	Policies  []string         // enabled policies selecting the pods, highest precedence first
+	UsageFrom string           // running pod whose usage the manifest was sized against
+	Updates   []ResourceUpdate // resizes the operator would make
+}
+
+// AssessPod sizes the pod of a manifest against the usage of a running pod
+// it selects in its namespace, without applying anything. The manifest's
+// labels, annotations and container resources replace those of the running
+// pod, so a changed spec can be checked before it is deployed.
+func (r *AdaptiveRightSizer) AssessPod(ctx context.Context, manifest *corev1.Pod, selector labels.Selector) (PodAssessment, error) {
+	var assessment PodAssessment
+
+	var list corev1.PodList
+	if err := r.Client.List(ctx, &list, client.InNamespace(manifest.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return assessment, err
+	}
+	pod := manifest.DeepCopy()
+	for i := range list.Items {
+		running := &list.Items[i]
+		if running.Status.Phase != corev1.PodRunning || !running.DeletionTimestamp.IsZero() {
+			continue
+		}
+		pod = overlayManifest(running, manifest)
+		assessment.UsageFrom = running.Name
+		break
+	}
+
+	profilePolicies := r.profilePolicies(ctx)
+	assessment.Policies = policyNames(r.matchingPolicies(ctx, pod, profilePolicies))
+
+	exclusions := exclusionRules(config.Get().Exclusions)
+	if reason := r.assessmentSkipReason(ctx, pod, profilePolicies, exclusions); reason != "" {
+		assessment.Skipped = reason
+		return assessment, nil
+	}
+	if assessment.UsageFrom == "" {
+		assessment.Skipped = "no running pod matches the manifest to size it from"
+		return assessment, nil
+	}
+	assessment.Updates, _ = r.analyzePod(ctx, *pod, r.MetricsProvider, profilePolicies, exclusions, false)
+	return assessment, nil
+}
+
+// overlayManifest returns a copy of a running pod with the labels,
+// annotations and container resources of the manifest. Containers the
+// running pod does not have are left out, as there is no usage to size them.
+func overlayManifest(running, manifest *corev1.Pod) *corev1.Pod {
+	pod := running.DeepCopy()
+	pod.Labels = manifest.Labels
+	pod.Annotations = manifest.Annotations
+	for _, container := range slices.Concat(manifest.Spec.InitContainers, manifest.Spec.Containers) {
+		if target, _, _ := findContainer(pod, container.Name); target != nil {
+			target.Resources = *container.Resources.DeepCopy()
+		}
+	}
+	return pod
+}
+
+// assessmentSkipReason returns why analyzePod would leave a pod alone before
+// looking at its usage, or ""
+func (r *AdaptiveRightSizer) assessmentSkipReason(ctx context.Context, pod *corev1.Pod, profilePolicies []v1alpha1.RightSizerPolicy, exclusions []exclusionRule) string {
+	self := r.isSelfPod(pod)
+	switch {
+	case self && !config.Get().SelfSizing.Enabled:
+		return "the operator's own pods are not sized"
+	case !self && !r.shouldProcessNamespace(pod.Namespace):
+		return "namespace " + pod.Namespace + " is not managed"
+	case !self && r.isSystemWorkload(pod.Namespace, pod.Name):
+		return "system workload"
+	case pod.Annotations["rightsizer.io/skip"] == "true":
+		return "annotated rightsizer.io/skip"
+	}
+	rules := append(policyExclusionRules(pod.Namespace, profilePolicies), exclusions...)
+	if rule, excluded := r.excludedPod(ctx, pod, rules); excluded {
+		return "matches the " + rule.describe()
+	}
+	return ""
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestAssessPodSizesManifest verifies a manifest is sized against the usage
+// of the running pod it selects, with the manifest's resources
+func TestAssessPodSizesManifest(t *testing.T) {
+	running := createTestPod("web-abc-1", "default", "1", "1Gi", "2", "2Gi")
+	running.Labels = map[string]string{"app": "web"}
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(running).Build()
+	r.MetricsProvider = containerUsageProvider{"test-container": {CPUMilli: 900, MemMB: 900}}
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+
+	manifest := createTestPod("web", "default", "100m", "128Mi", "200m", "256Mi")
+	manifest.Labels = map[string]string{"app": "web"}
+	selector := labels.SelectorFromSet(labels.Set{"app": "web"})
+
+	assessment, err := r.AssessPod(context.Background(), manifest, selector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assessment.Skipped != "" || assessment.UsageFrom != "web-abc-1" {
+		t.Fatalf("expected the manifest sized from web-abc-1, got %+v", assessment)
+	}
+	if len(assessment.Updates) != 1 {
+		t.Fatalf("expected one container resized, got %d", len(assessment.Updates))
+	}
+	if got := assessment.Updates[0].OldResources.Requests.Cpu().MilliValue(); got != 100 {
+		t.Errorf("expected the manifest's 100m CPU request as current, got %dm", got)
+	}
+	if got := assessment.Updates[0].NewResources.Requests.Cpu().MilliValue(); got <= 100 {
+		t.Errorf("expected the busy container sized up, got %dm", got)
+	}
+
+	manifest.Annotations = map[string]string{"rightsizer.io/skip": "true"}
+	assessment, err = r.AssessPod(context.Background(), manifest, selector)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assessment.Skipped == "" || len(assessment.Updates) != 0 {
+		t.Errorf("expected a skipped manifest to be left alone, got %+v", assessment)
+	}
+
+	manifest.Annotations = nil
+	assessment, err = r.AssessPod(context.Background(), manifest, labels.SelectorFromSet(labels.Set{"app": "api"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if assessment.Skipped == "" || assessment.UsageFrom != "" {
+		t.Errorf("expected no assessment without a running pod, got %+v", assessment)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"math"
+
+	"right-sizer/explain"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// memoryThrottlingFactor is the kubelet's default share of the distance from
+// the memory request to the limit at which memory.high is set
+const memoryThrottlingFactor = 0.9
+
+// MemoryQoSEnabled reports whether the kubelets protect memory requests with
+// memory.min and throttle with memory.high (cgroup v2 memory QoS)
+func (r *AdaptiveRightSizer) MemoryQoSEnabled() bool {
+	return r.memoryQoS.Load()
+}
+
+// SetMemoryQoS records whether the cluster enables memory QoS, when its
+// capabilities are detected
+func (r *AdaptiveRightSizer) SetMemoryQoS(enabled bool) {
+	if r.memoryQoS.Swap(enabled) == enabled {
+		return
+	}
+	if enabled {
+		logger.Info("✅ Memory QoS detected - memory requests are sized to stay protected by memory.min")
+	} else {
+		logger.Info("Memory QoS no longer detected")
+	}
+}
+
+// protectMemoryMin raises a memory request below the container's memory
+// usage to that usage, up to the memory limit. With memory QoS the request
+// becomes memory.min, and the kernel only protects usage below it from
+// reclaim.
+func protectMemoryMin(proposed corev1.ResourceRequirements, usageMB float64) corev1.ResourceRequirements {
+	request, ok := proposed.Requests[corev1.ResourceMemory]
+	if !ok || usageMB <= 0 {
+		return proposed
+	}
+	floor := int64(math.Ceil(usageMB))
+	if limit, ok := proposed.Limits[corev1.ResourceMemory]; ok {
+		floor = min(floor, limit.Value()/(1024*1024))
+	}
+	if request.Value()/(1024*1024) >= floor {
+		return proposed
+	}
+	protected := *proposed.DeepCopy()
+	protected.Requests[corev1.ResourceMemory] = *resource.NewQuantity(floor*1024*1024, resource.BinarySI)
+	return protected
+}
+
+// memoryHighMB returns the memory.high in MB the kubelet sets for resources,
+// the request plus the throttling factor of the way to the limit. Without a
+// memory limit it depends on the node's allocatable memory and is unknown.
+func memoryHighMB(resources corev1.ResourceRequirements) (int64, bool) {
+	limit, ok := resources.Limits[corev1.ResourceMemory]
+	if !ok || limit.IsZero() {
+		return 0, false
+	}
+	limitMB := limit.Value() / (1024 * 1024)
+	requestMB := resources.Requests.Memory().Value() / (1024 * 1024)
+	return requestMB + int64(memoryThrottlingFactor*float64(limitMB-requestMB)), true
+}
+
+// memoryQoSOf returns the memory protection the kubelet derives from resources
+func memoryQoSOf(resources corev1.ResourceRequirements) *explain.MemoryQoS {
+	qos := &explain.MemoryQoS{MinMB: resources.Requests.Memory().Value() / (1024 * 1024)}
+	if high, ok := memoryHighMB(resources); ok {
+		qos.HighMB = high
+	}
+	return qos
+}
+
+// memoryHighWarning describes the throttling a lowered memory.high would
+// cause a container using usageMB of memory, if the planned resources lower
+// it below that usage
+func memoryHighWarning(current, proposed corev1.ResourceRequirements, usageMB float64) (string, bool) {
+	high, ok := memoryHighMB(proposed)
+	if !ok || usageMB <= float64(high) {
+		return "", false
+	}
+	if previous, ok := memoryHighMB(current); ok && high >= previous {
+		return "", false
+	}
+	return fmt.Sprintf("memory usage of %.0fMB is above the %dMB memory.high of the new resources; the container would be throttled by memory reclaim", usageMB, high), true
+}
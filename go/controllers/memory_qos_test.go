@@ -0,0 +1,73 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// TestProtectMemoryMin verifies memory requests cover the memory usage up to the limit
+func TestProtectMemoryMin(t *testing.T) {
+	proposed := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("300Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1000Mi")},
+	}
+	if got := protectMemoryMin(proposed, 250); got.Requests.Memory().Cmp(resource.MustParse("300Mi")) != 0 {
+		t.Errorf("expected a request above usage to be kept, got %s", got.Requests.Memory())
+	}
+	if got := protectMemoryMin(proposed, 450.2); got.Requests.Memory().Cmp(resource.MustParse("451Mi")) != 0 {
+		t.Errorf("expected the request raised to 451Mi, got %s", got.Requests.Memory())
+	}
+	if got := protectMemoryMin(proposed, 1200); got.Requests.Memory().Cmp(resource.MustParse("1000Mi")) != 0 {
+		t.Errorf("expected the request capped at the limit, got %s", got.Requests.Memory())
+	}
+	if proposed.Requests.Memory().Cmp(resource.MustParse("300Mi")) != 0 {
+		t.Errorf("expected the proposed resources to be left untouched")
+	}
+}
+
+// TestMemoryHighWarning verifies decreases that put usage above memory.high are flagged
+func TestMemoryHighWarning(t *testing.T) {
+	resources := func(request, limit string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(request)},
+			Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)},
+		}
+	}
+	current := resources("500Mi", "1000Mi")
+
+	if high, ok := memoryHighMB(resources("400Mi", "600Mi")); !ok || high != 580 {
+		t.Fatalf("expected memory.high of 580MB, got %d (%v)", high, ok)
+	}
+	if _, ok := memoryHighMB(corev1.ResourceRequirements{Requests: current.Requests}); ok {
+		t.Errorf("expected no memory.high without a limit")
+	}
+	if _, ok := memoryHighWarning(current, resources("400Mi", "600Mi"), 550); ok {
+		t.Errorf("expected no warning for usage below memory.high")
+	}
+	if _, ok := memoryHighWarning(current, resources("400Mi", "600Mi"), 590); !ok {
+		t.Errorf("expected a warning for usage above the lowered memory.high")
+	}
+	if _, ok := memoryHighWarning(resources("400Mi", "600Mi"), resources("400Mi", "650Mi"), 640); ok {
+		t.Errorf("expected no warning when memory.high rises")
+	}
+	if qos := memoryQoSOf(resources("400Mi", "600Mi")); qos.MinMB != 400 || qos.HighMB != 580 {
+		t.Errorf("expected memory.min 400MB and memory.high 580MB, got %+v", qos)
+	}
+}
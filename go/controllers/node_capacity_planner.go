@@ -0,0 +1,227 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Node capacity strategies for upsizes that do not fit on the node right now
+const (
+	NodeCapacityCap   = "cap"
+	NodeCapacityDefer = "defer"
+)
+
+// plannedResources are the resources the planner checks against the node
+var plannedResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// NodeCapacityPlanner checks upsizes against the allocatable of the pod's node
+// and the requests already committed there before they are patched. The
+// kubelet marks a resize that can never fit on the node Infeasible and one
+// that does not fit right now Deferred; the planner does the same up front so
+// such resizes are not attempted.
+type NodeCapacityPlanner struct {
+	Client        client.Client
+	EventRecorder record.EventRecorder
+	Metrics       *metrics.OperatorMetrics
+	Strategy      string // cap or defer
+}
+
+// Plan returns the updates that fit on their nodes. Upsizes that exceed the
+// node's allocatable are dropped as infeasible; upsizes that exceed what is
+// left on the node are capped to the remaining room or deferred to a later run.
+func (p *NodeCapacityPlanner) Plan(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	nodes := make(map[string]*corev1.Node)
+	committed := make(map[string]corev1.ResourceList)
+	result := updates[:0:0]
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok || pod.Spec.NodeName == "" {
+			result = append(result, update)
+			continue
+		}
+
+		increase := requestIncrease(update.OldResources, update.NewResources)
+		if len(increase) == 0 {
+			result = append(result, update)
+			continue
+		}
+
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok {
+			node = &corev1.Node{}
+			if err := p.Client.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+				logger.Debug("Could not get node %s for capacity planning: %v", pod.Spec.NodeName, err)
+				node = nil
+			}
+			nodes[pod.Spec.NodeName] = node
+		}
+		if node == nil {
+			result = append(result, update)
+			continue
+		}
+		if _, ok := committed[node.Name]; !ok {
+			committed[node.Name] = committedRequests(node.Name, pods)
+		}
+
+		allocatable := node.Status.Allocatable
+		if name, ok := exceedsAllocatable(pod, update, allocatable); ok {
+			nodeAllocatable := allocatable[name]
+			p.reject(pod, update, "infeasible", "ResizeInfeasible",
+				fmt.Sprintf("Resize of container %s is infeasible: pod %s request would exceed node %s allocatable %s",
+					update.ContainerName, name, node.Name, nodeAllocatable.String()))
+			continue
+		}
+
+		capped := update.NewResources.DeepCopy()
+		var notes []string
+		for name, delta := range increase {
+			available := allocatable[name].DeepCopy()
+			available.Sub(committed[node.Name][name])
+			if delta.Cmp(available) <= 0 {
+				continue
+			}
+			if available.Sign() < 0 {
+				available = resource.Quantity{}
+			}
+			value := update.OldResources.Requests[name].DeepCopy()
+			value.Add(available)
+			capped.Requests[name] = value
+			notes = append(notes, fmt.Sprintf("%s request capped at %s", name, value.String()))
+		}
+
+		if len(notes) > 0 {
+			if p.Strategy == NodeCapacityDefer || len(requestIncrease(update.OldResources, *capped)) == 0 {
+				p.reject(pod, update, "node_capacity", "ResizeDeferred",
+					fmt.Sprintf("Resize of container %s deferred: not enough room left on node %s", update.ContainerName, node.Name))
+				continue
+			}
+			logger.Info("Capping resize of %s/%s/%s to fit node %s: %v", update.Namespace, update.Name, update.ContainerName, node.Name, notes)
+			if p.EventRecorder != nil {
+				p.EventRecorder.Event(pod, corev1.EventTypeNormal, "ResizeCapped",
+					fmt.Sprintf("Resize of container %s capped to fit node %s", update.ContainerName, node.Name))
+			}
+			update.NewResources = *capped
+			update.Reason += " (capped to node capacity)"
+			update.Explanation.AddStep("node-capacity", strings.Join(notes, "; "), update.NewResources)
+		}
+
+		// Later updates on the same node see the room this one takes
+		for name, delta := range requestIncrease(update.OldResources, update.NewResources) {
+			total := committed[node.Name][name].DeepCopy()
+			total.Add(delta)
+			committed[node.Name][name] = total
+		}
+		result = append(result, update)
+	}
+	return result
+}
+
+// reject drops an update, recording why on the pod and in the suppressed metric
+func (p *NodeCapacityPlanner) reject(pod *corev1.Pod, update ResourceUpdate, reason, eventReason, message string) {
+	logger.Info("%s/%s: %s", update.Namespace, update.Name, message)
+	if p.EventRecorder != nil {
+		p.EventRecorder.Event(pod, corev1.EventTypeWarning, eventReason, message)
+	}
+	if p.Metrics != nil {
+		p.Metrics.RecordSuppressedResize(update.Namespace, reason)
+	}
+}
+
+// requestIncrease returns the positive change in CPU and memory requests
+func requestIncrease(old, proposed corev1.ResourceRequirements) corev1.ResourceList {
+	increase := corev1.ResourceList{}
+	for _, name := range plannedResources {
+		value, ok := proposed.Requests[name]
+		if !ok {
+			continue
+		}
+		value = value.DeepCopy()
+		value.Sub(old.Requests[name])
+		if value.Sign() > 0 {
+			increase[name] = value
+		}
+	}
+	return increase
+}
+
+// committedRequests sums the requests of the active pods scheduled on a node
+func committedRequests(nodeName string, pods []corev1.Pod) corev1.ResourceList {
+	committed := corev1.ResourceList{}
+	for _, name := range plannedResources {
+		committed[name] = resource.Quantity{}
+	}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, name := range plannedResources {
+				if request, ok := container.Resources.Requests[name]; ok {
+					total := committed[name]
+					total.Add(request)
+					committed[name] = total
+				}
+			}
+		}
+	}
+	return committed
+}
+
+// exceedsAllocatable reports the first resource for which the pod's total
+// requests after the update would not fit on the node even if it were empty
+func exceedsAllocatable(pod *corev1.Pod, update ResourceUpdate, allocatable corev1.ResourceList) (corev1.ResourceName, bool) {
+	for _, name := range plannedResources {
+		limit, ok := allocatable[name]
+		if !ok {
+			continue
+		}
+		var total resource.Quantity
+		for _, container := range pod.Spec.Containers {
+			requests := container.Resources.Requests
+			if container.Name == update.ContainerName {
+				requests = update.NewResources.Requests
+			}
+			if request, ok := requests[name]; ok {
+				total.Add(request)
+			}
+		}
+		if total.Cmp(limit) > 0 {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// newCapacityPlanner returns a planner for a node with 1 CPU and 1Gi allocatable
+func newCapacityPlanner(strategy string) (*NodeCapacityPlanner, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	}
+	recorder := record.NewFakeRecorder(10)
+	return &NodeCapacityPlanner{
+		Client:        ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(node).Build(),
+		EventRecorder: recorder,
+		Strategy:      strategy,
+	}, recorder
+}
+
+// scheduledPods returns two pods on node-1 committing 700m CPU and 512Mi memory
+func scheduledPods() []corev1.Pod {
+	pods := []corev1.Pod{
+		runningReplica("web-abc-1", "web-abc", "400m", "256Mi"),
+		runningReplica("web-abc-2", "web-abc", "300m", "256Mi"),
+	}
+	for i := range pods {
+		pods[i].Spec.NodeName = "node-1"
+	}
+	return pods
+}
+
+func plannedUpdate(pod corev1.Pod, cpu, mem string) ResourceUpdate {
+	update := requestUpdate(pod.Name, cpu, mem)
+	update.OldResources = pod.Spec.Containers[0].Resources
+	return update
+}
+
+func TestNodeCapacityPlannerCapsToRemainingRoom(t *testing.T) {
+	planner, recorder := newCapacityPlanner(NodeCapacityCap)
+	pods := scheduledPods()
+
+	// 300m is left on the node: the first upsize takes 200m and the second is capped to the last 100m
+	got := planner.Plan(context.Background(), []ResourceUpdate{
+		plannedUpdate(pods[0], "600m", "256Mi"),
+		plannedUpdate(pods[1], "500m", "256Mi"),
+	}, pods)
+	if len(got) != 2 {
+		t.Fatalf("expected both upsizes planned, got %d", len(got))
+	}
+	if got[0].NewResources.Requests.Cpu().MilliValue() != 600 {
+		t.Errorf("expected first upsize unchanged, got %s", got[0].NewResources.Requests.Cpu())
+	}
+	if got[1].NewResources.Requests.Cpu().MilliValue() != 400 || !strings.Contains(got[1].Reason, "capped to node capacity") {
+		t.Errorf("expected second upsize capped to 400m, got %s (%s)", got[1].NewResources.Requests.Cpu(), got[1].Reason)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ResizeCapped") {
+		t.Errorf("expected ResizeCapped event, got %q", event)
+	}
+
+	// With no room left at all the upsize is deferred rather than capped to nothing
+	planner, recorder = newCapacityPlanner(NodeCapacityCap)
+	got = planner.Plan(context.Background(), []ResourceUpdate{
+		plannedUpdate(pods[0], "700m", "256Mi"),
+		plannedUpdate(pods[1], "500m", "256Mi"),
+	}, pods)
+	if len(got) != 1 || got[0].Name != "web-abc-1" {
+		t.Fatalf("expected the second upsize deferred once the node is full, got %+v", got)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ResizeDeferred") {
+		t.Errorf("expected ResizeDeferred event, got %q", event)
+	}
+}
+
+func TestNodeCapacityPlannerDeferStrategy(t *testing.T) {
+	planner, recorder := newCapacityPlanner(NodeCapacityDefer)
+	pods := scheduledPods()
+
+	if got := planner.Plan(context.Background(), []ResourceUpdate{plannedUpdate(pods[0], "900m", "256Mi")}, pods); len(got) != 0 {
+		t.Fatalf("expected upsize deferred, got %+v", got)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ResizeDeferred") {
+		t.Errorf("expected ResizeDeferred event, got %q", event)
+	}
+}
+
+func TestNodeCapacityPlannerInfeasible(t *testing.T) {
+	planner, recorder := newCapacityPlanner(NodeCapacityCap)
+	pods := scheduledPods()
+
+	got := planner.Plan(context.Background(), []ResourceUpdate{
+		plannedUpdate(pods[0], "400m", "2Gi"),
+		plannedUpdate(pods[1], "100m", "128Mi"),
+	}, pods)
+	if len(got) != 1 || got[0].Name != "web-abc-2" {
+		t.Fatalf("expected only the downsize to pass, got %+v", got)
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ResizeInfeasible") {
+		t.Errorf("expected ResizeInfeasible event, got %q", event)
+	}
+}
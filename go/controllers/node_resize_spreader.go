@@ -0,0 +1,118 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodeResizeSpreader orders resizes so consecutive ones land on different
+// nodes and caps how many pods of a node are resized at once. Every resize
+// makes the kubelet re-admit the pod and rewrite its cgroups, so a run that
+// resizes many pods of one node churns that node while the others sit idle.
+type NodeResizeSpreader struct {
+	MaxPerNode int // pods resizing on a node at once, counting resizes still in progress
+}
+
+// nodeResizeGroup is the updates of one pod, applied together
+type nodeResizeGroup struct {
+	node    string
+	updates []ResourceUpdate
+}
+
+// Spread returns the updates interleaved across nodes, keeping their order
+// within each node. Pods on a node that already has MaxPerNode resizes in
+// flight are deferred to a later run. Updates of pods not bound to a node are
+// not capped.
+func (s *NodeResizeSpreader) Spread(updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 || s.MaxPerNode < 1 {
+		return updates
+	}
+
+	nodeOf := make(map[string]string, len(pods))
+	inFlight := make(map[string]int)
+	for i := range pods {
+		pod := &pods[i]
+		nodeOf[pod.Namespace+"/"+pod.Name] = pod.Spec.NodeName
+		if pod.Spec.NodeName != "" && resizeInFlight(pod) {
+			inFlight[pod.Spec.NodeName]++
+		}
+	}
+
+	// Group the updates by pod, then queue the pods per node in the order
+	// their first update appears
+	var groups []*nodeResizeGroup
+	byPod := make(map[string]*nodeResizeGroup)
+	for _, update := range updates {
+		key := update.Namespace + "/" + update.Name
+		group, ok := byPod[key]
+		if !ok {
+			group = &nodeResizeGroup{node: nodeOf[key]}
+			byPod[key] = group
+			groups = append(groups, group)
+		}
+		group.updates = append(group.updates, update)
+	}
+	var nodes []string
+	queues := make(map[string][]*nodeResizeGroup)
+	for _, group := range groups {
+		if _, ok := queues[group.node]; !ok {
+			nodes = append(nodes, group.node)
+		}
+		queues[group.node] = append(queues[group.node], group)
+	}
+
+	// Take one pod per node per round until every queue is drained or capped
+	result := make([]ResourceUpdate, 0, len(updates))
+	deferred := make(map[string]int)
+	for remaining := len(groups); remaining > 0; {
+		for _, node := range nodes {
+			queue := queues[node]
+			if len(queue) == 0 {
+				continue
+			}
+			group := queue[0]
+			queues[node] = queue[1:]
+			remaining--
+			if node != "" && inFlight[node] >= s.MaxPerNode {
+				deferred[node]++
+				continue
+			}
+			if node != "" {
+				inFlight[node]++
+			}
+			result = append(result, group.updates...)
+		}
+	}
+
+	for node, count := range deferred {
+		logger.Info("Deferring resizes of %d pod(s) on node %s: %d resize(s) already in flight", count, node, s.MaxPerNode)
+	}
+	return result
+}
+
+// resizeInFlight reports whether the kubelet has yet to finish a resize of the pod
+func resizeInFlight(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if (condition.Type == corev1.PodResizeInProgress || condition.Type == corev1.PodResizePending) &&
+			condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
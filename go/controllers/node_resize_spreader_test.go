@@ -0,0 +1,93 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func podOnNode(name, node string, resizing bool) corev1.Pod {
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: node},
+	}
+	if resizing {
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodResizeInProgress, Status: corev1.ConditionTrue}}
+	}
+	return pod
+}
+
+func spreadNames(updates []ResourceUpdate) string {
+	var names []string
+	for _, update := range updates {
+		names = append(names, update.Name+"/"+update.ContainerName)
+	}
+	return strings.Join(names, ",")
+}
+
+// TestNodeResizeSpreaderInterleavesNodes verifies consecutive resizes land on
+// different nodes and a pod's containers stay together
+func TestNodeResizeSpreaderInterleavesNodes(t *testing.T) {
+	pods := []corev1.Pod{
+		podOnNode("a1", "node-a", false),
+		podOnNode("a2", "node-a", false),
+		podOnNode("b1", "node-b", false),
+		podOnNode("pending", "", false),
+	}
+	updates := []ResourceUpdate{
+		{Namespace: "default", Name: "a1", ContainerName: "app"},
+		{Namespace: "default", Name: "a1", ContainerName: "sidecar"},
+		{Namespace: "default", Name: "a2", ContainerName: "app"},
+		{Namespace: "default", Name: "b1", ContainerName: "app"},
+		{Namespace: "default", Name: "pending", ContainerName: "app"},
+	}
+
+	spreader := &NodeResizeSpreader{MaxPerNode: 2}
+	want := "a1/app,a1/sidecar,b1/app,pending/app,a2/app"
+	if got := spreadNames(spreader.Spread(updates, pods)); got != want {
+		t.Errorf("expected order %s, got %s", want, got)
+	}
+}
+
+// TestNodeResizeSpreaderCapsNodes verifies pods beyond the per-node cap,
+// counting resizes already in flight, are deferred
+func TestNodeResizeSpreaderCapsNodes(t *testing.T) {
+	pods := []corev1.Pod{
+		podOnNode("busy", "node-a", true),
+		podOnNode("a1", "node-a", false),
+		podOnNode("a2", "node-a", false),
+		podOnNode("b1", "node-b", false),
+		podOnNode("b2", "node-b", false),
+		podOnNode("b3", "node-b", false),
+	}
+	updates := []ResourceUpdate{
+		{Namespace: "default", Name: "a1", ContainerName: "app"},
+		{Namespace: "default", Name: "a2", ContainerName: "app"},
+		{Namespace: "default", Name: "b1", ContainerName: "app"},
+		{Namespace: "default", Name: "b2", ContainerName: "app"},
+		{Namespace: "default", Name: "b3", ContainerName: "app"},
+	}
+
+	spreader := &NodeResizeSpreader{MaxPerNode: 2}
+	want := "a1/app,b1/app,b2/app"
+	if got := spreadNames(spreader.Spread(updates, pods)); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,401 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+	"right-sizer/pause"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// oomHandledRetention is how long a handled OOM termination is remembered
+const oomHandledRetention = 24 * time.Hour
+
+// OOMWatcher watches container statuses for OOMKilled terminations and raises
+// the memory of the affected container straight away, without waiting for the
+// next resize interval. In recommendation-only and export mode the raised
+// memory is recommended or exported instead.
+type OOMWatcher struct {
+	client.Client
+	ClientSet       kubernetes.Interface
+	Config          *config.Config
+	AuditLogger     *audit.AuditLogger
+	OperatorMetrics *metrics.OperatorMetrics
+	Recommendations *RecommendationWriter // Publishes the raised memory in recommendation-only mode
+	Exporter        *GitOpsExporter       // Renders the raised memory as a patch in export mode
+	Pauses          *pause.State          // Pauses of the cluster and namespaces
+
+	mu      sync.Mutex
+	handled map[string]time.Time // podUID/container/finishedAt -> when it was handled
+	started time.Time            // OOM kills that finished earlier are not handled
+}
+
+// NewOOMWatcher creates a new OOM watcher
+func NewOOMWatcher(c client.Client, clientSet kubernetes.Interface, cfg *config.Config, auditLogger *audit.AuditLogger, operatorMetrics *metrics.OperatorMetrics) *OOMWatcher {
+	return &OOMWatcher{
+		Client:          c,
+		ClientSet:       clientSet,
+		Config:          cfg,
+		AuditLogger:     auditLogger,
+		OperatorMetrics: operatorMetrics,
+		handled:         make(map[string]time.Time),
+		started:         time.Now(),
+	}
+}
+
+// Reconcile bumps the memory of every container in the pod whose last
+// termination was an OOM kill that has not been handled yet. OOM kills that
+// finished before the watcher started are left alone: they may have been
+// handled by a previous run, and the resize cycle sizes those containers.
+func (w *OOMWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if !w.Config.PreventOOMKill {
+		return ctrl.Result{}, nil
+	}
+
+	var pod corev1.Pod
+	if err := w.Get(ctx, req.NamespacedName, &pod); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !w.Config.IsNamespaceIncluded(pod.Namespace) || pod.Annotations["rightsizer.io/skip"] == "true" {
+		return ctrl.Result{}, nil
+	}
+
+	reason, paused := namespacePaused(w.Pauses, pod.Namespace)
+	if !paused {
+		reason, paused = podPaused(ctx, w.Client, &pod, make(map[string]bool))
+	}
+	if paused {
+		logger.Debug("Not raising memory of OOM-killed pod %s/%s: %s", pod.Namespace, pod.Name, reason)
+		if w.OperatorMetrics != nil {
+			w.OperatorMetrics.RecordSuppressedResize(pod.Namespace, "paused")
+		}
+		return ctrl.Result{}, nil
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.LastTerminationState.Terminated
+		if terminated == nil || terminated.Reason != "OOMKilled" {
+			continue
+		}
+		// FinishedAt only keeps whole seconds
+		if terminated.FinishedAt.Time.Before(w.started.Truncate(time.Second)) {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%d", pod.UID, status.Name, terminated.FinishedAt.Unix())
+		if !w.markHandled(key) {
+			continue
+		}
+
+		if err := w.bumpMemory(ctx, &pod, status.Name); err != nil {
+			w.forget(key)
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// bumpMemory raises the memory limit of an OOM-killed container by the
+// configured factor, bounded by MaxMemoryLimit
+func (w *OOMWatcher) bumpMemory(ctx context.Context, pod *corev1.Pod, containerName string) error {
+	start := time.Now()
+
+	index := -1
+	for i, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	current := pod.Spec.Containers[index].Resources
+	newResources, action := emergencyMemoryBump(current, w.Config.OOMMemoryBumpFactor, w.Config.MaxMemoryLimit)
+	if w.OperatorMetrics != nil {
+		w.OperatorMetrics.RecordOOMKill(pod.Namespace, pod.Name, containerName, action)
+	}
+
+	if action != "bumped" {
+		logger.Warn("🧨 Container %s/%s/%s was OOM killed but memory cannot be raised (%s)", pod.Namespace, pod.Name, containerName, action)
+		if w.AuditLogger != nil {
+			w.AuditLogger.LogResourceChange(ctx, pod, containerName, current, current, "emergency_memory_bump", "OOMKilled", action, time.Since(start), nil)
+		}
+		return nil
+	}
+
+	if w.Config.DryRun {
+		logger.Info("🔍 DRY RUN: Would raise memory of OOM-killed container %s/%s/%s to %s",
+			pod.Namespace, pod.Name, containerName, memoryOf(newResources))
+		return nil
+	}
+
+	if w.Config.RecommendationOnly || w.Config.Export.Enabled {
+		return w.publishBump(ctx, pod, index, current, newResources, start)
+	}
+
+	var patchOps []map[string]interface{}
+	if qty, ok := newResources.Requests[corev1.ResourceMemory]; ok && !qty.Equal(current.Requests[corev1.ResourceMemory]) {
+		patchOps = append(patchOps, memoryPatchOp(index, "requests", current.Requests, qty))
+	}
+	if qty, ok := newResources.Limits[corev1.ResourceMemory]; ok {
+		patchOps = append(patchOps, memoryPatchOp(index, "limits", current.Limits, qty))
+	}
+
+	patchData, err := json.Marshal(patchOps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal memory patch: %w", err)
+	}
+
+	_, err = w.ClientSet.CoreV1().Pods(pod.Namespace).Patch(
+		ctx,
+		pod.Name,
+		types.JSONPatchType,
+		patchData,
+		metav1.PatchOptions{},
+		"resize",
+	)
+
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	if w.AuditLogger != nil {
+		w.AuditLogger.LogResourceChange(ctx, pod, containerName, current, newResources, "emergency_memory_bump", "OOMKilled", status, time.Since(start), err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to raise memory for OOM-killed container %s/%s/%s: %w", pod.Namespace, pod.Name, containerName, err)
+	}
+
+	logger.Info("🧨 Raised memory of OOM-killed container %s/%s/%s: %s -> %s",
+		pod.Namespace, pod.Name, containerName, memoryOf(current), memoryOf(newResources))
+	return nil
+}
+
+// publishBump recommends or exports the raised memory of an OOM-killed
+// container when the operator must not resize pods itself
+func (w *OOMWatcher) publishBump(ctx context.Context, pod *corev1.Pod, index int, current, newResources corev1.ResourceRequirements, start time.Time) error {
+	containerName := pod.Spec.Containers[index].Name
+	updates := []ResourceUpdate{{
+		Namespace:      pod.Namespace,
+		Name:           pod.Name,
+		ResourceType:   "Pod",
+		ContainerName:  containerName,
+		ContainerIndex: index,
+		OldResources:   current,
+		NewResources:   newResources,
+		Reason:         "Memory raised after OOM kill",
+	}}
+
+	var err error
+	status := "recommended"
+	if w.Config.RecommendationOnly {
+		if w.Recommendations == nil {
+			return nil
+		}
+		err = w.Recommendations.Write(ctx, updates, w.Config)
+	} else {
+		if w.Exporter == nil {
+			return nil
+		}
+		status = "exported"
+		err = w.Exporter.Export(ctx, updates, w.Config.Export)
+	}
+	if err != nil {
+		status = "failed"
+	}
+	if w.AuditLogger != nil {
+		w.AuditLogger.LogResourceChange(ctx, pod, containerName, current, newResources, "emergency_memory_bump", "OOMKilled", status, time.Since(start), err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish memory for OOM-killed container %s/%s/%s: %w", pod.Namespace, pod.Name, containerName, err)
+	}
+
+	logger.Info("🧨 Container %s/%s/%s was OOM killed, %s memory %s -> %s instead of resizing",
+		pod.Namespace, pod.Name, containerName, status, memoryOf(current), memoryOf(newResources))
+	return nil
+}
+
+// emergencyMemoryBump computes the resources after an OOM kill. The memory
+// limit (or the request when no limit is set) is multiplied by factor and
+// capped at maxMemoryMB. The request is raised along with the limit when they
+// were equal, so Guaranteed pods keep their QoS class. The returned action is
+// "bumped", "capped" when the container is already at the cap, or "skipped"
+// when it has no memory resources to scale from.
+func emergencyMemoryBump(current corev1.ResourceRequirements, factor float64, maxMemoryMB int64) (corev1.ResourceRequirements, string) {
+	if factor <= 1 {
+		factor = 1.5
+	}
+
+	base, hasLimit := current.Limits[corev1.ResourceMemory]
+	request, hasRequest := current.Requests[corev1.ResourceMemory]
+	if !hasLimit {
+		if !hasRequest {
+			return current, "skipped"
+		}
+		base = request
+	}
+
+	newBytes := int64(float64(base.Value()) * factor)
+	if maxMemoryMB > 0 {
+		maxBytes := maxMemoryMB * 1024 * 1024
+		if newBytes > maxBytes {
+			newBytes = maxBytes
+		}
+	}
+	if newBytes <= base.Value() {
+		return current, "capped"
+	}
+
+	newQty := resource.NewQuantity(newBytes, resource.BinarySI)
+	updated := *current.DeepCopy()
+	if updated.Requests == nil {
+		updated.Requests = corev1.ResourceList{}
+	}
+	if updated.Limits == nil {
+		updated.Limits = corev1.ResourceList{}
+	}
+
+	switch {
+	case !hasLimit:
+		updated.Requests[corev1.ResourceMemory] = *newQty
+	case hasRequest && request.Cmp(base) == 0:
+		updated.Requests[corev1.ResourceMemory] = *newQty
+		updated.Limits[corev1.ResourceMemory] = *newQty
+	default:
+		updated.Limits[corev1.ResourceMemory] = *newQty
+	}
+
+	return updated, "bumped"
+}
+
+// memoryPatchOp builds a JSON patch operation setting the memory of a container's requests or limits
+func memoryPatchOp(index int, field string, existing corev1.ResourceList, qty resource.Quantity) map[string]interface{} {
+	if existing == nil {
+		return map[string]interface{}{
+			"op":    "add",
+			"path":  fmt.Sprintf("/spec/containers/%d/resources/%s", index, field),
+			"value": corev1.ResourceList{corev1.ResourceMemory: qty},
+		}
+	}
+	return map[string]interface{}{
+		"op":    "add",
+		"path":  fmt.Sprintf("/spec/containers/%d/resources/%s/memory", index, field),
+		"value": qty.String(),
+	}
+}
+
+// memoryOf returns the memory limit, or the request when no limit is set, for logging
+func memoryOf(r corev1.ResourceRequirements) string {
+	if qty, ok := r.Limits[corev1.ResourceMemory]; ok {
+		return qty.String()
+	}
+	if qty, ok := r.Requests[corev1.ResourceMemory]; ok {
+		return qty.String()
+	}
+	return "none"
+}
+
+// markHandled records an OOM termination and reports whether it was new
+func (w *OOMWatcher) markHandled(key string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for k, t := range w.handled {
+		if now.Sub(t) > oomHandledRetention {
+			delete(w.handled, k)
+		}
+	}
+
+	if _, ok := w.handled[key]; ok {
+		return false
+	}
+	w.handled[key] = now
+	return true
+}
+
+// forget removes a handled OOM termination so it is retried
+func (w *OOMWatcher) forget(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.handled, key)
+}
+
+// hasOOMKilledContainer reports whether any container's last termination was an OOM kill
+func hasOOMKilledContainer(pod *corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if t := status.LastTerminationState.Terminated; t != nil && t.Reason == "OOMKilled" {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the watcher with the manager
+func (w *OOMWatcher) SetupWithManager(mgr ctrl.Manager) error {
+	oomPredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			pod, ok := e.Object.(*corev1.Pod)
+			return ok && hasOOMKilledContainer(pod)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			pod, ok := e.ObjectNew.(*corev1.Pod)
+			return ok && hasOOMKilledContainer(pod)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("oom-watcher").
+		For(&corev1.Pod{}).
+		WithEventFilter(oomPredicate).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: w.Config.MaxConcurrentReconciles,
+		}).
+		Complete(w)
+}
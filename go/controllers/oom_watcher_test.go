@@ -0,0 +1,290 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/pause"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func memoryResources(request, limit string) corev1.ResourceRequirements {
+	r := corev1.ResourceRequirements{}
+	if request != "" {
+		r.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(request)}
+	}
+	if limit != "" {
+		r.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)}
+	}
+	return r
+}
+
+func TestEmergencyMemoryBump(t *testing.T) {
+	tests := []struct {
+		name          string
+		current       corev1.ResourceRequirements
+		maxMemoryMB   int64
+		expectAction  string
+		expectRequest string
+		expectLimit   string
+	}{
+		{
+			name:          "limit raised, request untouched",
+			current:       memoryResources("128Mi", "256Mi"),
+			maxMemoryMB:   8192,
+			expectAction:  "bumped",
+			expectRequest: "128Mi",
+			expectLimit:   "384Mi",
+		},
+		{
+			name:          "guaranteed keeps request equal to limit",
+			current:       memoryResources("256Mi", "256Mi"),
+			maxMemoryMB:   8192,
+			expectAction:  "bumped",
+			expectRequest: "384Mi",
+			expectLimit:   "384Mi",
+		},
+		{
+			name:          "request raised when no limit is set",
+			current:       memoryResources("200Mi", ""),
+			maxMemoryMB:   8192,
+			expectAction:  "bumped",
+			expectRequest: "300Mi",
+		},
+		{
+			name:          "bounded by max memory limit",
+			current:       memoryResources("128Mi", "900Mi"),
+			maxMemoryMB:   1024,
+			expectAction:  "bumped",
+			expectRequest: "128Mi",
+			expectLimit:   "1Gi",
+		},
+		{
+			name:          "already at max memory limit",
+			current:       memoryResources("128Mi", "1Gi"),
+			maxMemoryMB:   1024,
+			expectAction:  "capped",
+			expectRequest: "128Mi",
+			expectLimit:   "1Gi",
+		},
+		{
+			name:         "no memory resources",
+			current:      corev1.ResourceRequirements{},
+			maxMemoryMB:  1024,
+			expectAction: "skipped",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, action := emergencyMemoryBump(tt.current, 1.5, tt.maxMemoryMB)
+			if action != tt.expectAction {
+				t.Fatalf("expected action %q, got %q", tt.expectAction, action)
+			}
+			if tt.expectRequest != "" {
+				want := resource.MustParse(tt.expectRequest)
+				if got.Requests.Memory().Cmp(want) != 0 {
+					t.Errorf("expected request %s, got %s", tt.expectRequest, got.Requests.Memory())
+				}
+			}
+			if tt.expectLimit != "" {
+				want := resource.MustParse(tt.expectLimit)
+				if got.Limits.Memory().Cmp(want) != 0 {
+					t.Errorf("expected limit %s, got %s", tt.expectLimit, got.Limits.Memory())
+				}
+			}
+		})
+	}
+}
+
+// newOOMKilledPod returns a pod whose container "app" was OOM killed at finishedAt
+func newOOMKilledPod(finishedAt time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "oom-pod", Namespace: "default", UID: "uid-1"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Resources: memoryResources("128Mi", "256Mi")}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "app",
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:     "OOMKilled",
+						ExitCode:   137,
+						FinishedAt: metav1.NewTime(finishedAt),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// TestOOMWatcherReconcile verifies an OOM kill is resized once via the resize subresource
+func TestOOMWatcherReconcile(t *testing.T) {
+	pod := newOOMKilledPod(time.Now())
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()
+	clientSet := fake.NewSimpleClientset(pod)
+
+	var patches []string
+	clientSet.PrependReactor("patch", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(clienttesting.PatchAction)
+		if patchAction.GetSubresource() != "resize" {
+			t.Errorf("expected resize subresource, got %q", patchAction.GetSubresource())
+		}
+		patches = append(patches, string(patchAction.GetPatch()))
+		return true, pod, nil
+	})
+
+	cfg := config.GetDefaults()
+	cfg.DryRun = false
+	watcher := NewOOMWatcher(fakeClient, clientSet, cfg, nil, nil)
+	watcher.started = time.Now().Add(-time.Minute)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "oom-pod"}}
+	for i := 0; i < 2; i++ {
+		if _, err := watcher.Reconcile(context.Background(), req); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(patches) != 1 {
+		t.Fatalf("expected one resize patch for a single OOM kill, got %d", len(patches))
+	}
+
+	var ops []map[string]interface{}
+	if err := json.Unmarshal([]byte(patches[0]), &ops); err != nil {
+		t.Fatalf("invalid patch: %v", err)
+	}
+	if len(ops) != 1 || ops[0]["path"] != "/spec/containers/0/resources/limits/memory" || ops[0]["value"] != "384Mi" {
+		t.Fatalf("unexpected patch %s", patches[0])
+	}
+}
+
+// TestOOMWatcherLeavesPodsAlone verifies OOM kills from before the watcher
+// started, pods opted out of right-sizing and paused pods are not resized
+func TestOOMWatcherLeavesPodsAlone(t *testing.T) {
+	tests := []struct {
+		name            string
+		finishedAt      time.Time
+		skip            bool
+		pauseNamespace  bool
+		pauseAnnotation bool
+	}{
+		{name: "killed before the watcher started", finishedAt: time.Now().Add(-time.Hour)},
+		{name: "skip annotation", finishedAt: time.Now(), skip: true},
+		{name: "namespace paused", finishedAt: time.Now(), pauseNamespace: true},
+		{name: "pod paused by annotation", finishedAt: time.Now(), pauseAnnotation: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := newOOMKilledPod(tt.finishedAt)
+			pod.Annotations = map[string]string{}
+			if tt.skip {
+				pod.Annotations["rightsizer.io/skip"] = "true"
+			}
+			if tt.pauseAnnotation {
+				pod.Annotations[pause.Annotation] = "true"
+			}
+
+			scheme := runtime.NewScheme()
+			_ = corev1.AddToScheme(scheme)
+			fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()
+			clientSet := fake.NewSimpleClientset(pod)
+
+			cfg := config.GetDefaults()
+			cfg.DryRun = false
+			watcher := NewOOMWatcher(fakeClient, clientSet, cfg, nil, nil)
+			watcher.started = time.Now().Add(-time.Minute)
+			watcher.Pauses = pause.NewState(nil)
+			if tt.pauseNamespace {
+				watcher.Pauses.Pause("default", "incident")
+			}
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "oom-pod"}}
+			if _, err := watcher.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, action := range clientSet.Actions() {
+				if action.GetVerb() == "patch" {
+					t.Fatal("expected no resize")
+				}
+			}
+		})
+	}
+}
+
+// TestOOMWatcherRecommendationOnly verifies the raised memory is recommended
+// instead of resized in recommendation-only mode
+func TestOOMWatcherRecommendationOnly(t *testing.T) {
+	pod := newOOMKilledPod(time.Now())
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(pod).
+		WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).
+		Build()
+	clientSet := fake.NewSimpleClientset(pod)
+
+	cfg := config.GetDefaults()
+	cfg.DryRun = false
+	cfg.RecommendationOnly = true
+	watcher := NewOOMWatcher(fakeClient, clientSet, cfg, nil, nil)
+	watcher.Recommendations = &RecommendationWriter{Client: fakeClient}
+	watcher.started = time.Now().Add(-time.Minute)
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "oom-pod"}}
+	if _, err := watcher.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, action := range clientSet.Actions() {
+		if action.GetVerb() == "patch" {
+			t.Fatal("expected no resize in recommendation-only mode")
+		}
+	}
+
+	var rec v1alpha1.RightSizerRecommendation
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "pod-oom-pod"}, &rec); err != nil {
+		t.Fatalf("expected a recommendation: %v", err)
+	}
+	if len(rec.Status.ContainerRecommendations) != 1 {
+		t.Fatalf("expected one container recommendation, got %d", len(rec.Status.ContainerRecommendations))
+	}
+	if got := rec.Status.ContainerRecommendations[0].Recommended.Limits.Memory(); got.Cmp(resource.MustParse("384Mi")) != 0 {
+		t.Fatalf("expected recommended limit 384Mi, got %s", got)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"right-sizer/opa"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OPAFilterName is the decision filter name OPA policies run under
+const OPAFilterName = "opa"
+
+// opaDecisionFilter asks an OPA server to decide on resizes
+type opaDecisionFilter struct {
+	opa    *opa.Client
+	reader client.Reader
+}
+
+// NewOPADecisionFilter returns a decision filter querying the OPA client's
+// rule. Namespace labels are read through reader. A query that fails, or a
+// rule that is undefined, vetoes the resize.
+func NewOPADecisionFilter(opaClient *opa.Client, reader client.Reader) DecisionFilter {
+	return opaDecisionFilter{opa: opaClient, reader: reader}
+}
+
+func (opaDecisionFilter) Name() string { return OPAFilterName }
+
+func (f opaDecisionFilter) Filter(ctx context.Context, pod *corev1.Pod, update ResourceUpdate) (ResourceUpdate, error) {
+	input, err := policyInput(ctx, f.reader, pod, update)
+	if err != nil {
+		return update, err
+	}
+	result, err := f.opa.Evaluate(ctx, input)
+	if err != nil {
+		return update, err
+	}
+	if !result.Allow {
+		return update, fmt.Errorf("denied by OPA: %s", result.Reason)
+	}
+	if result.Resources != nil {
+		update.NewResources = *result.Resources
+	}
+	return update, nil
+}
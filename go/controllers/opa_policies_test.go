@@ -0,0 +1,94 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/opa"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestOPADecisionFilter verifies resizes are governed by an OPA rule that
+// protects namespaces labelled tier=payment and caps CPU requests
+func TestOPADecisionFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input struct {
+				NamespaceLabels map[string]string           `json:"namespaceLabels"`
+				Workload        string                      `json:"workload"`
+				Proposed        corev1.ResourceRequirements `json:"proposed"`
+			} `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		if body.Input.NamespaceLabels["tier"] == "payment" {
+			w.Write([]byte(`{"result":{"allow":false,"reason":"payment tier"}}`))
+			return
+		}
+		if body.Input.Workload != "Deployment/web" {
+			t.Errorf("expected the pod's workload, got %q", body.Input.Workload)
+		}
+		w.Write([]byte(`{"result":{"allow":true,"resources":{"requests":{"cpu":"1"}}}}`))
+	}))
+	defer server.Close()
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	reader := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"tier": "payment"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+	).Build()
+	filter := NewOPADecisionFilter(opa.NewClient(server.URL+"/v1/data/rightsizer/resize", time.Second), reader)
+
+	controller := true
+	pod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace, Name: "web-1", Labels: map[string]string{"pod-template-hash": "6d4cf56db6"},
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-6d4cf56db6", Controller: &controller}},
+		}}
+	}
+	update := func(namespace string) ResourceUpdate {
+		return ResourceUpdate{Namespace: namespace, Name: "web-1", ContainerName: "app", NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		}}
+	}
+
+	if _, err := filter.Filter(context.Background(), pod("payments"), update("payments")); err == nil {
+		t.Error("expected resizes in the payment tier to be vetoed")
+	}
+	adjusted, err := filter.Filter(context.Background(), pod("shop"), update("shop"))
+	if err != nil {
+		t.Fatalf("expected the resize to be allowed, got %v", err)
+	}
+	if cpu := adjusted.NewResources.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 1000 {
+		t.Errorf("expected the CPU request capped at 1 core, got %s", cpu.String())
+	}
+	if _, err := filter.Filter(context.Background(), pod("missing"), update("missing")); err == nil {
+		t.Error("expected a resize in an unreadable namespace to be vetoed")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"right-sizer/logger"
+	"right-sizer/pause"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncAnnotatedPauses records the namespaces paused by annotation
+func (r *AdaptiveRightSizer) syncAnnotatedPauses(ctx context.Context) {
+	if r.Pauses == nil {
+		return
+	}
+	var namespaces corev1.NamespaceList
+	if err := r.Client.List(ctx, &namespaces); err != nil {
+		logger.Warn("Failed to list namespaces for pause annotations: %v", err)
+		return
+	}
+	var paused []string
+	for _, namespace := range namespaces.Items {
+		if namespace.Annotations[pause.Annotation] == "true" {
+			paused = append(paused, namespace.Name)
+		}
+	}
+	r.Pauses.SetAnnotatedNamespaces(paused)
+}
+
+// clusterPaused reports whether resizing is paused for the whole cluster
+func (r *AdaptiveRightSizer) clusterPaused() bool {
+	return r.Pauses != nil && r.Pauses.ClusterPaused()
+}
+
+// filterPaused drops updates of paused namespaces and of pods or workloads
+// carrying the pause annotation
+func (r *AdaptiveRightSizer) filterPaused(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+	workloads := make(map[string]bool)
+
+	result := updates[:0:0]
+	for _, update := range updates {
+		reason, paused := r.pausedReason(update.Namespace)
+		if !paused {
+			if pod, ok := podsByName[update.Namespace+"/"+update.Name]; ok {
+				reason, paused = podPaused(ctx, r.Client, pod, workloads)
+			}
+		}
+		if paused {
+			logger.Debug("Suppressing resize of %s/%s/%s: %s", update.Namespace, update.Name, update.ContainerName, reason)
+			if r.OperatorMetrics != nil {
+				r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "paused")
+			}
+			continue
+		}
+		result = append(result, update)
+	}
+	return result
+}
+
+// pausedReason describes the pause of the cluster or namespace, if any
+func (r *AdaptiveRightSizer) pausedReason(namespace string) (string, bool) {
+	return namespacePaused(r.Pauses, namespace)
+}
+
+// namespacePaused describes the pause of the cluster or namespace in pauses, if any
+func namespacePaused(pauses *pause.State, namespace string) (string, bool) {
+	if pauses == nil {
+		return "", false
+	}
+	entry, paused := pauses.Paused(namespace)
+	if !paused {
+		return "", false
+	}
+	scope := "cluster"
+	if entry.Namespace != "" {
+		scope = "namespace " + entry.Namespace
+	}
+	if entry.Reason != "" {
+		return fmt.Sprintf("%s paused by %s: %s", scope, entry.Source, entry.Reason), true
+	}
+	return fmt.Sprintf("%s paused by %s", scope, entry.Source), true
+}
+
+// podPaused reports whether the pod or the workload owning it carries the
+// pause annotation. Workload lookups are cached in workloads for the cycle.
+func podPaused(ctx context.Context, c client.Client, pod *corev1.Pod, workloads map[string]bool) (string, bool) {
+	if pod.Annotations[pause.Annotation] == "true" {
+		return "pod paused by annotation", true
+	}
+	target := resolveWorkloadRef(ctx, c, pod)
+	if target.Kind == "Pod" {
+		return "", false
+	}
+
+	key := target.APIVersion + "/" + target.Kind + "/" + pod.Namespace + "/" + target.Name
+	paused, ok := workloads[key]
+	if !ok {
+		obj := &metav1.PartialObjectMetadata{}
+		obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(target.APIVersion, target.Kind))
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: target.Name}, obj); err == nil {
+			paused = obj.Annotations[pause.Annotation] == "true"
+		}
+		workloads[key] = paused
+	}
+	if paused {
+		return fmt.Sprintf("%s %s paused by annotation", target.Kind, target.Name), true
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/config"
+	"right-sizer/pause"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFilterPaused(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	controller := true
+	paused := map[string]string{pause.Annotation: "true"}
+	objects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "batch", Annotations: paused}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod", Annotations: paused}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "db-abc", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "db", Controller: &controller}},
+		}},
+	}
+
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build()
+	r.Pauses = pause.NewState(nil)
+	r.syncAnnotatedPauses(context.Background())
+
+	pod := func(namespace, name string, annotations map[string]string, owner string) corev1.Pod {
+		p := createTestPod(name, namespace, "100m", "128Mi", "200m", "256Mi")
+		p.Annotations = annotations
+		if owner != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: owner, Controller: &controller}}
+		}
+		return *p
+	}
+	pods := []corev1.Pod{
+		pod("prod", "web", nil, ""),
+		pod("prod", "db-abc-1", nil, "db-abc"),
+		pod("prod", "cache", paused, ""),
+		pod("batch", "worker", nil, ""),
+		pod("staging", "web", nil, ""),
+	}
+	var updates []ResourceUpdate
+	for _, p := range pods {
+		updates = append(updates, ResourceUpdate{Namespace: p.Namespace, Name: p.Name, ContainerName: "app", ResourceType: "Pod"})
+	}
+
+	remaining := r.filterPaused(context.Background(), updates, pods)
+	if len(remaining) != 2 || remaining[0].Name != "web" || remaining[1].Namespace != "staging" {
+		t.Errorf("expected only the unpaused web pods to remain, got %+v", remaining)
+	}
+
+	r.Pauses.Pause("staging", "")
+	if remaining := r.filterPaused(context.Background(), updates, pods); len(remaining) != 1 {
+		t.Errorf("expected the namespace pause to hold back staging, got %+v", remaining)
+	}
+	r.Pauses.Pause("", "")
+	if remaining := r.filterPaused(context.Background(), updates, pods); len(remaining) != 0 {
+		t.Errorf("expected the cluster pause to hold back every update, got %+v", remaining)
+	}
+}
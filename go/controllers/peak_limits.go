@@ -0,0 +1,43 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"math"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+)
+
+// peakLimit returns the limit of a container's resource sized from its
+// spikes: the configured high percentile of its usage over the resource's
+// limit window plus the limit headroom, in millicores or MB. It reports
+// false when the resource has no limit window or the prediction engine has
+// too little history over it, leaving the limit derived from the request.
+func (r *AdaptiveRightSizer) peakLimit(namespace, podName, containerName, resourceType string, cfg *config.Config) (int64, bool) {
+	window := cfg.LimitWindow(resourceType)
+	if window <= 0 || r.Predictor == nil {
+		return 0, false
+	}
+	peak, samples, err := r.Predictor.GetPercentile(namespace, podName, containerName, resourceType, cfg.LimitPercentile, window)
+	if err != nil || peak <= 0 {
+		logger.Debug("No %s peak for the limit of %s/%s/%s over %v, deriving it from the request: %v", resourceType, namespace, podName, containerName, window, err)
+		return 0, false
+	}
+	limit := int64(math.Ceil(peak * float64(100+cfg.LimitHeadroomPercent) / 100))
+	logger.Debug("%s limit for %s/%s/%s from the P%g over %v: %d (peak %.2f, %d samples)", resourceType, namespace, podName, containerName, cfg.LimitPercentile, window, limit, peak, samples)
+	return limit, true
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/metrics"
+	"right-sizer/predictor"
+)
+
+// TestPeakLimit verifies limits follow the spikes within each resource's own window
+func TestPeakLimit(t *testing.T) {
+	engine, err := predictor.NewEngine(predictor.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	cfg := config.GetDefaults()
+	cfg.SetPeakLimits(99.9, 10, time.Hour, 0)
+	r := newAdaptiveTestRig(cfg)
+	r.Predictor = engine
+
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		cpu := 100.0
+		if i%7 == 0 {
+			cpu = 800 // short spikes
+		}
+		ts := now.Add(-time.Duration(i+1) * time.Minute)
+		_ = engine.StoreDataPoint("ns", "pod", "app", "cpu", cpu, ts)
+		_ = engine.StoreDataPoint("ns", "pod", "app", "memory", 200, ts)
+	}
+	// A spike older than the CPU window is ignored
+	_ = engine.StoreDataPoint("ns", "pod", "app", "cpu", 3000, now.Add(-2*time.Hour))
+
+	if limit, ok := r.peakLimit("ns", "pod", "app", "cpu", cfg); !ok || limit != 880 {
+		t.Fatalf("expected a CPU limit of the peak plus 10%% (880m), got %d (%v)", limit, ok)
+	}
+	if _, ok := r.peakLimit("ns", "pod", "app", "memory", cfg); ok {
+		t.Fatal("expected memory without a limit window to derive its limit from the request")
+	}
+	if _, ok := r.peakLimit("ns", "other", "app", "cpu", cfg); ok {
+		t.Fatal("expected no peak limit without history")
+	}
+
+	resources := r.calculateOptimalResourcesWithPrediction(context.Background(), "ns", "pod", "app",
+		metrics.Metrics{CPUMilli: 100, MemMB: 200}, usageAggregation{}, ResourceScalingDecision{CPU: ScaleUp, Memory: ScaleUp}, cfg, nil, true)
+	if got := resources.Limits.Cpu().MilliValue(); got != 880 {
+		t.Errorf("expected the CPU limit to follow the spikes (880m), got %dm", got)
+	}
+	if got, request := resources.Limits.Memory().Value(), resources.Requests.Memory().Value(); got != int64(float64(request)*cfg.MemoryLimitMultiplier) {
+		t.Errorf("expected the memory limit to be %.1fx its request %d, got %d", cfg.MemoryLimitMultiplier, request, got)
+	}
+}
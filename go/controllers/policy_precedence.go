@@ -0,0 +1,255 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Merge strategies for a policy that wins a workload selected by several policies
+const (
+	MergeStrategyMerge    = "merge"
+	MergeStrategyOverride = "override"
+)
+
+// Condition types set on RightSizerPolicy status
+const (
+	ConditionWorkloadsMatched = "WorkloadsMatched"
+	ConditionPolicyConflict   = "Conflict"
+)
+
+// maxListedWorkloads caps how many workloads a condition message lists
+const maxListedWorkloads = 10
+
+// policyPrecedes reports whether policy a takes precedence over policy b: the
+// higher priority wins, and equal priorities are ordered by namespace and name
+// so the outcome does not depend on list order
+func policyPrecedes(a, b *v1alpha1.RightSizerPolicy) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// sortPoliciesByPrecedence orders policies so the one that wins comes first
+func sortPoliciesByPrecedence(policies []v1alpha1.RightSizerPolicy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		return policyPrecedes(&policies[i], &policies[j])
+	})
+}
+
+// objectKind returns the workload kind of a policy target
+func objectKind(obj client.Object) string {
+	switch obj.(type) {
+	case *appsv1.Deployment:
+		return "Deployment"
+	case *appsv1.StatefulSet:
+		return "StatefulSet"
+	case *appsv1.DaemonSet:
+		return "DaemonSet"
+	case *batchv1.Job:
+		return "Job"
+	case *batchv1.CronJob:
+		return "CronJob"
+	case *corev1.Pod:
+		return "Pod"
+	}
+	return obj.GetObjectKind().GroupVersionKind().Kind
+}
+
+// workloadKey identifies a workload in status messages
+func workloadKey(obj client.Object) string {
+	return fmt.Sprintf("%s %s/%s", objectKind(obj), obj.GetNamespace(), obj.GetName())
+}
+
+// policySelectsObject reports whether a policy's target reference selects the workload
+func (r *RightSizerPolicyReconciler) policySelectsObject(policy *v1alpha1.RightSizerPolicy, obj client.Object) bool {
+	ref := policy.Spec.TargetRef
+	if ref.Kind != "" && ref.Kind != objectKind(obj) {
+		return false
+	}
+	if len(ref.Namespaces) > 0 && !slices.Contains(ref.Namespaces, obj.GetNamespace()) {
+		return false
+	}
+	if slices.Contains(ref.ExcludeNamespaces, obj.GetNamespace()) {
+		return false
+	}
+	if ref.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(ref.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+			return false
+		}
+	}
+	return r.matchesTargetRef(obj, ref)
+}
+
+// mergePolicies returns the effective policy for a workload selected by
+// several policies, ordered by precedence. The first policy wins. With the
+// merge strategy, thresholds, multipliers and other settings it leaves unset
+// are taken from the next policy that sets them, and the workload is only
+// resized for real if none of the policies is in dry-run. With the override
+// strategy the winning policy is used as is.
+func mergePolicies(policies []*v1alpha1.RightSizerPolicy) *v1alpha1.RightSizerPolicy {
+	effective := policies[0].DeepCopy()
+	if effective.Spec.MergeStrategy == MergeStrategyOverride {
+		return effective
+	}
+
+	for _, other := range policies[1:] {
+		effective.Spec.DryRun = effective.Spec.DryRun || other.Spec.DryRun
+		mergeCPUStrategy(&effective.Spec.ResourceStrategy.CPU, &other.Spec.ResourceStrategy.CPU)
+		mergeMemoryStrategy(&effective.Spec.ResourceStrategy.Memory, &other.Spec.ResourceStrategy.Memory)
+
+		if effective.Spec.Profile == "" {
+			effective.Spec.Profile = other.Spec.Profile
+		}
+		if effective.Spec.QoSMode == "" {
+			effective.Spec.QoSMode = other.Spec.QoSMode
+		}
+
+		strategy := &effective.Spec.ResourceStrategy
+		if strategy.Percentile == 0 {
+			strategy.Percentile = other.Spec.ResourceStrategy.Percentile
+		}
+		if strategy.HistoryWindow == "" {
+			strategy.HistoryWindow = other.Spec.ResourceStrategy.HistoryWindow
+		}
+		if len(strategy.CustomMetrics) == 0 {
+			for _, rule := range other.Spec.ResourceStrategy.CustomMetrics {
+				strategy.CustomMetrics = append(strategy.CustomMetrics, *rule.DeepCopy())
+			}
+		}
+
+		constraints := &effective.Spec.Constraints
+		mergePointer(&constraints.MaxChangePercentage, other.Spec.Constraints.MaxChangePercentage)
+		mergePointer(&constraints.MaxScaleUpPercentage, other.Spec.Constraints.MaxScaleUpPercentage)
+		mergePointer(&constraints.MinChangeThreshold, other.Spec.Constraints.MinChangeThreshold)
+		mergePointer(&constraints.MaxNetworkMbps, other.Spec.Constraints.MaxNetworkMbps)
+		mergePointer(&constraints.MaxDiskIOMBps, other.Spec.Constraints.MaxDiskIOMBps)
+		if constraints.CooldownPeriod == "" {
+			constraints.CooldownPeriod = other.Spec.Constraints.CooldownPeriod
+		}
+		if constraints.MinPodAge == "" {
+			constraints.MinPodAge = other.Spec.Constraints.MinPodAge
+		}
+		if constraints.ScaleDownDelay == "" {
+			constraints.ScaleDownDelay = other.Spec.Constraints.ScaleDownDelay
+		}
+		if effective.Spec.Schedule.ResizeInterval == "" {
+			effective.Spec.Schedule.ResizeInterval = other.Spec.Schedule.ResizeInterval
+		}
+	}
+	return effective
+}
+
+func mergeCPUStrategy(into, from *v1alpha1.CPUStrategy) {
+	mergePointer(&into.RequestMultiplier, from.RequestMultiplier)
+	mergePointer(&into.RequestAddition, from.RequestAddition)
+	mergePointer(&into.LimitMultiplier, from.LimitMultiplier)
+	mergePointer(&into.LimitAddition, from.LimitAddition)
+	mergePointer(&into.MinRequest, from.MinRequest)
+	mergePointer(&into.MaxLimit, from.MaxLimit)
+	mergePointer(&into.TargetUtilization, from.TargetUtilization)
+	if into.Aggregation == "" {
+		into.Aggregation = from.Aggregation
+	}
+}
+
+func mergeMemoryStrategy(into, from *v1alpha1.MemoryStrategy) {
+	mergePointer(&into.RequestMultiplier, from.RequestMultiplier)
+	mergePointer(&into.RequestAddition, from.RequestAddition)
+	mergePointer(&into.LimitMultiplier, from.LimitMultiplier)
+	mergePointer(&into.LimitAddition, from.LimitAddition)
+	mergePointer(&into.MinRequest, from.MinRequest)
+	mergePointer(&into.MaxLimit, from.MaxLimit)
+	mergePointer(&into.TargetUtilization, from.TargetUtilization)
+	if into.Aggregation == "" {
+		into.Aggregation = from.Aggregation
+	}
+	if into.SpecialMemory == "" {
+		into.SpecialMemory = from.SpecialMemory
+	}
+	if into.Metric == "" {
+		into.Metric = from.Metric
+	}
+}
+
+// mergePointer fills an unset field from a lower-priority policy
+func mergePointer[T any](into **T, from *T) {
+	if *into == nil && from != nil {
+		value := *from
+		*into = &value
+	}
+}
+
+// setPolicyConditions records the workloads a policy matched and the
+// conflicts with other policies selecting the same workloads
+func setPolicyConditions(policy *v1alpha1.RightSizerPolicy, matched, conflicts []string) {
+	meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+		Type:               ConditionWorkloadsMatched,
+		Status:             conditionStatus(len(matched) > 0),
+		ObservedGeneration: policy.Generation,
+		Reason:             "TargetsEvaluated",
+		Message:            fmt.Sprintf("Matched %d workloads%s", len(matched), listWorkloads(matched)),
+	})
+
+	condition := metav1.Condition{
+		Type:               ConditionPolicyConflict,
+		Status:             conditionStatus(len(conflicts) > 0),
+		ObservedGeneration: policy.Generation,
+		Reason:             "NoConflicts",
+		Message:            "No other policy selects the matched workloads",
+	}
+	if len(conflicts) > 0 {
+		condition.Reason = "OverlappingPolicies"
+		condition.Message = fmt.Sprintf("%d workloads are selected by other policies%s", len(conflicts), listWorkloads(conflicts))
+	}
+	meta.SetStatusCondition(&policy.Status.Conditions, condition)
+}
+
+func conditionStatus(value bool) metav1.ConditionStatus {
+	if value {
+		return metav1.ConditionTrue
+	}
+	return metav1.ConditionFalse
+}
+
+// listWorkloads formats the first entries of a list for a condition message
+func listWorkloads(entries []string) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	if len(entries) <= maxListedWorkloads {
+		return ": " + strings.Join(entries, "; ")
+	}
+	return fmt.Sprintf(": %s; and %d more", strings.Join(entries[:maxListedWorkloads], "; "), len(entries)-maxListedWorkloads)
+}
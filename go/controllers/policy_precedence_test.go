@@ -0,0 +1,117 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func precedencePolicy(namespace, name string, priority int32) v1alpha1.RightSizerPolicy {
+	return v1alpha1.RightSizerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       v1alpha1.RightSizerPolicySpec{Enabled: true, Priority: priority},
+	}
+}
+
+func TestSortPoliciesByPrecedence(t *testing.T) {
+	policies := []v1alpha1.RightSizerPolicy{
+		precedencePolicy("default", "low", 10),
+		precedencePolicy("team-b", "same", 50),
+		precedencePolicy("team-a", "zeta", 50),
+		precedencePolicy("team-a", "alpha", 50),
+		precedencePolicy("default", "high", 100),
+	}
+	sortPoliciesByPrecedence(policies)
+
+	want := []string{"default/high", "team-a/alpha", "team-a/zeta", "team-b/same", "default/low"}
+	for i, policy := range policies {
+		if got := policy.Namespace + "/" + policy.Name; got != want[i] {
+			t.Errorf("position %d: expected %s, got %s", i, want[i], got)
+		}
+	}
+}
+
+// TestMergePolicies verifies unset settings are filled from lower-priority policies and dry-run wins
+func TestMergePolicies(t *testing.T) {
+	winnerMultiplier, otherMultiplier, otherLimit := 1.5, 2.0, 3.0
+	maxChange := int32(20)
+
+	winner := precedencePolicy("default", "winner", 100)
+	winner.Spec.ResourceStrategy.CPU.RequestMultiplier = &winnerMultiplier
+	other := precedencePolicy("default", "other", 10)
+	other.Spec.DryRun = true
+	other.Spec.ResourceStrategy.CPU.RequestMultiplier = &otherMultiplier
+	other.Spec.ResourceStrategy.CPU.LimitMultiplier = &otherLimit
+	other.Spec.Constraints.MaxChangePercentage = &maxChange
+	other.Spec.Constraints.CooldownPeriod = "30m"
+	other.Spec.Profile = ProfileBursty
+
+	merged := mergePolicies([]*v1alpha1.RightSizerPolicy{&winner, &other})
+	cpu := merged.Spec.ResourceStrategy.CPU
+	if *cpu.RequestMultiplier != winnerMultiplier {
+		t.Errorf("expected the winner's request multiplier, got %v", *cpu.RequestMultiplier)
+	}
+	if cpu.LimitMultiplier == nil || *cpu.LimitMultiplier != otherLimit {
+		t.Errorf("expected the limit multiplier filled from the other policy, got %v", cpu.LimitMultiplier)
+	}
+	if merged.Spec.Constraints.MaxChangePercentage == nil || merged.Spec.Constraints.CooldownPeriod != "30m" {
+		t.Errorf("expected constraints filled from the other policy, got %+v", merged.Spec.Constraints)
+	}
+	if merged.Spec.Profile != ProfileBursty {
+		t.Errorf("expected the profile filled from the other policy, got %q", merged.Spec.Profile)
+	}
+	if !merged.Spec.DryRun {
+		t.Error("expected dry-run when any merged policy is in dry-run")
+	}
+	if winner.Spec.ResourceStrategy.CPU.LimitMultiplier != nil || winner.Spec.DryRun {
+		t.Error("expected the winning policy to be left unchanged")
+	}
+
+	winner.Spec.MergeStrategy = MergeStrategyOverride
+	overridden := mergePolicies([]*v1alpha1.RightSizerPolicy{&winner, &other})
+	if overridden.Spec.DryRun || overridden.Spec.ResourceStrategy.CPU.LimitMultiplier != nil {
+		t.Errorf("expected override to ignore lower-priority policies, got %+v", overridden.Spec)
+	}
+}
+
+func TestSetPolicyConditions(t *testing.T) {
+	policy := precedencePolicy("default", "winner", 100)
+	setPolicyConditions(&policy, []string{"Deployment default/web"}, nil)
+
+	matched := meta.FindStatusCondition(policy.Status.Conditions, ConditionWorkloadsMatched)
+	if matched == nil || matched.Status != metav1.ConditionTrue || !strings.Contains(matched.Message, "Deployment default/web") {
+		t.Errorf("expected matched workloads listed, got %+v", matched)
+	}
+	if !meta.IsStatusConditionFalse(policy.Status.Conditions, ConditionPolicyConflict) {
+		t.Error("expected no conflict")
+	}
+
+	conflicts := make([]string, maxListedWorkloads+2)
+	for i := range conflicts {
+		conflicts[i] = "Deployment default/web overridden by default/other (priority 200)"
+	}
+	setPolicyConditions(&policy, []string{"Deployment default/web"}, conflicts)
+	conflict := meta.FindStatusCondition(policy.Status.Conditions, ConditionPolicyConflict)
+	if conflict == nil || conflict.Status != metav1.ConditionTrue || !strings.HasSuffix(conflict.Message, "and 2 more") {
+		t.Errorf("expected a truncated conflict list, got %+v", conflict)
+	}
+}
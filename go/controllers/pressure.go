@@ -0,0 +1,132 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"math"
+
+	"right-sizer/config"
+	"right-sizer/explain"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// pressured reports whether a container's tasks stalled on CPU and memory
+// for longer than the configured pressure thresholds
+func pressured(usage metrics.Metrics, cfg *config.Config) (cpu, memory bool) {
+	cpu = cfg.CPUPressureThreshold > 0 && usage.CPUPressure > cfg.CPUPressureThreshold
+	memory = cfg.MemoryPressureThreshold > 0 && usage.MemoryPressure > cfg.MemoryPressureThreshold
+	return cpu, memory
+}
+
+// raisePressuredResource makes sure a resource a container stalls on gets
+// more than it has now. Its utilization can look acceptable, so usage-based
+// sizing may propose no more than the current request and limit; both are
+// raised by the stall percentage, bounded by maximum (millicores or MB), and
+// never reduced. Requests equal to limits stay equal.
+func raisePressuredResource(current, proposed corev1.ResourceRequirements, name corev1.ResourceName, pressurePercent float64, maximum int64) corev1.ResourceRequirements {
+	// Sized values are in millicores for CPU and MB for memory
+	sized := func(list corev1.ResourceList) int64 {
+		quantity, ok := list[name]
+		if !ok {
+			return 0
+		}
+		if name == corev1.ResourceCPU {
+			return quantity.MilliValue()
+		}
+		return quantity.Value() / (1024 * 1024)
+	}
+	quantity := func(value int64) resource.Quantity {
+		if name == corev1.ResourceCPU {
+			return *resource.NewMilliQuantity(value, resource.DecimalSI)
+		}
+		return *resource.NewQuantity(value*1024*1024, resource.BinarySI)
+	}
+	raised := func(value int64) int64 {
+		target := int64(math.Ceil(float64(value) * (1 + pressurePercent/100)))
+		if maximum > 0 && target > maximum {
+			target = max(maximum, value)
+		}
+		return target
+	}
+
+	result := *proposed.DeepCopy()
+	if result.Requests == nil {
+		result.Requests = corev1.ResourceList{}
+	}
+	if result.Limits == nil {
+		result.Limits = corev1.ResourceList{}
+	}
+
+	currentRequest, currentLimit := sized(current.Requests), sized(current.Limits)
+	if currentRequest > 0 {
+		if target := raised(currentRequest); sized(result.Requests) < target {
+			result.Requests[name] = quantity(target)
+		}
+	}
+	if currentLimit > 0 {
+		if target := raised(currentLimit); sized(result.Limits) < target {
+			result.Limits[name] = quantity(target)
+		}
+		if currentRequest == currentLimit {
+			// Keep requests equal to limits for Guaranteed pods
+			result.Requests[name] = result.Limits[name]
+		}
+	}
+	if limit, ok := result.Limits[name]; ok {
+		if request := result.Requests[name]; limit.Cmp(request) < 0 {
+			result.Limits[name] = request
+		}
+	}
+	return result
+}
+
+// pressureThresholds lists the pressure thresholds a container exceeded
+func pressureThresholds(usage metrics.Metrics, cfg *config.Config) []explain.Threshold {
+	var thresholds []explain.Threshold
+	cpu, memory := pressured(usage, cfg)
+	if cpu {
+		thresholds = append(thresholds, explain.Threshold{
+			Resource:    "cpu-pressure",
+			Utilization: usage.CPUPressure,
+			ScaleUp:     cfg.CPUPressureThreshold,
+			Decision:    scalingDecisionString(ScaleUp),
+		})
+	}
+	if memory {
+		thresholds = append(thresholds, explain.Threshold{
+			Resource:    "memory-pressure",
+			Utilization: usage.MemoryPressure,
+			ScaleUp:     cfg.MemoryPressureThreshold,
+			Decision:    scalingDecisionString(ScaleUp),
+		})
+	}
+	return thresholds
+}
+
+// pressureDetail describes the stalls that raised a container's resources
+func pressureDetail(usage metrics.Metrics, cpu, memory bool) string {
+	switch {
+	case cpu && memory:
+		return fmt.Sprintf("stalled %.0f%% of the time on CPU and %.0f%% on memory", usage.CPUPressure, usage.MemoryPressure)
+	case cpu:
+		return fmt.Sprintf("stalled %.0f%% of the time on CPU", usage.CPUPressure)
+	}
+	return fmt.Sprintf("stalled %.0f%% of the time on memory", usage.MemoryPressure)
+}
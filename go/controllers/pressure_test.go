@@ -0,0 +1,86 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// TestPressureTriggersScaleUp verifies sustained stalls scale up resources whose utilization looks acceptable
+func TestPressureTriggersScaleUp(t *testing.T) {
+	cfg := config.GetDefaults()
+	r := newAdaptiveTestRig(cfg)
+	current := corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000m"), corev1.ResourceMemory: resource.MustParse("1000Mi")}}
+	usage := metrics.Metrics{CPUMilli: 500, MemMB: 500, CPUPressure: 30, MemoryPressure: 15}
+
+	// Disabled by default
+	if d := r.checkScalingThresholds(usage, current, cfg); d.CPU != ScaleNone || d.Memory != ScaleNone {
+		t.Fatalf("expected pressure ignored by default, got %+v", d)
+	}
+
+	cfg.SetPressureThresholds(20, 20)
+	if d := r.checkScalingThresholds(usage, current, cfg); d.CPU != ScaleUp || d.Memory != ScaleNone {
+		t.Fatalf("expected a CPU scale up on CPU pressure only, got %+v", d)
+	}
+	if thresholds := scalingThresholds(usage, current, ResourceScalingDecision{CPU: ScaleUp}, cfg); thresholds[len(thresholds)-1].Resource != "cpu-pressure" {
+		t.Errorf("expected the CPU pressure threshold in the explanation, got %+v", thresholds)
+	}
+
+	// Out of range thresholds are ignored
+	cfg.SetPressureThresholds(-1, 150)
+	if cfg.CPUPressureThreshold != 20 || cfg.MemoryPressureThreshold != 20 {
+		t.Errorf("expected thresholds to be unchanged, got %v and %v", cfg.CPUPressureThreshold, cfg.MemoryPressureThreshold)
+	}
+}
+
+// TestRaisePressuredResource verifies requests and limits grow by the stall share and never shrink
+func TestRaisePressuredResource(t *testing.T) {
+	current := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("400Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("800Mi")},
+	}
+	proposed := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("300Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("600Mi")},
+	}
+
+	got := raisePressuredResource(current, proposed, corev1.ResourceMemory, 25, 8192)
+	if got.Requests.Memory().Value() != 500*1024*1024 || got.Limits.Memory().Value() != 1000*1024*1024 {
+		t.Fatalf("expected 500Mi/1000Mi, got %s/%s", got.Requests.Memory(), got.Limits.Memory())
+	}
+
+	// Bounded by the maximum limit
+	got = raisePressuredResource(current, proposed, corev1.ResourceMemory, 25, 900)
+	if got.Limits.Memory().Value() != 900*1024*1024 {
+		t.Fatalf("expected the limit capped at 900Mi, got %s", got.Limits.Memory())
+	}
+
+	// Guaranteed containers keep requests equal to limits
+	guaranteed := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+	}
+	got = raisePressuredResource(guaranteed, corev1.ResourceRequirements{}, corev1.ResourceCPU, 50, 4000)
+	if got.Requests.Cpu().MilliValue() != 750 || got.Limits.Cpu().MilliValue() != 750 {
+		t.Fatalf("expected 750m/750m, got %s/%s", got.Requests.Cpu(), got.Limits.Cpu())
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/validation"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// qosAnnotation selects the QoS mode of a pod's containers, overriding the
+// mode of any matching RightSizerPolicy
+const qosAnnotation = "rightsizer.io/qos"
+
+// QoS modes controlling how resizes treat a pod's QoS class
+const (
+	// QoSPreserve keeps Guaranteed pods Guaranteed by setting limits to requests
+	QoSPreserve = "preserve"
+	// QoSAllowBurstable sizes limits independently of requests, letting a
+	// Guaranteed pod become Burstable
+	QoSAllowBurstable = "allow-burstable"
+	// QoSForceGuaranteed sets limits to requests for every pod, making
+	// Burstable pods Guaranteed
+	QoSForceGuaranteed = "force-guaranteed"
+)
+
+// validQoSMode reports whether mode is a known QoS mode
+func validQoSMode(mode string) bool {
+	return mode == QoSPreserve || mode == QoSAllowBurstable || mode == QoSForceGuaranteed
+}
+
+// podQoSMode returns the QoS mode of a pod's containers: the one named by its
+// rightsizer.io/qos annotation, else the one of the effective policy
+// selecting its workload, else the global mode set by preserveGuaranteedQoS
+func podQoSMode(pod *corev1.Pod, policies []*v1alpha1.RightSizerPolicy) string {
+	if mode := pod.Annotations[qosAnnotation]; mode != "" {
+		if validQoSMode(mode) {
+			return mode
+		}
+		logger.Warn("Unknown QoS mode %q on pod %s/%s, ignoring it", mode, pod.Namespace, pod.Name)
+	}
+	if len(policies) > 0 {
+		if mode := mergePolicies(policies).Spec.QoSMode; validQoSMode(mode) {
+			return mode
+		}
+	}
+	return globalQoSMode(config.Get())
+}
+
+// globalQoSMode returns the QoS mode of pods without an override
+func globalQoSMode(cfg *config.Config) string {
+	if cfg.PreserveGuaranteedQoS {
+		return QoSPreserve
+	}
+	return QoSAllowBurstable
+}
+
+// applyQoSMode adjusts calculated resources to the QoS mode: limits are set
+// to requests when the mode keeps or makes the pod Guaranteed
+func applyQoSMode(mode string, current corev1.PodQOSClass, resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	guaranteed := mode == QoSForceGuaranteed || (mode == QoSPreserve && current == corev1.PodQOSGuaranteed)
+	if !guaranteed {
+		return resources
+	}
+	adjusted := *resources.DeepCopy()
+	if adjusted.Limits == nil {
+		adjusted.Limits = make(corev1.ResourceList)
+	}
+	for name, request := range adjusted.Requests {
+		adjusted.Limits[name] = request.DeepCopy()
+	}
+	return adjusted
+}
+
+// qosValidator returns a validator permitting the class changes the mode allows
+func qosValidator(mode string) *validation.QoSValidator {
+	return validation.NewQoSValidatorWithConfig(mode == QoSForceGuaranteed, mode == QoSAllowBurstable, true)
+}
+
+// checkQoS validates the QoS class a container's new resources leave the pod
+// in. It returns why the resize cannot be made: the mode forbids the class
+// change, or the change would be applied in place, which Kubernetes refuses
+// for resizes that change a pod's class. Class changes still reach the
+// workload through recommendations and exported patches.
+func checkQoS(pod *corev1.Pod, target podContainer, resources corev1.ResourceRequirements, mode string) (string, bool) {
+	if target.init {
+		return "", true // The validator only resolves regular containers
+	}
+	result := qosValidator(mode).ValidateQoSPreservation(pod, target.container.Name, resources)
+	if !result.Valid {
+		return fmt.Sprintf("QoS mode %s: %v", mode, result.Errors), false
+	}
+	for _, warning := range result.Warnings {
+		logger.Debug("QoS warning for %s/%s container %s: %s", pod.Namespace, pod.Name, target.container.Name, warning)
+	}
+	if cfg := config.Get(); result.ProposedQoS != result.CurrentQoS && !cfg.RecommendationOnly && !cfg.Export.Enabled {
+		return fmt.Sprintf("QoS class change from %s to %s cannot be made in place", result.CurrentQoS, result.ProposedQoS), false
+	}
+	return "", true
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodQoSModePrecedence(t *testing.T) {
+	pod := createTestPod("web", "default", "100m", "128Mi", "100m", "128Mi")
+	policy := &v1alpha1.RightSizerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "web"}}
+	policy.Spec.QoSMode = QoSForceGuaranteed
+
+	if got := podQoSMode(pod, nil); got != globalQoSMode(config.Get()) {
+		t.Errorf("expected the global mode without overrides, got %s", got)
+	}
+	if got := podQoSMode(pod, []*v1alpha1.RightSizerPolicy{policy}); got != QoSForceGuaranteed {
+		t.Errorf("expected the policy's mode, got %s", got)
+	}
+	pod.Annotations = map[string]string{qosAnnotation: QoSAllowBurstable}
+	if got := podQoSMode(pod, []*v1alpha1.RightSizerPolicy{policy}); got != QoSAllowBurstable {
+		t.Errorf("expected the annotation to override the policy, got %s", got)
+	}
+	pod.Annotations[qosAnnotation] = "sometimes"
+	if got := podQoSMode(pod, []*v1alpha1.RightSizerPolicy{policy}); got != QoSForceGuaranteed {
+		t.Errorf("expected an unknown annotation to be ignored, got %s", got)
+	}
+}
+
+func TestApplyQoSMode(t *testing.T) {
+	calculated := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("400m"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+	}
+	tests := []struct {
+		name       string
+		mode       string
+		current    corev1.PodQOSClass
+		guaranteed bool
+	}{
+		{"preserve guaranteed", QoSPreserve, corev1.PodQOSGuaranteed, true},
+		{"preserve burstable", QoSPreserve, corev1.PodQOSBurstable, false},
+		{"allow burstable", QoSAllowBurstable, corev1.PodQOSGuaranteed, false},
+		{"force guaranteed", QoSForceGuaranteed, corev1.PodQOSBurstable, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyQoSMode(tt.mode, tt.current, calculated)
+			limit := got.Limits[corev1.ResourceCPU]
+			if equal := limit.Cmp(got.Requests[corev1.ResourceCPU]) == 0; equal != tt.guaranteed {
+				t.Errorf("expected limits equal to requests %v, got CPU limit %s", tt.guaranteed, limit.String())
+			}
+		})
+	}
+	if limit := calculated.Limits[corev1.ResourceCPU]; limit.String() != "400m" {
+		t.Errorf("expected the calculated resources to be left untouched, got %s", limit.String())
+	}
+}
+
+func TestCheckQoS(t *testing.T) {
+	guaranteed := createTestPod("web", "default", "100m", "128Mi", "100m", "128Mi")
+	target := podContainer{container: guaranteed.Spec.Containers[0]}
+	burstable := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("400m"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+	}
+
+	if reason, ok := checkQoS(guaranteed, target, burstable, QoSPreserve); ok {
+		t.Error("expected preserve to refuse making a Guaranteed pod Burstable")
+	} else if reason == "" {
+		t.Error("expected a reason for the refusal")
+	}
+	if _, ok := checkQoS(guaranteed, target, burstable, QoSAllowBurstable); ok {
+		t.Error("expected a class change not to be made in place")
+	}
+	if _, ok := checkQoS(guaranteed, target, applyQoSMode(QoSPreserve, corev1.PodQOSGuaranteed, burstable), QoSPreserve); !ok {
+		t.Error("expected a resize keeping the pod Guaranteed to pass")
+	}
+
+	cfg := config.Get()
+	cfg.SetRecommendationOnly(true)
+	defer cfg.SetRecommendationOnly(false)
+	if reason, ok := checkQoS(guaranteed, target, burstable, QoSAllowBurstable); !ok {
+		t.Errorf("expected allow-burstable to recommend the class change, got %s", reason)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/logger"
+	"right-sizer/predictor"
+	"right-sizer/workload"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=rightsizer.io,resources=rightsizerrecommendations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rightsizer.io,resources=rightsizerrecommendations/status,verbs=get;update;patch
+
+// RecommendationWriter publishes resize decisions as RightSizerRecommendation
+// objects, one per workload, so they can be reviewed before being applied.
+type RecommendationWriter struct {
+	Client    client.Client
+	Predictor *predictor.Engine // Optional source for sample counts and the data window
+	Pricing   *cost.Client      // Optional source of the prices replica counts are compared at
+}
+
+// workloadRecommendation accumulates the container recommendations of one workload
+type workloadRecommendation struct {
+	namespace  string
+	target     v1alpha1.RecommendationTargetRef
+	containers map[string]*v1alpha1.ContainerRecommendation
+	order      []string
+	podSpec    map[string]bool // containers of the pod template, init containers included
+	dataWindow time.Duration
+	cpuUsage   map[string]float64 // CPU millicores used, by pod
+	horizontal *v1alpha1.HorizontalRecommendation
+}
+
+// Write groups the updates by owning workload and creates or refreshes the
+// RightSizerRecommendation for each of them
+func (w *RecommendationWriter) Write(ctx context.Context, updates []ResourceUpdate, cfg *config.Config) error {
+	workloads := make(map[string]*workloadRecommendation)
+	var keys []string
+
+	for _, update := range updates {
+		var pod corev1.Pod
+		if err := w.Client.Get(ctx, types.NamespacedName{Namespace: update.Namespace, Name: update.Name}, &pod); err != nil {
+			logger.Warn("Failed to get pod %s/%s for recommendation: %v", update.Namespace, update.Name, err)
+			continue
+		}
+
+		target := resolveWorkloadRef(ctx, w.Client, &pod)
+		key := update.Namespace + "/" + recommendationName(target)
+		wl, ok := workloads[key]
+		if !ok {
+			wl = &workloadRecommendation{
+				namespace:  update.Namespace,
+				target:     target,
+				containers: make(map[string]*v1alpha1.ContainerRecommendation),
+				cpuUsage:   make(map[string]float64),
+				podSpec:    podContainerNames(&pod),
+			}
+			workloads[key] = wl
+			keys = append(keys, key)
+		}
+
+		wl.cpuUsage[update.Name] += update.Usage.CPUMilli
+
+		samples, window := w.history(update.Namespace, update.Name, update.ContainerName, cfg.PercentileWindow)
+		if window > wl.dataWindow {
+			wl.dataWindow = window
+		}
+
+		rec := &v1alpha1.ContainerRecommendation{
+			ContainerName: update.ContainerName,
+			Current:       *update.OldResources.DeepCopy(),
+			Recommended:   *update.NewResources.DeepCopy(),
+			Confidence:    recommendationConfidence(samples, cfg.PercentileWindow, cfg.ResizeInterval),
+			Samples:       int32(samples),
+			Reason:        update.Reason,
+			VPATarget:     update.VPATarget,
+		}
+
+		// Replicas of the same workload may disagree; keep the larger recommendation
+		if existing, ok := wl.containers[update.ContainerName]; ok {
+			existing.Recommended.Requests = maxResourceList(existing.Recommended.Requests, rec.Recommended.Requests)
+			existing.Recommended.Limits = maxResourceList(existing.Recommended.Limits, rec.Recommended.Limits)
+			if rec.Samples > existing.Samples {
+				existing.Samples = rec.Samples
+				existing.Confidence = rec.Confidence
+			}
+			continue
+		}
+		wl.containers[update.ContainerName] = rec
+		wl.order = append(wl.order, update.ContainerName)
+	}
+
+	var errs []string
+	for _, key := range keys {
+		workloads[key].horizontal = w.horizontalAdvice(ctx, workloads[key], cfg)
+		if err := w.upsert(ctx, workloads[key], cfg.Algorithm); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to write %d recommendations: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	logger.Info("📝 Wrote %d workload recommendations", len(keys))
+	return nil
+}
+
+// upsert creates the recommendation object if needed and refreshes its status
+func (w *RecommendationWriter) upsert(ctx context.Context, wl *workloadRecommendation, algorithm string) error {
+	key := types.NamespacedName{Namespace: wl.namespace, Name: recommendationName(wl.target)}
+
+	existing := &v1alpha1.RightSizerRecommendation{}
+	err := w.Client.Get(ctx, key, existing)
+	if k8serrors.IsNotFound(err) {
+		existing = newRecommendation(key, wl.target)
+		if err := w.Client.Create(ctx, existing); err != nil {
+			return fmt.Errorf("failed to create recommendation %s: %w", key, err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get recommendation %s: %w", key, err)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		latest := &v1alpha1.RightSizerRecommendation{}
+		if err := w.Client.Get(ctx, key, latest); err != nil {
+			return err
+		}
+		latest.Status.ContainerRecommendations = wl.merge(latest.Status.ContainerRecommendations)
+		latest.Status.Algorithm = algorithm
+		latest.Status.DataWindow = wl.dataWindow.Round(time.Minute).String()
+		latest.Status.LastUpdateTime = &metav1.Time{Time: time.Now()}
+		latest.Status.Horizontal = wl.horizontal
+		return w.Client.Status().Update(ctx, latest)
+	})
+}
+
+// merge refreshes the stored container recommendations with those of this
+// cycle. Containers without an update this cycle keep their recommendation,
+// unless they are no longer in the pod template.
+func (wl *workloadRecommendation) merge(stored []v1alpha1.ContainerRecommendation) []v1alpha1.ContainerRecommendation {
+	containers := make([]v1alpha1.ContainerRecommendation, 0, len(stored)+len(wl.order))
+	seen := make(map[string]bool, len(stored))
+	for _, rec := range stored {
+		seen[rec.ContainerName] = true
+		if updated, ok := wl.containers[rec.ContainerName]; ok {
+			containers = append(containers, *updated)
+		} else if wl.podSpec[rec.ContainerName] {
+			containers = append(containers, rec)
+		}
+	}
+	for _, name := range wl.order {
+		if !seen[name] {
+			containers = append(containers, *wl.containers[name])
+		}
+	}
+	return containers
+}
+
+// podContainerNames returns the names of the containers and init containers of a pod
+func podContainerNames(pod *corev1.Pod) map[string]bool {
+	names := make(map[string]bool, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for _, container := range pod.Spec.InitContainers {
+		names[container.Name] = true
+	}
+	for _, container := range pod.Spec.Containers {
+		names[container.Name] = true
+	}
+	return names
+}
+
+// newRecommendation returns an empty recommendation for a workload
+func newRecommendation(key types.NamespacedName, target v1alpha1.RecommendationTargetRef) *v1alpha1.RightSizerRecommendation {
+	return &v1alpha1.RightSizerRecommendation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "right-sizer",
+			},
+		},
+		Spec: v1alpha1.RightSizerRecommendationSpec{TargetRef: target},
+	}
+}
+
+// history returns the number of recorded samples for a container and the span they cover
+func (w *RecommendationWriter) history(namespace, podName, containerName string, window time.Duration) (int, time.Duration) {
+	if w.Predictor == nil {
+		return 1, 0
+	}
+
+	data, err := w.Predictor.GetHistoricalData(namespace, podName, containerName, "cpu", time.Now().Add(-window))
+	if err != nil || len(data.DataPoints) == 0 {
+		return 1, 0
+	}
+
+	oldest := data.DataPoints[0].Timestamp
+	for _, dp := range data.DataPoints {
+		if dp.Timestamp.Before(oldest) {
+			oldest = dp.Timestamp
+		}
+	}
+	return len(data.DataPoints), time.Since(oldest)
+}
+
+// recommendationConfidence rates how much of the window is covered by samples, as a percentage
+func recommendationConfidence(samples int, window, interval time.Duration) int32 {
+	if interval <= 0 || window <= 0 {
+		return 0
+	}
+
+	expected := int(window / interval)
+	if expected <= 0 || samples >= expected {
+		return 100
+	}
+	return int32(samples * 100 / expected)
+}
+
+// recommendationName returns the object name used for a workload's recommendation
+func recommendationName(target v1alpha1.RecommendationTargetRef) string {
+	return strings.ToLower(target.Kind) + "-" + target.Name
+}
+
+// resolveWorkloadRef walks the pod's controller owners up to the top-level workload
+func resolveWorkloadRef(ctx context.Context, c client.Client, pod *corev1.Pod) v1alpha1.RecommendationTargetRef {
+	return workload.Resolve(ctx, c, pod)
+}
+
+// maxResourceList returns the element-wise maximum of two resource lists
+func maxResourceList(a, b corev1.ResourceList) corev1.ResourceList {
+	out := corev1.ResourceList{}
+	for name, qty := range a {
+		out[name] = qty.DeepCopy()
+	}
+	for name, qty := range b {
+		if current, ok := out[name]; !ok || qty.Cmp(current) > 0 {
+			out[name] = qty.DeepCopy()
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newOwnedPod(name, rsName string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rsName, Controller: &controller},
+			},
+		},
+	}
+}
+
+func requestUpdate(podName, cpu, mem string) ResourceUpdate {
+	return ResourceUpdate{
+		Namespace:     "default",
+		Name:          podName,
+		ResourceType:  "Pod",
+		ContainerName: "app",
+		NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(mem),
+			},
+		},
+		Reason: "CPU scale up",
+	}
+}
+
+// TestRecommendationWriterGroupsByWorkload verifies replicas produce one recommendation per Deployment
+func TestRecommendationWriterGroupsByWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	controller := true
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller},
+			},
+		},
+	}
+
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(rs, newOwnedPod("web-abc-1", "web-abc"), newOwnedPod("web-abc-2", "web-abc")).
+		WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).
+		Build()
+
+	writer := &RecommendationWriter{Client: fakeClient}
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-1", "200m", "256Mi"),
+		requestUpdate("web-abc-2", "150m", "512Mi"),
+	}
+
+	cfg := config.GetDefaults()
+	if err := writer.Write(context.Background(), updates, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rec v1alpha1.RightSizerRecommendation
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "deployment-web"}, &rec); err != nil {
+		t.Fatalf("expected recommendation for deployment web: %v", err)
+	}
+
+	if rec.Spec.TargetRef.Kind != "Deployment" || rec.Spec.TargetRef.Name != "web" {
+		t.Fatalf("unexpected target %+v", rec.Spec.TargetRef)
+	}
+	if len(rec.Status.ContainerRecommendations) != 1 {
+		t.Fatalf("expected one container recommendation, got %d", len(rec.Status.ContainerRecommendations))
+	}
+
+	// The larger value of each resource across replicas is recommended
+	got := rec.Status.ContainerRecommendations[0].Recommended.Requests
+	if got.Cpu().MilliValue() != 200 || got.Memory().Value() != 512*1024*1024 {
+		t.Fatalf("expected 200m/512Mi, got %s/%s", got.Cpu(), got.Memory())
+	}
+	if rec.Status.Algorithm != cfg.Algorithm || rec.Status.LastUpdateTime == nil {
+		t.Fatalf("expected algorithm and update time in status, got %+v", rec.Status)
+	}
+}
+
+// TestRecommendationWriterMergesContainers verifies containers without an
+// update keep their recommendation while containers gone from the pod are dropped
+func TestRecommendationWriterMergesContainers(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app"},
+			{Name: "sidecar"},
+		}},
+	}
+	key := types.NamespacedName{Namespace: "default", Name: "pod-web"}
+	stored := newRecommendation(key, v1alpha1.RecommendationTargetRef{Kind: "Pod", Name: "web"})
+	stored.Status.ContainerRecommendations = []v1alpha1.ContainerRecommendation{
+		{ContainerName: "app", Reason: "old"},
+		{ContainerName: "sidecar", Reason: "kept"},
+		{ContainerName: "removed", Reason: "gone"},
+	}
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(pod, stored).
+		WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).
+		Build()
+
+	writer := &RecommendationWriter{Client: fakeClient}
+	if err := writer.Write(context.Background(), []ResourceUpdate{requestUpdate("web", "200m", "256Mi")}, config.GetDefaults()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var rec v1alpha1.RightSizerRecommendation
+	if err := fakeClient.Get(context.Background(), key, &rec); err != nil {
+		t.Fatalf("failed to get recommendation: %v", err)
+	}
+	var reasons []string
+	for _, container := range rec.Status.ContainerRecommendations {
+		reasons = append(reasons, container.ContainerName+"="+container.Reason)
+	}
+	if got, want := strings.Join(reasons, ","), "app=CPU scale up,sidecar=kept"; got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
+
+func TestRecommendationConfidence(t *testing.T) {
+	if got := recommendationConfidence(30, time.Hour, time.Minute); got != 50 {
+		t.Fatalf("expected 50%% confidence, got %d", got)
+	}
+	if got := recommendationConfidence(120, time.Hour, time.Minute); got != 100 {
+		t.Fatalf("expected confidence capped at 100, got %d", got)
+	}
+	if got := recommendationConfidence(10, time.Hour, 0); got != 0 {
+		t.Fatalf("expected 0 without an interval, got %d", got)
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/events"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// deferredResizeRecheck is the first delay before a deferred resize is checked again
+	deferredResizeRecheck = time.Minute
+
+	// maxDeferredResizeChecks is how many times a deferred resize is checked
+	// before it is rolled back and left to the next run to re-plan
+	maxDeferredResizeChecks = 5
+)
+
+// resizeState is the last resize condition observed for a pod
+type resizeState struct {
+	condition string
+	reason    string
+	since     time.Time
+	checks    int
+	nextCheck time.Time
+}
+
+// ResizeConditionWatcher follows the PodResizePending and PodResizeInProgress
+// conditions the kubelet sets after an in-place resize. Every transition is
+// counted and audited. Infeasible resizes are rolled back to the resources the
+// kubelet allocated, so the pod spec matches what is running. Deferred resizes
+// are checked again with exponential backoff, since the kubelet applies them
+// once room frees up on the node; a resize still deferred after
+// maxDeferredResizeChecks is rolled back so the next run can re-plan it to fit.
+type ResizeConditionWatcher struct {
+	client.Client
+	ClientSet       kubernetes.Interface
+	Config          *config.Config
+	AuditLogger     *audit.AuditLogger
+	OperatorMetrics *metrics.OperatorMetrics
+	EventRecorder   record.EventRecorder
+	EventBus        *events.EventBus // Streams rollbacks to API clients; optional
+	Safety          *SafetyTuner     // Widens the headroom of workloads after rollbacks; optional
+
+	mu     sync.Mutex
+	states map[types.UID]*resizeState
+}
+
+// NewResizeConditionWatcher creates a new resize condition watcher
+func NewResizeConditionWatcher(c client.Client, clientSet kubernetes.Interface, cfg *config.Config, auditLogger *audit.AuditLogger, operatorMetrics *metrics.OperatorMetrics, eventRecorder record.EventRecorder) *ResizeConditionWatcher {
+	return &ResizeConditionWatcher{
+		Client:          c,
+		ClientSet:       clientSet,
+		Config:          cfg,
+		AuditLogger:     auditLogger,
+		OperatorMetrics: operatorMetrics,
+		EventRecorder:   eventRecorder,
+		states:          make(map[types.UID]*resizeState),
+	}
+}
+
+// Reconcile records resize condition transitions and reacts to Infeasible and Deferred resizes
+func (w *ResizeConditionWatcher) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pod corev1.Pod
+	if err := w.Get(ctx, req.NamespacedName, &pod); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	condition, reason, message := currentResizeCondition(&pod)
+	state, changed := w.observe(&pod, condition, reason)
+	if changed {
+		w.recordTransition(&pod, condition, reason, message)
+	}
+
+	// Only act on pods the operator manages
+	if !w.Config.IsNamespaceIncluded(pod.Namespace) || pod.Annotations["rightsizer.io/disable"] == "true" {
+		return ctrl.Result{}, nil
+	}
+
+	switch {
+	case condition == string(corev1.PodResizePending) && reason == corev1.PodReasonInfeasible:
+		return ctrl.Result{}, w.rollback(ctx, &pod, "ResizeInfeasible",
+			fmt.Sprintf("Resize is infeasible on node %s, reverted to allocated resources: %s", pod.Spec.NodeName, message))
+
+	case condition == string(corev1.PodResizePending) && reason == corev1.PodReasonDeferred:
+		w.mu.Lock()
+		now := time.Now()
+		if wait := state.nextCheck.Sub(now); wait > 0 {
+			// Woken by a pod update before the check is due
+			w.mu.Unlock()
+			return ctrl.Result{RequeueAfter: wait}, nil
+		}
+		checks := state.checks
+		delay := deferredResizeRecheck << checks
+		state.checks++
+		state.nextCheck = now.Add(delay)
+		w.mu.Unlock()
+
+		if checks >= maxDeferredResizeChecks {
+			return ctrl.Result{}, w.rollback(ctx, &pod, "ResizeAbandoned",
+				fmt.Sprintf("Resize still deferred after %v, reverted to allocated resources: %s", time.Since(state.since).Round(time.Second), message))
+		}
+		if checks > 0 && w.OperatorMetrics != nil {
+			w.OperatorMetrics.RecordResizeCondition(pod.Namespace, condition, "DeferredRetry")
+		}
+		logger.Debug("Resize of %s/%s deferred by the kubelet, checking again in %v", pod.Namespace, pod.Name, delay)
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// currentResizeCondition returns the active resize condition of a pod. A
+// pending resize takes precedence over one in progress, as it is the newer request.
+func currentResizeCondition(pod *corev1.Pod) (condition, reason, message string) {
+	for _, conditionType := range []corev1.PodConditionType{corev1.PodResizePending, corev1.PodResizeInProgress} {
+		if c, ok := GetCondition(pod, conditionType); ok && c.Status == corev1.ConditionTrue {
+			return string(c.Type), c.Reason, c.Message
+		}
+	}
+	return "", "", ""
+}
+
+// observe stores the pod's current resize condition and reports whether it changed
+func (w *ResizeConditionWatcher) observe(pod *corev1.Pod, condition, reason string) (*resizeState, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state, ok := w.states[pod.UID]
+	if condition == "" {
+		delete(w.states, pod.UID)
+		return nil, ok
+	}
+	if ok && state.condition == condition && state.reason == reason {
+		return state, false
+	}
+	state = &resizeState{condition: condition, reason: reason, since: time.Now()}
+	w.states[pod.UID] = state
+	return state, true
+}
+
+// recordTransition counts and audits a resize condition change
+func (w *ResizeConditionWatcher) recordTransition(pod *corev1.Pod, condition, reason, message string) {
+	status := "completed"
+	if condition == "" {
+		reason = ReasonResizeCompleted
+	} else {
+		status = "pending"
+		if condition == string(corev1.PodResizeInProgress) {
+			status = "in_progress"
+		}
+		logger.Info("📐 Pod %s/%s %s (%s): %s", pod.Namespace, pod.Name, condition, reason, message)
+		if w.OperatorMetrics != nil {
+			w.OperatorMetrics.RecordResizeCondition(pod.Namespace, condition, reason)
+		}
+	}
+
+	if w.AuditLogger != nil {
+		w.AuditLogger.LogOperatorEvent("ResizeCondition", "resize_status", reason, status, map[string]interface{}{
+			"namespace": pod.Namespace,
+			"pod":       pod.Name,
+			"node":      pod.Spec.NodeName,
+			"condition": condition,
+			"message":   message,
+		})
+	}
+}
+
+// rollback resets the pod's container resources to those the kubelet allocated
+func (w *ResizeConditionWatcher) rollback(ctx context.Context, pod *corev1.Pod, eventReason, message string) error {
+	var patchOps []map[string]interface{}
+	for i, container := range pod.Spec.Containers {
+		allocated := allocatedResources(pod, container.Name)
+		if allocated == nil {
+			continue
+		}
+		if len(allocated.Requests) > 0 && !resourceListsEqual(container.Resources.Requests, allocated.Requests) {
+			patchOps = append(patchOps, map[string]interface{}{
+				"op":    "replace",
+				"path":  fmt.Sprintf("/spec/containers/%d/resources/requests", i),
+				"value": allocated.Requests,
+			})
+		}
+		if len(allocated.Limits) > 0 && !resourceListsEqual(container.Resources.Limits, allocated.Limits) {
+			patchOps = append(patchOps, map[string]interface{}{
+				"op":    "replace",
+				"path":  fmt.Sprintf("/spec/containers/%d/resources/limits", i),
+				"value": allocated.Limits,
+			})
+		}
+	}
+
+	w.forget(pod.UID)
+	if len(patchOps) == 0 {
+		return nil
+	}
+	if w.Config != nil && w.Config.DryRun {
+		logger.Info("🔍 DRY RUN: Would revert resize of %s/%s: %s", pod.Namespace, pod.Name, message)
+		return nil
+	}
+
+	patchData, err := json.Marshal(patchOps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rollback patch: %w", err)
+	}
+	if _, err := w.ClientSet.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.JSONPatchType, patchData, metav1.PatchOptions{}, "resize"); err != nil {
+		return fmt.Errorf("failed to revert resize of %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	logger.Warn("↩️  %s/%s: %s", pod.Namespace, pod.Name, message)
+	if w.EventRecorder != nil {
+		w.EventRecorder.Event(pod, corev1.EventTypeWarning, eventReason, message)
+	}
+	if w.OperatorMetrics != nil {
+		w.OperatorMetrics.RecordSuppressedResize(pod.Namespace, "rolled_back")
+	}
+	if w.Safety != nil && w.Config != nil {
+		w.Safety.RecordRollback(ctx, pod, w.Config)
+	}
+	publishResizeEvent(w.EventBus, events.EventResizeRolledBack, events.SeverityWarning, pod.Namespace, pod.Name, message,
+		map[string]interface{}{"reason": eventReason})
+	return nil
+}
+
+// allocatedResources returns the resources the kubelet has applied to a container
+func allocatedResources(pod *corev1.Pod, containerName string) *corev1.ResourceRequirements {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		if status.Resources != nil {
+			return status.Resources
+		}
+		if len(status.AllocatedResources) > 0 {
+			return &corev1.ResourceRequirements{Requests: status.AllocatedResources}
+		}
+	}
+	return nil
+}
+
+// resourceListsEqual compares the CPU and memory of two resource lists
+func resourceListsEqual(a, b corev1.ResourceList) bool {
+	return a.Cpu().Equal(*b.Cpu()) && a.Memory().Equal(*b.Memory())
+}
+
+// forget drops the tracked state of a pod
+func (w *ResizeConditionWatcher) forget(uid types.UID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.states, uid)
+}
+
+// hasResizeCondition reports whether a pod carries a resize condition
+func hasResizeCondition(pod *corev1.Pod) bool {
+	condition, _, _ := currentResizeCondition(pod)
+	return condition != ""
+}
+
+// SetupWithManager sets up the watcher with the manager
+func (w *ResizeConditionWatcher) SetupWithManager(mgr ctrl.Manager) error {
+	resizePredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			pod, ok := e.Object.(*corev1.Pod)
+			return ok && hasResizeCondition(pod)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Also pass updates where a condition was cleared, to record completion
+			oldPod, ok := e.ObjectOld.(*corev1.Pod)
+			newPod, ok2 := e.ObjectNew.(*corev1.Pod)
+			return ok && ok2 && (hasResizeCondition(oldPod) || hasResizeCondition(newPod))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			if pod, ok := e.Object.(*corev1.Pod); ok {
+				w.forget(pod.UID)
+			}
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("resize-condition-watcher").
+		For(&corev1.Pod{}).
+		WithEventFilter(resizePredicate).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: w.Config.MaxConcurrentReconciles,
+		}).
+		Complete(w)
+}
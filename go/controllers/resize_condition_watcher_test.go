@@ -0,0 +1,150 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// pendingResizePod returns a pod resized from 256Mi to 1Gi that the kubelet has not applied
+func pendingResizePod(reason string) *corev1.Pod {
+	allocated := memoryResources("256Mi", "256Mi")
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "uid-1"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node-1",
+			Containers: []corev1.Container{{Name: "app", Resources: memoryResources("1Gi", "1Gi")}},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{
+				Type:    corev1.PodResizePending,
+				Status:  corev1.ConditionTrue,
+				Reason:  reason,
+				Message: "Node didn't have enough capacity: memory",
+			}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Resources: &allocated}},
+		},
+	}
+}
+
+func newResizeConditionWatcher(pod *corev1.Pod) (*ResizeConditionWatcher, *[]string, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	clientSet := fake.NewSimpleClientset(pod)
+
+	var patches []string
+	clientSet.PrependReactor("patch", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patches = append(patches, string(action.(clienttesting.PatchAction).GetPatch()))
+		return true, pod, nil
+	})
+
+	cfg := config.GetDefaults()
+	cfg.DryRun = false
+	recorder := record.NewFakeRecorder(10)
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build()
+	return NewResizeConditionWatcher(fakeClient, clientSet, cfg, nil, nil, recorder), &patches, recorder
+}
+
+var resizeRequest = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}}
+
+// TestResizeConditionWatcherInfeasible verifies an infeasible resize is reverted to the allocated resources
+func TestResizeConditionWatcherInfeasible(t *testing.T) {
+	watcher, patches, recorder := newResizeConditionWatcher(pendingResizePod(corev1.PodReasonInfeasible))
+
+	if _, err := watcher.Reconcile(context.Background(), resizeRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 1 {
+		t.Fatalf("expected one rollback patch, got %d", len(*patches))
+	}
+
+	var ops []map[string]interface{}
+	if err := json.Unmarshal([]byte((*patches)[0]), &ops); err != nil {
+		t.Fatalf("invalid patch: %v", err)
+	}
+	for _, op := range ops {
+		if value := op["value"].(map[string]interface{}); value["memory"] != "256Mi" {
+			t.Errorf("expected %s reverted to 256Mi, got %v", op["path"], value)
+		}
+	}
+	if event := <-recorder.Events; !strings.Contains(event, "ResizeInfeasible") {
+		t.Errorf("expected ResizeInfeasible event, got %q", event)
+	}
+}
+
+// TestResizeConditionWatcherDeferred verifies deferred resizes are re-checked with backoff and then rolled back
+func TestResizeConditionWatcherDeferred(t *testing.T) {
+	watcher, patches, _ := newResizeConditionWatcher(pendingResizePod(corev1.PodReasonDeferred))
+	ctx := context.Background()
+
+	result, err := watcher.Reconcile(ctx, resizeRequest)
+	if err != nil || result.RequeueAfter != deferredResizeRecheck {
+		t.Fatalf("expected a recheck after %v, got %v (%v)", deferredResizeRecheck, result.RequeueAfter, err)
+	}
+
+	// A pod update before the check is due does not count as a check
+	if result, _ = watcher.Reconcile(ctx, resizeRequest); result.RequeueAfter <= 0 || result.RequeueAfter > deferredResizeRecheck {
+		t.Fatalf("expected the remaining wait, got %v", result.RequeueAfter)
+	}
+
+	for i := 1; i < maxDeferredResizeChecks; i++ {
+		watcher.states["uid-1"].nextCheck = time.Time{}
+		result, _ = watcher.Reconcile(ctx, resizeRequest)
+		if want := deferredResizeRecheck << i; result.RequeueAfter != want {
+			t.Fatalf("check %d: expected backoff %v, got %v", i, want, result.RequeueAfter)
+		}
+	}
+	if len(*patches) != 0 {
+		t.Fatalf("expected no rollback while checks remain, got %d patches", len(*patches))
+	}
+
+	watcher.states["uid-1"].nextCheck = time.Time{}
+	if _, err := watcher.Reconcile(ctx, resizeRequest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 1 {
+		t.Fatalf("expected the deferred resize rolled back after %d checks, got %d patches", maxDeferredResizeChecks, len(*patches))
+	}
+}
+
+func TestCurrentResizeCondition(t *testing.T) {
+	pod := pendingResizePod(corev1.PodReasonDeferred)
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{Type: corev1.PodResizeInProgress, Status: corev1.ConditionTrue})
+	if condition, reason, _ := currentResizeCondition(pod); condition != string(corev1.PodResizePending) || reason != corev1.PodReasonDeferred {
+		t.Errorf("expected pending resize to take precedence, got %s/%s", condition, reason)
+	}
+
+	pod.Status.Conditions = nil
+	if hasResizeCondition(pod) {
+		t.Error("expected no resize condition")
+	}
+}
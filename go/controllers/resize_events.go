@@ -0,0 +1,109 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Reasons of the events recorded for the resizes of the apply phase
+const (
+	EventReasonResizeApplied = "ResizeApplied"
+	EventReasonResizeSkipped = "ResizeSkipped"
+	EventReasonResizeFailed  = "ResizeFailed"
+)
+
+// recordResizeEvent records a Kubernetes Event for the outcome of an update
+// on its pod and on the workload owning the pod, so `kubectl describe` shows
+// what the operator did with the old and new values and why. detail is the
+// error of a failed resize or why it was skipped.
+func (r *AdaptiveRightSizer) recordResizeEvent(ctx context.Context, pod *corev1.Pod, update ResourceUpdate, reason, detail string) {
+	if r.EventRecorder == nil {
+		return
+	}
+	if pod == nil {
+		pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: update.Namespace, Name: update.Name}}
+	}
+
+	eventType := corev1.EventTypeNormal
+	var message string
+	switch reason {
+	case EventReasonResizeApplied:
+		message = fmt.Sprintf("Resized container %s: %s", update.ContainerName, resizeChangeSummary(update))
+	case EventReasonResizeSkipped:
+		message = fmt.Sprintf("Skipped resize of container %s (%s): %s", update.ContainerName, resizeChangeSummary(update), detail)
+	default:
+		eventType = corev1.EventTypeWarning
+		message = fmt.Sprintf("Failed to resize container %s (%s): %s", update.ContainerName, resizeChangeSummary(update), detail)
+	}
+	if update.Reason != "" {
+		message += "; reason: " + update.Reason
+	}
+	r.EventRecorder.Event(pod, eventType, reason, message)
+
+	target := resolveWorkloadRef(ctx, r.Client, pod)
+	if target.Kind == "Pod" {
+		return
+	}
+	owner := &metav1.PartialObjectMetadata{}
+	gvk := schema.FromAPIVersionAndKind(target.APIVersion, target.Kind)
+	owner.SetGroupVersionKind(gvk)
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: target.Name}, owner); err != nil {
+		return
+	}
+	owner.SetGroupVersionKind(gvk)
+	r.EventRecorder.Event(owner, eventType, reason, "Pod "+pod.Name+": "+message)
+}
+
+// resizeChangeSummary lists the requests and limits an update changes, old
+// value to new, e.g. "cpu request 500m→250m, memory limit 1Gi→512Mi"
+func resizeChangeSummary(update ResourceUpdate) string {
+	var changes []string
+	for _, kind := range []struct {
+		name     string
+		old, new corev1.ResourceList
+	}{
+		{"request", update.OldResources.Requests, update.NewResources.Requests},
+		{"limit", update.OldResources.Limits, update.NewResources.Limits},
+	} {
+		for _, resource := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			newValue, ok := kind.new[resource]
+			if !ok {
+				continue
+			}
+			oldValue, had := kind.old[resource]
+			if had && oldValue.Cmp(newValue) == 0 {
+				continue
+			}
+			from := "none"
+			if had {
+				from = oldValue.String()
+			}
+			changes = append(changes, fmt.Sprintf("%s %s %s→%s", resource, kind.name, from, newValue.String()))
+		}
+	}
+	if len(changes) == 0 {
+		return "no change"
+	}
+	return strings.Join(changes, ", ")
+}
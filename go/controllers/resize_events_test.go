@@ -0,0 +1,88 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestRecordResizeEventOnPodAndWorkload verifies events land on the pod and on the
+// Deployment owning it, with the old and new values and the reason
+func TestRecordResizeEventOnPodAndWorkload(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	controller := true
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", UID: "deploy-uid"}}
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "shop",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}}}}
+	pod := createTestPod("web-abc-1", "shop", "500m", "512Mi", "1", "1Gi")
+	pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}}
+
+	recorder := record.NewFakeRecorder(10)
+	recorder.IncludeObject = true
+	r := &AdaptiveRightSizer{
+		Client:        ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(deployment, replicaSet, pod).Build(),
+		EventRecorder: recorder,
+	}
+	update := ResourceUpdate{
+		Namespace:     "shop",
+		Name:          pod.Name,
+		ContainerName: "test-container",
+		OldResources:  pod.Spec.Containers[0].Resources,
+		NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		Reason: "CPU usage below threshold",
+	}
+
+	r.recordResizeEvent(context.Background(), pod, update, EventReasonResizeApplied, "")
+	podEvent, workloadEvent := <-recorder.Events, <-recorder.Events
+	want := "Normal ResizeApplied Resized container test-container: cpu request 500m→250m, cpu limit 1→500m; reason: CPU usage below threshold"
+	if !strings.HasPrefix(podEvent, want) {
+		t.Errorf("unexpected pod event %q", podEvent)
+	}
+	if !strings.Contains(workloadEvent, "Pod web-abc-1: Resized container") || !strings.Contains(workloadEvent, "kind=Deployment") {
+		t.Errorf("unexpected workload event %q", workloadEvent)
+	}
+
+	r.recordResizeEvent(context.Background(), pod, update, EventReasonResizeFailed, "patch rejected")
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning ResizeFailed Failed to resize container test-container (cpu request 500m→250m, cpu limit 1→500m): patch rejected") {
+		t.Errorf("unexpected failure event %q", event)
+	}
+	<-recorder.Events
+
+	// A bare pod has no workload to record on
+	bare := createTestPod("bare", "shop", "500m", "512Mi", "1", "1Gi")
+	r.recordResizeEvent(context.Background(), bare, update, EventReasonResizeSkipped, "namespace paused")
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal ResizeSkipped Skipped resize of container test-container") {
+		t.Errorf("unexpected skip event %q", event)
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no workload event for a bare pod, got %q", <-recorder.Events)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// resizeSchedule is the scheduling wheel of the resize loop. Pods are
+// analyzed on the cadence of the resize interval of their effective policy,
+// or on the global resize interval, and each cadence comes due on its own,
+// so a dev namespace can be resized every few minutes while stable
+// infrastructure is left alone for hours.
+type resizeSchedule struct {
+	mu   sync.Mutex
+	next map[time.Duration]time.Time // when each cadence, by interval, is next due
+}
+
+// sync registers the cadences in use and drops the others. New cadences are
+// due right away.
+func (s *resizeSchedule) sync(intervals []time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inUse := make(map[time.Duration]bool, len(intervals))
+	for _, interval := range intervals {
+		if interval <= 0 {
+			continue
+		}
+		inUse[interval] = true
+		if _, ok := s.next[interval]; !ok {
+			if s.next == nil {
+				s.next = make(map[time.Duration]time.Time)
+			}
+			s.next[interval] = now
+		}
+	}
+	for interval := range s.next {
+		if !inUse[interval] {
+			delete(s.next, interval)
+		}
+	}
+}
+
+// replace moves a cadence to a new interval, first due one new interval from now
+func (s *resizeSchedule) replace(old, interval time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.next, old)
+	if interval > 0 {
+		if s.next == nil {
+			s.next = make(map[time.Duration]time.Time)
+		}
+		s.next[interval] = now.Add(interval)
+	}
+}
+
+// take returns the cadences due at now and schedules their next turn, whole
+// intervals after the last one so they keep their rhythm
+func (s *resizeSchedule) take(now time.Time) map[time.Duration]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make(map[time.Duration]bool)
+	for interval, next := range s.next {
+		if next.After(now) {
+			continue
+		}
+		due[interval] = true
+		for !next.After(now) {
+			next = next.Add(interval)
+		}
+		s.next[interval] = next
+	}
+	return due
+}
+
+// wait returns how long until the next cadence is due, or fallback when no
+// cadence is registered yet
+func (s *resizeSchedule) wait(now time.Time, fallback time.Duration) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.next) == 0 {
+		return fallback
+	}
+	var earliest time.Time
+	for _, next := range s.next {
+		if earliest.IsZero() || next.Before(earliest) {
+			earliest = next
+		}
+	}
+	return max(earliest.Sub(now), 0)
+}
+
+// duePods returns the pods whose cadence is due this run and schedules the
+// next turn of the due cadences. Without a global interval, e.g. in a run
+// outside the resize loop, every pod is due.
+func (r *AdaptiveRightSizer) duePods(ctx context.Context, pods []corev1.Pod, policies []v1alpha1.RightSizerPolicy, now time.Time) []corev1.Pod {
+	if r.Interval <= 0 {
+		return pods
+	}
+	intervals := []time.Duration{r.Interval}
+	for i := range policies {
+		if interval, err := time.ParseDuration(policies[i].Spec.Schedule.ResizeInterval); err == nil && interval > 0 {
+			intervals = append(intervals, interval)
+		}
+	}
+	r.schedule.sync(intervals, now)
+	due := r.schedule.take(now)
+	if len(due) == 0 {
+		return nil
+	}
+	if len(intervals) == 1 {
+		return pods
+	}
+
+	var result []corev1.Pod
+	for i := range pods {
+		if due[podResizeInterval(r.matchingPolicies(ctx, &pods[i], policies), r.Interval)] {
+			result = append(result, pods[i])
+		}
+	}
+	return result
+}
+
+// podResizeInterval returns the resize interval of the effective policy of a
+// pod, or the global interval when none sets a valid one
+func podResizeInterval(policies []*v1alpha1.RightSizerPolicy, global time.Duration) time.Duration {
+	if len(policies) > 0 {
+		if value := mergePolicies(policies).Spec.Schedule.ResizeInterval; value != "" {
+			interval, err := time.ParseDuration(value)
+			if err == nil && interval > 0 {
+				return interval
+			}
+			logger.Warn("Invalid resizeInterval %q in RightSizerPolicy %s, using the global value", value, policies[0].Name)
+		}
+	}
+	return global
+}
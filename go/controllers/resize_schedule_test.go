@@ -0,0 +1,91 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestResizeScheduleKeepsRhythm verifies cadences come due on their own
+// intervals, whole intervals apart
+func TestResizeScheduleKeepsRhythm(t *testing.T) {
+	var s resizeSchedule
+	start := time.Now()
+	s.sync([]time.Duration{5 * time.Minute, time.Hour}, start)
+
+	if due := s.take(start); !due[5*time.Minute] || !due[time.Hour] {
+		t.Fatalf("expected new cadences to be due right away, got %v", due)
+	}
+	if wait := s.wait(start, time.Minute); wait != 5*time.Minute {
+		t.Fatalf("expected to wait 5m, got %v", wait)
+	}
+	// A late wake-up does not shift the cadence
+	if due := s.take(start.Add(6 * time.Minute)); !due[5*time.Minute] || due[time.Hour] {
+		t.Fatalf("expected only the 5m cadence to be due, got %v", due)
+	}
+	if wait := s.wait(start.Add(6*time.Minute), time.Minute); wait != 4*time.Minute {
+		t.Fatalf("expected to wait 4m, got %v", wait)
+	}
+
+	s.sync([]time.Duration{5 * time.Minute}, start)
+	if due := s.take(start.Add(time.Hour)); due[time.Hour] {
+		t.Fatalf("expected the cadence no longer in use to be dropped")
+	}
+}
+
+// TestDuePodsFollowPolicyIntervals verifies pods are analyzed on the resize
+// interval of their policy and the others on the global interval
+func TestDuePodsFollowPolicyIntervals(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).Build()
+	r.Interval = 5 * time.Minute
+
+	policies := []v1alpha1.RightSizerPolicy{{
+		Spec: v1alpha1.RightSizerPolicySpec{
+			Enabled:   true,
+			TargetRef: v1alpha1.TargetReference{Namespaces: []string{"infra"}},
+			Schedule:  v1alpha1.ScheduleSpec{ResizeInterval: "1h"},
+		},
+	}}
+	pods := []corev1.Pod{
+		*createTestPod("ingress", "infra", "100m", "128Mi", "200m", "256Mi"),
+		*createTestPod("web", "dev", "100m", "128Mi", "200m", "256Mi"),
+	}
+	ctx := context.Background()
+	start := time.Now()
+
+	if due := r.duePods(ctx, pods, policies, start); len(due) != 2 {
+		t.Fatalf("expected every pod on the first run, got %d", len(due))
+	}
+	due := r.duePods(ctx, pods, policies, start.Add(5*time.Minute))
+	if len(due) != 1 || due[0].Name != "web" {
+		t.Fatalf("expected only the pod on the global interval, got %v", due)
+	}
+	if due := r.duePods(ctx, pods, policies, start.Add(time.Hour)); len(due) != 2 {
+		t.Fatalf("expected both pods once the policy interval passed, got %d", len(due))
+	}
+}
@@ -17,14 +17,19 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"right-sizer/config"
 	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -518,3 +523,339 @@ func createTestPod(name, namespace string, cpuReq, memReq, cpuLim, memLim string
 		},
 	}
 }
+
+// resizeRecorder returns a rightsizer whose resize patches are recorded; the
+// first failFirst combined patches are rejected with err
+func resizeRecorder(pod *corev1.Pod, failFirst int, err error) (*AdaptiveRightSizer, *[][]map[string]interface{}) {
+	var patches [][]map[string]interface{}
+	clientSet := fake.NewSimpleClientset(pod)
+	clientSet.PrependReactor("patch", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(clienttesting.PatchAction)
+		if patchAction.GetSubresource() != "resize" {
+			return true, pod, nil
+		}
+		var ops []map[string]interface{}
+		_ = json.Unmarshal(patchAction.GetPatch(), &ops)
+		patches = append(patches, ops)
+		if len(patches) <= failFirst {
+			return true, nil, err
+		}
+		return true, pod, nil
+	})
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = policyv1.AddToScheme(scheme)
+	return &AdaptiveRightSizer{
+		Client:    ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(pod).Build(),
+		ClientSet: clientSet,
+		Config:    config.GetDefaults(),
+	}, &patches
+}
+
+func groupedUpdate() ResourceUpdate {
+	return ResourceUpdate{
+		Namespace:     "default",
+		Name:          "test-pod",
+		ContainerName: "test-container",
+		NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("150m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("300m"),
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			},
+		},
+	}
+}
+
+// TestGroupedResizeSinglePatch verifies CPU and memory are resized in one patch
+func TestGroupedResizeSinglePatch(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	r, patches := resizeRecorder(pod, 0, nil)
+
+	result, err := r.updatePodInPlace(context.Background(), groupedUpdate())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result, "CPU and memory") {
+		t.Errorf("expected both resources reported, got %q", result)
+	}
+	if len(*patches) != 1 {
+		t.Fatalf("expected a single resize patch, got %d", len(*patches))
+	}
+	for _, op := range (*patches)[0] {
+		value := op["value"].(map[string]interface{})
+		if _, ok := value["cpu"]; !ok {
+			t.Errorf("expected cpu in %s", op["path"])
+		}
+		if _, ok := value["memory"]; !ok {
+			t.Errorf("expected memory in %s", op["path"])
+		}
+	}
+}
+
+// TestGroupedResizeFallback verifies the two-step flow is used when the cluster rejects combined patches
+func TestGroupedResizeFallback(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	r, patches := resizeRecorder(pod, 1, k8serrors.NewMethodNotSupported(corev1.Resource("pods"), "patch"))
+
+	if _, err := r.updatePodInPlace(context.Background(), groupedUpdate()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 3 {
+		t.Fatalf("expected the combined patch followed by CPU and memory patches, got %d", len(*patches))
+	}
+	if !r.groupedResizeUnsupported.Load() {
+		t.Error("expected combined resize to be disabled after the cluster rejected it")
+	}
+
+	// Later resizes go straight to the two-step flow
+	if _, err := r.updatePodInPlace(context.Background(), groupedUpdate()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 5 {
+		t.Fatalf("expected two more patches, got %d", len(*patches))
+	}
+}
+
+// TestGroupedResizePodSpecificRejection verifies a pod-specific rejection does not disable combined resizes
+func TestGroupedResizePodSpecificRejection(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	r, patches := resizeRecorder(pod, 1, k8serrors.NewBadRequest("invalid resize for this pod"))
+
+	if _, err := r.updatePodInPlace(context.Background(), groupedUpdate()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.groupedResizeUnsupported.Load() {
+		t.Error("expected combined resize to stay enabled after a pod-specific rejection")
+	}
+
+	// The next resize is attempted as a single patch again
+	if _, err := r.updatePodInPlace(context.Background(), groupedUpdate()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 4 {
+		t.Fatalf("expected the failed combined patch, the two-step patches and a combined patch, got %d", len(*patches))
+	}
+}
+
+// TestGroupedResizeNotSupported verifies which errors mean the cluster lacks combined resizes
+func TestGroupedResizeNotSupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"method not supported", k8serrors.NewMethodNotSupported(corev1.Resource("pods"), "patch"), true},
+		{"missing subresource", k8serrors.NewNotFound(schema.GroupResource{}, ""), true},
+		{"pod deleted", k8serrors.NewNotFound(corev1.Resource("pods"), "test-pod"), false},
+		{"bad request", k8serrors.NewBadRequest("invalid"), false},
+		{"forbidden", k8serrors.NewForbidden(corev1.Resource("pods"), "test-pod", errors.New("memory decrease")), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupedResizeNotSupported(tt.err); got != tt.want {
+				t.Errorf("groupedResizeNotSupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	r := &AdaptiveRightSizer{}
+	r.groupedResizeUnsupported.Store(true)
+	r.ResetGroupedResize()
+	if r.groupedResizeUnsupported.Load() {
+		t.Error("expected ResetGroupedResize to allow combined resizes again")
+	}
+}
+
+// TestMemoryDecreaseWithRestartPolicy verifies annotated pods get a RestartContainer memory policy and the decrease applied
+func TestMemoryDecreaseWithRestartPolicy(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	update := ResourceUpdate{
+		Namespace:     "default",
+		Name:          "test-pod",
+		ContainerName: "test-container",
+		NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("200m"),
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+		},
+	}
+
+	// Without the annotation the decrease is skipped
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	r, patches := resizeRecorder(pod, 0, nil)
+	if result, err := r.updatePodInPlace(context.Background(), update); err != nil || result != "" {
+		t.Fatalf("expected memory decrease skipped, got %q (%v)", result, err)
+	}
+	if len(*patches) != 0 {
+		t.Fatalf("expected no patches, got %d", len(*patches))
+	}
+
+	pod = createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	pod.Annotations = map[string]string{memoryRestartAnnotation: "true"}
+	r, patches = resizeRecorder(pod, 0, nil)
+	result, err := r.updatePodInPlace(context.Background(), update)
+	if err != nil || !strings.Contains(result, "memory") {
+		t.Fatalf("expected memory resized, got %q (%v)", result, err)
+	}
+	if len(*patches) != 2 {
+		t.Fatalf("expected resize policy and memory patches, got %d", len(*patches))
+	}
+
+	policyOp := (*patches)[0][0]
+	if policyOp["path"] != "/spec/containers/0/resizePolicy" {
+		t.Fatalf("expected resize policy patched first, got %v", policyOp["path"])
+	}
+	policies := policyOp["value"].([]interface{})
+	if policy := policies[0].(map[string]interface{}); policy["resourceName"] != "memory" || policy["restartPolicy"] != "RestartContainer" {
+		t.Errorf("expected memory RestartContainer policy, got %v", policy)
+	}
+	for _, op := range (*patches)[1] {
+		if value := op["value"].(map[string]interface{}); value["memory"] != "64Mi" && value["memory"] != "128Mi" {
+			t.Errorf("expected memory decreased in %s, got %v", op["path"], value)
+		}
+	}
+}
+
+func podDisruptionBudget(name string, allowed int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+	}
+}
+
+// TestDisruptionBudgetSpendsAllowedDisruptions verifies a run charges its
+// restarts against the budget and stops when none are left
+func TestDisruptionBudgetSpendsAllowedDisruptions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = policyv1.AddToScheme(scheme)
+	c := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(podDisruptionBudget("web", 1)).Build()
+	budget := newDisruptionBudget(c)
+
+	web := createTestPod("web-1", "default", "100m", "128Mi", "200m", "256Mi")
+	web.Labels = map[string]string{"app": "web"}
+	other := createTestPod("other", "default", "100m", "128Mi", "200m", "256Mi")
+
+	if allowed, _, err := budget.take(context.Background(), web); err != nil || !allowed {
+		t.Fatalf("expected the first restart allowed, got %v (%v)", allowed, err)
+	}
+	if allowed, pdb, _ := budget.take(context.Background(), web); allowed || pdb != "web" {
+		t.Errorf("expected the second restart refused by web, got %v (%q)", allowed, pdb)
+	}
+	if allowed, _, _ := budget.take(context.Background(), other); !allowed {
+		t.Error("expected a pod no budget selects to be allowed")
+	}
+}
+
+// TestMemoryRestartDeferredByDisruptionBudget verifies an opted-in memory
+// decrease is not applied while the pod's budget allows no disruptions
+func TestMemoryRestartDeferredByDisruptionBudget(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	pod.Labels = map[string]string{"app": "web"}
+	pod.Annotations = map[string]string{memoryRestartAnnotation: "true"}
+	r, patches := resizeRecorder(pod, 0, nil)
+	if err := r.Client.Create(context.Background(), podDisruptionBudget("web", 0)); err != nil {
+		t.Fatalf("failed to create budget: %v", err)
+	}
+
+	update := ResourceUpdate{
+		Namespace:     "default",
+		Name:          "test-pod",
+		ContainerName: "test-container",
+		NewResources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+		},
+	}
+	if result, err := r.updatePodInPlace(context.Background(), update); err != nil || result != "" {
+		t.Fatalf("expected memory decrease deferred, got %q (%v)", result, err)
+	}
+	if len(*patches) != 0 {
+		t.Errorf("expected no patches, got %d", len(*patches))
+	}
+}
+
+// TestResizeUnsupportedEvictsPod verifies a pod that opted in to restarts is
+// evicted when the cluster cannot resize it in place and the webhook sizes
+// its replacement
+func TestResizeUnsupportedEvictsPod(t *testing.T) {
+	config.Get().SetGroupedResize(false)
+	config.Get().SetAdmissionWebhooks(true, true)
+	defer config.Get().SetAdmissionWebhooks(false, false)
+
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	pod.Annotations = map[string]string{memoryRestartAnnotation: "true"}
+	r, _ := resizeRecorder(pod, 1, fmt.Errorf("the server rejected the request: resize is not supported"))
+	evictions := 0
+	r.ClientSet.(*fake.Clientset).PrependReactor("create", "pods", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "eviction" {
+			evictions++
+		}
+		return true, nil, nil
+	})
+
+	update := groupedUpdate()
+	update.NewResources.Requests[corev1.ResourceCPU] = resource.MustParse("100m")
+	update.NewResources.Limits[corev1.ResourceCPU] = resource.MustParse("200m")
+	result, err := r.updatePodInPlace(context.Background(), update)
+	if err != nil || !strings.Contains(result, "Evicted") {
+		t.Fatalf("expected the pod evicted, got %q (%v)", result, err)
+	}
+	if evictions != 1 {
+		t.Errorf("expected one eviction, got %d", evictions)
+	}
+}
+
+// TestResizeKeepsResourceClaims verifies resizing a container that claims
+// devices through DRA leaves its claims and claimed resources alone
+func TestResizeKeepsResourceClaims(t *testing.T) {
+	config.Get().SetGroupedResize(true)
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	resources := &pod.Spec.Containers[0].Resources
+	resources.Requests["example.com/gpu"] = resource.MustParse("1")
+	resources.Limits["example.com/gpu"] = resource.MustParse("1")
+	resources.Claims = []corev1.ResourceClaim{{Name: "gpu"}}
+	pod.Spec.ResourceClaims = []corev1.PodResourceClaim{{Name: "gpu"}}
+	pod.Status.ExtendedResourceClaimStatus = &corev1.PodExtendedResourceClaimStatus{
+		ResourceClaimName: "test-pod-extended-resources",
+		RequestMappings:   []corev1.ContainerExtendedResourceRequest{{ContainerName: "test-container", ResourceName: "example.com/gpu", RequestName: "r0"}},
+	}
+	r, patches := resizeRecorder(pod, 0, nil)
+
+	safe := ensureSafeResourcePatchAdaptive(*resources, groupedUpdate().NewResources)
+	if len(safe.Claims) != 1 || safe.Claims[0].Name != "gpu" {
+		t.Errorf("expected the resource claim preserved, got %+v", safe.Claims)
+	}
+
+	update := groupedUpdate()
+	update.NewResources.Requests["example.com/gpu"] = resource.MustParse("2")
+	if _, err := r.updatePodInPlace(context.Background(), update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*patches) != 1 {
+		t.Fatalf("expected a single resize patch, got %d", len(*patches))
+	}
+	for _, op := range (*patches)[0] {
+		if strings.Contains(op["path"].(string), "claims") {
+			t.Errorf("expected no claims patched, got %s", op["path"])
+		}
+		value := op["value"].(map[string]interface{})
+		if gpu := value["example.com/gpu"]; gpu != "1" {
+			t.Errorf("expected the claimed device count kept in %s, got %v", op["path"], gpu)
+		}
+	}
+}
@@ -95,6 +95,9 @@ type RetryManager struct {
 	// running indicates if the retry manager is active
 	running bool
 
+	// stopped is closed once the retry processing goroutine has returned
+	stopped chan struct{}
+
 	// runMutex protects the running state
 	runMutex sync.Mutex
 }
@@ -147,12 +150,14 @@ func (rm *RetryManager) Start() error {
 	logger.Info("Starting resize retry manager with interval %v", rm.retryInterval)
 
 	// Start the retry processing goroutine
+	rm.stopped = make(chan struct{})
 	go rm.processRetries()
 
 	return nil
 }
 
-// Stop stops the retry manager and clears all deferred resizes
+// Stop stops the retry manager, waits for it to finish the resizes it is
+// processing and clears all deferred resizes
 func (rm *RetryManager) Stop() {
 	rm.runMutex.Lock()
 	defer rm.runMutex.Unlock()
@@ -163,6 +168,7 @@ func (rm *RetryManager) Stop() {
 
 	logger.Info("Stopping resize retry manager")
 	rm.cancel()
+	<-rm.stopped
 	rm.running = false
 
 	// Clear all deferred resizes
@@ -234,6 +240,7 @@ func (rm *RetryManager) AddDeferredResize(pod *corev1.Pod, newResources map[stri
 
 // processRetries continuously processes deferred resizes
 func (rm *RetryManager) processRetries() {
+	defer close(rm.stopped)
 	ticker := time.NewTicker(rm.retryInterval)
 	defer ticker.Stop()
 
@@ -17,8 +17,8 @@ package controllers
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"path"
 	"time"
 
 	"right-sizer/admission"
@@ -29,10 +29,12 @@ import (
 	"right-sizer/logger"
 	"right-sizer/metrics"
 
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -48,6 +50,8 @@ type RightSizerConfigReconciler struct {
 	AuditLogger     *audit.AuditLogger
 	WebhookManager  *admission.WebhookManager
 	HealthChecker   *health.OperatorHealthChecker
+	EventRecorder   record.EventRecorder
+	Ready           *ConfigReady // Marked once the configuration is loaded
 }
 
 // +kubebuilder:rbac:groups=rightsizer.io,resources=rightsizerconfigs,verbs=get;list;watch;create;update;patch;delete
@@ -71,6 +75,7 @@ func (r *RightSizerConfigReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			// Reset to default configuration
 			log.Info("RightSizerConfig resource not found. Resetting to default configuration")
 			r.resetToDefaultConfig()
+			r.Ready.MarkReady()
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -99,9 +104,23 @@ func (r *RightSizerConfigReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		}
 	}
 
-	// Apply configuration from CRD
-	if err := r.applyConfiguration(ctx, rsc); err != nil {
+	// Apply configuration from CRD. Invalid settings are skipped, keeping
+	// their current or default values, and reported on the status.
+	problems := validateConfigSpec(&rsc.Spec)
+	skipped, err := r.applyConfiguration(ctx, rsc)
+	r.Ready.MarkReady()
+	// Components keeping settings of their own, such as the resize loop's
+	// interval and the API server's listener, pick up the new values
+	r.Config.NotifyChanged()
+	problems = append(problems, skipped...)
+	if len(problems) > 0 && rsc.Generation != rsc.Status.ObservedGeneration {
+		r.recordInvalidConfig(rsc, problems)
+	}
+	if err != nil {
 		log.Error("Failed to apply configuration: %v", err)
+		if r.EventRecorder != nil {
+			r.EventRecorder.Event(rsc, corev1.EventTypeWarning, "ConfigurationFailed", err.Error())
+		}
 		return r.updateConfigStatus(ctx, rsc, "Failed", fmt.Sprintf("Error: %v", err))
 	}
 
@@ -130,10 +149,15 @@ func (r *RightSizerConfigReconciler) Reconcile(ctx context.Context, req ctrl.Req
 			return err
 		}
 
+		now := metav1.Now()
 		latestRsc.Status.Phase = "Active"
-		latestRsc.Status.LastAppliedTime = &metav1.Time{Time: time.Now()}
+		latestRsc.Status.LastAppliedTime = &now
+		latestRsc.Status.LastReloadTime = &now
 		latestRsc.Status.ObservedGeneration = latestRsc.Generation
 		latestRsc.Status.Message = "Configuration successfully applied"
+		latestRsc.Status.ValidationErrors = problems
+		latestRsc.Status.EffectiveConfig = effectiveConfigStatus(r.Config)
+		setConfigConditions(latestRsc, "", problems)
 
 		// Update system health status
 		latestRsc.Status.SystemHealth = r.getSystemHealth(ctx)
@@ -159,10 +183,18 @@ func (r *RightSizerConfigReconciler) Reconcile(ctx context.Context, req ctrl.Req
 }
 
 // applyConfiguration applies the configuration from the CRD to the global config
-func (r *RightSizerConfigReconciler) applyConfiguration(ctx context.Context, rsc *v1alpha1.RightSizerConfig) error {
+func (r *RightSizerConfigReconciler) applyConfiguration(ctx context.Context, rsc *v1alpha1.RightSizerConfig) ([]string, error) {
 	log := logger.GetLogger()
 	log.Info("Applying configuration from RightSizerConfig CRD")
 
+	// invalid records a setting skipped as invalid
+	var skipped []string
+	invalid := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		log.Warn("%s", message)
+		skipped = append(skipped, message)
+	}
+
 	// Parse resize interval
 	resizeInterval := 30 * time.Second
 	if rsc.Spec.ResizeInterval != "" {
@@ -436,13 +468,420 @@ func (r *RightSizerConfigReconciler) applyConfiguration(ctx context.Context, rsc
 		"",
 	)
 
-	// Update logger level if changed
-	if rsc.Spec.ObservabilityConfig.LogLevel != "" {
-		logger.Init(rsc.Spec.ObservabilityConfig.LogLevel)
+	// Update percentile algorithm settings
+	var percentileWindow time.Duration
+	if rsc.Spec.DefaultResourceStrategy.HistoryWindow != "" {
+		if window, err := config.ParseHistoryWindow(rsc.Spec.DefaultResourceStrategy.HistoryWindow); err == nil {
+			percentileWindow = window
+		} else {
+			invalid("Invalid history window %q, keeping current value: %v", rsc.Spec.DefaultResourceStrategy.HistoryWindow, err)
+		}
+	}
+	r.Config.UpdatePercentileSettings(int(rsc.Spec.DefaultResourceStrategy.Percentile), percentileWindow)
+	r.Config.SetUsageAggregation(rsc.Spec.DefaultResourceStrategy.CPU.Aggregation, rsc.Spec.DefaultResourceStrategy.Memory.Aggregation)
+	limitWindows := make([]time.Duration, 2)
+	for i, window := range []string{rsc.Spec.DefaultResourceStrategy.CPU.LimitWindow, rsc.Spec.DefaultResourceStrategy.Memory.LimitWindow} {
+		if window == "" {
+			continue
+		}
+		if parsed, err := config.ParseHistoryWindow(window); err == nil {
+			limitWindows[i] = parsed
+		} else {
+			invalid("Invalid limit window %q, deriving the limit from the request: %v", window, err)
+		}
+	}
+	limitHeadroom := config.GetDefaults().LimitHeadroomPercent
+	if rsc.Spec.DefaultResourceStrategy.LimitHeadroomPercent != 0 {
+		limitHeadroom = int(rsc.Spec.DefaultResourceStrategy.LimitHeadroomPercent)
+	}
+	r.Config.SetPeakLimits(rsc.Spec.DefaultResourceStrategy.LimitPercentile, limitHeadroom, limitWindows[0], limitWindows[1])
+	r.Config.SetRecommendationOnly(rsc.Spec.RecommendationOnly)
+	r.Config.SetWorkloadAggregation(rsc.Spec.DefaultResourceStrategy.WorkloadAggregation)
+	r.Config.SetJobMode(rsc.Spec.DefaultResourceStrategy.JobMode)
+	r.Config.SetDaemonSetNodeClassLabel(rsc.Spec.DefaultResourceStrategy.DaemonSetNodeClassLabel)
+	advice := config.GetDefaults().HorizontalAdvice
+	if spec := rsc.Spec.DefaultResourceStrategy.HorizontalAdvice; spec != nil {
+		advice.Enabled = true
+		if spec.MinSavingsPercent > 0 {
+			advice.MinSavingsPercent = int(spec.MinSavingsPercent)
+		}
+		advice.MinReplicas = int(spec.MinReplicas)
+	}
+	r.Config.SetHorizontalAdvice(advice)
+	r.Config.SetAnalysisConcurrency(int(rsc.Spec.OperatorConfig.MaxAnalysisWorkers), int(rsc.Spec.OperatorConfig.MaxPodsPerCycle))
+	r.Config.SetMaxUpdatesPerCycle(int(rsc.Spec.OperatorConfig.MaxUpdatesPerCycle))
+	if rsc.Spec.DefaultResourceStrategy.CPU.ThrottleThreshold != 0 {
+		r.Config.SetCPUThrottleThreshold(rsc.Spec.DefaultResourceStrategy.CPU.ThrottleThreshold)
+	}
+	r.Config.SetPressureThresholds(rsc.Spec.DefaultResourceStrategy.CPU.PressureThreshold, rsc.Spec.DefaultResourceStrategy.Memory.PressureThreshold)
+	if rsc.Spec.GlobalConstraints.CooldownPeriod != "" {
+		if cooldown, err := time.ParseDuration(rsc.Spec.GlobalConstraints.CooldownPeriod); err == nil {
+			r.Config.SetResizeCooldown(cooldown)
+		} else {
+			invalid("Invalid cooldownPeriod %q: %v", rsc.Spec.GlobalConstraints.CooldownPeriod, err)
+		}
+	}
+	if rsc.Spec.GlobalConstraints.MinPodAge != "" {
+		if age, err := time.ParseDuration(rsc.Spec.GlobalConstraints.MinPodAge); err == nil {
+			r.Config.SetMinPodAge(age)
+		} else {
+			invalid("Invalid minPodAge %q: %v", rsc.Spec.GlobalConstraints.MinPodAge, err)
+		}
+	}
+	if rsc.Spec.GlobalConstraints.ScaleDownDelay != "" {
+		if delay, err := time.ParseDuration(rsc.Spec.GlobalConstraints.ScaleDownDelay); err == nil {
+			r.Config.SetScaleDownDelay(delay)
+		} else {
+			invalid("Invalid scaleDownDelay %q: %v", rsc.Spec.GlobalConstraints.ScaleDownDelay, err)
+		}
+	}
+	if rsc.Spec.MetricsConfig.EfficiencyWindow != "" {
+		if window, err := config.ParseHistoryWindow(rsc.Spec.MetricsConfig.EfficiencyWindow); err == nil && window > 0 {
+			r.Config.SetEfficiencyWindow(window)
+		} else {
+			invalid("Invalid efficiencyWindow %q", rsc.Spec.MetricsConfig.EfficiencyWindow)
+		}
+	}
+	if grouped, exists := rsc.Spec.FeatureGates["GroupedResize"]; exists {
+		r.Config.SetGroupedResize(grouped)
+	}
+	r.Config.SetNodeCapacityStrategy(rsc.Spec.GlobalConstraints.NodeCapacityStrategy)
+	vpaMode := rsc.Spec.GlobalConstraints.VPAMode
+	if !rsc.Spec.GlobalConstraints.RespectVPA {
+		vpaMode = config.VPAModeIgnore
+	}
+	r.Config.SetVPAMode(vpaMode)
+	r.Config.SetCustomMetrics(rsc.Spec.MetricsConfig.CustomMetrics)
+	for _, name := range rsc.Spec.GlobalConstraints.DecisionFilters {
+		if _, ok := LookupDecisionFilter(name); !ok {
+			invalid("Unknown decision filter %q, registered filters: %v", name, DecisionFilters())
+		}
+	}
+	r.Config.SetDecisionFilters(rsc.Spec.GlobalConstraints.DecisionFilters)
+	r.Config.SetMaxResizesPerNode(int(rsc.Spec.GlobalConstraints.MaxResizesPerNode))
+	budget := config.GetDefaults().ChangeBudget
+	if spec := rsc.Spec.GlobalConstraints.ChangeBudget; spec != nil {
+		budget.ClusterPercent = int(spec.ClusterPercent)
+		budget.NamespacePercent = int(spec.NamespacePercent)
+		budget.MinPods = int(spec.MinPods)
+		if spec.Window != "" {
+			if window, err := time.ParseDuration(spec.Window); err == nil {
+				budget.Window = window
+			} else {
+				invalid("Invalid changeBudget window %q: %v", spec.Window, err)
+			}
+		}
+	}
+	r.Config.SetChangeBudget(budget)
+	canary := config.GetDefaults().Canary
+	if spec := rsc.Spec.GlobalConstraints.Canary; spec != nil {
+		canary.Enabled = true
+		canary.MinReplicas = int(spec.MinReplicas)
+		canary.MaxRestarts = int(spec.MaxRestarts)
+		if spec.BakeTime != "" {
+			if bake, err := time.ParseDuration(spec.BakeTime); err == nil {
+				canary.BakeTime = bake
+			} else {
+				invalid("Invalid canary bakeTime %q: %v", spec.BakeTime, err)
+			}
+		}
+	}
+	r.Config.SetCanary(canary)
+	self := config.GetDefaults().SelfSizing
+	if spec := rsc.Spec.GlobalConstraints.SelfSizing; spec != nil {
+		self.Enabled = true
+		self.MaxStepPercent = int(spec.MaxChangePercent)
+		if spec.MinCPURequest != "" {
+			if millis, err := config.ParseCPUQuantity(spec.MinCPURequest); err == nil {
+				self.MinCPURequest = millis
+			} else {
+				invalid("Invalid selfSizing minCPURequest %q: %v", spec.MinCPURequest, err)
+			}
+		}
+		if spec.MinMemoryRequest != "" {
+			if mb, err := config.ParseMemoryQuantity(spec.MinMemoryRequest); err == nil {
+				self.MinMemoryRequest = mb
+			} else {
+				invalid("Invalid selfSizing minMemoryRequest %q: %v", spec.MinMemoryRequest, err)
+			}
+		}
+	}
+	r.Config.SetSelfSizing(self)
+	r.Config.SetMaxStepPercent(int(rsc.Spec.GlobalConstraints.MaxChangePercentage))
+	export := config.ExportConfig{
+		Enabled:            rsc.Spec.ExportConfig.Enabled,
+		Format:             rsc.Spec.ExportConfig.Format,
+		Target:             rsc.Spec.ExportConfig.Target,
+		ConfigMapName:      rsc.Spec.ExportConfig.ConfigMapName,
+		ConfigMapNamespace: rsc.Spec.ExportConfig.ConfigMapNamespace,
+
+		VerticalPodAutoscalers: rsc.Spec.ExportConfig.VerticalPodAutoscalers,
+	}
+	if git := rsc.Spec.ExportConfig.Git; git != nil {
+		export.GitRepository = git.Repository
+		export.GitBranch = git.Branch
+		export.GitPath = git.Path
+		export.GitAuthorName = git.AuthorName
+		export.GitAuthorEmail = git.AuthorEmail
+		if git.AuthSecretRef != nil {
+			export.GitAuthSecretName = git.AuthSecretRef.Name
+			export.GitAuthSecretKey = git.AuthSecretRef.Key
+		}
+	}
+	if export.Target == ExportTargetGit {
+		if err := gitExportAvailable(); err != nil {
+			invalid("Export target %q is unavailable, using %q instead: %v", ExportTargetGit, ExportTargetConfigMap, err)
+			export.Target = ExportTargetConfigMap
+		}
+	}
+	r.Config.SetExportConfig(export)
+	costConfig := config.CostConfig{
+		Provider: rsc.Spec.CostConfig.Provider,
+		Endpoint: rsc.Spec.CostConfig.Endpoint,
+		Window:   rsc.Spec.CostConfig.Window,
 	}
+	if rsc.Spec.CostConfig.RefreshInterval != "" {
+		if interval, err := time.ParseDuration(rsc.Spec.CostConfig.RefreshInterval); err == nil {
+			costConfig.RefreshInterval = interval
+		} else {
+			invalid("Invalid cost refreshInterval %q: %v", rsc.Spec.CostConfig.RefreshInterval, err)
+		}
+	}
+	r.Config.SetCostConfig(costConfig)
+	r.Config.SetAutoscalerConfig(config.AutoscalerConfig{
+		AnnotateNodes:          rsc.Spec.AutoscalerConfig.AnnotateNodes,
+		SkipConsolidatingNodes: rsc.Spec.AutoscalerConfig.SkipConsolidatingNodes,
+	})
+	breaker := config.CircuitBreakerConfig{
+		Enabled:          rsc.Spec.OperatorConfig.EnableCircuitBreaker,
+		Scope:            rsc.Spec.OperatorConfig.CircuitBreakerScope,
+		FailureThreshold: int(rsc.Spec.OperatorConfig.CircuitBreakerThreshold),
+		SuccessThreshold: int(rsc.Spec.OperatorConfig.CircuitBreakerSuccessThreshold),
+	}
+	if timeout := rsc.Spec.OperatorConfig.CircuitBreakerRecoveryTimeout; timeout != "" {
+		if duration, err := time.ParseDuration(timeout); err == nil {
+			breaker.RecoveryTimeout = duration
+		} else {
+			invalid("Invalid circuitBreakerRecoveryTimeout %q: %v", timeout, err)
+		}
+	}
+	r.Config.SetCircuitBreakerConfig(breaker)
+	pacing := config.ApplyPacingConfig{Adaptive: rsc.Spec.OperatorConfig.AdaptiveApplyPacing}
+	for _, d := range []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"applyLatencyTarget", rsc.Spec.OperatorConfig.ApplyLatencyTarget, &pacing.LatencyTarget},
+		{"minApplyDelay", rsc.Spec.OperatorConfig.MinApplyDelay, &pacing.MinDelay},
+		{"maxApplyDelay", rsc.Spec.OperatorConfig.MaxApplyDelay, &pacing.MaxDelay},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(d.value); err == nil {
+			*d.target = duration
+		} else {
+			invalid("Invalid %s %q: %v", d.name, d.value, err)
+		}
+	}
+	r.Config.SetApplyPacing(pacing)
+	auditSinks := config.AuditSinkConfig{}
+	if s3 := rsc.Spec.ObservabilityConfig.AuditSinks.S3; s3 != nil {
+		auditSinks.S3Bucket = s3.Bucket
+		auditSinks.S3Endpoint = s3.Endpoint
+		auditSinks.S3Region = s3.Region
+		auditSinks.S3Prefix = s3.Prefix
+		auditSinks.S3PathStyle = s3.PathStyle
+		auditSinks.S3CredentialsSecret = s3.CredentialsSecret
+		if s3.RotationInterval != "" {
+			if interval, err := time.ParseDuration(s3.RotationInterval); err == nil {
+				auditSinks.S3RotationInterval = interval
+			} else {
+				invalid("Invalid S3 audit sink rotationInterval %q: %v", s3.RotationInterval, err)
+			}
+		}
+	}
+	if es := rsc.Spec.ObservabilityConfig.AuditSinks.Elasticsearch; es != nil {
+		auditSinks.ElasticsearchURL = es.URL
+		auditSinks.ElasticsearchIndex = es.Index
+		auditSinks.ElasticsearchCredentialsSecret = es.CredentialsSecret
+	}
+	if kafka := rsc.Spec.ObservabilityConfig.AuditSinks.Kafka; kafka != nil {
+		auditSinks.KafkaRESTProxyURL = kafka.RESTProxyURL
+		auditSinks.KafkaTopic = kafka.Topic
+		auditSinks.KafkaCredentialsSecret = kafka.CredentialsSecret
+	}
+	r.Config.SetAuditSinks(auditSinks)
+	spec := rsc.Spec.NotificationConfig
+	notifications := config.NotificationConfig{
+		EnableNotifications: spec.EnableNotifications,
+		Level:               spec.NotificationLevel,
+	}
+	if slack := spec.SlackConfig; slack != nil {
+		notifications.SlackWebhookURL = slack.WebhookURL
+		notifications.SlackChannel = slack.Channel
+		notifications.SlackUsername = slack.Username
+		notifications.SlackIconEmoji = slack.IconEmoji
+	}
+	if teams := spec.TeamsConfig; teams != nil {
+		notifications.TeamsWebhookURL = teams.WebhookURL
+	}
+	if pagerDuty := spec.PagerDutyConfig; pagerDuty != nil {
+		notifications.PagerDutyRoutingKeySecret = pagerDuty.RoutingKeySecretRef.Name
+		notifications.PagerDutyRoutingKeySecretKey = pagerDuty.RoutingKeySecretRef.Key
+	}
+	if email := spec.EmailConfig; email != nil {
+		notifications.SMTPHost = email.SMTPServer
+		notifications.SMTPPort = int(email.SMTPPort)
+		notifications.SMTPUseTLS = email.UseTLS
+		notifications.SMTPUsername = email.From
+		notifications.EmailFrom = email.From
+		notifications.EmailRecipients = email.To
+		if email.AuthSecretRef != nil {
+			notifications.SMTPPasswordSecret = email.AuthSecretRef.Name
+			notifications.SMTPPasswordSecretKey = email.AuthSecretRef.Key
+		}
+	}
+	for _, webhook := range spec.WebhookConfigs {
+		notificationWebhook := config.NotificationWebhook{
+			Name:       webhook.Name,
+			URL:        webhook.URL,
+			Method:     webhook.Method,
+			Headers:    webhook.Headers,
+			RetryCount: int(webhook.RetryCount),
+		}
+		if webhook.Timeout != "" {
+			if timeout, err := time.ParseDuration(webhook.Timeout); err == nil {
+				notificationWebhook.Timeout = timeout
+			} else {
+				invalid("Invalid timeout %q of notification webhook %s: %v", webhook.Timeout, webhook.Name, err)
+			}
+		}
+		notifications.Webhooks = append(notifications.Webhooks, notificationWebhook)
+	}
+	r.Config.SetNotificationConfig(notifications)
+	reporting := config.ReportingConfig{
+		Periods: rsc.Spec.Reporting.Periods,
+		Formats: rsc.Spec.Reporting.Formats,
+		Notify:  rsc.Spec.Reporting.Notify,
+	}
+	if s3 := rsc.Spec.Reporting.S3; s3 != nil {
+		reporting.S3Bucket = s3.Bucket
+		reporting.S3Endpoint = s3.Endpoint
+		reporting.S3Region = s3.Region
+		reporting.S3Prefix = s3.Prefix
+		reporting.S3PathStyle = s3.PathStyle
+		reporting.S3CredentialsSecret = s3.CredentialsSecret
+	}
+	r.Config.SetReporting(reporting)
+	r.Config.SetAdmissionWebhooks(rsc.Spec.SecurityConfig.EnableAdmissionController, rsc.Spec.SecurityConfig.EnableMutatingWebhook)
+	r.Config.SetAPIServer(int(rsc.Spec.SecurityConfig.APIPort), rsc.Spec.SecurityConfig.APIAuthMode)
+	var queryStep time.Duration
+	if rsc.Spec.MetricsConfig.QueryStep != "" {
+		if step, err := time.ParseDuration(rsc.Spec.MetricsConfig.QueryStep); err == nil {
+			queryStep = step
+		} else {
+			invalid("Invalid queryStep %q: %v", rsc.Spec.MetricsConfig.QueryStep, err)
+		}
+	}
+	r.Config.SetPrometheusQuerySettings(queryStep, rsc.Spec.MetricsConfig.CustomQueries)
+	if metric := rsc.Spec.MetricsConfig.MemoryMetric; metric == "" || metrics.ValidMemoryMetric(metric) {
+		r.Config.SetMemoryMetric(metric)
+	} else {
+		invalid("Invalid memoryMetric %q, keeping current value", metric)
+	}
+	overrides, invalidOverrides := namespaceOverrides(rsc.Spec.NamespaceOverrides)
+	for _, message := range invalidOverrides {
+		invalid("%s", message)
+	}
+	r.Config.SetNamespaceOverrides(overrides)
+	var exclusions []config.WorkloadExclusion
+	for i, spec := range rsc.Spec.Exclusions {
+		exclusion := config.WorkloadExclusion{OwnerKinds: spec.OwnerKinds}
+		if spec.LabelSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(spec.LabelSelector)
+			if err != nil {
+				invalid("Invalid label selector of exclusions[%d]: %v", i, err)
+				continue
+			}
+			exclusion.LabelSelector = selector.String()
+		}
+		if exclusion.LabelSelector == "" && len(exclusion.OwnerKinds) == 0 {
+			invalid("exclusions[%d] has no label selector or owner kinds and is ignored", i)
+			continue
+		}
+		exclusions = append(exclusions, exclusion)
+	}
+	r.Config.SetExclusions(exclusions)
+	excludedContainers := config.GetDefaults().ExcludedContainers
+	if rsc.Spec.ExcludedContainers != nil {
+		excludedContainers = rsc.Spec.ExcludedContainers
+	}
+	for _, pattern := range excludedContainers {
+		if _, err := path.Match(pattern, ""); err != nil {
+			invalid("Invalid excludedContainers pattern %q: %v", pattern, err)
+		}
+	}
+	r.Config.SetExcludedContainers(excludedContainers)
 
 	log.Info("Configuration applied successfully from CRD")
-	return nil
+	return skipped, nil
+}
+
+// namespaceOverrides converts the namespace overrides of a configuration,
+// returning the quantities that could not be parsed and were skipped
+func namespaceOverrides(specs []v1alpha1.NamespaceOverrideSpec) ([]config.NamespaceOverride, []string) {
+	var overrides []config.NamespaceOverride
+	var skipped []string
+	for _, spec := range specs {
+		override := config.NamespaceOverride{
+			Namespaces: append([]string(nil), spec.Namespaces...),
+			CPU:        resourceOverride(spec.CPU),
+			Memory:     resourceOverride(spec.Memory),
+		}
+		for _, bound := range []struct {
+			name     string
+			quantity string
+			value    *int64
+			cpu      bool
+		}{
+			{"cpu minRequest", spec.CPU.MinRequest, &override.CPU.MinRequest, true},
+			{"cpu maxLimit", spec.CPU.MaxLimit, &override.CPU.MaxLimit, true},
+			{"memory minRequest", spec.Memory.MinRequest, &override.Memory.MinRequest, false},
+			{"memory maxLimit", spec.Memory.MaxLimit, &override.Memory.MaxLimit, false},
+		} {
+			if bound.quantity == "" {
+				continue
+			}
+			parse := config.ParseMemoryQuantity
+			if bound.cpu {
+				parse = config.ParseCPUQuantity
+			}
+			value, err := parse(bound.quantity)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("Invalid %s %q of the override for %v, keeping the global value: %v",
+					bound.name, bound.quantity, spec.Namespaces, err))
+				continue
+			}
+			*bound.value = value
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, skipped
+}
+
+// resourceOverride converts the multipliers, additions and thresholds of a
+// resource override; bounds are parsed by namespaceOverrides
+func resourceOverride(spec v1alpha1.ResourceStrategyOverride) config.ResourceOverride {
+	return config.ResourceOverride{
+		RequestMultiplier:  spec.RequestMultiplier,
+		RequestAddition:    spec.RequestAddition,
+		LimitMultiplier:    spec.LimitMultiplier,
+		LimitAddition:      spec.LimitAddition,
+		ScaleUpThreshold:   spec.ScaleUpThreshold,
+		ScaleDownThreshold: spec.ScaleDownThreshold,
+	}
 }
 
 // updateMetricsProvider updates the metrics provider based on configuration
@@ -459,6 +898,10 @@ func (r *RightSizerConfigReconciler) updateMetricsProvider(ctx context.Context,
 		desiredProvider = "metrics-server"
 	}
 
+	if failover, ok := (*r.MetricsProvider).(*metrics.FailoverProvider); ok {
+		return r.updateFailoverProvider(failover, desiredProvider, rsc)
+	}
+
 	// Check if we need to switch providers
 	currentProviderType := "unknown"
 	if _, ok := (*r.MetricsProvider).(*metrics.MetricsServerProvider); ok {
@@ -467,13 +910,24 @@ func (r *RightSizerConfigReconciler) updateMetricsProvider(ctx context.Context,
 		currentProviderType = "prometheus"
 	}
 
-	if currentProviderType != desiredProvider {
-		log.Info("Switching metrics provider from %s to %s", currentProviderType, desiredProvider)
+	// Prometheus is rebuilt on every change so endpoint, query and auth updates take effect
+	if currentProviderType != desiredProvider || desiredProvider == "prometheus" {
+		if currentProviderType != desiredProvider {
+			log.Info("Switching metrics provider from %s to %s", currentProviderType, desiredProvider)
+		}
 
 		var newProvider metrics.Provider
 		if desiredProvider == "prometheus" && rsc.Spec.MetricsConfig.PrometheusEndpoint != "" {
-			newProvider = metrics.NewPrometheusProvider(rsc.Spec.MetricsConfig.PrometheusEndpoint)
-			log.Info("Switched to Prometheus metrics provider: endpoint=%s", rsc.Spec.MetricsConfig.PrometheusEndpoint)
+			promProvider, err := newPrometheusProvider(r.Config, rsc.Spec.MetricsConfig.PrometheusEndpoint)
+			if err != nil {
+				log.Error("Failed to configure Prometheus provider: %v", err)
+				if r.HealthChecker != nil {
+					r.HealthChecker.UpdateComponentStatus("metrics-provider", false, fmt.Sprintf("Prometheus provider configuration failed: %v", err))
+				}
+				return err
+			}
+			newProvider = promProvider
+			log.Info("Configured Prometheus metrics provider: endpoint=%s", rsc.Spec.MetricsConfig.PrometheusEndpoint)
 			if r.HealthChecker != nil {
 				r.HealthChecker.UpdateComponentStatus("metrics-provider", true, "Prometheus provider initialized")
 			}
@@ -491,6 +945,70 @@ func (r *RightSizerConfigReconciler) updateMetricsProvider(ctx context.Context,
 	return nil
 }
 
+// updateFailoverProvider sets the primary and fallback providers behind a
+// failover provider, which probes them and reports their health itself
+func (r *RightSizerConfigReconciler) updateFailoverProvider(failover *metrics.FailoverProvider, desiredProvider string, rsc *v1alpha1.RightSizerConfig) error {
+	log := logger.GetLogger()
+	endpoint := rsc.Spec.MetricsConfig.PrometheusEndpoint
+	currentPrimary, currentSecondary := failover.Providers()
+
+	primaryName, primary, err := r.metricsProviderFor(desiredProvider, endpoint, currentPrimary)
+	if err != nil {
+		log.Error("Failed to configure %s metrics provider: %v", desiredProvider, err)
+		return err
+	}
+
+	var secondaryName string
+	var secondary metrics.Provider
+	if fallback := rsc.Spec.MetricsConfig.FallbackProvider; fallback != "" {
+		secondaryName, secondary, err = r.metricsProviderFor(fallback, endpoint, currentSecondary)
+		if err != nil {
+			log.Error("Failed to configure %s fallback metrics provider: %v", fallback, err)
+			return err
+		}
+		if secondaryName == primaryName {
+			secondaryName, secondary = "", nil
+		}
+	}
+
+	failover.SetProviders(primaryName, primary, secondaryName, secondary)
+	return nil
+}
+
+// metricsProviderFor returns the named provider and the name it runs under;
+// prometheus without an endpoint falls back to metrics-server, and an existing
+// metrics-server provider is reused
+func (r *RightSizerConfigReconciler) metricsProviderFor(name, prometheusEndpoint string, existing metrics.Provider) (string, metrics.Provider, error) {
+	if name == "prometheus" && prometheusEndpoint != "" {
+		provider, err := newPrometheusProvider(r.Config, prometheusEndpoint)
+		if err != nil {
+			return "", nil, err
+		}
+		return "prometheus", provider, nil
+	}
+
+	if _, ok := existing.(*metrics.MetricsServerProvider); ok {
+		return "metrics-server", existing, nil
+	}
+	return "metrics-server", metrics.NewMetricsServerProvider(r.Client), nil
+}
+
+// newPrometheusProvider builds a Prometheus provider for the endpoint using the
+// operator's credentials, TLS and query settings
+func newPrometheusProvider(cfg *config.Config, endpoint string) (*metrics.PrometheusProvider, error) {
+	return metrics.NewPrometheusProviderWithOptions(metrics.PrometheusOptions{
+		URL:                endpoint,
+		Username:           cfg.PrometheusUsername,
+		Password:           cfg.PrometheusPassword,
+		BearerToken:        cfg.PrometheusBearerToken,
+		CAFile:             cfg.PrometheusCAFile,
+		InsecureSkipVerify: cfg.PrometheusInsecureSkipVerify,
+		Step:               cfg.PrometheusQueryStep,
+		Queries:            cfg.PrometheusQueries,
+		MemoryMetric:       cfg.MemoryMetric,
+	})
+}
+
 // updateFeatureComponents updates feature components based on configuration
 func (r *RightSizerConfigReconciler) updateFeatureComponents(ctx context.Context, rsc *v1alpha1.RightSizerConfig) error {
 	log := logger.GetLogger()
@@ -624,9 +1142,16 @@ func (r *RightSizerConfigReconciler) resetToDefaultConfig() {
 
 	// Reset metrics provider to default
 	if r.MetricsProvider != nil {
-		*r.MetricsProvider = metrics.NewMetricsServerProvider(r.Client)
+		if failover, ok := (*r.MetricsProvider).(*metrics.FailoverProvider); ok {
+			current, _ := failover.Providers()
+			name, provider, _ := r.metricsProviderFor("metrics-server", "", current)
+			failover.SetProviders(name, provider, "", nil)
+		} else {
+			*r.MetricsProvider = metrics.NewMetricsServerProvider(r.Client)
+		}
 	}
 
+	r.Config.NotifyChanged()
 	log.Info("Configuration reset to defaults")
 }
 
@@ -640,11 +1165,14 @@ func (r *RightSizerConfigReconciler) updateConfigStatus(ctx context.Context, rsc
 			return err
 		}
 
+		now := metav1.Now()
 		latestRsc.Status.Phase = phase
 		latestRsc.Status.Message = message
 		latestRsc.Status.ObservedGeneration = latestRsc.Generation
+		latestRsc.Status.LastReloadTime = &now
 
 		if phase == "Failed" {
+			setConfigConditions(latestRsc, message, latestRsc.Status.ValidationErrors)
 			// Add to errors in system health
 			if latestRsc.Status.SystemHealth == nil {
 				latestRsc.Status.SystemHealth = &v1alpha1.SystemHealthStatus{}
@@ -670,6 +1198,11 @@ func (r *RightSizerConfigReconciler) updateConfigStatus(ctx context.Context, rsc
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *RightSizerConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Ready != nil {
+		if err := mgr.Add(&configReadyRunnable{reconciler: r, mgr: mgr}); err != nil {
+			return err
+		}
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.RightSizerConfig{}).
 		WithOptions(controller.Options{
@@ -677,57 +1210,3 @@ func (r *RightSizerConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		}).
 		Complete(r)
 }
-
-// parseResourceQuantity parses Kubernetes resource quantity strings to int64 values
-func parseResourceQuantity(quantity string, resourceType string) (int64, error) {
-	if quantity == "" {
-		return 0, errors.New("empty quantity string")
-	}
-
-	// Simple parsing for common cases
-	// For CPU: "10m" -> 10 (millicores), "1" -> 1000 (millicores)
-	// For Memory: "64Mi" -> 64 (MiB), "1Gi" -> 1024 (MiB)
-
-	if resourceType == "cpu" {
-		if quantity[len(quantity)-1:] == "m" {
-			// Parse millicores (e.g., "10m" -> 10)
-			return parseIntFromString(quantity[:len(quantity)-1])
-		}
-		// Assume whole cores, convert to millicores (e.g., "2" -> 2000)
-		if val, err := parseIntFromString(quantity); err == nil {
-			return val * 1000, nil
-		}
-	}
-
-	if resourceType == "memory" {
-		if len(quantity) >= 2 {
-			suffix := quantity[len(quantity)-2:]
-			if suffix == "Mi" {
-				// Parse MiB (e.g., "64Mi" -> 64)
-				return parseIntFromString(quantity[:len(quantity)-2])
-			}
-			if suffix == "Gi" {
-				// Parse GiB, convert to MiB (e.g., "1Gi" -> 1024)
-				if val, err := parseIntFromString(quantity[:len(quantity)-2]); err == nil {
-					return val * 1024, nil
-				}
-			}
-		}
-		// Assume MiB if no suffix
-		return parseIntFromString(quantity)
-	}
-
-	return 0, fmt.Errorf("unknown resource type or format: %s", quantity)
-}
-
-// parseIntFromString is a simple integer parser
-func parseIntFromString(s string) (int64, error) {
-	var result int64
-	for _, ch := range s {
-		if ch < '0' || ch > '9' {
-			return 0, fmt.Errorf("invalid integer: %s", s)
-		}
-		result = result*10 + int64(ch-'0')
-	}
-	return result, nil
-}
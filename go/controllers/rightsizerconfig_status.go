@@ -0,0 +1,179 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types set on RightSizerConfig status
+const (
+	ConditionConfigReady    = "Ready"
+	ConditionConfigDegraded = "Degraded"
+)
+
+// validateConfigSpec returns the settings of a configuration that cannot be
+// applied as written. applyConfiguration reports the invalid values it skips
+// while applying; these are the checks that span several settings.
+func validateConfigSpec(spec *v1alpha1.RightSizerConfigSpec) []string {
+	var problems []string
+	for name, value := range map[string]string{
+		"resizeInterval":               spec.ResizeInterval,
+		"operatorConfig.retryInterval": spec.OperatorConfig.RetryInterval,
+	} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(value); err != nil {
+			problems = append(problems, fmt.Sprintf("Invalid %s %q: %v", name, value, err))
+		}
+	}
+
+	strategy := spec.DefaultResourceStrategy
+	problems = append(problems, checkBounds("cpu", strategy.CPU.MinRequest, strategy.CPU.MaxLimit)...)
+	problems = append(problems, checkBounds("memory", strategy.Memory.MinRequest, strategy.Memory.MaxLimit)...)
+	if up, down := strategy.CPU.ScaleUpThreshold, strategy.CPU.ScaleDownThreshold; up != 0 && down != 0 && down >= up {
+		problems = append(problems, fmt.Sprintf("CPU scaleDownThreshold %.2f must be below scaleUpThreshold %.2f", down, up))
+	}
+	if up, down := strategy.Memory.ScaleUpThreshold, strategy.Memory.ScaleDownThreshold; up != 0 && down != 0 && down >= up {
+		problems = append(problems, fmt.Sprintf("Memory scaleDownThreshold %.2f must be below scaleUpThreshold %.2f", down, up))
+	}
+	for i, override := range spec.NamespaceOverrides {
+		if len(override.Namespaces) == 0 {
+			problems = append(problems, fmt.Sprintf("namespaceOverrides[%d] lists no namespaces", i))
+			continue
+		}
+		name := fmt.Sprintf("override for %v", override.Namespaces)
+		if up, down := override.CPU.ScaleUpThreshold, override.CPU.ScaleDownThreshold; up != 0 && down != 0 && down >= up {
+			problems = append(problems, fmt.Sprintf("CPU scaleDownThreshold %.2f of the %s must be below scaleUpThreshold %.2f", down, name, up))
+		}
+		if up, down := override.Memory.ScaleUpThreshold, override.Memory.ScaleDownThreshold; up != 0 && down != 0 && down >= up {
+			problems = append(problems, fmt.Sprintf("Memory scaleDownThreshold %.2f of the %s must be below scaleUpThreshold %.2f", down, name, up))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// checkBounds validates a resource's minimum request and maximum limit
+func checkBounds(name, minRequest, maxLimit string) []string {
+	var problems []string
+	parse := func(field, value string) *resource.Quantity {
+		q, err := resource.ParseQuantity(value)
+		if err == nil && name == "memory" {
+			// Also catches unitless values meant as MB
+			_, err = config.ParseMemoryQuantity(value)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Invalid %s %s %q, keeping the current value: %v", name, field, value, err))
+			return nil
+		}
+		return &q
+	}
+	var minQuantity, maxQuantity *resource.Quantity
+	if minRequest != "" {
+		minQuantity = parse("minRequest", minRequest)
+	}
+	if maxLimit != "" {
+		maxQuantity = parse("maxLimit", maxLimit)
+	}
+	if minQuantity != nil && maxQuantity != nil && minQuantity.Cmp(*maxQuantity) > 0 {
+		problems = append(problems, fmt.Sprintf("The %s minRequest %s exceeds its maxLimit %s", name, minRequest, maxLimit))
+	}
+	return problems
+}
+
+// effectiveConfigStatus summarizes the configuration the operator runs with
+func effectiveConfigStatus(cfg *config.Config) *v1alpha1.EffectiveConfigStatus {
+	cfg = cfg.Clone()
+	return &v1alpha1.EffectiveConfigStatus{
+		ResizeInterval:          cfg.ResizeInterval.String(),
+		DryRun:                  cfg.DryRun,
+		RecommendationOnly:      cfg.RecommendationOnly,
+		Algorithm:               cfg.Algorithm,
+		Percentile:              int32(cfg.Percentile),
+		PercentileWindow:        cfg.PercentileWindow.String(),
+		CPURequestMultiplier:    cfg.CPURequestMultiplier,
+		MemoryRequestMultiplier: cfg.MemoryRequestMultiplier,
+		CPULimitMultiplier:      cfg.CPULimitMultiplier,
+		MemoryLimitMultiplier:   cfg.MemoryLimitMultiplier,
+		MinCPURequest:           fmt.Sprintf("%dm", cfg.MinCPURequest),
+		MaxCPULimit:             fmt.Sprintf("%dm", cfg.MaxCPULimit),
+		MinMemoryRequest:        fmt.Sprintf("%dMi", cfg.MinMemoryRequest),
+		MaxMemoryLimit:          fmt.Sprintf("%dMi", cfg.MaxMemoryLimit),
+		CooldownPeriod:          cfg.ResizeCooldown.String(),
+		MinPodAge:               cfg.MinPodAge.String(),
+		ScaleDownDelay:          cfg.ScaleDownDelay.String(),
+		MaxResizesPerNode:       int32(cfg.MaxResizesPerNode),
+		NodeCapacityStrategy:    cfg.NodeCapacityStrategy,
+		WorkloadAggregation:     cfg.WorkloadAggregation,
+		MetricsProvider:         cfg.MetricsProvider,
+		IncludeNamespaces:       cfg.NamespaceInclude,
+		ExcludeNamespaces:       cfg.NamespaceExclude,
+	}
+}
+
+// setConfigConditions records whether the configuration was applied, and
+// whether some of its settings were ignored as invalid
+func setConfigConditions(rsc *v1alpha1.RightSizerConfig, applyErr string, problems []string) {
+	ready := metav1.Condition{
+		Type:               ConditionConfigReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: rsc.Generation,
+		Reason:             "Applied",
+		Message:            "Configuration applied",
+	}
+	if applyErr != "" {
+		ready.Status = metav1.ConditionFalse
+		ready.Reason = "ApplyFailed"
+		ready.Message = applyErr
+	}
+	meta.SetStatusCondition(&rsc.Status.Conditions, ready)
+
+	degraded := metav1.Condition{
+		Type:               ConditionConfigDegraded,
+		Status:             conditionStatus(len(problems) > 0),
+		ObservedGeneration: rsc.Generation,
+		Reason:             "Valid",
+		Message:            "All settings are valid",
+	}
+	if len(problems) > 0 {
+		degraded.Reason = "InvalidSettings"
+		degraded.Message = fmt.Sprintf("%d setting(s) ignored: %s", len(problems), strings.Join(problems, "; "))
+	}
+	meta.SetStatusCondition(&rsc.Status.Conditions, degraded)
+}
+
+// recordInvalidConfig emits a warning event for each invalid setting
+func (r *RightSizerConfigReconciler) recordInvalidConfig(rsc *v1alpha1.RightSizerConfig, problems []string) {
+	if r.EventRecorder == nil {
+		return
+	}
+	for _, problem := range problems {
+		r.EventRecorder.Event(rsc, corev1.EventTypeWarning, "InvalidConfiguration", problem)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateConfigSpec(t *testing.T) {
+	spec := &v1alpha1.RightSizerConfigSpec{ResizeInterval: "often"}
+	spec.DefaultResourceStrategy.CPU.MinRequest = "2"
+	spec.DefaultResourceStrategy.CPU.MaxLimit = "1000m"
+	spec.DefaultResourceStrategy.Memory.MinRequest = "lots"
+	spec.DefaultResourceStrategy.Memory.MaxLimit = "8192"
+	spec.DefaultResourceStrategy.Memory.ScaleUpThreshold = 0.5
+	spec.DefaultResourceStrategy.Memory.ScaleDownThreshold = 0.6
+
+	problems := validateConfigSpec(spec)
+	for _, want := range []string{"resizeInterval", "cpu minRequest 2 exceeds", "memory minRequest", "memory maxLimit \"8192\"", "Memory scaleDownThreshold"} {
+		found := false
+		for _, problem := range problems {
+			found = found || strings.Contains(problem, want)
+		}
+		if !found {
+			t.Errorf("expected a problem mentioning %q, got %v", want, problems)
+		}
+	}
+	if got := validateConfigSpec(&v1alpha1.RightSizerConfigSpec{ResizeInterval: "30s"}); len(got) != 0 {
+		t.Errorf("expected a valid spec to have no problems, got %v", got)
+	}
+}
+
+func TestNamespaceOverrides(t *testing.T) {
+	specs := []v1alpha1.NamespaceOverrideSpec{{
+		Namespaces: []string{"batch"},
+		CPU:        v1alpha1.ResourceStrategyOverride{RequestMultiplier: 1.1, MinRequest: "50m", MaxLimit: "2"},
+		Memory:     v1alpha1.ResourceStrategyOverride{MaxLimit: "16Gi", MinRequest: "lots"},
+	}}
+
+	overrides, skipped := namespaceOverrides(specs)
+	if len(overrides) != 1 {
+		t.Fatalf("expected one override, got %d", len(overrides))
+	}
+	override := overrides[0]
+	if override.CPU.RequestMultiplier != 1.1 || override.CPU.MinRequest != 50 || override.CPU.MaxLimit != 2000 {
+		t.Errorf("expected CPU settings in millicores, got %+v", override.CPU)
+	}
+	if override.Memory.MaxLimit != 16384 || override.Memory.MinRequest != 0 {
+		t.Errorf("expected memory settings in MB with the invalid minimum skipped, got %+v", override.Memory)
+	}
+	if len(skipped) != 1 || !strings.Contains(skipped[0], "memory minRequest") {
+		t.Errorf("expected the invalid memory minRequest to be reported, got %v", skipped)
+	}
+
+	spec := &v1alpha1.RightSizerConfigSpec{NamespaceOverrides: []v1alpha1.NamespaceOverrideSpec{
+		{},
+		{Namespaces: []string{"web"}, CPU: v1alpha1.ResourceStrategyOverride{ScaleUpThreshold: 0.5, ScaleDownThreshold: 0.7}},
+	}}
+	problems := validateConfigSpec(spec)
+	if len(problems) != 2 {
+		t.Errorf("expected an empty override and inverted thresholds to be reported, got %v", problems)
+	}
+}
+
+func TestReconcileReportsEffectiveConfig(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	rsc := &v1alpha1.RightSizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "default", Generation: 2}}
+	rsc.Spec.ResizeInterval = "1m"
+	rsc.Spec.GlobalConstraints.CooldownPeriod = "soon"
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(rsc).
+		WithStatusSubresource(&v1alpha1.RightSizerConfig{}).
+		Build()
+	recorder := record.NewFakeRecorder(10)
+	r := &RightSizerConfigReconciler{Client: fakeClient, Scheme: scheme, Config: config.GetDefaults(), EventRecorder: recorder}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "default"}}); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	got := &v1alpha1.RightSizerConfig{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "default"}, got); err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if got.Status.EffectiveConfig == nil || got.Status.EffectiveConfig.ResizeInterval != "1m0s" {
+		t.Errorf("expected the effective resize interval 1m0s, got %+v", got.Status.EffectiveConfig)
+	}
+	if got.Status.LastReloadTime == nil {
+		t.Error("expected the reload time to be set")
+	}
+	if len(got.Status.ValidationErrors) != 1 || !strings.Contains(got.Status.ValidationErrors[0], "cooldownPeriod") {
+		t.Errorf("expected the invalid cooldownPeriod to be reported, got %v", got.Status.ValidationErrors)
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionConfigReady) {
+		t.Error("expected the Ready condition to be true")
+	}
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionConfigDegraded) {
+		t.Error("expected the Degraded condition to be true")
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "InvalidConfiguration") {
+			t.Errorf("expected an InvalidConfiguration event, got %s", event)
+		}
+	default:
+		t.Error("expected an event for the invalid setting")
+	}
+}
+
+func TestReconcileRejectsUnavailableGitExport(t *testing.T) {
+	previous := gitExportAvailable
+	gitExportAvailable = func() error { return errors.New("git not found") }
+	defer func() { gitExportAvailable = previous }()
+
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	rsc := &v1alpha1.RightSizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	rsc.Spec.ExportConfig.Enabled = true
+	rsc.Spec.ExportConfig.Target = ExportTargetGit
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(rsc).
+		WithStatusSubresource(&v1alpha1.RightSizerConfig{}).
+		Build()
+	cfg := config.GetDefaults()
+	r := &RightSizerConfigReconciler{Client: fakeClient, Scheme: scheme, Config: cfg, EventRecorder: record.NewFakeRecorder(10)}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "default"}}); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	if target := cfg.Clone().Export.Target; target != ExportTargetConfigMap {
+		t.Errorf("expected the configmap target instead of git, got %q", target)
+	}
+	got := &v1alpha1.RightSizerConfig{}
+	if err := fakeClient.Get(context.Background(), types.NamespacedName{Name: "default"}, got); err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if len(got.Status.ValidationErrors) != 1 || !strings.Contains(got.Status.ValidationErrors[0], "git not found") {
+		t.Errorf("expected the unavailable git target to be reported, got %v", got.Status.ValidationErrors)
+	}
+}
+
+func TestUpdateFailoverProvider(t *testing.T) {
+	metricsServer := &metrics.MetricsServerProvider{}
+	failover := metrics.NewFailoverProvider("metrics-server", metricsServer)
+	var provider metrics.Provider = failover
+	r := &RightSizerConfigReconciler{Config: config.GetDefaults(), MetricsProvider: &provider}
+
+	rsc := &v1alpha1.RightSizerConfig{}
+	rsc.Spec.MetricsConfig.FallbackProvider = "prometheus"
+	rsc.Spec.MetricsConfig.PrometheusEndpoint = "http://prometheus:9090"
+	if err := r.updateMetricsProvider(context.Background(), rsc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	primary, secondary := failover.Providers()
+	if primary != metricsServer {
+		t.Error("expected the metrics-server provider to be reused")
+	}
+	if _, ok := secondary.(*metrics.PrometheusProvider); !ok {
+		t.Errorf("expected a Prometheus fallback, got %T", secondary)
+	}
+	if provider != failover {
+		t.Error("expected the failover provider to stay in place")
+	}
+
+	// A fallback that is the same as the primary is dropped
+	rsc.Spec.MetricsConfig.FallbackProvider = "metrics-server"
+	if err := r.updateMetricsProvider(context.Background(), rsc); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, secondary := failover.Providers(); secondary != nil {
+		t.Errorf("expected no fallback, got %T", secondary)
+	}
+}
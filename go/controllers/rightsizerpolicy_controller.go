@@ -18,12 +18,15 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"right-sizer/api/v1alpha1"
 	"right-sizer/config"
 	"right-sizer/logger"
 	"right-sizer/metrics"
+	"right-sizer/predictor"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -48,6 +51,7 @@ type RightSizerPolicyReconciler struct {
 	Scheme          *runtime.Scheme
 	MetricsProvider metrics.Provider
 	Config          *config.Config
+	Predictor       *predictor.Engine // Optional history source for percentile-based sizing
 }
 
 // +kubebuilder:rbac:groups=rightsizer.io,resources=rightsizerpolicies,verbs=get;list;watch;create;update;patch;delete
@@ -101,6 +105,15 @@ func (r *RightSizerPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Req
 		return r.updatePolicyStatus(ctx, policy, "Skipped", "Policy namespace not included in global configuration")
 	}
 
+	// Only resize inside the policy's maintenance windows
+	open, err := scheduleAllowsResize(policy.Spec.Schedule, time.Now())
+	if err != nil {
+		return r.updatePolicyStatus(ctx, policy, "Failed", fmt.Sprintf("Invalid maintenance windows: %v", err))
+	}
+	if !open {
+		return r.updatePolicyStatus(ctx, policy, "Pending", "Outside maintenance window")
+	}
+
 	// Process the policy
 	result, err := r.processPolicyTargets(ctx, policy)
 	if err != nil {
@@ -115,6 +128,7 @@ func (r *RightSizerPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	policy.Status.ResourcesResized = result.resized
 	policy.Status.ObservedGeneration = policy.Generation
 	policy.Status.Message = fmt.Sprintf("Successfully processed %d resources, resized %d", result.affected, result.resized)
+	setPolicyConditions(policy, result.matched, result.conflicts)
 
 	// Calculate savings if applicable
 	if result.cpuSaved > 0 || result.memorySaved > 0 {
@@ -140,6 +154,8 @@ type processResult struct {
 	resized     int32
 	cpuSaved    int64
 	memorySaved int64
+	matched     []string // Workloads the policy selects
+	conflicts   []string // Workloads also selected by other policies
 }
 
 // processPolicyTargets processes all resources targeted by the policy
@@ -160,9 +176,67 @@ func (r *RightSizerPolicyReconciler) processPolicyTargets(ctx context.Context, p
 	}
 	result.affected = int32(count)
 
+	// Other enabled policies may select the same workloads
+	var competitors []v1alpha1.RightSizerPolicy
+	var policies v1alpha1.RightSizerPolicyList
+	if err := r.List(ctx, &policies); err != nil {
+		return nil, err
+	}
+	for _, other := range policies.Items {
+		if other.Spec.Enabled && (other.Namespace != policy.Namespace || other.Name != policy.Name) {
+			competitors = append(competitors, other)
+		}
+	}
+	sortPoliciesByPrecedence(competitors)
+
+	// Workloads excluded globally or by this policy are left alone
+	exclusions := exclusionRules(r.Config.Exclusions)
+	for _, exclusion := range policy.Spec.Exclusions {
+		rule, err := parseExclusion(exclusion, fmt.Sprintf("policy %s/%s", policy.Namespace, policy.Name))
+		if err != nil {
+			logger.Warn("Ignoring exclusion of policy %s/%s: %v", policy.Namespace, policy.Name, err)
+			continue
+		}
+		exclusions = append(exclusions, rule)
+	}
+
 	// Process each resource
 	for _, res := range resources {
-		resized, cpuSaved, memorySaved, err := r.processResource(ctx, policy, res)
+		key := workloadKey(res)
+		if rule, excluded := excludedWorkload(res, exclusions); excluded {
+			logger.Debug("Skipping %s matching the %s", key, rule.describe())
+			continue
+		}
+		result.matched = append(result.matched, key)
+
+		selecting := []*v1alpha1.RightSizerPolicy{policy}
+		for i := range competitors {
+			if r.policySelectsObject(&competitors[i], res) {
+				selecting = append(selecting, &competitors[i])
+			}
+		}
+		effective := policy
+		if len(selecting) > 1 {
+			sort.SliceStable(selecting, func(i, j int) bool { return policyPrecedes(selecting[i], selecting[j]) })
+			if winner := selecting[0]; winner != policy {
+				// The workload is sized by the higher-priority policy
+				result.conflicts = append(result.conflicts, fmt.Sprintf("%s overridden by %s/%s (priority %d)",
+					key, winner.Namespace, winner.Name, winner.Spec.Priority))
+				continue
+			}
+			var others []string
+			for _, other := range selecting[1:] {
+				others = append(others, fmt.Sprintf("%s/%s (priority %d)", other.Namespace, other.Name, other.Spec.Priority))
+			}
+			action := "merged"
+			if policy.Spec.MergeStrategy == MergeStrategyOverride {
+				action = "overrides"
+			}
+			result.conflicts = append(result.conflicts, fmt.Sprintf("%s %s with %s", key, action, strings.Join(others, ", ")))
+			effective = mergePolicies(selecting)
+		}
+
+		resized, cpuSaved, memorySaved, err := r.processResource(ctx, effective, res)
 		if err != nil {
 			logger.Error("Failed to process resource %s/%s: %v", res.GetNamespace(), res.GetName(), err)
 			continue
@@ -531,10 +605,12 @@ func (r *RightSizerPolicyReconciler) calculateNewResources(ctx context.Context,
 	// Aggregate metrics from all pods
 	var totalCPU, totalMem float64
 	validPods := 0
+	podNames := make([]string, 0, len(podList.Items))
 	for _, pod := range podList.Items {
 		if pod.Status.Phase != corev1.PodRunning {
 			continue
 		}
+		podNames = append(podNames, pod.Name)
 
 		usage, err := r.MetricsProvider.FetchPodMetrics(ctx, pod.Namespace, pod.Name)
 		if err != nil {
@@ -556,9 +632,22 @@ func (r *RightSizerPolicyReconciler) calculateNewResources(ctx context.Context,
 		MemMB:    totalMem / float64(validPods),
 	}
 
+	// The pod template's annotation selects the sizing profile before the policy does
+	profileName := podTemplate.Annotations[profileAnnotation]
+	if profileName == "" {
+		profileName = policy.Spec.Profile
+	}
+	profile := profileByName(profileName)
+
 	// Calculate new resources for each container
 	for _, container := range podTemplate.Spec.Containers {
-		newReqs := r.calculateOptimalResourcesFromPolicy(policy, avgUsage)
+		usage := r.percentileUsageFromPolicy(policy, obj.GetNamespace(), podNames, container.Name, avgUsage)
+		usage = profile.Usage(usage, func(percentile int) metrics.Metrics {
+			withPercentile := policy.DeepCopy()
+			withPercentile.Spec.ResourceStrategy.Percentile = int32(percentile)
+			return r.percentileUsageFromPolicy(withPercentile, obj.GetNamespace(), podNames, container.Name, avgUsage)
+		})
+		newReqs := profile.Resources(r.calculateOptimalResourcesFromPolicy(policy, usage), usage, r.Config)
 		newResources[container.Name] = newReqs
 
 		// Calculate savings
@@ -576,6 +665,71 @@ func (r *RightSizerPolicyReconciler) calculateNewResources(ctx context.Context,
 	return newResources, totalCPUSaved, totalMemorySaved, nil
 }
 
+// percentileUsageFromPolicy returns the container's usage across all running
+// replicas over the policy's history window, aggregated per resource as the
+// policy or the global configuration selects: the policy's percentile, or e.g.
+// the peak for memory. The current average is returned when a resource has no
+// aggregation or not enough history.
+func (r *RightSizerPolicyReconciler) percentileUsageFromPolicy(policy *v1alpha1.RightSizerPolicy, namespace string, podNames []string, containerName string, current metrics.Metrics) metrics.Metrics {
+	strategy := policy.Spec.ResourceStrategy
+	if r.Predictor == nil || len(podNames) == 0 {
+		return current
+	}
+
+	window := r.Config.PercentileWindow
+	if strategy.HistoryWindow != "" {
+		parsed, err := config.ParseHistoryWindow(strategy.HistoryWindow)
+		if err != nil {
+			logger.Warn("Policy %s has invalid history window %q: %v", policy.Name, strategy.HistoryWindow, err)
+		} else {
+			window = parsed
+		}
+	}
+
+	usage := current
+	if cpu, ok := r.aggregateAcrossPods(namespace, podNames, containerName, "cpu", r.policyAggregation(strategy, "cpu", strategy.CPU.Aggregation), int(strategy.Percentile), window); ok {
+		usage.CPUMilli = cpu
+	}
+	if mem, ok := r.aggregateAcrossPods(namespace, podNames, containerName, "memory", r.policyAggregation(strategy, "memory", strategy.Memory.Aggregation), int(strategy.Percentile), window); ok {
+		usage.MemMB = mem
+	}
+
+	return usage
+}
+
+// policyAggregation returns how a resource's usage history is aggregated for
+// a policy: its explicit aggregation, its percentile, else the global average
+// or peak. The global percentile only applies to single pods.
+func (r *RightSizerPolicyReconciler) policyAggregation(strategy v1alpha1.ResourceStrategy, resource, aggregation string) string {
+	switch {
+	case config.ValidAggregation(aggregation):
+		return aggregation
+	case strategy.Percentile > 0:
+		return config.AggregationPercentile
+	}
+	if method := r.Config.UsageAggregation(resource); method != config.AggregationPercentile {
+		return method
+	}
+	return config.AggregationLatest
+}
+
+// aggregateAcrossPods aggregates the stored usage of the container in all pods.
+// Percentiles need the engine's minimum number of samples.
+func (r *RightSizerPolicyReconciler) aggregateAcrossPods(namespace string, podNames []string, containerName, resourceType, method string, percentile int, window time.Duration) (float64, bool) {
+	switch method {
+	case config.AggregationPercentile:
+		value, _, err := r.Predictor.GetPercentileAcrossPods(namespace, podNames, containerName, resourceType, float64(percentile), window)
+		return value, err == nil
+	case config.AggregationAverage, config.AggregationMax:
+		values, err := r.Predictor.GetValuesAcrossPods(namespace, podNames, containerName, resourceType, window)
+		if err != nil || len(values) == 0 {
+			return 0, false
+		}
+		return aggregateValues(values, method, percentile), true
+	}
+	return 0, false
+}
+
 // calculateOptimalResourcesFromPolicy calculates resources based on policy settings
 func (r *RightSizerPolicyReconciler) calculateOptimalResourcesFromPolicy(policy *v1alpha1.RightSizerPolicy, usage metrics.Metrics) corev1.ResourceRequirements {
 	strategy := policy.Spec.ResourceStrategy
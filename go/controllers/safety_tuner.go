@@ -0,0 +1,340 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SafetyTuner learns extra headroom for workloads whose resizes were rolled
+// back or followed by container restarts. Every incident widens the margin of
+// the workload by a step, a streak of clean resizes narrows it again. The
+// learned margin is kept in the status of the workload's
+// RightSizerRecommendation, so it survives restarts of the operator.
+type SafetyTuner struct {
+	Client client.Client
+
+	mu        sync.Mutex
+	loaded    bool
+	workloads map[string]*safetyRecord // namespace/recommendation name -> track record
+	resized   map[types.UID]resizedPod // pods resized within the restart window
+}
+
+// safetyRecord is the track record of one workload
+type safetyRecord struct {
+	namespace string
+	target    v1alpha1.RecommendationTargetRef
+	status    v1alpha1.SafetyMarginStatus
+	dirty     bool // changed since it was last persisted
+}
+
+// resizedPod remembers the restarts of a pod when it was last resized
+type resizedPod struct {
+	key       string
+	restarts  int32
+	resizedAt time.Time
+}
+
+// NewSafetyTuner creates a safety tuner persisting to recommendations through c
+func NewSafetyTuner(c client.Client) *SafetyTuner {
+	return &SafetyTuner{
+		Client:    c,
+		workloads: make(map[string]*safetyRecord),
+		resized:   make(map[types.UID]resizedPod),
+	}
+}
+
+// Widen adds the learned headroom of each update's workload to its new
+// resources. Widened limits stay within the configured maximums.
+func (t *SafetyTuner) Widen(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod, cfg *config.Config) []ResourceUpdate {
+	if !cfg.SafetyTuning.Enabled || len(updates) == 0 {
+		return updates
+	}
+	t.load(ctx)
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+	for i := range updates {
+		pod, ok := podsByName[updates[i].Namespace+"/"+updates[i].Name]
+		if !ok {
+			continue
+		}
+		key, _ := t.workloadKey(ctx, pod)
+		t.mu.Lock()
+		headroom := int32(0)
+		if record, ok := t.workloads[key]; ok {
+			headroom = record.status.HeadroomPercent
+		}
+		t.mu.Unlock()
+		if headroom <= 0 {
+			continue
+		}
+
+		updates[i].NewResources = widenResources(updates[i].NewResources, headroom, cfg)
+		updates[i].Reason = fmt.Sprintf("%s (+%d%% safety margin from past incidents)", updates[i].Reason, headroom)
+		logger.Debug("Widening resize of %s/%s/%s by %d%% learned from its workload's incidents",
+			updates[i].Namespace, updates[i].Name, updates[i].ContainerName, headroom)
+	}
+	return updates
+}
+
+// RecordResize counts an applied resize of the pod and remembers its
+// restarts, so restarts that follow are blamed on the resize
+func (t *SafetyTuner) RecordResize(ctx context.Context, pod *corev1.Pod, cfg *config.Config) {
+	if !cfg.SafetyTuning.Enabled {
+		return
+	}
+	t.load(ctx)
+	key, target := t.workloadKey(ctx, pod)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record := t.record(key, pod.Namespace, target)
+	record.status.Resizes++
+	record.status.CleanResizes++
+	if record.status.CleanResizes >= int32(cfg.SafetyTuning.DecayAfter) && record.status.HeadroomPercent > 0 {
+		record.status.HeadroomPercent = max(0, record.status.HeadroomPercent-int32(cfg.SafetyTuning.StepPercent))
+		record.status.CleanResizes = 0
+		logger.Info("🛡️  Safety margin of %s narrowed to %d%% after %d clean resizes", key, record.status.HeadroomPercent, cfg.SafetyTuning.DecayAfter)
+	}
+	record.dirty = true
+	t.resized[pod.UID] = resizedPod{key: key, restarts: podRestarts(pod), resizedAt: time.Now()}
+}
+
+// RecordRollback widens the margin of the pod's workload after one of its
+// resizes was rolled back
+func (t *SafetyTuner) RecordRollback(ctx context.Context, pod *corev1.Pod, cfg *config.Config) {
+	if !cfg.SafetyTuning.Enabled {
+		return
+	}
+	t.load(ctx)
+	key, target := t.workloadKey(ctx, pod)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	record := t.record(key, pod.Namespace, target)
+	record.status.Rollbacks++
+	t.widen(record, key, "rollback", cfg)
+}
+
+// ObserveRestarts widens the margin of workloads whose containers restarted
+// within the restart window of a resize
+func (t *SafetyTuner) ObserveRestarts(ctx context.Context, pods []corev1.Pod, cfg *config.Config) {
+	if !cfg.SafetyTuning.Enabled {
+		return
+	}
+	t.load(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.resized) == 0 {
+		return
+	}
+	current := make(map[types.UID]*corev1.Pod, len(pods))
+	for i := range pods {
+		current[pods[i].UID] = &pods[i]
+	}
+	for uid, resized := range t.resized {
+		pod, ok := current[uid]
+		if !ok || time.Since(resized.resizedAt) > cfg.SafetyTuning.RestartWindow {
+			delete(t.resized, uid)
+			continue
+		}
+		restarts := podRestarts(pod)
+		if restarts <= resized.restarts {
+			continue
+		}
+		record := t.record(resized.key, pod.Namespace, v1alpha1.RecommendationTargetRef{})
+		record.status.Restarts += restarts - resized.restarts
+		resized.restarts = restarts
+		t.resized[uid] = resized
+		t.widen(record, resized.key, "restart", cfg)
+	}
+}
+
+// Flush persists the track records changed since the last flush
+func (t *SafetyTuner) Flush(ctx context.Context) {
+	t.mu.Lock()
+	var pending []safetyRecord
+	for _, record := range t.workloads {
+		if record.dirty && record.target.Kind != "" {
+			pending = append(pending, *record)
+			record.dirty = false
+		}
+	}
+	t.mu.Unlock()
+
+	for _, record := range pending {
+		if err := t.persist(ctx, record); err != nil {
+			logger.Warn("Failed to persist safety margin of %s/%s: %v", record.namespace, recommendationName(record.target), err)
+		}
+	}
+}
+
+// widen raises the headroom of a workload by one step; the caller holds the lock
+func (t *SafetyTuner) widen(record *safetyRecord, key, incident string, cfg *config.Config) {
+	previous := record.status.HeadroomPercent
+	record.status.HeadroomPercent = min(previous+int32(cfg.SafetyTuning.StepPercent), int32(cfg.SafetyTuning.MaxPercent))
+	record.status.CleanResizes = 0
+	record.status.LastIncidentTime = &metav1.Time{Time: time.Now()}
+	record.dirty = true
+	if record.status.HeadroomPercent != previous {
+		logger.Info("🛡️  Safety margin of %s widened to %d%% after a %s", key, record.status.HeadroomPercent, incident)
+	}
+}
+
+// record returns the track record of a workload, creating an empty one; the
+// caller holds the lock
+func (t *SafetyTuner) record(key, namespace string, target v1alpha1.RecommendationTargetRef) *safetyRecord {
+	record, ok := t.workloads[key]
+	if !ok {
+		record = &safetyRecord{namespace: namespace, target: target}
+		t.workloads[key] = record
+	}
+	if record.target.Kind == "" {
+		record.target = target
+	}
+	return record
+}
+
+// workloadKey returns the key of the pod's workload and its reference
+func (t *SafetyTuner) workloadKey(ctx context.Context, pod *corev1.Pod) (string, v1alpha1.RecommendationTargetRef) {
+	target := resolveWorkloadRef(ctx, t.Client, pod)
+	return pod.Namespace + "/" + recommendationName(target), target
+}
+
+// load restores the track records kept in recommendations, once
+func (t *SafetyTuner) load(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loaded {
+		return
+	}
+	t.loaded = true
+
+	var recommendations v1alpha1.RightSizerRecommendationList
+	if err := t.Client.List(ctx, &recommendations); err != nil {
+		logger.Debug("Failed to load safety margins from recommendations: %v", err)
+		return
+	}
+	for _, rec := range recommendations.Items {
+		if rec.Status.SafetyMargin == nil {
+			continue
+		}
+		key := rec.Namespace + "/" + recommendationName(rec.Spec.TargetRef)
+		if _, ok := t.workloads[key]; !ok {
+			t.workloads[key] = &safetyRecord{
+				namespace: rec.Namespace,
+				target:    rec.Spec.TargetRef,
+				status:    *rec.Status.SafetyMargin.DeepCopy(),
+			}
+		}
+	}
+}
+
+// persist writes a track record to the status of the workload's recommendation
+func (t *SafetyTuner) persist(ctx context.Context, record safetyRecord) error {
+	key := types.NamespacedName{Namespace: record.namespace, Name: recommendationName(record.target)}
+	if err := t.Client.Get(ctx, key, &v1alpha1.RightSizerRecommendation{}); k8serrors.IsNotFound(err) {
+		rec := newRecommendation(key, record.target)
+		if err := t.Client.Create(ctx, rec); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		latest := &v1alpha1.RightSizerRecommendation{}
+		if err := t.Client.Get(ctx, key, latest); err != nil {
+			return err
+		}
+		latest.Status.SafetyMargin = record.status.DeepCopy()
+		return t.Client.Status().Update(ctx, latest)
+	})
+}
+
+// widenResources scales CPU and memory requests and limits up by percent.
+// Limits are capped at the configured maximums and requests at the limits.
+func widenResources(resources corev1.ResourceRequirements, percent int32, cfg *config.Config) corev1.ResourceRequirements {
+	widened := *resources.DeepCopy()
+	scale := func(list corev1.ResourceList, name corev1.ResourceName, maximum *resource.Quantity) {
+		qty, ok := list[name]
+		if !ok {
+			return
+		}
+		var scaled *resource.Quantity
+		if name == corev1.ResourceCPU {
+			scaled = resource.NewMilliQuantity(qty.MilliValue()*int64(100+percent)/100, qty.Format)
+		} else {
+			scaled = resource.NewQuantity(qty.Value()*int64(100+percent)/100, qty.Format)
+		}
+		if maximum != nil && scaled.Cmp(*maximum) > 0 {
+			scaled = maximum
+		}
+		list[name] = *scaled
+	}
+
+	var maxCPU, maxMemory *resource.Quantity
+	if cfg.MaxCPULimit > 0 {
+		maxCPU = resource.NewMilliQuantity(cfg.MaxCPULimit, resource.DecimalSI)
+	}
+	if cfg.MaxMemoryLimit > 0 {
+		maxMemory = resource.NewQuantity(cfg.MaxMemoryLimit*1024*1024, resource.BinarySI)
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		maximum := maxCPU
+		if name == corev1.ResourceMemory {
+			maximum = maxMemory
+		}
+		if limit, ok := widened.Limits[name]; ok {
+			scale(widened.Limits, name, maximum)
+			limit = widened.Limits[name]
+			maximum = &limit
+		}
+		scale(widened.Requests, name, maximum)
+	}
+	return widened
+}
+
+// podRestarts sums the restarts of all containers of a pod
+func podRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
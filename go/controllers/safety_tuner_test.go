@@ -0,0 +1,115 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSafetyTunerLearnsFromIncidents(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	controller := true
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller},
+			},
+		},
+	}
+	pod := newOwnedPod("web-abc-1", "web-abc")
+	pod.UID = "uid-1"
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app"}}
+	fakeClient := ctrlclientfake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(rs, pod).
+		WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).
+		Build()
+
+	ctx := context.Background()
+	cfg := config.GetDefaults()
+	tuner := NewSafetyTuner(fakeClient)
+
+	// A restart after a resize and a rollback widen the margin by a step each
+	tuner.RecordResize(ctx, pod, cfg)
+	restarted := pod.DeepCopy()
+	restarted.Status.ContainerStatuses[0].RestartCount = 1
+	tuner.ObserveRestarts(ctx, []corev1.Pod{*restarted}, cfg)
+	tuner.RecordRollback(ctx, pod, cfg)
+	tuner.Flush(ctx)
+
+	var rec v1alpha1.RightSizerRecommendation
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "deployment-web"}, &rec); err != nil {
+		t.Fatalf("expected recommendation for deployment web: %v", err)
+	}
+	margin := rec.Status.SafetyMargin
+	if margin == nil || margin.HeadroomPercent != 20 || margin.Restarts != 1 || margin.Rollbacks != 1 || margin.Resizes != 1 {
+		t.Fatalf("expected 20%% headroom after a restart and a rollback, got %+v", margin)
+	}
+
+	// The learned margin is restored from the recommendation and widens new decisions
+	restored := NewSafetyTuner(fakeClient)
+	update := requestUpdate("web-abc-1", "200m", "256Mi")
+	update.NewResources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("400m")}
+	widened := restored.Widen(ctx, []ResourceUpdate{update}, []corev1.Pod{*pod}, cfg)[0].NewResources
+	if widened.Requests.Cpu().MilliValue() != 240 || widened.Limits.Cpu().MilliValue() != 480 {
+		t.Fatalf("expected CPU widened to 240m/480m, got %s/%s", widened.Requests.Cpu(), widened.Limits.Cpu())
+	}
+	if widened.Requests.Memory().Value() != 256*1024*1024*12/10 {
+		t.Fatalf("expected memory widened by 20%%, got %s", widened.Requests.Memory())
+	}
+
+	// A streak of clean resizes narrows it again
+	for i := 0; i < cfg.SafetyTuning.DecayAfter; i++ {
+		restored.RecordResize(ctx, pod, cfg)
+	}
+	if got := restored.workloads["default/deployment-web"].status.HeadroomPercent; got != 10 {
+		t.Fatalf("expected headroom narrowed to 10%%, got %d", got)
+	}
+}
+
+func TestWidenResourcesRespectsMaximums(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.MaxCPULimit = 500
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("450m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("480m")},
+	}
+	widened := widenResources(resources, 50, cfg)
+	if widened.Limits.Cpu().MilliValue() != 500 || widened.Requests.Cpu().MilliValue() != 500 {
+		t.Fatalf("expected CPU capped at 500m, got %s/%s", widened.Requests.Cpu(), widened.Limits.Cpu())
+	}
+	if resources.Limits.Cpu().MilliValue() != 480 {
+		t.Fatalf("expected the original resources to be left alone, got %s", resources.Limits.Cpu())
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+)
+
+// podScaleDownDelay returns how long usage of pods selected by the given
+// policies must stay low before they are sized down: the effective policy's
+// scaleDownDelay when set, else the global one
+func podScaleDownDelay(policies []*v1alpha1.RightSizerPolicy, cfg *config.Config) time.Duration {
+	if len(policies) > 0 {
+		if value := mergePolicies(policies).Spec.Constraints.ScaleDownDelay; value != "" {
+			delay, err := time.ParseDuration(value)
+			if err == nil && delay >= 0 {
+				return delay
+			}
+			logger.Warn("Invalid scaleDownDelay %q in RightSizerPolicy %s, using the global value", value, policies[0].Name)
+		}
+	}
+	return cfg.ScaleDownDelay
+}
+
+// sustainScaleDown holds back a scale down until usage has stayed below the
+// scale-down threshold for the delay, so a momentary idle sample does not
+// shrink a container. The start of each resource's low usage is tracked in
+// the decision cache and cleared as soon as usage rises again.
+func (r *AdaptiveRightSizer) sustainScaleDown(namespace, podName, containerName string, decision ResourceScalingDecision, delay time.Duration, now time.Time) ResourceScalingDecision {
+	containerKey := fmt.Sprintf("%s/%s/%s", namespace, podName, containerName)
+
+	r.cacheMutex.Lock()
+	defer r.cacheMutex.Unlock()
+
+	cached, ok := r.resizeCache[containerKey]
+	if !ok {
+		if decision.CPU != ScaleDown && decision.Memory != ScaleDown {
+			return decision
+		}
+		cached = &ResizeDecisionCache{ContainerKey: containerKey}
+		r.resizeCache[containerKey] = cached
+	}
+	cached.UsageSeen = now
+
+	held := decision
+	decision.CPU = sustained(decision.CPU, &cached.CPULowSince, delay, now)
+	decision.Memory = sustained(decision.Memory, &cached.MemLowSince, delay, now)
+	if held != decision {
+		logger.Debug("Holding back scale down of %s until usage stays low for %v", containerKey, delay)
+	}
+	return decision
+}
+
+// sustainedScaleDown returns the decision sustainScaleDown would make now
+// without recording the usage, for analyses that must not advance the delay
+func (r *AdaptiveRightSizer) sustainedScaleDown(namespace, podName, containerName string, decision ResourceScalingDecision, delay time.Duration, now time.Time) ResourceScalingDecision {
+	var cpuLowSince, memLowSince time.Time
+	r.cacheMutex.RLock()
+	if cached, ok := r.resizeCache[fmt.Sprintf("%s/%s/%s", namespace, podName, containerName)]; ok {
+		cpuLowSince, memLowSince = cached.CPULowSince, cached.MemLowSince
+	}
+	r.cacheMutex.RUnlock()
+
+	decision.CPU = sustained(decision.CPU, &cpuLowSince, delay, now)
+	decision.Memory = sustained(decision.Memory, &memLowSince, delay, now)
+	return decision
+}
+
+// sustained returns the decision for one resource, recording since when its
+// usage has been low and dropping a scale down that has not lasted the delay
+func sustained(decision ScalingDecision, lowSince *time.Time, delay time.Duration, now time.Time) ScalingDecision {
+	if decision != ScaleDown {
+		*lowSince = time.Time{}
+		return decision
+	}
+	if lowSince.IsZero() {
+		*lowSince = now
+	}
+	if now.Sub(*lowSince) < delay {
+		return ScaleNone
+	}
+	return ScaleDown
+}
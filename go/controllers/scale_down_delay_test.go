@@ -0,0 +1,121 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+)
+
+// TestSustainScaleDown verifies a scale down waits for usage to stay low for the delay
+func TestSustainScaleDown(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+	delay := 30 * time.Minute
+	start := time.Now()
+	down := ResourceScalingDecision{CPU: ScaleDown, Memory: ScaleDown}
+
+	if got := r.sustainScaleDown("ns", "pod", "app", down, delay, start); got.CPU != ScaleNone || got.Memory != ScaleNone {
+		t.Fatalf("expected the first low sample to be held back, got %+v", got)
+	}
+	if got := r.sustainScaleDown("ns", "pod", "app", down, delay, start.Add(20*time.Minute)); got.CPU != ScaleNone {
+		t.Fatalf("expected a scale down before the delay to be held back, got %+v", got)
+	}
+
+	// Memory usage rises again, restarting its low period
+	mixed := ResourceScalingDecision{CPU: ScaleDown, Memory: ScaleNone}
+	r.sustainScaleDown("ns", "pod", "app", mixed, delay, start.Add(25*time.Minute))
+
+	got := r.sustainScaleDown("ns", "pod", "app", down, delay, start.Add(30*time.Minute))
+	if got.CPU != ScaleDown || got.Memory != ScaleNone {
+		t.Fatalf("expected only the CPU scale down after sustained low usage, got %+v", got)
+	}
+
+	// Scale ups are never held back
+	up := ResourceScalingDecision{CPU: ScaleUp, Memory: ScaleUp}
+	if got := r.sustainScaleDown("ns", "pod", "app", up, delay, start.Add(31*time.Minute)); got != up {
+		t.Fatalf("expected scale ups to pass through, got %+v", got)
+	}
+
+	// Without a delay scale downs apply at once
+	if got := r.sustainScaleDown("ns", "other", "app", down, 0, start); got != down {
+		t.Fatalf("expected an immediate scale down without a delay, got %+v", got)
+	}
+}
+
+// TestSustainScaleDownAfterResize verifies a resize restarts the low usage period
+func TestSustainScaleDownAfterResize(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+	down := ResourceScalingDecision{CPU: ScaleDown, Memory: ScaleNone}
+	start := time.Now().Add(-time.Hour)
+
+	r.sustainScaleDown("ns", "pod", "app", down, 30*time.Minute, start)
+	r.recordResize("ns", "pod", "app")
+	if got := r.sustainScaleDown("ns", "pod", "app", down, 30*time.Minute, time.Now()); got.CPU != ScaleNone {
+		t.Fatalf("expected the low usage period to restart after a resize, got %+v", got)
+	}
+}
+
+// TestSustainedScaleDown verifies on-demand analysis sees the held back
+// decision without starting or advancing the low usage period
+func TestSustainedScaleDown(t *testing.T) {
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.resizeCache = make(map[string]*ResizeDecisionCache)
+	delay := 30 * time.Minute
+	start := time.Now()
+	down := ResourceScalingDecision{CPU: ScaleDown, Memory: ScaleDown}
+
+	if got := r.sustainedScaleDown("ns", "pod", "app", down, delay, start); got.CPU != ScaleNone {
+		t.Fatalf("expected a scale down without sustained low usage to be held back, got %+v", got)
+	}
+	if len(r.resizeCache) != 0 {
+		t.Fatal("expected no low usage period to be recorded")
+	}
+
+	r.sustainScaleDown("ns", "pod", "app", down, delay, start)
+	if got := r.sustainedScaleDown("ns", "pod", "app", down, delay, start.Add(delay)); got != down {
+		t.Fatalf("expected the scale down once the recorded period lasted the delay, got %+v", got)
+	}
+	if got := r.sustainedScaleDown("ns", "pod", "app", ResourceScalingDecision{}, delay, start.Add(delay)); got.CPU != ScaleNone {
+		t.Fatalf("expected no change, got %+v", got)
+	}
+	if lowSince := r.resizeCache["ns/pod/app"].CPULowSince; !lowSince.Equal(start) {
+		t.Fatalf("expected the recorded low usage period to be kept, got %v", lowSince)
+	}
+}
+
+// TestPodScaleDownDelay verifies policies override the global scale-down delay
+func TestPodScaleDownDelay(t *testing.T) {
+	cfg := config.GetDefaults()
+	if got := podScaleDownDelay(nil, cfg); got != 30*time.Minute {
+		t.Fatalf("expected the global delay, got %v", got)
+	}
+
+	policy := &v1alpha1.RightSizerPolicy{}
+	policy.Spec.Constraints.ScaleDownDelay = "2h"
+	if got := podScaleDownDelay([]*v1alpha1.RightSizerPolicy{policy}, cfg); got != 2*time.Hour {
+		t.Fatalf("expected the policy's delay, got %v", got)
+	}
+
+	policy.Spec.Constraints.ScaleDownDelay = "soon"
+	if got := podScaleDownDelay([]*v1alpha1.RightSizerPolicy{policy}, cfg); got != 30*time.Minute {
+		t.Fatalf("expected the global delay for an invalid value, got %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"os"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// guardSelfSizing keeps resizes of the operator's own pods conservative: a
+// request or limit is lowered by at most the self-sizing step per resize,
+// requests stay above the self-sizing floors and memory limits are never
+// lowered, so a resize cannot leave the running operator short of memory.
+// Updates the guard reduces to no change are dropped.
+func (r *AdaptiveRightSizer) guardSelfSizing(updates []ResourceUpdate, pods []corev1.Pod, cfg config.SelfSizingConfig) []ResourceUpdate {
+	if !cfg.Enabled || len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	result := updates[:0:0]
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok || !r.isSelfPod(pod) {
+			result = append(result, update)
+			continue
+		}
+
+		guarded := guardSelfResources(update.OldResources, update.NewResources, cfg)
+		if resourcesEqual(guarded, update.OldResources) {
+			logger.Debug("Skipping resize of operator pod %s/%s container %s: within the self-sizing guardrails", update.Namespace, update.Name, update.ContainerName)
+			continue
+		}
+		if !resourcesEqual(guarded, update.NewResources) {
+			update.NewResources = guarded
+			if update.Explanation != nil {
+				explanation := *update.Explanation
+				explanation.Steps = append(explanation.Steps[:0:0], update.Explanation.Steps...)
+				explanation.AddStep("self_sizing", fmt.Sprintf("operator pod: lowered by at most %d%%, requests of at least %dm CPU and %dMi memory", cfg.MaxStepPercent, cfg.MinCPURequest, cfg.MinMemoryRequest), guarded)
+				update.Explanation = &explanation
+			}
+		}
+		result = append(result, update)
+	}
+	return result
+}
+
+// guardSelfResources applies the self-sizing guardrails to the proposed
+// resources of an operator container
+func guardSelfResources(current, proposed corev1.ResourceRequirements, cfg config.SelfSizingConfig) corev1.ResourceRequirements {
+	guarded := *proposed.DeepCopy()
+	floors := map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(cfg.MinCPURequest, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(cfg.MinMemoryRequest*1024*1024, resource.BinarySI),
+	}
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if request, ok := guarded.Requests[name]; ok {
+			request = limitDecrease(current.Requests, name, request, cfg.MaxStepPercent)
+			if floor := floors[name]; request.Cmp(floor) < 0 {
+				request = floor
+			}
+			guarded.Requests[name] = request
+		}
+		if limit, ok := guarded.Limits[name]; ok {
+			if old, ok := current.Limits[name]; ok && name == corev1.ResourceMemory && limit.Cmp(old) < 0 {
+				limit = old
+			}
+			limit = limitDecrease(current.Limits, name, limit, cfg.MaxStepPercent)
+			if request, ok := guarded.Requests[name]; ok && limit.Cmp(request) < 0 {
+				limit = request
+			}
+			guarded.Limits[name] = limit
+		}
+	}
+	return guarded
+}
+
+// limitDecrease raises proposed so it is at most stepPercent below the
+// current value of the resource; increases and new resources pass through
+func limitDecrease(current corev1.ResourceList, name corev1.ResourceName, proposed resource.Quantity, stepPercent int) resource.Quantity {
+	old, ok := current[name]
+	if !ok || proposed.Cmp(old) >= 0 {
+		return proposed
+	}
+	if name == corev1.ResourceCPU {
+		floor := resource.NewMilliQuantity(old.MilliValue()*int64(100-stepPercent)/100, resource.DecimalSI)
+		if proposed.Cmp(*floor) < 0 {
+			return *floor
+		}
+		return proposed
+	}
+	floor := resource.NewQuantity(old.Value()*int64(100-stepPercent)/100, resource.BinarySI)
+	if proposed.Cmp(*floor) < 0 {
+		return *floor
+	}
+	return proposed
+}
+
+// holdLeaderResize resizes the operator's standby replicas before the pod
+// running this process, which holds the leader lease. Updates of the leader's
+// own pod wait while a standby is being resized, is not ready, or has an
+// update of its own this run, so a resize that goes wrong never takes out the
+// leader first and a standby is ready to take over.
+func (r *AdaptiveRightSizer) holdLeaderResize(updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	namespace, name := ownPod()
+	if name == "" || len(updates) == 0 {
+		return updates
+	}
+
+	settled := true
+	standbys := make(map[string]bool)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Namespace != namespace || pod.Name == name || !r.isSelfPod(pod) || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		standbys[pod.Name] = true
+		if !podReady(pod) || resizeInFlight(pod) {
+			settled = false
+		}
+	}
+	for _, update := range updates {
+		if update.Namespace == namespace && standbys[update.Name] {
+			settled = false
+		}
+	}
+	if settled {
+		return updates
+	}
+
+	result := updates[:0:0]
+	for _, update := range updates {
+		if update.Namespace == namespace && update.Name == name {
+			logger.Info("⏸️  Holding resize of the leader pod %s/%s container %s until the standby replicas are resized and ready", namespace, name, update.ContainerName)
+			if r.OperatorMetrics != nil {
+				r.OperatorMetrics.RecordSuppressedResize(namespace, "self_sizing_leader")
+			}
+			continue
+		}
+		result = append(result, update)
+	}
+	return result
+}
+
+// ownPod returns the namespace and name of the pod running this process, from
+// the POD_NAME and OPERATOR_NAMESPACE variables or the hostname
+func ownPod() (string, string) {
+	name := os.Getenv("POD_NAME")
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	namespace := os.Getenv("OPERATOR_NAMESPACE")
+	if namespace == "" {
+		namespace = "right-sizer"
+	}
+	return namespace, name
+}
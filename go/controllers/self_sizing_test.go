@@ -0,0 +1,150 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// operatorPod returns a ready pod of the operator's Deployment
+func operatorPod(name string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "right-sizer",
+			Labels:    map[string]string{"app.kubernetes.io/name": "right-sizer"},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// selfUpdate returns an update of the operator pod from the current to the proposed resources
+func selfUpdate(podName string, current, proposed corev1.ResourceRequirements) ResourceUpdate {
+	return ResourceUpdate{
+		Namespace:     "right-sizer",
+		Name:          podName,
+		ResourceType:  "Pod",
+		ContainerName: "right-sizer",
+		OldResources:  current,
+		NewResources:  proposed,
+	}
+}
+
+func cpuMemory(cpu, mem string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(mem),
+	}
+}
+
+// TestGuardSelfSizingLimitsDownsizes verifies the operator is lowered one
+// step at a time and keeps its memory limit, while other pods pass through
+func TestGuardSelfSizingLimitsDownsizes(t *testing.T) {
+	cfg := config.SelfSizingConfig{Enabled: true, MaxStepPercent: 10, MinCPURequest: 100, MinMemoryRequest: 128}
+	r := &AdaptiveRightSizer{}
+	pods := []corev1.Pod{operatorPod("right-sizer-abc"), runningReplica("web-abc-1", "web-abc", "500m", "512Mi")}
+	updates := []ResourceUpdate{
+		selfUpdate("right-sizer-abc",
+			corev1.ResourceRequirements{Requests: cpuMemory("500m", "512Mi"), Limits: cpuMemory("1", "1Gi")},
+			corev1.ResourceRequirements{Requests: cpuMemory("200m", "256Mi"), Limits: cpuMemory("400m", "512Mi")}),
+		requestUpdate("web-abc-1", "200m", "256Mi"),
+	}
+
+	result := r.guardSelfSizing(updates, pods, cfg)
+	if len(result) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(result))
+	}
+	guarded := result[0].NewResources
+	if got := guarded.Requests.Cpu().MilliValue(); got != 450 {
+		t.Errorf("expected the CPU request lowered by 10%% to 450m, got %dm", got)
+	}
+	if got := guarded.Requests.Memory().Value(); got != 512*1024*1024*9/10 {
+		t.Errorf("expected the memory request lowered by 10%%, got %d", got)
+	}
+	if got := guarded.Limits.Cpu().MilliValue(); got != 900 {
+		t.Errorf("expected the CPU limit lowered by 10%% to 900m, got %dm", got)
+	}
+	if got := guarded.Limits.Memory(); got.Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("expected the memory limit kept at 1Gi, got %s", got.String())
+	}
+	if got := result[1].NewResources.Requests.Cpu().MilliValue(); got != 200 {
+		t.Errorf("expected other pods untouched, got %dm", got)
+	}
+}
+
+// TestGuardSelfSizingFloors verifies requests stay above the self-sizing
+// floors and updates reduced to no change are dropped
+func TestGuardSelfSizingFloors(t *testing.T) {
+	cfg := config.SelfSizingConfig{Enabled: true, MaxStepPercent: 50, MinCPURequest: 100, MinMemoryRequest: 128}
+	r := &AdaptiveRightSizer{}
+	pods := []corev1.Pod{operatorPod("right-sizer-a"), operatorPod("right-sizer-b")}
+	updates := []ResourceUpdate{
+		selfUpdate("right-sizer-a",
+			corev1.ResourceRequirements{Requests: cpuMemory("150m", "256Mi")},
+			corev1.ResourceRequirements{Requests: cpuMemory("50m", "64Mi")}),
+		selfUpdate("right-sizer-b",
+			corev1.ResourceRequirements{Requests: cpuMemory("100m", "128Mi")},
+			corev1.ResourceRequirements{Requests: cpuMemory("50m", "64Mi")}),
+	}
+
+	result := r.guardSelfSizing(updates, pods, cfg)
+	if len(result) != 1 || result[0].Name != "right-sizer-a" {
+		t.Fatalf("expected only right-sizer-a to be resized, got %v", updateNames(result))
+	}
+	requests := result[0].NewResources.Requests
+	if got := requests.Cpu().MilliValue(); got != 100 {
+		t.Errorf("expected the CPU request floored at 100m, got %dm", got)
+	}
+	if got := requests.Memory(); got.Cmp(resource.MustParse("128Mi")) != 0 {
+		t.Errorf("expected the memory request lowered by 50%% to 128Mi, got %s", got.String())
+	}
+}
+
+// TestHoldLeaderResize verifies the leader's own pod is resized only once the
+// standby replicas are resized and ready
+func TestHoldLeaderResize(t *testing.T) {
+	t.Setenv("POD_NAME", "right-sizer-leader")
+	t.Setenv("OPERATOR_NAMESPACE", "right-sizer")
+	r := &AdaptiveRightSizer{}
+	resources := corev1.ResourceRequirements{Requests: cpuMemory("200m", "256Mi")}
+	leader := selfUpdate("right-sizer-leader", resources, resources)
+	standby := selfUpdate("right-sizer-standby", resources, resources)
+	pods := []corev1.Pod{operatorPod("right-sizer-leader"), operatorPod("right-sizer-standby")}
+
+	result := r.holdLeaderResize([]ResourceUpdate{leader, standby}, pods)
+	if names := updateNames(result); len(names) != 1 || names[0] != "right-sizer-standby" {
+		t.Fatalf("expected only the standby to be resized first, got %v", names)
+	}
+
+	pods[1].Status.Conditions[0].Status = corev1.ConditionFalse
+	if result := r.holdLeaderResize([]ResourceUpdate{leader}, pods); len(result) != 0 {
+		t.Fatalf("expected the leader held while the standby is not ready, got %v", updateNames(result))
+	}
+
+	pods[1].Status.Conditions[0].Status = corev1.ConditionTrue
+	if result := r.holdLeaderResize([]ResourceUpdate{leader}, pods); len(result) != 1 {
+		t.Fatalf("expected the leader resized once the standby is ready, got %v", updateNames(result))
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Simulation compares the decisions the live configuration made over the
+// usage history with those a hypothetical configuration would have made
+type Simulation struct {
+	CostSource string // opencost, kubecost or estimate
+	Live       SimulationTotals
+	Simulated  SimulationTotals
+	Containers []SimulatedContainer // containers either configuration resizes
+}
+
+// SimulationTotals sums the replays of every container under one configuration
+type SimulationTotals struct {
+	Resizes             int
+	ContainersResized   int
+	CPUMillisReleased   int64 // requests released, negative when they grew
+	MemoryBytesReleased int64 // requests released, negative when they grew
+	MonthlySavings      float64
+}
+
+// SimulatedContainer is the replay of one container's usage history
+type SimulatedContainer struct {
+	Namespace string
+	Pod       string
+	Container string
+	Samples   int
+	Current   corev1.ResourceRequirements
+	Live      ReplayOutcome
+	Simulated ReplayOutcome
+}
+
+// ReplayOutcome is what a configuration did to a container over the history
+type ReplayOutcome struct {
+	Resizes    int
+	Final      corev1.ResourceRequirements // resources after the last resize
+	LastReason string
+}
+
+// usageSample is the usage of a container at a point of its history
+type usageSample struct {
+	time  time.Time
+	usage metrics.Metrics
+}
+
+// Simulate replays the usage history the prediction engine kept over the
+// window for every container of the managed running pods, in one namespace
+// or all, under both the live configuration and cfg. Each replay starts from
+// the container's current resources, makes the threshold decision at every
+// sample and resizes like the operator would, cooldown included. Nothing is
+// applied and the live configuration is left untouched.
+func (r *AdaptiveRightSizer) Simulate(ctx context.Context, cfg *config.Config, namespace string, window time.Duration) (Simulation, error) {
+	var simulation Simulation
+	if r.Predictor == nil {
+		return simulation, errors.New("no usage history is kept without the prediction engine")
+	}
+
+	var pods corev1.PodList
+	var opts []client.ListOption
+	if namespace != "" {
+		opts = append(opts, client.InNamespace(namespace))
+	}
+	if err := r.Client.List(ctx, &pods, opts...); err != nil {
+		return simulation, err
+	}
+
+	pricing := cost.EstimatedPricing()
+	if r.Recommendations != nil && r.Recommendations.Pricing != nil {
+		pricing = r.Recommendations.Pricing.Pricing(ctx)
+	}
+	simulation.CostSource = pricing.Source
+
+	profilePolicies := r.profilePolicies(ctx)
+	exclusions := exclusionRules(config.Get().Exclusions)
+	since := time.Now().Add(-window)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if r.assessmentSkipReason(ctx, pod, profilePolicies, exclusions) != "" {
+			continue
+		}
+		policies := r.matchingPolicies(ctx, pod, profilePolicies)
+		live := config.Get().ForNamespace(pod.Namespace)
+		hypothetical := cfg.ForNamespace(pod.Namespace)
+
+		for _, target := range resizableContainers(pod) {
+			container := target.container
+			if live.IsContainerExcluded(container.Name) {
+				continue
+			}
+			samples := r.usageHistory(pod.Namespace, pod.Name, container.Name, since)
+			if len(samples) == 0 {
+				continue
+			}
+			result := SimulatedContainer{
+				Namespace: pod.Namespace,
+				Pod:       pod.Name,
+				Container: container.Name,
+				Samples:   len(samples),
+				Current:   container.Resources,
+				Live:      r.replay(samples, container.Resources, live, podUsageAggregation(policies, live)),
+				Simulated: r.replay(samples, container.Resources, hypothetical, podUsageAggregation(policies, hypothetical)),
+			}
+			simulation.Live.add(result.Current, result.Live, pricing)
+			simulation.Simulated.add(result.Current, result.Simulated, pricing)
+			if result.Live.Resizes > 0 || result.Simulated.Resizes > 0 {
+				simulation.Containers = append(simulation.Containers, result)
+			}
+		}
+	}
+	return simulation, nil
+}
+
+// add counts the replay of a container
+func (t *SimulationTotals) add(current corev1.ResourceRequirements, outcome ReplayOutcome, pricing *cost.Pricing) {
+	if outcome.Resizes == 0 {
+		return
+	}
+	cpu := current.Requests.Cpu().MilliValue() - outcome.Final.Requests.Cpu().MilliValue()
+	memory := current.Requests.Memory().Value() - outcome.Final.Requests.Memory().Value()
+	t.Resizes += outcome.Resizes
+	t.ContainersResized++
+	t.CPUMillisReleased += cpu
+	t.MemoryBytesReleased += memory
+	t.MonthlySavings += pricing.MonthlyCost(cpu, memory)
+}
+
+// usageHistory pairs the CPU and memory samples the prediction engine kept
+// for a container since the given time, oldest first
+func (r *AdaptiveRightSizer) usageHistory(namespace, podName, containerName string, since time.Time) []usageSample {
+	cpu, err := r.Predictor.GetHistoricalData(namespace, podName, containerName, "cpu", since)
+	if err != nil {
+		return nil
+	}
+	memory, err := r.Predictor.GetHistoricalData(namespace, podName, containerName, "memory", since)
+	if err != nil {
+		return nil
+	}
+	memoryAt := make(map[int64]float64, len(memory.DataPoints))
+	for _, point := range memory.DataPoints {
+		memoryAt[point.Timestamp.UnixNano()] = point.Value
+	}
+
+	var samples []usageSample
+	for _, point := range cpu.DataPoints {
+		memMB, ok := memoryAt[point.Timestamp.UnixNano()]
+		if !ok {
+			continue
+		}
+		samples = append(samples, usageSample{
+			time:  point.Timestamp,
+			usage: metrics.Metrics{CPUMilli: point.Value, MemMB: memMB},
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].time.Before(samples[j].time) })
+	return samples
+}
+
+// replay walks a container's usage history from its current resources,
+// resizing whenever the configuration's thresholds call for it, and returns
+// how often it resized and where it ended up
+func (r *AdaptiveRightSizer) replay(samples []usageSample, current corev1.ResourceRequirements, cfg *config.Config, aggregation usageAggregation) ReplayOutcome {
+	outcome := ReplayOutcome{Final: *current.DeepCopy()}
+	var lastResize time.Time
+	for i, sample := range samples {
+		if !lastResize.IsZero() && sample.time.Sub(lastResize) < cfg.ResizeCooldown {
+			continue
+		}
+		decision := r.checkScalingThresholds(sample.usage, outcome.Final, cfg)
+		if decision.CPU == ScaleNone && decision.Memory != ScaleUp {
+			continue
+		}
+
+		usage := sample.usage
+		if aggregation.windowed() {
+			usage = aggregateSamples(samples[:i+1], aggregation)
+		}
+		proposed := r.calculateOptimalResourcesWithDecision(usage, decision, cfg)
+		if !r.needsAdjustmentWithDecision(outcome.Final, proposed, decision) {
+			continue
+		}
+		outcome.LastReason = r.getAdjustmentReasonWithDecision(outcome.Final, proposed, decision)
+		outcome.Final = proposed
+		outcome.Resizes++
+		lastResize = sample.time
+	}
+	return outcome
+}
+
+// aggregateSamples aggregates the samples within the aggregation window of
+// the last one, the way the live sizing aggregates the stored history
+func aggregateSamples(samples []usageSample, aggregation usageAggregation) metrics.Metrics {
+	latest := samples[len(samples)-1]
+	start := latest.time.Add(-aggregation.Window)
+	var cpu, memory []float64
+	for _, sample := range samples {
+		if sample.time.Before(start) {
+			continue
+		}
+		cpu = append(cpu, sample.usage.CPUMilli)
+		memory = append(memory, sample.usage.MemMB)
+	}
+	usage := latest.usage
+	usage.CPUMilli = withLatest(aggregateValues(cpu, aggregation.CPU, aggregation.Percentile), latest.usage.CPUMilli, aggregation.CPU)
+	usage.MemMB = withLatest(aggregateValues(memory, aggregation.Memory, aggregation.Percentile), latest.usage.MemMB, aggregation.Memory)
+	return usage
+}
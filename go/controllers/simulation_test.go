@@ -0,0 +1,110 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/metrics"
+	"right-sizer/predictor"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestSimulateComparesConfigurations verifies the stored history is replayed
+// under both configurations without touching the live one
+func TestSimulateComparesConfigurations(t *testing.T) {
+	engine, err := predictor.NewEngine(predictor.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		ts := now.Add(-time.Duration(10-i) * 5 * time.Minute)
+		_ = engine.StoreDataPoint("default", "web", "test-container", "cpu", 100, ts)
+		_ = engine.StoreDataPoint("default", "web", "test-container", "memory", 200, ts)
+	}
+
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(createTestPod("web", "default", "1", "1Gi", "2", "2Gi")).Build()
+	r.Predictor = engine
+
+	live := config.Get()
+	liveThreshold := live.CPUScaleDownThreshold
+	hypothetical := live.Clone()
+	hypothetical.CPUScaleDownThreshold = 0.04
+	hypothetical.MemoryScaleDownThreshold = 0.05
+
+	simulation, err := r.Simulate(context.Background(), hypothetical, "default", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(simulation.Containers) != 1 {
+		t.Fatalf("expected one container in the simulation, got %d", len(simulation.Containers))
+	}
+	c := simulation.Containers[0]
+	if c.Samples != 10 || c.Live.Resizes != 1 || c.Simulated.Resizes != 0 {
+		t.Fatalf("expected 10 samples, one live resize and none simulated, got %+v", c)
+	}
+	if got := c.Live.Final.Requests.Cpu().MilliValue(); got != 120 {
+		t.Errorf("expected the live replay to size CPU down to 120m, got %dm", got)
+	}
+	if simulation.Live.CPUMillisReleased != 880 || simulation.Live.MonthlySavings <= 0 || simulation.Simulated.Resizes != 0 {
+		t.Errorf("unexpected totals live %+v, simulated %+v", simulation.Live, simulation.Simulated)
+	}
+	if live.CPUScaleDownThreshold != liveThreshold {
+		t.Errorf("the live configuration changed to %v", live.CPUScaleDownThreshold)
+	}
+
+	r.Predictor = nil
+	if _, err := r.Simulate(context.Background(), hypothetical, "default", time.Hour); err == nil {
+		t.Error("expected an error without the prediction engine")
+	}
+}
+
+// TestReplayHonorsCooldown verifies a replay resizes at most once per cooldown
+func TestReplayHonorsCooldown(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.ResizeCooldown = time.Hour
+	r := newAdaptiveTestRig(cfg)
+	current := createTestPod("web", "default", "100m", "128Mi", "200m", "256Mi").Spec.Containers[0].Resources
+
+	// Usage keeps climbing past the scale-up threshold every minute
+	var samples []usageSample
+	start := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < 120; i++ {
+		samples = append(samples, usageSample{
+			time:  start.Add(time.Duration(i) * time.Minute),
+			usage: metrics.Metrics{CPUMilli: float64(190 + 10*i), MemMB: 100},
+		})
+	}
+	aggregation := usageAggregation{CPU: config.AggregationLatest, Memory: config.AggregationLatest}
+	outcome := r.replay(samples, current, cfg, aggregation)
+	if outcome.Resizes != 2 {
+		t.Fatalf("expected two resizes an hour apart, got %d", outcome.Resizes)
+	}
+	if outcome.LastReason == "" || outcome.Final.Requests.Cpu().MilliValue() <= 100 {
+		t.Errorf("expected CPU sized up with a reason, got %+v", outcome)
+	}
+}
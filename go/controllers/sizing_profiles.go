@@ -0,0 +1,257 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// profileAnnotation selects the sizing profile of a pod's containers,
+// overriding the profile of any matching RightSizerPolicy
+const profileAnnotation = "rightsizer.io/profile"
+
+// Built-in sizing profiles
+const (
+	ProfileSteady = "steady"
+	ProfileBursty = "bursty"
+	ProfileBatch  = "batch"
+)
+
+// SizingProfile changes the sizing math for workloads with a given usage
+// pattern. Profiles are selected by name through the rightsizer.io/profile
+// annotation or the profile field of a RightSizerPolicy.
+type SizingProfile interface {
+	// Name is the name the profile is selected by
+	Name() string
+	// Usage returns the usage a container is sized from, given its latest
+	// sample. usageAt returns a percentile of the container's recent usage.
+	Usage(sample metrics.Metrics, usageAt func(percentile int) metrics.Metrics) metrics.Metrics
+	// Resources adjusts the resources calculated from that usage
+	Resources(calculated corev1.ResourceRequirements, usage metrics.Metrics, cfg *config.Config) corev1.ResourceRequirements
+}
+
+var (
+	sizingProfilesMu sync.RWMutex
+	sizingProfiles   = make(map[string]SizingProfile)
+)
+
+// RegisterSizingProfile makes a profile selectable by its name, replacing
+// any profile registered under the same name
+func RegisterSizingProfile(profile SizingProfile) {
+	sizingProfilesMu.Lock()
+	defer sizingProfilesMu.Unlock()
+	sizingProfiles[profile.Name()] = profile
+}
+
+// LookupSizingProfile returns the profile registered under name
+func LookupSizingProfile(name string) (SizingProfile, bool) {
+	sizingProfilesMu.RLock()
+	defer sizingProfilesMu.RUnlock()
+	profile, ok := sizingProfiles[name]
+	return profile, ok
+}
+
+// SizingProfiles returns the names of the registered profiles
+func SizingProfiles() []string {
+	sizingProfilesMu.RLock()
+	defer sizingProfilesMu.RUnlock()
+	names := make([]string, 0, len(sizingProfiles))
+	for name := range sizingProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterSizingProfile(steadyProfile{})
+	RegisterSizingProfile(burstyProfile{CPULimitRatio: 4, MemoryLimitRatio: 2})
+	RegisterSizingProfile(batchProfile{Percentile: 50, RequestHeadroom: 1.05})
+}
+
+// steadyProfile sizes from the latest usage with the configured multipliers.
+// It is used when no profile is selected.
+type steadyProfile struct{}
+
+func (steadyProfile) Name() string { return ProfileSteady }
+
+func (steadyProfile) Usage(sample metrics.Metrics, _ func(int) metrics.Metrics) metrics.Metrics {
+	return sample
+}
+
+func (steadyProfile) Resources(calculated corev1.ResourceRequirements, _ metrics.Metrics, _ *config.Config) corev1.ResourceRequirements {
+	return calculated
+}
+
+// burstyProfile keeps limits at a multiple of requests, so containers that
+// idle most of the time have headroom for their bursts
+type burstyProfile struct {
+	CPULimitRatio    float64
+	MemoryLimitRatio float64
+}
+
+func (burstyProfile) Name() string { return ProfileBursty }
+
+func (burstyProfile) Usage(sample metrics.Metrics, _ func(int) metrics.Metrics) metrics.Metrics {
+	return sample
+}
+
+func (p burstyProfile) Resources(calculated corev1.ResourceRequirements, _ metrics.Metrics, cfg *config.Config) corev1.ResourceRequirements {
+	resources := *calculated.DeepCopy()
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+
+	if request, ok := resources.Requests[corev1.ResourceCPU]; ok {
+		limit := int64(float64(request.MilliValue()) * p.CPULimitRatio)
+		if cfg.MaxCPULimit > 0 {
+			limit = min(limit, max(cfg.MaxCPULimit, request.MilliValue()))
+		}
+		if current, ok := resources.Limits[corev1.ResourceCPU]; !ok || current.MilliValue() < limit {
+			resources.Limits[corev1.ResourceCPU] = *resource.NewMilliQuantity(limit, resource.DecimalSI)
+		}
+	}
+	if request, ok := resources.Requests[corev1.ResourceMemory]; ok {
+		limitMB := int64(float64(request.Value()) * p.MemoryLimitRatio / (1024 * 1024))
+		if cfg.MaxMemoryLimit > 0 {
+			limitMB = min(limitMB, max(cfg.MaxMemoryLimit, request.Value()/(1024*1024)))
+		}
+		if current, ok := resources.Limits[corev1.ResourceMemory]; !ok || current.Value() < limitMB*1024*1024 {
+			resources.Limits[corev1.ResourceMemory] = *resource.NewQuantity(limitMB*1024*1024, resource.BinarySI)
+		}
+	}
+	return resources
+}
+
+// batchProfile sizes requests tightly from a low percentile of recent usage,
+// so short spikes do not inflate them. Limits are kept from the calculated
+// resources, leaving the spikes room to run.
+type batchProfile struct {
+	Percentile      int
+	RequestHeadroom float64
+}
+
+func (batchProfile) Name() string { return ProfileBatch }
+
+func (p batchProfile) Usage(_ metrics.Metrics, usageAt func(int) metrics.Metrics) metrics.Metrics {
+	return usageAt(p.Percentile)
+}
+
+func (p batchProfile) Resources(calculated corev1.ResourceRequirements, usage metrics.Metrics, cfg *config.Config) corev1.ResourceRequirements {
+	resources := *calculated.DeepCopy()
+	if resources.Requests == nil {
+		return resources
+	}
+
+	if _, ok := resources.Requests[corev1.ResourceCPU]; ok {
+		request := max(int64(usage.CPUMilli*p.RequestHeadroom), cfg.MinCPURequest)
+		if limit, ok := resources.Limits[corev1.ResourceCPU]; ok && limit.MilliValue() < request {
+			request = limit.MilliValue()
+		}
+		resources.Requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(request, resource.DecimalSI)
+	}
+	if _, ok := resources.Requests[corev1.ResourceMemory]; ok {
+		requestMB := max(int64(usage.MemMB*p.RequestHeadroom), cfg.MinMemoryRequest)
+		if limit, ok := resources.Limits[corev1.ResourceMemory]; ok && limit.Value() < requestMB*1024*1024 {
+			requestMB = limit.Value() / (1024 * 1024)
+		}
+		resources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(requestMB*1024*1024, resource.BinarySI)
+	}
+	return resources
+}
+
+// profilePolicies lists the enabled policies, highest precedence first
+func (r *AdaptiveRightSizer) profilePolicies(ctx context.Context) []v1alpha1.RightSizerPolicy {
+	var list v1alpha1.RightSizerPolicyList
+	if err := r.Client.List(ctx, &list); err != nil {
+		logger.Debug("Unable to list RightSizerPolicies for sizing profiles: %v", err)
+		return nil
+	}
+
+	var policies []v1alpha1.RightSizerPolicy
+	for _, policy := range list.Items {
+		if policy.Spec.Enabled {
+			policies = append(policies, policy)
+		}
+	}
+	sortPoliciesByPrecedence(policies)
+	return policies
+}
+
+// sizingProfile returns the profile of a pod's containers: the one named by
+// its rightsizer.io/profile annotation, else the one of the effective policy
+// selecting its workload, else the steady profile
+func (r *AdaptiveRightSizer) sizingProfile(ctx context.Context, pod *corev1.Pod, policies []v1alpha1.RightSizerPolicy) SizingProfile {
+	return podSizingProfile(pod, r.matchingPolicies(ctx, pod, policies))
+}
+
+// matchingPolicies returns the policies selecting a pod's workload, highest
+// precedence first
+func (r *AdaptiveRightSizer) matchingPolicies(ctx context.Context, pod *corev1.Pod, policies []v1alpha1.RightSizerPolicy) []*v1alpha1.RightSizerPolicy {
+	if len(policies) == 0 {
+		return nil
+	}
+	target := resolveWorkloadRef(ctx, r.Client, pod)
+	var matching []*v1alpha1.RightSizerPolicy
+	for i := range policies {
+		if policyMatchesPod(&policies[i], pod, target) {
+			matching = append(matching, &policies[i])
+		}
+	}
+	return matching
+}
+
+// podSizingProfile returns the profile of a pod selected by the given policies
+func podSizingProfile(pod *corev1.Pod, matching []*v1alpha1.RightSizerPolicy) SizingProfile {
+	name := pod.Annotations[profileAnnotation]
+	if name == "" && len(matching) > 0 {
+		name = mergePolicies(matching).Spec.Profile
+	}
+	return profileByName(name)
+}
+
+// policyNames returns the names of the given policies
+func policyNames(policies []*v1alpha1.RightSizerPolicy) []string {
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+	return names
+}
+
+// profileByName returns the named profile, or the steady profile when the
+// name is empty or unknown
+func profileByName(name string) SizingProfile {
+	if name == "" {
+		name = ProfileSteady
+	}
+	profile, ok := LookupSizingProfile(name)
+	if !ok {
+		logger.Warn("Unknown sizing profile %q, using %s", name, ProfileSteady)
+		profile, _ = LookupSizingProfile(ProfileSteady)
+	}
+	return profile
+}
@@ -0,0 +1,165 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func profileResources(cpuReq, memReq, cpuLim, memLim string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpuReq),
+			corev1.ResourceMemory: resource.MustParse(memReq),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpuLim),
+			corev1.ResourceMemory: resource.MustParse(memLim),
+		},
+	}
+}
+
+// TestBurstyProfileRaisesLimits verifies limits are kept at a multiple of requests, within the maximums
+func TestBurstyProfileRaisesLimits(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.MaxCPULimit = 1000
+	profile := profileByName(ProfileBursty)
+
+	got := profile.Resources(profileResources("200m", "256Mi", "300m", "300Mi"), metrics.Metrics{}, cfg)
+	if cpu := got.Limits[corev1.ResourceCPU]; cpu.MilliValue() != 800 {
+		t.Errorf("expected a 4x CPU limit of 800m, got %s", cpu.String())
+	}
+	if mem := got.Limits[corev1.ResourceMemory]; mem.Value() != 512<<20 {
+		t.Errorf("expected a 2x memory limit of 512Mi, got %s", mem.String())
+	}
+
+	got = profile.Resources(profileResources("400m", "256Mi", "500m", "1Gi"), metrics.Metrics{}, cfg)
+	if cpu := got.Limits[corev1.ResourceCPU]; cpu.MilliValue() != 1000 {
+		t.Errorf("expected the CPU limit capped at 1000m, got %s", cpu.String())
+	}
+	if mem := got.Limits[corev1.ResourceMemory]; mem.Value() != 1<<30 {
+		t.Errorf("expected a higher memory limit to be kept, got %s", mem.String())
+	}
+}
+
+// TestBatchProfileIgnoresSpikes verifies requests follow the median usage while limits are kept
+func TestBatchProfileIgnoresSpikes(t *testing.T) {
+	cfg := config.GetDefaults()
+	profile := profileByName(ProfileBatch)
+
+	spike := metrics.Metrics{CPUMilli: 900, MemMB: 900}
+	usage := profile.Usage(spike, func(percentile int) metrics.Metrics {
+		if percentile != 50 {
+			t.Errorf("expected the median, got P%d", percentile)
+		}
+		return metrics.Metrics{CPUMilli: 100, MemMB: 200}
+	})
+	if usage.CPUMilli != 100 || usage.MemMB != 200 {
+		t.Fatalf("expected the median usage, got %+v", usage)
+	}
+
+	got := profile.Resources(profileResources("300m", "400Mi", "1", "1Gi"), usage, cfg)
+	if cpu := got.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 105 {
+		t.Errorf("expected a 105m CPU request, got %s", cpu.String())
+	}
+	if mem := got.Requests[corev1.ResourceMemory]; mem.Value() != 210<<20 {
+		t.Errorf("expected a 210Mi memory request, got %s", mem.String())
+	}
+	if cpu := got.Limits[corev1.ResourceCPU]; cpu.MilliValue() != 1000 {
+		t.Errorf("expected the CPU limit kept, got %s", cpu.String())
+	}
+}
+
+// TestSizingProfileSelection verifies the annotation wins over policies and unknown names fall back to steady
+func TestSizingProfileSelection(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+
+	batch := &v1alpha1.RightSizerPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "jobs", Namespace: "default"},
+		Spec: v1alpha1.RightSizerPolicySpec{
+			Enabled:   true,
+			Priority:  100,
+			Profile:   ProfileBatch,
+			TargetRef: v1alpha1.TargetReference{Namespaces: []string{"batch"}},
+		},
+	}
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(batch).Build()
+	policies := r.profilePolicies(context.Background())
+	if len(policies) != 1 {
+		t.Fatalf("expected the profile policy, got %d", len(policies))
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "batch"}}
+	if got := r.sizingProfile(context.Background(), pod, policies).Name(); got != ProfileBatch {
+		t.Errorf("expected the policy's batch profile, got %s", got)
+	}
+
+	pod.Annotations = map[string]string{profileAnnotation: ProfileBursty}
+	if got := r.sizingProfile(context.Background(), pod, policies).Name(); got != ProfileBursty {
+		t.Errorf("expected the annotated bursty profile, got %s", got)
+	}
+
+	pod.Annotations[profileAnnotation] = "unknown"
+	if got := r.sizingProfile(context.Background(), pod, policies).Name(); got != ProfileSteady {
+		t.Errorf("expected the steady profile for an unknown name, got %s", got)
+	}
+
+	other := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	if got := r.sizingProfile(context.Background(), other, policies).Name(); got != ProfileSteady {
+		t.Errorf("expected the steady profile without a matching policy, got %s", got)
+	}
+}
+
+type fixedProfile struct{ steadyProfile }
+
+func (fixedProfile) Name() string { return "fixed" }
+
+func (fixedProfile) Resources(corev1.ResourceRequirements, metrics.Metrics, *config.Config) corev1.ResourceRequirements {
+	return profileResources("1", "1Gi", "1", "1Gi")
+}
+
+// TestRegisterSizingProfile verifies custom profiles can be plugged in
+func TestRegisterSizingProfile(t *testing.T) {
+	RegisterSizingProfile(fixedProfile{})
+	defer func() {
+		sizingProfilesMu.Lock()
+		delete(sizingProfiles, "fixed")
+		sizingProfilesMu.Unlock()
+	}()
+
+	profile, ok := LookupSizingProfile("fixed")
+	if !ok {
+		t.Fatalf("expected the fixed profile among %v", SizingProfiles())
+	}
+	got := profile.Resources(corev1.ResourceRequirements{}, metrics.Metrics{}, config.GetDefaults())
+	if cpu := got.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 1000 {
+		t.Errorf("expected the fixed profile's resources, got %s", cpu.String())
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Special memory handling modes, set by the specialMemory field of a
+// policy's memory strategy
+const (
+	SpecialMemoryFloor = "floor"
+	SpecialMemorySkip  = "skip"
+)
+
+// specialMemory describes the memory of a container that its usage does not
+// reflect. Files in a memory-backed emptyDir are charged to the container
+// that writes them, so a tmpfs that is empty now can fill up to its size
+// limit later; hugepages are accounted apart from memory altogether.
+type specialMemory struct {
+	tmpfs          resource.Quantity // size limits of the memory-backed emptyDirs the container mounts
+	unboundedTmpfs bool              // a mounted memory-backed emptyDir has no size limit
+	hugepages      bool
+}
+
+// String lists what makes the container's memory special
+func (s specialMemory) String() string {
+	var parts []string
+	if !s.tmpfs.IsZero() {
+		parts = append(parts, "memory-backed emptyDir of "+s.tmpfs.String())
+	}
+	if s.unboundedTmpfs {
+		parts = append(parts, "memory-backed emptyDir without size limit")
+	}
+	if s.hugepages {
+		parts = append(parts, "hugepages")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// podSpecialMemory returns how the effective policy of a pod handles special
+// memory, floor by default
+func podSpecialMemory(policies []*v1alpha1.RightSizerPolicy) string {
+	if len(policies) > 0 {
+		if mode := mergePolicies(policies).Spec.ResourceStrategy.Memory.SpecialMemory; mode == SpecialMemorySkip {
+			return mode
+		}
+	}
+	return SpecialMemoryFloor
+}
+
+// containerSpecialMemory returns the special memory of a container, and
+// whether it has any
+func containerSpecialMemory(pod *corev1.Pod, container *corev1.Container) (specialMemory, bool) {
+	var special specialMemory
+	volumes := make(map[string]*corev1.EmptyDirVolumeSource, len(pod.Spec.Volumes))
+	for i := range pod.Spec.Volumes {
+		if emptyDir := pod.Spec.Volumes[i].EmptyDir; emptyDir != nil && emptyDir.Medium == corev1.StorageMediumMemory {
+			volumes[pod.Spec.Volumes[i].Name] = emptyDir
+		}
+	}
+	mounted := make(map[string]bool)
+	for _, mount := range container.VolumeMounts {
+		emptyDir, ok := volumes[mount.Name]
+		if !ok || mounted[mount.Name] {
+			continue
+		}
+		mounted[mount.Name] = true
+		if emptyDir.SizeLimit == nil {
+			special.unboundedTmpfs = true
+			continue
+		}
+		special.tmpfs.Add(*emptyDir.SizeLimit)
+	}
+
+	for _, list := range []corev1.ResourceList{container.Resources.Requests, container.Resources.Limits} {
+		for name := range list {
+			if strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) {
+				special.hugepages = true
+			}
+		}
+	}
+	return special, !special.tmpfs.IsZero() || special.unboundedTmpfs || special.hugepages
+}
+
+// applySpecialMemory adjusts the proposed resources of a container with
+// special memory. skip keeps the current memory; floor keeps memory at or
+// above the tmpfs size limits, and does not lower it when a tmpfs has none.
+// Resources other than CPU and memory, such as hugepages, are always kept:
+// they cannot be resized in place.
+func applySpecialMemory(mode string, special specialMemory, current, proposed corev1.ResourceRequirements) corev1.ResourceRequirements {
+	adjusted := *proposed.DeepCopy()
+	for _, lists := range []struct{ from, into *corev1.ResourceList }{
+		{&current.Requests, &adjusted.Requests},
+		{&current.Limits, &adjusted.Limits},
+	} {
+		for name, qty := range *lists.from {
+			if name == corev1.ResourceCPU || name == corev1.ResourceMemory {
+				continue
+			}
+			if *lists.into == nil {
+				*lists.into = corev1.ResourceList{}
+			}
+			(*lists.into)[name] = qty.DeepCopy()
+		}
+	}
+
+	keep := func(list corev1.ResourceList, from corev1.ResourceList) {
+		if memory, ok := from[corev1.ResourceMemory]; ok {
+			list[corev1.ResourceMemory] = memory.DeepCopy()
+		} else {
+			delete(list, corev1.ResourceMemory)
+		}
+	}
+	raise := func(list corev1.ResourceList, floor resource.Quantity) {
+		if memory, ok := list[corev1.ResourceMemory]; ok && memory.Cmp(floor) < 0 {
+			list[corev1.ResourceMemory] = floor.DeepCopy()
+		}
+	}
+
+	switch {
+	case mode == SpecialMemorySkip:
+		if adjusted.Requests != nil {
+			keep(adjusted.Requests, current.Requests)
+		}
+		if adjusted.Limits != nil {
+			keep(adjusted.Limits, current.Limits)
+		}
+	case special.unboundedTmpfs:
+		if adjusted.Requests != nil {
+			raise(adjusted.Requests, *current.Requests.Memory())
+		}
+		if adjusted.Limits != nil {
+			raise(adjusted.Limits, *current.Limits.Memory())
+		}
+	case !special.tmpfs.IsZero():
+		if adjusted.Requests != nil {
+			raise(adjusted.Requests, special.tmpfs)
+		}
+		if adjusted.Limits != nil {
+			raise(adjusted.Limits, special.tmpfs)
+		}
+	}
+	return adjusted
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func tmpfsResources(request, limit string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse(request)},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)},
+	}
+}
+
+// tmpfsPod returns a pod whose container mounts memory-backed emptyDirs of the given sizes
+func tmpfsPod(sizes ...string) (*corev1.Pod, *corev1.Container) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "default"}}
+	pod.Spec.Containers = []corev1.Container{{Name: "app"}}
+	for i, size := range sizes {
+		name := string(rune('a' + i))
+		emptyDir := &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory}
+		if size != "" {
+			limit := resource.MustParse(size)
+			emptyDir.SizeLimit = &limit
+		}
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir}})
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: name, MountPath: "/" + name})
+	}
+	return pod, &pod.Spec.Containers[0]
+}
+
+func TestContainerSpecialMemory(t *testing.T) {
+	pod, container := tmpfsPod("256Mi", "256Mi")
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{Name: "disk", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "disk", MountPath: "/disk"})
+	special, ok := containerSpecialMemory(pod, container)
+	if !ok || special.tmpfs.Cmp(resource.MustParse("512Mi")) != 0 || special.unboundedTmpfs {
+		t.Fatalf("expected 512Mi of bounded tmpfs, got %+v", special)
+	}
+
+	pod, container = tmpfsPod()
+	container.Resources.Limits = corev1.ResourceList{"hugepages-2Mi": resource.MustParse("128Mi")}
+	if special, ok := containerSpecialMemory(pod, container); !ok || !special.hugepages {
+		t.Fatalf("expected hugepages to be detected, got %+v", special)
+	}
+
+	pod, container = tmpfsPod()
+	if _, ok := containerSpecialMemory(pod, container); ok {
+		t.Fatal("expected a container without tmpfs or hugepages not to be special")
+	}
+}
+
+func TestApplySpecialMemoryFloor(t *testing.T) {
+	special := specialMemory{tmpfs: resource.MustParse("512Mi")}
+	got := applySpecialMemory(SpecialMemoryFloor, special, tmpfsResources("1Gi", "1Gi"), tmpfsResources("128Mi", "256Mi"))
+	if got.Requests.Memory().Cmp(resource.MustParse("512Mi")) != 0 || got.Limits.Memory().Cmp(resource.MustParse("512Mi")) != 0 {
+		t.Fatalf("expected memory floored at the tmpfs size, got request %s limit %s", got.Requests.Memory(), got.Limits.Memory())
+	}
+
+	got = applySpecialMemory(SpecialMemoryFloor, specialMemory{unboundedTmpfs: true}, tmpfsResources("1Gi", "1Gi"), tmpfsResources("128Mi", "256Mi"))
+	if got.Requests.Memory().Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Fatalf("expected memory not to be lowered with an unbounded tmpfs, got %s", got.Requests.Memory())
+	}
+
+	got = applySpecialMemory(SpecialMemoryFloor, special, tmpfsResources("128Mi", "1Gi"), tmpfsResources("768Mi", "1536Mi"))
+	if got.Requests.Memory().Cmp(resource.MustParse("768Mi")) != 0 {
+		t.Fatalf("expected upsizes above the floor to pass, got %s", got.Requests.Memory())
+	}
+}
+
+func TestApplySpecialMemorySkipKeepsHugepages(t *testing.T) {
+	current := tmpfsResources("1Gi", "1Gi")
+	current.Requests["hugepages-2Mi"] = resource.MustParse("128Mi")
+	current.Limits["hugepages-2Mi"] = resource.MustParse("128Mi")
+	proposed := tmpfsResources("256Mi", "512Mi")
+	proposed.Requests[corev1.ResourceCPU] = resource.MustParse("300m")
+
+	got := applySpecialMemory(SpecialMemorySkip, specialMemory{hugepages: true}, current, proposed)
+	if got.Requests.Memory().Cmp(resource.MustParse("1Gi")) != 0 || got.Limits.Memory().Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Fatalf("expected memory to be left unchanged, got request %s limit %s", got.Requests.Memory(), got.Limits.Memory())
+	}
+	if got.Requests.Cpu().MilliValue() != 300 {
+		t.Fatalf("expected CPU to still be resized, got %s", got.Requests.Cpu())
+	}
+	hugepages := got.Limits["hugepages-2Mi"]
+	if hugepages.Cmp(resource.MustParse("128Mi")) != 0 {
+		t.Fatalf("expected hugepages to be kept, got %s", hugepages.String())
+	}
+}
+
+func TestPodSpecialMemory(t *testing.T) {
+	if mode := podSpecialMemory(nil); mode != SpecialMemoryFloor {
+		t.Fatalf("expected floor by default, got %s", mode)
+	}
+	policy := &v1alpha1.RightSizerPolicy{}
+	policy.Spec.ResourceStrategy.Memory.SpecialMemory = SpecialMemorySkip
+	if mode := podSpecialMemory([]*v1alpha1.RightSizerPolicy{policy}); mode != SpecialMemorySkip {
+		t.Fatalf("expected the policy's skip mode, got %s", mode)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// startupGrace reports whether a pod is still starting up, and why. A pod is
+// starting until its startup probes have passed, it is ready, and it has been
+// running for minAge: before that its usage reflects warm-up rather than
+// load, and sizing from it would shrink the pod right after it starts.
+func startupGrace(pod *corev1.Pod, minAge time.Duration, now time.Time) (string, bool) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Started != nil && !*status.Started {
+			return fmt.Sprintf("container %s has not passed its startup probe", status.Name), true
+		}
+	}
+	if !podReady(pod) {
+		return "pod is not ready", true
+	}
+
+	started := pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		started = pod.Status.StartTime.Time
+	}
+	if age := now.Sub(started); !started.IsZero() && age < minAge {
+		return fmt.Sprintf("running for %s of %s", age.Round(time.Second), minAge), true
+	}
+	return "", false
+}
+
+// podReady reports whether the pod is ready. Pods without a Ready condition,
+// whose readiness is not reported yet, are not held back.
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return true
+}
+
+// minPodAge returns how long pods selected by the given policies must run
+// before they are analyzed: the effective policy's minPodAge when set, else
+// the global one
+func minPodAge(policies []*v1alpha1.RightSizerPolicy) time.Duration {
+	if len(policies) > 0 {
+		if value := mergePolicies(policies).Spec.Constraints.MinPodAge; value != "" {
+			age, err := time.ParseDuration(value)
+			if err == nil && age >= 0 {
+				return age
+			}
+			logger.Warn("Invalid minPodAge %q in RightSizerPolicy %s, using the global value", value, policies[0].Name)
+		}
+	}
+	return config.Get().MinPodAge
+}
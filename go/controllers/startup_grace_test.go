@@ -0,0 +1,83 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func startingPod(age time.Duration, ready bool, started bool) *corev1.Pod {
+	now := time.Now()
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status: corev1.PodStatus{
+			StartTime:         &metav1.Time{Time: now.Add(-age)},
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Started: &started}},
+		},
+	}
+}
+
+func TestStartupGrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		starting bool
+	}{
+		{"settled", startingPod(10*time.Minute, true, true), false},
+		{"too young", startingPod(time.Minute, true, true), true},
+		{"not ready", startingPod(10*time.Minute, false, true), true},
+		{"startup probe pending", startingPod(10*time.Minute, false, false), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, starting := startupGrace(tt.pod, 5*time.Minute, time.Now())
+			if starting != tt.starting {
+				t.Errorf("expected starting=%v, got %v (%s)", tt.starting, starting, reason)
+			}
+		})
+	}
+}
+
+func TestMinPodAgePolicyOverride(t *testing.T) {
+	if got := minPodAge(nil); got != config.Get().MinPodAge {
+		t.Errorf("expected the global minimum age %s, got %s", config.Get().MinPodAge, got)
+	}
+
+	strict := &v1alpha1.RightSizerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "slow-start"}}
+	strict.Spec.Constraints.MinPodAge = "30m"
+	unset := &v1alpha1.RightSizerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "defaults"}}
+	if got := minPodAge([]*v1alpha1.RightSizerPolicy{unset, strict}); got != 30*time.Minute {
+		t.Errorf("expected the merged policy's 30m, got %s", got)
+	}
+
+	invalid := &v1alpha1.RightSizerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "invalid"}}
+	invalid.Spec.Constraints.MinPodAge = "soon"
+	if got := minPodAge([]*v1alpha1.RightSizerPolicy{invalid}); got != config.Get().MinPodAge {
+		t.Errorf("expected an invalid value to fall back to the global age, got %s", got)
+	}
+}
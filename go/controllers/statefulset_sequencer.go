@@ -0,0 +1,203 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"right-sizer/logger"
+	"right-sizer/metrics"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ordinalOrderAnnotation makes a StatefulSet's pods resize from the highest
+// ordinal down, for workloads whose leader runs on ordinal 0 and should go last
+const ordinalOrderAnnotation = "rightsizer.io/ordinal-order"
+
+// StatefulSetSequencer resizes the pods of a StatefulSet one ordinal at a
+// time. Replicas of stateful workloads often hold quorum or leadership, so
+// resizing them as an unordered batch can take several down together; instead
+// each run resizes only the next ordinal, and only once every replica is ready
+// and no earlier resize is still in flight.
+type StatefulSetSequencer struct {
+	Client  client.Reader
+	Metrics *metrics.OperatorMetrics
+}
+
+// statefulSetSequence is the updates of one StatefulSet, by ordinal
+type statefulSetSequence struct {
+	namespace  string
+	name       string
+	descending bool
+	ordinals   map[int][]ResourceUpdate
+}
+
+// Sequence returns the updates with those of each StatefulSet reduced to its
+// next ordinal. Updates of other pods pass through.
+func (s *StatefulSetSequencer) Sequence(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	result := updates[:0:0]
+	sequences := make(map[string]*statefulSetSequence)
+	var order []string
+	for _, update := range updates {
+		pod := podsByName[update.Namespace+"/"+update.Name]
+		owner := statefulSetOwner(pod)
+		ordinal, ok := podOrdinal(update.Name, owner)
+		if owner == "" || !ok {
+			result = append(result, update)
+			continue
+		}
+		key := update.Namespace + "/" + owner
+		sequence, ok := sequences[key]
+		if !ok {
+			sequence = &statefulSetSequence{
+				namespace: update.Namespace,
+				name:      owner,
+				ordinals:  make(map[int][]ResourceUpdate),
+			}
+			sequences[key] = sequence
+			order = append(order, key)
+		}
+		if strings.EqualFold(pod.Annotations[ordinalOrderAnnotation], "descending") {
+			sequence.descending = true
+		}
+		sequence.ordinals[ordinal] = append(sequence.ordinals[ordinal], update)
+	}
+
+	for _, key := range order {
+		sequence := sequences[key]
+		held := 0
+		for _, ordinalUpdates := range sequence.ordinals {
+			held += len(ordinalUpdates)
+		}
+
+		if reason := s.notSettled(ctx, sequence, pods); reason != "" {
+			logger.Info("Holding %d resize(s) of StatefulSet %s/%s: %s", held, sequence.namespace, sequence.name, reason)
+			s.recordHeld(sequence.namespace, held)
+			continue
+		}
+
+		ordinals := make([]int, 0, len(sequence.ordinals))
+		for ordinal := range sequence.ordinals {
+			ordinals = append(ordinals, ordinal)
+		}
+		sort.Ints(ordinals)
+		next := ordinals[0]
+		if sequence.descending {
+			next = ordinals[len(ordinals)-1]
+		}
+		result = append(result, sequence.ordinals[next]...)
+		if held -= len(sequence.ordinals[next]); held > 0 {
+			logger.Debug("Resizing ordinal %d of StatefulSet %s/%s, holding %d resize(s) of later ordinals",
+				next, sequence.namespace, sequence.name, held)
+			s.recordHeld(sequence.namespace, held)
+		}
+	}
+	return result
+}
+
+// notSettled returns why the StatefulSet is not ready for its next ordinal to
+// be resized, or "" when every replica is ready and none is still resizing
+func (s *StatefulSetSequencer) notSettled(ctx context.Context, sequence *statefulSetSequence, pods []corev1.Pod) string {
+	running := 0
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Namespace != sequence.namespace || statefulSetOwner(pod) != sequence.name {
+			continue
+		}
+		if !pod.DeletionTimestamp.IsZero() {
+			return "pod " + pod.Name + " is terminating"
+		}
+		if pod.Status.Phase != corev1.PodRunning || !podReady(pod) {
+			return "pod " + pod.Name + " is not ready"
+		}
+		if resizeInFlight(pod) {
+			return "pod " + pod.Name + " is still resizing"
+		}
+		running++
+	}
+
+	if s.Client == nil {
+		return ""
+	}
+	var sts appsv1.StatefulSet
+	if err := s.Client.Get(ctx, types.NamespacedName{Namespace: sequence.namespace, Name: sequence.name}, &sts); err != nil {
+		logger.Debug("Could not get StatefulSet %s/%s: %v", sequence.namespace, sequence.name, err)
+		return ""
+	}
+	if desired := statefulSetReplicas(&sts); running < desired {
+		return strconv.Itoa(running) + " of " + strconv.Itoa(desired) + " replicas are ready"
+	}
+	return ""
+}
+
+func (s *StatefulSetSequencer) recordHeld(namespace string, count int) {
+	if s.Metrics == nil {
+		return
+	}
+	for range count {
+		s.Metrics.RecordSuppressedResize(namespace, "statefulset_ordinal")
+	}
+}
+
+// statefulSetOwner returns the name of the StatefulSet controlling the pod, or ""
+func statefulSetOwner(pod *corev1.Pod) string {
+	if pod == nil {
+		return ""
+	}
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller && ref.Kind == "StatefulSet" {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// podOrdinal parses the ordinal a StatefulSet appends to its pod names
+func podOrdinal(podName, statefulSet string) (int, bool) {
+	suffix, ok := strings.CutPrefix(podName, statefulSet+"-")
+	if !ok {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+// statefulSetReplicas returns the replicas the StatefulSet wants, defaulting to one
+func statefulSetReplicas(sts *appsv1.StatefulSet) int {
+	if sts.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*sts.Spec.Replicas)
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// statefulSetPods returns ready running pods db-0 through db-(n-1) owned by StatefulSet db
+func statefulSetPods(n int) []corev1.Pod {
+	controller := true
+	pods := make([]corev1.Pod, 0, n)
+	for i := range n {
+		pods = append(pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("db-%d", i),
+				Namespace:       "default",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+			},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		})
+	}
+	return pods
+}
+
+func newSequencer(replicas int32) *StatefulSetSequencer {
+	scheme := runtime.NewScheme()
+	_ = appsv1.AddToScheme(scheme)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}
+	return &StatefulSetSequencer{Client: ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(sts).Build()}
+}
+
+// TestStatefulSetSequencerOneOrdinalAtATime verifies only the lowest ordinal
+// is resized while other pods pass through
+func TestStatefulSetSequencerOneOrdinalAtATime(t *testing.T) {
+	pods := statefulSetPods(3)
+	updates := []ResourceUpdate{
+		requestUpdate("db-2", "200m", "256Mi"),
+		requestUpdate("db-1", "200m", "256Mi"),
+		requestUpdate("web", "200m", "256Mi"),
+	}
+
+	got := newSequencer(3).Sequence(context.Background(), updates, pods)
+	if names := updateNames(got); len(names) != 2 || names[0] != "web" || names[1] != "db-1" {
+		t.Fatalf("expected web and the lowest ordinal db-1, got %v", names)
+	}
+}
+
+// TestStatefulSetSequencerDescending verifies the annotation reverses the order
+func TestStatefulSetSequencerDescending(t *testing.T) {
+	pods := statefulSetPods(3)
+	for i := range pods {
+		pods[i].Annotations = map[string]string{ordinalOrderAnnotation: "descending"}
+	}
+	updates := []ResourceUpdate{
+		requestUpdate("db-0", "200m", "256Mi"),
+		requestUpdate("db-2", "200m", "256Mi"),
+		requestUpdate("db-1", "200m", "256Mi"),
+	}
+
+	got := newSequencer(3).Sequence(context.Background(), updates, pods)
+	if names := updateNames(got); len(names) != 1 || names[0] != "db-2" {
+		t.Fatalf("expected only the highest ordinal db-2, got %v", names)
+	}
+}
+
+// TestStatefulSetSequencerWaitsForReady verifies nothing is resized while a
+// replica is not ready, still resizing or missing
+func TestStatefulSetSequencerWaitsForReady(t *testing.T) {
+	updates := []ResourceUpdate{requestUpdate("db-1", "200m", "256Mi")}
+
+	pods := statefulSetPods(3)
+	pods[0].Status.Conditions[0].Status = corev1.ConditionFalse
+	if got := newSequencer(3).Sequence(context.Background(), updates, pods); len(got) != 0 {
+		t.Errorf("expected resizes to wait for db-0 to be ready, got %v", updateNames(got))
+	}
+
+	pods = statefulSetPods(3)
+	pods[0].Status.Conditions = append(pods[0].Status.Conditions, corev1.PodCondition{Type: corev1.PodResizeInProgress, Status: corev1.ConditionTrue})
+	if got := newSequencer(3).Sequence(context.Background(), updates, pods); len(got) != 0 {
+		t.Errorf("expected resizes to wait for db-0 to finish resizing, got %v", updateNames(got))
+	}
+
+	pods = statefulSetPods(2)
+	if got := newSequencer(3).Sequence(context.Background(), updates, pods); len(got) != 0 {
+		t.Errorf("expected resizes to wait for the missing replica, got %v", updateNames(got))
+	}
+}
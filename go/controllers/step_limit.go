@@ -0,0 +1,108 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"fmt"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// stepLimits bounds how far a request or limit moves in one resize, in
+// percent of its current value; 0 for no limit
+type stepLimits struct {
+	Up   int
+	Down int
+}
+
+// String describes the limits for decision explanations
+func (s stepLimits) String() string {
+	describe := func(percent int) string {
+		if percent == 0 {
+			return "unlimited"
+		}
+		return fmt.Sprintf("%d%%", percent)
+	}
+	return fmt.Sprintf("changes per resize limited to +%s/-%s", describe(s.Up), describe(s.Down))
+}
+
+// podStepLimits returns the step limits of pods selected by the given
+// policies: the global maxChangePercentage, overridden by the effective
+// policy's maxChangePercentage and, for increases, its maxScaleUpPercentage
+func podStepLimits(policies []*v1alpha1.RightSizerPolicy, cfg *config.Config) stepLimits {
+	limits := stepLimits{Up: cfg.MaxStepPercent, Down: cfg.MaxStepPercent}
+	if len(policies) == 0 {
+		return limits
+	}
+	constraints := mergePolicies(policies).Spec.Constraints
+	if constraints.MaxChangePercentage != nil {
+		limits.Up = int(*constraints.MaxChangePercentage)
+		limits.Down = int(*constraints.MaxChangePercentage)
+	}
+	if constraints.MaxScaleUpPercentage != nil {
+		limits.Up = int(*constraints.MaxScaleUpPercentage)
+	}
+	return limits
+}
+
+// limitStep moves each request and limit of proposed no further from its
+// current value than the step limits allow, so a single decision cannot
+// halve or double a container and sizing converges over several runs.
+// Resources that are not set yet are left alone, and limits are kept at or
+// above requests.
+func limitStep(current, proposed corev1.ResourceRequirements, limits stepLimits) corev1.ResourceRequirements {
+	if limits.Up == 0 && limits.Down == 0 {
+		return proposed
+	}
+
+	result := *proposed.DeepCopy()
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if quantity, ok := result.Requests[name]; ok {
+			result.Requests[name] = clampStep(current.Requests[name], quantity, name, limits)
+		}
+		if quantity, ok := result.Limits[name]; ok {
+			limit := clampStep(current.Limits[name], quantity, name, limits)
+			if request, ok := result.Requests[name]; ok && limit.Cmp(request) < 0 {
+				limit = request
+			}
+			result.Limits[name] = limit
+		}
+	}
+	return result
+}
+
+// clampStep bounds proposed to the step limits around current
+func clampStep(current, proposed resource.Quantity, name corev1.ResourceName, limits stepLimits) resource.Quantity {
+	value, target := current.MilliValue(), proposed.MilliValue()
+	if value <= 0 {
+		return proposed
+	}
+	if limits.Up > 0 && target > value+value*int64(limits.Up)/100 {
+		target = value + value*int64(limits.Up)/100
+	} else if limits.Down > 0 && target < value-value*int64(limits.Down)/100 {
+		target = value - value*int64(limits.Down)/100
+	} else {
+		return proposed
+	}
+	if name == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(target, resource.DecimalSI)
+	}
+	return *resource.NewQuantity(target/1000, resource.BinarySI)
+}
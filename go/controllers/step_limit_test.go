@@ -0,0 +1,78 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLimitStep(t *testing.T) {
+	current := profileResources("400m", "1Gi", "800m", "2Gi")
+	proposed := profileResources("1", "256Mi", "2", "512Mi")
+
+	limited := limitStep(current, proposed, stepLimits{Up: 25, Down: 25})
+	if got := limited.Requests.Cpu().String(); got != "500m" {
+		t.Errorf("expected the CPU request to grow by 25%% to 500m, got %s", got)
+	}
+	if got := limited.Limits.Cpu().String(); got != "1" {
+		t.Errorf("expected the CPU limit to grow by 25%% to 1, got %s", got)
+	}
+	if got := limited.Requests.Memory().String(); got != "768Mi" {
+		t.Errorf("expected the memory request to shrink by 25%% to 768Mi, got %s", got)
+	}
+	if got := limited.Limits.Memory().String(); got != "1536Mi" {
+		t.Errorf("expected the memory limit to shrink by 25%% to 1536Mi, got %s", got)
+	}
+
+	// Changes within the limits and unset resources are kept as proposed
+	small := profileResources("450m", "900Mi", "900m", "1800Mi")
+	if got := limitStep(current, small, stepLimits{Up: 25, Down: 25}); !resourcesEqual(got, small) {
+		t.Errorf("expected a small change to pass, got %v", got)
+	}
+	if got := limitStep(corev1.ResourceRequirements{}, proposed, stepLimits{Up: 25, Down: 25}); !resourcesEqual(got, proposed) {
+		t.Errorf("expected a container without resources to be sized as proposed, got %v", got)
+	}
+	if got := limitStep(current, proposed, stepLimits{}); !resourcesEqual(got, proposed) {
+		t.Errorf("expected no limit to change nothing, got %v", got)
+	}
+}
+
+func TestPodStepLimits(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.SetMaxStepPercent(25)
+	if got := podStepLimits(nil, cfg); got != (stepLimits{Up: 25, Down: 25}) {
+		t.Errorf("expected the global limit, got %+v", got)
+	}
+
+	scaleUp := int32(300)
+	policy := &v1alpha1.RightSizerPolicy{ObjectMeta: metav1.ObjectMeta{Name: "critical"}}
+	policy.Spec.Constraints.MaxScaleUpPercentage = &scaleUp
+	if got := podStepLimits([]*v1alpha1.RightSizerPolicy{policy}, cfg); got != (stepLimits{Up: 300, Down: 25}) {
+		t.Errorf("expected the policy to override scale-ups only, got %+v", got)
+	}
+
+	change := int32(10)
+	policy.Spec.Constraints.MaxChangePercentage = &change
+	if got := podStepLimits([]*v1alpha1.RightSizerPolicy{policy}, cfg); got != (stepLimits{Up: 300, Down: 10}) {
+		t.Errorf("expected the policy's change limit for scale-downs, got %+v", got)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Priority bands of pending updates. Every update in a higher band is applied
+// before any update in a lower one; within a band the weight, below
+// priorityBand, orders them.
+const (
+	priorityScaleDown = 0
+	priorityUpsize    = 1 * priorityBand
+	priorityThrottled = 2 * priorityBand
+	priorityOOMRisk   = 3 * priorityBand
+	priorityBand      = 1000
+)
+
+// oomRiskRatio is the memory usage, as a fraction of the current memory limit,
+// from which a container is considered at risk of being OOM-killed
+const oomRiskRatio = 0.9
+
+// defaultThrottlePriority is the throttling percentage from which a container
+// is considered heavily throttled when throttle-based scaling is disabled
+const defaultThrottlePriority = 25
+
+// prioritizeUpdates orders updates most urgent first, keeping the original
+// order between equally urgent ones
+func prioritizeUpdates(updates []ResourceUpdate, throttleThreshold float64) {
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updatePriority(updates[i], throttleThreshold) > updatePriority(updates[j], throttleThreshold)
+	})
+}
+
+// mostUrgentUpdates keeps the limit most urgent updates, in their current
+// order. Updates spread across nodes are no longer in priority order, so
+// cutting the list short would favour quiet nodes over urgent resizes.
+func mostUrgentUpdates(updates []ResourceUpdate, limit int, throttleThreshold float64) []ResourceUpdate {
+	if limit < 1 || len(updates) <= limit {
+		return updates
+	}
+	ranked := make([]int, len(updates))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return updatePriority(updates[ranked[i]], throttleThreshold) > updatePriority(updates[ranked[j]], throttleThreshold)
+	})
+	keep := make(map[int]bool, limit)
+	for _, i := range ranked[:limit] {
+		keep[i] = true
+	}
+	kept := make([]ResourceUpdate, 0, limit)
+	for i, update := range updates {
+		if keep[i] {
+			kept = append(kept, update)
+		}
+	}
+	return kept
+}
+
+// updatePriority scores an update: containers close to their memory limit
+// first, by how close; then heavily throttled containers, by how throttled;
+// then other upsizes, by how much they grow; and scale-downs last, by how
+// much they free
+func updatePriority(update ResourceUpdate, throttleThreshold float64) float64 {
+	if limitMB := memoryBoundMB(update.OldResources); limitMB > 0 {
+		if ratio := update.Usage.MemMB / limitMB; ratio >= oomRiskRatio {
+			return priorityOOMRisk + bandWeight(ratio*100)
+		}
+	}
+
+	if throttleThreshold <= 0 {
+		throttleThreshold = defaultThrottlePriority
+	}
+	if update.Usage.CPUThrottled >= throttleThreshold {
+		return priorityThrottled + bandWeight(update.Usage.CPUThrottled*10)
+	}
+
+	growth := requestGrowth(update.OldResources, update.NewResources)
+	if growth > 0 {
+		return priorityUpsize + bandWeight(growth*100)
+	}
+	return priorityScaleDown + bandWeight(-growth*100)
+}
+
+// bandWeight bounds a weight to its priority band
+func bandWeight(weight float64) float64 {
+	return min(max(weight, 0), priorityBand-1)
+}
+
+// memoryBoundMB returns the memory limit, or the request without a limit, in MB
+func memoryBoundMB(resources corev1.ResourceRequirements) float64 {
+	if limit, ok := resources.Limits[corev1.ResourceMemory]; ok && !limit.IsZero() {
+		return float64(limit.Value()) / (1024 * 1024)
+	}
+	if request, ok := resources.Requests[corev1.ResourceMemory]; ok && !request.IsZero() {
+		return float64(request.Value()) / (1024 * 1024)
+	}
+	return 0
+}
+
+// requestGrowth returns the largest relative growth of the CPU or memory
+// request, negative when both shrink
+func requestGrowth(old, new corev1.ResourceRequirements) float64 {
+	growth, found := 0.0, false
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		before, ok := old.Requests[name]
+		after, ok2 := new.Requests[name]
+		if !ok || !ok2 || before.IsZero() {
+			continue
+		}
+		change := float64(after.MilliValue()-before.MilliValue()) / float64(before.MilliValue())
+		if !found || change > growth {
+			growth, found = change, true
+		}
+	}
+	return growth
+}
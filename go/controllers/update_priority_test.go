@@ -0,0 +1,101 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func priorityUpdate(name, oldCPU, oldMem, newCPU, newMem string, usage metrics.Metrics) ResourceUpdate {
+	return ResourceUpdate{
+		Namespace:    "default",
+		Name:         name,
+		ResourceType: "Pod",
+		OldResources: profileResources(oldCPU, oldMem, oldCPU, oldMem),
+		NewResources: profileResources(newCPU, newMem, newCPU, newMem),
+		Usage:        usage,
+	}
+}
+
+// TestPrioritizeUpdates verifies OOM risks come first, then throttled
+// containers, then upsizes by growth, with scale-downs last
+func TestPrioritizeUpdates(t *testing.T) {
+	updates := []ResourceUpdate{
+		priorityUpdate("shrink-small", "200m", "256Mi", "180m", "256Mi", metrics.Metrics{CPUMilli: 50, MemMB: 64}),
+		priorityUpdate("grow-small", "100m", "256Mi", "120m", "256Mi", metrics.Metrics{CPUMilli: 100, MemMB: 64}),
+		priorityUpdate("shrink-large", "1", "1Gi", "200m", "256Mi", metrics.Metrics{CPUMilli: 50, MemMB: 64}),
+		priorityUpdate("throttled", "100m", "256Mi", "200m", "256Mi", metrics.Metrics{CPUMilli: 100, MemMB: 64, CPUThrottled: 60}),
+		priorityUpdate("grow-large", "100m", "256Mi", "300m", "256Mi", metrics.Metrics{CPUMilli: 250, MemMB: 64}),
+		priorityUpdate("oom-risk", "100m", "256Mi", "100m", "512Mi", metrics.Metrics{CPUMilli: 50, MemMB: 250}),
+		priorityUpdate("shrink-tie", "200m", "256Mi", "180m", "256Mi", metrics.Metrics{CPUMilli: 50, MemMB: 64}),
+	}
+
+	prioritizeUpdates(updates, 25)
+
+	var names []string
+	for _, update := range updates {
+		names = append(names, update.Name)
+	}
+	want := "oom-risk,throttled,grow-large,grow-small,shrink-large,shrink-small,shrink-tie"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("expected order %s, got %s", want, got)
+	}
+}
+
+// TestUpdatePriorityThrottleThreshold verifies the configured threshold decides
+// what counts as heavily throttled
+func TestUpdatePriorityThrottleThreshold(t *testing.T) {
+	update := priorityUpdate("web", "100m", "256Mi", "150m", "256Mi", metrics.Metrics{CPUThrottled: 30})
+	if got := updatePriority(update, 25); got < priorityThrottled {
+		t.Errorf("expected the throttled band at a 25%% threshold, got %.0f", got)
+	}
+	if got := updatePriority(update, 50); got >= priorityThrottled || got < priorityUpsize {
+		t.Errorf("expected the upsize band at a 50%% threshold, got %.0f", got)
+	}
+}
+
+// TestMostUrgentUpdatesAfterSpreading verifies the per-cycle budget keeps the
+// most urgent resizes of a busy node over scale-downs spread ahead of them
+func TestMostUrgentUpdatesAfterSpreading(t *testing.T) {
+	pods := []corev1.Pod{
+		podOnNode("oom-1", "busy", false),
+		podOnNode("oom-2", "busy", false),
+		podOnNode("quiet-1", "node-1", false),
+		podOnNode("quiet-2", "node-2", false),
+	}
+	oomRisk := metrics.Metrics{MemMB: 250}
+	updates := []ResourceUpdate{
+		priorityUpdate("quiet-1", "200m", "256Mi", "100m", "256Mi", metrics.Metrics{CPUMilli: 50}),
+		priorityUpdate("oom-1", "100m", "256Mi", "100m", "512Mi", oomRisk),
+		priorityUpdate("quiet-2", "200m", "256Mi", "100m", "256Mi", metrics.Metrics{CPUMilli: 50}),
+		priorityUpdate("oom-2", "100m", "256Mi", "100m", "512Mi", oomRisk),
+	}
+	prioritizeUpdates(updates, 0)
+	spread := (&NodeResizeSpreader{MaxPerNode: 2}).Spread(updates, pods)
+
+	var names []string
+	for _, update := range mostUrgentUpdates(spread, 3, 0) {
+		names = append(names, update.Name)
+	}
+	if got, want := strings.Join(names, ","), "oom-1,quiet-1,oom-2"; got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/explain"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+	"right-sizer/predictor"
+)
+
+// usageAggregation selects, per resource, how the usage history over the
+// window is reduced to the usage a container is sized from
+type usageAggregation struct {
+	CPU        string
+	Memory     string
+	Percentile int
+	Window     time.Duration
+}
+
+// String describes the aggregation for decision explanations
+func (a usageAggregation) String() string {
+	if a.CPU == a.Memory {
+		return a.CPU
+	}
+	return fmt.Sprintf("cpu %s, memory %s", a.CPU, a.Memory)
+}
+
+// windowed reports whether any resource is sized from its history
+func (a usageAggregation) windowed() bool {
+	return a.Window > 0 && (a.CPU != config.AggregationLatest || a.Memory != config.AggregationLatest)
+}
+
+// podUsageAggregation returns the usage aggregation of pods selected by the
+// given policies: the effective policy's per-resource aggregation, else the
+// global one
+func podUsageAggregation(policies []*v1alpha1.RightSizerPolicy, cfg *config.Config) usageAggregation {
+	aggregation := usageAggregation{
+		CPU:        cfg.UsageAggregation("cpu"),
+		Memory:     cfg.UsageAggregation("memory"),
+		Percentile: cfg.Percentile,
+		Window:     cfg.PercentileWindow,
+	}
+	if len(policies) == 0 {
+		return aggregation
+	}
+
+	strategy := mergePolicies(policies).Spec.ResourceStrategy
+	if config.ValidAggregation(strategy.CPU.Aggregation) {
+		aggregation.CPU = strategy.CPU.Aggregation
+	}
+	if config.ValidAggregation(strategy.Memory.Aggregation) {
+		aggregation.Memory = strategy.Memory.Aggregation
+	}
+	return aggregation
+}
+
+// podMemoryMetric returns the memory reading pods selected by the given
+// policies are sized from: the effective policy's, else the global one
+func podMemoryMetric(policies []*v1alpha1.RightSizerPolicy, cfg *config.Config) string {
+	if len(policies) > 0 {
+		if metric := mergePolicies(policies).Spec.ResourceStrategy.Memory.Metric; metrics.ValidMemoryMetric(metric) {
+			return metric
+		}
+	}
+	return cfg.MemoryMetric
+}
+
+// selectMemoryMetric sizes memory from the selected reading, keeping the
+// provider's memory usage when it does not report that reading
+func selectMemoryMetric(usage metrics.Metrics, metric string) metrics.Metrics {
+	if memory, ok := usage.Memory(metric); ok {
+		usage.MemMB = memory
+	}
+	return usage
+}
+
+// aggregateValues reduces usage values with the given method
+func aggregateValues(values []float64, method string, percentile int) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	switch method {
+	case config.AggregationAverage:
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	case config.AggregationMax:
+		peak := values[0]
+		for _, v := range values[1:] {
+			peak = max(peak, v)
+		}
+		return peak
+	case config.AggregationPercentile:
+		return predictor.Percentile(values, float64(percentile))
+	}
+	return values[len(values)-1]
+}
+
+// withLatest keeps a peak from falling below the latest sample, which the
+// history may not include yet
+func withLatest(value, latest float64, method string) float64 {
+	if method == config.AggregationMax {
+		return max(value, latest)
+	}
+	return value
+}
+
+// aggregateUsage replaces each resource of the latest usage sample with the
+// aggregation of the container's recorded history, falling back to the
+// metrics provider's history when it supports range queries. Resources
+// without history keep the latest sample so new containers are still sized.
+func (r *AdaptiveRightSizer) aggregateUsage(ctx context.Context, namespace, podName, containerName string, usage metrics.Metrics, aggregation usageAggregation) metrics.Metrics {
+	window := aggregation.Window
+	if window <= 0 {
+		return usage
+	}
+	skip := func(method string) bool {
+		return method == config.AggregationLatest || method == "" || (method == config.AggregationPercentile && aggregation.Percentile <= 0)
+	}
+
+	latest := usage
+	cpuFound, memFound := skip(aggregation.CPU), skip(aggregation.Memory)
+	if r.Predictor != nil {
+		if !cpuFound {
+			if cpu, samples, ok := r.storedAggregate(namespace, podName, containerName, "cpu", aggregation.CPU, aggregation); ok {
+				cpu = withLatest(cpu, latest.CPUMilli, aggregation.CPU)
+				logger.Debug("CPU %s for %s/%s/%s over %v: %.2f millicores (%d samples, latest %.2f)", aggregation.CPU, namespace, podName, containerName, window, cpu, samples, latest.CPUMilli)
+				usage.CPUMilli = cpu
+				cpuFound = true
+			}
+		}
+		if !memFound {
+			if mem, samples, ok := r.storedAggregate(namespace, podName, containerName, "memory", aggregation.Memory, aggregation); ok {
+				mem = withLatest(mem, latest.MemMB, aggregation.Memory)
+				logger.Debug("Memory %s for %s/%s/%s over %v: %.2f MB (%d samples, latest %.2f)", aggregation.Memory, namespace, podName, containerName, window, mem, samples, latest.MemMB)
+				usage.MemMB = mem
+				memFound = true
+			}
+		}
+	}
+	if cpuFound && memFound {
+		return usage
+	}
+
+	// Fall back to the metrics backend's own history, e.g. right after a restart
+	rangeProvider, ok := r.MetricsProvider.(metrics.RangeProvider)
+	if !ok {
+		return usage
+	}
+	if retention, err := config.ParseHistoryWindow(config.Get().HistoryRetention); err == nil && retention < window {
+		window = retention
+	}
+	end := time.Now()
+	history, err := rangeProvider.FetchContainerHistory(ctx, namespace, podName, containerName, end.Add(-window), end)
+	if err != nil {
+		logger.Debug("Failed to fetch usage history for %s/%s/%s: %v", namespace, podName, containerName, err)
+		return usage
+	}
+	if !cpuFound && len(history.CPUMilli) > 0 {
+		usage.CPUMilli = withLatest(aggregateValues(sampleValues(history.CPUMilli), aggregation.CPU, aggregation.Percentile), latest.CPUMilli, aggregation.CPU)
+		logger.Debug("CPU %s for %s/%s/%s from provider history: %.2f millicores (%d samples)", aggregation.CPU, namespace, podName, containerName, usage.CPUMilli, len(history.CPUMilli))
+	}
+	if !memFound && len(history.MemMB) > 0 {
+		usage.MemMB = withLatest(aggregateValues(sampleValues(history.MemMB), aggregation.Memory, aggregation.Percentile), latest.MemMB, aggregation.Memory)
+		logger.Debug("Memory %s for %s/%s/%s from provider history: %.2f MB (%d samples)", aggregation.Memory, namespace, podName, containerName, usage.MemMB, len(history.MemMB))
+	}
+	return usage
+}
+
+// storedAggregate aggregates the usage history kept by the prediction
+// engine. Percentiles need the engine's minimum number of samples; the
+// average and peak use whatever history there is.
+func (r *AdaptiveRightSizer) storedAggregate(namespace, podName, containerName, resourceType, method string, aggregation usageAggregation) (float64, int, bool) {
+	if method == config.AggregationPercentile {
+		value, samples, err := r.Predictor.GetPercentile(namespace, podName, containerName, resourceType, float64(aggregation.Percentile), aggregation.Window)
+		return value, samples, err == nil
+	}
+
+	history, err := r.Predictor.GetHistoricalData(namespace, podName, containerName, resourceType, time.Now().Add(-aggregation.Window))
+	if err != nil || len(history.DataPoints) == 0 {
+		return 0, 0, false
+	}
+	values := make([]float64, len(history.DataPoints))
+	for i, point := range history.DataPoints {
+		values[i] = point.Value
+	}
+	return aggregateValues(values, method, aggregation.Percentile), len(values), true
+}
+
+// sampleValues returns the values of the samples
+func sampleValues(samples []metrics.Sample) []float64 {
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.Value
+	}
+	return values
+}
+
+// windowUsage sizes from the aggregated usage history and records the window
+// on the decision explanation
+func (r *AdaptiveRightSizer) windowUsage(ctx context.Context, namespace, podName, containerName string, usage metrics.Metrics, aggregation usageAggregation, explanation *explain.Decision) metrics.Metrics {
+	if !aggregation.windowed() {
+		return usage
+	}
+	usage = r.aggregateUsage(ctx, namespace, podName, containerName, usage, aggregation)
+	if explanation != nil {
+		explanation.Window.Algorithm = aggregation.String()
+		explanation.Window.Percentile = aggregation.Percentile
+		explanation.Window.Duration = aggregation.Window.String()
+		explanation.Usage = explanationUsage(usage)
+	}
+	return usage
+}
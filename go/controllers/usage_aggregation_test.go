@@ -0,0 +1,164 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/metrics"
+	"right-sizer/predictor"
+)
+
+// TestPodUsageAggregation verifies policies override the global per-resource aggregation
+func TestPodUsageAggregation(t *testing.T) {
+	cfg := config.GetDefaults()
+
+	got := podUsageAggregation(nil, cfg)
+	if got.CPU != config.AggregationPercentile || got.Memory != config.AggregationMax {
+		t.Fatalf("expected percentile CPU and peak memory by default, got %+v", got)
+	}
+	if got.String() != "cpu percentile, memory max" {
+		t.Errorf("unexpected description %q", got.String())
+	}
+
+	policy := &v1alpha1.RightSizerPolicy{}
+	policy.Spec.ResourceStrategy.CPU.Aggregation = config.AggregationAverage
+	got = podUsageAggregation([]*v1alpha1.RightSizerPolicy{policy}, cfg)
+	if got.CPU != config.AggregationAverage || got.Memory != config.AggregationMax {
+		t.Fatalf("expected the policy's CPU aggregation, got %+v", got)
+	}
+}
+
+// TestPodMemoryMetric verifies policies override the global memory metric
+// and an unreported reading keeps the provider's memory usage
+func TestPodMemoryMetric(t *testing.T) {
+	cfg := config.GetDefaults()
+	cfg.SetMemoryMetric(metrics.MemoryMetricWorkingSet)
+
+	policy := &v1alpha1.RightSizerPolicy{}
+	if got := podMemoryMetric([]*v1alpha1.RightSizerPolicy{policy}, cfg); got != metrics.MemoryMetricWorkingSet {
+		t.Fatalf("expected the global working set, got %q", got)
+	}
+	policy.Spec.ResourceStrategy.Memory.Metric = metrics.MemoryMetricRSS
+	if got := podMemoryMetric([]*v1alpha1.RightSizerPolicy{policy}, cfg); got != metrics.MemoryMetricRSS {
+		t.Fatalf("expected the policy's RSS, got %q", got)
+	}
+
+	usage := metrics.Metrics{MemMB: 1024, MemWorkingSetMB: 512, MemRSSMB: 256}
+	if got := selectMemoryMetric(usage, metrics.MemoryMetricRSS); got.MemMB != 256 {
+		t.Errorf("expected memory sized from the RSS, got %+v", got)
+	}
+	if got := selectMemoryMetric(metrics.Metrics{MemMB: 1024}, metrics.MemoryMetricRSS); got.MemMB != 1024 {
+		t.Errorf("expected the memory usage without an RSS reading, got %+v", got)
+	}
+}
+
+// TestAggregateValues verifies each aggregation method
+func TestAggregateValues(t *testing.T) {
+	values := []float64{10, 40, 20, 30}
+	tests := map[string]float64{
+		config.AggregationLatest:  30,
+		config.AggregationAverage: 25,
+		config.AggregationMax:     40,
+	}
+	for method, want := range tests {
+		if got := aggregateValues(values, method, 95); got != want {
+			t.Errorf("%s: expected %v, got %v", method, want, got)
+		}
+	}
+	if got := aggregateValues(nil, config.AggregationMax, 95); got != 0 {
+		t.Errorf("expected 0 without values, got %v", got)
+	}
+}
+
+// TestAggregateUsagePeakMemory verifies memory is sized to its peak over the window
+func TestAggregateUsagePeakMemory(t *testing.T) {
+	engine, err := predictor.NewEngine(predictor.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Predictor = engine
+
+	now := time.Now()
+	for i, mem := range []float64{100, 400, 150} {
+		ts := now.Add(-time.Duration(i+1) * time.Minute)
+		_ = engine.StoreDataPoint("ns", "pod", "app", "cpu", float64((i+1)*100), ts)
+		_ = engine.StoreDataPoint("ns", "pod", "app", "memory", mem, ts)
+	}
+	// A spike older than the window is ignored
+	_ = engine.StoreDataPoint("ns", "pod", "app", "memory", 900, now.Add(-2*time.Hour))
+
+	aggregation := usageAggregation{CPU: config.AggregationAverage, Memory: config.AggregationMax, Window: time.Hour}
+	got := r.aggregateUsage(context.Background(), "ns", "pod", "app", metrics.Metrics{CPUMilli: 50, MemMB: 120}, aggregation)
+	if got.CPUMilli != 200 || got.MemMB != 400 {
+		t.Fatalf("expected average CPU (200m) and peak memory (400MB), got %+v", got)
+	}
+
+	// The latest sample counts towards the peak
+	got = r.aggregateUsage(context.Background(), "ns", "pod", "app", metrics.Metrics{CPUMilli: 50, MemMB: 500}, aggregation)
+	if got.MemMB != 500 {
+		t.Fatalf("expected the latest sample as the peak, got %+v", got)
+	}
+}
+
+// TestAggregateUsageFromProviderHistory verifies the peak falls back to the provider's history
+func TestAggregateUsageFromProviderHistory(t *testing.T) {
+	var history metrics.ContainerHistory
+	now := time.Now()
+	for i, mem := range []float64{100, 300, 200} {
+		history.MemMB = append(history.MemMB, metrics.Sample{Timestamp: now.Add(-time.Duration(i+1) * time.Minute), Value: mem})
+	}
+
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.MetricsProvider = &fakeRangeProvider{history: history}
+	aggregation := usageAggregation{CPU: config.AggregationLatest, Memory: config.AggregationMax, Window: time.Hour}
+	got := r.aggregateUsage(context.Background(), "ns", "pod", "app", metrics.Metrics{CPUMilli: 50, MemMB: 120}, aggregation)
+	if got.CPUMilli != 50 || got.MemMB != 300 {
+		t.Fatalf("expected latest CPU and peak memory from provider history, got %+v", got)
+	}
+}
+
+// TestPolicyUsageAcrossPods verifies workload policies size memory to the peak across replicas
+func TestPolicyUsageAcrossPods(t *testing.T) {
+	engine, err := predictor.NewEngine(predictor.DefaultConfig())
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	now := time.Now()
+	for i, pod := range []string{"web-1", "web-2"} {
+		_ = engine.StoreDataPoint("ns", pod, "app", "cpu", float64((i+1)*100), now.Add(-time.Minute))
+		_ = engine.StoreDataPoint("ns", pod, "app", "memory", float64((i+1)*200), now.Add(-time.Minute))
+	}
+
+	r := &RightSizerPolicyReconciler{Config: config.GetDefaults(), Predictor: engine}
+	policy := &v1alpha1.RightSizerPolicy{}
+	current := metrics.Metrics{CPUMilli: 150, MemMB: 300}
+	got := r.percentileUsageFromPolicy(policy, "ns", []string{"web-1", "web-2"}, "app", current)
+	if got.CPUMilli != 150 || got.MemMB != 400 {
+		t.Fatalf("expected average CPU and peak memory (400MB), got %+v", got)
+	}
+
+	policy.Spec.ResourceStrategy.CPU.Aggregation = config.AggregationMax
+	got = r.percentileUsageFromPolicy(policy, "ns", []string{"web-1", "web-2"}, "app", current)
+	if got.CPUMilli != 200 {
+		t.Fatalf("expected the policy's peak CPU (200m), got %+v", got)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// vpaListGVK identifies lists of VerticalPodAutoscalers, read without the
+// VPA client so the operator runs where the VPA is not installed
+var vpaListGVK = schema.GroupVersionKind{Group: "autoscaling.k8s.io", Version: "v1", Kind: "VerticalPodAutoscalerList"}
+
+// verticalPodAutoscaler is the part of a VPA the operator reads
+type verticalPodAutoscaler struct {
+	Name    string
+	Targets map[string]corev1.ResourceList // container -> recommended requests
+}
+
+// listVPAs returns the VerticalPodAutoscalers of the cluster keyed by the
+// namespace, kind and name of the workload they target. None are returned
+// when the VPA CRDs are not installed.
+func listVPAs(ctx context.Context, c client.Client) (map[string]*verticalPodAutoscaler, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(vpaListGVK)
+	if err := c.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	vpas := make(map[string]*verticalPodAutoscaler, len(list.Items))
+	for _, item := range list.Items {
+		// VPAs exported by the operator only carry its own recommendations
+		if managedByRightSizer(&item) {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(item.Object, "spec", "targetRef", "kind")
+		name, _, _ := unstructured.NestedString(item.Object, "spec", "targetRef", "name")
+		if kind == "" || name == "" {
+			continue
+		}
+		vpa := &verticalPodAutoscaler{Name: item.GetName(), Targets: make(map[string]corev1.ResourceList)}
+		recommendations, _, _ := unstructured.NestedSlice(item.Object, "status", "recommendation", "containerRecommendations")
+		for _, entry := range recommendations {
+			recommendation, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			container, _, _ := unstructured.NestedString(recommendation, "containerName")
+			target, _, _ := unstructured.NestedStringMap(recommendation, "target")
+			list := corev1.ResourceList{}
+			for resourceName, value := range target {
+				if quantity, err := resource.ParseQuantity(value); err == nil {
+					list[corev1.ResourceName(resourceName)] = quantity
+				}
+			}
+			if container != "" && len(list) > 0 {
+				vpa.Targets[container] = list
+			}
+		}
+		vpas[item.GetNamespace()+"/"+kind+"/"+name] = vpa
+	}
+	return vpas, nil
+}
+
+// filterVPAManaged keeps the operator from managing workloads a
+// VerticalPodAutoscaler already sizes. In skip mode their updates are
+// dropped; in compare mode they are published as recommendations carrying
+// the VPA's target, when recommendations are written, but not applied.
+func (r *AdaptiveRightSizer) filterVPAManaged(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod, cfg *config.Config) []ResourceUpdate {
+	if len(updates) == 0 || cfg.VPAMode == config.VPAModeIgnore {
+		return updates
+	}
+	vpas, err := listVPAs(ctx, r.Client)
+	if err != nil {
+		logger.Warn("Failed to list VerticalPodAutoscalers: %v", err)
+		return updates
+	}
+	if len(vpas) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+	owners := make(map[string]string)
+
+	var compared []ResourceUpdate
+	result := updates[:0:0]
+	for _, update := range updates {
+		podKey := update.Namespace + "/" + update.Name
+		owner, ok := owners[podKey]
+		if !ok {
+			if pod, found := podsByName[podKey]; found {
+				target := resolveWorkloadRef(ctx, r.Client, pod)
+				owner = update.Namespace + "/" + target.Kind + "/" + target.Name
+			}
+			owners[podKey] = owner
+		}
+		vpa, managed := vpas[owner]
+		if !managed {
+			result = append(result, update)
+			continue
+		}
+
+		if r.OperatorMetrics != nil {
+			r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "vpa")
+		}
+		if cfg.VPAMode != config.VPAModeCompare {
+			logger.Debug("Skipping resize of %s/%s/%s: managed by VerticalPodAutoscaler %s", update.Namespace, update.Name, update.ContainerName, vpa.Name)
+			continue
+		}
+		update.VPATarget = vpa.Targets[update.ContainerName]
+		logger.Info("🔀 %s/%s/%s is managed by VerticalPodAutoscaler %s: right-sizer recommends %s, the VPA targets %s",
+			update.Namespace, update.Name, update.ContainerName, vpa.Name, describeRequests(update.NewResources.Requests), describeRequests(update.VPATarget))
+		compared = append(compared, update)
+	}
+
+	if len(compared) > 0 && r.Recommendations != nil {
+		if err := r.Recommendations.Write(ctx, compared, cfg); err != nil {
+			logger.Warn("Failed to write VPA comparisons: %v", err)
+		}
+	}
+	return result
+}
+
+// describeRequests formats the CPU and memory of a resource list for logs
+func describeRequests(list corev1.ResourceList) string {
+	if len(list) == 0 {
+		return "nothing yet"
+	}
+	return "CPU " + list.Cpu().String() + ", memory " + list.Memory().String()
+}
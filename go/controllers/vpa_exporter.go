@@ -0,0 +1,178 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers/status,verbs=get;update;patch
+
+// vpaRecommender names the recommender of exported VPAs. No VPA recommender
+// runs under this name, so the VPA's own recommender leaves them alone.
+const vpaRecommender = "right-sizer"
+
+// VPAExporter writes resize decisions into VerticalPodAutoscaler objects,
+// one per workload with updateMode Off, so tooling and dashboards built on
+// VPA recommendations keep working. The VPAs never change pods themselves.
+type VPAExporter struct {
+	Client client.Client
+}
+
+// vpaWorkload accumulates the container targets of one workload
+type vpaWorkload struct {
+	namespace  string
+	owner      v1alpha1.RecommendationTargetRef
+	containers []string
+	targets    map[string]corev1.ResourceList // container -> requests
+}
+
+// Export groups the updates by workload and creates or refreshes the VPA of
+// each of them
+func (e *VPAExporter) Export(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) error {
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	owners := make(map[string]v1alpha1.RecommendationTargetRef)
+	workloads := make(map[string]*vpaWorkload)
+	var keys []string
+	for _, update := range updates {
+		podKey := update.Namespace + "/" + update.Name
+		owner, ok := owners[podKey]
+		if !ok {
+			pod, found := podsByName[podKey]
+			if !found {
+				continue
+			}
+			owner = resolveWorkloadRef(ctx, e.Client, pod)
+			owners[podKey] = owner
+		}
+
+		// A VPA can only target a workload controller, not a bare pod
+		if owner.Kind == "Pod" {
+			continue
+		}
+		key := update.Namespace + "/" + recommendationName(owner)
+		wl, ok := workloads[key]
+		if !ok {
+			wl = &vpaWorkload{namespace: update.Namespace, owner: owner, targets: make(map[string]corev1.ResourceList)}
+			workloads[key] = wl
+			keys = append(keys, key)
+		}
+		// Replicas of the same workload may disagree; keep the larger target
+		if existing, ok := wl.targets[update.ContainerName]; ok {
+			wl.targets[update.ContainerName] = maxResourceList(existing, update.NewResources.Requests)
+			continue
+		}
+		wl.targets[update.ContainerName] = update.NewResources.Requests.DeepCopy()
+		wl.containers = append(wl.containers, update.ContainerName)
+	}
+
+	var errs []string
+	for _, key := range keys {
+		if err := e.upsert(ctx, workloads[key]); err != nil {
+			if meta.IsNoMatchError(err) {
+				return fmt.Errorf("VerticalPodAutoscaler CRDs are not installed: %w", err)
+			}
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to export %d VerticalPodAutoscalers: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// upsert creates the VPA of a workload when missing and writes the
+// recommendation to its status. VPAs the operator did not create are left alone.
+func (e *VPAExporter) upsert(ctx context.Context, wl *vpaWorkload) error {
+	key := types.NamespacedName{Namespace: wl.namespace, Name: "right-sizer-" + recommendationName(wl.owner)}
+
+	vpa := &unstructured.Unstructured{}
+	vpa.SetGroupVersionKind(vpaListGVK.GroupVersion().WithKind("VerticalPodAutoscaler"))
+	err := e.Client.Get(ctx, key, vpa)
+	if k8serrors.IsNotFound(err) {
+		vpa = newExportedVPA(key, wl.owner)
+		if err := e.Client.Create(ctx, vpa); err != nil {
+			return fmt.Errorf("failed to create VerticalPodAutoscaler %s: %w", key, err)
+		}
+	} else if err != nil {
+		return err
+	} else if !managedByRightSizer(vpa) {
+		logger.Warn("Not exporting to VerticalPodAutoscaler %s: it is not managed by right-sizer", key)
+		return nil
+	}
+
+	recommendations := make([]interface{}, 0, len(wl.containers))
+	for _, name := range wl.containers {
+		target := make(map[string]interface{}, len(wl.targets[name]))
+		for resourceName, quantity := range wl.targets[name] {
+			target[string(resourceName)] = quantity.String()
+		}
+		recommendations = append(recommendations, map[string]interface{}{
+			"containerName":  name,
+			"target":         target,
+			"uncappedTarget": target,
+		})
+	}
+	if err := unstructured.SetNestedSlice(vpa.Object, recommendations, "status", "recommendation", "containerRecommendations"); err != nil {
+		return err
+	}
+	if err := e.Client.Status().Update(ctx, vpa); err != nil {
+		return fmt.Errorf("failed to update VerticalPodAutoscaler %s: %w", key, err)
+	}
+	return nil
+}
+
+// newExportedVPA returns a VPA that only carries recommendations for a workload
+func newExportedVPA(key types.NamespacedName, owner v1alpha1.RecommendationTargetRef) *unstructured.Unstructured {
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": owner.APIVersion,
+				"kind":       owner.Kind,
+				"name":       owner.Name,
+			},
+			"updatePolicy": map[string]interface{}{"updateMode": "Off"},
+			"recommenders": []interface{}{map[string]interface{}{"name": vpaRecommender}},
+		},
+	}}
+	vpa.SetGroupVersionKind(vpaListGVK.GroupVersion().WithKind("VerticalPodAutoscaler"))
+	vpa.SetNamespace(key.Namespace)
+	vpa.SetName(key.Name)
+	vpa.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "right-sizer"})
+	return vpa
+}
+
+// managedByRightSizer reports whether the operator created the object
+func managedByRightSizer(obj client.Object) bool {
+	return obj.GetLabels()["app.kubernetes.io/managed-by"] == "right-sizer"
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVPAExporter(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	controller := true
+	vpaKind := &unstructured.Unstructured{}
+	vpaKind.SetGroupVersionKind(vpaListGVK.GroupVersion().WithKind("VerticalPodAutoscaler"))
+	web1 := createTestPod("web-1", "prod", "100m", "128Mi", "200m", "256Mi")
+	web2 := createTestPod("web-2", "prod", "100m", "128Mi", "200m", "256Mi")
+	bare := createTestPod("debug", "prod", "100m", "128Mi", "200m", "256Mi")
+	for _, pod := range []*corev1.Pod{web1, web2} {
+		pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}}
+	}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-abc", Namespace: "prod",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+	}}
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(web1, web2, bare, rs).WithStatusSubresource(vpaKind).Build()
+
+	exporter := &VPAExporter{Client: fakeClient}
+	updates := []ResourceUpdate{
+		{Namespace: "prod", Name: "web-1", ContainerName: "app", NewResources: profileResources("150m", "200Mi", "300m", "400Mi")},
+		{Namespace: "prod", Name: "web-2", ContainerName: "app", NewResources: profileResources("250m", "100Mi", "500m", "200Mi")},
+		{Namespace: "prod", Name: "debug", ContainerName: "app", NewResources: profileResources("250m", "100Mi", "500m", "200Mi")},
+	}
+	ctx := context.Background()
+	if err := exporter.Export(ctx, updates, []corev1.Pod{*web1, *web2, *bare}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	vpa := vpaKind.DeepCopy()
+	if err := fakeClient.Get(ctx, client.ObjectKey{Namespace: "prod", Name: "right-sizer-deployment-web"}, vpa); err != nil {
+		t.Fatalf("expected a VerticalPodAutoscaler for the workload: %v", err)
+	}
+	if mode, _, _ := unstructured.NestedString(vpa.Object, "spec", "updatePolicy", "updateMode"); mode != "Off" {
+		t.Errorf("expected updateMode Off, got %q", mode)
+	}
+	recommendations, _, _ := unstructured.NestedSlice(vpa.Object, "status", "recommendation", "containerRecommendations")
+	if len(recommendations) != 1 {
+		t.Fatalf("expected one container recommendation, got %v", recommendations)
+	}
+	target, _, _ := unstructured.NestedStringMap(recommendations[0].(map[string]interface{}), "target")
+	if target["cpu"] != "250m" || target["memory"] != "200Mi" {
+		t.Errorf("expected the larger requests of the replicas as the target, got %v", target)
+	}
+
+	// Bare pods have no workload a VPA could target
+	var all unstructured.UnstructuredList
+	all.SetGroupVersionKind(vpaListGVK)
+	if err := fakeClient.List(ctx, &all); err != nil || len(all.Items) != 1 {
+		t.Errorf("expected a single VerticalPodAutoscaler, got %d (%v)", len(all.Items), err)
+	}
+
+	// Exported VPAs do not count as VPAs managing the workload
+	vpas, err := listVPAs(ctx, fakeClient)
+	if err != nil || len(vpas) != 0 {
+		t.Errorf("expected exported VPAs to be ignored, got %v (%v)", vpas, err)
+	}
+}
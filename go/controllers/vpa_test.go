@@ -0,0 +1,103 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestFilterVPAManaged(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
+	controller := true
+
+	vpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "prod"},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "db"},
+		},
+		"status": map[string]interface{}{
+			"recommendation": map[string]interface{}{
+				"containerRecommendations": []interface{}{
+					map[string]interface{}{"containerName": "app", "target": map[string]interface{}{"cpu": "250m", "memory": "300Mi"}},
+				},
+			},
+		},
+	}}
+	db := createTestPod("db-abc-1", "prod", "100m", "128Mi", "200m", "256Mi")
+	db.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "db-abc", Controller: &controller}}
+	web := createTestPod("web", "prod", "100m", "128Mi", "200m", "256Mi")
+	objects := []client.Object{
+		vpa, db, web,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "prod"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "db-abc", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "db", Controller: &controller}},
+		}},
+	}
+
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithStatusSubresource(&v1alpha1.RightSizerRecommendation{}).Build()
+	r.Recommendations = &RecommendationWriter{Client: r.Client}
+	pods := []corev1.Pod{*db, *web}
+	updates := []ResourceUpdate{
+		{Namespace: "prod", Name: "db-abc-1", ContainerName: "app", ResourceType: "Pod", NewResources: profileResources("150m", "200Mi", "300m", "400Mi")},
+		{Namespace: "prod", Name: "web", ContainerName: "app", ResourceType: "Pod"},
+	}
+	ctx := context.Background()
+
+	cfg := config.GetDefaults()
+	if remaining := r.filterVPAManaged(ctx, updates, pods, cfg); len(remaining) != 1 || remaining[0].Name != "web" {
+		t.Errorf("expected the VPA-managed pod to be skipped, got %+v", remaining)
+	}
+	var recommendations v1alpha1.RightSizerRecommendationList
+	if err := r.Client.List(ctx, &recommendations); err != nil || len(recommendations.Items) != 0 {
+		t.Errorf("expected no recommendations in skip mode, got %d (%v)", len(recommendations.Items), err)
+	}
+
+	cfg.SetVPAMode(config.VPAModeCompare)
+	if remaining := r.filterVPAManaged(ctx, updates, pods, cfg); len(remaining) != 1 || remaining[0].Name != "web" {
+		t.Errorf("expected the VPA-managed pod not to be resized in compare mode, got %+v", remaining)
+	}
+	if err := r.Client.List(ctx, &recommendations); err != nil || len(recommendations.Items) != 1 {
+		t.Fatalf("expected a recommendation for the VPA-managed workload, got %d (%v)", len(recommendations.Items), err)
+	}
+	containers := recommendations.Items[0].Status.ContainerRecommendations
+	if len(containers) != 1 || containers[0].VPATarget.Cpu().String() != "250m" {
+		t.Errorf("expected the recommendation to carry the VPA target, got %+v", containers)
+	}
+
+	cfg.SetVPAMode(config.VPAModeIgnore)
+	if remaining := r.filterVPAManaged(ctx, updates, pods, cfg); len(remaining) != 2 {
+		t.Errorf("expected the VPA to be ignored, got %+v", remaining)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"right-sizer/audit"
+	"right-sizer/wasmpolicy"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WASMFilterName is the decision filter name WebAssembly policies run under
+const WASMFilterName = "wasm"
+
+// wasmDecisionFilter runs the WebAssembly policy modules on resizes
+type wasmDecisionFilter struct {
+	engine *wasmpolicy.Engine
+	reader client.Reader
+}
+
+// NewWASMDecisionFilter returns a decision filter evaluating the engine's
+// modules. Namespace labels are read through reader. A module that fails
+// vetoes the resize.
+func NewWASMDecisionFilter(engine *wasmpolicy.Engine, reader client.Reader) DecisionFilter {
+	return wasmDecisionFilter{engine: engine, reader: reader}
+}
+
+func (wasmDecisionFilter) Name() string { return WASMFilterName }
+
+func (f wasmDecisionFilter) Filter(ctx context.Context, pod *corev1.Pod, update ResourceUpdate) (ResourceUpdate, error) {
+	input, err := policyInput(ctx, f.reader, pod, update)
+	if err != nil {
+		return update, err
+	}
+	decision, err := f.engine.Evaluate(ctx, input)
+	if err != nil {
+		return update, err
+	}
+	if !decision.Allow {
+		return update, fmt.Errorf("denied by %s: %s", decision.Module, decision.Reason)
+	}
+	update.NewResources = decision.Resources
+	return update, nil
+}
+
+// policyInput describes a resize to external policies: WebAssembly modules
+// and OPA rules see the same document. Without its namespace's labels a
+// policy could not tell protected namespaces apart, so failing to read them
+// is an error.
+func policyInput(ctx context.Context, reader client.Reader, pod *corev1.Pod, update ResourceUpdate) (wasmpolicy.Input, error) {
+	var namespace corev1.Namespace
+	if err := reader.Get(ctx, types.NamespacedName{Name: update.Namespace}, &namespace); err != nil {
+		return wasmpolicy.Input{}, fmt.Errorf("failed to read namespace %s: %w", update.Namespace, err)
+	}
+	return wasmpolicy.Input{
+		Namespace:       update.Namespace,
+		NamespaceLabels: namespace.Labels,
+		Pod:             update.Name,
+		Container:       update.ContainerName,
+		Workload:        audit.WorkloadOf(pod),
+		Labels:          pod.Labels,
+		Annotations:     pod.Annotations,
+		Current:         update.OldResources,
+		Proposed:        update.NewResources,
+		Reason:          update.Reason,
+	}, nil
+}
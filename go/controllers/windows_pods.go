@@ -0,0 +1,110 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"log"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// windowsPod reports whether a pod runs Windows containers, which cannot be
+// resized in place. The pod's declared OS is preferred, then its node
+// selector, then the OS label of the node it was scheduled to.
+func (r *AdaptiveRightSizer) windowsPod(ctx context.Context, pod *corev1.Pod) bool {
+	if pod.Spec.OS != nil && pod.Spec.OS.Name != "" {
+		return pod.Spec.OS.Name == corev1.Windows
+	}
+	if os, ok := pod.Spec.NodeSelector[corev1.LabelOSStable]; ok {
+		return os == string(corev1.Windows)
+	}
+	if pod.Spec.NodeName == "" {
+		return false
+	}
+	var node corev1.Node
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		logger.Debug("Cannot read node %s of pod %s/%s to detect its OS: %v", pod.Spec.NodeName, pod.Namespace, pod.Name, err)
+		return false
+	}
+	if os, ok := node.Labels[corev1.LabelOSStable]; ok {
+		return os == string(corev1.Windows)
+	}
+	return node.Status.NodeInfo.OperatingSystem == string(corev1.Windows)
+}
+
+// windowsRestartAllowed reports whether a Windows pod is resized by
+// replacing it: it opted in to restarts and the mutating webhook sizes its
+// replacement
+func windowsRestartAllowed(pod *corev1.Pod, cfg *config.Config) bool {
+	return cfg.MutatingWebhook && pod.Annotations[memoryRestartAnnotation] == "true"
+}
+
+// routeWindowsPods takes the updates of Windows pods out of the in-place
+// resizes, which the kubelet would reject on every run. Pods that opt in to
+// restarts keep their updates and are evicted when they are applied; the
+// others are published as recommendations.
+func (r *AdaptiveRightSizer) routeWindowsPods(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if len(updates) == 0 {
+		return updates
+	}
+	cfg := config.Get()
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	windows := make(map[string]bool)
+	var kept, recommended []ResourceUpdate
+	for _, update := range updates {
+		key := update.Namespace + "/" + update.Name
+		pod, ok := podsByName[key]
+		if !ok {
+			kept = append(kept, update)
+			continue
+		}
+		isWindows, seen := windows[key]
+		if !seen {
+			isWindows = r.windowsPod(ctx, pod)
+			windows[key] = isWindows
+		}
+		if !isWindows || windowsRestartAllowed(pod, cfg) {
+			kept = append(kept, update)
+			continue
+		}
+		logger.Debug("Recommending resize of Windows pod %s/%s container %s instead of resizing it in place", update.Namespace, update.Name, update.ContainerName)
+		if r.OperatorMetrics != nil {
+			r.OperatorMetrics.RecordSuppressedResize(update.Namespace, "windows")
+		}
+		recommended = append(recommended, update)
+	}
+	if len(recommended) == 0 {
+		return kept
+	}
+
+	// Recommendations were already written for every update in those modes
+	if !cfg.MutatingWebhook && !cfg.HorizontalAdvice.Enabled && r.Recommendations != nil {
+		if err := r.Recommendations.Write(ctx, recommended, cfg); err != nil {
+			log.Printf("Error writing recommendations: %v", err)
+		}
+	}
+	r.recordExplanations(recommended)
+	return kept
+}
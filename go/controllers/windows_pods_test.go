@@ -0,0 +1,103 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// TestWindowsPod verifies Windows pods are detected from their OS, node selector or node
+func TestWindowsPod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	windowsNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "win-1", Labels: map[string]string{corev1.LabelOSStable: "windows"}}}
+	linuxNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "linux-1", Labels: map[string]string{corev1.LabelOSStable: "linux"}}}
+	r := &AdaptiveRightSizer{Client: ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(windowsNode, linuxNode).Build()}
+
+	tests := []struct {
+		name    string
+		mutate  func(*corev1.Pod)
+		windows bool
+	}{
+		{"pod OS", func(p *corev1.Pod) { p.Spec.OS = &corev1.PodOS{Name: corev1.Windows} }, true},
+		{"node selector", func(p *corev1.Pod) { p.Spec.NodeSelector = map[string]string{corev1.LabelOSStable: "windows"} }, true},
+		{"node label", func(p *corev1.Pod) { p.Spec.NodeName = "win-1" }, true},
+		{"linux node", func(p *corev1.Pod) { p.Spec.NodeName = "linux-1" }, false},
+		{"pod OS wins over node", func(p *corev1.Pod) {
+			p.Spec.OS = &corev1.PodOS{Name: corev1.Linux}
+			p.Spec.NodeName = "win-1"
+		}, false},
+		{"unknown node", func(p *corev1.Pod) { p.Spec.NodeName = "missing" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+			tt.mutate(pod)
+			if got := r.windowsPod(context.Background(), pod); got != tt.windows {
+				t.Errorf("expected windows=%v, got %v", tt.windows, got)
+			}
+		})
+	}
+}
+
+// TestRouteWindowsPods verifies Windows pods are recommended unless they opt in to restarts
+func TestRouteWindowsPods(t *testing.T) {
+	defer config.Get().SetAdmissionWebhooks(false, false)
+	linux := createTestPod("linux", "default", "100m", "128Mi", "200m", "256Mi")
+	windows := createTestPod("windows", "default", "100m", "128Mi", "200m", "256Mi")
+	windows.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+	pods := []corev1.Pod{*linux, *windows}
+	updates := []ResourceUpdate{
+		{Namespace: "default", Name: "linux", ContainerName: "test-container"},
+		{Namespace: "default", Name: "windows", ContainerName: "test-container"},
+	}
+	r := newAdaptiveTestRig(config.GetDefaults())
+
+	kept := r.routeWindowsPods(context.Background(), updates, pods)
+	if len(kept) != 1 || kept[0].Name != "linux" {
+		t.Fatalf("expected only the Linux pod resized in place, got %+v", kept)
+	}
+
+	config.Get().SetAdmissionWebhooks(true, true)
+	pods[1].Annotations = map[string]string{memoryRestartAnnotation: "true"}
+	if kept := r.routeWindowsPods(context.Background(), updates, pods); len(kept) != 2 {
+		t.Errorf("expected the opted-in Windows pod kept for replacement, got %+v", kept)
+	}
+}
+
+// TestWindowsPodNotPatched verifies no resize patch is sent for Windows pods
+func TestWindowsPodNotPatched(t *testing.T) {
+	pod := createTestPod("test-pod", "default", "100m", "128Mi", "200m", "256Mi")
+	pod.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+	r, patches := resizeRecorder(pod, 0, nil)
+
+	result, err := r.updatePodInPlace(context.Background(), groupedUpdate())
+	if err != nil || !strings.Contains(result, "Windows") {
+		t.Fatalf("expected the resize skipped, got %q (%v)", result, err)
+	}
+	if len(*patches) != 0 {
+		t.Errorf("expected no patches, got %d", len(*patches))
+	}
+}
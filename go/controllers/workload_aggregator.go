@@ -0,0 +1,327 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/explain"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+	"right-sizer/predictor"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// aggregatedKinds are the workload kinds whose replicas are sized together
+var aggregatedKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+}
+
+// WorkloadAggregator combines per-pod resize decisions into a single
+// recommendation per workload container and applies it to every analyzed
+// replica, so replicas of the same Deployment, StatefulSet or DaemonSet do not
+// drift apart.
+type WorkloadAggregator struct {
+	Client     client.Client
+	Mode       string // max, percentile or none
+	Percentile int    // Percentile across replicas when Mode is percentile
+
+	// NodeClassLabel splits DaemonSet replicas by the value of this node
+	// label, so each node class gets its own recommendation; optional
+	NodeClassLabel string
+
+	// Canaries stages the rollout to large Deployments when Canary is enabled; optional
+	Canaries *CanaryRollouts
+	Canary   config.CanaryConfig
+
+	// Analyzed holds the pods, by namespace/name, that passed the analysis
+	// filters this cycle; only they are sized. Nil sizes every running replica.
+	Analyzed map[string]bool
+}
+
+// workloadContainerGroup collects the decisions for one container of a workload
+type workloadContainerGroup struct {
+	namespace   string
+	target      v1alpha1.RecommendationTargetRef
+	container   string
+	nodeClass   string // value of the NodeClassLabel on the replicas' nodes
+	proposals   []corev1.ResourceRequirements
+	usage       map[string]metrics.Metrics // by pod name
+	reason      string
+	qosMode     string
+	explanation *explain.Decision // of the first replica's decision
+}
+
+// Aggregate returns the updates with per-replica decisions for workload pods
+// replaced by one aggregated decision applied to every analyzed replica.
+// Updates for pods that do not belong to an aggregated workload pass through.
+func (a *WorkloadAggregator) Aggregate(ctx context.Context, updates []ResourceUpdate, pods []corev1.Pod) []ResourceUpdate {
+	if a.Mode == "none" || len(updates) == 0 {
+		return updates
+	}
+
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	targets := make(map[string]v1alpha1.RecommendationTargetRef)
+	resolve := func(pod *corev1.Pod) v1alpha1.RecommendationTargetRef {
+		ownerKey := pod.Namespace + "/" + pod.Name
+		if owner := controllerOwnerKey(pod); owner != "" {
+			ownerKey = owner
+		}
+		if target, ok := targets[ownerKey]; ok {
+			return target
+		}
+		target := resolveWorkloadRef(ctx, a.Client, pod)
+		targets[ownerKey] = target
+		return target
+	}
+
+	nodeClass := a.nodeClasses(ctx)
+
+	var result []ResourceUpdate
+	groups := make(map[string]*workloadContainerGroup)
+	var order []string
+
+	// Updates of workloads rolled out through a canary, with their replica counts
+	staged := make(map[string][]ResourceUpdate)
+	stagedReplicas := make(map[string]int)
+	var stagedOrder []string
+
+	for _, update := range updates {
+		pod, ok := podsByName[update.Namespace+"/"+update.Name]
+		if !ok {
+			result = append(result, update)
+			continue
+		}
+		target := resolve(pod)
+		if !aggregatedKinds[target.Kind] {
+			result = append(result, update)
+			continue
+		}
+
+		class := nodeClass(pod, target.Kind)
+		key := fmt.Sprintf("%s/%s/%s/%s/%s", update.Namespace, target.Kind, target.Name, update.ContainerName, class)
+		group, ok := groups[key]
+		if !ok {
+			group = &workloadContainerGroup{
+				namespace:   update.Namespace,
+				target:      target,
+				container:   update.ContainerName,
+				nodeClass:   class,
+				usage:       make(map[string]metrics.Metrics),
+				reason:      update.Reason,
+				qosMode:     update.QoSMode,
+				explanation: update.Explanation,
+			}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.proposals = append(group.proposals, update.NewResources)
+		group.usage[update.Name] = update.Usage
+	}
+
+	for _, key := range order {
+		group := groups[key]
+
+		// Replicas analyzed without a decision are already sized right:
+		// their current resources count towards the aggregate
+		var members []*corev1.Pod
+		for i := range pods {
+			pod := &pods[i]
+			if pod.Namespace != group.namespace || pod.Status.Phase != corev1.PodRunning || !pod.DeletionTimestamp.IsZero() {
+				continue
+			}
+			if pod.Annotations["rightsizer.io/skip"] == "true" {
+				continue
+			}
+			if a.Analyzed != nil && !a.Analyzed[pod.Namespace+"/"+pod.Name] {
+				continue
+			}
+			if resolve(pod) != group.target || nodeClass(pod, group.target.Kind) != group.nodeClass {
+				continue
+			}
+			members = append(members, pod)
+			if _, decided := group.usage[pod.Name]; !decided {
+				if container, _, _ := findContainer(pod, group.container); container != nil {
+					group.proposals = append(group.proposals, container.Resources)
+				}
+			}
+		}
+		recommended := a.combine(group.proposals)
+
+		replicas := len(members)
+		var groupUpdates []ResourceUpdate
+		for _, pod := range members {
+			container, idx, init := findContainer(pod, group.container)
+			if container == nil || equality.Semantic.DeepEqual(container.Resources, recommended) {
+				continue
+			}
+			groupUpdates = append(groupUpdates, ResourceUpdate{
+				Namespace:      pod.Namespace,
+				Name:           pod.Name,
+				ResourceType:   "Pod",
+				ContainerName:  container.Name,
+				ContainerIndex: idx,
+				InitContainer:  init,
+				OldResources:   container.Resources,
+				NewResources:   *recommended.DeepCopy(),
+				Usage:          group.usage[pod.Name],
+				QoSMode:        group.qosMode,
+			})
+		}
+
+		scope := group.target.Kind + " " + group.target.Name
+		if group.nodeClass != "" {
+			scope += fmt.Sprintf(" on %s=%s nodes", a.NodeClassLabel, group.nodeClass)
+		}
+		for i := range groupUpdates {
+			groupUpdates[i].Reason = fmt.Sprintf("%s (%s of %d replicas of %s)",
+				group.reason, a.Mode, replicas, scope)
+			if group.explanation != nil {
+				explanation := *group.explanation
+				explanation.Steps = append([]explain.Step(nil), group.explanation.Steps...)
+				explanation.AddStep("aggregation", fmt.Sprintf("%s of %d proposals across %d replicas", a.Mode, len(group.proposals), replicas), recommended)
+				groupUpdates[i].Explanation = &explanation
+			}
+		}
+		if len(groupUpdates) > 0 {
+			logger.Debug("Aggregated %d decisions for %s in %s container %s into %d replica updates",
+				len(group.proposals), scope, group.namespace, group.container, len(groupUpdates))
+		}
+		if a.Canaries != nil && a.Canary.Enabled && canaryKinds[group.target.Kind] {
+			workload := group.namespace + "/" + group.target.Kind + "/" + group.target.Name
+			if _, ok := staged[workload]; !ok {
+				stagedOrder = append(stagedOrder, workload)
+			}
+			staged[workload] = append(staged[workload], groupUpdates...)
+			stagedReplicas[workload] = max(stagedReplicas[workload], replicas)
+			continue
+		}
+		result = append(result, groupUpdates...)
+	}
+
+	now := time.Now()
+	for _, workload := range stagedOrder {
+		result = append(result, a.Canaries.Stage(now, workload, staged[workload], podsByName, stagedReplicas[workload], a.Canary)...)
+	}
+	return result
+}
+
+// nodeClasses returns a function giving the node class of a DaemonSet pod:
+// the value of NodeClassLabel on its node. Pods of other kinds, and all pods
+// when no label is configured or the nodes cannot be listed, share the class "".
+func (a *WorkloadAggregator) nodeClasses(ctx context.Context) func(pod *corev1.Pod, kind string) string {
+	none := func(*corev1.Pod, string) string { return "" }
+	if a.NodeClassLabel == "" || a.Client == nil {
+		return none
+	}
+
+	var nodes corev1.NodeList
+	if err := a.Client.List(ctx, &nodes); err != nil {
+		logger.Warn("Sizing DaemonSets without node classes: failed to list nodes: %v", err)
+		return none
+	}
+	classes := make(map[string]string, len(nodes.Items))
+	for _, node := range nodes.Items {
+		classes[node.Name] = node.Labels[a.NodeClassLabel]
+	}
+	return func(pod *corev1.Pod, kind string) string {
+		if kind != "DaemonSet" {
+			return ""
+		}
+		return classes[pod.Spec.NodeName]
+	}
+}
+
+// combine merges the proposed resources of several replicas into one
+func (a *WorkloadAggregator) combine(proposals []corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if a.Mode == "percentile" {
+		requests := make([]corev1.ResourceList, len(proposals))
+		limits := make([]corev1.ResourceList, len(proposals))
+		for i, p := range proposals {
+			requests[i] = p.Requests
+			limits[i] = p.Limits
+		}
+		return corev1.ResourceRequirements{
+			Requests: percentileResourceList(requests, float64(a.Percentile)),
+			Limits:   percentileResourceList(limits, float64(a.Percentile)),
+		}
+	}
+
+	var combined corev1.ResourceRequirements
+	for _, p := range proposals {
+		combined.Requests = maxResourceList(combined.Requests, p.Requests)
+		combined.Limits = maxResourceList(combined.Limits, p.Limits)
+	}
+	if len(combined.Requests) == 0 {
+		combined.Requests = nil
+	}
+	if len(combined.Limits) == 0 {
+		combined.Limits = nil
+	}
+	return combined
+}
+
+// percentileResourceList returns the p-th percentile of each resource across the lists
+func percentileResourceList(lists []corev1.ResourceList, p float64) corev1.ResourceList {
+	values := make(map[corev1.ResourceName][]float64)
+	for _, list := range lists {
+		for name, qty := range list {
+			if name == corev1.ResourceCPU {
+				values[name] = append(values[name], float64(qty.MilliValue()))
+			} else {
+				values[name] = append(values[name], float64(qty.Value()))
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	out := corev1.ResourceList{}
+	for name, vals := range values {
+		v := int64(predictor.Percentile(vals, p))
+		if name == corev1.ResourceCPU {
+			out[name] = *resource.NewMilliQuantity(v, resource.DecimalSI)
+		} else {
+			out[name] = *resource.NewQuantity(v, resource.BinarySI)
+		}
+	}
+	return out
+}
+
+// controllerOwnerKey identifies the pod's controlling owner, or "" for bare pods
+func controllerOwnerKey(pod *corev1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller {
+			return pod.Namespace + "/" + ref.Kind + "/" + ref.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func runningReplica(name, rsName, cpu, mem string) corev1.Pod {
+	pod := newOwnedPod(name, rsName)
+	pod.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(mem),
+			},
+		},
+	}}
+	pod.Status.Phase = corev1.PodRunning
+	return *pod
+}
+
+func newAggregatorClient(t *testing.T) *WorkloadAggregator {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+
+	controller := true
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-abc",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller},
+			},
+		},
+	}
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(rs).Build()
+	return &WorkloadAggregator{Client: fakeClient, Mode: "max", Percentile: 95}
+}
+
+// TestWorkloadAggregatorAppliesMaxToAllReplicas verifies every replica gets the same resources
+func TestWorkloadAggregatorAppliesMaxToAllReplicas(t *testing.T) {
+	a := newAggregatorClient(t)
+	pods := []corev1.Pod{
+		runningReplica("web-abc-1", "web-abc", "100m", "128Mi"),
+		runningReplica("web-abc-2", "web-abc", "100m", "128Mi"),
+		runningReplica("web-abc-3", "web-abc", "100m", "128Mi"),
+	}
+	bare := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"}}
+	bare.Status.Phase = corev1.PodRunning
+	pods = append(pods, bare)
+
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-1", "200m", "256Mi"),
+		requestUpdate("web-abc-2", "150m", "512Mi"),
+		requestUpdate("bare", "50m", "64Mi"),
+	}
+
+	got := a.Aggregate(context.Background(), updates, pods)
+	if len(got) != 4 {
+		t.Fatalf("expected bare pod update plus one per replica, got %d", len(got))
+	}
+	if got[0].Name != "bare" {
+		t.Fatalf("expected bare pod update to pass through first, got %s", got[0].Name)
+	}
+
+	for _, u := range got[1:] {
+		req := u.NewResources.Requests
+		if req.Cpu().MilliValue() != 200 || req.Memory().Value() != 512*1024*1024 {
+			t.Errorf("replica %s: expected 200m/512Mi, got %s/%s", u.Name, req.Cpu(), req.Memory())
+		}
+		if u.OldResources.Requests.Cpu().MilliValue() != 100 {
+			t.Errorf("replica %s: expected old resources from the pod spec, got %s", u.Name, u.OldResources.Requests.Cpu())
+		}
+	}
+	if got[3].Name != "web-abc-3" {
+		t.Fatalf("expected replica without a decision to be updated too, got %s", got[3].Name)
+	}
+}
+
+// TestWorkloadAggregatorOnlySizesAnalyzedReplicas verifies replicas left out
+// of the analysis are not resized, and analyzed replicas without a decision
+// count towards the aggregate with their current resources
+func TestWorkloadAggregatorOnlySizesAnalyzedReplicas(t *testing.T) {
+	a := newAggregatorClient(t)
+	a.Analyzed = map[string]bool{"default/web-abc-1": true, "default/web-abc-2": true, "default/web-abc-3": true}
+	pods := []corev1.Pod{
+		runningReplica("web-abc-1", "web-abc", "100m", "128Mi"),
+		runningReplica("web-abc-2", "web-abc", "100m", "128Mi"),
+		runningReplica("web-abc-3", "web-abc", "300m", "128Mi"),
+		runningReplica("web-abc-4", "web-abc", "100m", "128Mi"),
+	}
+	updates := []ResourceUpdate{
+		requestUpdate("web-abc-1", "200m", "256Mi"),
+		requestUpdate("web-abc-2", "150m", "512Mi"),
+	}
+
+	got := a.Aggregate(context.Background(), updates, pods)
+	if len(got) != 3 {
+		t.Fatalf("expected one update per analyzed replica, got %d", len(got))
+	}
+	for _, u := range got {
+		if u.Name == "web-abc-4" {
+			t.Fatal("expected the replica left out of the analysis to be left alone")
+		}
+		req := u.NewResources.Requests
+		if req.Cpu().MilliValue() != 300 || req.Memory().Value() != 512*1024*1024 {
+			t.Errorf("replica %s: expected 300m/512Mi, got %s/%s", u.Name, req.Cpu(), req.Memory())
+		}
+		if !strings.Contains(u.Reason, "of 3 replicas") {
+			t.Errorf("replica %s: expected the reason to count 3 replicas, got %q", u.Name, u.Reason)
+		}
+	}
+}
+
+func TestWorkloadAggregatorNoneMode(t *testing.T) {
+	a := newAggregatorClient(t)
+	a.Mode = "none"
+	pods := []corev1.Pod{runningReplica("web-abc-1", "web-abc", "100m", "128Mi")}
+	updates := []ResourceUpdate{requestUpdate("web-abc-1", "200m", "256Mi")}
+
+	if got := a.Aggregate(context.Background(), updates, pods); len(got) != 1 || got[0].Reason != "CPU scale up" {
+		t.Fatalf("expected updates unchanged, got %+v", got)
+	}
+}
+
+// TestWorkloadAggregatorDaemonSetNodeClasses verifies DaemonSet replicas get
+// one recommendation per node class
+func TestWorkloadAggregatorDaemonSetNodeClasses(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	node := func(name, class string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": class}}}
+	}
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node("small-1", "small"), node("small-2", "small"), node("big-1", "big")).Build()
+	a := &WorkloadAggregator{Client: fakeClient, Mode: "max", NodeClassLabel: "pool"}
+
+	var pods []corev1.Pod
+	for _, nodeName := range []string{"small-1", "small-2", "big-1"} {
+		pod := runningReplica("agent-"+nodeName, "agent", "100m", "128Mi")
+		pod.OwnerReferences[0].Kind = "DaemonSet"
+		pod.Spec.NodeName = nodeName
+		pods = append(pods, pod)
+	}
+	updates := []ResourceUpdate{
+		requestUpdate("agent-small-1", "150m", "128Mi"),
+		requestUpdate("agent-big-1", "800m", "1Gi"),
+	}
+
+	got := a.Aggregate(context.Background(), updates, pods)
+	if len(got) != 3 {
+		t.Fatalf("expected every replica to be updated, got %d updates", len(got))
+	}
+	for _, update := range got {
+		want := int64(150)
+		if update.Name == "agent-big-1" {
+			want = 800
+		}
+		if cpu := update.NewResources.Requests.Cpu().MilliValue(); cpu != want {
+			t.Errorf("expected %s to get %dm CPU from its node class, got %dm", update.Name, want, cpu)
+		}
+	}
+}
+
+func TestPercentileResourceList(t *testing.T) {
+	lists := []corev1.ResourceList{
+		{corev1.ResourceCPU: resource.MustParse("100m")},
+		{corev1.ResourceCPU: resource.MustParse("200m")},
+		{corev1.ResourceCPU: resource.MustParse("300m")},
+	}
+	if got := percentileResourceList(lists, 50); got.Cpu().MilliValue() != 200 {
+		t.Fatalf("expected median 200m, got %s", got.Cpu())
+	}
+	if got := percentileResourceList(nil, 50); got != nil {
+		t.Fatalf("expected nil for no lists, got %v", got)
+	}
+}
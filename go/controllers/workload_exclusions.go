@@ -0,0 +1,166 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// exclusionRule is a parsed workload exclusion and where it was configured
+type exclusionRule struct {
+	source     string
+	selector   labels.Selector
+	ownerKinds []string
+}
+
+// matches reports whether a pod with the given labels and owner kinds
+// matches every criterion of the rule
+func (e exclusionRule) matches(podLabels map[string]string, kinds []string) bool {
+	if e.selector == nil && len(e.ownerKinds) == 0 {
+		return false
+	}
+	if e.selector != nil && !e.selector.Matches(labels.Set(podLabels)) {
+		return false
+	}
+	if len(e.ownerKinds) > 0 && !slices.ContainsFunc(kinds, func(kind string) bool {
+		return slices.ContainsFunc(e.ownerKinds, func(excluded string) bool { return strings.EqualFold(excluded, kind) })
+	}) {
+		return false
+	}
+	return true
+}
+
+// describe summarizes the criteria of the rule
+func (e exclusionRule) describe() string {
+	var criteria []string
+	if e.selector != nil {
+		criteria = append(criteria, "labels "+e.selector.String())
+	}
+	if len(e.ownerKinds) > 0 {
+		criteria = append(criteria, "owner kind "+strings.Join(e.ownerKinds, "|"))
+	}
+	return e.source + " exclusion (" + strings.Join(criteria, ", ") + ")"
+}
+
+// exclusionRules parses the global exclusion rules; rules with an invalid
+// selector are logged and ignored
+func exclusionRules(exclusions []config.WorkloadExclusion) []exclusionRule {
+	var rules []exclusionRule
+	for _, exclusion := range exclusions {
+		rule := exclusionRule{source: "global", ownerKinds: exclusion.OwnerKinds}
+		if exclusion.LabelSelector != "" {
+			selector, err := labels.Parse(exclusion.LabelSelector)
+			if err != nil {
+				logger.Warn("Ignoring exclusion with invalid label selector %q: %v", exclusion.LabelSelector, err)
+				continue
+			}
+			rule.selector = selector
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// policyExclusionRules parses the exclusions of the policies whose target
+// reference covers the namespace
+func policyExclusionRules(namespace string, policies []v1alpha1.RightSizerPolicy) []exclusionRule {
+	var rules []exclusionRule
+	for i := range policies {
+		policy := &policies[i]
+		ref := policy.Spec.TargetRef
+		if len(ref.Namespaces) > 0 && !slices.Contains(ref.Namespaces, namespace) {
+			continue
+		}
+		if slices.Contains(ref.ExcludeNamespaces, namespace) {
+			continue
+		}
+		source := fmt.Sprintf("policy %s/%s", policy.Namespace, policy.Name)
+		for _, exclusion := range policy.Spec.Exclusions {
+			rule, err := parseExclusion(exclusion, source)
+			if err != nil {
+				logger.Warn("Ignoring exclusion of %s: %v", source, err)
+				continue
+			}
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseExclusion converts an exclusion of a custom resource into a rule
+func parseExclusion(exclusion v1alpha1.WorkloadExclusion, source string) (exclusionRule, error) {
+	rule := exclusionRule{source: source, ownerKinds: exclusion.OwnerKinds}
+	if exclusion.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(exclusion.LabelSelector)
+		if err != nil {
+			return rule, fmt.Errorf("invalid label selector: %w", err)
+		}
+		rule.selector = selector
+	}
+	return rule, nil
+}
+
+// excludedPod returns the exclusion rule a pod matches. The pod's owner is
+// only resolved when a rule needs its kind.
+func (r *AdaptiveRightSizer) excludedPod(ctx context.Context, pod *corev1.Pod, rules []exclusionRule) (exclusionRule, bool) {
+	var kinds []string
+	resolved := false
+	for _, rule := range rules {
+		if len(rule.ownerKinds) > 0 && !resolved {
+			kinds = podOwnerKinds(ctx, r.Client, pod)
+			resolved = true
+		}
+		if rule.matches(pod.Labels, kinds) {
+			return rule, true
+		}
+	}
+	return exclusionRule{}, false
+}
+
+// excludedWorkload returns the exclusion rule a workload matches by its own
+// labels and kind
+func excludedWorkload(obj client.Object, rules []exclusionRule) (exclusionRule, bool) {
+	kinds := []string{objectKind(obj)}
+	for _, rule := range rules {
+		if rule.matches(obj.GetLabels(), kinds) {
+			return rule, true
+		}
+	}
+	return exclusionRule{}, false
+}
+
+// podOwnerKinds returns the kinds of a pod's controller and of the workload
+// owning it, e.g. ReplicaSet and Deployment, or Pod for a bare pod
+func podOwnerKinds(ctx context.Context, c client.Client, pod *corev1.Pod) []string {
+	target := resolveWorkloadRef(ctx, c, pod)
+	kinds := []string{target.Kind}
+	if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind != target.Kind {
+		kinds = append(kinds, owner.Kind)
+	}
+	return kinds
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+	"right-sizer/config"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestExcludedPod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	controller := true
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-abc", Namespace: "prod",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+	}}
+	r := newAdaptiveTestRig(config.GetDefaults())
+	r.Client = ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(replicaSet).Build()
+
+	rules := exclusionRules([]config.WorkloadExclusion{
+		{LabelSelector: "app.kubernetes.io/component=database"},
+		{OwnerKinds: []string{"DaemonSet"}},
+		{LabelSelector: "tier=batch", OwnerKinds: []string{"deployment"}},
+	})
+
+	pod := func(labels map[string]string, kind, name string) *corev1.Pod {
+		p := createTestPod("pod", "prod", "100m", "128Mi", "200m", "256Mi")
+		p.Labels = labels
+		if kind != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, Controller: &controller}}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		excluded bool
+	}{
+		{"database label", pod(map[string]string{"app.kubernetes.io/component": "database"}, "", ""), true},
+		{"daemonset", pod(nil, "DaemonSet", "agent"), true},
+		{"batch deployment", pod(map[string]string{"tier": "batch"}, "ReplicaSet", "web-abc"), true},
+		{"batch bare pod", pod(map[string]string{"tier": "batch"}, "", ""), false},
+		{"web deployment", pod(map[string]string{"tier": "web"}, "ReplicaSet", "web-abc"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, excluded := r.excludedPod(context.Background(), tt.pod, rules); excluded != tt.excluded {
+				t.Errorf("expected excluded=%v, got %v", tt.excluded, excluded)
+			}
+		})
+	}
+}
+
+func TestPolicyExclusionRules(t *testing.T) {
+	policies := []v1alpha1.RightSizerPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "prod", Namespace: "right-sizer"},
+			Spec: v1alpha1.RightSizerPolicySpec{
+				TargetRef:  v1alpha1.TargetReference{Namespaces: []string{"prod"}},
+				Exclusions: []v1alpha1.WorkloadExclusion{{OwnerKinds: []string{"StatefulSet"}}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "right-sizer"},
+			Spec: v1alpha1.RightSizerPolicySpec{
+				Exclusions: []v1alpha1.WorkloadExclusion{{LabelSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Sometimes"}},
+				}}},
+			},
+		},
+	}
+
+	if rules := policyExclusionRules("prod", policies); len(rules) != 1 || rules[0].source != "policy right-sizer/prod" {
+		t.Errorf("expected the valid exclusion of the prod policy, got %+v", rules)
+	}
+	if rules := policyExclusionRules("staging", policies); len(rules) != 0 {
+		t.Errorf("expected no exclusions outside the policy's namespaces, got %+v", rules)
+	}
+}
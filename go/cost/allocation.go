@@ -0,0 +1,229 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package cost prices CPU and memory from an OpenCost or Kubecost allocation
+// endpoint so savings reflect what the cluster's nodes actually cost.
+package cost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+)
+
+// Cost providers
+const (
+	ProviderNone     = "none"
+	ProviderOpenCost = "opencost"
+	ProviderKubecost = "kubecost"
+
+	// SourceEstimate marks pricing from the built-in default prices
+	SourceEstimate = "estimate"
+)
+
+const (
+	// Default prices used when no cost provider is configured; these are the
+	// OpenCost default on-demand prices
+	defaultCPUCoreHourCost = 0.031611
+	defaultRAMGiBHourCost  = 0.004237
+
+	// HoursPerMonth is the average number of hours in a month
+	HoursPerMonth = 730
+
+	// idleAllocation is the name OpenCost and Kubecost give to unallocated capacity
+	idleAllocation = "__idle__"
+
+	allocationTimeout = 30 * time.Second
+	gib               = 1024 * 1024 * 1024
+)
+
+// Pricing holds the price of a CPU core and of a GiB of memory per hour and
+// the cost of capacity no workload requested
+type Pricing struct {
+	Source          string    `json:"source"`           // opencost, kubecost or estimate
+	Window          string    `json:"window,omitempty"` // window the allocation data covers
+	CPUCoreHourCost float64   `json:"cpuCoreHourCost"`
+	RAMGiBHourCost  float64   `json:"ramGiBHourCost"`
+	TotalCost       float64   `json:"totalCost"`   // cost of the cluster over the window
+	IdleCost        float64   `json:"idleCost"`    // cost of idle capacity over the window
+	IdlePercent     float64   `json:"idlePercent"` // share of the total cost that is idle
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// EstimatedPricing returns the built-in default prices
+func EstimatedPricing() *Pricing {
+	return &Pricing{
+		Source:          SourceEstimate,
+		CPUCoreHourCost: defaultCPUCoreHourCost,
+		RAMGiBHourCost:  defaultRAMGiBHourCost,
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// MonthlyCost returns the monthly cost of the given CPU millicores and memory bytes
+func (p *Pricing) MonthlyCost(cpuMillis, memoryBytes int64) float64 {
+	hourly := float64(cpuMillis)/1000*p.CPUCoreHourCost + float64(memoryBytes)/gib*p.RAMGiBHourCost
+	return hourly * HoursPerMonth
+}
+
+// allocation is the part of an OpenCost or Kubecost allocation used for pricing
+type allocation struct {
+	CPUCoreHours float64 `json:"cpuCoreHours"`
+	CPUCost      float64 `json:"cpuCost"`
+	RAMByteHours float64 `json:"ramByteHours"`
+	RAMCost      float64 `json:"ramCost"`
+	TotalCost    float64 `json:"totalCost"`
+}
+
+type allocationResponse struct {
+	Code    int                     `json:"code"`
+	Message string                  `json:"message"`
+	Data    []map[string]allocation `json:"data"`
+}
+
+// Client fetches pricing from the cost provider in config.Get().Cost and
+// caches it for the configured refresh interval. When no provider is
+// configured, or it cannot be reached, the estimated prices are used.
+type Client struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	pricing   *Pricing
+	fetchedAt time.Time
+	fetchedBy config.CostConfig
+}
+
+// NewClient creates a cost client
+func NewClient() *Client {
+	return &Client{httpClient: &http.Client{Timeout: allocationTimeout}}
+}
+
+// Pricing returns the current pricing; it never returns nil
+func (c *Client) Pricing(ctx context.Context) *Pricing {
+	cfg := config.Get().Cost
+	if cfg.Provider == "" || cfg.Provider == ProviderNone || cfg.Endpoint == "" {
+		return EstimatedPricing()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pricing != nil && c.fetchedBy == cfg && time.Since(c.fetchedAt) < cfg.RefreshInterval {
+		return c.pricing
+	}
+
+	pricing, err := c.fetch(ctx, cfg)
+	c.fetchedAt = time.Now()
+	c.fetchedBy = cfg
+	if err != nil {
+		logger.Warn("Failed to fetch pricing from %s, using estimated prices: %v", cfg.Provider, err)
+		// Keep serving the last good pricing; retry after the refresh interval
+		if c.pricing == nil || c.pricing.Source != cfg.Provider {
+			c.pricing = EstimatedPricing()
+		}
+		return c.pricing
+	}
+	c.pricing = pricing
+	return pricing
+}
+
+// allocationPath returns the allocation API path of a provider
+func allocationPath(provider string) string {
+	if provider == ProviderKubecost {
+		return "/model/allocation"
+	}
+	return "/allocation/compute"
+}
+
+// fetch prices CPU and memory from the cluster's allocation over the window.
+// Idle capacity is included so the prices are those of the nodes rather than
+// of the requested resources alone.
+func (c *Client) fetch(ctx context.Context, cfg config.CostConfig) (*Pricing, error) {
+	params := url.Values{}
+	params.Set("window", cfg.Window)
+	params.Set("aggregate", "cluster")
+	params.Set("accumulate", "true")
+	params.Set("includeIdle", "true")
+
+	ctx, cancel := context.WithTimeout(ctx, allocationTimeout)
+	defer cancel()
+	endpoint := strings.TrimSuffix(cfg.Endpoint, "/") + allocationPath(cfg.Provider) + "?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("allocation query failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var result allocationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode allocation response: %w", err)
+	}
+	if result.Code != 0 && result.Code != http.StatusOK {
+		return nil, fmt.Errorf("allocation query failed: %s", result.Message)
+	}
+	return pricingFromAllocations(cfg, result.Data)
+}
+
+// pricingFromAllocations derives unit prices from accumulated allocation sets
+func pricingFromAllocations(cfg config.CostConfig, sets []map[string]allocation) (*Pricing, error) {
+	var total, idle allocation
+	for _, set := range sets {
+		for name, alloc := range set {
+			total.CPUCoreHours += alloc.CPUCoreHours
+			total.CPUCost += alloc.CPUCost
+			total.RAMByteHours += alloc.RAMByteHours
+			total.RAMCost += alloc.RAMCost
+			total.TotalCost += alloc.TotalCost
+			if name == idleAllocation {
+				idle.TotalCost += alloc.TotalCost
+			}
+		}
+	}
+	if total.CPUCoreHours == 0 || total.RAMByteHours == 0 {
+		return nil, errors.New("no allocation data in window " + cfg.Window)
+	}
+
+	pricing := &Pricing{
+		Source:          cfg.Provider,
+		Window:          cfg.Window,
+		CPUCoreHourCost: total.CPUCost / total.CPUCoreHours,
+		RAMGiBHourCost:  total.RAMCost / (total.RAMByteHours / gib),
+		TotalCost:       total.TotalCost,
+		IdleCost:        idle.TotalCost,
+		UpdatedAt:       time.Now(),
+	}
+	if total.TotalCost > 0 {
+		pricing.IdlePercent = idle.TotalCost / total.TotalCost * 100
+	}
+	return pricing, nil
+}
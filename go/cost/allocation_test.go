@@ -0,0 +1,101 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cost
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+)
+
+// allocationBody is a week of a 4-core, 16GiB cluster, a quarter of it idle
+const allocationBody = `{
+  "code": 200,
+  "data": [{
+    "cluster-one": {"cpuCoreHours": 504, "cpuCost": 25.2, "ramByteHours": 2164663517184, "ramCost": 7.56, "totalCost": 32.76},
+    "__idle__": {"cpuCoreHours": 168, "cpuCost": 8.4, "ramByteHours": 721554505728, "ramCost": 2.52, "totalCost": 10.92}
+  }]
+}`
+
+func withCostConfig(t *testing.T, provider, endpoint string) {
+	t.Helper()
+	cfg := config.Get()
+	previous := cfg.Cost
+	cfg.SetCostConfig(config.CostConfig{Provider: provider, Endpoint: endpoint, RefreshInterval: time.Hour})
+	t.Cleanup(func() { cfg.Cost = previous })
+}
+
+func TestClientPricingFromOpenCost(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/allocation/compute" || r.URL.Query().Get("window") != "7d" || r.URL.Query().Get("includeIdle") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(allocationBody))
+	}))
+	defer server.Close()
+	withCostConfig(t, ProviderOpenCost, server.URL)
+
+	client := NewClient()
+	pricing := client.Pricing(context.Background())
+	if pricing.Source != ProviderOpenCost {
+		t.Fatalf("expected OpenCost pricing, got %s", pricing.Source)
+	}
+	if math.Abs(pricing.CPUCoreHourCost-0.05) > 1e-9 {
+		t.Errorf("expected 0.05 per core-hour, got %v", pricing.CPUCoreHourCost)
+	}
+	if math.Abs(pricing.RAMGiBHourCost-0.00375) > 1e-6 {
+		t.Errorf("expected 0.00375 per GiB-hour, got %v", pricing.RAMGiBHourCost)
+	}
+	if math.Abs(pricing.IdlePercent-25) > 1e-9 || pricing.IdleCost != 10.92 {
+		t.Errorf("expected a quarter of the cost idle, got %v (%v%%)", pricing.IdleCost, pricing.IdlePercent)
+	}
+
+	// Prices are reused until the refresh interval has passed
+	client.Pricing(context.Background())
+	if requests != 1 {
+		t.Errorf("expected cached pricing, got %d requests", requests)
+	}
+}
+
+func TestClientPricingFallsBackToEstimate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/model/allocation" {
+			t.Errorf("expected the Kubecost allocation API, got %s", r.URL.Path)
+		}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	withCostConfig(t, ProviderKubecost, server.URL)
+
+	if pricing := NewClient().Pricing(context.Background()); pricing.Source != SourceEstimate {
+		t.Errorf("expected estimated pricing when the provider fails, got %s", pricing.Source)
+	}
+}
+
+func TestPricingMonthlyCost(t *testing.T) {
+	pricing := &Pricing{CPUCoreHourCost: 0.04, RAMGiBHourCost: 0.005}
+	// Half a core and 2GiB for a month
+	if got, want := pricing.MonthlyCost(500, 2*gib), (0.02+0.01)*HoursPerMonth; math.Abs(got-want) > 1e-9 {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package efficiency scores how much of the resources containers request
+// they actually use over a sliding window, per container and aggregated to
+// workloads, namespaces and the cluster.
+package efficiency
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// bucketWidth is the span of usage folded into one bucket, bounding the
+// memory a container's history takes however often it is observed
+const bucketWidth = 15 * time.Minute
+
+// Sample is a container's requests and usage at a point in time
+type Sample struct {
+	Time         time.Time
+	Namespace    string
+	Workload     string // Kind/name
+	Pod          string
+	Container    string
+	CPURequest   float64 // millicores
+	CPUUsage     float64 // millicores
+	MemRequestMB float64
+	MemUsageMB   float64
+}
+
+// Score is usage as a percentage of requests over the window, capped at 100
+// per sample so a container using more than it requests does not hide the
+// waste of others. A resource that is never requested scores 0 and is left
+// out of Overall.
+type Score struct {
+	CPU     float64 `json:"cpu"`
+	Memory  float64 `json:"memory"`
+	Overall float64 `json:"overall"`
+}
+
+// ContainerScore is the score of a container
+type ContainerScore struct {
+	Namespace string `json:"namespace"`
+	Workload  string `json:"workload"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Score
+}
+
+// WorkloadScore is the score of all containers of a workload's pods
+type WorkloadScore struct {
+	Namespace  string `json:"namespace"`
+	Workload   string `json:"workload"`
+	Containers int    `json:"containers"`
+	Score
+}
+
+// NamespaceScore is the score of all containers of a namespace
+type NamespaceScore struct {
+	Namespace  string `json:"namespace"`
+	Containers int    `json:"containers"`
+	Score
+}
+
+// Report holds the scores at every level, sorted by name
+type Report struct {
+	Window     string           `json:"window"`
+	Time       time.Time        `json:"time"`
+	Cluster    Score            `json:"cluster"`
+	Namespaces []NamespaceScore `json:"namespaces"`
+	Workloads  []WorkloadScore  `json:"workloads"`
+	Containers []ContainerScore `json:"containers"`
+}
+
+// totals sums requests and usage, capped at requests, over samples
+type totals struct {
+	cpuRequest, cpuUsed float64
+	memRequest, memUsed float64
+}
+
+func (t *totals) add(o totals) {
+	t.cpuRequest += o.cpuRequest
+	t.cpuUsed += o.cpuUsed
+	t.memRequest += o.memRequest
+	t.memUsed += o.memUsed
+}
+
+func (t totals) score() Score {
+	var score Score
+	var resources float64
+	if t.cpuRequest > 0 {
+		score.CPU = t.cpuUsed / t.cpuRequest * 100
+		score.Overall += score.CPU
+		resources++
+	}
+	if t.memRequest > 0 {
+		score.Memory = t.memUsed / t.memRequest * 100
+		score.Overall += score.Memory
+		resources++
+	}
+	if resources > 0 {
+		score.Overall /= resources
+	}
+	return score
+}
+
+// bucket holds the totals of the samples within bucketWidth of start
+type bucket struct {
+	start time.Time
+	totals
+}
+
+// series is the bucketed history of a container
+type series struct {
+	namespace, workload, pod, container string
+	buckets                             []bucket
+	last                                Sample // uncapped, for Latest
+}
+
+// Tracker keeps the bucketed requests and usage of every observed container
+type Tracker struct {
+	mu         sync.Mutex
+	containers map[string]*series // namespace/pod/container
+}
+
+// NewTracker returns an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{containers: make(map[string]*series)}
+}
+
+// Observe adds a sample to its container's history
+func (t *Tracker) Observe(sample Sample) {
+	if t == nil {
+		return
+	}
+	key := sample.Namespace + "/" + sample.Pod + "/" + sample.Container
+	value := totals{
+		cpuRequest: sample.CPURequest,
+		cpuUsed:    min(sample.CPUUsage, sample.CPURequest),
+		memRequest: sample.MemRequestMB,
+		memUsed:    min(sample.MemUsageMB, sample.MemRequestMB),
+	}
+	start := sample.Time.Truncate(bucketWidth)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.containers[key]
+	if !ok {
+		s = &series{namespace: sample.Namespace, pod: sample.Pod, container: sample.Container}
+		t.containers[key] = s
+	}
+	s.workload = sample.Workload
+	s.last = sample
+	if n := len(s.buckets); n > 0 && s.buckets[n-1].start.Equal(start) {
+		s.buckets[n-1].add(value)
+		return
+	}
+	s.buckets = append(s.buckets, bucket{start: start, totals: value})
+}
+
+// Scores returns the scores over the window ending at now. History older
+// than the window is dropped, and with it containers no longer observed.
+func (t *Tracker) Scores(now time.Time, window time.Duration) Report {
+	report := Report{Window: window.String(), Time: now, Namespaces: []NamespaceScore{}, Workloads: []WorkloadScore{}, Containers: []ContainerScore{}}
+	if t == nil {
+		return report
+	}
+	cutoff := now.Add(-window).Truncate(bucketWidth)
+
+	type group struct {
+		containers int
+		totals
+	}
+	var cluster totals
+	namespaces := map[string]*group{}
+	workloads := map[[2]string]*group{}
+
+	t.mu.Lock()
+	for key, s := range t.containers {
+		kept := s.buckets[:0]
+		for _, b := range s.buckets {
+			if !b.start.Before(cutoff) {
+				kept = append(kept, b)
+			}
+		}
+		s.buckets = kept
+		if len(kept) == 0 {
+			delete(t.containers, key)
+			continue
+		}
+
+		var sum totals
+		for _, b := range kept {
+			sum.add(b.totals)
+		}
+		report.Containers = append(report.Containers, ContainerScore{
+			Namespace: s.namespace, Workload: s.workload, Pod: s.pod, Container: s.container, Score: sum.score(),
+		})
+		cluster.add(sum)
+		if namespaces[s.namespace] == nil {
+			namespaces[s.namespace] = &group{}
+		}
+		namespaces[s.namespace].containers++
+		namespaces[s.namespace].add(sum)
+		workload := [2]string{s.namespace, s.workload}
+		if workloads[workload] == nil {
+			workloads[workload] = &group{}
+		}
+		workloads[workload].containers++
+		workloads[workload].add(sum)
+	}
+	t.mu.Unlock()
+
+	report.Cluster = cluster.score()
+	for namespace, g := range namespaces {
+		report.Namespaces = append(report.Namespaces, NamespaceScore{Namespace: namespace, Containers: g.containers, Score: g.score()})
+	}
+	for workload, g := range workloads {
+		report.Workloads = append(report.Workloads, WorkloadScore{Namespace: workload[0], Workload: workload[1], Containers: g.containers, Score: g.score()})
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Workload < b.Workload
+	})
+	sort.Slice(report.Containers, func(i, j int) bool {
+		a, b := report.Containers[i], report.Containers[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		return a.Container < b.Container
+	})
+	return report
+}
+
+// WorkloadUsage is a workload's requests and usage summed over its containers
+// as of the latest analysis. Unlike scores, usage is not capped at requests.
+type WorkloadUsage struct {
+	Namespace    string
+	Workload     string // Kind/name
+	Containers   int
+	CPURequest   float64 // millicores
+	CPUUsage     float64 // millicores
+	MemRequestMB float64
+	MemUsageMB   float64
+}
+
+// Latest sums the last sample of every container by workload. Containers
+// not observed within maxAge of the newest sample, such as those of deleted
+// pods, are left out.
+func (t *Tracker) Latest(maxAge time.Duration) []WorkloadUsage {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var newest time.Time
+	for _, s := range t.containers {
+		if s.last.Time.After(newest) {
+			newest = s.last.Time
+		}
+	}
+	cutoff := newest.Add(-maxAge)
+	workloads := map[[2]string]*WorkloadUsage{}
+	for _, s := range t.containers {
+		if s.last.Time.Before(cutoff) {
+			continue
+		}
+		key := [2]string{s.namespace, s.workload}
+		usage, ok := workloads[key]
+		if !ok {
+			usage = &WorkloadUsage{Namespace: s.namespace, Workload: s.workload}
+			workloads[key] = usage
+		}
+		usage.Containers++
+		usage.CPURequest += s.last.CPURequest
+		usage.CPUUsage += s.last.CPUUsage
+		usage.MemRequestMB += s.last.MemRequestMB
+		usage.MemUsageMB += s.last.MemUsageMB
+	}
+
+	result := make([]WorkloadUsage, 0, len(workloads))
+	for _, usage := range workloads {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Workload < result[j].Workload
+	})
+	return result
+}
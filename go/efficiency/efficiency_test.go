@@ -0,0 +1,123 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package efficiency
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.01
+}
+
+func TestTrackerAggregatesScores(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	observe := func(at time.Time, namespace, workload, pod, container string, cpuRequest, cpuUsage, memRequest, memUsage float64) {
+		tracker.Observe(Sample{
+			Time: at, Namespace: namespace, Workload: workload, Pod: pod, Container: container,
+			CPURequest: cpuRequest, CPUUsage: cpuUsage, MemRequestMB: memRequest, MemUsageMB: memUsage,
+		})
+	}
+
+	// web uses a quarter of its CPU and half its memory across two samples
+	observe(now.Add(-2*time.Hour), "shop", "Deployment/web", "web-1", "app", 1000, 200, 512, 256)
+	observe(now.Add(-time.Hour), "shop", "Deployment/web", "web-1", "app", 1000, 300, 512, 256)
+	// db uses more CPU than it requests, which counts as fully used, and requests no memory
+	observe(now.Add(-time.Hour), "shop", "StatefulSet/db", "db-0", "db", 500, 800, 0, 100)
+	// batch was last seen outside the window
+	observe(now.Add(-48*time.Hour), "batch", "Job/report", "report-1", "app", 1000, 1000, 1024, 1024)
+
+	report := tracker.Scores(now, 24*time.Hour)
+
+	if len(report.Containers) != 2 {
+		t.Fatalf("expected the 2 containers within the window, got %+v", report.Containers)
+	}
+	if len(report.Namespaces) != 1 || report.Namespaces[0].Namespace != "shop" || report.Namespaces[0].Containers != 2 {
+		t.Fatalf("expected only the shop namespace, got %+v", report.Namespaces)
+	}
+
+	web := report.Workloads[0]
+	if web.Workload != "Deployment/web" || !near(web.CPU, 25) || !near(web.Memory, 50) || !near(web.Overall, 37.5) {
+		t.Errorf("unexpected web score: %+v", web)
+	}
+	db := report.Workloads[1]
+	if db.Workload != "StatefulSet/db" || !near(db.CPU, 100) || db.Memory != 0 || !near(db.Overall, 100) {
+		t.Errorf("unexpected db score: %+v", db)
+	}
+
+	// 500m of 2500m CPU used across both workloads, 512Mi of 1024Mi memory
+	if !near(report.Cluster.CPU, 40) || !near(report.Cluster.Memory, 50) || !near(report.Cluster.Overall, 45) {
+		t.Errorf("unexpected cluster score: %+v", report.Cluster)
+	}
+	if report.Namespaces[0].Score != report.Cluster {
+		t.Errorf("expected the only namespace to score like the cluster, got %+v", report.Namespaces[0].Score)
+	}
+
+	// History past the window is dropped with the containers it belonged to
+	if _, ok := tracker.containers["batch/report-1/app"]; ok {
+		t.Error("expected the container outside the window to be dropped")
+	}
+}
+
+func TestTrackerFoldsSamplesIntoBuckets(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	for i := 0; i < 10; i++ {
+		tracker.Observe(Sample{Time: now.Add(time.Duration(i) * time.Minute), Namespace: "shop", Pod: "web-1", Container: "app", CPURequest: 100, CPUUsage: 50})
+	}
+	tracker.Observe(Sample{Time: now.Add(bucketWidth), Namespace: "shop", Pod: "web-1", Container: "app", CPURequest: 100, CPUUsage: 100})
+
+	if buckets := len(tracker.containers["shop/web-1/app"].buckets); buckets != 2 {
+		t.Fatalf("expected 2 buckets, got %d", buckets)
+	}
+	report := tracker.Scores(now.Add(bucketWidth), time.Hour)
+	if got := report.Containers[0].CPU; !near(got, 600.0/11) {
+		t.Errorf("expected every sample to weigh the same, got %.2f", got)
+	}
+}
+
+func TestTrackerLatest(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.Observe(Sample{Time: now.Add(-time.Minute), Namespace: "shop", Workload: "Deployment/web", Pod: "web-1", Container: "app", CPURequest: 1000, CPUUsage: 100})
+	tracker.Observe(Sample{Time: now, Namespace: "shop", Workload: "Deployment/web", Pod: "web-1", Container: "app", CPURequest: 1000, CPUUsage: 200, MemRequestMB: 512, MemUsageMB: 600})
+	tracker.Observe(Sample{Time: now, Namespace: "shop", Workload: "Deployment/web", Pod: "web-2", Container: "app", CPURequest: 1000, CPUUsage: 300, MemRequestMB: 512, MemUsageMB: 100})
+	// old was deleted before the latest analysis
+	tracker.Observe(Sample{Time: now.Add(-time.Hour), Namespace: "shop", Workload: "Deployment/old", Pod: "old-1", Container: "app", CPURequest: 1000})
+
+	latest := tracker.Latest(5 * time.Minute)
+	if len(latest) != 1 {
+		t.Fatalf("expected only the workload of the latest analysis, got %+v", latest)
+	}
+	web := latest[0]
+	if web.Containers != 2 || web.CPURequest != 2000 || web.CPUUsage != 500 || web.MemUsageMB != 700 {
+		t.Errorf("expected the last samples summed without capping, got %+v", web)
+	}
+}
+
+func TestNilTracker(t *testing.T) {
+	var tracker *Tracker
+	tracker.Observe(Sample{Namespace: "shop", Pod: "web-1", Container: "app"})
+	if report := tracker.Scores(time.Now(), time.Hour); len(report.Containers) != 0 {
+		t.Errorf("expected an empty report, got %+v", report)
+	}
+	if latest := tracker.Latest(time.Hour); len(latest) != 0 {
+		t.Errorf("expected no workloads, got %+v", latest)
+	}
+}
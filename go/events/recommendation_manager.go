@@ -176,6 +176,19 @@ func (rm *RecommendationManager) GetRecommendations() []*Recommendation {
 	return recommendations
 }
 
+// GetRecommendation returns a copy of the recommendation with the given ID
+func (rm *RecommendationManager) GetRecommendation(id string) (*Recommendation, bool) {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	rec, exists := rm.recommendations[id]
+	if !exists {
+		return nil, false
+	}
+	recCopy := *rec
+	return &recCopy, true
+}
+
 // GetRecommendationsByStatus returns recommendations filtered by status
 func (rm *RecommendationManager) GetRecommendationsByStatus(status RecommendationStatus) []*Recommendation {
 	all := rm.GetRecommendations()
@@ -25,6 +25,7 @@ const (
 	EventResourceUnderUtilized  EventType = "resource.underutilized"
 	EventResourcePredictedOOM   EventType = "resource.predicted_oom"
 	EventResourcePredictedCrash EventType = "resource.predicted_crash"
+	EventResourceAnomaly        EventType = "resource.anomaly"
 
 	// Pod Events
 	EventPodOOMKilled        EventType = "pod.oom_killed"
@@ -54,10 +55,16 @@ const (
 	EventNodeResourcesFull    EventType = "node.resources_full"
 	EventNodePredictedFailure EventType = "node.predicted_failure"
 
+	// Resize Events
+	EventResizeApplied    EventType = "resize.applied"
+	EventResizeFailed     EventType = "resize.failed"
+	EventResizeRolledBack EventType = "resize.rolled_back"
+
 	// Controller Events
 	EventDeploymentScaled  EventType = "deployment.scaled"
 	EventStatefulSetScaled EventType = "statefulset.scaled"
 	EventReplicaSetUpdated EventType = "replicaset.updated"
+	EventWorkloadIdle      EventType = "workload.idle"
 
 	// System Events
 	EventHealthCheckFailed    EventType = "system.health_check_failed"
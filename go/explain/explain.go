@@ -0,0 +1,162 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package explain keeps how the operator arrived at its latest resize
+// decision for each container, so the decision can be inspected later.
+package explain
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Decision explains a resize decision for a container: the usage it was
+// sized from, the predictions and thresholds considered, the policies that
+// applied and every step that changed the resources on the way
+type Decision struct {
+	Time        time.Time                   `json:"time"`
+	Namespace   string                      `json:"namespace"`
+	Workload    string                      `json:"workload"` // Kind/name
+	Pod         string                      `json:"pod"`
+	Container   string                      `json:"container"`
+	Window      Window                      `json:"window"`
+	Usage       Usage                       `json:"usage"`
+	Predictions []Prediction                `json:"predictions,omitempty"`
+	Thresholds  []Threshold                 `json:"thresholds,omitempty"`
+	Policies    []string                    `json:"policies,omitempty"`
+	Steps       []Step                      `json:"steps,omitempty"`
+	MemoryQoS   *MemoryQoS                  `json:"memoryQoS,omitempty"`
+	Warnings    []string                    `json:"warnings,omitempty"`
+	Current     corev1.ResourceRequirements `json:"current"`
+	Final       corev1.ResourceRequirements `json:"final"`
+	Reason      string                      `json:"reason,omitempty"`
+}
+
+// Window is the metrics window usage was taken from
+type Window struct {
+	Algorithm  string `json:"algorithm"`
+	Percentile int    `json:"percentile,omitempty"`
+	Duration   string `json:"duration,omitempty"`
+	Profile    string `json:"profile"`
+}
+
+// Usage is the usage the container was sized from
+type Usage struct {
+	CPUMilli     float64 `json:"cpuMilli"`
+	MemMB        float64 `json:"memoryMB"`
+	CPUThrottled float64 `json:"cpuThrottledPercent,omitempty"`
+
+	// Percentage of time stalled on CPU and memory (cgroup v2 PSI)
+	CPUPressure    float64 `json:"cpuPressurePercent,omitempty"`
+	MemoryPressure float64 `json:"memoryPressurePercent,omitempty"`
+}
+
+// MemoryQoS is the cgroup v2 memory protection the kubelet derives from the
+// final resources when memory QoS is enabled
+type MemoryQoS struct {
+	MinMB  int64 `json:"memoryMinMB"`            // memory.min, the memory request
+	HighMB int64 `json:"memoryHighMB,omitempty"` // memory.high, where reclaim throttles the container
+}
+
+// Prediction is a forecast considered for a resource
+type Prediction struct {
+	Resource   string  `json:"resource"`
+	Method     string  `json:"method"`
+	Value      float64 `json:"value"`
+	Confidence float64 `json:"confidence"`
+	Used       bool    `json:"used"` // Confident enough and above the usage-based request
+}
+
+// Threshold is a scaling threshold checked against a resource's utilization
+type Threshold struct {
+	Resource    string  `json:"resource"`
+	Utilization float64 `json:"utilization"` // Share of the limit, or the request without one
+	ScaleUp     float64 `json:"scaleUp"`
+	ScaleDown   float64 `json:"scaleDown"`
+	Decision    string  `json:"decision"` // scale up, scale down or no change
+}
+
+// Step is a stage that changed the resources, with the resources after it
+type Step struct {
+	Stage     string                      `json:"stage"`
+	Detail    string                      `json:"detail,omitempty"`
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+// AddStep records a stage that changed the resources
+func (d *Decision) AddStep(stage, detail string, resources corev1.ResourceRequirements) {
+	if d == nil {
+		return
+	}
+	d.Steps = append(d.Steps, Step{Stage: stage, Detail: detail, Resources: *resources.DeepCopy()})
+}
+
+// AddWarning records a risk of the decision that did not stop it
+func (d *Decision) AddWarning(warning string) {
+	if d == nil {
+		return
+	}
+	d.Warnings = append(d.Warnings, warning)
+}
+
+// Store keeps the latest decision of every container, per workload
+type Store struct {
+	mu        sync.RWMutex
+	workloads map[string]map[string]Decision // namespace/name -> container -> decision
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{workloads: make(map[string]map[string]Decision)}
+}
+
+// Record keeps a decision as the latest of its container
+func (s *Store) Record(decision Decision) {
+	key := decision.Namespace + "/" + workloadName(decision.Workload)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	containers, ok := s.workloads[key]
+	if !ok {
+		containers = make(map[string]Decision)
+		s.workloads[key] = containers
+	}
+	containers[decision.Container] = decision
+}
+
+// Latest returns the latest decision of each container of the named
+// workload, ordered by container name
+func (s *Store) Latest(namespace, name string) []Decision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	containers := s.workloads[namespace+"/"+name]
+	decisions := make([]Decision, 0, len(containers))
+	for _, decision := range containers {
+		decisions = append(decisions, decision)
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].Container < decisions[j].Container })
+	return decisions
+}
+
+// workloadName strips the kind from a Kind/name workload
+func workloadName(workload string) string {
+	if i := strings.LastIndex(workload, "/"); i >= 0 {
+		return workload[i+1:]
+	}
+	return workload
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package explain
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestStoreKeepsLatestDecisionPerContainer(t *testing.T) {
+	store := NewStore()
+	store.Record(Decision{Namespace: "prod", Workload: "Deployment/web", Container: "sidecar", Reason: "first"})
+	store.Record(Decision{Namespace: "prod", Workload: "Deployment/web", Container: "app", Reason: "first"})
+	store.Record(Decision{Namespace: "prod", Workload: "Deployment/web", Container: "app", Reason: "second"})
+	store.Record(Decision{Namespace: "dev", Workload: "Deployment/web", Container: "app", Reason: "other namespace"})
+
+	decisions := store.Latest("prod", "web")
+	if len(decisions) != 2 {
+		t.Fatalf("expected 2 decisions, got %d", len(decisions))
+	}
+	if decisions[0].Container != "app" || decisions[0].Reason != "second" {
+		t.Errorf("expected the latest decision of app first, got %s: %s", decisions[0].Container, decisions[0].Reason)
+	}
+	if decisions[1].Container != "sidecar" {
+		t.Errorf("expected sidecar second, got %s", decisions[1].Container)
+	}
+	if got := store.Latest("prod", "api"); len(got) != 0 {
+		t.Errorf("expected no decisions for an unknown workload, got %d", len(got))
+	}
+}
+
+func TestAddStepCopiesResources(t *testing.T) {
+	resources := corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}}
+	decision := &Decision{}
+	decision.AddStep("calculated", "", resources)
+	resources.Requests[corev1.ResourceCPU] = resource.MustParse("200m")
+
+	got := decision.Steps[0].Resources.Requests[corev1.ResourceCPU]
+	if got.String() != "100m" {
+		t.Errorf("expected the step to keep 100m, got %s", got.String())
+	}
+
+	var missing *Decision
+	missing.AddStep("calculated", "", resources) // must not panic
+}
@@ -43,6 +43,7 @@ type OperatorHealthChecker struct {
 	components       map[string]*ComponentStatus
 	metricsServerURL string
 	webhookServerURL string
+	metricsProvider  func() (bool, string)
 	checkInterval    time.Duration
 	lastOverallCheck time.Time
 	k8sClient        client.Client
@@ -205,8 +206,15 @@ func (h *OperatorHealthChecker) performHealthChecks() {
 	// Check controller health (always healthy if this code is running)
 	h.UpdateComponentStatus("controller", true, "Controller is running")
 
-	// Check metrics server if enabled
-	if h.metricsServerURL != "" {
+	// Check the metrics provider with its own probe when one is set,
+	// otherwise the metrics server if enabled
+	h.mu.RLock()
+	metricsProvider := h.metricsProvider
+	h.mu.RUnlock()
+	if metricsProvider != nil {
+		healthy, message := metricsProvider()
+		h.UpdateComponentStatus("metrics-provider", healthy, message)
+	} else if h.metricsServerURL != "" {
 		if err := h.CheckHTTPEndpoint(h.metricsServerURL, 2*time.Second); err != nil {
 			h.UpdateComponentStatus("metrics-provider", false, fmt.Sprintf("Metrics server check failed: %v", err))
 		} else {
@@ -318,6 +326,24 @@ func (h *OperatorHealthChecker) ReadinessCheck(_ *http.Request) error {
 	return nil
 }
 
+// MetricsProviderReadinessCheck implements the healthz.Checker interface and
+// fails while no metrics provider is available
+func (h *OperatorHealthChecker) MetricsProviderReadinessCheck(_ *http.Request) error {
+	h.mu.RLock()
+	metricsProvider := h.metricsProvider
+	h.mu.RUnlock()
+	if metricsProvider == nil {
+		return nil
+	}
+
+	healthy, message := metricsProvider()
+	h.UpdateComponentStatus("metrics-provider", healthy, message)
+	if !healthy {
+		return fmt.Errorf("metrics provider is not healthy: %s", message)
+	}
+	return nil
+}
+
 // SetCheckInterval sets the interval for periodic health checks
 func (h *OperatorHealthChecker) SetCheckInterval(interval time.Duration) {
 	h.mu.Lock()
@@ -332,6 +358,14 @@ func (h *OperatorHealthChecker) SetMetricsServerURL(url string) {
 	h.metricsServerURL = url
 }
 
+// SetMetricsProviderCheck sets the function reporting the metrics provider's
+// health; it replaces the metrics server URL check
+func (h *OperatorHealthChecker) SetMetricsProviderCheck(check func() (bool, string)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.metricsProvider = check
+}
+
 // SetWebhookServerURL sets the URL for the webhook server health check
 func (h *OperatorHealthChecker) SetWebhookServerURL(url string) {
 	h.mu.Lock()
@@ -184,6 +184,32 @@ func TestOperatorHealthChecker_ReadinessCheck(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestOperatorHealthChecker_MetricsProviderReadinessCheck(t *testing.T) {
+	checker := health.NewOperatorHealthChecker()
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+
+	// Without a provider check the metrics provider does not affect readiness
+	assert.NoError(t, checker.MetricsProviderReadinessCheck(req))
+
+	healthy := true
+	checker.SetMetricsProviderCheck(func() (bool, string) {
+		if healthy {
+			return true, "using metrics-server"
+		}
+		return false, "no metrics provider is available"
+	})
+	assert.NoError(t, checker.MetricsProviderReadinessCheck(req))
+
+	healthy = false
+	assert.Error(t, checker.MetricsProviderReadinessCheck(req))
+
+	status, exists := checker.GetComponentStatus("metrics-provider")
+	assert.True(t, exists)
+	assert.False(t, status.Healthy)
+	assert.Equal(t, "no metrics provider is available", status.Message)
+}
+
 func TestOperatorHealthChecker_GetHealthReport(t *testing.T) {
 	checker := health.NewOperatorHealthChecker()
 
@@ -0,0 +1,173 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package idle flags workloads whose usage has stayed near zero for a long
+// time. Idle workloads are candidates for scaling to zero or removal, which
+// is not something resizing can do, so they are only reported.
+package idle
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/config"
+)
+
+// staleAfter is how long a workload that is no longer observed is remembered,
+// so workloads analyzed only every few cycles keep their idle history
+const staleAfter = time.Hour
+
+// Workload is a workload flagged as idle
+type Workload struct {
+	Namespace string    `json:"namespace"`
+	Workload  string    `json:"workload"` // Kind/name
+	IdleSince time.Time `json:"idleSince"`
+	IdleFor   string    `json:"idleFor"`
+	CPUMilli  float64   `json:"cpuMilli"` // latest CPU usage summed over the workload's pods
+	Pods      int       `json:"pods"`
+}
+
+// state is what is known of a workload across cycles
+type state struct {
+	idleSince time.Time
+	lastSeen  time.Time
+	cpuMilli  float64
+	pods      int
+	flagged   bool
+}
+
+// usage is a workload's usage observed in the current cycle
+type usage struct {
+	cpuMilli float64
+	pods     map[string]bool
+}
+
+// Detector tracks the usage of every workload across analysis cycles
+type Detector struct {
+	mu        sync.Mutex
+	workloads map[string]*state // namespace/Kind/name
+	cycle     map[string]*usage
+}
+
+// NewDetector returns a detector without history
+func NewDetector() *Detector {
+	return &Detector{workloads: make(map[string]*state), cycle: make(map[string]*usage)}
+}
+
+// Observe adds a container's CPU usage to its workload's usage in the current cycle
+func (d *Detector) Observe(namespace, workload, pod string, cpuMilli float64) {
+	if d == nil {
+		return
+	}
+	key := namespace + "/" + workload
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	u, ok := d.cycle[key]
+	if !ok {
+		u = &usage{pods: make(map[string]bool)}
+		d.cycle[key] = u
+	}
+	u.cpuMilli += cpuMilli
+	u.pods[pod] = true
+}
+
+// EndCycle folds the usage observed since the last cycle into each
+// workload's idle history and returns the workloads that became idle. With
+// detection disabled all history is dropped.
+func (d *Detector) EndCycle(now time.Time, cfg config.IdleConfig) []Workload {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	if !cfg.Enabled {
+		d.workloads = make(map[string]*state)
+		d.cycle = make(map[string]*usage)
+		d.mu.Unlock()
+		return nil
+	}
+	var flagged []Workload
+	for key, u := range d.cycle {
+		s, ok := d.workloads[key]
+		if !ok {
+			s = &state{}
+			d.workloads[key] = s
+		}
+		s.lastSeen = now
+		s.cpuMilli = u.cpuMilli
+		s.pods = len(u.pods)
+		if u.cpuMilli > cfg.CPUMilli {
+			s.idleSince = time.Time{}
+			s.flagged = false
+			continue
+		}
+		if s.idleSince.IsZero() {
+			s.idleSince = now
+		}
+		if !s.flagged && now.Sub(s.idleSince) >= cfg.After {
+			s.flagged = true
+			flagged = append(flagged, s.workload(key, now))
+		}
+	}
+	for key, s := range d.workloads {
+		if now.Sub(s.lastSeen) > staleAfter {
+			delete(d.workloads, key)
+		}
+	}
+	d.cycle = make(map[string]*usage)
+	d.mu.Unlock()
+
+	sortWorkloads(flagged)
+	return flagged
+}
+
+// Idle returns the workloads currently flagged as idle
+func (d *Detector) Idle(now time.Time) []Workload {
+	out := []Workload{}
+	if d == nil {
+		return out
+	}
+	d.mu.Lock()
+	for key, s := range d.workloads {
+		if s.flagged {
+			out = append(out, s.workload(key, now))
+		}
+	}
+	d.mu.Unlock()
+	sortWorkloads(out)
+	return out
+}
+
+func (s *state) workload(key string, now time.Time) Workload {
+	namespace, workload, _ := strings.Cut(key, "/")
+	return Workload{
+		Namespace: namespace,
+		Workload:  workload,
+		IdleSince: s.idleSince,
+		IdleFor:   now.Sub(s.idleSince).Round(time.Minute).String(),
+		CPUMilli:  s.cpuMilli,
+		Pods:      s.pods,
+	}
+}
+
+func sortWorkloads(workloads []Workload) {
+	sort.Slice(workloads, func(i, j int) bool {
+		if workloads[i].Namespace != workloads[j].Namespace {
+			return workloads[i].Namespace < workloads[j].Namespace
+		}
+		return workloads[i].Workload < workloads[j].Workload
+	})
+}
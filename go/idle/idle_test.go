@@ -0,0 +1,90 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package idle
+
+import (
+	"testing"
+	"time"
+
+	"right-sizer/config"
+)
+
+func TestDetectorFlagsWorkloadsIdleLongEnough(t *testing.T) {
+	cfg := config.IdleConfig{Enabled: true, CPUMilli: 5, After: 2 * time.Hour}
+	d := NewDetector()
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	cycle := func(at time.Time, legacyCPU float64) []Workload {
+		d.Observe("shop", "Deployment/legacy", "legacy-1", legacyCPU)
+		d.Observe("shop", "Deployment/legacy", "legacy-2", 1)
+		d.Observe("shop", "Deployment/web", "web-1", 250)
+		return d.EndCycle(at, cfg)
+	}
+
+	if flagged := cycle(start, 2); len(flagged) != 0 {
+		t.Fatalf("expected nothing flagged on the first idle cycle, got %+v", flagged)
+	}
+	if flagged := cycle(start.Add(time.Hour), 3); len(flagged) != 0 {
+		t.Fatalf("expected nothing flagged before the idle threshold, got %+v", flagged)
+	}
+
+	flagged := cycle(start.Add(2*time.Hour), 1)
+	if len(flagged) != 1 || flagged[0].Workload != "Deployment/legacy" || flagged[0].Pods != 2 || flagged[0].CPUMilli != 2 {
+		t.Fatalf("expected the legacy deployment to be flagged, got %+v", flagged)
+	}
+	if !flagged[0].IdleSince.Equal(start) || flagged[0].IdleFor != "2h0m0s" {
+		t.Errorf("expected idle since the first cycle, got %+v", flagged[0])
+	}
+
+	// Flagged once, then only reported
+	if flagged := cycle(start.Add(3*time.Hour), 1); len(flagged) != 0 {
+		t.Errorf("expected a workload to be flagged only once, got %+v", flagged)
+	}
+	if idle := d.Idle(start.Add(3 * time.Hour)); len(idle) != 1 || idle[0].IdleFor != "3h0m0s" {
+		t.Errorf("expected the legacy deployment to be reported idle, got %+v", idle)
+	}
+
+	// Usage above the threshold clears the flag
+	cycle(start.Add(4*time.Hour), 50)
+	if idle := d.Idle(start.Add(4 * time.Hour)); len(idle) != 0 {
+		t.Errorf("expected no idle workloads once usage returns, got %+v", idle)
+	}
+}
+
+func TestDetectorForgetsWorkloads(t *testing.T) {
+	cfg := config.IdleConfig{Enabled: true, CPUMilli: 5, After: 0}
+	d := NewDetector()
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	d.Observe("shop", "Deployment/legacy", "legacy-1", 0)
+	if flagged := d.EndCycle(now, cfg); len(flagged) != 1 {
+		t.Fatalf("expected the workload to be flagged, got %+v", flagged)
+	}
+
+	// Workloads not observed for a while are dropped
+	d.EndCycle(now.Add(staleAfter+time.Minute), cfg)
+	if idle := d.Idle(now); len(idle) != 0 {
+		t.Errorf("expected the stale workload to be dropped, got %+v", idle)
+	}
+
+	// Disabling detection drops everything
+	d.Observe("shop", "Deployment/legacy", "legacy-1", 0)
+	d.EndCycle(now, cfg)
+	cfg.Enabled = false
+	if flagged := d.EndCycle(now, cfg); flagged != nil || len(d.Idle(now)) != 0 {
+		t.Errorf("expected no idle workloads with detection disabled, got %+v", d.Idle(now))
+	}
+}
@@ -0,0 +1,168 @@
+package analyzers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"right-sizer/internal/aiops/core"
+)
+
+// AnomalyKind classifies a usage anomaly.
+type AnomalyKind string
+
+const (
+	AnomalyMemoryGrowth  AnomalyKind = "memory_growth"
+	AnomalyCPUSaturation AnomalyKind = "cpu_saturation"
+	AnomalyRestartStorm  AnomalyKind = "restart_storm"
+)
+
+// Anomaly is a usage pattern that resizing should not react to while it lasts.
+type Anomaly struct {
+	Kind        AnomalyKind    `json:"kind"`
+	Confidence  float64        `json:"confidence"`
+	Description string         `json:"description"`
+	Attributes  map[string]any `json:"attributes,omitempty"`
+}
+
+// AnomalyThresholds controls when recent usage counts as anomalous.
+type AnomalyThresholds struct {
+	Window               time.Duration // recent usage compared against the history before it
+	MemoryGrowthPercent  float64       // memory growth over the baseline that is anomalous
+	CPUSaturationPercent float64       // share of the CPU limit at which a sample is saturated
+	SaturatedShare       float64       // share of recent samples that must be saturated
+	RestartThreshold     int           // restarts within the window that make a storm
+	MinSamples           int           // samples needed in each of the recent and baseline series
+}
+
+// DefaultAnomalyThresholds returns sane defaults.
+func DefaultAnomalyThresholds() AnomalyThresholds {
+	return AnomalyThresholds{
+		Window:               15 * time.Minute,
+		MemoryGrowthPercent:  50,
+		CPUSaturationPercent: 95,
+		SaturatedShare:       0.8,
+		RestartThreshold:     3,
+		MinSamples:           3,
+	}
+}
+
+// DetectMemoryGrowth reports memory that grew sharply within the window
+// compared with the median before it and has not come back down, the shape
+// of a leak or runaway cache rather than a usage spike.
+func DetectMemoryGrowth(series []core.SamplePoint, now time.Time, t AnomalyThresholds) *Anomaly {
+	baseline, recent := splitSeries(series, now.Add(-t.Window))
+	if len(baseline) < t.MinSamples || len(recent) < t.MinSamples {
+		return nil
+	}
+
+	base := median(baseline)
+	if base <= 0 {
+		return nil
+	}
+
+	// Compare the tail of the window so a single spike does not count
+	tail := recent[len(recent)-min(3, len(recent)):]
+	current := 0.0
+	for _, p := range tail {
+		current += p.Value
+	}
+	current /= float64(len(tail))
+	if current < recent[0].Value {
+		return nil // Receding rather than growing
+	}
+
+	growth := (current - base) / base * 100
+	if growth < t.MemoryGrowthPercent {
+		return nil
+	}
+
+	return &Anomaly{
+		Kind:        AnomalyMemoryGrowth,
+		Confidence:  core.Clamp01(0.6 + 0.35*(growth/t.MemoryGrowthPercent-1)),
+		Description: fmt.Sprintf("Memory grew %.0f%% in %v (%.0fMB to %.0fMB)", growth, t.Window, base, current),
+		Attributes: map[string]any{
+			"baselineMB":    base,
+			"currentMB":     current,
+			"growthPercent": growth,
+		},
+	}
+}
+
+// DetectCPUSaturation reports a container that spent most of the window at
+// its CPU limit. Containers without a limit cannot saturate.
+func DetectCPUSaturation(series []core.SamplePoint, limitMilli float64, now time.Time, t AnomalyThresholds) *Anomaly {
+	if limitMilli <= 0 {
+		return nil
+	}
+	_, recent := splitSeries(series, now.Add(-t.Window))
+	if len(recent) < t.MinSamples {
+		return nil
+	}
+
+	threshold := limitMilli * t.CPUSaturationPercent / 100
+	saturated := 0
+	for _, p := range recent {
+		if p.Value >= threshold {
+			saturated++
+		}
+	}
+	share := float64(saturated) / float64(len(recent))
+	if share < t.SaturatedShare {
+		return nil
+	}
+
+	return &Anomaly{
+		Kind:        AnomalyCPUSaturation,
+		Confidence:  core.Clamp01(share),
+		Description: fmt.Sprintf("CPU at its %.0fm limit for %.0f%% of the last %v", limitMilli, share*100, t.Window),
+		Attributes: map[string]any{
+			"limitMilli":     limitMilli,
+			"saturatedShare": share,
+		},
+	}
+}
+
+// DetectRestartStorm reports a container that restarted at least the
+// threshold number of times within the window.
+func DetectRestartStorm(restarts int, t AnomalyThresholds) *Anomaly {
+	if t.RestartThreshold <= 0 || restarts < t.RestartThreshold {
+		return nil
+	}
+	return &Anomaly{
+		Kind:        AnomalyRestartStorm,
+		Confidence:  core.Clamp01(0.7 + 0.1*float64(restarts-t.RestartThreshold)),
+		Description: fmt.Sprintf("Restarted %d times in %v", restarts, t.Window),
+		Attributes: map[string]any{
+			"restarts": restarts,
+		},
+	}
+}
+
+// splitSeries sorts the series and splits it into the points before and
+// from the cutoff.
+func splitSeries(series []core.SamplePoint, cutoff time.Time) (before, after []core.SamplePoint) {
+	sorted := make([]core.SamplePoint, len(series))
+	copy(sorted, series)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	i := sort.Search(len(sorted), func(i int) bool {
+		return !sorted[i].Timestamp.Before(cutoff)
+	})
+	return sorted[:i], sorted[i:]
+}
+
+// median returns the median value of the points.
+func median(points []core.SamplePoint) float64 {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.Value
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
@@ -0,0 +1,85 @@
+package analyzers
+
+import (
+	"testing"
+	"time"
+
+	"right-sizer/internal/aiops/core"
+)
+
+// minuteSeries builds one point per minute ending at now from the value function
+func minuteSeries(now time.Time, minutes int, value func(minute int) float64) []core.SamplePoint {
+	points := make([]core.SamplePoint, 0, minutes)
+	for m := 0; m < minutes; m++ {
+		points = append(points, core.SamplePoint{
+			Timestamp: now.Add(-time.Duration(minutes-1-m) * time.Minute),
+			Value:     value(m),
+		})
+	}
+	return points
+}
+
+// TestDetectMemoryGrowth verifies sustained growth is flagged and spikes are not
+func TestDetectMemoryGrowth(t *testing.T) {
+	now := time.Now()
+	thresholds := DefaultAnomalyThresholds()
+
+	// Flat at 200MB for 45 minutes, then climbing to 500MB within the window
+	growing := minuteSeries(now, 60, func(m int) float64 {
+		if m < 45 {
+			return 200
+		}
+		return 200 + float64(m-44)*20
+	})
+	anomaly := DetectMemoryGrowth(growing, now, thresholds)
+	if anomaly == nil || anomaly.Kind != AnomalyMemoryGrowth {
+		t.Fatalf("expected memory growth, got %+v", anomaly)
+	}
+	if growth := anomaly.Attributes["growthPercent"].(float64); growth < 100 {
+		t.Errorf("expected growth over 100%%, got %.0f%%", growth)
+	}
+
+	// A spike that already came back down is not growth
+	spike := minuteSeries(now, 60, func(m int) float64 {
+		if m >= 48 && m < 52 {
+			return 600
+		}
+		return 200
+	})
+	if anomaly := DetectMemoryGrowth(spike, now, thresholds); anomaly != nil {
+		t.Errorf("expected no anomaly for a spike, got %+v", anomaly)
+	}
+
+	// Without history before the window there is no baseline
+	if anomaly := DetectMemoryGrowth(growing[45:], now, thresholds); anomaly != nil {
+		t.Errorf("expected no anomaly without a baseline, got %+v", anomaly)
+	}
+}
+
+// TestDetectCPUSaturation verifies containers pinned at their limit are flagged
+func TestDetectCPUSaturation(t *testing.T) {
+	now := time.Now()
+	thresholds := DefaultAnomalyThresholds()
+	pinned := minuteSeries(now, 20, func(int) float64 { return 498 })
+
+	if anomaly := DetectCPUSaturation(pinned, 500, now, thresholds); anomaly == nil || anomaly.Kind != AnomalyCPUSaturation {
+		t.Fatalf("expected CPU saturation, got %+v", anomaly)
+	}
+	if anomaly := DetectCPUSaturation(pinned, 0, now, thresholds); anomaly != nil {
+		t.Errorf("expected no saturation without a limit, got %+v", anomaly)
+	}
+	if anomaly := DetectCPUSaturation(pinned, 1000, now, thresholds); anomaly != nil {
+		t.Errorf("expected no saturation at half the limit, got %+v", anomaly)
+	}
+}
+
+// TestDetectRestartStorm verifies the restart threshold
+func TestDetectRestartStorm(t *testing.T) {
+	thresholds := DefaultAnomalyThresholds()
+	if anomaly := DetectRestartStorm(thresholds.RestartThreshold-1, thresholds); anomaly != nil {
+		t.Errorf("expected no storm below the threshold, got %+v", anomaly)
+	}
+	if anomaly := DetectRestartStorm(thresholds.RestartThreshold, thresholds); anomaly == nil || anomaly.Kind != AnomalyRestartStorm {
+		t.Errorf("expected a restart storm, got %+v", anomaly)
+	}
+}
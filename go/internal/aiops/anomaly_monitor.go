@@ -0,0 +1,333 @@
+package aiops
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/events"
+	"right-sizer/internal/aiops/analyzers"
+	"right-sizer/internal/aiops/core"
+	"right-sizer/logger"
+	"right-sizer/predictor"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+)
+
+// UsageHistory provides the per-container usage history anomalies are
+// detected in. The prediction engine implements it.
+type UsageHistory interface {
+	GetHistoricalData(namespace, podName, container, resourceType string, since time.Time) (predictor.HistoricalData, error)
+}
+
+// ActiveAnomaly is an anomaly that has not subsided yet.
+type ActiveAnomaly struct {
+	analyzers.Anomaly
+	Namespace  string    `json:"namespace"`
+	Pod        string    `json:"pod"`
+	Container  string    `json:"container"`
+	Since      time.Time `json:"since"`
+	LastSeen   time.Time `json:"lastSeen"`
+	podUID     types.UID
+	incidentID string
+}
+
+// restartSample is a container's restart count at a point in time.
+type restartSample struct {
+	at    time.Time
+	count int32
+}
+
+// AnomalyMonitor periodically checks running containers for sudden memory
+// growth, CPU saturation and restart storms. Anomalies are recorded as
+// Kubernetes events, published on the event bus for notifications and kept
+// active until they subside, so resizes can be held back instead of chasing
+// a leak.
+type AnomalyMonitor struct {
+	History   UsageHistory   // Usage history; without it only restart storms are detected
+	Incidents *IncidentStore // Optional; anomalies are also recorded as incidents
+
+	clientset kubernetes.Interface
+	eventBus  *events.EventBus
+	recorder  record.EventRecorder
+	interval  time.Duration
+
+	mu       sync.RWMutex
+	active   map[string]*ActiveAnomaly
+	restarts map[string][]restartSample
+}
+
+// NewAnomalyMonitor creates an anomaly monitor. The event bus and recorder may be nil.
+func NewAnomalyMonitor(clientset kubernetes.Interface, eventBus *events.EventBus, recorder record.EventRecorder) *AnomalyMonitor {
+	return &AnomalyMonitor{
+		clientset: clientset,
+		eventBus:  eventBus,
+		recorder:  recorder,
+		interval:  time.Minute,
+		active:    make(map[string]*ActiveAnomaly),
+		restarts:  make(map[string][]restartSample),
+	}
+}
+
+// Start checks for anomalies until the context is cancelled.
+func (m *AnomalyMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	logger.Info("[AIOPS] anomaly monitor started interval=%s", m.interval)
+	for {
+		select {
+		case <-ticker.C:
+			m.Check(ctx)
+		case <-ctx.Done():
+			logger.Info("[AIOPS] anomaly monitor stopping (context canceled)")
+			return
+		}
+	}
+}
+
+// Check runs one detection pass over all running pods.
+func (m *AnomalyMonitor) Check(ctx context.Context) {
+	cfg := config.Get()
+	settings := cfg.Anomalies
+	if !settings.Enabled {
+		m.mu.Lock()
+		m.active = make(map[string]*ActiveAnomaly)
+		m.restarts = make(map[string][]restartSample)
+		m.mu.Unlock()
+		return
+	}
+
+	pods, err := m.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Error("[AIOPS] anomaly monitor list pods error: %v", err)
+		return
+	}
+
+	thresholds := analyzers.DefaultAnomalyThresholds()
+	thresholds.Window = settings.Window
+	thresholds.MemoryGrowthPercent = settings.MemoryGrowthPercent
+	thresholds.CPUSaturationPercent = settings.CPUSaturationPercent
+	thresholds.RestartThreshold = settings.RestartThreshold
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || !cfg.IsNamespaceIncluded(pod.Namespace) {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			key := anomalyKey(pod.Namespace, pod.Name, container.Name)
+			seen[key] = true
+			if anomaly := m.detect(pod, container, thresholds, now); anomaly != nil {
+				m.observe(pod, container.Name, anomaly, now)
+			}
+		}
+	}
+
+	m.resolve(seen, settings.ClearAfter, now)
+}
+
+// detect returns the most pressing anomaly of a container, if any.
+func (m *AnomalyMonitor) detect(pod *corev1.Pod, container corev1.Container, t analyzers.AnomalyThresholds, now time.Time) *analyzers.Anomaly {
+	if anomaly := analyzers.DetectRestartStorm(m.recordRestarts(pod, container.Name, t.Window, now), t); anomaly != nil {
+		return anomaly
+	}
+	if m.History == nil {
+		return nil
+	}
+
+	since := now.Add(-5 * t.Window)
+	if memory := m.series(pod, container.Name, "memory", since); memory != nil {
+		if anomaly := analyzers.DetectMemoryGrowth(memory, now, t); anomaly != nil {
+			return anomaly
+		}
+	}
+	if cpu := m.series(pod, container.Name, "cpu", since); cpu != nil {
+		limit := float64(container.Resources.Limits.Cpu().MilliValue())
+		if anomaly := analyzers.DetectCPUSaturation(cpu, limit, now, t); anomaly != nil {
+			return anomaly
+		}
+	}
+	return nil
+}
+
+// series returns a container's usage history since a point in time.
+func (m *AnomalyMonitor) series(pod *corev1.Pod, container, resourceType string, since time.Time) []core.SamplePoint {
+	data, err := m.History.GetHistoricalData(pod.Namespace, pod.Name, container, resourceType, since)
+	if err != nil || len(data.DataPoints) == 0 {
+		return nil
+	}
+	points := make([]core.SamplePoint, len(data.DataPoints))
+	for i, dp := range data.DataPoints {
+		points[i] = core.SamplePoint{Timestamp: dp.Timestamp, Value: dp.Value}
+	}
+	return points
+}
+
+// recordRestarts keeps the container's restart count and returns how many
+// restarts happened within the window.
+func (m *AnomalyMonitor) recordRestarts(pod *corev1.Pod, container string, window time.Duration, now time.Time) int {
+	var count int32
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			count = status.RestartCount
+		}
+	}
+
+	key := anomalyKey(pod.Namespace, pod.Name, container)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	samples := m.restarts[key]
+	kept := samples[:0]
+	for _, s := range samples {
+		// The count only drops when the pod was replaced under the same name
+		if now.Sub(s.at) <= window && s.count <= count {
+			kept = append(kept, s)
+		}
+	}
+	kept = append(kept, restartSample{at: now, count: count})
+	m.restarts[key] = kept
+	return int(count - kept[0].count)
+}
+
+// observe records a detected anomaly, announcing it when it is new.
+func (m *AnomalyMonitor) observe(pod *corev1.Pod, container string, anomaly *analyzers.Anomaly, now time.Time) {
+	key := anomalyKey(pod.Namespace, pod.Name, container)
+
+	m.mu.Lock()
+	if active, ok := m.active[key]; ok {
+		active.Anomaly = *anomaly
+		active.LastSeen = now
+		m.mu.Unlock()
+		return
+	}
+	active := &ActiveAnomaly{
+		Anomaly:   *anomaly,
+		Namespace: pod.Namespace,
+		Pod:       pod.Name,
+		Container: container,
+		Since:     now,
+		LastSeen:  now,
+		podUID:    pod.UID,
+	}
+	m.active[key] = active
+	m.mu.Unlock()
+
+	message := fmt.Sprintf("Container %s: %s.", container, anomaly.Description)
+	if config.Get().Anomalies.SuppressResizes {
+		message += " Resizes are paused until it subsides."
+	}
+	logger.Warn("[AIOPS] anomaly %s in %s: %s", anomaly.Kind, key, anomaly.Description)
+
+	if m.recorder != nil {
+		m.recorder.Event(pod, corev1.EventTypeWarning, "UsageAnomalyDetected", message)
+	}
+
+	if m.eventBus != nil {
+		severity := events.SeverityWarning
+		if anomaly.Kind == analyzers.AnomalyRestartStorm {
+			severity = events.SeverityError
+		}
+		m.eventBus.PublishAsync(events.NewEvent(events.EventResourceAnomaly, config.Get().ClusterID, pod.Namespace, pod.Name, severity, message).
+			WithDetails(map[string]interface{}{
+				"containerName": container,
+				"kind":          string(anomaly.Kind),
+				"confidence":    anomaly.Confidence,
+				"attributes":    anomaly.Attributes,
+			}).
+			WithTags("anomaly", string(anomaly.Kind)))
+	}
+
+	if m.Incidents != nil {
+		incidentType := IncidentMemoryLeak
+		switch anomaly.Kind {
+		case analyzers.AnomalyCPUSaturation:
+			incidentType = IncidentCPUStarvation
+		case analyzers.AnomalyRestartStorm:
+			incidentType = IncidentRestartStorm
+		}
+		inc := NewIncident(GenerateIncidentID("anomaly"), incidentType, SeverityWarning, key)
+		inc.InitialMessage = message
+		evidence := Evidence{
+			ID:          fmt.Sprintf("anomaly-%s-%d", anomaly.Kind, now.UnixNano()),
+			Category:    "METRIC",
+			Description: anomaly.Description,
+			Confidence:  anomaly.Confidence,
+			Data:        anomaly.Attributes,
+			Timestamp:   now,
+		}
+		status := StatusDetected
+		m.Incidents.UpsertIncident(inc, []Evidence{evidence}, nil, &status)
+
+		m.mu.Lock()
+		active.incidentID = inc.ID
+		m.mu.Unlock()
+	}
+}
+
+// resolve clears anomalies not detected for clearAfter and those of
+// containers that are gone.
+func (m *AnomalyMonitor) resolve(seen map[string]bool, clearAfter time.Duration, now time.Time) {
+	var resolved []*ActiveAnomaly
+
+	m.mu.Lock()
+	for key, active := range m.active {
+		if seen[key] && now.Sub(active.LastSeen) < clearAfter {
+			continue
+		}
+		delete(m.active, key)
+		if seen[key] {
+			resolved = append(resolved, active)
+		}
+	}
+	for key := range m.restarts {
+		if !seen[key] {
+			delete(m.restarts, key)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, active := range resolved {
+		message := fmt.Sprintf("Container %s: %s anomaly subsided after %v.", active.Container, active.Kind, now.Sub(active.Since).Round(time.Second))
+		logger.Info("[AIOPS] anomaly %s in %s resolved", active.Kind, anomalyKey(active.Namespace, active.Pod, active.Container))
+		if m.recorder != nil {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: active.Namespace, Name: active.Pod, UID: active.podUID}}
+			m.recorder.Event(pod, corev1.EventTypeNormal, "UsageAnomalyResolved", message)
+		}
+		if m.Incidents == nil || active.incidentID == "" {
+			continue
+		}
+		if inc, ok := m.Incidents.Get(active.incidentID); ok {
+			status := StatusResolved
+			m.Incidents.UpsertIncident(&inc, nil, nil, &status)
+		}
+	}
+}
+
+// SuppressResize reports whether resizes of a container are held back by
+// an active anomaly, and why.
+func (m *AnomalyMonitor) SuppressResize(namespace, pod, container string) (string, bool) {
+	settings := config.Get().Anomalies
+	if !settings.Enabled || !settings.SuppressResizes {
+		return "", false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	active, ok := m.active[anomalyKey(namespace, pod, container)]
+	if !ok {
+		return "", false
+	}
+	return active.Description, true
+}
+
+func anomalyKey(namespace, pod, container string) string {
+	return namespace + "/" + pod + "/" + container
+}
@@ -0,0 +1,119 @@
+package aiops
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/predictor"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// growingHistory serves memory that doubled over the last 15 minutes
+type growingHistory struct{}
+
+func (growingHistory) GetHistoricalData(namespace, podName, container, resourceType string, since time.Time) (predictor.HistoricalData, error) {
+	data := predictor.HistoricalData{ResourceType: resourceType}
+	if resourceType != "memory" {
+		return data, nil
+	}
+	now := time.Now()
+	for m := 60; m >= 0; m-- {
+		value := 200.0
+		if m < 15 {
+			value = 200 + float64(15-m)*15
+		}
+		data.DataPoints = append(data.DataPoints, predictor.DataPoint{Timestamp: now.Add(-time.Duration(m) * time.Minute), Value: value})
+	}
+	return data, nil
+}
+
+func anomalyTestPod(restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+		}}},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+		},
+	}
+}
+
+// TestAnomalyMonitorSuppressesResizes verifies a detected anomaly pauses
+// resizes, is recorded as an event and incident, and clears once it subsides
+func TestAnomalyMonitorSuppressesResizes(t *testing.T) {
+	config.Load()
+	clientset := fake.NewSimpleClientset(anomalyTestPod(0))
+	recorder := record.NewFakeRecorder(10)
+	store := NewIncidentStore(DefaultIncidentStoreConfig(), nil)
+	defer store.Stop()
+
+	monitor := NewAnomalyMonitor(clientset, nil, recorder)
+	monitor.Incidents = store
+	ctx := context.Background()
+
+	monitor.Check(ctx)
+	if _, suppress := monitor.SuppressResize("shop", "web", "app"); suppress {
+		t.Fatal("expected no anomaly without history")
+	}
+
+	monitor.History = growingHistory{}
+	monitor.Check(ctx)
+	reason, suppress := monitor.SuppressResize("shop", "web", "app")
+	if !suppress || !strings.Contains(reason, "Memory grew") {
+		t.Fatalf("expected resizes to be suppressed for memory growth, got %q %v", reason, suppress)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning UsageAnomalyDetected") {
+		t.Errorf("unexpected event %q", event)
+	}
+	if incidents := store.List(IncidentFilter{}); len(incidents) != 1 || incidents[0].Type != IncidentMemoryLeak {
+		t.Errorf("expected one memory incident, got %+v", incidents)
+	}
+
+	// Once the growth is gone for clearAfter the anomaly is resolved
+	monitor.History = nil
+	monitor.mu.Lock()
+	monitor.active["shop/web/app"].LastSeen = time.Now().Add(-time.Hour)
+	monitor.mu.Unlock()
+	monitor.Check(ctx)
+	if _, suppress := monitor.SuppressResize("shop", "web", "app"); suppress {
+		t.Fatal("expected the anomaly to be resolved")
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Normal UsageAnomalyResolved") {
+		t.Errorf("unexpected event %q", event)
+	}
+}
+
+// TestAnomalyMonitorRestartStorm verifies restarts within the window make a storm
+func TestAnomalyMonitorRestartStorm(t *testing.T) {
+	config.Load()
+	monitor := NewAnomalyMonitor(nil, nil, nil)
+	now := time.Now()
+	window := 15 * time.Minute
+
+	if restarts := monitor.recordRestarts(anomalyTestPod(2), "app", window, now.Add(-30*time.Minute)); restarts != 0 {
+		t.Fatalf("expected no restarts on the first observation, got %d", restarts)
+	}
+	if restarts := monitor.recordRestarts(anomalyTestPod(3), "app", window, now.Add(-10*time.Minute)); restarts != 0 {
+		t.Fatalf("expected the observation outside the window to be dropped, got %d", restarts)
+	}
+	if restarts := monitor.recordRestarts(anomalyTestPod(7), "app", window, now); restarts != 4 {
+		t.Fatalf("expected 4 restarts within the window, got %d", restarts)
+	}
+	// A replaced pod starts counting again
+	if restarts := monitor.recordRestarts(anomalyTestPod(0), "app", window, now.Add(time.Minute)); restarts != 0 {
+		t.Fatalf("expected the count to reset, got %d", restarts)
+	}
+}
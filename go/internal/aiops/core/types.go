@@ -62,6 +62,7 @@ const (
 	IncidentNetworkPolicyBlock IncidentType = "NETWORK_POLICY_BLOCK"
 	IncidentConfigRegression   IncidentType = "CONFIG_REGRESSION"
 	IncidentNoisyNeighbor      IncidentType = "NOISY_NEIGHBOR"
+	IncidentRestartStorm       IncidentType = "RESTART_STORM"
 )
 
 // IncidentStatus models lifecycle progression of an incident investigation.
@@ -16,6 +16,10 @@ func (m *mockMetricsProvider) FetchPodMetrics(ctx context.Context, namespace, po
 	return metrics.Metrics{CPUMilli: 150, MemMB: 300}, nil
 }
 
+func (m *mockMetricsProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (metrics.ContainerMetrics, error) {
+	return metrics.ContainerMetrics{"app": {CPUMilli: 150, MemMB: 300}}, nil
+}
+
 // TestEngineStartStop ensures the AIOps engine starts goroutines without panic and stops cleanly.
 func TestEngineStartStop(t *testing.T) {
 	engine := NewEngine(nil, &mockMetricsProvider{}, narrative.LLMConfig{}, nil, "test-cluster")
@@ -32,6 +32,7 @@ const (
 	IncidentNetworkPolicyBlock IncidentType = "NETWORK_POLICY_BLOCK"
 	IncidentConfigRegression   IncidentType = "CONFIG_REGRESSION"
 	IncidentNoisyNeighbor      IncidentType = "NOISY_NEIGHBOR"
+	IncidentRestartStorm       IncidentType = "RESTART_STORM"
 )
 
 // IncidentStatus represents lifecycle progression.
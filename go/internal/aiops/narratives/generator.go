@@ -1,14 +1,28 @@
 package narrative
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"right-sizer/internal/aiops/analyzers"
 	"right-sizer/internal/aiops/collector"
 )
 
+const (
+	// defaultAPIURL is the OpenAI-compatible chat completions endpoint used when none is configured.
+	defaultAPIURL = "https://api.openai.com/v1/chat/completions"
+	// defaultModelName is the model used when none is configured.
+	defaultModelName = "gpt-4o-mini"
+	// llmTimeout bounds a single completion request.
+	llmTimeout = 60 * time.Second
+)
+
 // LLMConfig holds the configuration for a Large Language Model provider.
 type LLMConfig struct {
 	APIKey    string
@@ -19,11 +33,17 @@ type LLMConfig struct {
 // NarrativeGenerator generates human-readable RCA narratives.
 type NarrativeGenerator struct {
 	config LLMConfig
+	client *http.Client
 }
 
 // NewNarrativeGenerator creates a new NarrativeGenerator.
 func NewNarrativeGenerator(config LLMConfig) *NarrativeGenerator {
-	return &NarrativeGenerator{config: config}
+	return &NarrativeGenerator{config: config, client: &http.Client{Timeout: llmTimeout}}
+}
+
+// Enabled reports whether an LLM is configured.
+func (g *NarrativeGenerator) Enabled() bool {
+	return g.config.APIKey != ""
 }
 
 // GenerateOOMNarrative creates a human-readable story for an OOM event.
@@ -161,9 +181,81 @@ func emptyFallback(s, fb string) string {
 	return s
 }
 
-// callLLM is a placeholder for real LLM API integration.
+// chatMessage is a message of an OpenAI-compatible chat completion.
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// callLLM sends the prompt to the configured OpenAI-compatible chat
+// completions endpoint and returns the reply.
 func (g *NarrativeGenerator) callLLM(ctx context.Context, prompt string) (string, error) {
-	return "LLM integration not yet implemented.", nil
+	if g.config.APIKey == "" {
+		return "", fmt.Errorf("no LLM API key configured")
+	}
+	url := emptyFallback(g.config.APIURL, defaultAPIURL)
+	body, err := json.Marshal(map[string]interface{}{
+		"model":    emptyFallback(g.config.ModelName, defaultModelName),
+		"messages": []chatMessage{{Role: "user", Content: prompt}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.config.APIKey)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("LLM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("LLM returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return "", fmt.Errorf("failed to decode LLM response: %w", err)
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("LLM returned no completion")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// GenerateNamespaceReport writes the narrative of a namespace's periodic
+// report from the facts gathered for it.
+func (g *NarrativeGenerator) GenerateNamespaceReport(ctx context.Context, namespace, period, facts string) (string, error) {
+	prompt := fmt.Sprintf(
+		"ROLE: Senior Kubernetes FinOps and Site Reliability Engineer\n"+
+			"TASK: Write a %s resource report for the owners of the Kubernetes namespace %q.\n\n"+
+			"FACTS:\n%s\n"+
+			"OUTPUT REQUIREMENTS:\n"+
+			"1. Open with a two sentence summary of how well the namespace is sized.\n"+
+			"2. Call out the most over- and under-provisioned workloads by name.\n"+
+			"3. State the savings achieved and still available in money.\n"+
+			"4. Mention incidents only when there were any.\n"+
+			"5. End with at most 3 concrete next steps.\n"+
+			"6. Use plain text, keep it under 250 words and do not invent numbers.\n\n"+
+			"Write the report now.",
+		period, namespace, facts,
+	)
+
+	narrative, err := g.callLLM(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(narrative), nil
 }
 
 // GenerateHealthSnapshotSummary generates a high-level summary of cluster health.
@@ -0,0 +1,140 @@
+// Copyright (C) 2025 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultRefreshInterval is how often a Monitor re-detects capabilities by default.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// Monitor keeps the detected capabilities current by re-running detection
+// periodically, so features that appear or disappear while the operator runs
+// (e.g. in-place resize after a cluster upgrade to 1.33, or metrics-server
+// being installed) take effect without a restart.
+type Monitor struct {
+	detector *Detector
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.RWMutex
+	current  Capabilities
+	detected bool
+	handlers []func(previous, current Capabilities)
+	// refreshHandlers run after every successful detection
+	refreshHandlers []func(current Capabilities)
+}
+
+// NewMonitor constructs a Monitor re-detecting capabilities every interval
+// (DefaultRefreshInterval when not positive).
+func NewMonitor(detector *Detector, interval time.Duration) *Monitor {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+	return &Monitor{detector: detector, interval: interval, timeout: 30 * time.Second}
+}
+
+// OnChange registers a handler called after the first successful detection
+// and whenever a later detection finds different capabilities. Handlers run
+// synchronously, in registration order, and must not block.
+func (m *Monitor) OnChange(handler func(previous, current Capabilities)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, handler)
+}
+
+// OnRefresh registers a handler called after every successful detection,
+// whether or not the capabilities changed. Handlers must not block.
+func (m *Monitor) OnRefresh(handler func(current Capabilities)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshHandlers = append(m.refreshHandlers, handler)
+}
+
+// Current returns the latest detected capabilities and whether detection has
+// succeeded at least once.
+func (m *Monitor) Current() (Capabilities, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current, m.detected
+}
+
+// Refresh detects the capabilities now and notifies the handlers of a change.
+// On error the previous capabilities are kept.
+func (m *Monitor) Refresh(ctx context.Context) (Capabilities, error) {
+	caps, err := m.detector.WithTimeout(ctx, m.timeout)
+	if err != nil {
+		current, _ := m.Current()
+		return current, err
+	}
+
+	m.mu.Lock()
+	previous, detected := m.current, m.detected
+	m.current, m.detected = caps, true
+	handlers := append([]func(previous, current Capabilities){}, m.handlers...)
+	refreshHandlers := append([]func(current Capabilities){}, m.refreshHandlers...)
+	m.mu.Unlock()
+
+	for _, handler := range refreshHandlers {
+		handler(caps)
+	}
+	if !detected || previous != caps {
+		for _, handler := range handlers {
+			handler(previous, caps)
+		}
+	}
+	return caps, nil
+}
+
+// Start re-detects the capabilities every interval until ctx is done. Errors
+// are passed to onError, which may be nil.
+func (m *Monitor) Start(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := m.Refresh(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// Changed lists the names of the features whose availability differs between
+// two capability sets, for logging.
+func Changed(previous, current Capabilities) []string {
+	var changed []string
+	add := func(name string, before, after bool) {
+		if before != after {
+			changed = append(changed, name)
+		}
+	}
+	add("supportedVersion", previous.Supported, current.Supported)
+	add("ephemeralContainers", previous.EphemeralContainers, current.EphemeralContainers)
+	add("podResize", previous.PodResize, current.PodResize)
+	add("metricsServer", previous.MetricsServerAvailable, current.MetricsServerAvailable)
+	add("dra", previous.DynamicResourceAllocation, current.DynamicResourceAllocation)
+	add("memQoS", previous.MemoryQoS, current.MemoryQoS)
+	add("inPlaceVS", previous.InPlacePodVerticalScaling, current.InPlacePodVerticalScaling)
+	return changed
+}
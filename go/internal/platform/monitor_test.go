@@ -0,0 +1,87 @@
+package platform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newFakeCluster(minor string, resources ...string) (*fake.Clientset, *fakediscovery.FakeDiscovery) {
+	cs := fake.NewSimpleClientset()
+	disc := cs.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.FakedServerVersion = &version.Info{Major: "1", Minor: minor}
+	setCoreResources(disc, resources...)
+	return cs, disc
+}
+
+func setCoreResources(disc *fakediscovery.FakeDiscovery, resources ...string) {
+	list := &metav1.APIResourceList{GroupVersion: "v1"}
+	for _, name := range resources {
+		list.APIResources = append(list.APIResources, metav1.APIResource{Name: name})
+	}
+	disc.Resources = []*metav1.APIResourceList{list}
+}
+
+func TestMonitor_Refresh(t *testing.T) {
+	cs, disc := newFakeCluster("32", "pods")
+	monitor := NewMonitor(NewDetector(cs), 0)
+
+	var changes []Capabilities
+	monitor.OnChange(func(previous, current Capabilities) {
+		changes = append(changes, current)
+	})
+
+	_, detected := monitor.Current()
+	assert.False(t, detected)
+
+	caps, err := monitor.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.False(t, caps.PodResize)
+	require.Len(t, changes, 1, "first detection notifies the handlers")
+
+	// Unchanged capabilities do not notify again
+	_, err = monitor.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	// Cluster upgraded to 1.33 with pods/resize
+	disc.FakedServerVersion = &version.Info{Major: "1", Minor: "33"}
+	setCoreResources(disc, "pods", "pods/resize")
+	caps, err = monitor.Refresh(context.Background())
+	require.NoError(t, err)
+	assert.True(t, caps.PodResize)
+	assert.True(t, caps.Supported)
+	require.Len(t, changes, 2)
+	assert.True(t, changes[1].PodResize)
+
+	current, detected := monitor.Current()
+	assert.True(t, detected)
+	assert.Equal(t, caps, current)
+}
+
+func TestMonitor_OnRefresh(t *testing.T) {
+	cs, _ := newFakeCluster("33", "pods", "pods/resize")
+	monitor := NewMonitor(NewDetector(cs), 0)
+
+	refreshes := 0
+	monitor.OnRefresh(func(Capabilities) { refreshes++ })
+
+	for i := 0; i < 2; i++ {
+		_, err := monitor.Refresh(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, refreshes, "every detection notifies, even when nothing changed")
+}
+
+func TestChanged(t *testing.T) {
+	previous := Capabilities{Supported: true, PodResize: false, MetricsServerAvailable: true}
+	current := Capabilities{Supported: true, PodResize: true, MetricsServerAvailable: false}
+	assert.Equal(t, []string{"podResize", "metricsServer"}, Changed(previous, current))
+	assert.Empty(t, Changed(current, current))
+}
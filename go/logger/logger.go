@@ -20,6 +20,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -33,8 +34,10 @@ const (
 	ERROR
 )
 
-// Logger represents a logger with configurable level
+// Logger represents a logger with configurable level. The level may change
+// while other goroutines log.
 type Logger struct {
+	mu     sync.RWMutex // guards level
 	level  LogLevel
 	prefix string
 	logger *log.Logger
@@ -43,6 +46,8 @@ type Logger struct {
 var (
 	// Global logger instance
 	Global *Logger
+	// globalMu guards Global once goroutines may be logging
+	globalMu sync.RWMutex
 
 	// Color codes for different log levels
 	colorReset  = "\033[0m"
@@ -63,11 +68,25 @@ func NewLogger(levelStr string, prefix string) *Logger {
 	}
 }
 
-// Init initializes the global logger
+// Init initializes the global logger. Once initialized, only its level
+// changes, so loggers obtained with GetLogger follow the new level.
 func Init(levelStr string) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	if Global != nil {
+		Global.SetLevel(levelStr)
+		return
+	}
 	Global = NewLogger(levelStr, "")
 }
 
+// global returns the global logger, or nil before it is created
+func global() *Logger {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return Global
+}
+
 // parseLogLevel converts a string log level to LogLevel
 func parseLogLevel(levelStr string) LogLevel {
 	switch strings.ToLower(levelStr) {
@@ -112,7 +131,7 @@ func (l *Logger) formatMessage(level string, color string, format string, args .
 
 // Debug logs a debug message
 func (l *Logger) Debug(format string, args ...interface{}) {
-	if l.level <= DEBUG {
+	if l.enabled(DEBUG) {
 		msg := l.formatMessage("DEBUG", colorGray, format, args...)
 		l.logger.Println(msg)
 	}
@@ -120,7 +139,7 @@ func (l *Logger) Debug(format string, args ...interface{}) {
 
 // Info logs an info message (without level prefix for cleaner output)
 func (l *Logger) Info(format string, args ...interface{}) {
-	if l.level <= INFO {
+	if l.enabled(INFO) {
 		timestamp := time.Now().Format("2006/01/02 15:04:05")
 		message := fmt.Sprintf(format, args...)
 
@@ -137,7 +156,7 @@ func (l *Logger) Info(format string, args ...interface{}) {
 
 // Warn logs a warning message
 func (l *Logger) Warn(format string, args ...interface{}) {
-	if l.level <= WARN {
+	if l.enabled(WARN) {
 		msg := l.formatMessage("WARN", colorYellow, format, args...)
 		l.logger.Println(msg)
 	}
@@ -145,7 +164,7 @@ func (l *Logger) Warn(format string, args ...interface{}) {
 
 // Error logs an error message
 func (l *Logger) Error(format string, args ...interface{}) {
-	if l.level <= ERROR {
+	if l.enabled(ERROR) {
 		msg := l.formatMessage("ERROR", colorRed, format, args...)
 		l.logger.Println(msg)
 	}
@@ -153,7 +172,7 @@ func (l *Logger) Error(format string, args ...interface{}) {
 
 // Success logs a success message (always shown, without level prefix for cleaner output)
 func (l *Logger) Success(format string, args ...interface{}) {
-	if l.level <= INFO {
+	if l.enabled(INFO) {
 		timestamp := time.Now().Format("2006/01/02 15:04:05")
 		message := fmt.Sprintf(format, args...)
 
@@ -170,11 +189,22 @@ func (l *Logger) Success(format string, args ...interface{}) {
 
 // SetLevel changes the log level
 func (l *Logger) SetLevel(levelStr string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.level = parseLogLevel(levelStr)
 }
 
+// enabled reports whether messages of the given level are logged
+func (l *Logger) enabled(level LogLevel) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level <= level
+}
+
 // WithPrefix creates a new logger with a prefix
 func (l *Logger) WithPrefix(prefix string) *Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	return &Logger{
 		level:  l.level,
 		prefix: prefix,
@@ -186,8 +216,8 @@ func (l *Logger) WithPrefix(prefix string) *Logger {
 
 // Debug logs a debug message using the global logger
 func Debug(format string, args ...interface{}) {
-	if Global != nil {
-		Global.Debug(format, args...)
+	if l := global(); l != nil {
+		l.Debug(format, args...)
 	} else {
 		log.Printf("[DEBUG] "+format, args...)
 	}
@@ -195,8 +225,8 @@ func Debug(format string, args ...interface{}) {
 
 // Info logs an info message using the global logger
 func Info(format string, args ...interface{}) {
-	if Global != nil {
-		Global.Info(format, args...)
+	if l := global(); l != nil {
+		l.Info(format, args...)
 	} else {
 		log.Printf("[INFO] "+format, args...)
 	}
@@ -204,8 +234,8 @@ func Info(format string, args ...interface{}) {
 
 // Warn logs a warning message using the global logger
 func Warn(format string, args ...interface{}) {
-	if Global != nil {
-		Global.Warn(format, args...)
+	if l := global(); l != nil {
+		l.Warn(format, args...)
 	} else {
 		log.Printf("[WARN] "+format, args...)
 	}
@@ -213,8 +243,8 @@ func Warn(format string, args ...interface{}) {
 
 // Error logs an error message using the global logger
 func Error(format string, args ...interface{}) {
-	if Global != nil {
-		Global.Error(format, args...)
+	if l := global(); l != nil {
+		l.Error(format, args...)
 	} else {
 		log.Printf("[ERROR] "+format, args...)
 	}
@@ -222,8 +252,8 @@ func Error(format string, args ...interface{}) {
 
 // Success logs a success message using the global logger
 func Success(format string, args ...interface{}) {
-	if Global != nil {
-		Global.Success(format, args...)
+	if l := global(); l != nil {
+		l.Success(format, args...)
 	} else {
 		log.Printf("[SUCCESS] "+format, args...)
 	}
@@ -240,6 +270,8 @@ func New(level LogLevel) *Logger {
 
 // GetLogger returns the global logger instance, creating it if necessary
 func GetLogger() *Logger {
+	globalMu.Lock()
+	defer globalMu.Unlock()
 	if Global == nil {
 		Global = New(INFO)
 	}
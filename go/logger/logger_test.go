@@ -187,6 +187,31 @@ func TestLogger_SetLevel(t *testing.T) {
 	assert.Equal(t, ERROR, logger.level)
 }
 
+// TestLogger_SetLevelWhileLogging changes the level while another goroutine
+// logs, for the race detector
+func TestLogger_SetLevelWhileLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := &Logger{
+		level:  INFO,
+		logger: log.New(&buf, "", 0),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			logger.Debug("debug message")
+			logger.Info("info message")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		logger.SetLevel("debug")
+		logger.SetLevel("info")
+	}
+	<-done
+	assert.Contains(t, buf.String(), "info message")
+}
+
 func TestLogger_FormatMessage_WithColor(t *testing.T) {
 	// Skip this test in CI environments where terminal colors aren't supported
 	if os.Getenv("CI") != "" || os.Getenv("GITHUB_ACTIONS") != "" {
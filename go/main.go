@@ -17,32 +17,51 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"right-sizer/admission"
 	"right-sizer/api"
+	grpcapi "right-sizer/api/grpc"
 	"right-sizer/api/v1alpha1"
+	"right-sizer/api/v1beta1"
 	"right-sizer/audit"
 	"right-sizer/config"
 	"right-sizer/controllers"
+	"right-sizer/cost"
 	"right-sizer/dashboard"
 	dashboardapi "right-sizer/dashboard-api"
+	"right-sizer/efficiency"
 	"right-sizer/events"
+	"right-sizer/explain"
 	"right-sizer/health"
+	"right-sizer/idle"
 	"right-sizer/logger"
 	"right-sizer/metrics"
+	"right-sizer/notifications"
+	"right-sizer/opa"
+	"right-sizer/pause"
+	"right-sizer/reports"
 	"right-sizer/retry"
 	"right-sizer/validation"
+	"right-sizer/wasmpolicy"
 
 	"github.com/go-logr/zapr"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
@@ -75,8 +94,60 @@ var (
 	capabilityGauge    *prometheus.GaugeVec
 	clusterVersionInfo *prometheus.GaugeVec
 	registerOnce       sync.Once
+
+	// capabilityMonitor re-detects cluster capabilities while the operator runs
+	capabilityMonitor *platform.Monitor
 )
 
+// serverRunnable is a manager Runnable that runs on every replica, not only
+// on the leader
+type serverRunnable func(ctx context.Context) error
+
+func (f serverRunnable) Start(ctx context.Context) error { return f(ctx) }
+
+func (serverRunnable) NeedLeaderElection() bool { return false }
+
+// addServer runs a server with the manager, which stops it on shutdown. Its
+// errors are logged rather than stopping the manager.
+func addServer(mgr manager.Manager, name string, start func(ctx context.Context) error) {
+	runnable := serverRunnable(func(ctx context.Context) error {
+		if err := start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("%s error: %v", name, err)
+		}
+		return nil
+	})
+	if err := mgr.Add(runnable); err != nil {
+		logger.Error("Failed to add %s to the manager: %v", name, err)
+	}
+}
+
+// setCapabilityGauges publishes detected cluster capabilities as metrics
+func setCapabilityGauges(previous, caps platform.Capabilities) {
+	if clusterVersionInfo != nil {
+		if previous.RawVersion != "" && previous.RawVersion != caps.RawVersion {
+			clusterVersionInfo.DeleteLabelValues(previous.RawVersion, fmt.Sprintf("%d", previous.Minor))
+		}
+		clusterVersionInfo.WithLabelValues(caps.RawVersion, fmt.Sprintf("%d", caps.Minor)).Set(1)
+	}
+	if capabilityGauge == nil {
+		return
+	}
+	setCap := func(name string, v bool) {
+		if v {
+			capabilityGauge.WithLabelValues(name).Set(1)
+		} else {
+			capabilityGauge.WithLabelValues(name).Set(0)
+		}
+	}
+	setCap("ephemeral_containers", caps.EphemeralContainers)
+	setCap("pod_resize", caps.PodResize)
+	setCap("metrics_server", caps.MetricsServerAvailable)
+	setCap("dynamic_resource_allocation", caps.DynamicResourceAllocation)
+	setCap("in_place_vertical_scaling", caps.InPlacePodVerticalScaling)
+	setCap("memory_qos", caps.MemoryQoS)
+	setCap("supported_version", caps.Supported)
+}
+
 func main() {
 	// Print startup banner
 	fmt.Println("========================================")
@@ -166,18 +237,6 @@ func main() {
 				logger.Info("   ✅ Kubernetes version satisfies minimum (>=1.33)")
 			}
 
-			// Dynamic capability detection (uses discovery API)
-			capDetector := platform.NewDetector(clientset)
-			caps, capErr := capDetector.Detect(context.Background())
-			if capErr != nil {
-				logger.Warn("   ⚠️  Capability detection partial: %v", capErr)
-			} else {
-				logger.Info("   Capabilities: %s", caps.Summary())
-				if !caps.Supported && caps.VersionWarning != "" {
-					logger.Warn("   ⚠️  %s", caps.VersionWarning)
-				}
-			}
-
 			// Expose capability metrics early so they are present when /metrics is scraped.
 			// We register here unconditionally; metrics server startup (later) will expose them.
 			registerOnce.Do(func() {
@@ -191,24 +250,20 @@ func main() {
 				}, []string{"version", "minor"})
 			})
 
-			if clusterVersionInfo != nil {
-				clusterVersionInfo.WithLabelValues(caps.RawVersion, fmt.Sprintf("%d", caps.Minor)).Set(1)
-			}
-			if capabilityGauge != nil {
-				setCap := func(name string, v bool) {
-					if v {
-						capabilityGauge.WithLabelValues(name).Set(1)
-					} else {
-						capabilityGauge.WithLabelValues(name).Set(0)
-					}
+			// Dynamic capability detection (uses discovery API), repeated while
+			// the operator runs so the gauges follow cluster upgrades
+			capabilityMonitor = platform.NewMonitor(platform.NewDetector(clientset), platform.DefaultRefreshInterval)
+			capabilityMonitor.OnChange(func(previous, current platform.Capabilities) {
+				setCapabilityGauges(previous, current)
+			})
+			caps, capErr := capabilityMonitor.Refresh(context.Background())
+			if capErr != nil {
+				logger.Warn("   ⚠️  Capability detection partial: %v", capErr)
+			} else {
+				logger.Info("   Capabilities: %s", caps.Summary())
+				if !caps.Supported && caps.VersionWarning != "" {
+					logger.Warn("   ⚠️  %s", caps.VersionWarning)
 				}
-				setCap("ephemeral_containers", caps.EphemeralContainers)
-				setCap("pod_resize", caps.PodResize)
-				setCap("metrics_server", caps.MetricsServerAvailable)
-				setCap("dynamic_resource_allocation", caps.DynamicResourceAllocation)
-				setCap("in_place_vertical_scaling", caps.InPlacePodVerticalScaling)
-				setCap("memory_qos", caps.MemoryQoS)
-				setCap("supported_version", caps.Supported)
 			}
 
 			// (Retain API version log for continuity)
@@ -343,6 +398,10 @@ func main() {
 		logger.Error("unable to add CRD schemes: %v", err)
 		os.Exit(1)
 	}
+	if err := v1beta1.AddToScheme(mgr.GetScheme()); err != nil {
+		logger.Error("unable to add CRD schemes: %v", err)
+		os.Exit(1)
+	}
 
 	// Create metrics client for accessing metrics-server
 	metricsClient, err := metricsclient.NewForConfig(kubeConfig)
@@ -364,6 +423,10 @@ func main() {
 	// Initialize audit logger (will be enabled/disabled based on CRD config)
 	var auditLogger *audit.AuditLogger
 	auditConfig := audit.DefaultAuditConfig()
+	if cfg.PredictionStorage == "file" {
+		// Keep the queryable audit history on the same volume as the prediction history
+		auditConfig.StorePath = filepath.Join(cfg.PredictionStoragePath, "audit")
+	}
 	auditLogger, err = audit.NewAuditLogger(mgr.GetClient(), cfg, operatorMetrics, auditConfig)
 	if err != nil {
 		logger.Warn("Failed to initialize audit logger: %v", err)
@@ -374,9 +437,10 @@ func main() {
 	webhookConfig := admission.WebhookConfig{
 		Port:              8443,
 		EnableValidation:  true,
-		EnableMutation:    false,
+		EnableMutation:    true,
 		DryRun:            cfg.DryRun,
 		RequireAnnotation: false,
+		EnableConversion:  cfg.ConversionWebhook,
 	}
 	webhookManager = admission.NewWebhookManager(
 		mgr.GetClient(),
@@ -387,11 +451,23 @@ func main() {
 		webhookConfig,
 	)
 
-	// Initialize metrics provider (default to metrics-server, will be updated from CRD)
+	// Initialize metrics provider (default to metrics-server, will be updated from CRD).
+	// The failover provider probes it and switches to the configured fallback while it is down.
 	var provider metrics.Provider
 	logger.Info("Using default metrics-server provider (can be changed via RightSizerConfig CRD)")
-	provider = metrics.NewMetricsServerProvider(mgr.GetClient())
+	failoverProvider := metrics.NewFailoverProvider("metrics-server", metrics.NewMetricsServerProvider(mgr.GetClient()))
+	failoverProvider.OnFailover = func(from, to string) {
+		logger.Warn("⚠️ Metrics provider switched from %s to %s", from, to)
+	}
+	providerCtx, providerCancel := context.WithCancel(context.Background())
+	defer providerCancel()
+	failoverProvider.Start(providerCtx)
+	provider = failoverProvider
+	healthChecker.SetMetricsProviderCheck(failoverProvider.Health)
 	healthChecker.UpdateComponentStatus("metrics-provider", true, "Metrics provider initialized")
+	if err := mgr.AddReadyzCheck("metrics-provider", healthChecker.MetricsProviderReadinessCheck); err != nil {
+		logger.Warn("unable to set up metrics provider ready check: %v", err)
+	}
 
 	// Initialize new comprehensive dashboard client for real-time event streaming
 	var newDashboardClient *dashboardapi.Client
@@ -481,6 +557,24 @@ func main() {
 	}
 
 	// Setup CRD controllers only if CRDs exist
+	var policyController *controllers.RightSizerPolicyReconciler
+	// Servers reading the configuration at startup wait until it is loaded
+	// from the RightSizerConfig CRD, or right away when it is not installed
+	configReady := controllers.NewConfigReady()
+	// Signalled after each RightSizerConfig change so the API server can pick
+	// up a new port or authentication mode
+	apiReload := make(chan struct{}, 1)
+	cfg.Subscribe(func(changed *config.Config) {
+		logger.Init(changed.LogLevel)
+		select {
+		case apiReload <- struct{}{}:
+		default:
+		}
+	})
+	if !configCRDExists {
+		configReady.MarkReady()
+	}
+
 	if configCRDExists || policyCRDExists {
 		logger.Info("Setting up CRD controllers...")
 
@@ -494,6 +588,8 @@ func main() {
 				AuditLogger:     auditLogger,
 				WebhookManager:  webhookManager,
 				HealthChecker:   healthChecker,
+				EventRecorder:   mgr.GetEventRecorderFor("right-sizer"),
+				Ready:           configReady,
 			}
 			if err := configController.SetupWithManager(mgr); err != nil {
 				logger.Error("unable to setup RightSizerConfig controller: %v", err)
@@ -504,7 +600,7 @@ func main() {
 
 		if policyCRDExists {
 			// Setup RightSizerPolicy controller
-			policyController := &controllers.RightSizerPolicyReconciler{
+			policyController = &controllers.RightSizerPolicyReconciler{
 				Client:          mgr.GetClient(),
 				Scheme:          mgr.GetScheme(),
 				MetricsProvider: provider,
@@ -524,28 +620,93 @@ func main() {
 	// The controller will use configuration from CRDs
 	logger.Info("Setting up main RightSizer controller...")
 
+	// The event bus carries resize and cluster events to the API stream and recommendation manager
+	eventBus := events.NewEventBus(1000) // Buffer size of 1000 events
+
+	// Usage anomalies pause resizes of the affected containers until they subside
+	anomalyMonitor := aiops.NewAnomalyMonitor(clientset, eventBus, mgr.GetEventRecorderFor("right-sizer"))
+
 	// Use AdaptiveRightSizer as the default implementation with rate limiting
 	// It will check for in-place resize capability based on CRD configuration
 	// The controller will respect the manager's rate limiting configuration
-	predictorEngine, err := controllers.SetupAdaptiveRightSizer(mgr, provider, auditLogger, cfg.DryRun, newDashboardClient)
+	// The latest sizing decision of every container is kept for /api/workloads/{namespace}/{name}/explain
+	explanations := explain.NewStore()
+
+	// Resizing can be paused through /api/pause or the rightsizer.io/paused annotation
+	pauses := pause.NewState(operatorMetrics)
+
+	adaptiveRightSizer, err := controllers.SetupAdaptiveRightSizer(mgr, provider, auditLogger, cfg.DryRun, newDashboardClient, eventBus, anomalyMonitor, explanations, pauses)
 	if err != nil {
 		logger.Error("unable to setup AdaptiveRightSizer: %v", err)
 		os.Exit(1)
 	}
+	adaptiveRightSizer.RetryHandler = retryHandler
+	efficiencyTracker := efficiency.NewTracker()
+	adaptiveRightSizer.Efficiency = efficiencyTracker
+	idleDetector := idle.NewDetector()
+	adaptiveRightSizer.Idle = idleDetector
+
+	// WebAssembly policy modules vet resizes as the "wasm" decision filter
+	if dir := cfg.WASMPolicies.Dir; dir != "" {
+		engine, err := wasmpolicy.Load(context.Background(), dir, cfg.WASMPolicies.Timeout)
+		if err != nil {
+			logger.Error("unable to load WebAssembly policies: %v", err)
+			os.Exit(1)
+		}
+		controllers.RegisterDecisionFilter(controllers.NewWASMDecisionFilter(engine, mgr.GetClient()))
+		logger.Info("✅ Loaded WebAssembly policies %v, enabled through the %q decision filter", engine.Modules(), controllers.WASMFilterName)
+	}
+
+	// An OPA server, usually a sidecar, governs resizes as the "opa" decision filter
+	if url := cfg.OPA.URL; url != "" {
+		controllers.RegisterDecisionFilter(controllers.NewOPADecisionFilter(opa.NewClient(url, cfg.OPA.Timeout), mgr.GetClient()))
+		logger.Info("✅ Resizes can be governed by OPA rule %s through the %q decision filter", url, controllers.OPAFilterName)
+	}
+	predictorEngine := adaptiveRightSizer.Predictor
 	logger.Info("✅ AdaptiveRightSizer controller initialized")
+	if predictorEngine != nil {
+		anomalyMonitor.History = predictorEngine
+	}
 
-	// Start metrics server (will be enabled/disabled based on CRD config)
-	go func() {
-		// Wait for configuration to be loaded from CRD
-		time.Sleep(5 * time.Second)
+	// Share the prediction history with policies for percentile-based sizing
+	if policyController != nil {
+		policyController.Predictor = predictorEngine
+	}
 
-		if cfg.MetricsEnabled {
-			logger.Info("🔍 Starting metrics server on port %d", cfg.MetricsPort)
-			if err := metrics.StartMetricsServer(cfg.MetricsPort); err != nil {
-				logger.Error("Metrics server error: %v", err)
-			}
+	// Keep re-detecting cluster capabilities, so resizing switches between
+	// in place and recommendations when the cluster gains or loses pods/resize,
+	// and memory requests follow memory QoS
+	if capabilityMonitor != nil {
+		if caps, ok := capabilityMonitor.Current(); ok {
+			adaptiveRightSizer.SetMemoryQoS(caps.MemoryQoS)
 		}
-	}()
+		capabilityMonitor.OnChange(func(previous, current platform.Capabilities) {
+			logger.Info("🔄 Cluster capabilities changed (%s): %s", strings.Join(platform.Changed(previous, current), ", "), current.Summary())
+			adaptiveRightSizer.SetInPlaceEnabled(current.PodResize)
+			adaptiveRightSizer.SetMemoryQoS(current.MemoryQoS)
+		})
+		// Give combined resize patches another chance after each re-detection
+		capabilityMonitor.OnRefresh(func(platform.Capabilities) {
+			adaptiveRightSizer.ResetGroupedResize()
+		})
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			capabilityMonitor.Start(ctx, func(err error) {
+				logger.Warn("Capability re-detection failed, keeping the previous capabilities: %v", err)
+			})
+			return nil
+		})); err != nil {
+			logger.Warn("Failed to start capability re-detection: %v", err)
+		}
+	}
+
+	// Start metrics server (will be enabled/disabled based on CRD config)
+	addServer(mgr, "metrics server", func(ctx context.Context) error {
+		if !configReady.Wait(ctx) || !cfg.MetricsEnabled {
+			return nil
+		}
+		logger.Info("🔍 Starting metrics server on port %d", cfg.MetricsPort)
+		return metrics.StartMetricsServer(ctx, cfg.MetricsPort)
+	})
 
 	// Create a simple event store for optimization events
 	type OptimizationEvent struct {
@@ -570,26 +731,61 @@ func main() {
 	// var eventsMutex sync.RWMutex
 
 	// Start admission webhook (will be enabled/disabled based on CRD config)
-	go func() {
-		// Wait for configuration to be loaded from CRD
-		time.Sleep(5 * time.Second)
+	addServer(mgr, "admission webhook", func(ctx context.Context) error {
+		if !configReady.Wait(ctx) {
+			return nil
+		}
 
-		if cfg.AdmissionController && webhookManager != nil {
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
+		if (cfg.AdmissionController || cfg.ConversionWebhook) && webhookManager != nil {
+			// Point the CRDs served in several versions at /convert
+			var conversionWebhook *admission.ConversionWebhook
+			if cfg.ConversionWebhook {
+				apiextensionsClient, err := apiextensionsclientset.NewForConfig(kubeConfig)
+				if err != nil {
+					logger.Warn("Failed to create apiextensions client, CRD conversion is not configured: %v", err)
+				} else {
+					conversionWebhook = &admission.ConversionWebhook{
+						Client:      apiextensionsClient,
+						Namespace:   os.Getenv("OPERATOR_NAMESPACE"),
+						ServiceName: cfg.WebhookServiceName,
+						Port:        int32(webhookConfig.Port),
+						CRDs:        admission.ConvertedCRDs,
+					}
+				}
+			}
+
+			// Serve a certificate that is renewed without a restart
+			switch cfg.WebhookCertMode {
+			case admission.CertModeSelfSigned:
+				webhookManager.SetCertificates(&admission.CertRotator{
+					Clientset:             clientset,
+					Namespace:             os.Getenv("OPERATOR_NAMESPACE"),
+					ServiceName:           cfg.WebhookServiceName,
+					SecretName:            cfg.WebhookCertSecret,
+					WebhookConfigurations: []string{cfg.WebhookConfigName},
+					Conversion:            conversionWebhook,
+				})
+			case admission.CertModeCertManager:
+				webhookManager.SetCertificates(&admission.CertFileLoader{
+					CertPath:   filepath.Join(cfg.TLSCertDir, "tls.crt"),
+					KeyPath:    filepath.Join(cfg.TLSCertDir, "tls.key"),
+					CAPath:     filepath.Join(cfg.TLSCertDir, "ca.crt"),
+					Conversion: conversionWebhook,
+				})
+			}
 
 			logger.Info("🛡️  Starting admission webhook...")
 			healthChecker.UpdateComponentStatus("webhook", false, "Webhook starting...")
 			if err := webhookManager.Start(ctx); err != nil {
-				logger.Error("admission webhook error: %v", err)
 				healthChecker.UpdateComponentStatus("webhook", false, fmt.Sprintf("Webhook error: %v", err))
-			} else {
-				healthChecker.UpdateComponentStatus("webhook", true, "Webhook server is running")
+				return err
 			}
+			healthChecker.UpdateComponentStatus("webhook", true, "Webhook server is running")
 		} else {
 			healthChecker.UpdateComponentStatus("webhook", false, "Not enabled")
 		}
-	}()
+		return nil
+	})
 
 	// Start periodic health checks
 	healthCheckCtx, healthCheckCancel := context.WithCancel(context.Background())
@@ -612,9 +808,25 @@ func main() {
 		logger.Info("🤖 AIOps Engine disabled: LLM_API_KEY environment variable not set.")
 	}
 
-	// Initialize event bus and recommendation manager
-	logger.Info("🔮 Initializing Event Bus and Recommendation Manager...")
-	eventBus := events.NewEventBus(1000) // Buffer size of 1000 events
+	// Detect usage anomalies from the prediction history, recording them as incidents when the engine runs
+	if aiopsEngine != nil {
+		anomalyMonitor.Incidents = aiopsEngine.IncidentStore()
+	}
+	go anomalyMonitor.Start(ctx)
+
+	// Write a periodic report per namespace, narrated by the LLM when one is configured
+	var auditStore *audit.Store
+	if auditLogger != nil {
+		auditStore = auditLogger.Store()
+	}
+	reportGenerator := reports.NewGenerator(clientset, provider, auditStore, cost.NewClient(), narrative.NewNarrativeGenerator(llmConfig))
+	if aiopsEngine != nil {
+		reportGenerator.Incidents = aiopsEngine.IncidentStore()
+	}
+	reportGenerator.Efficiency = efficiencyTracker
+
+	// Initialize recommendation manager
+	logger.Info("🔮 Initializing Recommendation Manager...")
 	recommendationManager := events.NewRecommendationManager(
 		clientset,
 		eventBus,
@@ -643,6 +855,29 @@ func main() {
 	}
 	logger.Info("✅ EventDrivenController initialized")
 
+	// Setup OOMWatcher to raise memory as soon as a container is OOM killed
+	if cfg.PreventOOMKill {
+		oomWatcher := controllers.NewOOMWatcher(mgr.GetClient(), clientset, cfg, auditLogger, operatorMetrics)
+		oomWatcher.Recommendations = adaptiveRightSizer.Recommendations
+		oomWatcher.Exporter = adaptiveRightSizer.Exporter
+		oomWatcher.Pauses = pauses
+		if err := oomWatcher.SetupWithManager(mgr); err != nil {
+			logger.Error("unable to setup OOMWatcher: %v", err)
+			os.Exit(1)
+		}
+		logger.Info("✅ OOMWatcher initialized")
+	}
+
+	// Setup ResizeConditionWatcher to follow how the kubelet handles in-place resizes
+	resizeConditionWatcher := controllers.NewResizeConditionWatcher(mgr.GetClient(), clientset, cfg, auditLogger, operatorMetrics, mgr.GetEventRecorderFor("right-sizer"))
+	resizeConditionWatcher.EventBus = eventBus
+	resizeConditionWatcher.Safety = adaptiveRightSizer.Safety
+	if err := resizeConditionWatcher.SetupWithManager(mgr); err != nil {
+		logger.Error("unable to setup ResizeConditionWatcher: %v", err)
+		os.Exit(1)
+	}
+	logger.Info("✅ ResizeConditionWatcher initialized")
+
 	// Bridge EventBus to AIOps Engine
 	if aiopsEngine != nil {
 		eventBus.Subscribe("aiops-bridge", func(event *events.Event) {
@@ -687,16 +922,152 @@ func main() {
 		}
 	}
 
+	// Send resize, OOM and rollback notifications to the configured channels
+	notificationDispatcher := notifications.NewDispatcher(mgr.GetClient(), eventBus)
+	if err := notificationDispatcher.Start(ctx); err != nil {
+		logger.Error("Failed to start notification dispatcher: %v", err)
+	}
+	reportGenerator.Notify = notificationDispatcher.NotifyReport
+	go reportGenerator.Start(ctx)
+
+	// Restore the dashboard's metrics history kept on the storage volume
+	metricsHistoryPath := filepath.Join(cfg.PredictionStoragePath, "metrics-history.json")
+	if cfg.PredictionStorage == "file" {
+		if err := api.LoadMetricsHistory(metricsHistoryPath); err != nil {
+			logger.Warn("Failed to load metrics history: %v", err)
+		}
+	}
+
 	// Start API server using the new API server module
-	go func() {
-		// Wait for configuration to be loaded from CRD
-		time.Sleep(5 * time.Second)
+	addServer(mgr, "API server", func(ctx context.Context) error {
+		if !configReady.Wait(ctx) {
+			return nil
+		}
 
 		apiServer := api.NewServer(clientset, metricsClient, mgr.GetClient(), predictorEngine, recommendationManager, operatorMetrics)
-		if err := apiServer.Start(8082); err != nil {
-			logger.Error("API server error: %v", err)
+		apiServer.SetEventBus(eventBus)
+		if auditStore != nil {
+			apiServer.SetAuditStore(auditStore)
 		}
-	}()
+		apiServer.SetExplanationStore(explanations)
+		apiServer.SetEfficiencyTracker(efficiencyTracker)
+		apiServer.SetIdleDetector(idleDetector)
+		apiServer.SetPauseState(pauses)
+		apiServer.SetRetryHandler(retryHandler)
+		apiServer.SetReportGenerator(reportGenerator)
+		if source, ok := provider.(metrics.IOSource); ok {
+			apiServer.SetIOSource(source)
+		}
+		if source, ok := provider.(metrics.RangeProvider); ok {
+			apiServer.SetUsageHistory(source)
+		}
+		apiServer.SetAssessor(api.AssessorFunc(
+			func(ctx context.Context, pod *corev1.Pod, selector labels.Selector) (api.ManifestAssessment, error) {
+				assessment, err := adaptiveRightSizer.AssessPod(ctx, pod, selector)
+				if err != nil {
+					return api.ManifestAssessment{}, err
+				}
+				result := api.ManifestAssessment{
+					Skipped:   assessment.Skipped,
+					Policies:  assessment.Policies,
+					UsageFrom: assessment.UsageFrom,
+				}
+				for _, update := range assessment.Updates {
+					result.Containers = append(result.Containers, api.ContainerAssessment{
+						Container:   update.ContainerName,
+						Current:     update.OldResources,
+						Recommended: update.NewResources,
+						Reason:      update.Reason,
+					})
+				}
+				return result, nil
+			}))
+		apiServer.SetSimulator(api.SimulatorFunc(
+			func(ctx context.Context, simulated *config.Config, namespace string, window time.Duration) (api.SimulationResult, error) {
+				simulation, err := adaptiveRightSizer.Simulate(ctx, simulated, namespace, window)
+				if err != nil {
+					return api.SimulationResult{}, err
+				}
+				totals := func(t controllers.SimulationTotals) api.SimulationTotals {
+					return api.SimulationTotals{
+						Resizes:             t.Resizes,
+						ContainersResized:   t.ContainersResized,
+						CPUMillisReleased:   t.CPUMillisReleased,
+						MemoryBytesReleased: t.MemoryBytesReleased,
+						MonthlySavings:      t.MonthlySavings,
+					}
+				}
+				outcome := func(o controllers.ReplayOutcome) api.ReplayOutcome {
+					return api.ReplayOutcome{Resizes: o.Resizes, Final: o.Final, LastReason: o.LastReason}
+				}
+				result := api.SimulationResult{
+					CostSource: simulation.CostSource,
+					Live:       totals(simulation.Live),
+					Simulated:  totals(simulation.Simulated),
+				}
+				for _, c := range simulation.Containers {
+					result.Containers = append(result.Containers, api.SimulatedContainer{
+						Namespace: c.Namespace,
+						Pod:       c.Pod,
+						Container: c.Container,
+						Samples:   c.Samples,
+						Current:   c.Current,
+						Live:      outcome(c.Live),
+						Simulated: outcome(c.Simulated),
+					})
+				}
+				return result, nil
+			}))
+		return apiServer.Run(ctx, apiReload)
+	})
+
+	// Start the gRPC API alongside the HTTP API
+	if cfg.GRPCPort > 0 {
+		addServer(mgr, "gRPC API", func(ctx context.Context) error {
+			if !configReady.Wait(ctx) {
+				return nil
+			}
+
+			grpcConfig := cfg.Clone()
+			if grpcConfig.GRPCTLSCert == "" || grpcConfig.GRPCTLSKey == "" {
+				return fmt.Errorf("gRPC API requires TLS, set GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+			}
+			// Serve a certificate that is reloaded when it is renewed
+			certificates := &admission.CertFileLoader{
+				Name:     "gRPC API",
+				CertPath: grpcConfig.GRPCTLSCert,
+				KeyPath:  grpcConfig.GRPCTLSKey,
+			}
+			if err := certificates.Start(ctx); err != nil {
+				return err
+			}
+
+			grpcServer := grpcapi.NewServer(grpcConfig, eventBus, nil, provider)
+			grpcServer.SetRecommendationServer(grpcapi.NewRecommendationServer(recommendationManager, eventBus, grpcapi.AnalyzerFunc(
+				func(ctx context.Context, namespace, name string) ([]grpcapi.ContainerResize, error) {
+					updates, err := adaptiveRightSizer.AnalyzePod(ctx, namespace, name)
+					if err != nil {
+						return nil, err
+					}
+					resizes := make([]grpcapi.ContainerResize, 0, len(updates))
+					for _, update := range updates {
+						resizes = append(resizes, grpcapi.ContainerResize{
+							Container:   update.ContainerName,
+							Current:     update.OldResources,
+							Recommended: update.NewResources,
+							Reason:      update.Reason,
+						})
+					}
+					return resizes, nil
+				})))
+
+			logger.Info("🔌 Starting gRPC API on port %d", grpcConfig.GRPCPort)
+			return grpcServer.Run(ctx, fmt.Sprintf(":%d", grpcConfig.GRPCPort), &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: certificates.GetCertificate,
+			})
+		})
+	}
 
 	// Start manager in a goroutine
 	managerDone := make(chan error, 1)
@@ -713,19 +1084,26 @@ func main() {
 	}()
 
 	// Wait for shutdown signal or manager error
+	shutdownTimeout := 30 * time.Second
 	select {
 	case <-signalChan:
 		logger.Info("📢 Shutdown signal received, initiating graceful shutdown...")
+		// The manager got the signal too: wait for its runnables, the servers
+		// included, to stop
+		select {
+		case err := <-managerDone:
+			if err != nil {
+				logger.Error("❌ Manager error: %v", err)
+			}
+		case <-time.After(shutdownTimeout):
+			logger.Warn("Manager did not stop within %v", shutdownTimeout)
+		}
 	case err := <-managerDone:
 		if err != nil {
 			logger.Error("❌ Manager error: %v", err)
 		}
 	}
 
-	// Graceful shutdown
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	// Stop dashboard client and flush remaining events
 	if newDashboardClient != nil {
 		logger.Info("Stopping dashboard client and flushing events...")
@@ -737,6 +1115,18 @@ func main() {
 	}
 
 	// Cleanup components
+	if predictorEngine != nil {
+		logger.Info("🔮 Saving prediction history...")
+		if err := predictorEngine.Close(); err != nil {
+			logger.Warn("Error saving prediction history: %v", err)
+		}
+	}
+	if cfg.PredictionStorage == "file" {
+		if err := api.SaveMetricsHistory(metricsHistoryPath); err != nil {
+			logger.Warn("Error saving metrics history: %v", err)
+		}
+	}
+
 	if auditLogger != nil {
 		logger.Info("📋 Closing audit logger...")
 		if err := auditLogger.Close(); err != nil {
@@ -744,13 +1134,6 @@ func main() {
 		}
 	}
 
-	if webhookManager != nil {
-		logger.Info("🛡️  Stopping admission webhook...")
-		if err := webhookManager.Stop(shutdownCtx); err != nil {
-			logger.Warn("Error stopping webhook manager: %v", err)
-		}
-	}
-
 	if predictiveMonitor != nil {
 		logger.Info("🔮 Stopping predictive monitor...")
 		predictiveMonitor.Stop()
@@ -766,6 +1149,9 @@ func main() {
 		dashboardBridge.Stop()
 	}
 
+	logger.Info("📣 Stopping notification dispatcher...")
+	notificationDispatcher.Stop()
+
 	// Log final statistics
 	logger.Info("✅ Right-sizer operator shutdown completed")
 
@@ -15,10 +15,11 @@ import (
 
 // CachedProvider wraps a Provider with TTL-based caching to optimize query latency
 type CachedProvider struct {
-	provider Provider
-	cache    map[string]*cacheEntry
-	mu       sync.RWMutex
-	ttl      time.Duration
+	provider       Provider
+	cache          map[string]*cacheEntry
+	containerCache map[string]*containerCacheEntry
+	mu             sync.RWMutex
+	ttl            time.Duration
 }
 
 type cacheEntry struct {
@@ -26,13 +27,19 @@ type cacheEntry struct {
 	timestamp time.Time
 }
 
+type containerCacheEntry struct {
+	metrics   ContainerMetrics
+	timestamp time.Time
+}
+
 // NewCachedProvider creates a new cached metrics provider
 // ttl: time-to-live for cache entries (e.g., 30 seconds)
 func NewCachedProvider(provider Provider, ttl time.Duration) Provider {
 	c := &CachedProvider{
-		provider: provider,
-		cache:    make(map[string]*cacheEntry),
-		ttl:      ttl,
+		provider:       provider,
+		cache:          make(map[string]*cacheEntry),
+		containerCache: make(map[string]*containerCacheEntry),
+		ttl:            ttl,
 	}
 
 	// Start background cleanup goroutine
@@ -72,6 +79,34 @@ func (c *CachedProvider) FetchPodMetrics(ctx context.Context, namespace, podName
 	return metrics, nil
 }
 
+// FetchContainerMetrics fetches per-container metrics with caching
+func (c *CachedProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error) {
+	key := namespace + "/" + podName
+
+	c.mu.RLock()
+	if entry, ok := c.containerCache[key]; ok {
+		if time.Since(entry.timestamp) < c.ttl {
+			c.mu.RUnlock()
+			return entry.metrics, nil
+		}
+	}
+	c.mu.RUnlock()
+
+	metrics, err := c.provider.FetchContainerMetrics(ctx, namespace, podName)
+	if err != nil {
+		return metrics, err
+	}
+
+	c.mu.Lock()
+	c.containerCache[key] = &containerCacheEntry{
+		metrics:   metrics,
+		timestamp: time.Now(),
+	}
+	c.mu.Unlock()
+
+	return metrics, nil
+}
+
 // cleanup removes stale cache entries periodically
 func (c *CachedProvider) cleanup() {
 	ticker := time.NewTicker(c.ttl)
@@ -85,6 +120,11 @@ func (c *CachedProvider) cleanup() {
 				delete(c.cache, key)
 			}
 		}
+		for key, entry := range c.containerCache {
+			if now.Sub(entry.timestamp) > c.ttl*2 {
+				delete(c.containerCache, key)
+			}
+		}
 		c.mu.Unlock()
 	}
 }
@@ -94,6 +134,7 @@ func (c *CachedProvider) Invalidate(namespace, podName string) {
 	key := namespace + "/" + podName
 	c.mu.Lock()
 	delete(c.cache, key)
+	delete(c.containerCache, key)
 	c.mu.Unlock()
 }
 
@@ -101,5 +142,6 @@ func (c *CachedProvider) Invalidate(namespace, podName string) {
 func (c *CachedProvider) Clear() {
 	c.mu.Lock()
 	c.cache = make(map[string]*cacheEntry)
+	c.containerCache = make(map[string]*containerCacheEntry)
 	c.mu.Unlock()
 }
@@ -25,6 +25,11 @@ func (m *mockProvider) FetchPodMetrics(ctx context.Context, namespace, podName s
 	return m.metrics, m.err
 }
 
+func (m *mockProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error) {
+	m.fetchCount++
+	return ContainerMetrics{"app": m.metrics}, m.err
+}
+
 func TestCachedProvider_HitCache(t *testing.T) {
 	mock := &mockProvider{
 		metrics: Metrics{CPUMilli: 100, MemMB: 256},
@@ -0,0 +1,63 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	custommetrics "k8s.io/metrics/pkg/client/custom_metrics"
+)
+
+// podGroupKind identifies pods in custom metrics API requests
+var podGroupKind = schema.GroupKind{Kind: "Pod"}
+
+// CustomMetricsProvider reads per-pod metrics from the custom metrics API
+// (custom.metrics.k8s.io), served by adapters such as prometheus-adapter
+// or KEDA
+type CustomMetricsProvider struct {
+	Client custommetrics.CustomMetricsClient
+}
+
+// NewCustomMetricsProvider returns a provider for the custom metrics API
+// version the cluster serves. The version is looked up on first use, so the
+// adapter may be installed after the operator starts.
+func NewCustomMetricsProvider(config *rest.Config, mapper meta.RESTMapper, discoveryClient discovery.DiscoveryInterface) *CustomMetricsProvider {
+	apis := custommetrics.NewAvailableAPIsGetter(discoveryClient)
+	return &CustomMetricsProvider{Client: custommetrics.NewForConfig(config, mapper, apis)}
+}
+
+// FetchPodCustomMetric returns the current value of the named metric for a pod
+func (p *CustomMetricsProvider) FetchPodCustomMetric(ctx context.Context, namespace, podName, metric string) (float64, error) {
+	if p.Client == nil {
+		return 0, errors.New("custom metrics client not available")
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	value, err := p.Client.NamespacedMetrics(namespace).GetForObject(podGroupKind, podName, metric, labels.Everything())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get custom metric %s: %w", metric, err)
+	}
+	return value.Value.AsApproximateFloat64(), nil
+}
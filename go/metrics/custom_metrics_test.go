@@ -0,0 +1,47 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	cmv1beta2 "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	cmfake "k8s.io/metrics/pkg/client/custom_metrics/fake"
+)
+
+func TestCustomMetricsProvider_FetchPodCustomMetric(t *testing.T) {
+	client := &cmfake.FakeCustomMetricsClient{}
+	client.AddReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		get := action.(cmfake.GetForAction)
+		if get.GetNamespace() != "default" || get.GetName() != "worker-0" {
+			t.Errorf("unexpected object %s/%s", get.GetNamespace(), get.GetName())
+		}
+		if get.GetMetricName() != "queue_depth" {
+			return true, nil, errors.New("metric not found")
+		}
+		return true, &cmv1beta2.MetricValueList{Items: []cmv1beta2.MetricValue{{Value: resource.MustParse("1500m")}}}, nil
+	})
+	p := &CustomMetricsProvider{Client: client}
+
+	value, err := p.FetchPodCustomMetric(context.Background(), "default", "worker-0", "queue_depth")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != 1.5 {
+		t.Errorf("expected 1.5, got %v", value)
+	}
+
+	if _, err := p.FetchPodCustomMetric(context.Background(), "default", "worker-0", "latency"); err == nil {
+		t.Error("expected an error for a missing metric")
+	}
+}
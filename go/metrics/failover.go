@@ -0,0 +1,396 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Provider roles reported in ProviderStatus
+const (
+	RolePrimary   = "primary"
+	RoleSecondary = "secondary"
+)
+
+// ProviderStatus describes the health of one provider behind a FailoverProvider
+type ProviderStatus struct {
+	Name                string    `json:"name"`
+	Role                string    `json:"role"`
+	Healthy             bool      `json:"healthy"`
+	Active              bool      `json:"active"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastProbe           time.Time `json:"lastProbe,omitempty"`
+	NextProbe           time.Time `json:"nextProbe,omitempty"`
+}
+
+// FailoverProvider sends requests to a primary provider and switches to a
+// secondary one while the primary's health probes fail. Failed probes are
+// retried with exponential backoff.
+type FailoverProvider struct {
+	// ProbeInterval is the time between probes of a healthy provider
+	ProbeInterval time.Duration
+	// MinBackoff and MaxBackoff bound the time between probes of a failing provider
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// ProbeTimeout bounds a single probe
+	ProbeTimeout time.Duration
+
+	// OnFailover, when set, is called with the provider names whenever the
+	// active provider changes
+	OnFailover func(from, to string)
+
+	mu       sync.RWMutex
+	backends []*failoverBackend
+	active   string
+	wake     chan struct{}
+	now      func() time.Time
+}
+
+// failoverBackend tracks the probe state of one provider
+type failoverBackend struct {
+	name      string
+	role      string
+	provider  Provider
+	healthy   bool
+	failures  int
+	lastErr   string
+	lastProbe time.Time
+	nextProbe time.Time
+}
+
+// NewFailoverProvider returns a FailoverProvider with only a primary provider
+func NewFailoverProvider(name string, primary Provider) *FailoverProvider {
+	f := &FailoverProvider{
+		ProbeInterval: 30 * time.Second,
+		MinBackoff:    5 * time.Second,
+		MaxBackoff:    5 * time.Minute,
+		ProbeTimeout:  10 * time.Second,
+		wake:          make(chan struct{}, 1),
+		now:           time.Now,
+	}
+	f.SetProviders(name, primary, "", nil)
+	return f
+}
+
+// SetProviders replaces the primary and, when secondary is not nil, the
+// secondary provider. A provider replaced under the same name and role keeps
+// its probe state; new ones start out healthy and are probed right away.
+func (f *FailoverProvider) SetProviders(primaryName string, primary Provider, secondaryName string, secondary Provider) {
+	f.mu.Lock()
+	backends := []*failoverBackend{f.backendLocked(primaryName, RolePrimary, primary)}
+	if secondary != nil {
+		backends = append(backends, f.backendLocked(secondaryName, RoleSecondary, secondary))
+	}
+	f.backends = backends
+	f.active = f.selectLocked().name
+	f.mu.Unlock()
+	f.trigger()
+}
+
+// backendLocked returns the backend for a provider, carrying over the probe
+// state of the current backend with the same name and role
+func (f *FailoverProvider) backendLocked(name, role string, provider Provider) *failoverBackend {
+	b := &failoverBackend{name: name, role: role, provider: provider, healthy: true, nextProbe: f.now()}
+	for _, old := range f.backends {
+		if old.name == name && old.role == role {
+			*b = *old
+			b.provider = provider
+		}
+	}
+	return b
+}
+
+// Start probes the providers until ctx is done
+func (f *FailoverProvider) Start(ctx context.Context) {
+	go func() {
+		for {
+			f.ProbeDue(ctx)
+
+			timer := time.NewTimer(f.untilNextProbe())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-f.wake:
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// ProbeDue probes every provider whose next probe is due
+func (f *FailoverProvider) ProbeDue(ctx context.Context) {
+	f.mu.RLock()
+	now := f.now()
+	var due []*failoverBackend
+	for _, b := range f.backends {
+		if !now.Before(b.nextProbe) {
+			due = append(due, b)
+		}
+	}
+	f.mu.RUnlock()
+
+	for _, b := range due {
+		err := f.probe(ctx, b.provider)
+
+		f.mu.Lock()
+		b.lastProbe = f.now()
+		if err != nil {
+			b.healthy = false
+			b.failures++
+			b.lastErr = err.Error()
+			b.nextProbe = b.lastProbe.Add(f.backoff(b.failures))
+		} else {
+			b.healthy = true
+			b.failures = 0
+			b.lastErr = ""
+			b.nextProbe = b.lastProbe.Add(f.ProbeInterval)
+		}
+		f.mu.Unlock()
+	}
+
+	f.updateActive()
+}
+
+// probe checks a provider with its Prober, or with a batch fetch when it has
+// no probe of its own. Providers with neither are assumed healthy.
+func (f *FailoverProvider) probe(ctx context.Context, provider Provider) error {
+	if f.ProbeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.ProbeTimeout)
+		defer cancel()
+	}
+
+	switch p := provider.(type) {
+	case Prober:
+		return p.Probe(ctx)
+	case BatchProvider:
+		_, err := p.FetchAllContainerMetrics(ctx)
+		return err
+	}
+	return nil
+}
+
+// backoff returns the wait before the next probe after the given number of
+// consecutive failures
+func (f *FailoverProvider) backoff(failures int) time.Duration {
+	wait := f.MinBackoff
+	for i := 1; i < failures && wait < f.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if f.MaxBackoff > 0 && wait > f.MaxBackoff {
+		wait = f.MaxBackoff
+	}
+	return wait
+}
+
+// untilNextProbe returns the time until the earliest due probe
+func (f *FailoverProvider) untilNextProbe() time.Duration {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	wait := f.ProbeInterval
+	now := f.now()
+	for _, b := range f.backends {
+		if d := b.nextProbe.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// updateActive selects the primary when healthy, else a healthy secondary,
+// else the primary
+func (f *FailoverProvider) updateActive() {
+	f.mu.Lock()
+	previous := f.active
+	f.active = f.selectLocked().name
+	current := f.active
+	f.mu.Unlock()
+
+	if previous != current && f.OnFailover != nil {
+		f.OnFailover(previous, current)
+	}
+}
+
+func (f *FailoverProvider) selectLocked() *failoverBackend {
+	for _, b := range f.backends {
+		if b.healthy {
+			return b
+		}
+	}
+	return f.backends[0]
+}
+
+// current returns the provider requests go to
+func (f *FailoverProvider) current() Provider {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.selectLocked().provider
+}
+
+// trigger wakes the probe loop
+func (f *FailoverProvider) trigger() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// failed brings forward the next probe of the active provider after a fetch
+// error, so an outage is noticed before the next regular probe. Probes stay
+// at least MinBackoff apart however many fetches fail.
+func (f *FailoverProvider) failed(err error) {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	f.mu.Lock()
+	b := f.selectLocked()
+	soon := b.lastProbe.Add(f.MinBackoff)
+	moved := b.healthy && soon.Before(b.nextProbe)
+	if moved {
+		b.nextProbe = soon
+	}
+	f.mu.Unlock()
+
+	if moved {
+		f.trigger()
+	}
+}
+
+// FetchPodMetrics fetches pod usage from the active provider
+func (f *FailoverProvider) FetchPodMetrics(ctx context.Context, namespace, podName string) (Metrics, error) {
+	m, err := f.current().FetchPodMetrics(ctx, namespace, podName)
+	f.failed(err)
+	return m, err
+}
+
+// FetchContainerMetrics fetches per-container usage from the active provider
+func (f *FailoverProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error) {
+	m, err := f.current().FetchContainerMetrics(ctx, namespace, podName)
+	f.failed(err)
+	return m, err
+}
+
+// FetchAllContainerMetrics fetches the usage of every pod when the active
+// provider supports batch requests
+func (f *FailoverProvider) FetchAllContainerMetrics(ctx context.Context) (map[string]ContainerMetrics, error) {
+	batch, ok := f.current().(BatchProvider)
+	if !ok {
+		return nil, errors.New("active metrics provider does not support batch requests")
+	}
+	m, err := batch.FetchAllContainerMetrics(ctx)
+	f.failed(err)
+	return m, err
+}
+
+// FetchContainerHistory fetches usage history when the active provider
+// supports range requests
+func (f *FailoverProvider) FetchContainerHistory(ctx context.Context, namespace, podName, container string, start, end time.Time) (ContainerHistory, error) {
+	ranged, ok := f.current().(RangeProvider)
+	if !ok {
+		return ContainerHistory{}, errors.New("active metrics provider does not support usage history")
+	}
+	return ranged.FetchContainerHistory(ctx, namespace, podName, container, start, end)
+}
+
+// FetchPodIO fetches a pod's network and disk throughput when the active
+// provider reports I/O
+func (f *FailoverProvider) FetchPodIO(ctx context.Context, namespace, podName string) (IOUsage, error) {
+	source, ok := f.current().(IOSource)
+	if !ok {
+		return IOUsage{}, errors.New("active metrics provider does not report I/O")
+	}
+	return source.FetchPodIO(ctx, namespace, podName)
+}
+
+// FetchClusterIO fetches the network and disk throughput of all pods when
+// the active provider reports I/O
+func (f *FailoverProvider) FetchClusterIO(ctx context.Context) (IOUsage, error) {
+	source, ok := f.current().(IOSource)
+	if !ok {
+		return IOUsage{}, errors.New("active metrics provider does not report I/O")
+	}
+	return source.FetchClusterIO(ctx)
+}
+
+// Providers returns the primary and secondary provider; secondary is nil
+// when there is none
+func (f *FailoverProvider) Providers() (primary, secondary Provider) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if len(f.backends) > 1 {
+		secondary = f.backends[1].provider
+	}
+	return f.backends[0].provider, secondary
+}
+
+// Status returns the probe state of each provider, primary first
+func (f *FailoverProvider) Status() []ProviderStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	statuses := make([]ProviderStatus, 0, len(f.backends))
+	for _, b := range f.backends {
+		statuses = append(statuses, ProviderStatus{
+			Name:                b.name,
+			Role:                b.role,
+			Healthy:             b.healthy,
+			Active:              b.name == f.active,
+			ConsecutiveFailures: b.failures,
+			LastError:           b.lastErr,
+			LastProbe:           b.lastProbe,
+			NextProbe:           b.nextProbe,
+		})
+	}
+	return statuses
+}
+
+// Health reports whether any provider is healthy, with a message naming the
+// active provider and the errors of the failing ones
+func (f *FailoverProvider) Health() (bool, string) {
+	var healthy bool
+	var active string
+	var failing []string
+	for _, s := range f.Status() {
+		if s.Healthy {
+			healthy = true
+		} else {
+			failing = append(failing, fmt.Sprintf("%s: %s", s.Name, s.LastError))
+		}
+		if s.Active {
+			active = s.Name
+		}
+	}
+
+	switch {
+	case !healthy:
+		return false, fmt.Sprintf("no metrics provider is available (%v)", failing)
+	case len(failing) > 0:
+		return true, fmt.Sprintf("using %s (%v)", active, failing)
+	}
+	return true, fmt.Sprintf("using %s", active)
+}
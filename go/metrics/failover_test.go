@@ -0,0 +1,163 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// probedProvider is a Provider whose probe result can be switched
+type probedProvider struct {
+	cpu    float64
+	down   bool
+	probes int
+}
+
+func (p *probedProvider) Probe(context.Context) error {
+	p.probes++
+	if p.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (p *probedProvider) FetchPodMetrics(context.Context, string, string) (Metrics, error) {
+	if p.down {
+		return Metrics{}, errors.New("connection refused")
+	}
+	return Metrics{CPUMilli: p.cpu}, nil
+}
+
+func (p *probedProvider) FetchContainerMetrics(context.Context, string, string) (ContainerMetrics, error) {
+	if p.down {
+		return nil, errors.New("connection refused")
+	}
+	return ContainerMetrics{"app": {CPUMilli: p.cpu}}, nil
+}
+
+// newTestFailover returns a FailoverProvider on a manual clock
+func newTestFailover(primary, secondary Provider) (*FailoverProvider, *time.Time) {
+	f := NewFailoverProvider("metrics-server", primary)
+	now := time.Now()
+	f.now = func() time.Time { return now }
+	f.SetProviders("metrics-server", primary, "prometheus", secondary)
+	return f, &now
+}
+
+func TestFailoverProviderSwitchesToSecondary(t *testing.T) {
+	primary := &probedProvider{cpu: 100}
+	secondary := &probedProvider{cpu: 200}
+	f, now := newTestFailover(primary, secondary)
+
+	var switches []string
+	f.OnFailover = func(from, to string) { switches = append(switches, from+"->"+to) }
+
+	ctx := context.Background()
+	f.ProbeDue(ctx)
+	if m, _ := f.FetchPodMetrics(ctx, "default", "web"); m.CPUMilli != 100 {
+		t.Fatalf("expected the primary to serve requests, got %+v", m)
+	}
+
+	primary.down = true
+	if _, err := f.FetchPodMetrics(ctx, "default", "web"); err == nil {
+		t.Fatal("expected the failing fetch to return its error")
+	}
+	*now = now.Add(f.MinBackoff)
+	f.ProbeDue(ctx)
+	if m, _ := f.FetchContainerMetrics(ctx, "default", "web"); m["app"].CPUMilli != 200 {
+		t.Fatalf("expected the secondary to serve requests, got %+v", m)
+	}
+	if healthy, msg := f.Health(); !healthy {
+		t.Errorf("expected healthy while the secondary is up, got %q", msg)
+	}
+
+	primary.down = false
+	*now = now.Add(f.MinBackoff)
+	f.ProbeDue(ctx)
+	if m, _ := f.FetchPodMetrics(ctx, "default", "web"); m.CPUMilli != 100 {
+		t.Fatalf("expected the primary to serve requests again, got %+v", m)
+	}
+
+	if len(switches) != 2 || switches[0] != "metrics-server->prometheus" || switches[1] != "prometheus->metrics-server" {
+		t.Errorf("unexpected failovers: %v", switches)
+	}
+}
+
+func TestFailoverProviderBacksOff(t *testing.T) {
+	primary := &probedProvider{down: true}
+	f, now := newTestFailover(primary, nil)
+	ctx := context.Background()
+
+	var waits []time.Duration
+	for i := 0; i < 4; i++ {
+		if i > 0 {
+			*now = f.Status()[0].NextProbe
+		}
+		f.ProbeDue(ctx)
+		status := f.Status()[0]
+		waits = append(waits, status.NextProbe.Sub(status.LastProbe))
+	}
+
+	want := []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("probe %d: expected backoff %v, got %v", i+1, want[i], waits[i])
+		}
+	}
+	if got := f.backoff(20); got != f.MaxBackoff {
+		t.Errorf("expected backoff capped at %v, got %v", f.MaxBackoff, got)
+	}
+
+	status := f.Status()[0]
+	if status.Healthy || status.ConsecutiveFailures != 4 || status.LastError == "" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if healthy, _ := f.Health(); healthy {
+		t.Error("expected unhealthy with no provider available")
+	}
+
+	// Probes that are not due are skipped
+	f.ProbeDue(ctx)
+	if primary.probes != 4 {
+		t.Errorf("expected 4 probes, got %d", primary.probes)
+	}
+}
+
+func TestFailoverProviderUnsupportedRequests(t *testing.T) {
+	f, _ := newTestFailover(&probedProvider{}, nil)
+
+	if _, err := f.FetchAllContainerMetrics(context.Background()); err == nil {
+		t.Error("expected an error for batch requests")
+	}
+	if _, err := f.FetchContainerHistory(context.Background(), "default", "web", "app", time.Time{}, time.Time{}); err == nil {
+		t.Error("expected an error for history requests")
+	}
+}
+
+func TestFailoverProviderKeepsStateOnReplace(t *testing.T) {
+	primary := &probedProvider{down: true}
+	f, _ := newTestFailover(primary, &probedProvider{})
+	f.ProbeDue(context.Background())
+
+	replaced := &probedProvider{cpu: 300}
+	f.SetProviders("metrics-server", replaced, "prometheus", &probedProvider{})
+	if status := f.Status()[0]; status.Healthy || status.ConsecutiveFailures != 1 || status.Active {
+		t.Errorf("expected the replaced primary to keep its probe state, got %+v", status)
+	}
+	if p, _ := f.Providers(); p != replaced {
+		t.Error("expected the replaced primary to be returned")
+	}
+
+	f.SetProviders("prometheus", &probedProvider{}, "", nil)
+	if status := f.Status(); len(status) != 1 || !status[0].Healthy || !status[0].Active {
+		t.Errorf("expected a new provider to start out healthy, got %+v", status)
+	}
+}
@@ -16,6 +16,7 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 
@@ -343,41 +344,39 @@ func createMemoryMetrics() *MemoryMetrics {
 		),
 	}
 
-	// Temporarily disable memory metrics registration to test crash fix
-	_ = metrics
-	// safeRegister(
-	//	metrics.PodMemoryUsageBytes,
-	//	metrics.PodMemoryWorkingSetBytes,
-	//	metrics.PodMemoryRSSBytes,
-	//	metrics.PodMemoryCacheBytes,
-	//	metrics.PodMemorySwapBytes,
-	//	metrics.PodMemoryLimitBytes,
-	//	metrics.PodMemoryRequestBytes,
-	//	metrics.PodMemoryUtilizationPercentage,
-	//	metrics.PodMemoryRequestUtilization,
-	//	metrics.PodMemoryLimitUtilization,
-	//	metrics.MemoryRecommendationBytes,
-	//	metrics.MemoryRecommendationRatio,
-	//	metrics.MemoryPressureGauge,
-	//	metrics.MemoryOOMKillEvents,
-	//	metrics.MemoryThrottlingEvents,
-	//	metrics.MemoryTrendSlope,
-	//	metrics.MemoryUsageHistory,
-	//	metrics.MemoryResizeEvents,
-	//	metrics.MemoryPatternMatches,
-	// )
-	//	metrics.MemoryPeakUsageBytes,
-	//	metrics.MemoryAverageUsageBytes,
-	//	metrics.MemoryWasteBytes,
-	//	metrics.MemoryEfficiencyScore,
-	//	metrics.ContainerMemoryUsageBytes,
-	//	metrics.ContainerMemoryWorkingSetBytes,
-	//	metrics.ContainerMemoryRSSBytes,
-	//	metrics.ContainerMemoryCacheBytes,
-	//	metrics.MemoryAllocationFailures,
-	//	metrics.MemoryResizeOperations,
-	//	metrics.MemoryResizeSuccessRate,
-	// )
+	// Register every metric with the dedicated registry
+	if err := errors.Join(
+		registerCollector(Registry, &metrics.PodMemoryUsageBytes),
+		registerCollector(Registry, &metrics.PodMemoryWorkingSetBytes),
+		registerCollector(Registry, &metrics.PodMemoryRSSBytes),
+		registerCollector(Registry, &metrics.PodMemoryCacheBytes),
+		registerCollector(Registry, &metrics.PodMemorySwapBytes),
+		registerCollector(Registry, &metrics.PodMemoryLimitBytes),
+		registerCollector(Registry, &metrics.PodMemoryRequestBytes),
+		registerCollector(Registry, &metrics.PodMemoryUtilizationPercentage),
+		registerCollector(Registry, &metrics.PodMemoryRequestUtilization),
+		registerCollector(Registry, &metrics.PodMemoryLimitUtilization),
+		registerCollector(Registry, &metrics.MemoryRecommendationBytes),
+		registerCollector(Registry, &metrics.MemoryRecommendationRatio),
+		registerCollector(Registry, &metrics.MemoryPressureEvents),
+		registerCollector(Registry, &metrics.MemoryPressureLevel),
+		registerCollector(Registry, &metrics.MemoryOOMKillEvents),
+		registerCollector(Registry, &metrics.MemoryThrottlingEvents),
+		registerCollector(Registry, &metrics.MemoryTrendSlope),
+		registerCollector(Registry, &metrics.MemoryPeakUsageBytes),
+		registerCollector(Registry, &metrics.MemoryAverageUsageBytes),
+		registerCollector(Registry, &metrics.MemoryWasteBytes),
+		registerCollector(Registry, &metrics.MemoryEfficiencyScore),
+		registerCollector(Registry, &metrics.ContainerMemoryUsageBytes),
+		registerCollector(Registry, &metrics.ContainerMemoryWorkingSetBytes),
+		registerCollector(Registry, &metrics.ContainerMemoryRSSBytes),
+		registerCollector(Registry, &metrics.ContainerMemoryCacheBytes),
+		registerCollector(Registry, &metrics.MemoryAllocationFailures),
+		registerCollector(Registry, &metrics.MemoryResizeOperations),
+		registerCollector(Registry, &metrics.MemoryResizeSuccessRate),
+	); err != nil {
+		klog.Errorf("Failed to register memory metrics: %v", err)
+	}
 
 	return metrics
 }
@@ -21,6 +21,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -78,8 +79,79 @@ func (m *MetricsServerProvider) FetchPodMetrics(ctx context.Context, namespace,
 	totalMemMB := float64(totalMemBytes) / (1024 * 1024)
 
 	return Metrics{
-		CPUMilli:     totalCPUMilli,
-		MemMB:        totalMemMB,
-		CPUThrottled: 0, // metrics-server doesn't provide throttling
+		CPUMilli:        totalCPUMilli,
+		MemMB:           totalMemMB,
+		MemWorkingSetMB: totalMemMB, // metrics-server reports the working set
+		CPUThrottled:    0,          // metrics-server doesn't provide throttling
 	}, nil
 }
+
+// FetchContainerMetrics fetches CPU and memory usage for each container in a pod from metrics-server
+func (m *MetricsServerProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error) {
+	if m.MetricsClient == nil {
+		return nil, errors.New("metrics client not available")
+	}
+
+	podMetrics, err := m.MetricsClient.MetricsV1beta1().PodMetricses(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+	}
+
+	return toContainerMetrics(podMetrics.Containers), nil
+}
+
+// FetchAllContainerMetrics lists the usage of every pod from metrics-server
+// in one call
+func (m *MetricsServerProvider) FetchAllContainerMetrics(ctx context.Context) (map[string]ContainerMetrics, error) {
+	if m.MetricsClient == nil {
+		return nil, errors.New("metrics client not available")
+	}
+
+	list, err := m.MetricsClient.MetricsV1beta1().PodMetricses(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+
+	result := make(map[string]ContainerMetrics, len(list.Items))
+	for _, podMetrics := range list.Items {
+		result[podMetrics.Namespace+"/"+podMetrics.Name] = toContainerMetrics(podMetrics.Containers)
+	}
+	return result, nil
+}
+
+// Probe checks that metrics-server is serving pod metrics
+func (m *MetricsServerProvider) Probe(ctx context.Context) error {
+	if m.MetricsClient == nil {
+		return errors.New("metrics client not available")
+	}
+
+	_, err := m.MetricsClient.MetricsV1beta1().PodMetricses(metav1.NamespaceAll).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to list pod metrics: %w", err)
+	}
+	return nil
+}
+
+// toContainerMetrics converts metrics-server container usage
+func toContainerMetrics(containers []metricsv1beta1.ContainerMetrics) ContainerMetrics {
+	result := make(ContainerMetrics, len(containers))
+	for _, container := range containers {
+		var cpuMilli float64
+		var memBytes int64
+
+		if cpuUsage, ok := container.Usage["cpu"]; ok {
+			cpuMilli = float64(cpuUsage.MilliValue())
+		}
+		if memUsage, ok := container.Usage["memory"]; ok {
+			memBytes = memUsage.Value()
+		}
+
+		// metrics-server reports the working set as the memory usage
+		result[container.Name] = Metrics{
+			CPUMilli:        cpuMilli,
+			MemMB:           float64(memBytes) / (1024 * 1024),
+			MemWorkingSetMB: float64(memBytes) / (1024 * 1024),
+		}
+	}
+	return result
+}
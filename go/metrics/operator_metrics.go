@@ -16,13 +16,19 @@
 package metrics
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"right-sizer/efficiency"
+	"right-sizer/idle"
+
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
 )
 
 // OperatorMetrics holds all Prometheus metrics for the right-sizer operator
@@ -42,26 +48,58 @@ type OperatorMetrics struct {
 	ProcessingDuration        *prometheus.HistogramVec
 	APICallDuration           *prometheus.HistogramVec
 	MetricsCollectionDuration prometheus.Histogram
+	ResizeDuration            *prometheus.HistogramVec // rightsizer_resize_duration_seconds
+	APIErrorsTotal            *prometheus.CounterVec   // rightsizer_api_errors_total
+	ApplyDelay                prometheus.Gauge         // rightsizer_apply_delay_seconds
+
+	// Resize decisions and their outcome, by reason
+	ResizeDecisionsTotal *prometheus.CounterVec // rightsizer_resize_decisions_total
+
+	// OOM handling metrics
+	OOMKillsTotal *prometheus.CounterVec // rightsizer_oom_kills_total
+
+	// Resizes held back, e.g. during a container's cooldown
+	ResizesSuppressedTotal *prometheus.CounterVec // rightsizer_resizes_suppressed_total
+	Paused                 *prometheus.GaugeVec   // rightsizer_paused
+
+	// Requested resources actually used, per workload, namespace and cluster
+	EfficiencyScore *prometheus.GaugeVec // rightsizer_efficiency_score
+
+	// Workloads whose usage has stayed near zero
+	IdleWorkloadSeconds *prometheus.GaugeVec // rightsizer_idle_workload_seconds
+
+	// Pod resize conditions observed, e.g. PodResizePending with reason Deferred
+	ResizeConditionsTotal *prometheus.CounterVec // rightsizer_resize_conditions_total
+
+	// Decisions clamped to fit namespace LimitRanges or ResourceQuotas
+	ConstrainedDecisionsTotal *prometheus.CounterVec // rightsizer_constrained_decisions_total
 
 	// Safety and validation metrics
 	SafetyThresholdViolations *prometheus.CounterVec
 	ResourceValidationErrors  *prometheus.CounterVec
 
 	// Retry and error metrics
-	RetryAttemptsTotal *prometheus.CounterVec
-	RetrySuccessTotal  *prometheus.CounterVec
+	RetryAttemptsTotal        *prometheus.CounterVec
+	RetrySuccessTotal         *prometheus.CounterVec
+	RetryExhaustedTotal       *prometheus.CounterVec   // rightsizer_retry_exhausted_total
+	RetryBackoffSeconds       *prometheus.HistogramVec // rightsizer_retry_backoff_seconds
+	CircuitBreakerState       *prometheus.GaugeVec     // rightsizer_circuit_breaker_state
+	CircuitBreakerTransitions *prometheus.CounterVec   // rightsizer_circuit_breaker_transitions_total
 
 	// Cluster resource metrics
 	ClusterResourceUtilization *prometheus.GaugeVec
 	NodeResourceAvailability   *prometheus.GaugeVec
+	NodeProjectedUtilization   *prometheus.GaugeVec // rightsizer_node_projected_utilization_ratio
 
 	// Policy and configuration metrics
 	PolicyRuleApplications *prometheus.CounterVec
 	ConfigurationReloads   prometheus.Counter
 
 	// Historical trend metrics
-	ResourceTrendPredictions *prometheus.GaugeVec
-	HistoricalDataPoints     prometheus.Gauge
+	ResourceTrendPredictions  *prometheus.GaugeVec
+	HistoricalDataPoints      prometheus.Gauge
+	PredictionModelSelected   *prometheus.GaugeVec // rightsizer_prediction_model_selected
+	PredictionModelConfidence *prometheus.GaugeVec // rightsizer_prediction_model_confidence
 
 	// Recommendation metrics
 	RecommendationsTotal    *prometheus.CounterVec // rightsizer_recommendations_total
@@ -86,17 +124,23 @@ var (
 	operatorMetricsOnce     sync.Once
 )
 
-// NewOperatorMetrics creates and registers all Prometheus metrics
-// Uses singleton pattern to prevent duplicate registration
+// NewOperatorMetrics creates the operator's metrics and registers them with
+// the dedicated Registry. Uses singleton pattern to prevent duplicate registration.
 func NewOperatorMetrics() *OperatorMetrics {
 	operatorMetricsOnce.Do(func() {
-		operatorMetricsInstance = createOperatorMetrics()
+		var err error
+		operatorMetricsInstance, err = NewOperatorMetricsWithRegisterer(Registry)
+		if err != nil {
+			klog.Errorf("Failed to register operator metrics: %v", err)
+		}
 	})
 	return operatorMetricsInstance
 }
 
-// createOperatorMetrics creates and registers all Prometheus metrics (internal)
-func createOperatorMetrics() *OperatorMetrics {
+// NewOperatorMetricsWithRegisterer creates the operator's metrics and
+// registers them with reg. The metrics are usable even when registering some
+// of them fails; those are then not exported.
+func NewOperatorMetricsWithRegisterer(reg prometheus.Registerer) (*OperatorMetrics, error) {
 	metrics := &OperatorMetrics{
 		PodsProcessedTotal: prometheus.NewCounter(prometheus.CounterOpts{
 			Name: "rightsizer_pods_processed_total",
@@ -127,6 +171,62 @@ func createOperatorMetrics() *OperatorMetrics {
 			[]string{"namespace", "pod_name", "error_type"},
 		),
 
+		OOMKillsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_oom_kills_total",
+				Help: "Total number of OOM-killed containers observed and the emergency action taken",
+			},
+			[]string{"namespace", "pod_name", "container_name", "action"},
+		),
+
+		ResizesSuppressedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_resizes_suppressed_total",
+				Help: "Total number of resize decisions that were not applied, by reason",
+			},
+			[]string{"namespace", "reason"},
+		),
+
+		Paused: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_paused",
+				Help: "Whether resizing is paused for the whole cluster (scope cluster) or for a namespace (scope namespace)",
+			},
+			[]string{"scope", "namespace"},
+		),
+
+		EfficiencyScore: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_efficiency_score",
+				Help: "Percentage of requested resources used over the efficiency window, for the cluster (scope cluster), a namespace (scope namespace) or a workload (scope workload)",
+			},
+			[]string{"scope", "namespace", "workload", "resource"},
+		),
+
+		IdleWorkloadSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_idle_workload_seconds",
+				Help: "How long a workload flagged as idle has used near-zero CPU",
+			},
+			[]string{"namespace", "workload"},
+		),
+
+		ResizeConditionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_resize_conditions_total",
+				Help: "Total number of pod resize condition transitions observed, by condition and reason",
+			},
+			[]string{"namespace", "condition", "reason"},
+		),
+
+		ConstrainedDecisionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_constrained_decisions_total",
+				Help: "Total number of resize decisions clamped to fit a LimitRange or ResourceQuota",
+			},
+			[]string{"namespace", "constraint"},
+		),
+
 		CPUAdjustmentsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rightsizer_cpu_adjustments_total",
@@ -178,6 +278,36 @@ func createOperatorMetrics() *OperatorMetrics {
 			},
 		),
 
+		ResizeDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "rightsizer_resize_duration_seconds",
+				Help:    "Time taken to apply an in-place resize of a container, by result",
+				Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"namespace", "result"},
+		),
+
+		APIErrorsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_api_errors_total",
+				Help: "Total number of failed Kubernetes API calls, by reason",
+			},
+			[]string{"api_endpoint", "method", "reason"},
+		),
+
+		ApplyDelay: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "rightsizer_apply_delay_seconds",
+			Help: "Delay between resizes the apply phase currently keeps, paced by the API server",
+		}),
+
+		ResizeDecisionsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_resize_decisions_total",
+				Help: "Total number of resize decisions, by outcome and reason",
+			},
+			[]string{"namespace", "decision", "reason"},
+		),
+
 		SafetyThresholdViolations: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rightsizer_safety_threshold_violations_total",
@@ -210,6 +340,39 @@ func createOperatorMetrics() *OperatorMetrics {
 			[]string{"operation"},
 		),
 
+		RetryExhaustedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_retry_exhausted_total",
+				Help: "Total number of operations that failed after all retries",
+			},
+			[]string{"operation"},
+		),
+
+		RetryBackoffSeconds: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "rightsizer_retry_backoff_seconds",
+				Help:    "Backoff waited before retrying an operation",
+				Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+			},
+			[]string{"operation"},
+		),
+
+		CircuitBreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_circuit_breaker_state",
+				Help: "Circuit breaker state: 0 closed, 1 open, 2 half-open",
+			},
+			[]string{"name"},
+		),
+
+		CircuitBreakerTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "rightsizer_circuit_breaker_transitions_total",
+				Help: "Total number of circuit breaker state transitions",
+			},
+			[]string{"name", "from", "to"},
+		),
+
 		ClusterResourceUtilization: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "rightsizer_cluster_resource_utilization_ratio",
@@ -226,6 +389,14 @@ func createOperatorMetrics() *OperatorMetrics {
 			[]string{"resource_type", "node_name"},
 		),
 
+		NodeProjectedUtilization: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_node_projected_utilization_ratio",
+				Help: "Share of a node's allocatable requested once the planned resizes are applied",
+			},
+			[]string{"node_name", "resource_type"},
+		),
+
 		PolicyRuleApplications: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "rightsizer_policy_rule_applications_total",
@@ -252,6 +423,22 @@ func createOperatorMetrics() *OperatorMetrics {
 			Help: "Number of historical data points stored",
 		}),
 
+		PredictionModelSelected: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_prediction_model_selected",
+				Help: "Whether the prediction model was selected for a container's resource (1) or only evaluated (0)",
+			},
+			[]string{"namespace", "pod_name", "container_name", "resource_type", "method"},
+		),
+
+		PredictionModelConfidence: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "rightsizer_prediction_model_confidence",
+				Help: "Confidence of each prediction model's latest prediction for a container's resource",
+			},
+			[]string{"namespace", "pod_name", "container_name", "resource_type", "method"},
+		),
+
 		// Aggregate metrics gauges
 		CPUUsagePercent: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "rightsizer_cpu_usage_percent",
@@ -271,11 +458,11 @@ func createOperatorMetrics() *OperatorMetrics {
 		}),
 		NetworkUsageMbps: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "rightsizer_network_usage_mbps",
-			Help: "Estimated aggregate network usage (simulated or collected)",
+			Help: "Aggregate network throughput of all pods in Mbps, received plus transmitted",
 		}),
 		DiskIOMBps: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "rightsizer_disk_io_mbps",
-			Help: "Estimated aggregate disk IO in MB/s (simulated or collected)",
+			Help: "Aggregate disk throughput of all pods in MB/s, read plus written",
 		}),
 		RecommendationsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -316,61 +503,62 @@ func createOperatorMetrics() *OperatorMetrics {
 		}),
 	}
 
-	// Register all metrics with safe registration (handles already registered errors)
-	safeRegister(
-		metrics.PodsProcessedTotal,
-		metrics.PodsResizedTotal,
-		metrics.PodsSkippedTotal,
-		metrics.PodProcessingErrors,
-		metrics.CPUAdjustmentsTotal,
-		metrics.MemoryAdjustmentsTotal,
-		metrics.ResourceChangeSize,
-		metrics.ProcessingDuration,
-		metrics.APICallDuration,
-		metrics.MetricsCollectionDuration,
-		metrics.SafetyThresholdViolations,
-		metrics.ResourceValidationErrors,
-		metrics.RetryAttemptsTotal,
-		metrics.RetrySuccessTotal,
-		metrics.ClusterResourceUtilization,
-		metrics.NodeResourceAvailability,
-		metrics.PolicyRuleApplications,
-		metrics.ConfigurationReloads,
-		metrics.ResourceTrendPredictions,
-		metrics.HistoricalDataPoints,
-		metrics.RecommendationsTotal,
-		metrics.RecommendationsApproved,
-		metrics.RecommendationsRejected,
-		metrics.RecommendationsExecuted,
-		metrics.RecommendationsExpired,
-		metrics.PendingRecommendations,
-		metrics.CPUUsagePercent,
-		metrics.MemoryUsagePercent,
-		metrics.ActivePodsTotal,
-		metrics.OptimizedResourcesTotal,
-		metrics.NetworkUsageMbps,
-		metrics.DiskIOMBps,
-		metrics.AvgUtilizationPercent,
+	// Register every metric, collecting the ones that could not be registered
+	err := errors.Join(
+		registerCollector(reg, &metrics.PodsProcessedTotal),
+		registerCollector(reg, &metrics.PodsResizedTotal),
+		registerCollector(reg, &metrics.PodsSkippedTotal),
+		registerCollector(reg, &metrics.PodProcessingErrors),
+		registerCollector(reg, &metrics.OOMKillsTotal),
+		registerCollector(reg, &metrics.ResizesSuppressedTotal),
+		registerCollector(reg, &metrics.Paused),
+		registerCollector(reg, &metrics.EfficiencyScore),
+		registerCollector(reg, &metrics.IdleWorkloadSeconds),
+		registerCollector(reg, &metrics.ResizeConditionsTotal),
+		registerCollector(reg, &metrics.ConstrainedDecisionsTotal),
+		registerCollector(reg, &metrics.CPUAdjustmentsTotal),
+		registerCollector(reg, &metrics.MemoryAdjustmentsTotal),
+		registerCollector(reg, &metrics.ResourceChangeSize),
+		registerCollector(reg, &metrics.ProcessingDuration),
+		registerCollector(reg, &metrics.APICallDuration),
+		registerCollector(reg, &metrics.MetricsCollectionDuration),
+		registerCollector(reg, &metrics.ResizeDuration),
+		registerCollector(reg, &metrics.APIErrorsTotal),
+		registerCollector(reg, &metrics.ApplyDelay),
+		registerCollector(reg, &metrics.ResizeDecisionsTotal),
+		registerCollector(reg, &metrics.SafetyThresholdViolations),
+		registerCollector(reg, &metrics.ResourceValidationErrors),
+		registerCollector(reg, &metrics.RetryAttemptsTotal),
+		registerCollector(reg, &metrics.RetrySuccessTotal),
+		registerCollector(reg, &metrics.RetryExhaustedTotal),
+		registerCollector(reg, &metrics.RetryBackoffSeconds),
+		registerCollector(reg, &metrics.CircuitBreakerState),
+		registerCollector(reg, &metrics.CircuitBreakerTransitions),
+		registerCollector(reg, &metrics.ClusterResourceUtilization),
+		registerCollector(reg, &metrics.NodeResourceAvailability),
+		registerCollector(reg, &metrics.NodeProjectedUtilization),
+		registerCollector(reg, &metrics.PolicyRuleApplications),
+		registerCollector(reg, &metrics.ConfigurationReloads),
+		registerCollector(reg, &metrics.ResourceTrendPredictions),
+		registerCollector(reg, &metrics.HistoricalDataPoints),
+		registerCollector(reg, &metrics.PredictionModelSelected),
+		registerCollector(reg, &metrics.PredictionModelConfidence),
+		registerCollector(reg, &metrics.RecommendationsTotal),
+		registerCollector(reg, &metrics.RecommendationsApproved),
+		registerCollector(reg, &metrics.RecommendationsRejected),
+		registerCollector(reg, &metrics.RecommendationsExecuted),
+		registerCollector(reg, &metrics.RecommendationsExpired),
+		registerCollector(reg, &metrics.PendingRecommendations),
+		registerCollector(reg, &metrics.CPUUsagePercent),
+		registerCollector(reg, &metrics.MemoryUsagePercent),
+		registerCollector(reg, &metrics.ActivePodsTotal),
+		registerCollector(reg, &metrics.OptimizedResourcesTotal),
+		registerCollector(reg, &metrics.NetworkUsageMbps),
+		registerCollector(reg, &metrics.DiskIOMBps),
+		registerCollector(reg, &metrics.AvgUtilizationPercent),
 	)
 
-	return metrics
-}
-
-// safeRegister registers Prometheus collectors, ignoring AlreadyRegisteredError
-func safeRegister(collectors ...prometheus.Collector) {
-	for _, collector := range collectors {
-		if err := prometheus.Register(collector); err != nil {
-			// Check if the error is because the metric is already registered
-			if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
-				// If it's not an AlreadyRegisteredError, log it but don't panic
-				// This allows the operator to continue even if some metrics fail to register
-				// In production, you might want to use a proper logger here
-				// For now, we'll silently continue to prevent crashes
-				continue
-			}
-			// If it's already registered, that's fine - just continue
-		}
-	}
+	return metrics, err
 }
 
 // UpdateMetrics sets the aggregate gauges used by the metrics API.
@@ -430,6 +618,73 @@ func (m *OperatorMetrics) RecordProcessingError(namespace, podName, errorType st
 	m.PodProcessingErrors.WithLabelValues(namespace, podName, errorType).Inc()
 }
 
+// RecordOOMKill records an OOM-killed container and the emergency action taken
+func (m *OperatorMetrics) RecordOOMKill(namespace, podName, containerName, action string) {
+	m.OOMKillsTotal.WithLabelValues(namespace, podName, containerName, action).Inc()
+}
+
+// RecordSuppressedResize records a resize decision that was held back
+func (m *OperatorMetrics) RecordSuppressedResize(namespace, reason string) {
+	m.ResizesSuppressedTotal.WithLabelValues(namespace, reason).Inc()
+}
+
+// SetPaused publishes the pause state: the cluster series is always
+// exported, a namespace series only while the namespace is paused
+func (m *OperatorMetrics) SetPaused(cluster bool, namespaces []string) {
+	m.Paused.Reset()
+	value := 0.0
+	if cluster {
+		value = 1
+	}
+	m.Paused.WithLabelValues("cluster", "").Set(value)
+	for _, namespace := range namespaces {
+		m.Paused.WithLabelValues("namespace", namespace).Set(1)
+	}
+}
+
+// SetEfficiencyScores publishes the efficiency scores of the report,
+// dropping the series of namespaces and workloads no longer scored
+func (m *OperatorMetrics) SetEfficiencyScores(report efficiency.Report) {
+	m.EfficiencyScore.Reset()
+	set := func(scope, namespace, workload string, score efficiency.Score) {
+		m.EfficiencyScore.WithLabelValues(scope, namespace, workload, "cpu").Set(score.CPU)
+		m.EfficiencyScore.WithLabelValues(scope, namespace, workload, "memory").Set(score.Memory)
+		m.EfficiencyScore.WithLabelValues(scope, namespace, workload, "overall").Set(score.Overall)
+	}
+	set("cluster", "", "", report.Cluster)
+	for _, ns := range report.Namespaces {
+		set("namespace", ns.Namespace, "", ns.Score)
+	}
+	for _, w := range report.Workloads {
+		set("workload", w.Namespace, w.Workload, w.Score)
+	}
+}
+
+// SetIdleWorkloads publishes the workloads flagged as idle, dropping the
+// series of workloads that are no longer idle
+func (m *OperatorMetrics) SetIdleWorkloads(workloads []idle.Workload, now time.Time) {
+	m.IdleWorkloadSeconds.Reset()
+	for _, w := range workloads {
+		m.IdleWorkloadSeconds.WithLabelValues(w.Namespace, w.Workload).Set(now.Sub(w.IdleSince).Seconds())
+	}
+}
+
+// RecordResizeCondition records a pod entering a resize condition
+func (m *OperatorMetrics) RecordResizeCondition(namespace, condition, reason string) {
+	m.ResizeConditionsTotal.WithLabelValues(namespace, condition, reason).Inc()
+}
+
+// RecordConstrainedDecision records a decision clamped by a namespace constraint
+func (m *OperatorMetrics) RecordConstrainedDecision(namespace, constraint string) {
+	m.ConstrainedDecisionsTotal.WithLabelValues(namespace, constraint).Inc()
+}
+
+// RecordNodeProjectedUtilization records the requested share of a node's
+// allocatable once the planned resizes are applied
+func (m *OperatorMetrics) RecordNodeProjectedUtilization(nodeName, resourceType string, ratio float64) {
+	m.NodeProjectedUtilization.WithLabelValues(nodeName, resourceType).Set(ratio)
+}
+
 // RecordResourceAdjustment records a resource adjustment
 func (m *OperatorMetrics) RecordResourceAdjustment(namespace, podName, containerName, resourceType, direction string, changePercentage float64) {
 	if resourceType == "cpu" {
@@ -451,6 +706,43 @@ func (m *OperatorMetrics) RecordAPICall(endpoint, method string, duration time.D
 	m.APICallDuration.WithLabelValues(endpoint, method).Observe(duration.Seconds())
 }
 
+// RecordAPIError records a failed Kubernetes API call by the reason the
+// API server gave, e.g. Conflict or Forbidden
+func (m *OperatorMetrics) RecordAPIError(endpoint, method string, err error) {
+	reason := string(apierrors.ReasonForError(err))
+	if reason == "" {
+		reason = "Unknown"
+	}
+	m.APIErrorsTotal.WithLabelValues(endpoint, method, reason).Inc()
+}
+
+// SetApplyDelay publishes the delay between resizes of the apply phase
+func (m *OperatorMetrics) SetApplyDelay(delay time.Duration) {
+	m.ApplyDelay.Set(delay.Seconds())
+}
+
+// RecordResizeDuration records how long applying a pod's resize took, with
+// the pod as exemplar
+func (m *OperatorMetrics) RecordResizeDuration(namespace, podName, result string, duration time.Duration) {
+	observer := m.ResizeDuration.WithLabelValues(namespace, result)
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok {
+		exemplarObserver.ObserveWithExemplar(duration.Seconds(), podExemplar(namespace, podName))
+		return
+	}
+	observer.Observe(duration.Seconds())
+}
+
+// RecordResizeDecision records the outcome of a resize decision, e.g.
+// applied, failed or skipped, with the pod as exemplar
+func (m *OperatorMetrics) RecordResizeDecision(namespace, podName, decision, reason string) {
+	counter := m.ResizeDecisionsTotal.WithLabelValues(namespace, decision, reason)
+	if exemplarAdder, ok := counter.(prometheus.ExemplarAdder); ok {
+		exemplarAdder.AddWithExemplar(1, podExemplar(namespace, podName))
+		return
+	}
+	counter.Inc()
+}
+
 // RecordMetricsCollection records the duration of metrics collection
 func (m *OperatorMetrics) RecordMetricsCollection(duration time.Duration) {
 	m.MetricsCollectionDuration.Observe(duration.Seconds())
@@ -476,6 +768,27 @@ func (m *OperatorMetrics) RecordRetrySuccess(operation string) {
 	m.RetrySuccessTotal.WithLabelValues(operation).Inc()
 }
 
+// RecordRetryExhausted records an operation that failed after all retries
+func (m *OperatorMetrics) RecordRetryExhausted(operation string) {
+	m.RetryExhaustedTotal.WithLabelValues(operation).Inc()
+}
+
+// RecordRetryBackoff records the backoff waited before a retry
+func (m *OperatorMetrics) RecordRetryBackoff(operation string, backoff time.Duration) {
+	m.RetryBackoffSeconds.WithLabelValues(operation).Observe(backoff.Seconds())
+}
+
+// SetCircuitBreakerState publishes the state of a circuit breaker, encoded
+// as 0 closed, 1 open and 2 half-open
+func (m *OperatorMetrics) SetCircuitBreakerState(name string, state int) {
+	m.CircuitBreakerState.WithLabelValues(name).Set(float64(state))
+}
+
+// RecordCircuitBreakerTransition records a circuit breaker changing state
+func (m *OperatorMetrics) RecordCircuitBreakerTransition(name, from, to string) {
+	m.CircuitBreakerTransitions.WithLabelValues(name, from, to).Inc()
+}
+
 // UpdateClusterResourceUtilization updates cluster resource utilization metrics
 func (m *OperatorMetrics) UpdateClusterResourceUtilization(resourceType, nodeName string, utilization float64) {
 	m.ClusterResourceUtilization.WithLabelValues(resourceType, nodeName).Set(utilization)
@@ -501,6 +814,19 @@ func (m *OperatorMetrics) UpdateResourceTrendPrediction(namespace, podName, cont
 	m.ResourceTrendPredictions.WithLabelValues(namespace, podName, containerName, resourceType, predictionHorizon).Set(prediction)
 }
 
+// RecordPredictionModelSelection records the confidence of every model
+// evaluated for a container's resource and which one was selected
+func (m *OperatorMetrics) RecordPredictionModelSelection(namespace, podName, containerName, resourceType, selected string, confidences map[string]float64) {
+	for method, confidence := range confidences {
+		m.PredictionModelConfidence.WithLabelValues(namespace, podName, containerName, resourceType, method).Set(confidence)
+		value := 0.0
+		if method == selected {
+			value = 1
+		}
+		m.PredictionModelSelected.WithLabelValues(namespace, podName, containerName, resourceType, method).Set(value)
+	}
+}
+
 // UpdateHistoricalDataPoints updates the count of historical data points
 func (m *OperatorMetrics) UpdateHistoricalDataPoints(count float64) {
 	m.HistoricalDataPoints.Set(count)
@@ -536,10 +862,11 @@ func (m *OperatorMetrics) UpdatePendingRecommendations(count float64) {
 	m.PendingRecommendations.Set(count)
 }
 
-// StartMetricsServer starts the Prometheus metrics HTTP server
-func StartMetricsServer(port int) error {
+// StartMetricsServer serves the Prometheus metrics until ctx is done, then
+// shuts the server down
+func StartMetricsServer(ctx context.Context, port int) error {
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/metrics", Handler())
 
 	// Add custom health check for metrics
 	mux.HandleFunc("/metrics/health", func(w http.ResponseWriter, r *http.Request) {
@@ -556,7 +883,18 @@ func StartMetricsServer(port int) error {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	return server.ListenAndServe()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
 }
 
 // Timer is a helper for measuring operation durations
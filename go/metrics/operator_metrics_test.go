@@ -16,13 +16,20 @@
 package metrics
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"right-sizer/efficiency"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestNewOperatorMetrics(t *testing.T) {
@@ -58,23 +65,105 @@ func TestNewOperatorMetrics_Singleton(t *testing.T) {
 	assert.Equal(t, metrics1, metrics2, "Should return the same singleton instance")
 }
 
-func TestSafeRegister(t *testing.T) {
-	// Create a test counter
+func TestRegisterCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
 	counter := prometheus.NewCounter(prometheus.CounterOpts{
-		Name: "test_safe_register_counter",
-		Help: "Test counter for safe registration",
+		Name: "test_register_collector_counter",
+		Help: "Test counter for registration",
 	})
+	require.NoError(t, registerCollector(reg, &counter))
 
-	// First registration should succeed
-	safeRegister(counter)
+	// Registering an identical collector again adopts the registered one
+	duplicate := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "test_register_collector_counter",
+		Help: "Test counter for registration",
+	})
+	require.NoError(t, registerCollector(reg, &duplicate))
+	assert.Same(t, counter, duplicate)
 
-	// Second registration should not panic (already registered)
-	assert.NotPanics(t, func() {
-		safeRegister(counter)
-	}, "Safe register should not panic on duplicate registration")
+	// A conflicting definition is reported instead of panicking
+	conflicting := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "test_register_collector_counter",
+		Help: "Conflicting help",
+	})
+	assert.Error(t, registerCollector(reg, &conflicting))
+}
+
+func TestNewOperatorMetricsWithRegisterer(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	metrics, err := NewOperatorMetricsWithRegisterer(reg)
+	require.NoError(t, err)
+
+	// Creating the metrics again, as a reset singleton does, must neither
+	// fail nor leave the recorded values unexported
+	again, err := NewOperatorMetricsWithRegisterer(reg)
+	require.NoError(t, err)
+	again.RecordResizeDecision("prod", "web-0", "applied", "scale_down")
+	again.RecordResizeDuration("prod", "web-0", "success", 300*time.Millisecond)
+	again.RecordAPIError("pods/resize", "PATCH", apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "web-0", errors.New("changed")))
+	metrics.RecordResizeDecision("prod", "web-1", "applied", "scale_down")
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	decisions := byName["rightsizer_resize_decisions_total"]
+	require.NotNil(t, decisions)
+	counter := decisions.GetMetric()[0].GetCounter()
+	assert.Equal(t, 2.0, counter.GetValue())
+	require.NotNil(t, counter.GetExemplar())
+	assert.Equal(t, "prod/web-1", counter.GetExemplar().GetLabel()[0].GetValue())
+
+	durations := byName["rightsizer_resize_duration_seconds"]
+	require.NotNil(t, durations)
+	assert.Equal(t, uint64(1), durations.GetMetric()[0].GetHistogram().GetSampleCount())
+
+	apiErrors := byName["rightsizer_api_errors_total"]
+	require.NotNil(t, apiErrors)
+	for _, label := range apiErrors.GetMetric()[0].GetLabel() {
+		if label.GetName() == "reason" {
+			assert.Equal(t, "Conflict", label.GetValue())
+		}
+	}
+}
+
+func TestNewOperatorMetricsWithRegisterer_Conflict(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "rightsizer_pods_processed_total",
+		Help: "A conflicting definition",
+	}))
+
+	var metrics *OperatorMetrics
+	var err error
+	require.NotPanics(t, func() {
+		metrics, err = NewOperatorMetricsWithRegisterer(reg)
+	})
+	assert.Error(t, err)
+	require.NotNil(t, metrics)
+	assert.NotPanics(t, func() { metrics.RecordPodProcessed() })
+}
 
-	// Cleanup
-	prometheus.Unregister(counter)
+func TestOperatorAndMemoryMetricsShareRegistry(t *testing.T) {
+	operatorMetricsOnce = sync.Once{}
+	operatorMetricsInstance = nil
+	memoryMetricsOnce = sync.Once{}
+	memoryMetricsInstance = nil
+
+	require.NotNil(t, NewOperatorMetrics())
+	require.NotNil(t, NewMemoryMetrics())
+	NewMemoryMetrics().UpdatePodMemoryMetrics("prod", "web-0", "app", 100, 90, 80, 10, 0, 200, 150)
+
+	families, err := Registry.Gather()
+	require.NoError(t, err)
+	names := make(map[string]bool, len(families))
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	assert.True(t, names["rightsizer_pod_memory_usage_bytes"], "memory metrics should be exported")
 }
 
 func TestRecordPodProcessed(t *testing.T) {
@@ -285,6 +374,23 @@ func TestUpdateClusterResourceUtilization(t *testing.T) {
 	})
 }
 
+func TestSetEfficiencyScores(t *testing.T) {
+	metrics, err := NewOperatorMetricsWithRegisterer(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	metrics.SetEfficiencyScores(efficiency.Report{
+		Cluster:    efficiency.Score{CPU: 40, Memory: 50, Overall: 45},
+		Namespaces: []efficiency.NamespaceScore{{Namespace: "shop", Score: efficiency.Score{CPU: 40, Memory: 50, Overall: 45}}},
+		Workloads:  []efficiency.WorkloadScore{{Namespace: "shop", Workload: "Deployment/web", Score: efficiency.Score{CPU: 25, Memory: 50, Overall: 37.5}}},
+	})
+	assert.Equal(t, 9, testutil.CollectAndCount(metrics.EfficiencyScore))
+	assert.InDelta(t, 25, testutil.ToFloat64(metrics.EfficiencyScore.WithLabelValues("workload", "shop", "Deployment/web", "cpu")), 0.01)
+
+	// Workloads no longer scored are dropped
+	metrics.SetEfficiencyScores(efficiency.Report{Cluster: efficiency.Score{CPU: 40}})
+	assert.Equal(t, 3, testutil.CollectAndCount(metrics.EfficiencyScore))
+}
+
 func TestUpdateNodeResourceAvailability(t *testing.T) {
 	operatorMetricsOnce = sync.Once{}
 	operatorMetricsInstance = nil
@@ -16,43 +16,192 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 )
 
+// Names of the queries that can be overridden with PrometheusProvider.Queries
+const (
+	QueryCPU                   = "cpu"
+	QueryMemory                = "memory"
+	QueryCPUThrottled          = "cpuThrottled"
+	QueryContainerCPU          = "containerCPU"
+	QueryContainerMemory       = "containerMemory"
+	QueryContainerWorkingSet   = "containerWorkingSet"
+	QueryContainerRSS          = "containerRSS"
+	QueryContainerCPUThrottled = "containerCPUThrottled"
+	QueryContainerCPUPressure  = "containerCPUPressure"
+	QueryContainerMemPressure  = "containerMemoryPressure"
+	QueryCPUHistory            = "cpuHistory"
+	QueryMemoryHistory         = "memoryHistory"
+	QueryWorkingSetHistory     = "workingSetHistory"
+	QueryRSSHistory            = "rssHistory"
+	QueryNetwork               = "network"
+	QueryDiskIO                = "diskIO"
+	QueryClusterNetwork        = "clusterNetwork"
+	QueryClusterDiskIO         = "clusterDiskIO"
+)
+
+// defaultQueries are the PromQL templates used unless overridden. CPU is in
+// millicores and memory in bytes; container queries group by the container label.
+var defaultQueries = map[string]string{
+	QueryCPU:    `sum(rate(container_cpu_usage_seconds_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m])) * 1000`,
+	QueryMemory: `sum(container_memory_usage_bytes{namespace="{{.Namespace}}", pod="{{.Pod}}"})`,
+	// Formula: (increase in throttled CFS periods) / (increase in total CFS periods) * 100
+	QueryCPUThrottled: `
+		sum(increase(container_cpu_cfs_throttled_periods_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m]))
+		/
+		sum(increase(container_cpu_cfs_periods_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m]))
+		* 100`,
+	QueryContainerCPU:        `sum by (container) (rate(container_cpu_usage_seconds_total{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"}[5m])) * 1000`,
+	QueryContainerMemory:     `sum by (container) (container_memory_usage_bytes{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"})`,
+	QueryContainerWorkingSet: `sum by (container) (container_memory_working_set_bytes{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"})`,
+	QueryContainerRSS:        `sum by (container) (container_memory_rss{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"})`,
+	QueryContainerCPUThrottled: `
+		sum by (container) (increase(container_cpu_cfs_throttled_periods_total{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"}[5m]))
+		/
+		sum by (container) (increase(container_cpu_cfs_periods_total{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"}[5m]))
+		* 100`,
+	// Percentage of time tasks stalled on CPU or memory, from the cgroup v2
+	// pressure stall information cAdvisor exports on cgroup v2 nodes
+	QueryContainerCPUPressure: `sum by (container) (rate(container_pressure_cpu_waiting_seconds_total{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"}[5m])) * 100`,
+	QueryContainerMemPressure: `sum by (container) (rate(container_pressure_memory_waiting_seconds_total{namespace="{{.Namespace}}", pod="{{.Pod}}", container!="", container!="POD"}[5m])) * 100`,
+	QueryCPUHistory:           `sum(rate(container_cpu_usage_seconds_total{namespace="{{.Namespace}}", pod="{{.Pod}}", container="{{.Container}}"}[5m])) * 1000`,
+	QueryMemoryHistory:        `sum(container_memory_usage_bytes{namespace="{{.Namespace}}", pod="{{.Pod}}", container="{{.Container}}"})`,
+	QueryWorkingSetHistory:    `sum(container_memory_working_set_bytes{namespace="{{.Namespace}}", pod="{{.Pod}}", container="{{.Container}}"})`,
+	QueryRSSHistory:           `sum(container_memory_rss{namespace="{{.Namespace}}", pod="{{.Pod}}", container="{{.Container}}"})`,
+	// Network throughput is in megabits per second and disk throughput in
+	// megabytes per second, both directions added up
+	QueryNetwork: `
+		(sum(rate(container_network_receive_bytes_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m]))
+		+ sum(rate(container_network_transmit_bytes_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m])))
+		* 8 / 1000000`,
+	QueryDiskIO: `
+		(sum(rate(container_fs_reads_bytes_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m]))
+		+ sum(rate(container_fs_writes_bytes_total{namespace="{{.Namespace}}", pod="{{.Pod}}"}[5m])))
+		/ 1048576`,
+	QueryClusterNetwork: `
+		(sum(rate(container_network_receive_bytes_total{pod!=""}[5m]))
+		+ sum(rate(container_network_transmit_bytes_total{pod!=""}[5m])))
+		* 8 / 1000000`,
+	QueryClusterDiskIO: `
+		(sum(rate(container_fs_reads_bytes_total{pod!=""}[5m]))
+		+ sum(rate(container_fs_writes_bytes_total{pod!=""}[5m])))
+		/ 1048576`,
+}
+
+// maxRangePoints is the most points per series Prometheus returns from a range query
+const maxRangePoints = 11000
+
+// PrometheusOptions configures a PrometheusProvider
+type PrometheusOptions struct {
+	URL                string
+	Username           string
+	Password           string
+	BearerToken        string
+	CAFile             string // PEM bundle used to verify the server certificate
+	InsecureSkipVerify bool
+	Step               time.Duration
+	Queries            map[string]string
+	Timeout            time.Duration
+	MemoryMetric       string // usage, workingSet or rss history
+}
+
+// queryVars are the values available to query templates
+type queryVars struct {
+	Namespace string
+	Pod       string
+	Container string
+}
+
 // NewPrometheusProvider returns a PrometheusProvider
 func NewPrometheusProvider(promURL string) Provider {
 	return &PrometheusProvider{URL: promURL}
 }
 
+// NewPrometheusProviderWithOptions returns a PrometheusProvider with
+// authentication, TLS, custom queries and range query settings
+func NewPrometheusProviderWithOptions(opts PrometheusOptions) (*PrometheusProvider, error) {
+	for name, query := range opts.Queries {
+		if _, ok := defaultQueries[name]; !ok {
+			return nil, fmt.Errorf("unknown Prometheus query %q", name)
+		}
+		if _, err := template.New(name).Parse(query); err != nil {
+			return nil, fmt.Errorf("invalid Prometheus query %q: %w", name, err)
+		}
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify} // #nosec G402 -- opt-in for self-signed endpoints
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Prometheus CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &PrometheusProvider{
+		URL:          strings.TrimSuffix(opts.URL, "/"),
+		Username:     opts.Username,
+		Password:     opts.Password,
+		BearerToken:  opts.BearerToken,
+		Queries:      opts.Queries,
+		Step:         opts.Step,
+		MemoryMetric: opts.MemoryMetric,
+		HTTPClient:   &http.Client{Timeout: timeout, Transport: transport},
+	}, nil
+}
+
 // FetchPodMetrics queries Prometheus for CPU and memory usage for a pod
 func (p *PrometheusProvider) FetchPodMetrics(ctx context.Context, namespace, podName string) (Metrics, error) {
 	// Query CPU usage (millicores)
-	cpuQuery := fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{namespace="%s", pod="%s"}[5m])) * 1000`, namespace, podName)
+	vars := queryVars{Namespace: namespace, Pod: podName}
+	cpuQuery, err := p.buildQuery(QueryCPU, vars)
+	if err != nil {
+		return Metrics{}, err
+	}
 	cpuMilli, err := p.queryPrometheus(ctx, cpuQuery)
 	if err != nil {
 		return Metrics{}, fmt.Errorf("failed to query CPU metrics: %w", err)
 	}
 
 	// Query memory usage (bytes)
-	memQuery := fmt.Sprintf(`sum(container_memory_usage_bytes{namespace="%s", pod="%s"})`, namespace, podName)
+	memQuery, err := p.buildQuery(QueryMemory, vars)
+	if err != nil {
+		return Metrics{}, err
+	}
 	memBytes, err := p.queryPrometheus(ctx, memQuery)
 	if err != nil {
 		return Metrics{}, fmt.Errorf("failed to query memory metrics: %w", err)
 	}
 
 	// Query CPU throttling percentage
-	// Formula: (sum of increase in throttled time) / (sum of increase in total CPU time) * 100
-	throttledQuery := fmt.Sprintf(`
-		sum(increase(container_cpu_cfs_throttled_seconds_total{namespace="%s", pod="%s"}[5m]))
-		/
-		sum(increase(container_cpu_usage_seconds_total{namespace="%s", pod="%s"}[5m]))
-		* 100`, namespace, podName, namespace, podName)
-
+	throttledQuery, err := p.buildQuery(QueryCPUThrottled, vars)
+	if err != nil {
+		return Metrics{}, err
+	}
 	cpuThrottled, err := p.queryPrometheus(ctx, throttledQuery)
 	if err != nil {
 		// Throttling might not be available or 0 if no usage
@@ -69,51 +218,346 @@ func (p *PrometheusProvider) FetchPodMetrics(ctx context.Context, namespace, pod
 	}, nil
 }
 
-// queryPrometheus runs a Prometheus instant query and returns the value
-func (p *PrometheusProvider) queryPrometheus(ctx context.Context, query string) (float64, error) {
-	endpoint := fmt.Sprintf("%s/api/v1/query?query=%s", p.URL, url.QueryEscape(query))
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+// FetchContainerMetrics queries Prometheus for CPU and memory usage of each container in a pod
+func (p *PrometheusProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error) {
+	vars := queryVars{Namespace: namespace, Pod: podName}
+	cpuQuery, err := p.buildQuery(QueryContainerCPU, vars)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-	resp, err := http.DefaultClient.Do(req)
+	cpuByContainer, err := p.queryPrometheusVector(ctx, cpuQuery, "container")
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("failed to query container CPU metrics: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	memQuery, err := p.buildQuery(QueryContainerMemory, vars)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	memByContainer, err := p.queryPrometheusVector(ctx, memQuery, "container")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query container memory metrics: %w", err)
+	}
+
+	throttledQuery, err := p.buildQuery(QueryContainerCPUThrottled, vars)
+	if err != nil {
+		return nil, err
+	}
+	throttledByContainer, err := p.queryPrometheusVector(ctx, throttledQuery, "container")
+	if err != nil {
+		// Throttling might not be available
+		throttledByContainer = map[string]float64{}
+	}
+
+	result := make(ContainerMetrics)
+	for name, cpuMilli := range cpuByContainer {
+		m := result[name]
+		m.CPUMilli = cpuMilli
+		result[name] = m
+	}
+	for name, memBytes := range memByContainer {
+		m := result[name]
+		m.MemMB = memBytes / (1024 * 1024)
+		result[name] = m
+	}
+	for name, throttled := range throttledByContainer {
+		if m, ok := result[name]; ok {
+			m.CPUThrottled = throttled
+			result[name] = m
+		}
+	}
+
+	// The working set and RSS are alternatives to the usage and pressure is
+	// only reported on cgroup v2 nodes; not every backend exports them
+	for _, reading := range []struct {
+		query  string
+		scale  float64
+		target func(*Metrics) *float64
+	}{
+		{QueryContainerWorkingSet, 1024 * 1024, func(m *Metrics) *float64 { return &m.MemWorkingSetMB }},
+		{QueryContainerRSS, 1024 * 1024, func(m *Metrics) *float64 { return &m.MemRSSMB }},
+		{QueryContainerCPUPressure, 1, func(m *Metrics) *float64 { return &m.CPUPressure }},
+		{QueryContainerMemPressure, 1, func(m *Metrics) *float64 { return &m.MemoryPressure }},
+	} {
+		query, err := p.buildQuery(reading.query, vars)
+		if err != nil {
+			return nil, err
+		}
+		byContainer, err := p.queryPrometheusVector(ctx, query, "container")
+		if err != nil {
+			continue
+		}
+		for name, value := range byContainer {
+			if m, ok := result[name]; ok {
+				*reading.target(&m) = value / reading.scale
+				result[name] = m
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// FetchPodIO queries Prometheus for the network and disk throughput of a pod
+func (p *PrometheusProvider) FetchPodIO(ctx context.Context, namespace, podName string) (IOUsage, error) {
+	return p.fetchIO(ctx, QueryNetwork, QueryDiskIO, queryVars{Namespace: namespace, Pod: podName})
+}
+
+// FetchClusterIO queries Prometheus for the network and disk throughput of all pods
+func (p *PrometheusProvider) FetchClusterIO(ctx context.Context) (IOUsage, error) {
+	return p.fetchIO(ctx, QueryClusterNetwork, QueryClusterDiskIO, queryVars{})
+}
+
+// fetchIO runs the named network and disk queries
+func (p *PrometheusProvider) fetchIO(ctx context.Context, networkName, diskName string, vars queryVars) (IOUsage, error) {
+	networkQuery, err := p.buildQuery(networkName, vars)
+	if err != nil {
+		return IOUsage{}, err
+	}
+	network, err := p.queryPrometheus(ctx, networkQuery)
+	if err != nil {
+		return IOUsage{}, fmt.Errorf("failed to query network metrics: %w", err)
+	}
+
+	diskQuery, err := p.buildQuery(diskName, vars)
+	if err != nil {
+		return IOUsage{}, err
+	}
+	disk, err := p.queryPrometheus(ctx, diskQuery)
+	if err != nil {
+		return IOUsage{}, fmt.Errorf("failed to query disk metrics: %w", err)
 	}
 
-	var result struct {
-		Status string `json:"status"`
-		Data   struct {
-			ResultType string `json:"resultType"`
-			Result     []struct {
-				Value []interface{} `json:"value"`
-			} `json:"result"`
-		} `json:"data"`
+	return IOUsage{NetworkMbps: network, DiskIOMBps: disk}, nil
+}
+
+// Probe checks that Prometheus answers queries
+func (p *PrometheusProvider) Probe(ctx context.Context) error {
+	if _, err := p.doQuery(ctx, "vector(1)"); err != nil {
+		return fmt.Errorf("prometheus probe failed: %w", err)
+	}
+	return nil
+}
+
+// promQueryResult is the decoded body of a Prometheus instant query
+type promQueryResult struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Value  []interface{}     `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// doQuery runs a Prometheus instant query and returns the decoded response
+func (p *PrometheusProvider) doQuery(ctx context.Context, query string) (*promQueryResult, error) {
+	body, err := p.get(ctx, "/api/v1/query", url.Values{"query": {query}})
+	if err != nil {
+		return nil, err
 	}
+
+	var result promQueryResult
 	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	if result.Status != "success" || len(result.Data.Result) == 0 {
-		return 0, errors.New("no data returned from Prometheus")
+		return nil, errors.New("no data returned from Prometheus")
+	}
+	return &result, nil
+}
+
+// queryPrometheus runs a Prometheus instant query and returns the value
+func (p *PrometheusProvider) queryPrometheus(ctx context.Context, query string) (float64, error) {
+	result, err := p.doQuery(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return parseSampleValue(result.Data.Result[0].Value)
+}
+
+// queryPrometheusVector runs a Prometheus instant query and returns the values keyed by the given label
+func (p *PrometheusProvider) queryPrometheusVector(ctx context.Context, query, label string) (map[string]float64, error) {
+	result, err := p.doQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]float64, len(result.Data.Result))
+	for _, sample := range result.Data.Result {
+		key, ok := sample.Metric[label]
+		if !ok || key == "" {
+			continue
+		}
+		val, err := parseSampleValue(sample.Value)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = val
+	}
+	return values, nil
+}
+
+// parseSampleValue extracts the float value from a Prometheus [timestamp, "value"] pair
+func parseSampleValue(value []interface{}) (float64, error) {
+	if len(value) < 2 {
+		return 0, errors.New("unexpected value format")
 	}
 
 	// Value[1] is the string representation of the metric value
-	valStr, ok := result.Data.Result[0].Value[1].(string)
+	valStr, ok := value[1].(string)
 	if !ok {
 		return 0, errors.New("unexpected value format")
 	}
 
 	var val float64
-	_, err = fmt.Sscanf(valStr, "%f", &val)
+	_, err := fmt.Sscanf(valStr, "%f", &val)
 	if err != nil {
 		return 0, err
 	}
 	return val, nil
 }
+
+// promRangeResult is the decoded body of a Prometheus range query
+type promRangeResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		Result []struct {
+			Values [][]interface{} `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// FetchContainerHistory returns a container's CPU and memory usage between start and end
+func (p *PrometheusProvider) FetchContainerHistory(ctx context.Context, namespace, podName, container string, start, end time.Time) (ContainerHistory, error) {
+	vars := queryVars{Namespace: namespace, Pod: podName, Container: container}
+
+	cpuQuery, err := p.buildQuery(QueryCPUHistory, vars)
+	if err != nil {
+		return ContainerHistory{}, err
+	}
+	cpu, err := p.queryRange(ctx, cpuQuery, start, end)
+	if err != nil {
+		return ContainerHistory{}, fmt.Errorf("failed to query CPU history: %w", err)
+	}
+
+	memoryHistory := QueryMemoryHistory
+	switch p.MemoryMetric {
+	case MemoryMetricWorkingSet:
+		memoryHistory = QueryWorkingSetHistory
+	case MemoryMetricRSS:
+		memoryHistory = QueryRSSHistory
+	}
+	memQuery, err := p.buildQuery(memoryHistory, vars)
+	if err != nil {
+		return ContainerHistory{}, err
+	}
+	mem, err := p.queryRange(ctx, memQuery, start, end)
+	if err != nil {
+		return ContainerHistory{}, fmt.Errorf("failed to query memory history: %w", err)
+	}
+	for i := range mem {
+		mem[i].Value /= 1024 * 1024
+	}
+
+	return ContainerHistory{CPUMilli: cpu, MemMB: mem}, nil
+}
+
+// queryRange runs a Prometheus range query and returns the samples of all series
+func (p *PrometheusProvider) queryRange(ctx context.Context, query string, start, end time.Time) ([]Sample, error) {
+	step := p.Step
+	if step <= 0 {
+		step = time.Minute
+	}
+	// Widen the step rather than have the server reject a long range
+	if minStep := end.Sub(start) / maxRangePoints; step < minStep {
+		step = minStep.Truncate(time.Second) + time.Second
+	}
+
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	body, err := p.get(ctx, "/api/v1/query_range", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result promRangeResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.Status != "success" {
+		return nil, fmt.Errorf("range query failed: %s", result.Error)
+	}
+
+	var samples []Sample
+	for _, series := range result.Data.Result {
+		for _, pair := range series.Values {
+			value, err := parseSampleValue(pair)
+			if err != nil {
+				continue
+			}
+			ts, ok := pair[0].(float64)
+			if !ok {
+				continue
+			}
+			samples = append(samples, Sample{Timestamp: time.Unix(0, int64(ts*float64(time.Second))), Value: value})
+		}
+	}
+	return samples, nil
+}
+
+// get sends an authenticated GET request to the API path and returns the body
+func (p *PrometheusProvider) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.BearerToken)
+	} else if p.Username != "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+
+	httpClient := p.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("prometheus returned %s", resp.Status)
+	}
+	return body, nil
+}
+
+// buildQuery renders the named query, preferring a configured override
+func (p *PrometheusProvider) buildQuery(name string, vars queryVars) (string, error) {
+	text, ok := p.Queries[name]
+	if !ok {
+		text = defaultQueries[name]
+	}
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid Prometheus query %q: %w", name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("failed to render Prometheus query %q: %w", name, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,278 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakePrometheus returns a server that answers instant queries by matching
+// a substring of the PromQL expression against the given responses
+func newFakePrometheus(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		for match, body := range responses {
+			if strings.Contains(query, match) {
+				fmt.Fprint(w, body)
+				return
+			}
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	}))
+}
+
+func TestPrometheusProvider_FetchContainerMetrics(t *testing.T) {
+	srv := newFakePrometheus(t, map[string]string{
+		"container_cpu_cfs_throttled_periods_total": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"12.5"]}]}}`,
+		"rate(container_cpu_usage_seconds_total": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"250"]},
+			{"metric":{"container":"sidecar"},"value":[0,"10"]}]}}`,
+		"container_memory_usage_bytes": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"268435456"]},
+			{"metric":{"container":"sidecar"},"value":[0,"33554432"]}]}}`,
+	})
+	defer srv.Close()
+
+	p := &PrometheusProvider{URL: srv.URL}
+	got, err := p.FetchContainerMetrics(context.Background(), "default", "web-0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 containers, got %d: %+v", len(got), got)
+	}
+	if got["app"].CPUMilli != 250 || got["app"].MemMB != 256 || got["app"].CPUThrottled != 12.5 {
+		t.Errorf("unexpected app metrics: %+v", got["app"])
+	}
+	if got["sidecar"].CPUMilli != 10 || got["sidecar"].MemMB != 32 {
+		t.Errorf("unexpected sidecar metrics: %+v", got["sidecar"])
+	}
+}
+
+func TestPrometheusProvider_FetchContainerOptionalReadings(t *testing.T) {
+	var historyQuery string
+	srv := newFakePrometheus(t, map[string]string{
+		"rate(container_cpu_usage_seconds_total": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"250"]}]}}`,
+		"container_memory_usage_bytes": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"1073741824"]}]}}`,
+		"container_memory_working_set_bytes": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"536870912"]}]}}`,
+		"container_memory_rss": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"268435456"]}]}}`,
+		"container_pressure_cpu_waiting_seconds_total": `{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"container":"app"},"value":[0,"35"]}]}}`,
+	})
+	defer srv.Close()
+
+	p := &PrometheusProvider{URL: srv.URL}
+	got, err := p.FetchContainerMetrics(context.Background(), "default", "web-0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	app := got["app"]
+	if app.MemMB != 1024 || app.MemWorkingSetMB != 512 || app.MemRSSMB != 256 {
+		t.Fatalf("expected usage, working set and RSS readings, got %+v", app)
+	}
+	for metric, want := range map[string]float64{"": 1024, MemoryMetricUsage: 1024, MemoryMetricWorkingSet: 512, MemoryMetricRSS: 256} {
+		if memory, ok := app.Memory(metric); !ok || memory != want {
+			t.Errorf("%q: expected %v MB, got %v (%v)", metric, want, memory, ok)
+		}
+	}
+	if app.CPUPressure != 35 || app.MemoryPressure != 0 {
+		t.Errorf("expected CPU pressure only, got %+v", app)
+	}
+	if _, ok := (Metrics{MemMB: 100}).Memory(MemoryMetricRSS); ok {
+		t.Error("expected an unreported RSS to be missing")
+	}
+
+	// The memory history follows the memory metric
+	history := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if query := r.URL.Query().Get("query"); strings.Contains(query, "memory") {
+			historyQuery = query
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"matrix","result":[]}}`)
+	}))
+	defer history.Close()
+	p = &PrometheusProvider{URL: history.URL, MemoryMetric: MemoryMetricWorkingSet}
+	if _, err := p.FetchContainerHistory(context.Background(), "default", "web-0", "app", time.Now().Add(-time.Hour), time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(historyQuery, "container_memory_working_set_bytes") {
+		t.Errorf("expected the working set history, got %s", historyQuery)
+	}
+}
+
+func TestPrometheusProvider_FetchContainerMetrics_NoData(t *testing.T) {
+	srv := newFakePrometheus(t, map[string]string{})
+	defer srv.Close()
+
+	p := &PrometheusProvider{URL: srv.URL}
+	if _, err := p.FetchContainerMetrics(context.Background(), "default", "web-0"); err == nil {
+		t.Fatal("expected error when Prometheus returns no data")
+	}
+}
+
+func TestPrometheusProvider_FetchPodIO(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+		queries = append(queries, query)
+		value := "0"
+		switch {
+		case strings.Contains(query, "container_network_receive_bytes_total"):
+			value = "120.5"
+		case strings.Contains(query, "container_fs_reads_bytes_total"):
+			value = "42"
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[0,"%s"]}]}}`, value)
+	}))
+	defer srv.Close()
+
+	p := &PrometheusProvider{URL: srv.URL}
+	got, err := p.FetchPodIO(context.Background(), "default", "web-0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.NetworkMbps != 120.5 || got.DiskIOMBps != 42 {
+		t.Errorf("unexpected I/O: %+v", got)
+	}
+	for _, query := range queries {
+		if !strings.Contains(query, `pod="web-0"`) {
+			t.Errorf("pod query not scoped to the pod: %s", query)
+		}
+	}
+
+	queries = nil
+	if _, err := p.FetchClusterIO(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, query := range queries {
+		if strings.Contains(query, "namespace=") {
+			t.Errorf("cluster query scoped to a namespace: %s", query)
+		}
+	}
+}
+
+func TestPrometheusProvider_FetchPodIO_NoData(t *testing.T) {
+	srv := newFakePrometheus(t, map[string]string{})
+	defer srv.Close()
+
+	p := &PrometheusProvider{URL: srv.URL}
+	if _, err := p.FetchPodIO(context.Background(), "default", "web-0"); err == nil {
+		t.Fatal("expected error when Prometheus returns no data")
+	}
+}
+
+func TestPrometheusProvider_FetchContainerHistory(t *testing.T) {
+	var gotAuth, gotStep string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/select/0/prometheus/api/v1/query_range" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		gotAuth = r.Header.Get("Authorization")
+		gotStep = r.URL.Query().Get("step")
+		value := "0.25"
+		if strings.Contains(r.URL.Query().Get("query"), "memory") {
+			value = "134217728"
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{},"values":[[1700000000,"%s"],[1700000060.5,"%s"]]}]}}`, value, value)
+	}))
+	defer srv.Close()
+
+	p, err := NewPrometheusProviderWithOptions(PrometheusOptions{
+		URL:         srv.URL + "/select/0/prometheus/",
+		BearerToken: "secret",
+		Step:        30 * time.Second,
+		Queries: map[string]string{
+			QueryCPUHistory: `avg_over_time(cpu{ns="{{.Namespace}}", pod="{{.Pod}}", c="{{.Container}}"}[1m])`,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	end := time.Unix(1700000120, 0)
+	history, err := p.FetchContainerHistory(context.Background(), "default", "web-0", "app", end.Add(-time.Hour), end)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("expected bearer token, got %q", gotAuth)
+	}
+	if gotStep != "30" {
+		t.Errorf("expected 30s step, got %q", gotStep)
+	}
+	if len(history.CPUMilli) != 2 || history.CPUMilli[0].Value != 0.25 {
+		t.Errorf("unexpected CPU history: %+v", history.CPUMilli)
+	}
+	if len(history.MemMB) != 2 || history.MemMB[1].Value != 128 {
+		t.Errorf("expected memory history in MB, got %+v", history.MemMB)
+	}
+	if history.MemMB[1].Timestamp.UnixMilli() != 1700000060500 {
+		t.Errorf("unexpected timestamp %v", history.MemMB[1].Timestamp)
+	}
+}
+
+func TestPrometheusProvider_BasicAuthAndCustomQuery(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "reader" || pass != "pw" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if q := r.URL.Query().Get("query"); strings.Contains(q, "custom_cpu") {
+			gotQuery = q
+		}
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"container":"app"},"value":[0,"1"]}]}}`)
+	}))
+	defer srv.Close()
+
+	p, err := NewPrometheusProviderWithOptions(PrometheusOptions{
+		URL:      srv.URL,
+		Username: "reader",
+		Password: "pw",
+		Queries:  map[string]string{QueryContainerCPU: `sum by (container) (custom_cpu{namespace="{{.Namespace}}", pod="{{.Pod}}"})`},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := p.FetchContainerMetrics(context.Background(), "default", "web-0"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotQuery != `sum by (container) (custom_cpu{namespace="default", pod="web-0"})` {
+		t.Errorf("expected rendered custom query, got %q", gotQuery)
+	}
+
+	p.Password = "wrong"
+	if _, err := p.FetchContainerMetrics(context.Background(), "default", "web-0"); err == nil {
+		t.Fatal("expected error on unauthorized response")
+	}
+}
+
+func TestNewPrometheusProviderWithOptions_InvalidQuery(t *testing.T) {
+	if _, err := NewPrometheusProviderWithOptions(PrometheusOptions{URL: "http://x", Queries: map[string]string{"unknown": "up"}}); err == nil {
+		t.Error("expected error for unknown query name")
+	}
+	if _, err := NewPrometheusProviderWithOptions(PrometheusOptions{URL: "http://x", Queries: map[string]string{QueryCPU: "{{.Namespace"}}); err == nil {
+		t.Error("expected error for invalid template")
+	}
+	if _, err := NewPrometheusProviderWithOptions(PrometheusOptions{URL: "http://x", CAFile: "/nonexistent/ca.pem"}); err == nil {
+		t.Error("expected error for missing CA file")
+	}
+}
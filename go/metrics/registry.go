@@ -0,0 +1,66 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is the dedicated registry of the operator's own metrics. Keeping
+// them out of the global default registry means nothing else in the process
+// (controller-runtime, client-go, promauto) can collide with them.
+var Registry = prometheus.NewRegistry()
+
+// registerCollector registers a collector with reg. When an identical
+// collector is already registered, e.g. because the metrics were created
+// again, the registered one is adopted so that what is recorded is still
+// exported. Any other conflict is returned.
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, collector *T) error {
+	err := reg.Register(*collector)
+	var already prometheus.AlreadyRegisteredError
+	if errors.As(err, &already) {
+		if existing, ok := already.ExistingCollector.(T); ok {
+			*collector = existing
+			return nil
+		}
+	}
+	return err
+}
+
+// Handler serves the operator's metrics together with those of the default
+// registry, in the OpenMetrics format when the scraper accepts it so that
+// exemplars are exposed
+func Handler() http.Handler {
+	return promhttp.HandlerFor(
+		prometheus.Gatherers{Registry, prometheus.DefaultGatherer},
+		promhttp.HandlerOpts{EnableOpenMetrics: true},
+	)
+}
+
+// podExemplar returns the exemplar labels identifying a pod, so a sample can
+// be traced back to it without a per-pod label on the metric
+func podExemplar(namespace, podName string) prometheus.Labels {
+	pod := namespace + "/" + podName
+	// The labels of an exemplar are limited to 128 runes in total
+	if len([]rune(pod)) > 125 {
+		pod = string([]rune(pod)[:125])
+	}
+	return prometheus.Labels{"pod": pod}
+}
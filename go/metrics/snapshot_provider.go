@@ -0,0 +1,60 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+)
+
+// SnapshotProvider serves the usage of every pod from a single batched
+// fetch, so a sizing cycle costs one metrics call rather than one per pod
+type SnapshotProvider struct {
+	pods map[string]ContainerMetrics
+}
+
+// NewSnapshot takes a snapshot of every pod's usage from a batch provider
+func NewSnapshot(ctx context.Context, provider BatchProvider) (*SnapshotProvider, error) {
+	pods, err := provider.FetchAllContainerMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SnapshotProvider{pods: pods}, nil
+}
+
+// FetchPodMetrics returns the pod's usage summed across its containers
+func (s *SnapshotProvider) FetchPodMetrics(ctx context.Context, namespace, podName string) (Metrics, error) {
+	containers, err := s.FetchContainerMetrics(ctx, namespace, podName)
+	if err != nil {
+		return Metrics{}, err
+	}
+	var total Metrics
+	for _, usage := range containers {
+		total.CPUMilli += usage.CPUMilli
+		total.MemMB += usage.MemMB
+	}
+	return total, nil
+}
+
+// FetchContainerMetrics returns the pod's usage per container. Pods missing
+// from the snapshot have no metrics yet.
+func (s *SnapshotProvider) FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error) {
+	containers, ok := s.pods[namespace+"/"+podName]
+	if !ok {
+		return nil, fmt.Errorf("no metrics for pod %s/%s", namespace, podName)
+	}
+	return containers, nil
+}
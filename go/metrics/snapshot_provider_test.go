@@ -0,0 +1,63 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type batchFunc func(ctx context.Context) (map[string]ContainerMetrics, error)
+
+func (f batchFunc) FetchAllContainerMetrics(ctx context.Context) (map[string]ContainerMetrics, error) {
+	return f(ctx)
+}
+
+func TestSnapshotProvider(t *testing.T) {
+	calls := 0
+	snapshot, err := NewSnapshot(context.Background(), batchFunc(func(context.Context) (map[string]ContainerMetrics, error) {
+		calls++
+		return map[string]ContainerMetrics{
+			"default/web": {"app": {CPUMilli: 100, MemMB: 64}, "proxy": {CPUMilli: 20, MemMB: 32}},
+		}, nil
+	}))
+	if err != nil {
+		t.Fatalf("NewSnapshot() error: %v", err)
+	}
+
+	for range 3 {
+		pod, err := snapshot.FetchPodMetrics(context.Background(), "default", "web")
+		if err != nil {
+			t.Fatalf("FetchPodMetrics() error: %v", err)
+		}
+		if pod.CPUMilli != 120 || pod.MemMB != 96 {
+			t.Errorf("expected the containers summed to 120m/96MB, got %+v", pod)
+		}
+	}
+	containers, err := snapshot.FetchContainerMetrics(context.Background(), "default", "web")
+	if err != nil || containers["proxy"].CPUMilli != 20 {
+		t.Errorf("expected the proxy's own usage, got %+v, %v", containers, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected a single batched fetch, got %d", calls)
+	}
+
+	if _, err := snapshot.FetchPodMetrics(context.Background(), "default", "missing"); err == nil {
+		t.Error("expected an error for a pod missing from the snapshot")
+	}
+}
+
+func TestSnapshotProvider_Error(t *testing.T) {
+	_, err := NewSnapshot(context.Background(), batchFunc(func(context.Context) (map[string]ContainerMetrics, error) {
+		return nil, errors.New("metrics-server unavailable")
+	}))
+	if err == nil {
+		t.Fatal("expected the batch error")
+	}
+}
@@ -16,6 +16,8 @@ package metrics
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,13 +26,79 @@ import (
 // Metrics holds CPU and memory usage values
 type Metrics struct {
 	CPUMilli     float64 // CPU usage in millicores
-	MemMB        float64 // Memory usage in MB
-	CPUThrottled float64 // CPU throttling percentage (0-100)
+	MemMB        float64 // Memory usage in MB, as the provider aggregates it
+	CPUThrottled float64 // Percentage of CFS periods throttled (0-100)
+
+	// Pressure stall information (PSI) of cgroup v2 nodes: the percentage of
+	// time (0-100) some of the container's tasks were stalled waiting for CPU
+	// or memory; zero when the provider does not report it
+	CPUPressure    float64
+	MemoryPressure float64
+
+	// Memory readings a memory metric selects instead of MemMB; zero when the
+	// provider does not report them
+	MemWorkingSetMB float64 // Working set: usage minus inactive page cache
+	MemRSSMB        float64 // Resident set size, without any page cache
+}
+
+// Memory metrics a container's memory can be sized from
+const (
+	MemoryMetricUsage      = "usage"      // The provider's memory usage, page cache included for Prometheus
+	MemoryMetricWorkingSet = "workingSet" // The working set, what the kubelet evicts and OOM kills on
+	MemoryMetricRSS        = "rss"        // The resident set size, for cache-heavy workloads
+)
+
+// ValidMemoryMetric reports whether metric is a known memory metric
+func ValidMemoryMetric(metric string) bool {
+	switch metric {
+	case MemoryMetricUsage, MemoryMetricWorkingSet, MemoryMetricRSS:
+		return true
+	}
+	return false
+}
+
+// Memory returns the memory reading in MB the metric selects, and whether
+// the provider reported it. The usage, and an empty metric, select MemMB.
+func (m Metrics) Memory(metric string) (float64, bool) {
+	switch metric {
+	case MemoryMetricWorkingSet:
+		return m.MemWorkingSetMB, m.MemWorkingSetMB > 0
+	case MemoryMetricRSS:
+		return m.MemRSSMB, m.MemRSSMB > 0
+	}
+	return m.MemMB, true
 }
 
+// ContainerMetrics maps container names to their individual usage
+type ContainerMetrics map[string]Metrics
+
 // Provider interface for metrics sources
 type Provider interface {
 	FetchPodMetrics(ctx context.Context, namespace, podName string) (Metrics, error)
+	// FetchContainerMetrics returns usage for each container in the pod, keyed by container name
+	FetchContainerMetrics(ctx context.Context, namespace, podName string) (ContainerMetrics, error)
+}
+
+// BatchProvider is implemented by providers that can return the usage of
+// every pod with a single call, rather than one call per pod
+type BatchProvider interface {
+	// FetchAllContainerMetrics returns the per-container usage of every pod,
+	// keyed by namespace/name
+	FetchAllContainerMetrics(ctx context.Context) (map[string]ContainerMetrics, error)
+}
+
+// Prober is implemented by providers that can check whether their backend
+// is reachable without fetching a particular pod
+type Prober interface {
+	// Probe returns an error when the backend cannot serve metrics
+	Probe(ctx context.Context) error
+}
+
+// CustomMetricsSource is implemented by providers of per-pod application
+// metrics such as queue depth, requests per second or latency
+type CustomMetricsSource interface {
+	// FetchPodCustomMetric returns the current value of the named metric for a pod
+	FetchPodCustomMetric(ctx context.Context, namespace, podName, metric string) (float64, error)
 }
 
 // MetricsServerProvider fetches metrics from metrics-server
@@ -39,7 +107,60 @@ type MetricsServerProvider struct {
 	MetricsClient *metricsclient.Clientset
 }
 
-// PrometheusProvider implements Provider for Prometheus
+// RangeProvider is implemented by providers that can return usage history
+type RangeProvider interface {
+	// FetchContainerHistory returns a container's usage between start and end
+	FetchContainerHistory(ctx context.Context, namespace, podName, container string, start, end time.Time) (ContainerHistory, error)
+}
+
+// IOSource is implemented by providers that can report network and disk
+// throughput
+type IOSource interface {
+	// FetchPodIO returns a pod's current network and disk throughput
+	FetchPodIO(ctx context.Context, namespace, podName string) (IOUsage, error)
+	// FetchClusterIO returns the throughput of all pods together
+	FetchClusterIO(ctx context.Context) (IOUsage, error)
+}
+
+// IOUsage is network and disk throughput
+type IOUsage struct {
+	NetworkMbps float64 // Received plus transmitted, in megabits per second
+	DiskIOMBps  float64 // Read plus written, in megabytes per second
+}
+
+// Sample is a usage value at a point in time
+type Sample struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// ContainerHistory holds a container's usage over a time range
+type ContainerHistory struct {
+	CPUMilli []Sample // CPU usage in millicores
+	MemMB    []Sample // Memory usage in MB
+}
+
+// PrometheusProvider implements Provider for Prometheus and API-compatible
+// backends such as Thanos Query and VictoriaMetrics
 type PrometheusProvider struct {
 	URL string
+
+	// Optional authentication; a bearer token takes precedence over basic auth
+	Username    string
+	Password    string
+	BearerToken string
+
+	// Queries overrides the default PromQL by name (see the Query* constants)
+	// with text/template expressions over .Namespace, .Pod and .Container
+	Queries map[string]string
+
+	// Step is the resolution of range queries; one minute when zero
+	Step time.Duration
+
+	// MemoryMetric selects the memory history: usage, workingSet or rss;
+	// usage when empty
+	MemoryMetric string
+
+	// HTTPClient sends the requests; http.DefaultClient when nil
+	HTTPClient *http.Client
 }
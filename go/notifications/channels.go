@@ -0,0 +1,469 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/events"
+)
+
+const (
+	// defaultRetries is how often Slack, Teams and PagerDuty requests are retried
+	defaultRetries = 3
+	// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+	pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+)
+
+// retryBackoff is the wait before the first retry; it doubles with every retry
+var retryBackoff = time.Second
+
+// sendJSON sends a JSON payload, retrying on network errors, 429 and 5xx
+// responses; GET requests carry the fields of the query instead of a body
+func sendJSON(ctx context.Context, httpClient *http.Client, method, target string, headers map[string]string, payload interface{}, query url.Values, retries int) error {
+	var body []byte
+	if method != http.MethodGet {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+	}
+	if len(query) > 0 {
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("invalid URL: %w", err)
+		}
+		values := parsed.Query()
+		for key, value := range query {
+			values[key] = value
+		}
+		parsed.RawQuery = values.Encode()
+		target = parsed.String()
+	}
+
+	backoff := retryBackoff
+	var lastErr error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return lastErr
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", retries+1, lastErr)
+}
+
+// severityColor returns the accent color of a severity as a hex triplet
+func severityColor(severity events.Severity) string {
+	switch severity {
+	case events.SeverityError, events.SeverityCritical:
+		return "A30200"
+	case events.SeverityWarning:
+		return "DAA038"
+	default:
+		return "2EB886"
+	}
+}
+
+// facts returns the labelled details of a notification shown by chat channels
+func facts(n *Notification) [][2]string {
+	var result [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			result = append(result, [2]string{name, value})
+		}
+	}
+	add("Cluster", n.Cluster)
+	add("Namespace", n.Namespace)
+	add("Pod", n.Pod)
+	add("Container", n.Container)
+	add("Severity", string(n.Severity))
+	return result
+}
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+	channel    string
+	username   string
+	iconEmoji  string
+	httpClient *http.Client
+}
+
+// NewSlackNotifier creates a Slack notifier; the channel, username and icon
+// override the webhook's defaults when set
+func NewSlackNotifier(webhookURL, channel, username, iconEmoji string) *SlackNotifier {
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		channel:    channel,
+		username:   username,
+		iconEmoji:  iconEmoji,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the channel
+func (s *SlackNotifier) Name() string { return "slack" }
+
+// Send posts the notification as a message with a colored attachment
+func (s *SlackNotifier) Send(ctx context.Context, n *Notification) error {
+	type field struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+		Short bool   `json:"short"`
+	}
+	var fields []field
+	for _, fact := range facts(n) {
+		fields = append(fields, field{Title: fact[0], Value: fact[1], Short: true})
+	}
+	payload := map[string]interface{}{
+		"text": n.Title,
+		"attachments": []map[string]interface{}{{
+			"color":    "#" + severityColor(n.Severity),
+			"fallback": n.Title,
+			"text":     n.Text,
+			"fields":   fields,
+			"ts":       n.Timestamp.Unix(),
+		}},
+	}
+	if s.channel != "" {
+		payload["channel"] = s.channel
+	}
+	if s.username != "" {
+		payload["username"] = s.username
+	}
+	if s.iconEmoji != "" {
+		payload["icon_emoji"] = s.iconEmoji
+	}
+	return sendJSON(ctx, s.httpClient, http.MethodPost, s.webhookURL, nil, payload, nil, defaultRetries)
+}
+
+// TeamsNotifier posts notifications to a Microsoft Teams incoming webhook
+type TeamsNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// NewTeamsNotifier creates a Microsoft Teams notifier
+func NewTeamsNotifier(webhookURL string) *TeamsNotifier {
+	return &TeamsNotifier{
+		webhookURL: webhookURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the channel
+func (t *TeamsNotifier) Name() string { return "teams" }
+
+// Send posts the notification as a message card
+func (t *TeamsNotifier) Send(ctx context.Context, n *Notification) error {
+	type fact struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	var cardFacts []fact
+	for _, f := range facts(n) {
+		cardFacts = append(cardFacts, fact{Name: f[0], Value: f[1]})
+	}
+	payload := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"themeColor": severityColor(n.Severity),
+		"summary":    n.Title,
+		"title":      n.Title,
+		"text":       n.Text,
+		"sections":   []map[string]interface{}{{"facts": cardFacts}},
+	}
+	return sendJSON(ctx, t.httpClient, http.MethodPost, t.webhookURL, nil, payload, nil, defaultRetries)
+}
+
+// PagerDutyNotifier triggers PagerDuty incidents through the Events API v2
+type PagerDutyNotifier struct {
+	routingKey string
+	eventsURL  string
+	httpClient *http.Client
+}
+
+// NewPagerDutyNotifier creates a PagerDuty notifier for the integration's routing key
+func NewPagerDutyNotifier(routingKey string) *PagerDutyNotifier {
+	return &PagerDutyNotifier{
+		routingKey: routingKey,
+		eventsURL:  pagerDutyEventsURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the channel
+func (p *PagerDutyNotifier) Name() string { return "pagerduty" }
+
+// Send triggers an event; repeated notifications of the same type for a pod
+// share a dedup key, so they are grouped into one incident
+func (p *PagerDutyNotifier) Send(ctx context.Context, n *Notification) error {
+	severity := string(n.Severity)
+	if _, ok := severityRank[severity]; !ok || severity == "debug" {
+		severity = string(events.SeverityInfo)
+	}
+	source := n.Cluster
+	if source == "" {
+		source = "right-sizer"
+	}
+	summary := n.Title + ": " + n.Text
+	if len(summary) > 1024 {
+		summary = summary[:1021] + "..."
+	}
+
+	payload := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    strings.Join([]string{source, n.Namespace, n.Pod, string(n.Type)}, "/"),
+		"payload": map[string]interface{}{
+			"summary":   summary,
+			"source":    source,
+			"severity":  severity,
+			"timestamp": n.Timestamp.UTC().Format(time.RFC3339),
+			"component": n.Namespace + "/" + n.Pod,
+			"group":     n.Namespace,
+			"class":     string(n.Type),
+			"custom_details": map[string]string{
+				"container":    n.Container,
+				"oldResources": n.OldResources,
+				"newResources": n.NewResources,
+				"reason":       n.Reason,
+				"error":        n.Error,
+			},
+		},
+	}
+	return sendJSON(ctx, p.httpClient, http.MethodPost, p.eventsURL, nil, payload, nil, defaultRetries)
+}
+
+// EmailNotifier sends notifications as plain text email over SMTP, with
+// their attachments in a multipart message
+type EmailNotifier struct {
+	host     string
+	port     int
+	useTLS   bool
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+// NewEmailNotifier creates an email notifier; with useTLS the connection is
+// encrypted with implicit TLS on port 465 and STARTTLS on other ports
+func NewEmailNotifier(host string, port int, useTLS bool, username, password, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{
+		host:     host,
+		port:     port,
+		useTLS:   useTLS,
+		username: username,
+		password: password,
+		from:     from,
+		to:       to,
+	}
+}
+
+// Name identifies the channel
+func (e *EmailNotifier) Name() string { return "email" }
+
+// Send mails the notification to all recipients
+func (e *EmailNotifier) Send(ctx context.Context, n *Notification) error {
+	addr := net.JoinHostPort(e.host, strconv.Itoa(e.port))
+	tlsConfig := &tls.Config{ServerName: e.host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if e.useTLS && e.port == 465 {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer c.Close()
+
+	if e.useTLS && e.port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if e.password != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.username, e.password, e.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(e.from); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, recipient := range e.to {
+		if err := c.Rcpt(recipient); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", recipient, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(e.message(n)); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return c.Quit()
+}
+
+// message renders the notification as an RFC 5322 message
+func (e *EmailNotifier) message(n *Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[right-sizer] "+n.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Timestamp.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	var body strings.Builder
+	body.WriteString(n.Text)
+	body.WriteString("\r\n\r\n")
+	for _, fact := range facts(n) {
+		fmt.Fprintf(&body, "%s: %s\r\n", fact[0], fact[1])
+	}
+	if len(n.Attachments) == 0 {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(body.String())
+		return []byte(b.String())
+	}
+
+	var parts bytes.Buffer
+	w := multipart.NewWriter(&parts)
+	fmt.Fprintf(&b, "Content-Type: multipart/mixed; boundary=%q\r\n", w.Boundary())
+	b.WriteString("\r\n")
+	text, _ := w.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+	_, _ = text.Write([]byte(body.String()))
+	for _, attachment := range n.Attachments {
+		part, _ := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			_, _ = part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		_, _ = part.Write([]byte(encoded + "\r\n"))
+	}
+	_ = w.Close()
+	b.Write(parts.Bytes())
+	return []byte(b.String())
+}
+
+// WebhookNotifier sends notifications as JSON to a generic webhook
+type WebhookNotifier struct {
+	webhook    config.NotificationWebhook
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a generic webhook notifier
+func NewWebhookNotifier(webhook config.NotificationWebhook) *WebhookNotifier {
+	return &WebhookNotifier{
+		webhook:    webhook,
+		httpClient: &http.Client{Timeout: webhook.Timeout},
+	}
+}
+
+// Name identifies the channel
+func (w *WebhookNotifier) Name() string { return "webhook " + w.webhook.Name }
+
+// Send delivers the notification, retrying failed requests RetryCount times
+func (w *WebhookNotifier) Send(ctx context.Context, n *Notification) error {
+	method := strings.ToUpper(w.webhook.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	var query url.Values
+	if method == http.MethodGet {
+		query = url.Values{
+			"type":      {string(n.Type)},
+			"severity":  {string(n.Severity)},
+			"namespace": {n.Namespace},
+			"pod":       {n.Pod},
+			"title":     {n.Title},
+			"text":      {n.Text},
+		}
+	}
+	return sendJSON(ctx, w.httpClient, method, w.webhook.URL, w.webhook.Headers, n, query, w.webhook.RetryCount)
+}
@@ -0,0 +1,460 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package notifications tells people about resizes, failed resizes, OOM
+// kills, rollbacks, usage anomalies and idle workloads through Slack,
+// Microsoft Teams, PagerDuty, email and generic webhooks.
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/events"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// queueSize is how many notifications wait for delivery before new ones are dropped
+	queueSize = 100
+	// deliveryTimeout bounds sending a notification to a single channel, retries included
+	deliveryTimeout = 2 * time.Minute
+	// buildRetryInterval is how long channels that could not be set up wait before another attempt
+	buildRetryInterval = time.Minute
+)
+
+// Type identifies what a notification is about
+type Type string
+
+const (
+	ResizeApplied    Type = "resize_applied"
+	ResizeFailed     Type = "resize_failed"
+	OOMDetected      Type = "oom_detected"
+	ResizeRolledBack Type = "resize_rolled_back"
+	AnomalyDetected  Type = "anomaly_detected"
+	WorkloadIdle     Type = "workload_idle"
+	ReportGenerated  Type = "report_generated"
+)
+
+// Notification is a message about a pod, rendered from the template of its type
+type Notification struct {
+	Type         Type            `json:"type"`
+	Severity     events.Severity `json:"severity"`
+	Cluster      string          `json:"cluster,omitempty"`
+	Namespace    string          `json:"namespace"`
+	Pod          string          `json:"pod"`
+	Container    string          `json:"container,omitempty"`
+	OldResources string          `json:"oldResources,omitempty"`
+	NewResources string          `json:"newResources,omitempty"`
+	Reason       string          `json:"reason,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	Message      string          `json:"message,omitempty"`
+	Report       string          `json:"report,omitempty"` // name of a scheduled report
+	Timestamp    time.Time       `json:"timestamp"`
+	Title        string          `json:"title"`
+	Text         string          `json:"text"`
+
+	// Attachments are sent by email only; chat and webhook channels get the text
+	Attachments []Attachment `json:"-"`
+}
+
+// Attachment is a file sent along with a notification
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+type messageTemplate struct {
+	title *template.Template
+	text  *template.Template
+}
+
+func newMessageTemplate(name, title, text string) messageTemplate {
+	return messageTemplate{
+		title: template.Must(template.New(name + "-title").Parse(title)),
+		text:  template.Must(template.New(name + "-text").Parse(text)),
+	}
+}
+
+var messageTemplates = map[Type]messageTemplate{
+	ResizeApplied: newMessageTemplate(string(ResizeApplied),
+		`Resized {{.Namespace}}/{{.Pod}}`,
+		`Container {{.Container}} was resized from {{.OldResources}} to {{.NewResources}}.{{if .Reason}} Reason: {{.Reason}}{{end}}`),
+	ResizeFailed: newMessageTemplate(string(ResizeFailed),
+		`Resize failed for {{.Namespace}}/{{.Pod}}`,
+		`Container {{.Container}} could not be resized: {{.Error}}{{if .Reason}} The resize was requested because: {{.Reason}}{{end}}`),
+	OOMDetected: newMessageTemplate(string(OOMDetected),
+		`OOM kill in {{.Namespace}}/{{.Pod}}`,
+		`{{if .Container}}Container {{.Container}}{{else}}A container{{end}} was killed for running out of memory.`),
+	ResizeRolledBack: newMessageTemplate(string(ResizeRolledBack),
+		`Resize rolled back for {{.Namespace}}/{{.Pod}}`,
+		`{{.Message}}`),
+	AnomalyDetected: newMessageTemplate(string(AnomalyDetected),
+		`Usage anomaly in {{.Namespace}}/{{.Pod}}`,
+		`{{.Message}}`),
+	WorkloadIdle: newMessageTemplate(string(WorkloadIdle),
+		`Idle workload {{.Namespace}}/{{.Pod}}`,
+		`{{.Message}}`),
+	ReportGenerated: newMessageTemplate(string(ReportGenerated),
+		`Right-sizing {{.Report}}`,
+		`{{.Message}}`),
+}
+
+// render fills in the title and text from the template of the notification's type
+func (n *Notification) render() error {
+	tmpl, ok := messageTemplates[n.Type]
+	if !ok {
+		return fmt.Errorf("no template for notification type %s", n.Type)
+	}
+	var title, text bytes.Buffer
+	if err := tmpl.title.Execute(&title, n); err != nil {
+		return fmt.Errorf("failed to render %s title: %w", n.Type, err)
+	}
+	if err := tmpl.text.Execute(&text, n); err != nil {
+		return fmt.Errorf("failed to render %s text: %w", n.Type, err)
+	}
+	n.Title = title.String()
+	n.Text = text.String()
+	return nil
+}
+
+// Notifier sends notifications to one channel
+type Notifier interface {
+	// Name identifies the channel in logs
+	Name() string
+	// Send delivers a rendered notification
+	Send(ctx context.Context, n *Notification) error
+}
+
+// severityRank orders severities and notification levels
+var severityRank = map[string]int{
+	"debug":                         0,
+	string(events.SeverityInfo):     1,
+	string(events.SeverityWarning):  2,
+	string(events.SeverityError):    3,
+	string(events.SeverityCritical): 4,
+}
+
+// meetsLevel reports whether a severity is at or above the minimum level
+func meetsLevel(severity events.Severity, level string) bool {
+	minimum, ok := severityRank[level]
+	if !ok {
+		minimum = severityRank[string(events.SeverityWarning)]
+	}
+	return severityRank[string(severity)] >= minimum
+}
+
+// Dispatcher turns resize, OOM and rollback events into notifications and
+// sends them to the channels in the notification configuration
+type Dispatcher struct {
+	client       client.Client
+	eventBus     *events.EventBus
+	config       *config.Config
+	subscriberID string
+	queue        chan *Notification
+	stop         chan struct{}
+	wg           sync.WaitGroup
+
+	// Only used by the delivery goroutine
+	notifiers     []Notifier
+	settings      config.NotificationConfig
+	settingsReady bool
+	buildFailed   bool
+	buildRetryAt  time.Time
+}
+
+// NewDispatcher creates a dispatcher; the client reads credential secrets
+func NewDispatcher(c client.Client, eventBus *events.EventBus) *Dispatcher {
+	return &Dispatcher{
+		client:   c,
+		eventBus: eventBus,
+		queue:    make(chan *Notification, queueSize),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start subscribes to the event bus and starts delivering notifications
+func (d *Dispatcher) Start(ctx context.Context) error {
+	if d.eventBus != nil {
+		d.subscriberID = "notifications"
+		d.eventBus.Subscribe(d.subscriberID, d.handleEvent)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.stop:
+				return
+			case n := <-d.queue:
+				d.deliver(ctx, n)
+			}
+		}
+	}()
+
+	logger.Info("📣 Notification dispatcher started")
+	return nil
+}
+
+// Stop unsubscribes from the event bus and waits for the delivery in progress
+func (d *Dispatcher) Stop() {
+	if d.subscriberID != "" {
+		d.eventBus.Unsubscribe(d.subscriberID)
+	}
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// Notify queues a notification if notifications are enabled and its severity
+// meets the configured level; it never blocks
+func (d *Dispatcher) Notify(n *Notification) {
+	settings := d.currentSettings()
+	if !settings.EnableNotifications || !meetsLevel(n.Severity, settings.Level) {
+		return
+	}
+	d.enqueue(n)
+}
+
+// NotifyReport queues a scheduled report if notifications are enabled,
+// whatever the configured level; it never blocks
+func (d *Dispatcher) NotifyReport(n *Notification) {
+	if !d.currentSettings().EnableNotifications {
+		return
+	}
+	d.enqueue(n)
+}
+
+// enqueue renders a notification and queues it for delivery
+func (d *Dispatcher) enqueue(n *Notification) {
+	if n.Timestamp.IsZero() {
+		n.Timestamp = time.Now()
+	}
+	if err := n.render(); err != nil {
+		logger.Warn("Failed to render notification: %v", err)
+		return
+	}
+
+	select {
+	case d.queue <- n:
+	default:
+		logger.Warn("Notification queue is full, dropping %s notification for %s/%s", n.Type, n.Namespace, n.Pod)
+	}
+}
+
+// handleEvent maps the events notifications are sent for
+func (d *Dispatcher) handleEvent(event *events.Event) {
+	if n := notificationFor(event); n != nil {
+		d.Notify(n)
+	}
+}
+
+// notificationFor builds the notification of an event, or nil if the event
+// is not notified
+func notificationFor(event *events.Event) *Notification {
+	n := &Notification{
+		Severity:  event.Severity,
+		Cluster:   event.ClusterID,
+		Namespace: event.Namespace,
+		Pod:       event.Resource,
+		Message:   event.Message,
+		Timestamp: event.Timestamp,
+		Container: detailString(event.Details, "containerName"),
+		Reason:    detailString(event.Details, "reason"),
+		Error:     detailString(event.Details, "error"),
+	}
+	switch event.Type {
+	case events.EventResizeApplied:
+		n.Type = ResizeApplied
+		n.OldResources = formatResources(event.Details["oldResources"])
+		n.NewResources = formatResources(event.Details["newResources"])
+	case events.EventResizeFailed:
+		n.Type = ResizeFailed
+	case events.EventResizeRolledBack:
+		n.Type = ResizeRolledBack
+	case events.EventResourceAnomaly:
+		n.Type = AnomalyDetected
+	case events.EventWorkloadIdle:
+		n.Type = WorkloadIdle
+	case events.EventPodOOMKilled:
+		n.Type = OOMDetected
+		if rca, ok := event.Details["RCA"].(map[string]interface{}); ok {
+			n.Container = detailString(rca, "container")
+		}
+	default:
+		return nil
+	}
+	return n
+}
+
+func detailString(details map[string]interface{}, key string) string {
+	if value, ok := details[key]; ok && value != nil {
+		return fmt.Sprint(value)
+	}
+	return ""
+}
+
+// formatResources renders container resources as "cpu 100m/200m, memory 128Mi/256Mi"
+// with requests before limits
+func formatResources(value interface{}) string {
+	resources, ok := value.(corev1.ResourceRequirements)
+	if !ok {
+		if value == nil {
+			return ""
+		}
+		return fmt.Sprint(value)
+	}
+	names := map[corev1.ResourceName]bool{}
+	for name := range resources.Requests {
+		names[name] = true
+	}
+	for name := range resources.Limits {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, string(name))
+	}
+	sort.Strings(sorted)
+
+	parts := make([]string, 0, len(sorted))
+	for _, name := range sorted {
+		request, limit := "-", "-"
+		if q, ok := resources.Requests[corev1.ResourceName(name)]; ok {
+			request = q.String()
+		}
+		if q, ok := resources.Limits[corev1.ResourceName(name)]; ok {
+			limit = q.String()
+		}
+		parts = append(parts, fmt.Sprintf("%s %s/%s", name, request, limit))
+	}
+	if len(parts) == 0 {
+		return "no requests or limits"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// deliver sends a notification to every channel, setting the channels up
+// again when their configuration changed
+func (d *Dispatcher) deliver(ctx context.Context, n *Notification) {
+	settings := d.currentSettings()
+	if !d.settingsReady || !reflect.DeepEqual(settings, d.settings) || (d.buildFailed && time.Now().After(d.buildRetryAt)) {
+		d.notifiers, d.buildFailed = d.buildNotifiers(ctx, settings)
+		d.settings = settings
+		d.settingsReady = true
+		d.buildRetryAt = time.Now().Add(buildRetryInterval)
+	}
+
+	for _, notifier := range d.notifiers {
+		sendCtx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+		if err := notifier.Send(sendCtx, n); err != nil {
+			logger.Warn("Failed to send %s notification for %s/%s to %s: %v", n.Type, n.Namespace, n.Pod, notifier.Name(), err)
+		}
+		cancel()
+	}
+}
+
+// currentSettings returns the current notification configuration
+func (d *Dispatcher) currentSettings() config.NotificationConfig {
+	cfg := d.config
+	if cfg == nil {
+		cfg = config.Get()
+	}
+	if cfg.NotificationConfig == nil {
+		return config.NotificationConfig{}
+	}
+	return *cfg.NotificationConfig
+}
+
+// buildNotifiers creates the channels enabled in the configuration; it
+// reports whether any of them could not be created
+func (d *Dispatcher) buildNotifiers(ctx context.Context, settings config.NotificationConfig) ([]Notifier, bool) {
+	var notifiers []Notifier
+	failed := false
+
+	if settings.SlackWebhookURL != "" {
+		notifiers = append(notifiers, NewSlackNotifier(settings.SlackWebhookURL, settings.SlackChannel, settings.SlackUsername, settings.SlackIconEmoji))
+	}
+	if settings.TeamsWebhookURL != "" {
+		notifiers = append(notifiers, NewTeamsNotifier(settings.TeamsWebhookURL))
+	}
+	if settings.PagerDutyRoutingKeySecret != "" {
+		routingKey, err := d.secretValue(ctx, settings.PagerDutyRoutingKeySecret, settings.PagerDutyRoutingKeySecretKey)
+		if err != nil {
+			logger.Warn("Failed to set up PagerDuty notifications: %v", err)
+			failed = true
+		} else {
+			notifiers = append(notifiers, NewPagerDutyNotifier(routingKey))
+		}
+	}
+	if settings.SMTPHost != "" && len(settings.EmailRecipients) > 0 {
+		password := settings.SMTPPassword
+		var err error
+		if settings.SMTPPasswordSecret != "" {
+			password, err = d.secretValue(ctx, settings.SMTPPasswordSecret, settings.SMTPPasswordSecretKey)
+		}
+		if err != nil {
+			logger.Warn("Failed to set up email notifications: %v", err)
+			failed = true
+		} else {
+			from := settings.EmailFrom
+			if from == "" {
+				from = settings.SMTPUsername
+			}
+			notifiers = append(notifiers, NewEmailNotifier(settings.SMTPHost, settings.SMTPPort, settings.SMTPUseTLS,
+				settings.SMTPUsername, password, from, settings.EmailRecipients))
+		}
+	}
+	for _, webhook := range settings.Webhooks {
+		notifiers = append(notifiers, NewWebhookNotifier(webhook))
+	}
+	return notifiers, failed
+}
+
+// secretValue reads a key of a secret in the operator's namespace
+func (d *Dispatcher) secretValue(ctx context.Context, name, key string) (string, error) {
+	if d.client == nil {
+		return "", fmt.Errorf("cannot read secret %s without a Kubernetes client", name)
+	}
+	namespace := os.Getenv("OPERATOR_NAMESPACE")
+	if namespace == "" {
+		namespace = "right-sizer"
+	}
+	var secret corev1.Secret
+	if err := d.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		return "", fmt.Errorf("failed to get secret %s/%s: %w", namespace, name, err)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %s", namespace, name, key)
+	}
+	return strings.TrimSpace(string(value)), nil
+}
@@ -0,0 +1,284 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/events"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func init() {
+	retryBackoff = time.Millisecond
+}
+
+func resources(cpuRequest, memoryLimit string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpuRequest)},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memoryLimit)},
+	}
+}
+
+// TestNotificationFor verifies events are mapped to rendered notifications
+func TestNotificationFor(t *testing.T) {
+	applied := events.NewEvent(events.EventResizeApplied, "prod-eu", "shop", "web-1", events.SeverityInfo, "resized").
+		WithDetails(map[string]interface{}{
+			"containerName": "app",
+			"oldResources":  resources("100m", "256Mi"),
+			"newResources":  resources("250m", "512Mi"),
+			"reason":        "CPU usage above target",
+		})
+	oom := events.NewEvent(events.EventPodOOMKilled, "prod-eu", "shop", "web-2", events.SeverityError, "Pod was OOMKilled").
+		WithDetails(map[string]interface{}{"RCA": map[string]interface{}{"container": "worker"}})
+	idle := events.NewEvent(events.EventWorkloadIdle, "prod-eu", "shop", "Deployment/legacy", events.SeverityWarning, "Deployment/legacy has used at most 5m CPU for 72h0m0s.")
+
+	tests := []struct {
+		event     *events.Event
+		wantType  Type
+		wantTitle string
+		wantText  string
+	}{
+		{applied, ResizeApplied, "Resized shop/web-1",
+			"Container app was resized from cpu 100m/-, memory -/256Mi to cpu 250m/-, memory -/512Mi. Reason: CPU usage above target"},
+		{oom, OOMDetected, "OOM kill in shop/web-2", "Container worker was killed for running out of memory."},
+		{idle, WorkloadIdle, "Idle workload shop/Deployment/legacy", "Deployment/legacy has used at most 5m CPU for 72h0m0s."},
+	}
+	for _, tt := range tests {
+		n := notificationFor(tt.event)
+		if n == nil || n.Type != tt.wantType {
+			t.Fatalf("notificationFor(%s) = %+v, want type %s", tt.event.Type, n, tt.wantType)
+		}
+		if err := n.render(); err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		if n.Title != tt.wantTitle || n.Text != tt.wantText {
+			t.Errorf("rendered %q / %q, want %q / %q", n.Title, n.Text, tt.wantTitle, tt.wantText)
+		}
+	}
+
+	if n := notificationFor(events.NewEvent(events.EventPodCrashLoop, "", "shop", "web", events.SeverityError, "")); n != nil {
+		t.Errorf("expected no notification for %s, got %+v", events.EventPodCrashLoop, n)
+	}
+}
+
+// TestNotifyLevel verifies notifications below the level or while disabled are dropped
+func TestNotifyLevel(t *testing.T) {
+	cfg := config.GetDefaults()
+	d := NewDispatcher(nil, nil)
+	d.config = cfg
+
+	failed := &Notification{Type: ResizeFailed, Severity: events.SeverityError, Namespace: "shop", Pod: "web"}
+	d.Notify(failed)
+	if len(d.queue) != 0 {
+		t.Fatal("expected no notification while notifications are disabled")
+	}
+
+	cfg.SetNotificationConfig(config.NotificationConfig{EnableNotifications: true})
+	d.Notify(&Notification{Type: ResizeApplied, Severity: events.SeverityInfo})
+	d.Notify(failed)
+	if len(d.queue) != 1 {
+		t.Fatalf("expected only the error to meet the default warning level, got %d queued", len(d.queue))
+	}
+	if n := <-d.queue; n.Title != "Resize failed for shop/web" {
+		t.Errorf("expected a rendered notification, got %+v", n)
+	}
+}
+
+// TestDispatcherDeliversToWebhooks verifies queued notifications reach the configured webhooks
+func TestDispatcherDeliversToWebhooks(t *testing.T) {
+	var received Notification
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	cfg := config.GetDefaults()
+	cfg.SetNotificationConfig(config.NotificationConfig{
+		EnableNotifications: true,
+		Level:               "info",
+		Webhooks: []config.NotificationWebhook{
+			{Name: "ops", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}},
+		},
+	})
+	d := NewDispatcher(nil, nil)
+	d.config = cfg
+
+	d.Notify(&Notification{Type: ResizeRolledBack, Severity: events.SeverityWarning, Namespace: "shop", Pod: "web", Message: "Rolled back"})
+	d.deliver(context.Background(), <-d.queue)
+
+	if received.Type != ResizeRolledBack || received.Text != "Rolled back" || authorization != "Bearer token" {
+		t.Errorf("unexpected delivery %+v with authorization %q", received, authorization)
+	}
+}
+
+// TestSendJSONRetries verifies server errors are retried and client errors are not
+func TestSendJSONRetries(t *testing.T) {
+	var attempts int32
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(config.NotificationWebhook{Name: "ops", URL: server.URL, Method: "POST", RetryCount: 3})
+	n := &Notification{Type: ResizeFailed}
+	if err := notifier.Send(context.Background(), n); err != nil || attempts != 3 {
+		t.Fatalf("expected success on the third attempt, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	status = http.StatusBadRequest
+	if err := notifier.Send(context.Background(), n); err == nil || attempts != 1 {
+		t.Fatalf("expected a client error without retries, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = -10
+	status = http.StatusInternalServerError
+	if err := notifier.Send(context.Background(), n); err == nil || !strings.Contains(err.Error(), "after 4 attempts") {
+		t.Fatalf("expected to give up after the retries, got %v", err)
+	}
+}
+
+// TestChatPayloads verifies the Slack, Teams and PagerDuty request bodies
+func TestChatPayloads(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path == "/pagerduty" {
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	n := &Notification{Type: OOMDetected, Severity: events.SeverityError, Cluster: "prod-eu", Namespace: "shop", Pod: "web", Timestamp: time.Now()}
+	if err := n.render(); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := NewSlackNotifier(server.URL, "#ops", "RightSizer", ":robot_face:").Send(ctx, n); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+	attachments, _ := body["attachments"].([]interface{})
+	if body["text"] != "OOM kill in shop/web" || body["channel"] != "#ops" || len(attachments) != 1 {
+		t.Errorf("unexpected Slack payload %v", body)
+	}
+
+	if err := NewTeamsNotifier(server.URL).Send(ctx, n); err != nil {
+		t.Fatalf("teams: %v", err)
+	}
+	if body["@type"] != "MessageCard" || body["themeColor"] != "A30200" || body["title"] != "OOM kill in shop/web" {
+		t.Errorf("unexpected Teams payload %v", body)
+	}
+
+	pagerDuty := NewPagerDutyNotifier("routing-key")
+	pagerDuty.eventsURL = server.URL + "/pagerduty"
+	if err := pagerDuty.Send(ctx, n); err != nil {
+		t.Fatalf("pagerduty: %v", err)
+	}
+	payload, _ := body["payload"].(map[string]interface{})
+	if body["routing_key"] != "routing-key" || body["event_action"] != "trigger" ||
+		body["dedup_key"] != "prod-eu/shop/web/oom_detected" || payload["severity"] != "error" {
+		t.Errorf("unexpected PagerDuty payload %v", body)
+	}
+}
+
+// TestEmailMessage verifies the headers and body of notification emails
+func TestEmailMessage(t *testing.T) {
+	notifier := NewEmailNotifier("smtp.example.com", 587, true, "bot@example.com", "", "bot@example.com",
+		[]string{"ops@example.com", "sre@example.com"})
+	n := &Notification{Type: ResizeFailed, Severity: events.SeverityError, Namespace: "shop", Pod: "web",
+		Container: "app", Error: "quota exceeded", Timestamp: time.Now()}
+	if err := n.render(); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	message := string(notifier.message(n))
+	for _, want := range []string{
+		"To: ops@example.com, sre@example.com\r\n",
+		"Subject: [right-sizer] Resize failed for shop/web\r\n",
+		"\r\n\r\nContainer app could not be resized: quota exceeded\r\n",
+		"Container: app\r\n",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message does not contain %q:\n%s", want, message)
+		}
+	}
+}
+
+// TestEmailMessageAttachments verifies reports are attached in a multipart message
+func TestEmailMessageAttachments(t *testing.T) {
+	notifier := NewEmailNotifier("smtp.example.com", 587, true, "bot@example.com", "", "bot@example.com",
+		[]string{"ops@example.com"})
+	n := &Notification{Type: ReportGenerated, Severity: events.SeverityInfo, Report: "weekly report",
+		Message: "12 resizes saved $40.00 a month.", Timestamp: time.Now(),
+		Attachments: []Attachment{{Name: "report.csv", ContentType: "text/csv", Data: []byte("namespace,resizes\nshop,12\n")}}}
+	if err := n.render(); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(notifier.message(n)))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, want multipart/mixed", msg.Header.Get("Content-Type"))
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	text, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("text part: %v", err)
+	}
+	body, _ := io.ReadAll(text)
+	if !strings.Contains(string(body), "12 resizes saved $40.00 a month.") {
+		t.Errorf("unexpected text part %q", body)
+	}
+	attachment, err := reader.NextPart()
+	if err != nil {
+		t.Fatalf("attachment part: %v", err)
+	}
+	if attachment.FileName() != "report.csv" {
+		t.Errorf("attachment name = %q, want report.csv", attachment.FileName())
+	}
+	encoded, _ := io.ReadAll(attachment)
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil || string(data) != "namespace,resizes\nshop,12\n" {
+		t.Errorf("attachment = %q, %v", data, err)
+	}
+}
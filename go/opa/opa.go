@@ -0,0 +1,104 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package opa asks an Open Policy Agent server, typically a sidecar, to
+// decide on resizes, so platform teams can govern them with Rego policies
+// kept outside right-sizer's own configuration.
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Result is a policy's decision. The policy may return it as an object or
+// as a bare boolean allowing or denying the resize. Resources, when set,
+// replace the proposed resources of an allowed resize.
+type Result struct {
+	Allow     bool                         `json:"allow"`
+	Reason    string                       `json:"reason,omitempty"`
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// UnmarshalJSON accepts an object or a boolean
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var allow bool
+	if err := json.Unmarshal(data, &allow); err == nil {
+		*r = Result{Allow: allow}
+		return nil
+	}
+	type result Result
+	return json.Unmarshal(data, (*result)(r))
+}
+
+// Client queries a rule through OPA's Data API
+type Client struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the rule at url, e.g.
+// http://localhost:8181/v1/data/rightsizer/resize
+func NewClient(url string, timeout time.Duration) *Client {
+	return &Client{url: url, httpClient: &http.Client{Timeout: timeout}}
+}
+
+// URL returns the rule the client queries
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Evaluate sends input to the rule and returns its decision. A rule that is
+// undefined for the input, such as one whose policy is not loaded, returns
+// an error rather than a decision.
+func (c *Client) Evaluate(ctx context.Context, input interface{}) (Result, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode input: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return Result{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return Result{}, fmt.Errorf("OPA query failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	var response struct {
+		Result *Result `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return Result{}, fmt.Errorf("failed to decode OPA response: %w", err)
+	}
+	if response.Result == nil {
+		return Result{}, fmt.Errorf("OPA rule %s is undefined", c.url)
+	}
+	return *response.Result, nil
+}
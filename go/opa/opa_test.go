@@ -0,0 +1,90 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestClientEvaluate(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		response string
+		want     *Result // nil when an error is expected
+	}{
+		{name: "object", status: http.StatusOK, response: `{"result":{"allow":false,"reason":"payment tier"}}`, want: &Result{Reason: "payment tier"}},
+		{name: "boolean", status: http.StatusOK, response: `{"result":true}`, want: &Result{Allow: true}},
+		{name: "undefined", status: http.StatusOK, response: `{}`},
+		{name: "server error", status: http.StatusInternalServerError, response: `{"code":"internal_error"}`},
+		{name: "invalid", status: http.StatusOK, response: `allow`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var input map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/v1/data/rightsizer/resize" {
+					t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				}
+				var body struct {
+					Input map[string]interface{} `json:"input"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid request body: %v", err)
+				}
+				input = body.Input
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL+"/v1/data/rightsizer/resize", time.Second)
+			result, err := client.Evaluate(context.Background(), map[string]string{"namespace": "payments"})
+			if input["namespace"] != "payments" {
+				t.Errorf("expected the input to be sent, got %v", input)
+			}
+			if tc.want == nil {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if result.Allow != tc.want.Allow || result.Reason != tc.want.Reason {
+				t.Errorf("expected %+v, got %+v", *tc.want, result)
+			}
+		})
+	}
+}
+
+func TestResultResources(t *testing.T) {
+	var result Result
+	if err := json.Unmarshal([]byte(`{"allow":true,"resources":{"requests":{"cpu":"1"}}}`), &result); err != nil {
+		t.Fatal(err)
+	}
+	if cpu := result.Resources.Requests[corev1.ResourceCPU]; !result.Allow || cpu.MilliValue() != 1000 {
+		t.Errorf("expected the adjusted resources, got %+v", result)
+	}
+}
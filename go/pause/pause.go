@@ -0,0 +1,168 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pause keeps whether resizing is paused, for the whole cluster or
+// for single namespaces, so the operator can be frozen during an incident.
+package pause
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"right-sizer/metrics"
+)
+
+// Annotation pauses resizing of the namespace or workload it is set on when "true"
+const Annotation = "rightsizer.io/paused"
+
+// Entry is a pause of the whole cluster, or of a namespace
+type Entry struct {
+	Namespace string    `json:"namespace,omitempty"` // Empty for the whole cluster
+	Source    string    `json:"source"`              // api, or annotation for a paused namespace
+	Reason    string    `json:"reason,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+// State keeps the pauses set through the API and the namespaces paused by
+// annotation, and publishes them on the paused gauge. Pauses set through the
+// API do not survive a restart; annotations do.
+type State struct {
+	mu        sync.RWMutex
+	cluster   *Entry
+	paused    map[string]Entry
+	annotated map[string]Entry
+	metrics   *metrics.OperatorMetrics
+}
+
+// NewState returns a state with nothing paused; metrics may be nil
+func NewState(m *metrics.OperatorMetrics) *State {
+	s := &State{paused: make(map[string]Entry), annotated: make(map[string]Entry), metrics: m}
+	s.publishLocked()
+	return s
+}
+
+// Pause pauses resizing in a namespace, or in the whole cluster when the
+// namespace is empty
+func (s *State) Pause(namespace, reason string) Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := Entry{Namespace: namespace, Source: "api", Reason: reason, Since: time.Now()}
+	if namespace == "" {
+		s.cluster = &entry
+	} else {
+		s.paused[namespace] = entry
+	}
+	s.publishLocked()
+	return entry
+}
+
+// Resume lifts a pause set through the API and reports whether there was one.
+// A namespace paused by annotation stays paused until the annotation is removed.
+func (s *State) Resume(namespace string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found bool
+	if namespace == "" {
+		found = s.cluster != nil
+		s.cluster = nil
+	} else {
+		_, found = s.paused[namespace]
+		delete(s.paused, namespace)
+	}
+	s.publishLocked()
+	return found
+}
+
+// SetAnnotatedNamespaces replaces the namespaces paused by annotation
+func (s *State) SetAnnotatedNamespaces(namespaces []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	annotated := make(map[string]Entry, len(namespaces))
+	for _, namespace := range namespaces {
+		entry, ok := s.annotated[namespace]
+		if !ok {
+			entry = Entry{Namespace: namespace, Source: "annotation", Since: time.Now()}
+		}
+		annotated[namespace] = entry
+	}
+	s.annotated = annotated
+	s.publishLocked()
+}
+
+// Paused returns the pause that applies to a namespace: the cluster's, or the
+// namespace's own
+func (s *State) Paused(namespace string) (Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.cluster != nil {
+		return *s.cluster, true
+	}
+	if entry, ok := s.paused[namespace]; ok {
+		return entry, true
+	}
+	entry, ok := s.annotated[namespace]
+	return entry, ok
+}
+
+// ClusterPaused reports whether the whole cluster is paused
+func (s *State) ClusterPaused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cluster != nil
+}
+
+// List returns every pause, the cluster's first and then by namespace
+func (s *State) List() []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []Entry
+	for _, entry := range s.paused {
+		entries = append(entries, entry)
+	}
+	for namespace, entry := range s.annotated {
+		if _, ok := s.paused[namespace]; !ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Namespace < entries[j].Namespace })
+	if s.cluster != nil {
+		entries = append([]Entry{*s.cluster}, entries...)
+	}
+	return entries
+}
+
+// publishLocked sets the paused gauge; the caller holds the lock
+func (s *State) publishLocked() {
+	if s.metrics == nil {
+		return
+	}
+	namespaces := make([]string, 0, len(s.paused)+len(s.annotated))
+	for namespace := range s.paused {
+		namespaces = append(namespaces, namespace)
+	}
+	for namespace := range s.annotated {
+		if !slices.Contains(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	s.metrics.SetPaused(s.cluster != nil, namespaces)
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pause
+
+import (
+	"testing"
+
+	"right-sizer/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStatePausesAndResumes(t *testing.T) {
+	m, err := metrics.NewOperatorMetricsWithRegisterer(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("failed to create metrics: %v", err)
+	}
+	state := NewState(m)
+	if got := testutil.ToFloat64(m.Paused.WithLabelValues("cluster", "")); got != 0 {
+		t.Errorf("expected the cluster gauge to start at 0, got %v", got)
+	}
+
+	state.Pause("payments", "INC-1234")
+	state.SetAnnotatedNamespaces([]string{"batch"})
+	if _, paused := state.Paused("payments"); !paused {
+		t.Error("expected payments to be paused")
+	}
+	if entry, paused := state.Paused("batch"); !paused || entry.Source != "annotation" {
+		t.Errorf("expected batch to be paused by annotation, got %+v", entry)
+	}
+	if _, paused := state.Paused("web"); paused {
+		t.Error("expected web not to be paused")
+	}
+	if got := testutil.CollectAndCount(m.Paused); got != 3 {
+		t.Errorf("expected the cluster and two namespace series, got %d", got)
+	}
+
+	state.Pause("", "freeze")
+	if entry, paused := state.Paused("web"); !paused || entry.Namespace != "" {
+		t.Errorf("expected the cluster pause to cover web, got %+v", entry)
+	}
+	if entries := state.List(); len(entries) != 3 || entries[0].Namespace != "" || entries[1].Namespace != "batch" {
+		t.Errorf("expected the cluster pause first and namespaces in order, got %+v", entries)
+	}
+
+	if !state.Resume("") || !state.Resume("payments") {
+		t.Error("expected the pauses set through the API to be lifted")
+	}
+	if state.Resume("batch") {
+		t.Error("expected a namespace paused by annotation not to be resumable through the API")
+	}
+	if _, paused := state.Paused("batch"); !paused {
+		t.Error("expected batch to stay paused by its annotation")
+	}
+	state.SetAnnotatedNamespaces(nil)
+	if got := testutil.CollectAndCount(m.Paused); got != 1 {
+		t.Errorf("expected only the cluster series once everything resumed, got %d", got)
+	}
+}
@@ -18,6 +18,7 @@ package predictor
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 	"sync"
 	"time"
@@ -45,6 +46,14 @@ func NewEngine(config *Config) (*Engine, error) {
 	switch config.StorageDriver {
 	case "memory":
 		store = NewMemoryStore(config)
+	case "file":
+		fileStore, err := NewFileStore(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file store: %w", err)
+		}
+		store = fileStore
+	case "prometheus":
+		store = NewPrometheusStore(config)
 	default:
 		return nil, fmt.Errorf("unsupported storage driver: %s", config.StorageDriver)
 	}
@@ -79,6 +88,15 @@ func (e *Engine) addPredictor(method PredictionMethod) error {
 		predictor = NewSimpleMovingAveragePredictor(5) // Default window size
 	case PredictionMethodSeasonal:
 		predictor = NewSeasonalPredictor()
+	case PredictionMethodHoltWintersDaily:
+		predictor = NewDailyHoltWintersPredictor()
+	case PredictionMethodHoltWintersWeekly:
+		predictor = NewWeeklyHoltWintersPredictor()
+	case PredictionMethodExternal:
+		if e.config.ExternalPredictorURL == "" {
+			return fmt.Errorf("external predictor requires an endpoint URL")
+		}
+		predictor = NewExternalPredictor(e.config.ExternalPredictorURL, e.config.ExternalPredictorToken, e.config.ExternalPredictorTimeout)
 	default:
 		return fmt.Errorf("unsupported prediction method: %s", method)
 	}
@@ -121,6 +139,33 @@ func (e *Engine) Stop() error {
 	return nil
 }
 
+// Close flushes and releases the store when it keeps state outside the process
+func (e *Engine) Close() error {
+	if closer, ok := e.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Reconfigure changes the settings that follow the operator's configuration
+// while the engine runs: how long historical data is kept, how often it is
+// collected and the minimum confidence of the predictions returned. Zero
+// values keep the current setting.
+func (e *Engine) Reconfigure(retention, collectionInterval time.Duration, confidenceThreshold float64) {
+	e.config.mu.Lock()
+	defer e.config.mu.Unlock()
+
+	if retention > 0 {
+		e.config.HistoricalDataRetention = retention
+	}
+	if collectionInterval > 0 {
+		e.config.CollectionInterval = collectionInterval
+	}
+	if confidenceThreshold > 0 {
+		e.config.ConfidenceThreshold = confidenceThreshold
+	}
+}
+
 // StoreDataPoint stores a new historical data point
 func (e *Engine) StoreDataPoint(namespace, podName, container, resourceType string, value float64, timestamp time.Time) error {
 	dataPoint := DataPoint{
@@ -136,6 +181,12 @@ func (e *Engine) StoreDataPoint(namespace, podName, container, resourceType stri
 
 // Predict generates predictions for a resource using all enabled predictors
 func (e *Engine) Predict(ctx context.Context, request PredictionRequest) (*PredictionResponse, error) {
+	response, _, err := e.predict(ctx, request)
+	return response, err
+}
+
+// predict generates predictions and also returns those below the confidence threshold
+func (e *Engine) predict(ctx context.Context, request PredictionRequest) (*PredictionResponse, []ResourcePrediction, error) {
 	// Use default horizons if none specified
 	horizons := request.Horizons
 	if len(horizons) == 0 {
@@ -149,10 +200,10 @@ func (e *Engine) Predict(ctx context.Context, request PredictionRequest) (*Predi
 	}
 
 	// Get historical data
-	since := time.Now().Add(-e.config.HistoricalDataRetention)
+	since := time.Now().Add(-e.config.retention())
 	historicalData, err := e.store.GetHistoricalData(request.Namespace, request.PodName, request.Container, request.ResourceType, since)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get historical data: %w", err)
+		return nil, nil, fmt.Errorf("failed to get historical data: %w", err)
 	}
 
 	// Check if we have enough data
@@ -162,7 +213,7 @@ func (e *Engine) Predict(ctx context.Context, request PredictionRequest) (*Predi
 			Predictions: []ResourcePrediction{},
 			Timestamp:   time.Now(),
 			DataPoints:  len(historicalData.DataPoints),
-		}, nil
+		}, nil, nil
 	}
 
 	// Generate predictions using multiple methods
@@ -221,53 +272,30 @@ func (e *Engine) Predict(ctx context.Context, request PredictionRequest) (*Predi
 		close(errorChan)
 	}()
 
-	// Collect predictions and errors
-	for {
+	// Collect predictions and errors until every method has finished
+	for predictionChan != nil || errorChan != nil {
 		select {
-		case predictions := <-predictionChan:
-			if predictions != nil {
-				allPredictions = append(allPredictions, predictions...)
-			}
-		case err := <-errorChan:
-			if err != nil {
-				predictionErrors = append(predictionErrors, err)
-			}
-		case <-predCtx.Done():
-			return nil, fmt.Errorf("prediction timeout exceeded")
-		default:
-			// Check if both channels are closed
-			if predictionChan == nil && errorChan == nil {
-				goto collectResults
-			}
-			// Continue collecting
-		}
-
-		// Check if channels are closed
-		select {
-		case _, ok := <-predictionChan:
+		case predictions, ok := <-predictionChan:
 			if !ok {
 				predictionChan = nil
+				continue
 			}
-		default:
-		}
-		select {
-		case _, ok := <-errorChan:
+			allPredictions = append(allPredictions, predictions...)
+		case err, ok := <-errorChan:
 			if !ok {
 				errorChan = nil
+				continue
 			}
-		default:
-		}
-
-		if predictionChan == nil && errorChan == nil {
-			break
+			predictionErrors = append(predictionErrors, err)
+		case <-predCtx.Done():
+			return nil, nil, fmt.Errorf("prediction timeout exceeded")
 		}
 	}
 
-collectResults:
 	// Filter predictions by confidence threshold
 	var filteredPredictions []ResourcePrediction
 	for _, pred := range allPredictions {
-		if pred.Confidence >= e.config.ConfidenceThreshold {
+		if pred.Confidence >= e.config.confidenceThreshold() {
 			filteredPredictions = append(filteredPredictions, pred)
 		}
 	}
@@ -295,11 +323,19 @@ collectResults:
 		DataPoints:  len(historicalData.DataPoints),
 	}
 
-	return response, nil
+	return response, allPredictions, nil
 }
 
-// GetBestPrediction returns the best prediction for a specific horizon
-func (e *Engine) GetBestPrediction(ctx context.Context, namespace, podName, container, resourceType string, horizon time.Duration) (*ResourcePrediction, error) {
+// ModelSelection is the prediction chosen for a horizon together with the
+// confidence of every model that produced a prediction for it
+type ModelSelection struct {
+	Best        *ResourcePrediction
+	Confidences map[PredictionMethod]float64
+}
+
+// SelectModel runs all enabled models and picks the most confident prediction
+// for the horizon; Best is nil when no prediction meets the confidence threshold
+func (e *Engine) SelectModel(ctx context.Context, namespace, podName, container, resourceType string, horizon time.Duration) (*ModelSelection, error) {
 	request := PredictionRequest{
 		Namespace:    namespace,
 		PodName:      podName,
@@ -309,30 +345,42 @@ func (e *Engine) GetBestPrediction(ctx context.Context, namespace, podName, cont
 		Methods:      e.config.EnabledMethods,
 	}
 
-	response, err := e.Predict(ctx, request)
+	response, all, err := e.predict(ctx, request)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(response.Predictions) == 0 {
-		return nil, fmt.Errorf("no predictions available")
+	selection := &ModelSelection{Confidences: make(map[PredictionMethod]float64)}
+	for _, pred := range all {
+		if pred.Horizon == horizon && pred.Confidence > selection.Confidences[pred.Method] {
+			selection.Confidences[pred.Method] = pred.Confidence
+		}
 	}
 
 	// Return the prediction with highest confidence for the requested horizon
-	var bestPrediction *ResourcePrediction
 	for _, pred := range response.Predictions {
 		if pred.Horizon == horizon {
-			if bestPrediction == nil || pred.Confidence > bestPrediction.Confidence {
-				bestPrediction = &pred
+			if selection.Best == nil || pred.Confidence > selection.Best.Confidence {
+				selection.Best = &pred
 			}
 		}
 	}
 
-	if bestPrediction == nil {
-		return nil, fmt.Errorf("no prediction found for horizon %v", horizon)
+	return selection, nil
+}
+
+// GetBestPrediction returns the best prediction for a specific horizon
+func (e *Engine) GetBestPrediction(ctx context.Context, namespace, podName, container, resourceType string, horizon time.Duration) (*ResourcePrediction, error) {
+	selection, err := e.SelectModel(ctx, namespace, podName, container, resourceType, horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	if selection.Best == nil {
+		return nil, fmt.Errorf("no predictions available")
 	}
 
-	return bestPrediction, nil
+	return selection.Best, nil
 }
 
 // GetHistoricalData retrieves historical data for a resource
@@ -360,7 +408,7 @@ func (e *Engine) cleanupRoutine(ctx context.Context) {
 			return
 		case <-ticker.C:
 			// Perform cleanup
-			cutoff := time.Now().Add(-e.config.HistoricalDataRetention)
+			cutoff := time.Now().Add(-e.config.retention())
 			if err := e.store.CleanupOldData(cutoff); err != nil {
 				fmt.Printf("Cleanup error: %v\n", err)
 			}
@@ -385,8 +433,8 @@ func (e *Engine) GetStats() map[string]interface{} {
 	}
 
 	// Add store stats if available
-	if memoryStore, ok := e.store.(*MemoryStore); ok {
-		stats["store"] = memoryStore.GetStats()
+	if statsStore, ok := e.store.(interface{ GetStats() map[string]interface{} }); ok {
+		stats["store"] = statsStore.GetStats()
 	}
 
 	return stats
@@ -0,0 +1,191 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// externalDefaultConfidence is used when the model does not score its forecast
+const externalDefaultConfidence = 0.8
+
+// ExternalPredictor sends the usage history to a user-provided model endpoint
+// and uses its forecast. Requests and responses follow the KServe V1
+// inference protocol, so a KServe InferenceService can be targeted directly
+// at its :predict URL.
+type ExternalPredictor struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// externalInstance is the series sent to the model for one container resource
+type externalInstance struct {
+	Namespace    string    `json:"namespace,omitempty"`
+	Pod          string    `json:"pod,omitempty"`
+	Container    string    `json:"container,omitempty"`
+	ResourceType string    `json:"resourceType"`
+	Timestamps   []int64   `json:"timestamps"` // Unix seconds
+	Values       []float64 `json:"values"`
+	Horizons     []int64   `json:"horizons"` // Seconds ahead of now
+}
+
+// externalForecast is the model's answer, one value per requested horizon
+type externalForecast struct {
+	Values     []float64 `json:"values"`
+	Confidence []float64 `json:"confidence,omitempty"`
+	Lower      []float64 `json:"lower,omitempty"`
+	Upper      []float64 `json:"upper,omitempty"`
+}
+
+// NewExternalPredictor creates a predictor backed by the model served at url
+func NewExternalPredictor(url, token string, timeout time.Duration) *ExternalPredictor {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &ExternalPredictor{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// GetMethod returns the prediction method
+func (p *ExternalPredictor) GetMethod() PredictionMethod {
+	return PredictionMethodExternal
+}
+
+// GetMinDataPoints returns minimum data points required
+func (p *ExternalPredictor) GetMinDataPoints() int {
+	return 1 // The model decides how much history it needs
+}
+
+// ValidateData checks there is history to send
+func (p *ExternalPredictor) ValidateData(data HistoricalData) error {
+	if len(data.DataPoints) < p.GetMinDataPoints() {
+		return fmt.Errorf("no data points to send to the external predictor")
+	}
+	return nil
+}
+
+// Predict asks the model for a forecast at each horizon
+func (p *ExternalPredictor) Predict(data HistoricalData, horizons []time.Duration) ([]ResourcePrediction, error) {
+	if err := p.ValidateData(data); err != nil {
+		return nil, err
+	}
+
+	instance := externalInstance{
+		ResourceType: data.ResourceType,
+		Timestamps:   make([]int64, len(data.DataPoints)),
+		Values:       make([]float64, len(data.DataPoints)),
+		Horizons:     make([]int64, len(horizons)),
+	}
+	for i, dp := range data.DataPoints {
+		instance.Timestamps[i] = dp.Timestamp.Unix()
+		instance.Values[i] = dp.Value
+		if instance.Namespace == "" {
+			instance.Namespace, instance.Pod, instance.Container = dp.Namespace, dp.PodName, dp.Container
+		}
+	}
+	for i, horizon := range horizons {
+		instance.Horizons[i] = int64(horizon / time.Second)
+	}
+
+	forecast, err := p.request(instance)
+	if err != nil {
+		return nil, err
+	}
+	if len(forecast.Values) != len(horizons) {
+		return nil, fmt.Errorf("external predictor returned %d values for %d horizons", len(forecast.Values), len(horizons))
+	}
+
+	now := time.Now()
+	predictions := make([]ResourcePrediction, 0, len(horizons))
+	for i, horizon := range horizons {
+		value := math.Max(0, forecast.Values[i])
+		confidence := externalDefaultConfidence
+		if i < len(forecast.Confidence) {
+			confidence = math.Max(0, math.Min(1, forecast.Confidence[i]))
+		}
+
+		prediction := ResourcePrediction{
+			Value:      value,
+			Confidence: confidence,
+			Horizon:    horizon,
+			Timestamp:  now,
+			Method:     PredictionMethodExternal,
+			Metadata: map[string]interface{}{
+				"endpoint":    p.url,
+				"data_points": len(data.DataPoints),
+			},
+		}
+		if i < len(forecast.Lower) && i < len(forecast.Upper) {
+			prediction.ConfidenceInterval = &ConfidenceInterval{
+				Lower:      math.Max(0, forecast.Lower[i]),
+				Upper:      forecast.Upper[i],
+				Percentage: 95,
+			}
+		}
+		predictions = append(predictions, prediction)
+	}
+
+	return predictions, nil
+}
+
+// request posts the instance to the model and decodes the first prediction
+func (p *ExternalPredictor) request(instance externalInstance) (*externalForecast, error) {
+	body, err := json.Marshal(map[string]interface{}{"instances": []externalInstance{instance}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode external predictor request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external predictor request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("external predictor request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("external predictor returned %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+
+	var result struct {
+		Predictions []externalForecast `json:"predictions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode external predictor response: %w", err)
+	}
+	if len(result.Predictions) == 0 {
+		return nil, fmt.Errorf("external predictor returned no predictions")
+	}
+	return &result.Predictions[0], nil
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalPredictor_Predict(t *testing.T) {
+	var request struct {
+		Instances []externalInstance `json:"instances"`
+	}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		_, _ = w.Write([]byte(`{"predictions":[{"values":[150,-5],"confidence":[0.9,1.5],"lower":[120,0],"upper":[180,10]}]}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	data := HistoricalData{ResourceType: "cpu", DataPoints: []DataPoint{
+		{Timestamp: now.Add(-time.Minute), Value: 100, Namespace: "shop", PodName: "web", Container: "app"},
+		{Timestamp: now, Value: 120, Namespace: "shop", PodName: "web", Container: "app"},
+	}}
+
+	predictor := NewExternalPredictor(server.URL, "secret", time.Second)
+	predictions, err := predictor.Predict(data, []time.Duration{5 * time.Minute, time.Hour})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer secret", authorization)
+	require.Len(t, request.Instances, 1)
+	instance := request.Instances[0]
+	assert.Equal(t, "shop", instance.Namespace)
+	assert.Equal(t, "app", instance.Container)
+	assert.Equal(t, []float64{100, 120}, instance.Values)
+	assert.Equal(t, now.Unix(), instance.Timestamps[1])
+	assert.Equal(t, []int64{300, 3600}, instance.Horizons)
+
+	require.Len(t, predictions, 2)
+	assert.Equal(t, 150.0, predictions[0].Value)
+	assert.Equal(t, 0.9, predictions[0].Confidence)
+	assert.Equal(t, PredictionMethodExternal, predictions[0].Method)
+	require.NotNil(t, predictions[0].ConfidenceInterval)
+	assert.Equal(t, 120.0, predictions[0].ConfidenceInterval.Lower)
+
+	// Negative forecasts and out of range confidences are clamped
+	assert.Equal(t, 0.0, predictions[1].Value)
+	assert.Equal(t, 1.0, predictions[1].Confidence)
+}
+
+func TestExternalPredictor_Errors(t *testing.T) {
+	response, status := "", http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	data := HistoricalData{ResourceType: "memory", DataPoints: []DataPoint{{Timestamp: time.Now(), Value: 1}}}
+	horizons := []time.Duration{time.Hour}
+	predictor := NewExternalPredictor(server.URL, "", time.Second)
+
+	response = `{"predictions":[{"values":[1,2]}]}`
+	_, err := predictor.Predict(data, horizons)
+	assert.ErrorContains(t, err, "returned 2 values for 1 horizons")
+
+	response, status = "model not loaded", http.StatusServiceUnavailable
+	_, err = predictor.Predict(data, horizons)
+	assert.ErrorContains(t, err, "model not loaded")
+
+	_, err = predictor.Predict(HistoricalData{ResourceType: "cpu"}, horizons)
+	assert.Error(t, err)
+}
+
+func TestEngineExternalPredictorRequiresURL(t *testing.T) {
+	config := DefaultConfig()
+	config.EnabledMethods = []PredictionMethod{PredictionMethodExternal}
+	_, err := NewEngine(config)
+	assert.ErrorContains(t, err, "requires an endpoint URL")
+
+	config.ExternalPredictorURL = "http://model.example/v1/models/usage:predict"
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+	assert.Contains(t, engine.predictors, PredictionMethodExternal)
+}
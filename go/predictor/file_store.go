@@ -0,0 +1,200 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileStoreName is the snapshot file written inside the storage directory
+const fileStoreName = "predictor-state.json"
+
+// fileStoreSnapshot is the on-disk representation of the store
+type fileStoreSnapshot struct {
+	SavedAt        time.Time                       `json:"savedAt"`
+	HistoricalData map[string][]DataPoint          `json:"historicalData"`
+	Predictions    map[string][]ResourcePrediction `json:"predictions"`
+}
+
+// FileStore is a prediction store that keeps its data in memory and writes it
+// to a directory, typically a PersistentVolume, so history survives restarts.
+// Writes are batched: the state is flushed at most once per FlushInterval and
+// on Close.
+type FileStore struct {
+	*MemoryStore
+	path          string
+	flushInterval time.Duration
+
+	flushMutex sync.Mutex
+	lastFlush  time.Time
+	dirty      bool
+}
+
+// NewFileStore opens the file store in config.StoragePath, loading any state
+// left by a previous run
+func NewFileStore(config *Config) (*FileStore, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.StoragePath == "" {
+		return nil, errors.New("storage path is required for the file store")
+	}
+	if err := os.MkdirAll(config.StoragePath, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	s := &FileStore{
+		MemoryStore:   NewMemoryStore(config),
+		path:          filepath.Join(config.StoragePath, fileStoreName),
+		flushInterval: config.FlushInterval,
+		lastFlush:     time.Now(),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// StoreHistoricalData stores a data point and flushes to disk when due
+func (s *FileStore) StoreHistoricalData(namespace, podName, container, resourceType string, dataPoint DataPoint) error {
+	if err := s.MemoryStore.StoreHistoricalData(namespace, podName, container, resourceType, dataPoint); err != nil {
+		return err
+	}
+	return s.markDirty()
+}
+
+// StorePrediction stores a prediction and flushes to disk when due
+func (s *FileStore) StorePrediction(namespace, podName, container, resourceType string, prediction ResourcePrediction) error {
+	if err := s.MemoryStore.StorePrediction(namespace, podName, container, resourceType, prediction); err != nil {
+		return err
+	}
+	return s.markDirty()
+}
+
+// CleanupOldData removes old data and records that the snapshot changed
+func (s *FileStore) CleanupOldData(olderThan time.Time) error {
+	if err := s.MemoryStore.CleanupOldData(olderThan); err != nil {
+		return err
+	}
+	return s.markDirty()
+}
+
+// Flush writes the current state to disk
+func (s *FileStore) Flush() error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+	return s.flushLocked()
+}
+
+// Close flushes any pending changes
+func (s *FileStore) Close() error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+// markDirty records a change and flushes if the flush interval has elapsed
+func (s *FileStore) markDirty() error {
+	s.flushMutex.Lock()
+	defer s.flushMutex.Unlock()
+
+	s.dirty = true
+	if time.Since(s.lastFlush) < s.flushInterval {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+// flushLocked writes the snapshot atomically; the caller must hold flushMutex
+func (s *FileStore) flushLocked() error {
+	s.mutex.RLock()
+	data, err := json.Marshal(fileStoreSnapshot{
+		SavedAt:        time.Now(),
+		HistoricalData: s.historicalData,
+		Predictions:    s.predictions,
+	})
+	s.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode predictor state: %w", err)
+	}
+
+	// Write to a temporary file and rename so a crash never leaves a torn snapshot
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write predictor state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace predictor state: %w", err)
+	}
+
+	s.lastFlush = time.Now()
+	s.dirty = false
+	return nil
+}
+
+// load restores the snapshot written by a previous run, dropping expired data
+func (s *FileStore) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read predictor state: %w", err)
+	}
+
+	var snapshot fileStoreSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to decode predictor state %s: %w", s.path, err)
+	}
+
+	historicalCutoff := time.Now().Add(-s.config.retention())
+	predictionCutoff := time.Now().Add(-s.config.PredictionRetention)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key, dataPoints := range snapshot.HistoricalData {
+		var kept []DataPoint
+		for _, dp := range dataPoints {
+			if dp.Timestamp.After(historicalCutoff) {
+				kept = append(kept, dp)
+			}
+		}
+		if len(kept) > 0 {
+			s.historicalData[key] = kept
+		}
+	}
+	for key, predictions := range snapshot.Predictions {
+		var kept []ResourcePrediction
+		for _, p := range predictions {
+			if p.Timestamp.After(predictionCutoff) {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) > 0 {
+			s.predictions[key] = kept
+		}
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// holtWintersDamping damps the trend so long horizons do not extrapolate it forever
+const holtWintersDamping = 0.98
+
+// Smoothing parameters tried when fitting; the combination with the smallest
+// one-step-ahead error is used
+var (
+	holtWintersAlphas = []float64{0.1, 0.2, 0.4, 0.6}
+	holtWintersBetas  = []float64{0, 0.01, 0.05}
+	holtWintersGammas = []float64{0.05, 0.1, 0.3}
+)
+
+// HoltWintersPredictor fits additive Holt-Winters (triple exponential
+// smoothing) to usage resampled into evenly spaced buckets, so forecasts
+// follow the daily or weekly cycle instead of lagging behind it
+type HoltWintersPredictor struct {
+	method       PredictionMethod
+	period       time.Duration // Length of one season
+	seasonLength int           // Buckets per season
+}
+
+// NewHoltWintersPredictor creates a Holt-Winters predictor for a season of
+// period split into seasonLength buckets
+func NewHoltWintersPredictor(method PredictionMethod, period time.Duration, seasonLength int) *HoltWintersPredictor {
+	return &HoltWintersPredictor{
+		method:       method,
+		period:       period,
+		seasonLength: seasonLength,
+	}
+}
+
+// NewDailyHoltWintersPredictor creates a predictor for a daily cycle in 15 minute buckets
+func NewDailyHoltWintersPredictor() *HoltWintersPredictor {
+	return NewHoltWintersPredictor(PredictionMethodHoltWintersDaily, 24*time.Hour, 96)
+}
+
+// NewWeeklyHoltWintersPredictor creates a predictor for a weekly cycle in hourly buckets
+func NewWeeklyHoltWintersPredictor() *HoltWintersPredictor {
+	return NewHoltWintersPredictor(PredictionMethodHoltWintersWeekly, 7*24*time.Hour, 168)
+}
+
+// GetMethod returns the prediction method
+func (p *HoltWintersPredictor) GetMethod() PredictionMethod {
+	return p.method
+}
+
+// GetMinDataPoints returns minimum data points required
+func (p *HoltWintersPredictor) GetMinDataPoints() int {
+	return 2 * p.seasonLength // Two seasons, one sample per bucket
+}
+
+// ValidateData checks the data covers at least two seasons
+func (p *HoltWintersPredictor) ValidateData(data HistoricalData) error {
+	if len(data.DataPoints) < p.GetMinDataPoints() {
+		return fmt.Errorf("insufficient data points for Holt-Winters: have %d, need %d",
+			len(data.DataPoints), p.GetMinDataPoints())
+	}
+
+	first, last := data.DataPoints[0].Timestamp, data.DataPoints[0].Timestamp
+	for _, dp := range data.DataPoints {
+		if dp.Timestamp.Before(first) {
+			first = dp.Timestamp
+		}
+		if dp.Timestamp.After(last) {
+			last = dp.Timestamp
+		}
+	}
+	if span := last.Sub(first); span < 2*p.period-p.bucket() {
+		return fmt.Errorf("insufficient history for Holt-Winters: have %v, need two seasons of %v", span.Round(time.Minute), p.period)
+	}
+	return nil
+}
+
+// bucket returns the width of a bucket
+func (p *HoltWintersPredictor) bucket() time.Duration {
+	return p.period / time.Duration(p.seasonLength)
+}
+
+// Predict forecasts every bucket between now and each horizon and returns
+// the peak, so a daily peak within the horizon is sized for before it arrives
+func (p *HoltWintersPredictor) Predict(data HistoricalData, horizons []time.Duration) ([]ResourcePrediction, error) {
+	if err := p.ValidateData(data); err != nil {
+		return nil, err
+	}
+
+	values, start := p.resample(data.DataPoints)
+	if len(values) < 2*p.seasonLength {
+		return nil, fmt.Errorf("insufficient buckets for Holt-Winters: have %d, need %d", len(values), 2*p.seasonLength)
+	}
+	model := p.fitBest(values)
+
+	bucket := p.bucket()
+	last := len(values) - 1
+	lastTime := start.Add(time.Duration(last) * bucket)
+	now := time.Now()
+
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	predictions := make([]ResourcePrediction, 0, len(horizons))
+	for _, horizon := range horizons {
+		// Forecast the buckets from now until the end of the horizon
+		first := int(now.Sub(lastTime) / bucket)
+		if first < 1 {
+			first = 1
+		}
+		end := int(now.Add(horizon).Sub(lastTime) / bucket)
+		if end < first {
+			end = first
+		}
+
+		peak, peakStep := math.Inf(-1), first
+		for h := first; h <= end; h++ {
+			if forecast := model.forecast(last, h); forecast > peak {
+				peak, peakStep = forecast, h
+			}
+		}
+		value := math.Max(0, peak)
+
+		margin := 1.96 * model.rmse * math.Sqrt(1+float64(end-1)*model.alpha*model.alpha)
+		predictions = append(predictions, ResourcePrediction{
+			Value:      value,
+			Confidence: p.calculateConfidence(model, mean, len(values), horizon),
+			Horizon:    horizon,
+			Timestamp:  now,
+			Method:     p.method,
+			ConfidenceInterval: &ConfidenceInterval{
+				Lower:      math.Max(0, value-margin),
+				Upper:      value + margin,
+				Percentage: 95,
+			},
+			Metadata: map[string]interface{}{
+				"alpha":              model.alpha,
+				"beta":               model.beta,
+				"gamma":              model.gamma,
+				"level":              model.level,
+				"trend":              model.trend,
+				"seasonal_component": model.seasonal[(last+peakStep)%p.seasonLength],
+				"peak_at":            lastTime.Add(time.Duration(peakStep) * bucket),
+				"season_length":      p.seasonLength,
+				"bucket":             bucket.String(),
+				"mae":                model.mae,
+				"rmse":               model.rmse,
+			},
+		})
+	}
+
+	return predictions, nil
+}
+
+// resample averages the data points into buckets aligned to the clock,
+// interpolating empty buckets from their neighbours
+func (p *HoltWintersPredictor) resample(dataPoints []DataPoint) ([]float64, time.Time) {
+	sorted := make([]DataPoint, len(dataPoints))
+	copy(sorted, dataPoints)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	bucket := p.bucket()
+	start := sorted[0].Timestamp.Truncate(bucket)
+	n := int(sorted[len(sorted)-1].Timestamp.Sub(start)/bucket) + 1
+	sums := make([]float64, n)
+	counts := make([]int, n)
+	for _, dp := range sorted {
+		i := int(dp.Timestamp.Sub(start) / bucket)
+		sums[i] += dp.Value
+		counts[i]++
+	}
+
+	values := make([]float64, n)
+	previous := -1
+	for i := range values {
+		if counts[i] == 0 {
+			continue
+		}
+		values[i] = sums[i] / float64(counts[i])
+		if previous == -1 {
+			// Leading empty buckets take the first value
+			for j := 0; j < i; j++ {
+				values[j] = values[i]
+			}
+		} else {
+			for j := previous + 1; j < i; j++ {
+				fraction := float64(j-previous) / float64(i-previous)
+				values[j] = values[previous] + fraction*(values[i]-values[previous])
+			}
+		}
+		previous = i
+	}
+	return values, start
+}
+
+// holtWintersModel is the state of a fitted model after the last bucket
+type holtWintersModel struct {
+	alpha, beta, gamma float64
+	level, trend       float64
+	seasonal           []float64
+	mae, rmse          float64
+}
+
+// forecast returns the value h buckets after bucket last
+func (m *holtWintersModel) forecast(last, h int) float64 {
+	damped := 0.0
+	factor := 1.0
+	for i := 1; i <= h; i++ {
+		factor *= holtWintersDamping
+		damped += factor
+	}
+	return m.level + damped*m.trend + m.seasonal[(last+h)%len(m.seasonal)]
+}
+
+// fitBest fits the model with every parameter combination and keeps the one
+// with the smallest one-step-ahead squared error
+func (p *HoltWintersPredictor) fitBest(values []float64) *holtWintersModel {
+	var best *holtWintersModel
+	for _, alpha := range holtWintersAlphas {
+		for _, beta := range holtWintersBetas {
+			for _, gamma := range holtWintersGammas {
+				model := p.fit(values, alpha, beta, gamma)
+				if best == nil || model.rmse < best.rmse {
+					best = model
+				}
+			}
+		}
+	}
+	return best
+}
+
+// fit runs additive Holt-Winters with a damped trend over the values,
+// initialised from the first two seasons
+func (p *HoltWintersPredictor) fit(values []float64, alpha, beta, gamma float64) *holtWintersModel {
+	m := p.seasonLength
+	firstMean, secondMean := 0.0, 0.0
+	for i := 0; i < m; i++ {
+		firstMean += values[i]
+		secondMean += values[m+i]
+	}
+	firstMean /= float64(m)
+	secondMean /= float64(m)
+
+	model := &holtWintersModel{
+		alpha:    alpha,
+		beta:     beta,
+		gamma:    gamma,
+		level:    firstMean,
+		trend:    (secondMean - firstMean) / float64(m),
+		seasonal: make([]float64, m),
+	}
+	for i := 0; i < m; i++ {
+		model.seasonal[i] = values[i] - firstMean
+	}
+
+	var absErr, sqErr float64
+	for t := m; t < len(values); t++ {
+		season := model.seasonal[t%m]
+		forecast := model.level + holtWintersDamping*model.trend + season
+		err := values[t] - forecast
+		absErr += math.Abs(err)
+		sqErr += err * err
+
+		level := alpha*(values[t]-season) + (1-alpha)*(model.level+holtWintersDamping*model.trend)
+		model.trend = beta*(level-model.level) + (1-beta)*holtWintersDamping*model.trend
+		model.seasonal[t%m] = gamma*(values[t]-level) + (1-gamma)*season
+		model.level = level
+	}
+
+	steps := float64(len(values) - m)
+	model.mae = absErr / steps
+	model.rmse = math.Sqrt(sqErr / steps)
+	return model
+}
+
+// calculateConfidence scores the fit by its error relative to the mean usage,
+// discounted while only a few seasons were seen and for longer horizons
+func (p *HoltWintersPredictor) calculateConfidence(model *holtWintersModel, mean float64, buckets int, horizon time.Duration) float64 {
+	fit := 0.5
+	if mean > 0 {
+		fit = math.Max(0, 1-model.mae/mean)
+	}
+
+	seasons := buckets / p.seasonLength
+	coverage := math.Min(1, 0.85+0.05*float64(seasons-2))
+	horizonPenalty := math.Min(0.5, float64(horizon)/float64(p.period)*0.2)
+
+	return math.Max(0.1, math.Min(0.95, fit*coverage*(1-horizonPenalty)))
+}
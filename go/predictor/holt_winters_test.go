@@ -0,0 +1,118 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dailyUsage peaks at 800 at 14:00 UTC and bottoms out at 200 at 02:00 UTC
+func dailyUsage(t time.Time) float64 {
+	hours := float64(t.UTC().Hour()) + float64(t.UTC().Minute())/60
+	return 500 + 300*math.Sin(2*math.Pi*(hours-8)/24)
+}
+
+func dailyHistory(days int, step time.Duration) HistoricalData {
+	now := time.Now()
+	data := HistoricalData{ResourceType: "cpu", LastUpdated: now}
+	for ts := now.Add(-time.Duration(days) * 24 * time.Hour); !ts.After(now); ts = ts.Add(step) {
+		data.DataPoints = append(data.DataPoints, DataPoint{Timestamp: ts, Value: dailyUsage(ts)})
+	}
+	return data
+}
+
+func TestHoltWintersPredictor_AnticipatesDailyPeak(t *testing.T) {
+	predictor := NewDailyHoltWintersPredictor()
+	assert.Equal(t, PredictionMethodHoltWintersDaily, predictor.GetMethod())
+
+	data := dailyHistory(5, 5*time.Minute)
+	require.NoError(t, predictor.ValidateData(data))
+
+	horizons := []time.Duration{15 * time.Minute, 24 * time.Hour}
+	predictions, err := predictor.Predict(data, horizons)
+	require.NoError(t, err)
+	require.Len(t, predictions, 2)
+
+	// The next bucket follows the cycle rather than the last sample
+	next := predictions[0]
+	expected := dailyUsage(time.Now().Add(15 * time.Minute))
+	assert.InDelta(t, expected, next.Value, 40, "next bucket forecast")
+
+	// Within a day the forecast reaches the daily peak at 14:00
+	day := predictions[1]
+	assert.InDelta(t, 800, day.Value, 40, "daily peak")
+	peakAt := day.Metadata["peak_at"].(time.Time).UTC()
+	assert.InDelta(t, 14, float64(peakAt.Hour())+float64(peakAt.Minute())/60, 1, "peak time")
+
+	for _, pred := range predictions {
+		assert.Greater(t, pred.Confidence, 0.6)
+		assert.LessOrEqual(t, pred.Confidence, 0.95)
+		require.NotNil(t, pred.ConfidenceInterval)
+		assert.LessOrEqual(t, pred.ConfidenceInterval.Lower, pred.Value)
+		assert.GreaterOrEqual(t, pred.ConfidenceInterval.Upper, pred.Value)
+	}
+}
+
+func TestHoltWintersPredictor_NeedsTwoSeasons(t *testing.T) {
+	// A day of minute samples has enough points but not two daily seasons
+	err := NewDailyHoltWintersPredictor().ValidateData(dailyHistory(1, time.Minute))
+	assert.ErrorContains(t, err, "two seasons")
+
+	err = NewWeeklyHoltWintersPredictor().ValidateData(dailyHistory(7, 5*time.Minute))
+	assert.ErrorContains(t, err, "two seasons")
+}
+
+func TestHoltWintersPredictor_ResampleFillsGaps(t *testing.T) {
+	predictor := NewHoltWintersPredictor(PredictionMethodHoltWintersDaily, 4*time.Hour, 4)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	values, first := predictor.resample([]DataPoint{
+		{Timestamp: start.Add(3 * time.Hour), Value: 40},
+		{Timestamp: start.Add(30 * time.Minute), Value: 10},
+		{Timestamp: start, Value: 20},
+	})
+
+	assert.Equal(t, start, first)
+	assert.Equal(t, []float64{15, 23.333333333333332, 31.666666666666664, 40}, values)
+}
+
+func TestEngineSelectModel(t *testing.T) {
+	config := DefaultConfig()
+	config.HistoricalDataRetention = 4 * 24 * time.Hour
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+
+	for _, dp := range dailyHistory(3, 10*time.Minute).DataPoints {
+		require.NoError(t, engine.StoreDataPoint("default", "web", "app", "cpu", dp.Value, dp.Timestamp))
+	}
+
+	selection, err := engine.SelectModel(context.Background(), "default", "web", "app", "cpu", time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, selection.Best)
+
+	// Every model that could run was scored, the weekly one lacks history
+	assert.Contains(t, selection.Confidences, PredictionMethodHoltWintersDaily)
+	assert.NotContains(t, selection.Confidences, PredictionMethodHoltWintersWeekly)
+	for method, confidence := range selection.Confidences {
+		assert.LessOrEqual(t, confidence, selection.Best.Confidence, "method %s", method)
+	}
+	assert.Equal(t, selection.Confidences[selection.Best.Method], selection.Best.Confidence)
+}
@@ -89,7 +89,7 @@ func (s *MemoryStore) StoreHistoricalData(namespace, podName, container, resourc
 	})
 
 	// Remove old data points based on retention policy
-	cutoff := time.Now().Add(-s.config.HistoricalDataRetention)
+	cutoff := time.Now().Add(-s.config.retention())
 	var filteredData []DataPoint
 	for _, dp := range dataPoints {
 		if dp.Timestamp.After(cutoff) {
@@ -108,6 +108,26 @@ func (s *MemoryStore) StoreHistoricalData(namespace, podName, container, resourc
 	return nil
 }
 
+// appendHistoricalData merges a batch of data points, sorting once for the whole batch
+func (s *MemoryStore) appendHistoricalData(namespace, podName, container, resourceType string, points []DataPoint) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := s.makeKey(namespace, podName, container, resourceType)
+	cutoff := time.Now().Add(-s.config.retention())
+
+	dataPoints := s.historicalData[key]
+	for _, dp := range points {
+		if dp.Timestamp.After(cutoff) && !math.IsNaN(dp.Value) && !math.IsInf(dp.Value, 0) {
+			dataPoints = append(dataPoints, dp)
+		}
+	}
+	sort.Slice(dataPoints, func(i, j int) bool {
+		return dataPoints[i].Timestamp.Before(dataPoints[j].Timestamp)
+	})
+	s.historicalData[key] = dataPoints
+}
+
 // GetHistoricalData retrieves historical data for a resource
 func (s *MemoryStore) GetHistoricalData(namespace, podName, container, resourceType string, since time.Time) (HistoricalData, error) {
 	s.mutex.RLock()
@@ -244,7 +264,7 @@ func (s *MemoryStore) CleanupOldData(olderThan time.Time) error {
 
 // performCleanup performs automatic cleanup based on retention policies
 func (s *MemoryStore) performCleanup() {
-	historicalCutoff := time.Now().Add(-s.config.HistoricalDataRetention)
+	historicalCutoff := time.Now().Add(-s.config.retention())
 	predictionCutoff := time.Now().Add(-s.config.PredictionRetention)
 
 	// Use the earliest cutoff time
@@ -277,7 +297,7 @@ func (s *MemoryStore) GetStats() map[string]interface{} {
 		"totalDataPoints":     totalDataPoints,
 		"totalPredictions":    totalPredictions,
 		"lastCleanup":         s.lastCleanup,
-		"dataRetention":       s.config.HistoricalDataRetention.String(),
+		"dataRetention":       s.config.retention().String(),
 		"predictionRetention": s.config.PredictionRetention.String(),
 	}
 }
@@ -0,0 +1,108 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Percentile returns the p-th percentile (0-100) of values using linear
+// interpolation between the closest ranks. The input slice is not modified.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 100 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	weight := rank - float64(lower)
+	return sorted[lower]*(1-weight) + sorted[upper]*weight
+}
+
+// PercentileOf returns the p-th percentile of the data points' values
+func PercentileOf(dataPoints []DataPoint, p float64) float64 {
+	values := make([]float64, len(dataPoints))
+	for i, dp := range dataPoints {
+		values[i] = dp.Value
+	}
+	return Percentile(values, p)
+}
+
+// GetPercentile computes the p-th percentile of a container's stored usage over
+// the given window. It returns the percentile and the number of samples used,
+// and fails when fewer than the engine's MinDataPoints samples are available.
+func (e *Engine) GetPercentile(namespace, podName, container, resourceType string, p float64, window time.Duration) (float64, int, error) {
+	return e.GetPercentileAcrossPods(namespace, []string{podName}, container, resourceType, p, window)
+}
+
+// GetPercentileAcrossPods computes the p-th percentile over the combined history
+// of the same container in several pods, such as all replicas of a workload.
+func (e *Engine) GetPercentileAcrossPods(namespace string, podNames []string, container, resourceType string, p float64, window time.Duration) (float64, int, error) {
+	since := time.Now().Add(-window)
+
+	var dataPoints []DataPoint
+	for _, podName := range podNames {
+		history, err := e.store.GetHistoricalData(namespace, podName, container, resourceType, since)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to get historical data: %w", err)
+		}
+		dataPoints = append(dataPoints, history.DataPoints...)
+	}
+
+	samples := len(dataPoints)
+	if samples < e.config.MinDataPoints {
+		return 0, samples, fmt.Errorf("insufficient data points for percentile: have %d, need %d", samples, e.config.MinDataPoints)
+	}
+
+	return PercentileOf(dataPoints, p), samples, nil
+}
+
+// GetValuesAcrossPods returns the stored usage values of the same container in
+// several pods over the given window
+func (e *Engine) GetValuesAcrossPods(namespace string, podNames []string, container, resourceType string, window time.Duration) ([]float64, error) {
+	since := time.Now().Add(-window)
+
+	var values []float64
+	for _, podName := range podNames {
+		history, err := e.store.GetHistoricalData(namespace, podName, container, resourceType, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get historical data: %w", err)
+		}
+		for _, point := range history.DataPoints {
+			values = append(values, point.Value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5}
+
+	assert.Equal(t, 0.0, Percentile(nil, 95))
+	assert.Equal(t, 1.0, Percentile(values, 0))
+	assert.Equal(t, 10.0, Percentile(values, 100))
+	assert.InDelta(t, 5.5, Percentile(values, 50), 1e-9)
+	assert.InDelta(t, 9.55, Percentile(values, 95), 1e-9)
+
+	// Input must not be reordered
+	assert.Equal(t, 10.0, values[0])
+}
+
+func TestEngineGetPercentile(t *testing.T) {
+	config := DefaultConfig()
+	config.MinDataPoints = 5
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, engine.StoreDataPoint("ns", "pod", "app", "cpu", float64(100*(i+1)), now.Add(-time.Duration(i)*time.Minute)))
+	}
+
+	_, samples, err := engine.GetPercentile("ns", "pod", "app", "cpu", 95, time.Hour)
+	assert.Error(t, err, "expected insufficient data error")
+	assert.Equal(t, 4, samples)
+
+	// A sample outside the window is ignored
+	require.NoError(t, engine.StoreDataPoint("ns", "pod", "app", "cpu", 5000, now.Add(-2*time.Hour)))
+	require.NoError(t, engine.StoreDataPoint("ns", "pod", "app", "cpu", 500, now))
+
+	value, samples, err := engine.GetPercentile("ns", "pod", "app", "cpu", 50, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 5, samples)
+	assert.InDelta(t, 300, value, 1e-9)
+}
+
+func TestEngineGetPercentileAcrossPods(t *testing.T) {
+	config := DefaultConfig()
+	config.MinDataPoints = 4
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i, pod := range []string{"web-1", "web-2"} {
+		for j := 0; j < 2; j++ {
+			require.NoError(t, engine.StoreDataPoint("ns", pod, "app", "memory", float64(100*(2*i+j+1)), now.Add(-time.Duration(j)*time.Minute)))
+		}
+	}
+
+	// Neither replica alone has enough history
+	_, _, err = engine.GetPercentile("ns", "web-1", "app", "memory", 99, time.Hour)
+	assert.Error(t, err)
+
+	value, samples, err := engine.GetPercentileAcrossPods("ns", []string{"web-1", "web-2"}, "app", "memory", 100, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 4, samples)
+	assert.Equal(t, 400.0, value)
+}
@@ -294,6 +294,35 @@ func TestPredictionEngine(t *testing.T) {
 	assert.Equal(t, 2, stats["predictors"])
 }
 
+func TestEngineReconfigure(t *testing.T) {
+	config := DefaultConfig()
+	config.EnabledMethods = []PredictionMethod{PredictionMethodSimpleMovingAverage}
+	config.MinDataPoints = 3
+	config.HistoricalDataRetention = 6 * time.Hour
+
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+
+	baseTime := time.Now().Add(-3 * time.Hour)
+	for i := 0; i < 5; i++ {
+		err := engine.StoreDataPoint("test-ns", "test-pod", "test-container", "cpu", 100, baseTime.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+	request := PredictionRequest{Namespace: "test-ns", PodName: "test-pod", Container: "test-container", ResourceType: "cpu"}
+
+	response, err := engine.Predict(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, 5, response.DataPoints)
+
+	// The samples are older than the new retention of an hour
+	engine.Reconfigure(time.Hour, 0, 0.9)
+	response, err = engine.Predict(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, 0, response.DataPoints)
+	assert.Equal(t, 0.9, config.confidenceThreshold())
+	assert.Equal(t, time.Minute, config.collectionInterval(), "zero values keep the setting")
+}
+
 func TestDataValidation(t *testing.T) {
 	predictor := NewLinearRegressionPredictor()
 
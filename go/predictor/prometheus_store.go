@@ -0,0 +1,202 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"right-sizer/logger"
+)
+
+// prometheusBackfillTimeout bounds a single history backfill query
+const prometheusBackfillTimeout = 30 * time.Second
+
+// PrometheusStore is a prediction store that keeps recent data in memory and,
+// the first time a container's history is read, backfills it from Prometheus.
+// A restarted operator therefore starts from the usage Prometheus already
+// retains instead of an empty history.
+type PrometheusStore struct {
+	*MemoryStore
+	url        string
+	httpClient *http.Client
+
+	backfillMutex sync.Mutex
+	backfilled    map[string]bool
+}
+
+// NewPrometheusStore creates a store that backfills from config.PrometheusURL
+func NewPrometheusStore(config *Config) *PrometheusStore {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &PrometheusStore{
+		MemoryStore: NewMemoryStore(config),
+		url:         config.PrometheusURL,
+		httpClient:  &http.Client{Timeout: prometheusBackfillTimeout},
+		backfilled:  make(map[string]bool),
+	}
+}
+
+// GetHistoricalData returns stored history, backfilling it from Prometheus first if needed
+func (s *PrometheusStore) GetHistoricalData(namespace, podName, container, resourceType string, since time.Time) (HistoricalData, error) {
+	key := s.makeKey(namespace, podName, container, resourceType)
+
+	s.backfillMutex.Lock()
+	needsBackfill := !s.backfilled[key] && s.url != ""
+	s.backfilled[key] = true
+	s.backfillMutex.Unlock()
+
+	if needsBackfill {
+		start := time.Now().Add(-s.config.retention())
+
+		// Only fetch the span before the samples collected since startup to avoid duplicates
+		end := time.Now()
+		if existing, err := s.MemoryStore.GetHistoricalData(namespace, podName, container, resourceType, start); err == nil && len(existing.DataPoints) > 0 {
+			end = existing.DataPoints[0].Timestamp
+		}
+
+		points, err := s.queryRange(namespace, podName, container, resourceType, start, end)
+		if err != nil {
+			// Serve what is in memory; the next restart will try again
+			logger.Warn("Failed to backfill history for %s from Prometheus: %v", key, err)
+		}
+		var older []DataPoint
+		for _, dp := range points {
+			if dp.Timestamp.Before(end) {
+				older = append(older, dp)
+			}
+		}
+		s.appendHistoricalData(namespace, podName, container, resourceType, older)
+	}
+
+	return s.MemoryStore.GetHistoricalData(namespace, podName, container, resourceType, since)
+}
+
+// historyQuery returns the PromQL expression for a container's usage in the
+// units the engine stores: millicores for CPU and MB for memory
+func historyQuery(namespace, podName, container, resourceType string) (string, error) {
+	selector := fmt.Sprintf(`namespace="%s", pod="%s", container="%s"`, namespace, podName, container)
+	switch resourceType {
+	case "cpu":
+		return fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{%s}[5m])) * 1000`, selector), nil
+	case "memory":
+		return fmt.Sprintf(`sum(container_memory_working_set_bytes{%s}) / 1048576`, selector), nil
+	default:
+		return "", fmt.Errorf("unsupported resource type: %s", resourceType)
+	}
+}
+
+// queryRange fetches a container's usage between start and end from the Prometheus range API
+func (s *PrometheusStore) queryRange(namespace, podName, container, resourceType string, start, end time.Time) ([]DataPoint, error) {
+	query, err := historyQuery(namespace, podName, container, resourceType)
+	if err != nil {
+		return nil, err
+	}
+
+	step := s.config.collectionInterval()
+	if step <= 0 {
+		step = time.Minute
+	}
+	// Prometheus rejects queries returning more than 11000 points per series
+	if minStep := end.Sub(start) / 10000; step < minStep {
+		step = minStep
+	}
+
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+	ctx, cancel := context.WithTimeout(context.Background(), prometheusBackfillTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+"/api/v1/query_range?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Data   struct {
+			Result []struct {
+				Values [][]interface{} `json:"values"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.Status != "success" {
+		return nil, errors.New("prometheus range query failed")
+	}
+
+	var points []DataPoint
+	for _, series := range result.Data.Result {
+		for _, pair := range series.Values {
+			dp, ok := parseRangeSample(pair)
+			if !ok {
+				continue
+			}
+			dp.Namespace = namespace
+			dp.PodName = podName
+			dp.Container = container
+			points = append(points, dp)
+		}
+	}
+	return points, nil
+}
+
+// parseRangeSample converts a Prometheus [unixSeconds, "value"] pair into a data point
+func parseRangeSample(pair []interface{}) (DataPoint, bool) {
+	if len(pair) < 2 {
+		return DataPoint{}, false
+	}
+	ts, ok := pair[0].(float64)
+	if !ok {
+		return DataPoint{}, false
+	}
+	raw, ok := pair[1].(string)
+	if !ok {
+		return DataPoint{}, false
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return DataPoint{}, false
+	}
+
+	sec := int64(ts)
+	nsec := int64((ts - float64(sec)) * float64(time.Second))
+	return DataPoint{Timestamp: time.Unix(sec, nsec), Value: value}, true
+}
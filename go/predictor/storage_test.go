@@ -0,0 +1,102 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package predictor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	config := DefaultConfig()
+	config.StorageDriver = "file"
+	config.StoragePath = t.TempDir()
+
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, engine.StoreDataPoint("ns", "pod", "app", "cpu", float64(i*100), now.Add(-time.Duration(i)*time.Minute)))
+	}
+	require.NoError(t, engine.Close())
+
+	// A new engine on the same directory sees the earlier history
+	restarted, err := NewEngine(config)
+	require.NoError(t, err)
+	data, err := restarted.GetHistoricalData("ns", "pod", "app", "cpu", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, data.DataPoints, 5)
+	assert.Equal(t, 500.0, data.DataPoints[0].Value)
+}
+
+func TestFileStoreDropsExpiredData(t *testing.T) {
+	config := DefaultConfig()
+	config.StoragePath = t.TempDir()
+	config.HistoricalDataRetention = time.Hour
+
+	store, err := NewFileStore(config)
+	require.NoError(t, err)
+	require.NoError(t, store.StoreHistoricalData("ns", "pod", "app", "cpu", DataPoint{Timestamp: time.Now().Add(-30 * time.Minute), Value: 1}))
+	require.NoError(t, store.Flush())
+
+	// Reopen with a shorter retention than the stored sample's age
+	config.HistoricalDataRetention = 10 * time.Minute
+	reopened, err := NewFileStore(config)
+	require.NoError(t, err)
+	data, err := reopened.GetHistoricalData("ns", "pod", "app", "cpu", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, data.DataPoints)
+}
+
+func TestPrometheusStoreBackfill(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("query"))
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[%d,"120"],[%d,"180"]]}]}}`,
+			now.Add(-20*time.Minute).Unix(), now.Add(-10*time.Minute).Unix())
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.StorageDriver = "prometheus"
+	config.PrometheusURL = srv.URL
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+
+	// A sample collected since startup is kept alongside the backfilled history
+	require.NoError(t, engine.StoreDataPoint("ns", "pod", "app", "cpu", 150, now.Add(-time.Minute)))
+
+	data, err := engine.GetHistoricalData("ns", "pod", "app", "cpu", now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, data.DataPoints, 3)
+	assert.Equal(t, 120.0, data.DataPoints[0].Value)
+	assert.Equal(t, 150.0, data.DataPoints[2].Value)
+
+	// History is only backfilled once per container
+	_, err = engine.GetHistoricalData("ns", "pod", "app", "cpu", now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.True(t, strings.Contains(queries[0], `container="app"`))
+}
@@ -17,6 +17,7 @@ package predictor
 
 import (
 	"right-sizer/config"
+	"sync"
 	"time"
 )
 
@@ -47,6 +48,9 @@ const (
 	PredictionMethodSeasonal             PredictionMethod = "seasonal"
 	PredictionMethodEnsemble             PredictionMethod = "ensemble"
 	PredictionMethodSimpleMovingAverage  PredictionMethod = "simple_moving_average"
+	PredictionMethodHoltWintersDaily     PredictionMethod = "holt_winters_daily"
+	PredictionMethodHoltWintersWeekly    PredictionMethod = "holt_winters_weekly"
+	PredictionMethodExternal             PredictionMethod = "external"
 )
 
 // DataPoint represents a single historical data point
@@ -131,6 +135,8 @@ type PredictionStore interface {
 
 // Config holds configuration for the prediction system
 type Config struct {
+	mu sync.RWMutex // Guards the settings Reconfigure changes while the engine runs
+
 	// Data retention
 	HistoricalDataRetention time.Duration `json:"historicalDataRetention"` // How long to keep historical data
 	PredictionRetention     time.Duration `json:"predictionRetention"`     // How long to keep predictions
@@ -149,7 +155,15 @@ type Config struct {
 	PredictionTimeout        time.Duration `json:"predictionTimeout"`        // Timeout for prediction calculations
 
 	// Storage
-	StorageDriver string `json:"storageDriver"` // "memory", "prometheus", etc.
+	StorageDriver string        `json:"storageDriver"` // "memory", "file" or "prometheus"
+	StoragePath   string        `json:"storagePath"`   // Directory for the file driver, typically a PVC mount
+	FlushInterval time.Duration `json:"flushInterval"` // How often the file driver writes its state to disk
+	PrometheusURL string        `json:"prometheusURL"` // Prometheus server the prometheus driver backfills history from
+
+	// External model serving
+	ExternalPredictorURL     string        `json:"externalPredictorURL"`     // Endpoint of a user-provided forecasting model
+	ExternalPredictorTimeout time.Duration `json:"externalPredictorTimeout"` // Timeout of a forecast request
+	ExternalPredictorToken   string        `json:"-"`                        // Bearer token sent to the model endpoint
 }
 
 // DefaultConfig returns a sensible default configuration
@@ -171,10 +185,36 @@ func DefaultConfig() *Config {
 			PredictionMethodExponentialSmoothing,
 			PredictionMethodSimpleMovingAverage,
 			PredictionMethodSeasonal,
+			PredictionMethodHoltWintersDaily,
+			PredictionMethodHoltWintersWeekly,
 		},
 		ConfidenceThreshold:      config.DefaultPredictionConfidenceThreshold, // 60% confidence minimum
 		MaxConcurrentPredictions: 10,
 		PredictionTimeout:        30 * time.Second,
 		StorageDriver:            "memory",
+		StoragePath:              "/var/lib/right-sizer",
+		FlushInterval:            5 * time.Minute,
+		ExternalPredictorTimeout: 10 * time.Second,
 	}
 }
+
+// retention returns how long historical data is kept
+func (c *Config) retention() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.HistoricalDataRetention
+}
+
+// collectionInterval returns how often data points are collected
+func (c *Config) collectionInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.CollectionInterval
+}
+
+// confidenceThreshold returns the minimum confidence of the predictions used
+func (c *Config) confidenceThreshold() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ConfidenceThreshold
+}
@@ -0,0 +1,438 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package reports writes a periodic, human-readable report per namespace:
+// its most over- and under-provisioned workloads, the savings right-sizer
+// achieved and the incidents it saw. The narrative is written by the
+// configured LLM, or from a template without one, and reports are kept in
+// ConfigMaps in the operator namespace. Weekly and monthly reports of the
+// savings and efficiency of the cluster are rendered as CSV, JSON or HTML
+// and delivered by notification or to object storage.
+package reports
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/efficiency"
+	"right-sizer/internal/aiops"
+	narrative "right-sizer/internal/aiops/narratives"
+	"right-sizer/logger"
+	"right-sizer/metrics"
+	"right-sizer/notifications"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// overProvisionedPercent is the request utilization below which a workload is over-provisioned
+	overProvisionedPercent = 50
+	// underProvisionedPercent is the request utilization above which a workload is under-provisioned
+	underProvisionedPercent = 90
+	// recentIncidents is how many incidents a report lists
+	recentIncidents = 5
+	// bytesPerMB converts the metrics provider's MB to bytes
+	bytesPerMB = 1024 * 1024
+)
+
+// NarrativeSource tells how a report's narrative was written
+const (
+	NarrativeLLM      = "llm"
+	NarrativeTemplate = "template"
+)
+
+// Report summarizes a namespace over a period
+type Report struct {
+	Namespace        string           `json:"namespace"`
+	PeriodStart      time.Time        `json:"periodStart"`
+	PeriodEnd        time.Time        `json:"periodEnd"`
+	GeneratedAt      time.Time        `json:"generatedAt"`
+	Workloads        int              `json:"workloads"`
+	OverProvisioned  []WorkloadSizing `json:"overProvisioned"`
+	UnderProvisioned []WorkloadSizing `json:"underProvisioned"`
+	Savings          Savings          `json:"savings"`
+	Incidents        IncidentSummary  `json:"incidents"`
+	Narrative        string           `json:"narrative"`
+	NarrativeSource  string           `json:"narrativeSource"` // llm or template
+}
+
+// WorkloadSizing compares a workload's requests, summed over its replicas, with its usage
+type WorkloadSizing struct {
+	Workload           string  `json:"workload"` // Kind/name
+	Replicas           int     `json:"replicas"`
+	CPURequestMillis   int64   `json:"cpuRequestMillis"`
+	CPUUsageMillis     int64   `json:"cpuUsageMillis"`
+	MemoryRequestBytes int64   `json:"memoryRequestBytes"`
+	MemoryUsageBytes   int64   `json:"memoryUsageBytes"`
+	CPUUtilization     float64 `json:"cpuUtilization"`    // usage as a percentage of the request, 0 without a request
+	MemoryUtilization  float64 `json:"memoryUtilization"` // usage as a percentage of the request, 0 without a request
+	MonthlyWaste       float64 `json:"monthlyWaste"`      // monthly cost of the requested but unused resources
+}
+
+// Savings totals the resizes of the period
+type Savings struct {
+	Resizes              int     `json:"resizes"`
+	CPUMillis            int64   `json:"cpuMillis"`            // requests released, negative when they grew
+	MemoryBytes          int64   `json:"memoryBytes"`          // requests released, negative when they grew
+	MonthlyCost          float64 `json:"monthlyCost"`          // monthly cost of the released requests
+	PotentialMonthlyCost float64 `json:"potentialMonthlyCost"` // monthly cost still wasted by over-provisioned workloads
+	CostSource           string  `json:"costSource"`           // opencost, kubecost or estimate
+}
+
+// IncidentSummary counts the incidents of the period
+type IncidentSummary struct {
+	Total  int             `json:"total"`
+	Active int             `json:"active"`
+	ByType map[string]int  `json:"byType,omitempty"`
+	Recent []IncidentBrief `json:"recent,omitempty"`
+}
+
+// IncidentBrief describes a single incident
+type IncidentBrief struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Severity  string    `json:"severity"`
+	Status    string    `json:"status"`
+	Resource  string    `json:"resource"`
+	Message   string    `json:"message,omitempty"`
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// Generator gathers the facts of namespace reports and writes their narratives
+type Generator struct {
+	Incidents  *aiops.IncidentStore // Optional; without it reports list no incidents
+	Efficiency *efficiency.Tracker  // Optional; without it scheduled reports score no efficiency
+
+	// Notify sends scheduled reports through the notification channels; optional
+	Notify func(*notifications.Notification)
+
+	clientset  kubernetes.Interface
+	provider   metrics.Provider
+	auditStore *audit.Store
+	costClient *cost.Client
+	narrator   *narrative.NarrativeGenerator
+	namespace  string // where reports are stored
+
+	mu      sync.Mutex
+	lastRun time.Time
+}
+
+// NewGenerator creates a report generator. The audit store and cost client
+// may be nil; reports then show no achieved savings and estimated prices.
+func NewGenerator(clientset kubernetes.Interface, provider metrics.Provider, auditStore *audit.Store, costClient *cost.Client, narrator *narrative.NarrativeGenerator) *Generator {
+	return &Generator{
+		clientset:  clientset,
+		provider:   provider,
+		auditStore: auditStore,
+		costClient: costClient,
+		narrator:   narrator,
+		namespace:  operatorNamespace(),
+	}
+}
+
+// Generate builds the report of a namespace for the period ending now
+func (g *Generator) Generate(ctx context.Context, namespace string, period time.Duration, now time.Time) (*Report, error) {
+	report := &Report{
+		Namespace:   namespace,
+		PeriodStart: now.Add(-period),
+		PeriodEnd:   now,
+		GeneratedAt: now,
+	}
+
+	pricing := cost.EstimatedPricing()
+	if g.costClient != nil {
+		pricing = g.costClient.Pricing(ctx)
+	}
+
+	sizings, err := g.workloadSizings(ctx, namespace, pricing)
+	if err != nil {
+		return nil, err
+	}
+	report.Workloads = len(sizings)
+	report.OverProvisioned, report.UnderProvisioned = rankSizings(sizings, config.Get().Reports.TopWorkloads)
+	for _, sizing := range sizings {
+		report.Savings.PotentialMonthlyCost += sizing.MonthlyWaste
+	}
+
+	report.Savings.CostSource = pricing.Source
+	if err := g.addSavings(report, pricing); err != nil {
+		logger.Warn("Failed to total the savings of namespace %s: %v", namespace, err)
+	}
+	g.addIncidents(report)
+
+	report.Narrative, report.NarrativeSource = templateNarrative(report), NarrativeTemplate
+	if g.narrator != nil && g.narrator.Enabled() {
+		text, err := g.narrator.GenerateNamespaceReport(ctx, namespace, periodName(period), facts(report))
+		if err != nil {
+			logger.Warn("Failed to write the report narrative of namespace %s, using the template: %v", namespace, err)
+		} else {
+			report.Narrative, report.NarrativeSource = text, NarrativeLLM
+		}
+	}
+	return report, nil
+}
+
+// workloadSizings sums the requests and usage of the running pods of a namespace by workload
+func (g *Generator) workloadSizings(ctx context.Context, namespace string, pricing *cost.Pricing) ([]WorkloadSizing, error) {
+	pods, err := g.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in %s: %w", namespace, err)
+	}
+
+	byWorkload := make(map[string]*WorkloadSizing)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		var usage metrics.ContainerMetrics
+		if g.provider != nil {
+			if usage, err = g.provider.FetchContainerMetrics(ctx, pod.Namespace, pod.Name); err != nil {
+				logger.Debug("No metrics for pod %s/%s: %v", pod.Namespace, pod.Name, err)
+				continue
+			}
+		}
+
+		name := audit.WorkloadOf(pod)
+		sizing, ok := byWorkload[name]
+		if !ok {
+			sizing = &WorkloadSizing{Workload: name}
+			byWorkload[name] = sizing
+		}
+		sizing.Replicas++
+		for _, container := range pod.Spec.Containers {
+			sizing.CPURequestMillis += container.Resources.Requests.Cpu().MilliValue()
+			sizing.MemoryRequestBytes += container.Resources.Requests.Memory().Value()
+			if m, ok := usage[container.Name]; ok {
+				sizing.CPUUsageMillis += int64(m.CPUMilli)
+				sizing.MemoryUsageBytes += int64(m.MemMB * bytesPerMB)
+			}
+		}
+	}
+
+	sizings := make([]WorkloadSizing, 0, len(byWorkload))
+	for _, sizing := range byWorkload {
+		sizing.CPUUtilization = utilization(sizing.CPUUsageMillis, sizing.CPURequestMillis)
+		sizing.MemoryUtilization = utilization(sizing.MemoryUsageBytes, sizing.MemoryRequestBytes)
+		unusedCPU := max(sizing.CPURequestMillis-sizing.CPUUsageMillis, 0)
+		unusedMemory := max(sizing.MemoryRequestBytes-sizing.MemoryUsageBytes, 0)
+		sizing.MonthlyWaste = pricing.MonthlyCost(unusedCPU, unusedMemory)
+		sizings = append(sizings, *sizing)
+	}
+	return sizings, nil
+}
+
+// rankSizings returns the top over-provisioned workloads by wasted cost and
+// the top under-provisioned workloads by utilization
+func rankSizings(sizings []WorkloadSizing, top int) (over, under []WorkloadSizing) {
+	for _, s := range sizings {
+		if isOverProvisioned(s) {
+			over = append(over, s)
+		}
+		if isUnderProvisioned(s) {
+			under = append(under, s)
+		}
+	}
+	sort.Slice(over, func(i, j int) bool {
+		if over[i].MonthlyWaste != over[j].MonthlyWaste {
+			return over[i].MonthlyWaste > over[j].MonthlyWaste
+		}
+		return over[i].Workload < over[j].Workload
+	})
+	sort.Slice(under, func(i, j int) bool {
+		pi := max(under[i].CPUUtilization, under[i].MemoryUtilization)
+		pj := max(under[j].CPUUtilization, under[j].MemoryUtilization)
+		if pi != pj {
+			return pi > pj
+		}
+		return under[i].Workload < under[j].Workload
+	})
+	if top > 0 {
+		over = over[:min(top, len(over))]
+		under = under[:min(top, len(under))]
+	}
+	return over, under
+}
+
+// isOverProvisioned reports a workload using less than half of a resource it requests
+func isOverProvisioned(s WorkloadSizing) bool {
+	return (s.CPURequestMillis > 0 && s.CPUUtilization < overProvisionedPercent) ||
+		(s.MemoryRequestBytes > 0 && s.MemoryUtilization < overProvisionedPercent)
+}
+
+// isUnderProvisioned reports a workload using almost all of a resource it
+// requests, or using a resource without requesting it
+func isUnderProvisioned(s WorkloadSizing) bool {
+	return s.CPUUtilization > underProvisionedPercent || s.MemoryUtilization > underProvisionedPercent ||
+		(s.CPURequestMillis == 0 && s.CPUUsageMillis > 0) || (s.MemoryRequestBytes == 0 && s.MemoryUsageBytes > 0)
+}
+
+// addSavings totals the requests released by the successful resizes of the period
+func (g *Generator) addSavings(report *Report, pricing *cost.Pricing) error {
+	if g.auditStore == nil {
+		return nil
+	}
+	result, err := g.auditStore.Query(audit.Query{
+		Namespace: report.Namespace,
+		EventType: "ResourceChange",
+		Status:    "success",
+		Since:     report.PeriodStart,
+		Until:     report.PeriodEnd,
+		Limit:     1000,
+	})
+	if err != nil {
+		return err
+	}
+	for _, event := range result.Events {
+		if event.OldResources == nil || event.NewResources == nil {
+			continue
+		}
+		report.Savings.Resizes++
+		report.Savings.CPUMillis += event.OldResources.Requests.Cpu().MilliValue() - event.NewResources.Requests.Cpu().MilliValue()
+		report.Savings.MemoryBytes += event.OldResources.Requests.Memory().Value() - event.NewResources.Requests.Memory().Value()
+	}
+	report.Savings.MonthlyCost = pricing.MonthlyCost(report.Savings.CPUMillis, report.Savings.MemoryBytes)
+	return nil
+}
+
+// addIncidents summarizes the incidents of the namespace updated within the period
+func (g *Generator) addIncidents(report *Report) {
+	if g.Incidents == nil {
+		return
+	}
+	since := report.PeriodStart
+	incidents := g.Incidents.List(aiops.IncidentFilter{
+		ResourcePrefix: report.Namespace + "/",
+		UpdatedSince:   &since,
+		SortBy:         "first",
+	})
+
+	summary := IncidentSummary{Total: len(incidents), ByType: make(map[string]int)}
+	for i := range incidents {
+		inc := &incidents[i]
+		summary.ByType[string(inc.Type)]++
+		if inc.Status != aiops.StatusResolved {
+			summary.Active++
+		}
+		if len(summary.Recent) < recentIncidents {
+			summary.Recent = append(summary.Recent, IncidentBrief{
+				ID:        inc.ID,
+				Type:      string(inc.Type),
+				Severity:  string(inc.Severity),
+				Status:    string(inc.Status),
+				Resource:  inc.PrimaryResource,
+				Message:   inc.InitialMessage,
+				FirstSeen: inc.FirstSeen,
+			})
+		}
+	}
+	report.Incidents = summary
+}
+
+// facts lists the report's numbers for the LLM prompt
+func facts(r *Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "- Period: %s to %s\n", r.PeriodStart.Format(time.RFC3339), r.PeriodEnd.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Workloads: %d\n", r.Workloads)
+	for _, s := range r.OverProvisioned {
+		fmt.Fprintf(&b, "- Over-provisioned: %s\n", describeSizing(s))
+	}
+	for _, s := range r.UnderProvisioned {
+		fmt.Fprintf(&b, "- Under-provisioned: %s\n", describeSizing(s))
+	}
+	fmt.Fprintf(&b, "- Resizes applied: %d, releasing %dm CPU and %s memory worth $%.2f/month\n",
+		r.Savings.Resizes, r.Savings.CPUMillis, formatBytes(r.Savings.MemoryBytes), r.Savings.MonthlyCost)
+	fmt.Fprintf(&b, "- Still wasted by over-provisioning: $%.2f/month (prices: %s)\n", r.Savings.PotentialMonthlyCost, r.Savings.CostSource)
+	fmt.Fprintf(&b, "- Incidents: %d (%d active)\n", r.Incidents.Total, r.Incidents.Active)
+	for _, inc := range r.Incidents.Recent {
+		fmt.Fprintf(&b, "- Incident %s on %s (%s, %s): %s\n", inc.Type, inc.Resource, inc.Severity, inc.Status, inc.Message)
+	}
+	return b.String()
+}
+
+// templateNarrative writes the report narrative without an LLM
+func templateNarrative(r *Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Resource report for namespace %s, %s to %s.\n\n",
+		r.Namespace, r.PeriodStart.Format("2006-01-02"), r.PeriodEnd.Format("2006-01-02"))
+
+	fmt.Fprintf(&b, "%d workloads are running. ", r.Workloads)
+	if r.Savings.Resizes > 0 {
+		fmt.Fprintf(&b, "right-sizer applied %d resizes, releasing %dm CPU and %s memory worth $%.2f a month. ",
+			r.Savings.Resizes, r.Savings.CPUMillis, formatBytes(r.Savings.MemoryBytes), r.Savings.MonthlyCost)
+	} else {
+		b.WriteString("No resizes were applied. ")
+	}
+	fmt.Fprintf(&b, "Over-provisioned workloads still waste $%.2f a month.\n", r.Savings.PotentialMonthlyCost)
+
+	if len(r.OverProvisioned) > 0 {
+		b.WriteString("\nMost over-provisioned:\n")
+		for _, s := range r.OverProvisioned {
+			fmt.Fprintf(&b, "- %s\n", describeSizing(s))
+		}
+	}
+	if len(r.UnderProvisioned) > 0 {
+		b.WriteString("\nMost under-provisioned:\n")
+		for _, s := range r.UnderProvisioned {
+			fmt.Fprintf(&b, "- %s\n", describeSizing(s))
+		}
+	}
+	if r.Incidents.Total > 0 {
+		fmt.Fprintf(&b, "\n%d incidents, %d still active:\n", r.Incidents.Total, r.Incidents.Active)
+		for _, inc := range r.Incidents.Recent {
+			fmt.Fprintf(&b, "- %s on %s (%s)\n", inc.Type, inc.Resource, inc.Status)
+		}
+	}
+	return b.String()
+}
+
+func describeSizing(s WorkloadSizing) string {
+	return fmt.Sprintf("%s (%d replicas): CPU %dm of %dm requested (%.0f%%), memory %s of %s requested (%.0f%%), $%.2f/month unused",
+		s.Workload, s.Replicas,
+		s.CPUUsageMillis, s.CPURequestMillis, s.CPUUtilization,
+		formatBytes(s.MemoryUsageBytes), formatBytes(s.MemoryRequestBytes), s.MemoryUtilization,
+		s.MonthlyWaste)
+}
+
+func utilization(usage, request int64) float64 {
+	if request <= 0 {
+		return 0
+	}
+	return float64(usage) / float64(request) * 100
+}
+
+func formatBytes(b int64) string {
+	return fmt.Sprintf("%dMi", b/bytesPerMB)
+}
+
+// periodName names the period of a report in the LLM prompt
+func periodName(period time.Duration) string {
+	switch period {
+	case 24 * time.Hour:
+		return "daily"
+	case 7 * 24 * time.Hour:
+		return "weekly"
+	}
+	return "periodic"
+}
@@ -0,0 +1,223 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	narrative "right-sizer/internal/aiops/narratives"
+	"right-sizer/metrics"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// staticMetrics serves fixed usage per pod
+type staticMetrics map[string]metrics.ContainerMetrics
+
+func (s staticMetrics) FetchPodMetrics(ctx context.Context, namespace, podName string) (metrics.Metrics, error) {
+	return s[podName]["app"], nil
+}
+
+func (s staticMetrics) FetchContainerMetrics(ctx context.Context, namespace, podName string) (metrics.ContainerMetrics, error) {
+	return s[podName], nil
+}
+
+func reportTestPod(name, cpu, memory string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func newTestGenerator(t *testing.T, narrator *narrative.NarrativeGenerator) (*Generator, *fake.Clientset) {
+	config.Load()
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		reportTestPod("idle", "1", "1Gi"),
+		reportTestPod("busy", "100m", "128Mi"),
+	)
+	usage := staticMetrics{
+		"idle": {"app": {CPUMilli: 100, MemMB: 256}},
+		"busy": {"app": {CPUMilli: 99, MemMB: 120}},
+	}
+
+	store, err := audit.OpenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open audit store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	err = store.Append(audit.AuditEvent{
+		EventID:   "1",
+		Timestamp: time.Now().Add(-time.Hour),
+		EventType: "ResourceChange",
+		Namespace: "shop",
+		Status:    "success",
+		OldResources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		}},
+		NewResources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to append audit event: %v", err)
+	}
+
+	return NewGenerator(clientset, usage, store, nil, narrator), clientset
+}
+
+// TestGenerate verifies workloads are ranked and savings totalled
+func TestGenerate(t *testing.T) {
+	g, _ := newTestGenerator(t, nil)
+	report, err := g.Generate(context.Background(), "shop", 7*24*time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+
+	if report.Workloads != 2 {
+		t.Errorf("expected 2 workloads, got %d", report.Workloads)
+	}
+	if len(report.OverProvisioned) != 1 || report.OverProvisioned[0].Workload != "Pod/idle" {
+		t.Errorf("expected Pod/idle to be over-provisioned, got %+v", report.OverProvisioned)
+	}
+	if len(report.UnderProvisioned) != 1 || report.UnderProvisioned[0].Workload != "Pod/busy" {
+		t.Errorf("expected Pod/busy to be under-provisioned, got %+v", report.UnderProvisioned)
+	}
+	if report.OverProvisioned[0].MonthlyWaste <= 0 || report.Savings.PotentialMonthlyCost <= 0 {
+		t.Errorf("expected wasted cost, got %+v", report.Savings)
+	}
+
+	if report.Savings.Resizes != 1 || report.Savings.CPUMillis != 1000 || report.Savings.MemoryBytes != 1<<30 {
+		t.Errorf("unexpected savings %+v", report.Savings)
+	}
+	if report.Savings.MonthlyCost <= 0 || report.Savings.CostSource != "estimate" {
+		t.Errorf("expected estimated savings, got %+v", report.Savings)
+	}
+
+	if report.NarrativeSource != NarrativeTemplate || !strings.Contains(report.Narrative, "Pod/idle") {
+		t.Errorf("expected a template narrative naming Pod/idle, got %q", report.Narrative)
+	}
+}
+
+// TestGenerateLLMNarrative verifies the LLM writes the narrative and that
+// its failures fall back to the template
+func TestGenerateLLMNarrative(t *testing.T) {
+	fail := false
+	var prompt string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if fail {
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		}
+		var body struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Messages) != 1 {
+			t.Errorf("unexpected request body: %v", err)
+		}
+		prompt = body.Messages[0].Content
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":" The shop namespace is mostly right-sized. "}}]}`))
+	}))
+	defer llm.Close()
+
+	narrator := narrative.NewNarrativeGenerator(narrative.LLMConfig{APIKey: "secret", APIURL: llm.URL, ModelName: "test"})
+	g, _ := newTestGenerator(t, narrator)
+
+	report, err := g.Generate(context.Background(), "shop", 7*24*time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if report.NarrativeSource != NarrativeLLM || report.Narrative != "The shop namespace is mostly right-sized." {
+		t.Errorf("unexpected narrative %q from %s", report.Narrative, report.NarrativeSource)
+	}
+	if !strings.Contains(prompt, "weekly") || !strings.Contains(prompt, "Over-provisioned: Pod/idle") {
+		t.Errorf("expected the prompt to carry the facts, got %q", prompt)
+	}
+
+	fail = true
+	report, err = g.Generate(context.Background(), "shop", 7*24*time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("Generate() error: %v", err)
+	}
+	if report.NarrativeSource != NarrativeTemplate {
+		t.Errorf("expected the template narrative when the LLM fails, got %s", report.NarrativeSource)
+	}
+}
+
+// TestGenerateAllStoresReports verifies reports are kept in ConfigMaps and read back
+func TestGenerateAllStoresReports(t *testing.T) {
+	g, clientset := newTestGenerator(t, nil)
+	ctx := context.Background()
+
+	if report, err := g.Get(ctx, "shop"); err != nil || report != nil {
+		t.Fatalf("expected no report yet, got %+v, %v", report, err)
+	}
+
+	generated, err := g.GenerateAll(ctx)
+	if err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	if len(generated) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(generated))
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps("right-sizer").Get(ctx, "right-sizer-report-shop", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the report ConfigMap: %v", err)
+	}
+	if cm.Labels[namespaceLabel] != "shop" || cm.Data[narrativeKey] == "" {
+		t.Errorf("unexpected ConfigMap %+v", cm)
+	}
+
+	// Generating again replaces the stored report
+	if _, err := g.GenerateAll(ctx); err != nil {
+		t.Fatalf("GenerateAll() error: %v", err)
+	}
+	reports, err := g.List(ctx)
+	if err != nil || len(reports) != 1 || reports[0].Namespace != "shop" {
+		t.Fatalf("expected the shop report, got %+v, %v", reports, err)
+	}
+	report, err := g.Get(ctx, "shop")
+	if err != nil || report == nil || report.Workloads != 2 {
+		t.Errorf("expected the shop report, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,427 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"right-sizer/audit"
+	"right-sizer/config"
+	"right-sizer/cost"
+	"right-sizer/events"
+	"right-sizer/logger"
+	"right-sizer/notifications"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Periods of scheduled reports
+const (
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+)
+
+// Formats scheduled reports are rendered in
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+	FormatHTML = "html"
+)
+
+// deliveriesConfigMap records the end of the last period delivered per report period
+const deliveriesConfigMap = "right-sizer-report-deliveries"
+
+// PeriodicReport summarizes the savings and efficiency of every included
+// namespace over a calendar week or month
+type PeriodicReport struct {
+	Period      string             `json:"period"` // weekly or monthly
+	PeriodStart time.Time          `json:"periodStart"`
+	PeriodEnd   time.Time          `json:"periodEnd"`
+	GeneratedAt time.Time          `json:"generatedAt"`
+	CostSource  string             `json:"costSource"` // opencost, kubecost or estimate
+	Totals      NamespaceSummary   `json:"totals"`
+	Namespaces  []NamespaceSummary `json:"namespaces"`
+}
+
+// NamespaceSummary totals the resizes of a namespace over the period and
+// scores how much of its requests it uses. Efficiency is measured over the
+// efficiency window ending when the report is generated.
+type NamespaceSummary struct {
+	Namespace               string  `json:"namespace,omitempty"`
+	Workloads               int     `json:"workloads"`
+	Resizes                 int     `json:"resizes"`
+	CPUMillisReleased       int64   `json:"cpuMillisReleased"`   // negative when requests grew
+	MemoryBytesReleased     int64   `json:"memoryBytesReleased"` // negative when requests grew
+	MonthlySavings          float64 `json:"monthlySavings"`
+	PotentialMonthlySavings float64 `json:"potentialMonthlySavings"`
+	CPUEfficiency           float64 `json:"cpuEfficiency"`    // percent
+	MemoryEfficiency        float64 `json:"memoryEfficiency"` // percent
+	Efficiency              float64 `json:"efficiency"`       // percent
+}
+
+// periodBounds returns the last complete period before now: weekly periods
+// end on Monday 00:00 UTC and monthly periods on the first of the month
+func periodBounds(period string, now time.Time) (start, end time.Time, err error) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodWeekly:
+		end = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		return end.AddDate(0, 0, -7), end, nil
+	case PeriodMonthly:
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -1, 0), end, nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unknown report period %q", period)
+	}
+}
+
+// GeneratePeriodic builds the report of the last complete period before now
+func (g *Generator) GeneratePeriodic(ctx context.Context, period string, now time.Time) (*PeriodicReport, error) {
+	start, end, err := periodBounds(period, now)
+	if err != nil {
+		return nil, err
+	}
+	cfg := config.Get()
+	namespaces, err := g.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	pricing := cost.EstimatedPricing()
+	if g.costClient != nil {
+		pricing = g.costClient.Pricing(ctx)
+	}
+	scores := g.Efficiency.Scores(now, cfg.EfficiencyWindow)
+
+	report := &PeriodicReport{
+		Period:      period,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		GeneratedAt: now,
+		CostSource:  pricing.Source,
+		Namespaces:  []NamespaceSummary{},
+	}
+	for _, ns := range namespaces.Items {
+		if !cfg.IsNamespaceIncluded(ns.Name) {
+			continue
+		}
+		sizings, err := g.workloadSizings(ctx, ns.Name, pricing)
+		if err != nil {
+			logger.Warn("Failed to size the workloads of namespace %s for the %s report: %v", ns.Name, period, err)
+			continue
+		}
+		// The savings of a namespace come from the audit log, as in namespace reports
+		totals := &Report{Namespace: ns.Name, PeriodStart: start, PeriodEnd: end}
+		if err := g.addSavings(totals, pricing); err != nil {
+			logger.Warn("Failed to total the savings of namespace %s: %v", ns.Name, err)
+		}
+		if len(sizings) == 0 && totals.Savings.Resizes == 0 {
+			continue
+		}
+
+		summary := NamespaceSummary{
+			Namespace:           ns.Name,
+			Workloads:           len(sizings),
+			Resizes:             totals.Savings.Resizes,
+			CPUMillisReleased:   totals.Savings.CPUMillis,
+			MemoryBytesReleased: totals.Savings.MemoryBytes,
+			MonthlySavings:      totals.Savings.MonthlyCost,
+		}
+		for _, sizing := range sizings {
+			summary.PotentialMonthlySavings += sizing.MonthlyWaste
+		}
+		for _, score := range scores.Namespaces {
+			if score.Namespace == ns.Name {
+				summary.CPUEfficiency, summary.MemoryEfficiency, summary.Efficiency = score.CPU, score.Memory, score.Overall
+			}
+		}
+		report.Namespaces = append(report.Namespaces, summary)
+
+		report.Totals.Workloads += summary.Workloads
+		report.Totals.Resizes += summary.Resizes
+		report.Totals.CPUMillisReleased += summary.CPUMillisReleased
+		report.Totals.MemoryBytesReleased += summary.MemoryBytesReleased
+		report.Totals.MonthlySavings += summary.MonthlySavings
+		report.Totals.PotentialMonthlySavings += summary.PotentialMonthlySavings
+	}
+	report.Totals.CPUEfficiency = scores.Cluster.CPU
+	report.Totals.MemoryEfficiency = scores.Cluster.Memory
+	report.Totals.Efficiency = scores.Cluster.Overall
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	return report, nil
+}
+
+// Name describes the report, as in "weekly report 2026-10-05 to 2026-10-12"
+func (r *PeriodicReport) Name() string {
+	return fmt.Sprintf("%s report %s to %s", r.Period, r.PeriodStart.Format("2006-01-02"), r.PeriodEnd.Format("2006-01-02"))
+}
+
+// Summary is the report in a few sentences
+func (r *PeriodicReport) Summary() string {
+	t := r.Totals
+	return fmt.Sprintf("%d resizes across %d namespaces released %dm CPU and %s memory, saving $%.2f a month. "+
+		"Over-provisioned workloads still waste $%.2f a month (prices: %s). "+
+		"Requests are %.0f%% used for CPU and %.0f%% for memory.",
+		t.Resizes, len(r.Namespaces), t.CPUMillisReleased, formatBytes(t.MemoryBytesReleased), t.MonthlySavings,
+		t.PotentialMonthlySavings, r.CostSource, t.CPUEfficiency, t.MemoryEfficiency)
+}
+
+// Render returns the report in a format along with its content type
+func (r *PeriodicReport) Render(format string) ([]byte, string, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(r, "", "  ")
+		return data, "application/json", err
+	case FormatCSV:
+		data, err := r.csv()
+		return data, "text/csv", err
+	case FormatHTML:
+		var b bytes.Buffer
+		err := periodicHTML.Execute(&b, r)
+		return b.Bytes(), "text/html; charset=utf-8", err
+	default:
+		return nil, "", fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+// csv writes a row per namespace followed by the totals
+func (r *PeriodicReport) csv() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"namespace", "workloads", "resizes", "cpu_millis_released", "memory_bytes_released",
+		"monthly_savings", "potential_monthly_savings", "cpu_efficiency", "memory_efficiency", "efficiency"})
+	row := func(name string, s NamespaceSummary) {
+		_ = w.Write([]string{name, strconv.Itoa(s.Workloads), strconv.Itoa(s.Resizes),
+			strconv.FormatInt(s.CPUMillisReleased, 10), strconv.FormatInt(s.MemoryBytesReleased, 10),
+			strconv.FormatFloat(s.MonthlySavings, 'f', 2, 64), strconv.FormatFloat(s.PotentialMonthlySavings, 'f', 2, 64),
+			strconv.FormatFloat(s.CPUEfficiency, 'f', 1, 64), strconv.FormatFloat(s.MemoryEfficiency, 'f', 1, 64),
+			strconv.FormatFloat(s.Efficiency, 'f', 1, 64)})
+	}
+	for _, s := range r.Namespaces {
+		row(s.Namespace, s)
+	}
+	row("total", r.Totals)
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+var periodicHTML = template.Must(template.New("periodic").Funcs(template.FuncMap{
+	"bytes": formatBytes,
+	"title": func(s string) string { return strings.ToUpper(s[:1]) + s[1:] },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>right-sizer {{.Name}}</title>
+<style>
+body { font-family: sans-serif; color: #222; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{title .Period}} right-sizing report</h1>
+<p>{{.PeriodStart.Format "2006-01-02"}} to {{.PeriodEnd.Format "2006-01-02"}}, generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}.</p>
+<p>{{.Summary}}</p>
+<table>
+<thead><tr><th>Namespace</th><th>Workloads</th><th>Resizes</th><th>CPU released</th><th>Memory released</th><th>Savings / month</th><th>Potential / month</th><th>CPU efficiency</th><th>Memory efficiency</th></tr></thead>
+<tbody>
+{{- range .Namespaces}}
+<tr><td>{{.Namespace}}</td><td>{{.Workloads}}</td><td>{{.Resizes}}</td><td>{{.CPUMillisReleased}}m</td><td>{{bytes .MemoryBytesReleased}}</td><td>${{printf "%.2f" .MonthlySavings}}</td><td>${{printf "%.2f" .PotentialMonthlySavings}}</td><td>{{printf "%.0f" .CPUEfficiency}}%</td><td>{{printf "%.0f" .MemoryEfficiency}}%</td></tr>
+{{- end}}
+</tbody>
+{{- with .Totals}}
+<tfoot><tr><td>Total</td><td>{{.Workloads}}</td><td>{{.Resizes}}</td><td>{{.CPUMillisReleased}}m</td><td>{{bytes .MemoryBytesReleased}}</td><td>${{printf "%.2f" .MonthlySavings}}</td><td>${{printf "%.2f" .PotentialMonthlySavings}}</td><td>{{printf "%.0f" .CPUEfficiency}}%</td><td>{{printf "%.0f" .MemoryEfficiency}}%</td></tr></tfoot>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// deliverScheduled generates and delivers the reports of periods that ended
+// since the last delivery. The first check only records the current periods,
+// so a new installation does not report on a period it did not observe.
+func (g *Generator) deliverScheduled(ctx context.Context, now time.Time) {
+	settings := config.Get().Reporting
+	if !settings.Enabled() {
+		return
+	}
+	delivered, err := g.deliveries(ctx)
+	if err != nil {
+		logger.Warn("Failed to read scheduled report deliveries: %v", err)
+		return
+	}
+
+	changed := false
+	for _, period := range settings.Periods {
+		_, end, err := periodBounds(period, now)
+		if err != nil {
+			logger.Warn("Skipping scheduled report: %v", err)
+			continue
+		}
+		last, ok := delivered[period]
+		if ok && !end.After(last) {
+			continue
+		}
+		if ok {
+			report, err := g.GeneratePeriodic(ctx, period, now)
+			if err == nil {
+				err = g.deliver(ctx, report, settings)
+			}
+			if err != nil {
+				// Retried at the next check
+				logger.Warn("Failed to deliver the %s report: %v", period, err)
+				continue
+			}
+			logger.Info("📝 Delivered the %s", report.Name())
+		}
+		delivered[period] = end
+		changed = true
+	}
+	if changed {
+		if err := g.saveDeliveries(ctx, delivered); err != nil {
+			logger.Warn("Failed to record scheduled report deliveries: %v", err)
+		}
+	}
+}
+
+// deliver writes a report to the bucket, then sends it as a notification
+func (g *Generator) deliver(ctx context.Context, report *PeriodicReport, settings config.ReportingConfig) error {
+	type rendered struct {
+		format, contentType string
+		data                []byte
+	}
+	var files []rendered
+	for _, format := range settings.Formats {
+		data, contentType, err := report.Render(format)
+		if err != nil {
+			return err
+		}
+		files = append(files, rendered{format, contentType, data})
+	}
+
+	if settings.S3Bucket != "" {
+		bucket, err := g.reportBucket(ctx, settings)
+		if err != nil {
+			return err
+		}
+		dir := path.Join(strings.Trim(settings.S3Prefix, "/"), report.Period)
+		for _, file := range files {
+			key := path.Join(dir, report.PeriodStart.Format("2006-01-02")+"."+file.format)
+			if err := bucket.PutObject(ctx, key, file.contentType, file.data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if settings.Notify && g.Notify != nil {
+		n := &notifications.Notification{
+			Type:      notifications.ReportGenerated,
+			Severity:  events.SeverityInfo,
+			Report:    report.Name(),
+			Message:   report.Summary(),
+			Timestamp: report.GeneratedAt,
+		}
+		for _, file := range files {
+			n.Attachments = append(n.Attachments, notifications.Attachment{
+				Name:        fmt.Sprintf("right-sizer-%s-%s.%s", report.Period, report.PeriodStart.Format("2006-01-02"), file.format),
+				ContentType: file.contentType,
+				Data:        file.data,
+			})
+		}
+		g.Notify(n)
+	}
+	return nil
+}
+
+// reportBucket connects to the bucket with the credentials secret in the operator namespace
+func (g *Generator) reportBucket(ctx context.Context, settings config.ReportingConfig) (*audit.S3Sink, error) {
+	var accessKey, secretKey string
+	if settings.S3CredentialsSecret != "" {
+		secret, err := g.clientset.CoreV1().Secrets(g.namespace).Get(ctx, settings.S3CredentialsSecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read credentials secret %s: %w", settings.S3CredentialsSecret, err)
+		}
+		accessKey, secretKey = string(secret.Data["accessKeyId"]), string(secret.Data["secretAccessKey"])
+	}
+	return audit.NewS3Sink(config.AuditSinkConfig{
+		S3Bucket:    settings.S3Bucket,
+		S3Endpoint:  settings.S3Endpoint,
+		S3Region:    settings.S3Region,
+		S3PathStyle: settings.S3PathStyle,
+	}, accessKey, secretKey)
+}
+
+// deliveries reads the end of the last delivered period of each report period
+func (g *Generator) deliveries(ctx context.Context) (map[string]time.Time, error) {
+	delivered := make(map[string]time.Time)
+	cm, err := g.clientset.CoreV1().ConfigMaps(g.namespace).Get(ctx, deliveriesConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return delivered, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for period, value := range cm.Data {
+		end, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			logger.Warn("Ignoring invalid delivery %q of the %s report", value, period)
+			continue
+		}
+		delivered[period] = end
+	}
+	return delivered, nil
+}
+
+// saveDeliveries records the end of the last delivered period of each report period
+func (g *Generator) saveDeliveries(ctx context.Context, delivered map[string]time.Time) error {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deliveriesConfigMap,
+			Namespace: g.namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "right-sizer",
+				"app.kubernetes.io/component":  "report",
+			},
+		},
+		Data: make(map[string]string, len(delivered)),
+	}
+	for period, end := range delivered {
+		cm.Data[period] = end.UTC().Format(time.RFC3339)
+	}
+
+	configMaps := g.clientset.CoreV1().ConfigMaps(g.namespace)
+	_, err := configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/efficiency"
+	"right-sizer/notifications"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestPeriodBounds verifies reports cover the last complete calendar week or month
+func TestPeriodBounds(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		period     string
+		now        string
+		start, end string
+	}{
+		{PeriodWeekly, "2026-10-14T15:30:00Z", "2026-10-05T00:00:00Z", "2026-10-12T00:00:00Z"},
+		{PeriodWeekly, "2026-10-12T00:00:00Z", "2026-10-05T00:00:00Z", "2026-10-12T00:00:00Z"},
+		{PeriodWeekly, "2026-10-11T23:59:00Z", "2026-09-28T00:00:00Z", "2026-10-05T00:00:00Z"},
+		{PeriodMonthly, "2026-10-16T08:00:00Z", "2026-09-01T00:00:00Z", "2026-10-01T00:00:00Z"},
+		{PeriodMonthly, "2026-01-03T00:00:00Z", "2025-12-01T00:00:00Z", "2026-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		start, end, err := periodBounds(tt.period, day(tt.now))
+		if err != nil {
+			t.Fatalf("periodBounds(%s, %s) error: %v", tt.period, tt.now, err)
+		}
+		if !start.Equal(day(tt.start)) || !end.Equal(day(tt.end)) {
+			t.Errorf("periodBounds(%s, %s) = %s to %s, want %s to %s", tt.period, tt.now, start, end, tt.start, tt.end)
+		}
+	}
+	if _, _, err := periodBounds("daily", time.Now()); err == nil {
+		t.Error("expected an error for an unknown period")
+	}
+}
+
+// TestGeneratePeriodicRenders verifies the totals and efficiency of a
+// periodic report and its CSV, JSON and HTML renderings
+func TestGeneratePeriodicRenders(t *testing.T) {
+	g, _ := newTestGenerator(t, nil)
+	// The week after the audited resize reports on it
+	now := time.Now().Add(-time.Hour).AddDate(0, 0, 7)
+	g.Efficiency = efficiency.NewTracker()
+	g.Efficiency.Observe(efficiency.Sample{Time: now.Add(-time.Hour), Namespace: "shop", Workload: "Pod/idle", Pod: "idle",
+		Container: "app", CPURequest: 1000, CPUUsage: 250, MemRequestMB: 1024, MemUsageMB: 512})
+
+	report, err := g.GeneratePeriodic(context.Background(), PeriodWeekly, now)
+	if err != nil {
+		t.Fatalf("GeneratePeriodic() error: %v", err)
+	}
+	if len(report.Namespaces) != 1 || report.Namespaces[0].Namespace != "shop" {
+		t.Fatalf("expected a summary of namespace shop, got %+v", report.Namespaces)
+	}
+	shop := report.Namespaces[0]
+	if shop.Workloads != 2 || shop.Resizes != 1 || shop.CPUMillisReleased != 1000 || shop.MemoryBytesReleased != 1<<30 {
+		t.Errorf("unexpected summary %+v", shop)
+	}
+	if shop.MonthlySavings <= 0 || shop.PotentialMonthlySavings <= 0 {
+		t.Errorf("expected savings and potential savings, got %+v", shop)
+	}
+	if shop.CPUEfficiency != 25 || shop.MemoryEfficiency != 50 || report.Totals.Efficiency != 37.5 {
+		t.Errorf("unexpected efficiency %+v, totals %+v", shop, report.Totals)
+	}
+	if report.Totals.Resizes != 1 || report.Totals.MonthlySavings != shop.MonthlySavings {
+		t.Errorf("unexpected totals %+v", report.Totals)
+	}
+
+	data, contentType, err := report.Render(FormatCSV)
+	if err != nil || contentType != "text/csv" {
+		t.Fatalf("Render(csv) = %s, %v", contentType, err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil || len(rows) != 3 || rows[1][0] != "shop" || rows[1][2] != "1" || rows[2][0] != "total" {
+		t.Errorf("unexpected CSV %q: %v", data, err)
+	}
+
+	data, _, err = report.Render(FormatJSON)
+	if err != nil {
+		t.Fatalf("Render(json) error: %v", err)
+	}
+	var decoded PeriodicReport
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Period != PeriodWeekly || decoded.Totals.Resizes != 1 {
+		t.Errorf("unexpected JSON %s: %v", data, err)
+	}
+
+	data, contentType, err = report.Render(FormatHTML)
+	if err != nil || !strings.HasPrefix(contentType, "text/html") {
+		t.Fatalf("Render(html) = %s, %v", contentType, err)
+	}
+	if !strings.Contains(string(data), "<h1>Weekly right-sizing report</h1>") || !strings.Contains(string(data), "<td>shop</td>") {
+		t.Errorf("unexpected HTML:\n%s", data)
+	}
+
+	if _, _, err := report.Render("pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+// TestDeliverScheduled verifies the first check only records the current
+// period and that a later period is written to the bucket and notified once
+func TestDeliverScheduled(t *testing.T) {
+	g, clientset := newTestGenerator(t, nil)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	objects := map[string]string{}
+	bucket := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			t.Errorf("unexpected %s request with authorization %q", r.Method, r.Header.Get("Authorization"))
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		objects[r.URL.Path] = r.Header.Get("Content-Type")
+		mu.Unlock()
+	}))
+	defer bucket.Close()
+
+	_, err := clientset.CoreV1().Secrets(g.namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "report-bucket", Namespace: g.namespace},
+		Data:       map[string][]byte{"accessKeyId": []byte("key"), "secretAccessKey": []byte("secret")},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to create secret: %v", err)
+	}
+
+	config.Get().SetReporting(config.ReportingConfig{
+		Periods:             []string{PeriodWeekly},
+		Formats:             []string{FormatCSV, FormatHTML},
+		Notify:              true,
+		S3Bucket:            "reports",
+		S3Endpoint:          bucket.URL,
+		S3PathStyle:         true,
+		S3CredentialsSecret: "report-bucket",
+	})
+	t.Cleanup(func() { config.Get().SetReporting(config.GetDefaults().Reporting) })
+
+	var sent []*notifications.Notification
+	g.Notify = func(n *notifications.Notification) { sent = append(sent, n) }
+
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	g.deliverScheduled(ctx, now)
+	if len(sent) != 0 || len(objects) != 0 {
+		t.Fatalf("expected the first check to deliver nothing, got %d notifications and %d objects", len(sent), len(objects))
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(g.namespace).Get(ctx, deliveriesConfigMap, metav1.GetOptions{})
+	if err != nil || cm.Data[PeriodWeekly] != "2026-10-12T00:00:00Z" {
+		t.Fatalf("expected the current week to be recorded, got %v, %v", cm, err)
+	}
+
+	// Later in the same week nothing is due
+	g.deliverScheduled(ctx, now.Add(48*time.Hour))
+	if len(sent) != 0 {
+		t.Fatalf("expected no report within the same week, got %d", len(sent))
+	}
+
+	g.deliverScheduled(ctx, now.AddDate(0, 0, 7))
+	if len(sent) != 1 {
+		t.Fatalf("expected one report notification, got %d", len(sent))
+	}
+	n := sent[0]
+	if n.Type != notifications.ReportGenerated || n.Report != "weekly report 2026-10-12 to 2026-10-19" || len(n.Attachments) != 2 {
+		t.Errorf("unexpected notification %+v", n)
+	}
+	if n.Attachments[0].Name != "right-sizer-weekly-2026-10-12.csv" || n.Attachments[1].ContentType != "text/html; charset=utf-8" {
+		t.Errorf("unexpected attachments %+v", n.Attachments)
+	}
+	for key, contentType := range map[string]string{
+		"/reports/right-sizer/reports/weekly/2026-10-12.csv":  "text/csv",
+		"/reports/right-sizer/reports/weekly/2026-10-12.html": "text/html; charset=utf-8",
+	} {
+		if objects[key] != contentType {
+			t.Errorf("object %s has content type %q, want %q (objects: %v)", key, objects[key], contentType, objects)
+		}
+	}
+
+	g.deliverScheduled(ctx, now.AddDate(0, 0, 8))
+	if len(sent) != 1 {
+		t.Errorf("expected the report to be delivered once, got %d notifications", len(sent))
+	}
+}
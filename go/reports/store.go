@@ -0,0 +1,209 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"right-sizer/config"
+	"right-sizer/logger"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// configMapPrefix names the ConfigMap a namespace's report is kept in
+	configMapPrefix = "right-sizer-report-"
+	// namespaceLabel marks report ConfigMaps with the namespace they cover
+	namespaceLabel = "rightsizer.io/report-namespace"
+	// reportKey holds the JSON report, narrativeKey the narrative alone
+	reportKey    = "report.json"
+	narrativeKey = "narrative.txt"
+	// checkInterval is how often the schedule checks whether reports are due
+	checkInterval = time.Hour
+)
+
+// Start generates reports of every included namespace each report interval
+// until the context is cancelled. Reports that are already current when the
+// operator starts are not generated again.
+func (g *Generator) Start(ctx context.Context) {
+	if existing, err := g.List(ctx); err == nil {
+		g.mu.Lock()
+		for i := range existing {
+			if existing[i].GeneratedAt.After(g.lastRun) {
+				g.lastRun = existing[i].GeneratedAt
+			}
+		}
+		g.mu.Unlock()
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	logger.Info("📝 Namespace reports scheduled every %v", config.Get().Reports.Interval)
+	for {
+		settings := config.Get().Reports
+		g.mu.Lock()
+		due := settings.Enabled && time.Since(g.lastRun) >= settings.Interval
+		g.mu.Unlock()
+		if due {
+			if _, err := g.GenerateAll(ctx); err != nil {
+				logger.Warn("Failed to generate namespace reports: %v", err)
+			}
+		}
+		g.deliverScheduled(ctx, time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// GenerateAll generates and stores the reports of every included namespace
+// that runs pods
+func (g *Generator) GenerateAll(ctx context.Context) ([]Report, error) {
+	cfg := config.Get()
+	namespaces, err := g.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	now := time.Now()
+	var generated []Report
+	for _, ns := range namespaces.Items {
+		if !cfg.IsNamespaceIncluded(ns.Name) {
+			continue
+		}
+		report, err := g.GenerateAndStore(ctx, ns.Name, now)
+		if err != nil {
+			logger.Warn("Failed to generate the report of namespace %s: %v", ns.Name, err)
+			continue
+		}
+		if report.Workloads > 0 {
+			generated = append(generated, *report)
+		}
+	}
+
+	g.mu.Lock()
+	g.lastRun = now
+	g.mu.Unlock()
+	logger.Info("📝 Generated %d namespace reports", len(generated))
+	return generated, nil
+}
+
+// GenerateAndStore generates the report of a namespace over the report
+// interval and stores it. Namespaces without workloads are not stored.
+func (g *Generator) GenerateAndStore(ctx context.Context, namespace string, now time.Time) (*Report, error) {
+	report, err := g.Generate(ctx, namespace, config.Get().Reports.Interval, now)
+	if err != nil {
+		return nil, err
+	}
+	if report.Workloads == 0 {
+		return report, nil
+	}
+	if err := g.save(ctx, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// save writes a report to its ConfigMap, replacing the previous one
+func (g *Generator) save(ctx context.Context, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      configMapPrefix + report.Namespace,
+			Namespace: g.namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "right-sizer",
+				"app.kubernetes.io/component":  "report",
+				namespaceLabel:                 report.Namespace,
+			},
+		},
+		Data: map[string]string{
+			reportKey:    string(data),
+			narrativeKey: report.Narrative,
+		},
+	}
+
+	configMaps := g.clientset.CoreV1().ConfigMaps(g.namespace)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store the report of namespace %s: %w", report.Namespace, err)
+	}
+	return nil
+}
+
+// Get returns the stored report of a namespace, nil when there is none
+func (g *Generator) Get(ctx context.Context, namespace string) (*Report, error) {
+	cm, err := g.clientset.CoreV1().ConfigMaps(g.namespace).Get(ctx, configMapPrefix+namespace, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeReport(cm)
+}
+
+// List returns the stored reports of all namespaces, sorted by namespace
+func (g *Generator) List(ctx context.Context) ([]Report, error) {
+	configMaps, err := g.clientset.CoreV1().ConfigMaps(g.namespace).List(ctx, metav1.ListOptions{LabelSelector: namespaceLabel})
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(configMaps.Items))
+	for i := range configMaps.Items {
+		report, err := decodeReport(&configMaps.Items[i])
+		if err != nil {
+			logger.Warn("Skipping report ConfigMap %s: %v", configMaps.Items[i].Name, err)
+			continue
+		}
+		reports = append(reports, *report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Namespace < reports[j].Namespace })
+	return reports, nil
+}
+
+func decodeReport(cm *corev1.ConfigMap) (*Report, error) {
+	var report Report
+	if err := json.Unmarshal([]byte(cm.Data[reportKey]), &report); err != nil {
+		return nil, fmt.Errorf("invalid report: %w", err)
+	}
+	return &report, nil
+}
+
+// operatorNamespace returns the namespace the operator runs in
+func operatorNamespace() string {
+	if namespace := os.Getenv("OPERATOR_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return "right-sizer"
+}
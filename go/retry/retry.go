@@ -51,6 +51,11 @@ func (r *RetryableError) Error() string {
 	return r.Err.Error()
 }
 
+// Unwrap returns the wrapped error, so API errors can still be matched
+func (r *RetryableError) Unwrap() error {
+	return r.Err
+}
+
 // IsRetryable returns true if the error can be retried
 func (r *RetryableError) IsRetryable() bool {
 	return r.Retryable
@@ -147,6 +152,9 @@ func (r *Retryer) DoWithContext(ctx context.Context, operation string, fn RetryF
 		// Check if we've exhausted retries
 		if attempt >= r.config.MaxRetries {
 			logger.Error("Operation %s failed after %d attempts: %v", operation, attempt+1, err)
+			if r.metrics != nil {
+				r.metrics.RecordRetryExhausted(operation)
+			}
 			break
 		}
 
@@ -162,6 +170,9 @@ func (r *Retryer) DoWithContext(ctx context.Context, operation string, fn RetryF
 		nextDelay := r.calculateDelay(delay, attempt)
 		logger.Debug("Operation %s failed (attempt %d/%d), retrying in %v: %v",
 			operation, attempt+1, r.config.MaxRetries+1, nextDelay, err)
+		if r.metrics != nil {
+			r.metrics.RecordRetryBackoff(operation, nextDelay)
+		}
 
 		// Sleep before retry
 		select {
@@ -248,19 +259,37 @@ type CircuitBreaker struct {
 	failureCount    int
 	successCount    int
 	lastFailureTime time.Time
+	lastTransition  time.Time
 	mutex           sync.RWMutex
 	metrics         *metrics.OperatorMetrics
 	name            string
 }
 
+// CircuitBreakerStatus is a snapshot of a circuit breaker
+type CircuitBreakerStatus struct {
+	Name           string     `json:"name"`
+	State          string     `json:"state"`
+	Failures       int        `json:"failures"`
+	Successes      int        `json:"successes"`
+	LastFailure    *time.Time `json:"lastFailure,omitempty"`
+	LastTransition time.Time  `json:"lastTransition"`
+	// RetryAt is when an open breaker lets the next call through
+	RetryAt *time.Time `json:"retryAt,omitempty"`
+}
+
 // NewCircuitBreaker creates a new circuit breaker
 func NewCircuitBreaker(name string, config CircuitBreakerConfig, metrics *metrics.OperatorMetrics) *CircuitBreaker {
-	return &CircuitBreaker{
-		config:  config,
-		state:   StateClosed,
-		metrics: metrics,
-		name:    name,
+	cb := &CircuitBreaker{
+		config:         config,
+		state:          StateClosed,
+		lastTransition: time.Now(),
+		metrics:        metrics,
+		name:           name,
 	}
+	if metrics != nil {
+		metrics.SetCircuitBreakerState(name, int(StateClosed))
+	}
+	return cb
 }
 
 // Execute executes the function through the circuit breaker
@@ -270,11 +299,10 @@ func (cb *CircuitBreaker) Execute(fn RetryFunc) error {
 	})
 }
 
-// ExecuteWithContext executes the function through the circuit breaker with context
+// ExecuteWithContext executes the function through the circuit breaker with
+// context. Errors marked non-retryable are returned without counting as
+// failures: they say nothing about the health of the callee.
 func (cb *CircuitBreaker) ExecuteWithContext(ctx context.Context, fn RetryFuncWithContext) error {
-	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
-
 	// Check if context is already cancelled
 	select {
 	case <-ctx.Done():
@@ -282,22 +310,30 @@ func (cb *CircuitBreaker) ExecuteWithContext(ctx context.Context, fn RetryFuncWi
 	default:
 	}
 
+	cb.mutex.Lock()
 	// Check if circuit should transition to half-open
 	if cb.state == StateOpen && time.Since(cb.lastFailureTime) >= cb.config.RecoveryTimeout {
-		cb.state = StateHalfOpen
 		cb.successCount = 0
-		logger.Info("Circuit breaker %s transitioned to HALF_OPEN", cb.name)
+		cb.transition(StateHalfOpen)
 	}
 
 	// If circuit is open, fail fast
 	if cb.state == StateOpen {
+		cb.mutex.Unlock()
 		return NewRetryableError(fmt.Errorf("circuit breaker %s is OPEN", cb.name), false)
 	}
+	cb.mutex.Unlock()
 
-	// Execute the function
+	// Execute the function without holding the lock, so the state can be
+	// read while a call is in flight
 	err := fn(ctx)
+
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
 	if err != nil {
-		cb.onFailure()
+		if retryableErr, ok := err.(*RetryableError); !ok || retryableErr.IsRetryable() {
+			cb.onFailure()
+		}
 		return err
 	}
 
@@ -312,9 +348,8 @@ func (cb *CircuitBreaker) onSuccess() {
 	if cb.state == StateHalfOpen {
 		cb.successCount++
 		if cb.successCount >= cb.config.SuccessThreshold {
-			cb.state = StateClosed
 			cb.successCount = 0
-			logger.Info("Circuit breaker %s transitioned to CLOSED", cb.name)
+			cb.transition(StateClosed)
 		}
 	}
 }
@@ -325,11 +360,30 @@ func (cb *CircuitBreaker) onFailure() {
 	cb.lastFailureTime = time.Now()
 
 	if cb.state == StateClosed && cb.failureCount >= cb.config.FailureThreshold {
-		cb.state = StateOpen
-		logger.Warn("Circuit breaker %s transitioned to OPEN after %d failures", cb.name, cb.failureCount)
+		cb.transition(StateOpen)
 	} else if cb.state == StateHalfOpen {
-		cb.state = StateOpen
+		cb.transition(StateOpen)
+	}
+}
+
+// transition moves the breaker to state and publishes the change; the
+// caller holds the lock
+func (cb *CircuitBreaker) transition(state CircuitBreakerState) {
+	from := cb.state
+	cb.state = state
+	cb.lastTransition = time.Now()
+
+	switch {
+	case state == StateOpen && from == StateHalfOpen:
 		logger.Warn("Circuit breaker %s transitioned back to OPEN from HALF_OPEN", cb.name)
+	case state == StateOpen:
+		logger.Warn("Circuit breaker %s transitioned to OPEN after %d failures", cb.name, cb.failureCount)
+	default:
+		logger.Info("Circuit breaker %s transitioned to %s", cb.name, state)
+	}
+	if cb.metrics != nil {
+		cb.metrics.SetCircuitBreakerState(cb.name, int(state))
+		cb.metrics.RecordCircuitBreakerTransition(cb.name, from.String(), state.String())
 	}
 }
 
@@ -347,10 +401,33 @@ func (cb *CircuitBreaker) GetStats() (state CircuitBreakerState, failures int, s
 	return cb.state, cb.failureCount, cb.successCount
 }
 
+// GetStatus returns a snapshot of the circuit breaker
+func (cb *CircuitBreaker) GetStatus() CircuitBreakerStatus {
+	cb.mutex.RLock()
+	defer cb.mutex.RUnlock()
+	status := CircuitBreakerStatus{
+		Name:           cb.name,
+		State:          cb.state.String(),
+		Failures:       cb.failureCount,
+		Successes:      cb.successCount,
+		LastTransition: cb.lastTransition,
+	}
+	if !cb.lastFailureTime.IsZero() {
+		lastFailure := cb.lastFailureTime
+		status.LastFailure = &lastFailure
+	}
+	if cb.state == StateOpen {
+		retryAt := cb.lastFailureTime.Add(cb.config.RecoveryTimeout)
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
 // RetryWithCircuitBreaker combines retry logic with circuit breaker
 type RetryWithCircuitBreaker struct {
 	retryer        *Retryer
 	circuitBreaker *CircuitBreaker
+	targets        *CircuitBreakers // per-target breakers of ExecuteForTarget
 }
 
 // NewRetryWithCircuitBreaker creates a new retry handler with circuit breaker
@@ -358,6 +435,7 @@ func NewRetryWithCircuitBreaker(name string, retryConfig Config, cbConfig Circui
 	return &RetryWithCircuitBreaker{
 		retryer:        New(retryConfig, metrics),
 		circuitBreaker: NewCircuitBreaker(name, cbConfig, metrics),
+		targets:        NewCircuitBreakers(name, cbConfig, metrics),
 	}
 }
 
@@ -380,6 +458,11 @@ func (r *RetryWithCircuitBreaker) GetCircuitBreakerState() CircuitBreakerState {
 	return r.circuitBreaker.GetState()
 }
 
+// GetCircuitBreakerStatus returns a snapshot of the circuit breaker
+func (r *RetryWithCircuitBreaker) GetCircuitBreakerStatus() CircuitBreakerStatus {
+	return r.circuitBreaker.GetStatus()
+}
+
 // IsRetryableKubernetesError determines if a Kubernetes error should be retried
 func IsRetryableKubernetesError(err error) bool {
 	if err == nil {
@@ -9,7 +9,11 @@ import (
 
 	"right-sizer/metrics"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRetryableError(t *testing.T) {
@@ -275,6 +279,48 @@ func TestCircuitBreaker_GetStats(t *testing.T) {
 	assert.Equal(t, 0, successes)
 }
 
+func TestCircuitBreaker_Metrics(t *testing.T) {
+	m, err := metrics.NewOperatorMetricsWithRegisterer(prometheus.NewRegistry())
+	require.NoError(t, err)
+	config := CircuitBreakerConfig{FailureThreshold: 1, RecoveryTimeout: 20 * time.Millisecond, SuccessThreshold: 1}
+	cb := NewCircuitBreaker("resize", config, m)
+	assert.Equal(t, float64(StateClosed), testutil.ToFloat64(m.CircuitBreakerState.WithLabelValues("resize")))
+
+	// Non-retryable errors say nothing about the callee and keep the breaker closed
+	cb.Execute(func() error { return NewRetryableError(errors.New("invalid"), false) })
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	cb.Execute(func() error { return errors.New("failure") })
+	assert.Equal(t, float64(StateOpen), testutil.ToFloat64(m.CircuitBreakerState.WithLabelValues("resize")))
+	status := cb.GetStatus()
+	assert.Equal(t, "OPEN", status.State)
+	require.NotNil(t, status.LastFailure)
+	require.NotNil(t, status.RetryAt)
+	assert.Equal(t, status.LastFailure.Add(config.RecoveryTimeout), *status.RetryAt)
+
+	time.Sleep(30 * time.Millisecond)
+	require.NoError(t, cb.Execute(func() error { return nil }))
+	assert.Equal(t, float64(StateClosed), testutil.ToFloat64(m.CircuitBreakerState.WithLabelValues("resize")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.CircuitBreakerTransitions.WithLabelValues("resize", "CLOSED", "OPEN")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.CircuitBreakerTransitions.WithLabelValues("resize", "OPEN", "HALF_OPEN")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.CircuitBreakerTransitions.WithLabelValues("resize", "HALF_OPEN", "CLOSED")))
+	assert.Nil(t, cb.GetStatus().RetryAt)
+}
+
+func TestRetryer_Metrics(t *testing.T) {
+	m, err := metrics.NewOperatorMetricsWithRegisterer(prometheus.NewRegistry())
+	require.NoError(t, err)
+	r := New(Config{MaxRetries: 2, InitialDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond, BackoffFactor: 2}, m)
+
+	assert.Error(t, r.Do("resize", func() error { return errors.New("failure") }))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RetryExhaustedTotal.WithLabelValues("resize")))
+
+	// Both waits between the three attempts are observed
+	var backoff dto.Metric
+	require.NoError(t, m.RetryBackoffSeconds.WithLabelValues("resize").(prometheus.Histogram).Write(&backoff))
+	assert.Equal(t, uint64(2), backoff.GetHistogram().GetSampleCount())
+}
+
 func TestCircuitBreakerState_String(t *testing.T) {
 	assert.Equal(t, "CLOSED", StateClosed.String())
 	assert.Equal(t, "OPEN", StateOpen.String())
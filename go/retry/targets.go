@@ -0,0 +1,130 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package retry
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"right-sizer/metrics"
+)
+
+// CircuitBreakers keeps a circuit breaker per target, such as a namespace or
+// a node, so the failures of one target do not stop calls to the others
+type CircuitBreakers struct {
+	name     string
+	config   CircuitBreakerConfig
+	metrics  *metrics.OperatorMetrics
+	mutex    sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakers creates a set of per-target circuit breakers
+func NewCircuitBreakers(name string, config CircuitBreakerConfig, metrics *metrics.OperatorMetrics) *CircuitBreakers {
+	return &CircuitBreakers{
+		name:     name,
+		config:   config,
+		metrics:  metrics,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the circuit breaker of target, creating it on first use. It is
+// named after the set and the target, e.g. pod-resize/payments.
+func (c *CircuitBreakers) Get(target string) *CircuitBreaker {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if cb, ok := c.breakers[target]; ok {
+		return cb
+	}
+	name := c.name
+	if target != "" {
+		name += "/" + target
+	}
+	cb := NewCircuitBreaker(name, c.config, c.metrics)
+	c.breakers[target] = cb
+	return cb
+}
+
+// SetConfig changes the thresholds of every circuit breaker in the set
+func (c *CircuitBreakers) SetConfig(config CircuitBreakerConfig) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if config == c.config {
+		return
+	}
+	c.config = config
+	for _, cb := range c.breakers {
+		cb.setConfig(config)
+	}
+}
+
+// Statuses returns a snapshot of every circuit breaker, ordered by name
+func (c *CircuitBreakers) Statuses() []CircuitBreakerStatus {
+	c.mutex.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(c.breakers))
+	for _, cb := range c.breakers {
+		breakers = append(breakers, cb)
+	}
+	c.mutex.Unlock()
+
+	statuses := make([]CircuitBreakerStatus, 0, len(breakers))
+	for _, cb := range breakers {
+		statuses = append(statuses, cb.GetStatus())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// setConfig changes the thresholds of the circuit breaker; they apply from
+// the next call
+func (cb *CircuitBreaker) setConfig(config CircuitBreakerConfig) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.config = config
+}
+
+// ExecuteForTarget executes the function with retry logic and the circuit
+// breaker of target, so one failing target leaves the others alone. An empty
+// target uses the shared circuit breaker.
+func (r *RetryWithCircuitBreaker) ExecuteForTarget(ctx context.Context, operation, target string, fn RetryFuncWithContext) error {
+	cb := r.circuitBreaker
+	if target != "" {
+		cb = r.targets.Get(target)
+	}
+	return r.retryer.DoWithContext(ctx, operation, func(ctx context.Context) error {
+		return cb.ExecuteWithContext(ctx, fn)
+	})
+}
+
+// Retry executes the function with retry logic only
+func (r *RetryWithCircuitBreaker) Retry(ctx context.Context, operation string, fn RetryFuncWithContext) error {
+	return r.retryer.DoWithContext(ctx, operation, fn)
+}
+
+// SetCircuitBreakerConfig changes the thresholds of the shared and the
+// per-target circuit breakers
+func (r *RetryWithCircuitBreaker) SetCircuitBreakerConfig(config CircuitBreakerConfig) {
+	r.circuitBreaker.setConfig(config)
+	r.targets.SetConfig(config)
+}
+
+// GetCircuitBreakerStatuses returns a snapshot of the shared circuit breaker
+// followed by the per-target ones
+func (r *RetryWithCircuitBreaker) GetCircuitBreakerStatuses() []CircuitBreakerStatus {
+	return append([]CircuitBreakerStatus{r.circuitBreaker.GetStatus()}, r.targets.Statuses()...)
+}
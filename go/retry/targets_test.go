@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryWithCircuitBreaker_ExecuteForTarget(t *testing.T) {
+	rcb := NewRetryWithCircuitBreaker("pod-resize", Config{}, CircuitBreakerConfig{FailureThreshold: 2, RecoveryTimeout: time.Minute, SuccessThreshold: 1}, nil)
+	ctx := context.Background()
+	fail := func(context.Context) error { return errors.New("failure") }
+	succeed := func(context.Context) error { return nil }
+
+	// Failures in one namespace open only its breaker
+	rcb.ExecuteForTarget(ctx, "resize", "namespace/payments", fail)
+	rcb.ExecuteForTarget(ctx, "resize", "namespace/payments", fail)
+	err := rcb.ExecuteForTarget(ctx, "resize", "namespace/payments", succeed)
+	assert.ErrorContains(t, err, "circuit breaker pod-resize/namespace/payments is OPEN")
+	assert.NoError(t, rcb.ExecuteForTarget(ctx, "resize", "namespace/shop", succeed))
+	assert.Equal(t, StateClosed, rcb.GetCircuitBreakerState())
+
+	statuses := rcb.GetCircuitBreakerStatuses()
+	require.Len(t, statuses, 3)
+	assert.Equal(t, "pod-resize", statuses[0].Name)
+	assert.Equal(t, "pod-resize/namespace/payments", statuses[1].Name)
+	assert.Equal(t, "OPEN", statuses[1].State)
+	assert.Equal(t, "pod-resize/namespace/shop", statuses[2].Name)
+	assert.Equal(t, "CLOSED", statuses[2].State)
+
+	// An empty target uses the shared breaker
+	rcb.ExecuteForTarget(ctx, "resize", "", fail)
+	_, failures, _ := rcb.circuitBreaker.GetStats()
+	assert.Equal(t, 1, failures)
+}
+
+func TestCircuitBreakers_SetConfig(t *testing.T) {
+	breakers := NewCircuitBreakers("pod-resize", CircuitBreakerConfig{FailureThreshold: 5, RecoveryTimeout: time.Minute}, nil)
+	cb := breakers.Get("node/worker-1")
+	assert.Same(t, cb, breakers.Get("node/worker-1"))
+
+	// Lowered thresholds apply to breakers already created
+	breakers.SetConfig(CircuitBreakerConfig{FailureThreshold: 1, RecoveryTimeout: time.Minute})
+	cb.Execute(func() error { return errors.New("failure") })
+	assert.Equal(t, StateOpen, cb.GetState())
+	assert.Equal(t, 1, breakers.Get("node/worker-2").config.FailureThreshold)
+}
@@ -0,0 +1,119 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package validation
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+)
+
+// DRAResources returns the extended resources of a container that the
+// scheduler backs with a ResourceClaim it generated for the pod (DRA
+// extended resources), as recorded in the pod's status
+func DRAResources(pod *corev1.Pod, containerName string) []corev1.ResourceName {
+	status := pod.Status.ExtendedResourceClaimStatus
+	if status == nil {
+		return nil
+	}
+	var names []corev1.ResourceName
+	for _, mapping := range status.RequestMappings {
+		if mapping.ContainerName == containerName {
+			names = append(names, corev1.ResourceName(mapping.ResourceName))
+		}
+	}
+	return names
+}
+
+// UsesDRA reports whether a container is allocated devices through Dynamic
+// Resource Allocation, by resource claims or DRA extended resources
+func UsesDRA(pod *corev1.Pod, containerName string) bool {
+	if container := findContainer(pod, containerName); container != nil && len(container.Resources.Claims) > 0 {
+		return true
+	}
+	return len(DRAResources(pod, containerName)) > 0
+}
+
+// DRAChanges describes the changes proposed resources make to the DRA-backed
+// resources of a container: its resource claims and the quantities of its
+// DRA extended resources. Claims and resources the proposal leaves out are
+// kept by resize patches and are not changes. Right-sizer only resizes CPU
+// and memory, and the claims of a pod cannot change once it is allocated.
+func DRAChanges(pod *corev1.Pod, containerName string, proposed corev1.ResourceRequirements) []string {
+	container := findContainer(pod, containerName)
+	if container == nil {
+		return nil
+	}
+	current := container.Resources
+
+	var changes []string
+	if proposed.Claims != nil && !apiequality.Semantic.DeepEqual(current.Claims, proposed.Claims) {
+		changes = append(changes, fmt.Sprintf("resource claims of container %s cannot be changed", containerName))
+	}
+	for _, name := range DRAResources(pod, containerName) {
+		for _, list := range []struct {
+			kind              string
+			current, proposed corev1.ResourceList
+		}{
+			{"request", current.Requests, proposed.Requests},
+			{"limit", current.Limits, proposed.Limits},
+		} {
+			value, ok := list.proposed[name]
+			if !ok {
+				continue
+			}
+			if existing, ok := list.current[name]; !ok || !existing.Equal(value) {
+				changes = append(changes, fmt.Sprintf("%s %s of container %s is backed by a ResourceClaim and cannot be changed", name, list.kind, containerName))
+			}
+		}
+	}
+	return changes
+}
+
+// validateDRAResources rejects changes to the DRA-backed resources of a container
+func (rv *ResourceValidator) validateDRAResources(pod *corev1.Pod, newResources corev1.ResourceRequirements, containerName string, result *ValidationResult) {
+	for _, change := range DRAChanges(pod, containerName, newResources) {
+		result.AddError(change)
+	}
+}
+
+// keepDRAResources restores the DRA extended resources of a container in
+// clamped to their proposed quantities, which namespace constraints on
+// device counts must not change
+func keepDRAResources(pod *corev1.Pod, containerName string, proposed corev1.ResourceRequirements, clamped *corev1.ResourceRequirements) {
+	for _, name := range DRAResources(pod, containerName) {
+		if value, ok := proposed.Requests[name]; ok {
+			clamped.Requests[name] = value
+		}
+		if value, ok := proposed.Limits[name]; ok {
+			clamped.Limits[name] = value
+		}
+	}
+}
+
+// findContainer returns a container of the pod by name, native sidecars and
+// init containers included
+func findContainer(pod *corev1.Pod, containerName string) *corev1.Container {
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for i := range containers {
+			if containers[i].Name == containerName {
+				return &containers[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package validation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// defaultConstraintsTTL is how long a namespace's LimitRanges and quotas are cached
+const defaultConstraintsTTL = time.Minute
+
+// Constraint labels recorded on the constrained decisions metric
+const (
+	ConstraintLimitRange    = "limit_range"
+	ConstraintResourceQuota = "resource_quota"
+)
+
+// quotaResources maps quota resource names to the container field they limit
+var quotaResources = []struct {
+	name     corev1.ResourceName
+	resource corev1.ResourceName
+	limits   bool
+}{
+	{corev1.ResourceRequestsCPU, corev1.ResourceCPU, false},
+	{corev1.ResourceCPU, corev1.ResourceCPU, false},
+	{corev1.ResourceRequestsMemory, corev1.ResourceMemory, false},
+	{corev1.ResourceMemory, corev1.ResourceMemory, false},
+	{corev1.ResourceLimitsCPU, corev1.ResourceCPU, true},
+	{corev1.ResourceLimitsMemory, corev1.ResourceMemory, true},
+}
+
+// namespaceConstraints is a cached snapshot of a namespace's LimitRanges and quotas
+type namespaceConstraints struct {
+	limitRanges []corev1.LimitRange
+	quotas      []corev1.ResourceQuota
+	fetchedAt   time.Time
+
+	// consumed tracks quota taken by clamped decisions since the snapshot,
+	// keyed by quota name and resource, so one run cannot overshoot a quota
+	consumed map[string]resource.Quantity
+}
+
+// ClampToNamespaceConstraints adjusts proposed resources for a container so
+// they fit the LimitRanges and ResourceQuotas of the pod's namespace. It
+// returns the clamped resources and a note for every adjustment made.
+func (rv *ResourceValidator) ClampToNamespaceConstraints(ctx context.Context, pod *corev1.Pod, containerName string, proposed corev1.ResourceRequirements) (corev1.ResourceRequirements, []string) {
+	rv.constraintsMutex.Lock()
+	defer rv.constraintsMutex.Unlock()
+
+	constraints, err := rv.getNamespaceConstraints(ctx, pod.Namespace)
+	if err != nil {
+		return proposed, nil
+	}
+
+	clamped := *proposed.DeepCopy()
+	var notes []string
+
+	limitRangeNotes := clampToLimitRanges(constraints.limitRanges, &clamped)
+	if len(limitRangeNotes) > 0 && rv.metrics != nil {
+		rv.metrics.RecordConstrainedDecision(pod.Namespace, ConstraintLimitRange)
+	}
+	notes = append(notes, limitRangeNotes...)
+
+	// Native sidecars and init containers are sized as well, so look in both lists
+	var current corev1.ResourceRequirements
+	for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+		for _, container := range containers {
+			if container.Name == containerName {
+				current = container.Resources
+			}
+		}
+	}
+
+	quotaNotes := constraints.clampToQuotas(current, &clamped)
+	if len(quotaNotes) > 0 && rv.metrics != nil {
+		rv.metrics.RecordConstrainedDecision(pod.Namespace, ConstraintResourceQuota)
+	}
+	notes = append(notes, quotaNotes...)
+	keepDRAResources(pod, containerName, proposed, &clamped)
+
+	constraints.consume(current, clamped)
+	return clamped, notes
+}
+
+// getNamespaceConstraints returns the cached constraints for a namespace,
+// reloading them once the TTL has expired; the caller must hold constraintsMutex
+func (rv *ResourceValidator) getNamespaceConstraints(ctx context.Context, namespace string) (*namespaceConstraints, error) {
+	if cached, ok := rv.constraints[namespace]; ok && time.Since(cached.fetchedAt) < rv.constraintsTTL {
+		return cached, nil
+	}
+
+	limitRangeList := &corev1.LimitRangeList{}
+	if err := rv.client.List(ctx, limitRangeList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	quotaList := &corev1.ResourceQuotaList{}
+	if err := rv.client.List(ctx, quotaList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	constraints := &namespaceConstraints{
+		limitRanges: limitRangeList.Items,
+		quotas:      quotaList.Items,
+		fetchedAt:   time.Now(),
+		consumed:    make(map[string]resource.Quantity),
+	}
+	rv.constraints[namespace] = constraints
+	return constraints, nil
+}
+
+// clampToLimitRanges applies container min, max and limit-to-request ratio constraints
+func clampToLimitRanges(limitRanges []corev1.LimitRange, resources *corev1.ResourceRequirements) []string {
+	var notes []string
+	for _, limitRange := range limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+
+			for name, min := range item.Min {
+				if raiseTo(resources.Requests, name, min) {
+					notes = append(notes, fmt.Sprintf("%s request raised to LimitRange %s minimum %s", name, limitRange.Name, min.String()))
+				}
+				if raiseTo(resources.Limits, name, min) {
+					notes = append(notes, fmt.Sprintf("%s limit raised to LimitRange %s minimum %s", name, limitRange.Name, min.String()))
+				}
+			}
+
+			for name, max := range item.Max {
+				if lowerTo(resources.Limits, name, max) {
+					notes = append(notes, fmt.Sprintf("%s limit capped at LimitRange %s maximum %s", name, limitRange.Name, max.String()))
+				}
+				if lowerTo(resources.Requests, name, max) {
+					notes = append(notes, fmt.Sprintf("%s request capped at LimitRange %s maximum %s", name, limitRange.Name, max.String()))
+				}
+			}
+
+			for name, ratio := range item.MaxLimitRequestRatio {
+				request, hasRequest := resources.Requests[name]
+				if !hasRequest || request.IsZero() {
+					continue
+				}
+				maxLimit := scaleQuantity(request, float64(ratio.MilliValue())/1000.0)
+				if lowerTo(resources.Limits, name, maxLimit) {
+					notes = append(notes, fmt.Sprintf("%s limit lowered to LimitRange %s ratio %s", name, limitRange.Name, ratio.String()))
+				}
+			}
+		}
+	}
+
+	keepRequestsWithinLimits(resources)
+	return notes
+}
+
+// clampToQuotas caps the increase over current resources at the room left in each quota
+func (c *namespaceConstraints) clampToQuotas(current corev1.ResourceRequirements, resources *corev1.ResourceRequirements) []string {
+	var notes []string
+	for _, quota := range c.quotas {
+		// Scoped quotas may not apply to this pod; the API server still enforces them
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+
+		for _, qr := range quotaResources {
+			hard, ok := quota.Status.Hard[qr.name]
+			if !ok {
+				continue
+			}
+			list, currentList := resources.Requests, current.Requests
+			if qr.limits {
+				list, currentList = resources.Limits, current.Limits
+			}
+			proposed, ok := list[qr.resource]
+			if !ok {
+				continue
+			}
+
+			existing := currentList[qr.resource]
+			increase := proposed.DeepCopy()
+			increase.Sub(existing)
+			if increase.Sign() <= 0 {
+				continue
+			}
+
+			available := hard.DeepCopy()
+			available.Sub(quota.Status.Used[qr.name])
+			available.Sub(c.consumed[quotaKey(quota.Name, qr.name)])
+			if available.Sign() < 0 {
+				available = resource.Quantity{}
+			}
+			if increase.Cmp(available) <= 0 {
+				continue
+			}
+
+			capped := existing.DeepCopy()
+			capped.Add(available)
+			list[qr.resource] = capped
+			notes = append(notes, fmt.Sprintf("%s capped at %s by ResourceQuota %s (%s)", qr.name, capped.String(), quota.Name, qr.name))
+		}
+	}
+
+	keepRequestsWithinLimits(resources)
+	return notes
+}
+
+// consume records the quota taken by a clamped decision
+func (c *namespaceConstraints) consume(current, clamped corev1.ResourceRequirements) {
+	for _, quota := range c.quotas {
+		for _, qr := range quotaResources {
+			if _, ok := quota.Status.Hard[qr.name]; !ok {
+				continue
+			}
+			list, currentList := clamped.Requests, current.Requests
+			if qr.limits {
+				list, currentList = clamped.Limits, current.Limits
+			}
+			increase := list[qr.resource].DeepCopy()
+			increase.Sub(currentList[qr.resource])
+			if increase.Sign() <= 0 {
+				continue
+			}
+			key := quotaKey(quota.Name, qr.name)
+			total := c.consumed[key].DeepCopy()
+			total.Add(increase)
+			c.consumed[key] = total
+		}
+	}
+}
+
+// quotaKey identifies a resource within a quota
+func quotaKey(quotaName string, name corev1.ResourceName) string {
+	return quotaName + "/" + string(name)
+}
+
+// raiseTo sets list[name] to min if it is set and below it
+func raiseTo(list corev1.ResourceList, name corev1.ResourceName, min resource.Quantity) bool {
+	value, ok := list[name]
+	if !ok || value.Cmp(min) >= 0 {
+		return false
+	}
+	list[name] = min.DeepCopy()
+	return true
+}
+
+// lowerTo sets list[name] to max if it is set and above it
+func lowerTo(list corev1.ResourceList, name corev1.ResourceName, max resource.Quantity) bool {
+	value, ok := list[name]
+	if !ok || value.Cmp(max) <= 0 {
+		return false
+	}
+	list[name] = max.DeepCopy()
+	return true
+}
+
+// keepRequestsWithinLimits lowers any request that ended up above its limit
+func keepRequestsWithinLimits(resources *corev1.ResourceRequirements) {
+	for name, limit := range resources.Limits {
+		lowerTo(resources.Requests, name, limit)
+	}
+}
+
+// scaleQuantity multiplies a quantity by factor, keeping its format
+func scaleQuantity(q resource.Quantity, factor float64) resource.Quantity {
+	if q.Format == resource.DecimalSI {
+		return *resource.NewMilliQuantity(int64(float64(q.MilliValue())*factor), q.Format)
+	}
+	return *resource.NewQuantity(int64(float64(q.Value())*factor), q.Format)
+}
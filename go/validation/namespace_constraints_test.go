@@ -0,0 +1,131 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package validation
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func constrainedValidator(objects ...client.Object) *ResourceValidator {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	fakeClient := ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return NewResourceValidator(fakeClient, nil, config.GetDefaults(), nil)
+}
+
+func podWithResources(name, cpu, mem string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "app",
+			Resources: requirements(cpu, mem, "", ""),
+		}}},
+	}
+}
+
+func requirements(cpuRequest, memRequest, cpuLimit, memLimit string) corev1.ResourceRequirements {
+	r := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	for list, values := range map[*corev1.ResourceList][2]string{&r.Requests: {cpuRequest, memRequest}, &r.Limits: {cpuLimit, memLimit}} {
+		if values[0] != "" {
+			(*list)[corev1.ResourceCPU] = resource.MustParse(values[0])
+		}
+		if values[1] != "" {
+			(*list)[corev1.ResourceMemory] = resource.MustParse(values[1])
+		}
+	}
+	return r
+}
+
+func TestClampToLimitRange(t *testing.T) {
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "bounds", Namespace: "team"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type:                 corev1.LimitTypeContainer,
+			Min:                  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("50m")},
+			Max:                  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			MaxLimitRequestRatio: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		}}},
+	}
+	rv := constrainedValidator(limitRange)
+
+	proposed := requirements("10m", "2Gi", "500m", "4Gi")
+	got, notes := rv.ClampToNamespaceConstraints(context.Background(), podWithResources("web", "100m", "256Mi"), "app", proposed)
+
+	if len(notes) == 0 {
+		t.Fatal("expected clamping notes")
+	}
+	if got.Requests.Cpu().MilliValue() != 50 {
+		t.Errorf("expected cpu request raised to 50m, got %s", got.Requests.Cpu())
+	}
+	if got.Limits.Cpu().MilliValue() != 100 {
+		t.Errorf("expected cpu limit lowered to 2x the request, got %s", got.Limits.Cpu())
+	}
+	if got.Limits.Memory().Cmp(resource.MustParse("1Gi")) != 0 || got.Requests.Memory().Cmp(resource.MustParse("1Gi")) != 0 {
+		t.Errorf("expected memory capped at 1Gi, got request %s limit %s", got.Requests.Memory(), got.Limits.Memory())
+	}
+	if proposed.Requests.Cpu().MilliValue() != 10 {
+		t.Error("expected proposed resources left untouched")
+	}
+}
+
+func TestClampToResourceQuota(t *testing.T) {
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "team"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("1")},
+			Used: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("800m")},
+		},
+	}
+	rv := constrainedValidator(quota)
+	ctx := context.Background()
+
+	// 150m of the 200m left in the quota is granted to the first pod
+	got, notes := rv.ClampToNamespaceConstraints(ctx, podWithResources("a", "100m", "128Mi"), "app", requirements("250m", "128Mi", "", ""))
+	if len(notes) != 0 || got.Requests.Cpu().MilliValue() != 250 {
+		t.Fatalf("expected increase within quota unchanged, got %s (%v)", got.Requests.Cpu(), notes)
+	}
+
+	// Only 50m remains for the second pod
+	got, notes = rv.ClampToNamespaceConstraints(ctx, podWithResources("b", "100m", "128Mi"), "app", requirements("300m", "128Mi", "", ""))
+	if len(notes) != 1 || got.Requests.Cpu().MilliValue() != 150 {
+		t.Fatalf("expected increase capped at remaining quota 150m, got %s (%v)", got.Requests.Cpu(), notes)
+	}
+
+	// Decreases never touch the quota
+	got, notes = rv.ClampToNamespaceConstraints(ctx, podWithResources("c", "500m", "128Mi"), "app", requirements("200m", "128Mi", "", ""))
+	if len(notes) != 0 || got.Requests.Cpu().MilliValue() != 200 {
+		t.Fatalf("expected decrease unchanged, got %s (%v)", got.Requests.Cpu(), notes)
+	}
+}
+
+func TestClampWithoutConstraints(t *testing.T) {
+	rv := constrainedValidator()
+	proposed := requirements("2", "4Gi", "4", "8Gi")
+	got, notes := rv.ClampToNamespaceConstraints(context.Background(), podWithResources("web", "100m", "128Mi"), "app", proposed)
+	if len(notes) != 0 || got.Requests.Cpu().Cmp(*proposed.Requests.Cpu()) != 0 {
+		t.Fatalf("expected resources unchanged, got %+v (%v)", got, notes)
+	}
+}
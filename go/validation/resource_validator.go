@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"right-sizer/config"
 	"right-sizer/logger"
@@ -91,6 +93,11 @@ type ResourceValidator struct {
 	nodeCache   map[string]*corev1.Node
 	quotaCache  map[string]*corev1.ResourceQuota
 	limitRanges map[string][]*corev1.LimitRange
+
+	// Per-namespace LimitRanges and quotas used to clamp recommendations
+	constraintsMutex sync.Mutex
+	constraints      map[string]*namespaceConstraints
+	constraintsTTL   time.Duration
 }
 
 // NewResourceValidator creates a new resource validator
@@ -103,6 +110,9 @@ func NewResourceValidator(client client.Client, clientset kubernetes.Interface,
 		nodeCache:   make(map[string]*corev1.Node),
 		quotaCache:  make(map[string]*corev1.ResourceQuota),
 		limitRanges: make(map[string][]*corev1.LimitRange),
+		constraints: make(map[string]*namespaceConstraints),
+
+		constraintsTTL: defaultConstraintsTTL,
 	}
 }
 
@@ -138,6 +148,9 @@ func (rv *ResourceValidator) ValidateResourceChange(ctx context.Context, pod *co
 	// QoS class validation
 	rv.validateQoSImpact(pod, newResources, containerName, result)
 
+	// Resource claims and DRA extended resources are passed through unchanged
+	rv.validateDRAResources(pod, newResources, containerName, result)
+
 	// Record metrics
 	if rv.metrics != nil {
 		if !result.IsValid() {
@@ -663,6 +676,10 @@ func (rv *ResourceValidator) ClearCaches() {
 	rv.nodeCache = make(map[string]*corev1.Node)
 	rv.quotaCache = make(map[string]*corev1.ResourceQuota)
 	rv.limitRanges = make(map[string][]*corev1.LimitRange)
+
+	rv.constraintsMutex.Lock()
+	rv.constraints = make(map[string]*namespaceConstraints)
+	rv.constraintsMutex.Unlock()
 }
 
 // RefreshCaches refreshes all caches
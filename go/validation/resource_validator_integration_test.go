@@ -290,3 +290,111 @@ func TestValidateLimitRanges(t *testing.T) {
 		})
 	}
 }
+
+// draPod returns a pod whose app container claims a device and requests a
+// DRA extended resource
+func draPod(namespace string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dra-pod",
+			Namespace: namespace,
+		},
+		Spec: corev1.PodSpec{
+			ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu"}},
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+							corev1.ResourceMemory: resource.MustParse("512Mi"),
+							"example.com/gpu":     resource.MustParse("1"),
+						},
+						Limits: corev1.ResourceList{
+							"example.com/gpu": resource.MustParse("1"),
+						},
+						Claims: []corev1.ResourceClaim{{Name: "gpu"}},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			ExtendedResourceClaimStatus: &corev1.PodExtendedResourceClaimStatus{
+				ResourceClaimName: "dra-pod-extended-resources",
+				RequestMappings: []corev1.ContainerExtendedResourceRequest{
+					{ContainerName: "app", ResourceName: "example.com/gpu", RequestName: "container-0-request-0"},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateDRAResources(t *testing.T) {
+	validator := createTestValidator(nil)
+	pod := draPod("default")
+	ctx := context.TODO()
+
+	assert.True(t, UsesDRA(pod, "app"))
+	assert.Equal(t, []corev1.ResourceName{"example.com/gpu"}, DRAResources(pod, "app"))
+
+	resize := func(mutate func(*corev1.ResourceRequirements)) corev1.ResourceRequirements {
+		resources := *pod.Spec.Containers[0].Resources.DeepCopy()
+		resources.Requests[corev1.ResourceCPU] = resource.MustParse("300m")
+		mutate(&resources)
+		return resources
+	}
+
+	tests := []struct {
+		name         string
+		newResources corev1.ResourceRequirements
+		expectError  bool
+	}{
+		{"CPU resize keeps claims", resize(func(*corev1.ResourceRequirements) {}), false},
+		{"Claims left out are kept", resize(func(r *corev1.ResourceRequirements) { r.Claims = nil }), false},
+		{"Claims changed", resize(func(r *corev1.ResourceRequirements) { r.Claims = []corev1.ResourceClaim{{Name: "other"}} }), true},
+		{"Claimed device count changed", resize(func(r *corev1.ResourceRequirements) { r.Requests["example.com/gpu"] = resource.MustParse("2") }), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.ValidateResourceChange(ctx, pod, tt.newResources, "app")
+			if tt.expectError {
+				assert.False(t, result.IsValid())
+			} else {
+				assert.True(t, result.IsValid(), result.Errors)
+			}
+		})
+	}
+}
+
+func TestClampKeepsDRAResources(t *testing.T) {
+	namespace := "dra-ns"
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "devices", Namespace: namespace},
+		Spec: corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type: corev1.LimitTypeContainer,
+					Max: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("400m"),
+						"example.com/gpu":  resource.MustParse("0"),
+					},
+				},
+			},
+		},
+	}
+	validator := createTestValidator([]runtime.Object{limitRange})
+	pod := draPod(namespace)
+
+	proposed := *pod.Spec.Containers[0].Resources.DeepCopy()
+	proposed.Limits[corev1.ResourceCPU] = resource.MustParse("1000m")
+	clamped, _ := validator.ClampToNamespaceConstraints(context.TODO(), pod, "app", proposed)
+
+	cpuLimit := clamped.Limits[corev1.ResourceCPU]
+	assert.Equal(t, "400m", cpuLimit.String())
+	gpuRequest := clamped.Requests["example.com/gpu"]
+	gpuLimit := clamped.Limits["example.com/gpu"]
+	assert.Equal(t, "1", gpuRequest.String())
+	assert.Equal(t, "1", gpuLimit.String())
+	assert.Equal(t, proposed.Claims, clamped.Claims)
+}
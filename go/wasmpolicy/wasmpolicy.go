@@ -0,0 +1,224 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package wasmpolicy evaluates user-supplied WebAssembly modules against
+// resize decisions, so custom rules can be added without rebuilding the
+// operator.
+//
+// A policy module exports its memory and two functions:
+//
+//	allocate(size i32) i32            reserves size bytes and returns their offset
+//	evaluate(ptr i32, len i32) i64    decides on the Input JSON at ptr
+//
+// evaluate returns the offset of its Result JSON in the upper 32 bits and
+// its length in the lower 32. Modules may import WASI, so those built with
+// TinyGo, Rust or AssemblyScript run as they are. Every evaluation runs in
+// a fresh instance, so modules keep no state between decisions.
+package wasmpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// memoryLimitPages caps a module's memory at 16MiB
+const memoryLimitPages = 256
+
+// Input is what a module is asked to decide on
+type Input struct {
+	Namespace       string                      `json:"namespace"`
+	NamespaceLabels map[string]string           `json:"namespaceLabels,omitempty"`
+	Pod             string                      `json:"pod"`
+	Container       string                      `json:"container"`
+	Workload        string                      `json:"workload"` // Kind/name
+	Labels          map[string]string           `json:"labels,omitempty"`
+	Annotations     map[string]string           `json:"annotations,omitempty"`
+	Current         corev1.ResourceRequirements `json:"current"`
+	Proposed        corev1.ResourceRequirements `json:"proposed"`
+	Reason          string                      `json:"reason,omitempty"`
+}
+
+// Result is a module's decision. Resources, when set, replace the proposed
+// resources of an allowed resize.
+type Result struct {
+	Allow     bool                         `json:"allow"`
+	Reason    string                       `json:"reason,omitempty"`
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Decision is the outcome of all modules
+type Decision struct {
+	Allow     bool
+	Module    string // Module that denied the resize
+	Reason    string
+	Resources corev1.ResourceRequirements // Proposed resources after every adjustment
+}
+
+// module is a compiled policy module
+type module struct {
+	name     string
+	compiled wazero.CompiledModule
+}
+
+// Engine evaluates the policy modules of a directory in name order
+type Engine struct {
+	runtime wazero.Runtime
+	modules []module
+	timeout time.Duration
+}
+
+// Load compiles every .wasm module of dir. Each evaluation of a module is
+// stopped after timeout.
+func Load(ctx context.Context, dir string, timeout time.Duration) (*Engine, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy modules from %s: %w", dir, err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".wasm") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	config := wazero.NewRuntimeConfig().WithCloseOnContextDone(true).WithMemoryLimitPages(memoryLimitPages)
+	runtime := wazero.NewRuntimeWithConfig(ctx, config)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+	engine := &Engine{runtime: runtime, timeout: timeout}
+	for _, name := range names {
+		code, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			runtime.Close(ctx)
+			return nil, fmt.Errorf("failed to read policy module %s: %w", name, err)
+		}
+		compiled, err := runtime.CompileModule(ctx, code)
+		if err != nil {
+			runtime.Close(ctx)
+			return nil, fmt.Errorf("failed to compile policy module %s: %w", name, err)
+		}
+		for _, export := range []string{"allocate", "evaluate"} {
+			if _, ok := compiled.ExportedFunctions()[export]; !ok {
+				runtime.Close(ctx)
+				return nil, fmt.Errorf("policy module %s does not export %s", name, export)
+			}
+		}
+		engine.modules = append(engine.modules, module{name: name, compiled: compiled})
+	}
+	return engine, nil
+}
+
+// Modules returns the names of the loaded modules, in evaluation order
+func (e *Engine) Modules() []string {
+	names := make([]string, 0, len(e.modules))
+	for _, m := range e.modules {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// Close releases the runtime and its compiled modules
+func (e *Engine) Close(ctx context.Context) error {
+	return e.runtime.Close(ctx)
+}
+
+// Evaluate runs every module on the input in order. The first module to
+// deny ends the evaluation; resources a module adjusts are what the next
+// one is proposed. A module that fails, traps or times out returns an error.
+func (e *Engine) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	decision := Decision{Allow: true, Resources: input.Proposed}
+	for _, m := range e.modules {
+		input.Proposed = decision.Resources
+		result, err := e.run(ctx, m, input)
+		if err != nil {
+			return Decision{}, fmt.Errorf("policy module %s: %w", m.name, err)
+		}
+		if !result.Allow {
+			return Decision{Module: m.name, Reason: result.Reason, Resources: decision.Resources}, nil
+		}
+		if result.Resources != nil {
+			decision.Resources = *result.Resources
+		}
+	}
+	return decision, nil
+}
+
+// run evaluates the input in a fresh instance of a module
+func (e *Engine) run(ctx context.Context, m module, input Input) (Result, error) {
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to encode input: %w", err)
+	}
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	instance, err := e.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to instantiate: %w", err)
+	}
+	defer instance.Close(ctx)
+
+	ptr, err := call(ctx, instance.ExportedFunction("allocate"), uint64(len(payload)))
+	if err != nil {
+		return Result{}, fmt.Errorf("allocate failed: %w", err)
+	}
+	if !instance.Memory().Write(uint32(ptr), payload) {
+		return Result{}, errors.New("allocate returned memory out of range")
+	}
+	packed, err := call(ctx, instance.ExportedFunction("evaluate"), ptr, uint64(len(payload)))
+	if err != nil {
+		return Result{}, fmt.Errorf("evaluate failed: %w", err)
+	}
+	output, ok := instance.Memory().Read(uint32(packed>>32), uint32(packed))
+	if !ok {
+		return Result{}, errors.New("evaluate returned memory out of range")
+	}
+
+	var result Result
+	if err := json.Unmarshal(output, &result); err != nil {
+		return Result{}, fmt.Errorf("invalid result %q: %w", output, err)
+	}
+	return result, nil
+}
+
+// call calls a function returning a single value
+func call(ctx context.Context, fn api.Function, params ...uint64) (uint64, error) {
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	if len(results) != 1 {
+		return 0, fmt.Errorf("expected 1 result, got %d", len(results))
+	}
+	return results[0], nil
+}
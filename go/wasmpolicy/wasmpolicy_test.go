@@ -0,0 +1,190 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package wasmpolicy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// resultOffset is where test modules keep their result
+const resultOffset = 2048
+
+func uleb(n uint64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(n int64) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if (n == 0 && b&0x40 == 0) || (n == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func section(id byte, content ...byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+// policyModule assembles a module whose evaluate runs body, which must leave
+// an i64 on the stack. The result JSON is stored at resultOffset.
+func policyModule(result string, body ...byte) []byte {
+	name := func(s string) []byte { return append(uleb(uint64(len(s))), s...) }
+	function := func(code ...byte) []byte {
+		code = append(append([]byte{0x00}, code...), 0x0b)
+		return append(uleb(uint64(len(code))), code...)
+	}
+
+	module := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	module = append(module, section(1, 0x02, 0x60, 0x01, 0x7f, 0x01, 0x7f, 0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7e)...)
+	module = append(module, section(3, 0x02, 0x00, 0x01)...)
+	module = append(module, section(5, 0x01, 0x00, 0x01)...)
+	exports := []byte{0x03}
+	exports = append(append(exports, name("memory")...), 0x02, 0x00)
+	exports = append(append(exports, name("allocate")...), 0x00, 0x00)
+	exports = append(append(exports, name("evaluate")...), 0x00, 0x01)
+	module = append(module, section(7, exports...)...)
+	code := []byte{0x02}
+	code = append(code, function(append([]byte{0x41}, sleb(1024)...)...)...)
+	code = append(code, function(body...)...)
+	module = append(module, section(10, code...)...)
+	data := append([]byte{0x01, 0x00, 0x41}, sleb(resultOffset)...)
+	data = append(append(append(data, 0x0b), uleb(uint64(len(result)))...), result...)
+	return append(module, section(11, data...)...)
+}
+
+// returning assembles a module returning result
+func returning(result string) []byte {
+	return policyModule(result, append([]byte{0x42}, sleb(resultOffset<<32|int64(len(result)))...)...)
+}
+
+func writeModules(t *testing.T, modules map[string][]byte) string {
+	dir := t.TempDir()
+	for name, code := range modules {
+		if err := os.WriteFile(filepath.Join(dir, name), code, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testInput() Input {
+	return Input{
+		Namespace: "shop", Pod: "web-1", Container: "app", Workload: "Deployment/web",
+		Proposed: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+	}
+}
+
+func TestEngineEvaluatesModulesInOrder(t *testing.T) {
+	ctx := context.Background()
+	dir := writeModules(t, map[string][]byte{
+		"10-cap.wasm":   returning(`{"allow":true,"resources":{"requests":{"cpu":"1"}}}`),
+		"20-allow.wasm": returning(`{"allow":true}`),
+		"README.md":     []byte("not a module"),
+	})
+	engine, err := Load(ctx, dir, time.Second)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer engine.Close(ctx)
+	if modules := engine.Modules(); len(modules) != 2 || modules[0] != "10-cap.wasm" {
+		t.Fatalf("expected the two modules in name order, got %v", modules)
+	}
+
+	decision, err := engine.Evaluate(ctx, testInput())
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if !decision.Allow {
+		t.Fatalf("expected the resize to be allowed, got %+v", decision)
+	}
+	if cpu := decision.Resources.Requests[corev1.ResourceCPU]; cpu.MilliValue() != 1000 {
+		t.Errorf("expected the CPU request capped at 1 core, got %s", cpu.String())
+	}
+}
+
+func TestEngineDeny(t *testing.T) {
+	ctx := context.Background()
+	dir := writeModules(t, map[string][]byte{
+		"10-freeze.wasm": returning(`{"allow":false,"reason":"change freeze"}`),
+		"20-trap.wasm":   policyModule("", 0x00), // unreachable, never evaluated
+	})
+	engine, err := Load(ctx, dir, time.Second)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer engine.Close(ctx)
+
+	decision, err := engine.Evaluate(ctx, testInput())
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if decision.Allow || decision.Module != "10-freeze.wasm" || decision.Reason != "change freeze" {
+		t.Errorf("expected the freeze module to deny the resize, got %+v", decision)
+	}
+}
+
+func TestEngineFailures(t *testing.T) {
+	ctx := context.Background()
+	loop := []byte{0x03, 0x40, 0x0c, 0x00, 0x0b, 0x42, 0x00} // loop br 0 end; i64.const 0
+	cases := map[string][]byte{
+		"trap":    policyModule("", 0x00),
+		"timeout": policyModule("", loop...),
+		"invalid": returning(`allow`),
+	}
+	for name, code := range cases {
+		t.Run(name, func(t *testing.T) {
+			engine, err := Load(ctx, writeModules(t, map[string][]byte{name + ".wasm": code}), 50*time.Millisecond)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			defer engine.Close(ctx)
+			if _, err := engine.Evaluate(ctx, testInput()); err == nil || !strings.Contains(err.Error(), name+".wasm") {
+				t.Errorf("expected an error naming the module, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidModules(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Load(ctx, writeModules(t, map[string][]byte{"bad.wasm": []byte("not wasm")}), time.Second); err == nil {
+		t.Error("expected an error for a module that does not compile")
+	}
+	if _, err := Load(ctx, filepath.Join(t.TempDir(), "missing"), time.Second); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
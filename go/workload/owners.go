@@ -0,0 +1,140 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package workload resolves the workload a pod belongs to by walking its
+// controller owners, including workloads defined by third-party CRDs such as
+// Argo Rollouts and Knative Services.
+package workload
+
+import (
+	"context"
+
+	"right-sizer/api/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// API groups of the third-party workloads
+const (
+	ArgoRolloutsGroup   = "argoproj.io"
+	KnativeServingGroup = "serving.knative.dev"
+)
+
+// knativeRevisionLabel is set by Knative on the pods of a Revision
+const knativeRevisionLabel = "serving.knative.dev/revision"
+
+// maxOwnerDepth bounds the owner chain walked through unstructured objects
+const maxOwnerDepth = 4
+
+// Resolve walks the pod's controller owners up to the top-level workload:
+//
+//	ReplicaSet -> Deployment
+//	ReplicaSet -> Rollout (Argo Rollouts)
+//	ReplicaSet -> Deployment -> Revision -> Configuration -> Service (Knative)
+//	Job -> CronJob
+//
+// Pods without a controller resolve to themselves. When a step cannot be
+// read, for example because a CRD is not installed, the last owner found is
+// returned.
+func Resolve(ctx context.Context, c client.Reader, pod *corev1.Pod) v1alpha1.RecommendationTargetRef {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return v1alpha1.RecommendationTargetRef{APIVersion: "v1", Kind: "Pod", Name: pod.Name}
+	}
+
+	switch owner.Kind {
+	case "ReplicaSet":
+		var rs appsv1.ReplicaSet
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, &rs); err == nil {
+			parent := metav1.GetControllerOf(&rs)
+			switch {
+			case parent == nil:
+			case parent.Kind == "Deployment" && pod.Labels[knativeRevisionLabel] != "":
+				return resolveKnative(ctx, c, pod.Namespace, *parent)
+			case parent.Kind == "Deployment", isRollout(*parent):
+				return targetRef(*parent)
+			}
+		}
+	case "Job":
+		var job batchv1.Job
+		if err := c.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}, &job); err == nil {
+			if parent := metav1.GetControllerOf(&job); parent != nil && parent.Kind == "CronJob" {
+				return targetRef(*parent)
+			}
+		}
+	}
+
+	return targetRef(*owner)
+}
+
+// IsCustom reports whether a workload is defined by a third-party CRD, so its
+// pod template has to be read and written through unstructured objects
+func IsCustom(target v1alpha1.RecommendationTargetRef) bool {
+	group := schema.FromAPIVersionAndKind(target.APIVersion, target.Kind).Group
+	return group == ArgoRolloutsGroup || group == KnativeServingGroup
+}
+
+// resolveKnative follows a Knative Revision's Deployment up through the
+// Revision and its Configuration to the Service whose template defines it
+func resolveKnative(ctx context.Context, c client.Reader, namespace string, deployment metav1.OwnerReference) v1alpha1.RecommendationTargetRef {
+	var d appsv1.Deployment
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: deployment.Name}, &d); err != nil {
+		return targetRef(deployment)
+	}
+	owner := metav1.GetControllerOf(&d)
+	if owner == nil || !isKnative(*owner) {
+		return targetRef(deployment)
+	}
+
+	// A Revision's template is immutable; the Configuration or Service that
+	// stamped it out is the workload to size
+	for range maxOwnerDepth {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(owner.APIVersion)
+		obj.SetKind(owner.Kind)
+		if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: owner.Name}, obj); err != nil {
+			break
+		}
+		parent := metav1.GetControllerOf(obj)
+		if parent == nil || !isKnative(*parent) {
+			break
+		}
+		owner = parent
+	}
+	return targetRef(*owner)
+}
+
+func isRollout(ref metav1.OwnerReference) bool {
+	return ref.Kind == "Rollout" && groupOf(ref) == ArgoRolloutsGroup
+}
+
+func isKnative(ref metav1.OwnerReference) bool {
+	return groupOf(ref) == KnativeServingGroup
+}
+
+func groupOf(ref metav1.OwnerReference) string {
+	return schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).Group
+}
+
+func targetRef(ref metav1.OwnerReference) v1alpha1.RecommendationTargetRef {
+	return v1alpha1.RecommendationTargetRef{APIVersion: ref.APIVersion, Kind: ref.Kind, Name: ref.Name}
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package workload
+
+import (
+	"context"
+	"testing"
+
+	"right-sizer/api/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlclientfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func controlledBy(apiVersion, kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &controller}}
+}
+
+func meta(name string, owners []metav1.OwnerReference) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: "default", OwnerReferences: owners}
+}
+
+// customObject returns an unstructured object of a third-party kind
+func customObject(apiVersion, kind, name string, owners []metav1.OwnerReference) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetName(name)
+	obj.SetNamespace("default")
+	obj.SetOwnerReferences(owners)
+	return obj
+}
+
+func newClient(objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = appsv1.AddToScheme(scheme)
+	_ = batchv1.AddToScheme(scheme)
+	return ctrlclientfake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func TestResolve(t *testing.T) {
+	const serving = "serving.knative.dev/v1"
+	c := newClient(
+		&appsv1.ReplicaSet{ObjectMeta: meta("web-abc", controlledBy("apps/v1", "Deployment", "web"))},
+		&appsv1.ReplicaSet{ObjectMeta: meta("canary-abc", controlledBy("argoproj.io/v1alpha1", "Rollout", "canary"))},
+		&batchv1.Job{ObjectMeta: meta("nightly-1", controlledBy("batch/v1", "CronJob", "nightly"))},
+		&appsv1.ReplicaSet{ObjectMeta: meta("hello-00001-deployment-abc", controlledBy("apps/v1", "Deployment", "hello-00001-deployment"))},
+		&appsv1.Deployment{ObjectMeta: meta("hello-00001-deployment", controlledBy(serving, "Revision", "hello-00001"))},
+		customObject(serving, "Revision", "hello-00001", controlledBy(serving, "Configuration", "hello")),
+		customObject(serving, "Configuration", "hello", controlledBy(serving, "Service", "hello")),
+		customObject(serving, "Service", "hello", nil),
+	)
+
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		labels map[string]string
+		want   v1alpha1.RecommendationTargetRef
+	}{
+		{
+			name: "bare pod",
+			pod:  &corev1.Pod{ObjectMeta: meta("standalone", nil)},
+			want: v1alpha1.RecommendationTargetRef{APIVersion: "v1", Kind: "Pod", Name: "standalone"},
+		},
+		{
+			name: "deployment",
+			pod:  &corev1.Pod{ObjectMeta: meta("web-abc-1", controlledBy("apps/v1", "ReplicaSet", "web-abc"))},
+			want: v1alpha1.RecommendationTargetRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"},
+		},
+		{
+			name: "argo rollout",
+			pod:  &corev1.Pod{ObjectMeta: meta("canary-abc-1", controlledBy("apps/v1", "ReplicaSet", "canary-abc"))},
+			want: v1alpha1.RecommendationTargetRef{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary"},
+		},
+		{
+			name: "cronjob",
+			pod:  &corev1.Pod{ObjectMeta: meta("nightly-1-abc", controlledBy("batch/v1", "Job", "nightly-1"))},
+			want: v1alpha1.RecommendationTargetRef{APIVersion: "batch/v1", Kind: "CronJob", Name: "nightly"},
+		},
+		{
+			name:   "knative service",
+			pod:    &corev1.Pod{ObjectMeta: meta("hello-00001-deployment-abc-1", controlledBy("apps/v1", "ReplicaSet", "hello-00001-deployment-abc"))},
+			labels: map[string]string{knativeRevisionLabel: "hello-00001"},
+			want:   v1alpha1.RecommendationTargetRef{APIVersion: serving, Kind: "Service", Name: "hello"},
+		},
+		{
+			name: "missing replicaset",
+			pod:  &corev1.Pod{ObjectMeta: meta("gone-abc-1", controlledBy("apps/v1", "ReplicaSet", "gone-abc"))},
+			want: v1alpha1.RecommendationTargetRef{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "gone-abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pod.Labels = tt.labels
+			if got := Resolve(context.Background(), c, tt.pod); got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestResolveKnativeWithoutService verifies a standalone Configuration is the workload
+func TestResolveKnativeWithoutService(t *testing.T) {
+	const serving = "serving.knative.dev/v1"
+	c := newClient(
+		&appsv1.ReplicaSet{ObjectMeta: meta("job-00001-deployment-abc", controlledBy("apps/v1", "Deployment", "job-00001-deployment"))},
+		&appsv1.Deployment{ObjectMeta: meta("job-00001-deployment", controlledBy(serving, "Revision", "job-00001"))},
+		customObject(serving, "Revision", "job-00001", controlledBy(serving, "Configuration", "job")),
+		customObject(serving, "Configuration", "job", nil),
+	)
+	pod := &corev1.Pod{ObjectMeta: meta("job-00001-deployment-abc-1", controlledBy("apps/v1", "ReplicaSet", "job-00001-deployment-abc"))}
+	pod.Labels = map[string]string{knativeRevisionLabel: "job-00001"}
+
+	want := v1alpha1.RecommendationTargetRef{APIVersion: serving, Kind: "Configuration", Name: "job"}
+	if got := Resolve(context.Background(), c, pod); got != want {
+		t.Errorf("Resolve() = %+v, want %+v", got, want)
+	}
+}
+
+func TestIsCustom(t *testing.T) {
+	if !IsCustom(v1alpha1.RecommendationTargetRef{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary"}) {
+		t.Error("expected a Rollout to be custom")
+	}
+	if !IsCustom(v1alpha1.RecommendationTargetRef{APIVersion: "serving.knative.dev/v1", Kind: "Service", Name: "hello"}) {
+		t.Error("expected a Knative Service to be custom")
+	}
+	if IsCustom(v1alpha1.RecommendationTargetRef{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}) {
+		t.Error("expected a Deployment not to be custom")
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2024 right-sizer contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package workload
+
+import (
+	"slices"
+
+	"right-sizer/api/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// MatchesPolicy reports whether the policy's target reference selects the
+// pod, whose workload was resolved to target
+func MatchesPolicy(policy *v1alpha1.RightSizerPolicy, pod *corev1.Pod, target v1alpha1.RecommendationTargetRef) bool {
+	ref := policy.Spec.TargetRef
+
+	if len(ref.Namespaces) > 0 && !slices.Contains(ref.Namespaces, pod.Namespace) {
+		return false
+	}
+	if slices.Contains(ref.ExcludeNamespaces, pod.Namespace) {
+		return false
+	}
+	if ref.Kind != "" && ref.Kind != target.Kind {
+		return false
+	}
+	if len(ref.Names) > 0 && !slices.Contains(ref.Names, target.Name) {
+		return false
+	}
+	if slices.Contains(ref.ExcludeNames, target.Name) {
+		return false
+	}
+	if ref.LabelSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(ref.LabelSelector)
+		if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+			return false
+		}
+	}
+	return true
+}